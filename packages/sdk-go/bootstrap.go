@@ -0,0 +1,63 @@
+package rollgate
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// copyBootstrap copies Config.Bootstrap and normalizes Config.BootstrapValues
+// to what the V2 flags endpoint would decode to (numbers become float64,
+// structs become maps), so typed accessors convert them the same way.
+func copyBootstrap(flags map[string]bool, values map[string]any) (map[string]bool, map[string]any, error) {
+	var copiedFlags map[string]bool
+	if flags != nil {
+		copiedFlags = make(map[string]bool, len(flags))
+		for k, v := range flags {
+			copiedFlags[k] = v
+		}
+	}
+
+	var copiedValues map[string]any
+	if values != nil {
+		copiedValues = make(map[string]any, len(values))
+		for k, v := range values {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid bootstrap value for flag %q: %w", k, err)
+			}
+			var decoded any
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				return nil, nil, fmt.Errorf("invalid bootstrap value for flag %q: %w", k, err)
+			}
+			copiedValues[k] = decoded
+		}
+	}
+	return copiedFlags, copiedValues, nil
+}
+
+// bootstrapping reports whether evaluations are served from the bootstrap
+// values: until the client is ready with flags from a successful fetch.
+// Caller must hold c.mu.
+func (c *Client) bootstrapping() bool {
+	return !c.ready || !c.fetched
+}
+
+// bootstrapFlag returns the bootstrap value of a boolean flag while the
+// client is bootstrapping. Caller must hold c.mu.
+func (c *Client) bootstrapFlag(flagKey string) (bool, bool) {
+	if !c.bootstrapping() {
+		return false, false
+	}
+	value, ok := c.bootstrap[flagKey]
+	return value, ok
+}
+
+// bootstrapValue returns the bootstrap value of a string, number or JSON
+// flag while the client is bootstrapping. Caller must hold c.mu.
+func (c *Client) bootstrapValue(flagKey string) (any, bool) {
+	if !c.bootstrapping() {
+		return nil, false
+	}
+	value, ok := c.bootstrapValues[flagKey]
+	return value, ok
+}
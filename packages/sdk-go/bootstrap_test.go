@@ -0,0 +1,97 @@
+package rollgate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_Bootstrap(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			<-release
+			fmt.Fprint(w, `{"flags":{"checkout":false,"banner":true}}`)
+		case "/api/v1/sdk/v2/flags":
+			fmt.Fprint(w, `{"flags":{"banner":{"type":"string","value":"Fetched","enabled":true}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:           "test-key",
+		BaseURL:          server.URL,
+		RefreshInterval:  time.Hour,
+		StartWaitTimeout: 5 * time.Second,
+		Bootstrap:        map[string]bool{"checkout": true},
+		BootstrapValues:  map[string]any{"banner": "Bootstrapped", "max-items": 25},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	t.Run("should serve bootstrap values before Init", func(t *testing.T) {
+		detail := client.IsEnabledDetail("checkout", false)
+		if !detail.Value || detail.Reason.Kind != ReasonBootstrap {
+			t.Errorf("expected true with a BOOTSTRAP reason, got %+v", detail)
+		}
+		if flags := client.GetAllFlags(); !flags["checkout"] {
+			t.Errorf("expected GetAllFlags to include the bootstrap values, got %v", flags)
+		}
+	})
+
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	t.Run("should serve typed bootstrap values while initializing", func(t *testing.T) {
+		if got := client.GetString("banner", ""); got != "Bootstrapped" {
+			t.Errorf("expected the bootstrap string, got %q", got)
+		}
+		if got := client.GetInt("max-items", 0); got != 25 {
+			t.Errorf("expected 25, got %d", got)
+		}
+		if detail := client.GetNumberDetail("banner", 1); detail.Value != 1 || detail.Reason.ErrorKind != ErrorWrongType {
+			t.Errorf("expected WRONG_TYPE for a string bootstrap value, got %+v", detail)
+		}
+	})
+
+	t.Run("should keep reporting unknown flags as not ready", func(t *testing.T) {
+		if detail := client.IsEnabledDetail("other", false); detail.Reason.ErrorKind != ErrorClientNotReady {
+			t.Errorf("expected CLIENT_NOT_READY, got %+v", detail.Reason)
+		}
+	})
+
+	close(release)
+	if err := client.WaitForInitialization(context.Background()); err != nil {
+		t.Fatalf("WaitForInitialization failed: %v", err)
+	}
+
+	t.Run("should replace bootstrap values once flags are fetched", func(t *testing.T) {
+		detail := client.IsEnabledDetail("checkout", true)
+		if detail.Value || detail.Reason.Kind != ReasonFallthrough {
+			t.Errorf("expected the fetched value, got %+v", detail)
+		}
+		if got := client.GetString("banner", ""); got != "Fetched" {
+			t.Errorf("expected the fetched string, got %q", got)
+		}
+		if got := client.GetInt("max-items", 3); got != 3 {
+			t.Errorf("expected the default for a flag only in the bootstrap, got %d", got)
+		}
+	})
+}
+
+func TestClient_BootstrapInvalid(t *testing.T) {
+	_, err := NewClient(Config{APIKey: "test-key", BootstrapValues: map[string]any{"bad": make(chan int)}})
+	if err == nil {
+		t.Error("expected an error for a value that cannot be encoded")
+	}
+}
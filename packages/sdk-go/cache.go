@@ -1,6 +1,11 @@
 package rollgate
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 )
@@ -9,13 +14,33 @@ import (
 type CacheEntry struct {
 	Flags     map[string]bool
 	Timestamp time.Time
+
+	// ETag is the validator of the response the flags came from, if any
+	ETag string
+
+	// UserID is the user the flags were evaluated for
+	UserID string
 }
 
 // CacheResult represents the result of a cache lookup.
 type CacheResult struct {
-	Flags map[string]bool
-	Stale bool
-	Found bool
+	Flags  map[string]bool
+	Stale  bool
+	Found  bool
+	ETag   string
+	UserID string
+}
+
+// persistedCacheVersion is the format version of the persisted cache file.
+const persistedCacheVersion = 1
+
+// persistedCache is the on-disk form of a CacheEntry.
+type persistedCache struct {
+	Version   int             `json:"version"`
+	Flags     map[string]bool `json:"flags"`
+	Timestamp time.Time       `json:"timestamp"`
+	ETag      string          `json:"etag,omitempty"`
+	UserID    string          `json:"userId,omitempty"`
 }
 
 // CacheStats holds cache statistics.
@@ -25,12 +50,17 @@ type CacheStats struct {
 	StaleHits int64
 }
 
-// FlagCache provides in-memory caching for feature flags.
+// FlagCache provides in-memory caching for feature flags, optionally
+// persisted to CacheConfig.PersistencePath.
 type FlagCache struct {
 	mu     sync.RWMutex
 	config CacheConfig
 	entry  *CacheEntry
 	stats  CacheStats
+
+	// persistMu serializes writes to the persisted file so an older entry
+	// never overwrites a newer one
+	persistMu sync.Mutex
 }
 
 // NewFlagCache creates a new FlagCache with the given config.
@@ -63,38 +93,152 @@ func (c *FlagCache) Get() CacheResult {
 	if age > c.config.TTL {
 		c.stats.StaleHits++
 		return CacheResult{
-			Flags: c.copyFlags(c.entry.Flags),
-			Stale: true,
-			Found: true,
+			Flags:  c.copyFlags(c.entry.Flags),
+			Stale:  true,
+			Found:  true,
+			ETag:   c.entry.ETag,
+			UserID: c.entry.UserID,
 		}
 	}
 
 	// Fresh cache hit
 	c.stats.Hits++
 	return CacheResult{
-		Flags: c.copyFlags(c.entry.Flags),
-		Stale: false,
-		Found: true,
+		Flags:  c.copyFlags(c.entry.Flags),
+		Stale:  false,
+		Found:  true,
+		ETag:   c.entry.ETag,
+		UserID: c.entry.UserID,
 	}
 }
 
-// Set stores flags in the cache.
+// GetForUser is Get for flags evaluated for userID: an entry saved for
+// another user counts as a miss, as its flags carry that user's targeting.
+func (c *FlagCache) GetForUser(userID string) CacheResult {
+	c.mu.Lock()
+	if c.entry != nil && c.entry.UserID != userID {
+		c.stats.Misses++
+		c.mu.Unlock()
+		return CacheResult{Found: false}
+	}
+	c.mu.Unlock()
+	return c.Get()
+}
+
+// Set stores flags in the cache. Errors writing the persisted file are
+// dropped; use Save to see them.
 func (c *FlagCache) Set(flags map[string]bool) {
+	_ = c.Save(CacheEntry{Flags: flags})
+}
+
+// Save stores an entry in the cache, timestamped now, and writes it to
+// PersistencePath when one is configured. The in-memory entry is updated
+// even if the write fails.
+func (c *FlagCache) Save(entry CacheEntry) error {
+	c.persistMu.Lock()
+	defer c.persistMu.Unlock()
+
+	entry.Flags = c.copyFlags(entry.Flags)
+	entry.Timestamp = time.Now()
+
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.entry = &entry
+	c.mu.Unlock()
 
-	c.entry = &CacheEntry{
-		Flags:     c.copyFlags(flags),
-		Timestamp: time.Now(),
+	if c.config.PersistencePath == "" {
+		return nil
 	}
+	return writeFileAtomic(c.config.PersistencePath, persistedCache{
+		Version:   persistedCacheVersion,
+		Flags:     entry.Flags,
+		Timestamp: entry.Timestamp,
+		ETag:      entry.ETag,
+		UserID:    entry.UserID,
+	})
 }
 
-// Clear removes all cached data.
-func (c *FlagCache) Clear() {
+// Load reads the entry persisted at PersistencePath into the cache, keeping
+// its original timestamp so TTL and StaleTTL still apply across restarts.
+// A missing file is not an error.
+func (c *FlagCache) Load() error {
+	if c.config.PersistencePath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.config.PersistencePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	var persisted persistedCache
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return fmt.Errorf("failed to parse cache file: %w", err)
+	}
+	if persisted.Version != persistedCacheVersion {
+		return fmt.Errorf("unsupported cache file version %d", persisted.Version)
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.entry = &CacheEntry{
+		Flags:     persisted.Flags,
+		Timestamp: persisted.Timestamp,
+		ETag:      persisted.ETag,
+		UserID:    persisted.UserID,
+	}
+	return nil
+}
+
+// writeFileAtomic writes v as JSON to a temporary file next to path and
+// renames it into place, so readers never see a partial file.
+func writeFileAtomic(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache file: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace cache file: %w", err)
+	}
+	return nil
+}
 
+// Clear removes all cached data, including the persisted file.
+func (c *FlagCache) Clear() {
+	c.persistMu.Lock()
+	defer c.persistMu.Unlock()
+
+	c.mu.Lock()
 	c.entry = nil
+	c.mu.Unlock()
+
+	if c.config.PersistencePath != "" {
+		_ = os.Remove(c.config.PersistencePath)
+	}
 }
 
 // HasFresh returns true if cache has fresh (non-stale) data.
@@ -121,6 +265,18 @@ func (c *FlagCache) HasAny() bool {
 	return time.Since(c.entry.Timestamp) <= c.config.StaleTTL
 }
 
+// HasAnyForUser is HasAny for flags evaluated for userID.
+func (c *FlagCache) HasAnyForUser(userID string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.entry == nil || c.entry.UserID != userID {
+		return false
+	}
+
+	return time.Since(c.entry.Timestamp) <= c.config.StaleTTL
+}
+
 // GetStats returns cache statistics.
 func (c *FlagCache) GetStats() CacheStats {
 	c.mu.RLock()
@@ -1,6 +1,10 @@
 package rollgate
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -176,3 +180,176 @@ func TestFlagCache_Stats(t *testing.T) {
 		}
 	})
 }
+
+func TestFlagCache_Persistence(t *testing.T) {
+	t.Run("should round-trip an entry through the file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "flags.json")
+		config := DefaultCacheConfig()
+		config.PersistencePath = path
+
+		if err := NewFlagCache(config).Save(CacheEntry{Flags: map[string]bool{"a": true}, ETag: `"v1"`, UserID: "user-1"}); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		cache := NewFlagCache(config)
+		if err := cache.Load(); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		result := cache.Get()
+		if !result.Found || !result.Flags["a"] || result.ETag != `"v1"` || result.UserID != "user-1" {
+			t.Errorf("expected the saved entry, got %+v", result)
+		}
+	})
+
+	t.Run("should replace the file without leaving temporary files", func(t *testing.T) {
+		dir := t.TempDir()
+		config := DefaultCacheConfig()
+		config.PersistencePath = filepath.Join(dir, "flags.json")
+		cache := NewFlagCache(config)
+
+		for i := 0; i < 3; i++ {
+			if err := cache.Save(CacheEntry{Flags: map[string]bool{"a": i%2 == 0}}); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 || entries[0].Name() != "flags.json" {
+			t.Errorf("expected only flags.json, got %v", entries)
+		}
+	})
+
+	t.Run("should keep the original timestamp", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "flags.json")
+		old := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339Nano)
+		os.WriteFile(path, []byte(`{"version":1,"flags":{"a":true},"timestamp":"`+old+`"}`), 0o600)
+
+		config := DefaultCacheConfig()
+		config.PersistencePath = path
+		cache := NewFlagCache(config)
+		if err := cache.Load(); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if cache.HasAny() {
+			t.Error("expected an entry older than StaleTTL to be unusable")
+		}
+	})
+
+	t.Run("should ignore a missing file and reject a corrupt one", func(t *testing.T) {
+		config := DefaultCacheConfig()
+		config.PersistencePath = filepath.Join(t.TempDir(), "flags.json")
+		cache := NewFlagCache(config)
+		if err := cache.Load(); err != nil {
+			t.Errorf("expected no error for a missing file, got %v", err)
+		}
+
+		os.WriteFile(config.PersistencePath, []byte(`{"flags":`), 0o600)
+		if err := cache.Load(); err == nil || !strings.Contains(err.Error(), "failed to parse cache file") {
+			t.Errorf("expected a parse error, got %v", err)
+		}
+		if cache.HasAny() {
+			t.Error("expected a corrupt file to leave the cache empty")
+		}
+	})
+
+	t.Run("should remove the file on Clear", func(t *testing.T) {
+		config := DefaultCacheConfig()
+		config.PersistencePath = filepath.Join(t.TempDir(), "flags.json")
+		cache := NewFlagCache(config)
+		cache.Set(map[string]bool{"a": true})
+
+		cache.Clear()
+		if _, err := os.Stat(config.PersistencePath); !os.IsNotExist(err) {
+			t.Errorf("expected the file to be removed, got %v", err)
+		}
+	})
+}
+
+func TestFlagCache_ForUser(t *testing.T) {
+	cache := NewFlagCache(CacheConfig{TTL: time.Minute, StaleTTL: time.Hour})
+	cache.Save(CacheEntry{Flags: map[string]bool{"beta": true}, UserID: "user-1"})
+
+	if result := cache.GetForUser("user-1"); !result.Found || !result.Flags["beta"] {
+		t.Errorf("expected the flags saved for user-1, got %+v", result)
+	}
+	if result := cache.GetForUser("user-2"); result.Found {
+		t.Errorf("expected a miss for user-2, got %+v", result)
+	}
+	if !cache.HasAnyForUser("user-1") || cache.HasAnyForUser("user-2") {
+		t.Error("expected HasAnyForUser to match the saved user only")
+	}
+	if stats := cache.GetStats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("expected 1 hit and 1 miss, got %+v", stats)
+	}
+}
+
+func TestClient_PersistentCache(t *testing.T) {
+	server := newMockServer(t, &mockFlag{Key: "beta", Enabled: true, Rollout: 100})
+	path := filepath.Join(t.TempDir(), "flags.json")
+	ctx := context.Background()
+
+	newClient := func(baseURL, userID string) *Client {
+		t.Helper()
+		config := server.config()
+		config.BaseURL = baseURL
+		config.User = &UserContext{ID: userID}
+		config.Cache = CacheConfig{PersistencePath: path}
+		client, err := NewClient(config)
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		t.Cleanup(client.Close)
+		return client
+	}
+
+	first := newClient(server.URL, "user-1")
+	if err := first.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	first.Close()
+
+	t.Run("should initialize offline from the persisted flags", func(t *testing.T) {
+		offline := httptestClosedURL(t)
+		client := newClient(offline, "user-1")
+		if err := client.Init(ctx); err != nil {
+			t.Fatalf("expected Init to succeed offline, got %v", err)
+		}
+		if !client.IsEnabled("beta", false) {
+			t.Error("expected the persisted value of beta")
+		}
+	})
+
+	t.Run("should not serve flags persisted for another user", func(t *testing.T) {
+		client := newClient(httptestClosedURL(t), "user-2")
+		if err := client.Init(ctx); err == nil {
+			t.Fatal("expected Init to fail offline without flags for user-2")
+		}
+		if client.IsEnabled("beta", false) {
+			t.Error("expected user-2 not to get the flags persisted for user-1")
+		}
+		client.useCachedFallback()
+		if client.IsEnabled("beta", false) {
+			t.Error("expected the cache fallback to skip flags persisted for user-1")
+		}
+	})
+
+	t.Run("should revalidate the persisted flags with their ETag", func(t *testing.T) {
+		client := newClient(server.URL, "user-1")
+		if err := client.Init(ctx); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		if server.notModifiedCount() != 1 {
+			t.Errorf("expected the first request to be answered with 304, got %d", server.notModifiedCount())
+		}
+		if !client.IsEnabled("beta", false) {
+			t.Error("expected beta to stay enabled after a 304")
+		}
+	})
+}
+
+// httptestClosedURL returns the URL of a server that is no longer listening.
+func httptestClosedURL(t *testing.T) string {
+	t.Helper()
+	server := newMockServer(t)
+	server.Close()
+	return server.URL
+}
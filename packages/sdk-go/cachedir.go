@@ -0,0 +1,73 @@
+package rollgate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// cacheDirName is the directory created under the OS cache directory.
+const cacheDirName = "rollgate"
+
+// DefaultCacheDir returns the default directory for on-disk SDK state:
+// $XDG_CACHE_HOME/rollgate (or ~/.cache/rollgate) on Linux,
+// %LOCALAPPDATA%\rollgate on Windows and ~/Library/Caches/rollgate on macOS.
+// It fails when the OS cache directory cannot be determined, e.g. in
+// containers without $HOME.
+func DefaultCacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("no user cache directory: %w", err)
+	}
+	return filepath.Join(base, cacheDirName), nil
+}
+
+// ResolveCacheDir returns the directory on-disk SDK state is written to:
+// override (Config.CacheDir) if set, otherwise DefaultCacheDir. The
+// directory is created and checked for writability, so read-only root
+// filesystems are detected up front.
+func ResolveCacheDir(override string) (string, error) {
+	dir := override
+	if dir == "" {
+		var err error
+		if dir, err = DefaultCacheDir(); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("cache directory %s: %w", dir, err)
+	}
+	probe, err := os.CreateTemp(dir, ".probe-*")
+	if err != nil {
+		return "", fmt.Errorf("cache directory %s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return dir, nil
+}
+
+// cacheFileName names the persisted flags file after a hash of the API
+// key, so clients for different environments sharing a directory do not
+// overwrite each other's flags.
+func cacheFileName(apiKey string) string {
+	if apiKey == "" {
+		return "flags.json"
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return "flags-" + hex.EncodeToString(sum[:8]) + ".json"
+}
+
+// resolvePersistencePath returns the persisted cache file in the resolved
+// cache directory, or "" with a warning when the directory is unusable, so
+// the cache stays in memory.
+func resolvePersistencePath(config Config) string {
+	dir, err := ResolveCacheDir(config.CacheDir)
+	if err != nil {
+		config.Logger.Warn("cache directory unusable, keeping the flag cache in memory", "error", err)
+		return ""
+	}
+	return filepath.Join(dir, cacheFileName(config.APIKey))
+}
@@ -0,0 +1,137 @@
+package rollgate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDefaultCacheDir_XDG(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG_CACHE_HOME is only used on Linux")
+	}
+	base := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", base)
+
+	dir, err := DefaultCacheDir()
+	if err != nil {
+		t.Fatalf("DefaultCacheDir failed: %v", err)
+	}
+	if want := filepath.Join(base, "rollgate"); dir != want {
+		t.Errorf("expected %s, got %s", want, dir)
+	}
+}
+
+func TestResolveCacheDir_Override(t *testing.T) {
+	override := filepath.Join(t.TempDir(), "nested", "cache")
+
+	dir, err := ResolveCacheDir(override)
+	if err != nil {
+		t.Fatalf("ResolveCacheDir failed: %v", err)
+	}
+	if dir != override {
+		t.Errorf("expected %s, got %s", override, dir)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("cache dir not created: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected the writability probe to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestResolveCacheDir_NotWritable(t *testing.T) {
+	// A regular file where the directory should be fails even as root,
+	// like a read-only root filesystem would
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ResolveCacheDir(filepath.Join(file, "cache")); err == nil {
+		t.Error("expected an error for an unusable cache directory")
+	}
+}
+
+func TestResolveCacheDir_NoHome(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("depends on Linux cache directory lookup")
+	}
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("HOME", "")
+
+	if _, err := ResolveCacheDir(""); err == nil {
+		t.Error("expected an error without HOME or XDG_CACHE_HOME")
+	}
+}
+
+func TestClient_CacheDir(t *testing.T) {
+	server := newMockServer(t, &mockFlag{Key: "beta", Enabled: true, Rollout: 100})
+
+	t.Run("should persist the cache under CacheDir", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "state")
+		config := server.config()
+		config.CacheDir = dir
+		client := newIntegrationClient(t, config)
+		if err := client.Refresh(context.Background()); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+		want := filepath.Join(dir, cacheFileName(config.APIKey))
+		if client.config.Cache.PersistencePath != want {
+			t.Errorf("expected the cache file %s, got %s", want, client.config.Cache.PersistencePath)
+		}
+		if _, err := os.Stat(want); err != nil {
+			t.Errorf("expected the flags to be persisted: %v", err)
+		}
+	})
+
+	t.Run("should use the OS cache directory with Persist", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("XDG_CACHE_HOME is only used on Linux")
+		}
+		base := t.TempDir()
+		t.Setenv("XDG_CACHE_HOME", base)
+		config := server.config()
+		config.Cache = CacheConfig{Persist: true}
+		client := newIntegrationClient(t, config)
+		if want := filepath.Join(base, "rollgate", cacheFileName(config.APIKey)); client.config.Cache.PersistencePath != want {
+			t.Errorf("expected the cache file %s, got %s", want, client.config.Cache.PersistencePath)
+		}
+	})
+
+	t.Run("should stay in memory with a warning when CacheDir is unusable", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "file")
+		if err := os.WriteFile(file, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		logger := &recordingLogger{}
+		config := server.config()
+		config.CacheDir = filepath.Join(file, "cache")
+		config.Logger = logger
+		client := newIntegrationClient(t, config)
+		if err := client.Refresh(context.Background()); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+		if client.config.Cache.PersistencePath != "" {
+			t.Errorf("expected no cache file, got %s", client.config.Cache.PersistencePath)
+		}
+		if n := logger.count("cache directory unusable, keeping the flag cache in memory"); n != 1 {
+			t.Errorf("expected one warning, got %d", n)
+		}
+		if !client.cache.HasAny() {
+			t.Error("expected the flags to be cached in memory")
+		}
+	})
+
+	t.Run("should name cache files after the API key", func(t *testing.T) {
+		if cacheFileName("key-a") == cacheFileName("key-b") {
+			t.Error("expected different files for different API keys")
+		}
+		if cacheFileName("") != "flags.json" {
+			t.Errorf("expected flags.json without an API key, got %s", cacheFileName(""))
+		}
+	})
+}
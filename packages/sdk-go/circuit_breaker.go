@@ -1,6 +1,7 @@
 package rollgate
 
 import (
+	"errors"
 	"sync"
 	"time"
 )
@@ -64,6 +65,10 @@ func (cb *CircuitBreaker) Execute(fn func() error) error {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
+	// Client-side throttling says nothing about the server's health
+	if errors.Is(err, ErrClientRateLimited) {
+		return err
+	}
 	if err != nil {
 		cb.recordFailure()
 		return err
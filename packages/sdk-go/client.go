@@ -3,12 +3,14 @@ package rollgate
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,6 +19,9 @@ type UserContext struct {
 	ID         string
 	Email      string
 	Attributes map[string]any
+	// Contexts are other kinds evaluated with the user, such as its
+	// organization or device, targeted as "org.plan" and the like
+	Contexts MultiContext
 }
 
 // Client is the Rollgate SDK client.
@@ -25,25 +30,89 @@ type Client struct {
 
 	config Config
 	client *http.Client
-
-	flags       map[string]bool
-	flagReasons map[string]EvaluationReason
-	user        *UserContext
-	lastETag    string
+	// transport is the SDK-built transport, closed with the client; nil
+	// when Config.HTTPTransport was injected by the caller
+	transport *http.Transport
+
+	flags        map[string]bool
+	flagReasons  map[string]EvaluationReason
+	flagMeta     map[string]FlagMetadata // Version and update time of each flag, when the server sends them
+	typedFlags   map[string]typedFlag
+	evaluator    *LocalEvaluator // Targeting rules; nil unless EvaluationModeLocal
+	defaults     map[string]any
+	policies     map[string]FlagPolicy
+	user         *UserContext
+	userVersion  uint64 // Bumped by Identify and Reset; fetches for an older user are discarded
+	lastETag     string
+	lastModified string
+	typedETag    string
+	hints        ServerHints // From the last flags response
 
 	circuitBreaker *CircuitBreaker
 	cache          *FlagCache
 	retryer        *Retryer
 	dedup          *RequestDeduplicator
+	tokens         *tokenSource
+	failover       *baseURLFailover // nil without Config.FallbackBaseURLs
+	// overrides holds flags evaluated remotely for WithUser/WithAttributes
+	overrides *overrideCache
 	metrics        *SDKMetrics
 	sseClient      *SSEClient
+	clock          *serverClock
+	timers         timeSource // Polling timer; the TestClock in test mode
+
+	rateLimits *rateLimitTracker // 429 responses of every API the client calls
 
 	eventCollector     *EventCollector
 	telemetryCollector *TelemetryCollector
-
-	stopPolling chan struct{}
-	ready       bool
-	streaming   bool
+	exposures          *exposureTracker
+	eventDedup         *recentKeys
+	evalContexts       *evaluationContexts // nil when events are disabled
+	usage              *flagUsage
+
+	// Config.Bootstrap and Config.BootstrapValues, served until flags are
+	// fetched; the values are normalized like V2 values
+	bootstrap       map[string]bool
+	bootstrapValues map[string]any
+
+	// localOverrides are the values of Config.OverridesFile, which win over
+	// every other source, as of the file's modification time and size
+	localOverrides   map[string]any
+	overridesModTime time.Time
+	overridesSize    int64
+
+	// ctx is the parent of background event and telemetry flushes and is
+	// cancelled by Close
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	stopPolling      chan struct{}
+	closeOnce        sync.Once
+	closed           bool
+	ready            bool
+	degraded         bool // Init hit StartupTimeout; cleared by the next successful fetch
+	fetched          bool // A fetch succeeded, or offline flags were loaded; ends bootstrapping
+	malformed        bool // The latest flags response could not be parsed
+	typedUnsupported bool // The server has no V2 flags endpoint
+	streaming        bool
+
+	// Background typed flag refreshes started by streaming updates
+	typedRefreshing     bool
+	typedRefreshPending bool
+
+	hooks []Hook // Copied on write by AddHook
+
+	// FreezeFlags deadline in Unix nanoseconds, 0 when not frozen
+	frozenUntil atomic.Int64
+	freezeTimer *time.Timer
+
+	// Polling in place of a failing stream. SSE callbacks take fallbackMu,
+	// not mu.
+	fallbackMu     sync.Mutex
+	streamFailures int           // Consecutive failed stream connections
+	fallbackStop   chan struct{} // Stops the fallback polling; nil when not polling
+
+	initAttempt *initAttempt // The latest Init call; nil before Init
 
 	// Circuit breaker callbacks
 	onCircuitOpenCallbacks  []func()
@@ -54,11 +123,18 @@ type Client struct {
 type flagsResponse struct {
 	Flags   map[string]bool              `json:"flags"`
 	Reasons map[string]EvaluationReason  `json:"reasons,omitempty"`
+	Metadata map[string]FlagMetadata    `json:"metadata,omitempty"`
+
+	SDKConfig *sdkConfigPayload `json:"sdkConfig,omitempty"`
 }
 
-// NewClient creates a new Rollgate client with the given config.
-func NewClient(config Config) (*Client, error) {
-	if config.APIKey == "" {
+// NewClient creates a new Rollgate client with the given config, adjusted
+// by opts in order.
+func NewClient(config Config, opts ...ClientOption) (*Client, error) {
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.APIKey == "" && config.TokenProvider == nil && !config.Offline {
 		return nil, ErrInvalidAPIKey
 	}
 
@@ -72,6 +148,21 @@ func NewClient(config Config) (*Client, error) {
 	if config.RefreshInterval == 0 {
 		config.RefreshInterval = 30 * time.Second
 	}
+	if config.MaxRefreshInterval == 0 {
+		config.MaxRefreshInterval = 5 * time.Minute
+	}
+	if config.MaxRefreshInterval < config.RefreshInterval {
+		config.MaxRefreshInterval = config.RefreshInterval
+	}
+	if config.RefreshBackoffMultiplier <= 1 {
+		config.RefreshBackoffMultiplier = 2
+	}
+	if config.StreamFallbackThreshold == 0 {
+		config.StreamFallbackThreshold = 3
+	}
+	if config.FailBackInterval <= 0 {
+		config.FailBackInterval = time.Minute
+	}
 	if config.Retry.MaxRetries == 0 {
 		config.Retry = DefaultRetryConfig()
 	}
@@ -79,7 +170,21 @@ func NewClient(config Config) (*Client, error) {
 		config.CircuitBreaker = DefaultCircuitBreakerConfig()
 	}
 	if config.Cache.TTL == 0 {
+		// Setting only PersistencePath or Persist gets the default cache
+		// with it
+		persistencePath, persist := config.Cache.PersistencePath, config.Cache.Persist
 		config.Cache = DefaultCacheConfig()
+		config.Cache.PersistencePath, config.Cache.Persist = persistencePath, persist
+	}
+	if config.RateLimit.Burst == 0 {
+		config.RateLimit.Burst = DefaultRateLimitConfig().Burst
+	}
+	switch config.EvaluationMode {
+	case "":
+		config.EvaluationMode = EvaluationModeRemote
+	case EvaluationModeRemote, EvaluationModeLocal:
+	default:
+		return nil, fmt.Errorf("unknown evaluation mode %q", config.EvaluationMode)
 	}
 
 	// Apply event collector defaults
@@ -92,37 +197,180 @@ func NewClient(config Config) (*Client, error) {
 		config.Telemetry = DefaultTelemetryConfig()
 	}
 
-	httpClient := &http.Client{Timeout: config.Timeout}
+	// Apply per-flag metrics defaults
+	if config.FlagMetrics.MaxFlags == 0 {
+		config.FlagMetrics.MaxFlags = DefaultFlagMetricsConfig().MaxFlags
+	}
+
+	// Apply exposure defaults
+	if config.Exposure.Interval == 0 {
+		config.Exposure.Interval = DefaultExposureConfig().Interval
+	}
 
+	// Apply event dedup defaults
+	if config.EventDedup.Window == 0 {
+		config.EventDedup.Window = DefaultEventDedupConfig().Window
+	}
+
+	if config.Offline {
+		applyOfflineConfig(&config)
+	}
+	if config.TestMode.Enabled {
+		applyTestModeConfig(&config)
+	}
+
+	bootstrap, bootstrapValues, err := copyBootstrap(config.Bootstrap, config.BootstrapValues)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy custom headers so the caller's map can change without racing
+	// in-flight requests
+	config.CustomHeaders = copyHeaders(config.CustomHeaders)
+
+	// Unused flags are only logged to a logger the caller configured. Every
+	// other message goes through the level filter, which stands in a no-op
+	// logger for a nil one
+	logUnused := config.UnusedFlagsLogInterval > 0 && config.Logger != nil
+	config.Logger = newLevelLogger(config.Logger, config.LogLevel)
+
+	if config.Cache.Enabled && config.Cache.PersistencePath == "" && (config.Cache.Persist || config.CacheDir != "") {
+		config.Cache.PersistencePath = resolvePersistencePath(config)
+	}
+
+	// Apply transport defaults
+	defaultTransport := DefaultTransportConfig()
+	if config.Transport.MaxIdleConns == 0 {
+		config.Transport.MaxIdleConns = defaultTransport.MaxIdleConns
+	}
+	if config.Transport.MaxIdleConnsPerHost == 0 {
+		config.Transport.MaxIdleConnsPerHost = defaultTransport.MaxIdleConnsPerHost
+	}
+	if config.Transport.IdleConnTimeout == 0 {
+		config.Transport.IdleConnTimeout = defaultTransport.IdleConnTimeout
+	}
+	if config.Transport.KeepAlive == 0 {
+		config.Transport.KeepAlive = defaultTransport.KeepAlive
+	}
+	if config.Transport.ForceHTTP2 == nil {
+		config.Transport.ForceHTTP2 = defaultTransport.ForceHTTP2
+	}
+
+	metrics := NewSDKMetrics()
+	if config.FlagMetrics.Enabled {
+		metrics.EnableFlagMetrics(config.FlagMetrics)
+	}
+
+	// All outbound API calls share one client so they also share the
+	// connection pool and the rate limiter
+	transport := config.HTTPTransport
+	var ownTransport *http.Transport
+	if transport == nil {
+		ownTransport = newTransport(config.Transport)
+		transport = ownTransport
+	}
+	httpClient := &http.Client{Timeout: config.Timeout, Transport: transport}
+	if limiter := NewRateLimiter(config.RateLimit); limiter != nil {
+		httpClient.Transport = &rateLimitedTransport{
+			base:    transport,
+			limiter: limiter,
+			metrics: metrics,
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 	c := &Client{
 		config:         config,
 		client:         httpClient,
+		transport:      ownTransport,
 		flags:          make(map[string]bool),
 		flagReasons:    make(map[string]EvaluationReason),
 		circuitBreaker: NewCircuitBreaker(config.CircuitBreaker),
 		cache:          NewFlagCache(config.Cache),
 		retryer:        NewRetryer(config.Retry),
+		timers:         timeSourceFor(config.TestMode),
 		dedup:          NewRequestDeduplicator(),
-		metrics:        NewSDKMetrics(),
-		eventCollector: NewEventCollector(
-			config.BaseURL+"/api/v1/sdk/events",
+		tokens:         newTokenSource(config.APIKey, config.TokenProvider),
+		overrides:      newOverrideCache(),
+		metrics:        metrics,
+		clock:          newServerClock(),
+		rateLimits:     newRateLimitTracker(),
+		ctx:            ctx,
+		cancel:         cancel,
+		stopPolling:    make(chan struct{}),
+		usage:          newFlagUsage(),
+		user:           config.User,
+
+		bootstrap:       bootstrap,
+		bootstrapValues: bootstrapValues,
+	}
+
+	c.retryer.timers = c.timers
+	if config.EvaluationMode == EvaluationModeLocal {
+		c.evaluator = NewLocalEvaluator()
+	}
+	if config.Cache.Enabled {
+		if err := c.cache.Load(); err != nil {
+			config.Logger.Warn("failed to load persisted flag cache", "path", config.Cache.PersistencePath, "error", err)
+		}
+	}
+	if len(config.FallbackBaseURLs) > 0 && !config.Offline {
+		c.failover = newBaseURLFailover(config.BaseURL, config.FallbackBaseURLs, config.FailBackInterval)
+	}
+	if config.Exposure.Enabled {
+		c.exposures = newExposureTracker(config.Exposure.Interval)
+	}
+	if config.EventDedup.Enabled {
+		c.eventDedup = newRecentKeys(config.EventDedup.Window)
+	}
+
+	if logUnused {
+		go c.startUnusedFlagsLog(config.UnusedFlagsLogInterval)
+	}
+
+	// Disabled collectors are not built at all, so they never start
+	// goroutines or send requests
+	if config.Events.Enabled {
+		c.eventCollector = NewEventCollector(
+			resolveEndpoint(config.BaseURL, config.EventsURL, "/api/v1/sdk/events"),
 			config.APIKey,
 			config.Events,
 			httpClient,
-		),
-		telemetryCollector: NewTelemetryCollector(
-			config.BaseURL+"/api/v1/sdk/telemetry",
+		)
+		c.eventCollector.SetRequestObserver(config.RequestObserver)
+		c.eventCollector.setClock(c.clock)
+		c.eventCollector.setRateLimits(c.rateLimits)
+		c.eventCollector.setHeaders(config.CustomHeaders)
+		c.eventCollector.setContext(c.ctx)
+		c.eventCollector.setTokenSource(c.tokens)
+		c.eventCollector.setMetrics(metrics)
+		if config.TestMode.Enabled {
+			c.eventCollector.setTimeSource(c.timers)
+		}
+		c.evalContexts = newEvaluationContexts(maxEvaluationContexts)
+	}
+	if config.Telemetry.Enabled {
+		c.telemetryCollector = NewTelemetryCollector(
+			resolveEndpoint(config.BaseURL, config.TelemetryURL, "/api/v1/sdk/telemetry"),
 			config.APIKey,
 			config.Telemetry,
 			httpClient,
-		),
-		stopPolling: make(chan struct{}),
+		)
+		c.telemetryCollector.SetRequestObserver(config.RequestObserver)
+		c.telemetryCollector.setMetrics(metrics)
+		c.telemetryCollector.setHeaders(config.CustomHeaders)
+		c.telemetryCollector.setContext(c.ctx)
+		c.telemetryCollector.setTokenSource(c.tokens)
+		c.telemetryCollector.setTimeSource(c.timers)
+		c.telemetryCollector.setRateLimits(c.rateLimits)
 	}
 
 	// Set up circuit breaker state change tracking
 	c.circuitBreaker.OnStateChange(func(from, to CircuitState) {
 		c.metrics.RecordCircuitStateChange(to)
-		if c.config.Logger != nil {
+		if to == CircuitStateOpen {
+			c.config.Logger.Warn("circuit breaker state changed", "from", from, "to", to)
+		} else {
 			c.config.Logger.Info("circuit breaker state changed", "from", from, "to", to)
 		}
 		// Invoke user-registered callbacks
@@ -145,6 +393,8 @@ func NewClient(config Config) (*Client, error) {
 
 // Init initializes the client. This is the primary initialization method.
 // It fetches initial flags and starts background polling or streaming.
+// With Config.StartWaitTimeout it returns at once and initializes in the
+// background; see WaitForInitialization.
 func (c *Client) Init(ctx context.Context) error {
 	return c.Initialize(ctx)
 }
@@ -152,32 +402,63 @@ func (c *Client) Init(ctx context.Context) error {
 // Initialize fetches the initial flags and starts background polling.
 // Deprecated: Use Init instead.
 func (c *Client) Initialize(ctx context.Context) error {
-	// Try to load from cache first
+	attempt := &initAttempt{done: make(chan struct{})}
+	c.mu.Lock()
+	c.initAttempt = attempt
+	c.mu.Unlock()
+
+	if c.config.StartWaitTimeout <= 0 {
+		attempt.finish(c.initialize(ctx))
+		return attempt.err
+	}
+
+	// Callers usually cancel ctx once Init returns, so initialize with the
+	// client's context, which Close cancels
+	go func() {
+		attempt.finish(c.initialize(c.ctx))
+		if attempt.err != nil {
+			c.config.Logger.Warn("background initialization failed", "error", attempt.err)
+		}
+	}()
+	return nil
+}
+
+func (c *Client) initialize(ctx context.Context) error {
+	if c.config.OverridesFile != "" {
+		if err := c.loadOverridesFile(); err != nil {
+			return fmt.Errorf("failed to initialize: %w", err)
+		}
+	}
+	if c.config.Offline {
+		return c.initializeOffline()
+	}
+
+	// Try to load from cache first, if it holds flags for this user
 	if c.config.Cache.Enabled {
-		cached := c.cache.Get()
+		cached := c.cache.GetForUser(c.currentUserID())
 		if cached.Found {
 			c.mu.Lock()
 			c.flags = cached.Flags
+			// Flags persisted for this user can be revalidated instead of
+			// downloaded again
+			if c.lastETag == "" && cached.ETag != "" {
+				c.lastETag = cached.ETag
+			}
 			c.mu.Unlock()
 			c.metrics.RecordCacheHit(cached.Stale)
+			c.checkConsistency(updateSourceCache, false)
 		}
 	}
 
-	// If streaming is enabled, set up SSE
-	if c.config.EnableStreaming {
+	// If streaming is enabled, set up SSE. Stream events carry values
+	// evaluated for one user, so local evaluation keeps polling the rules.
+	if c.config.EnableStreaming && c.evaluator == nil {
 		return c.initializeWithSSE(ctx)
 	}
 
 	// Fetch fresh flags via HTTP
-	if err := c.Refresh(ctx); err != nil {
-		// If we have cached data, we can continue
-		if c.cache.HasAny() {
-			if c.config.Logger != nil {
-				c.config.Logger.Warn("failed to fetch fresh flags, using cache", "error", err)
-			}
-		} else {
-			return fmt.Errorf("failed to initialize: %w", err)
-		}
+	if err := c.fetchInitialFlags(ctx); err != nil {
+		return err
 	}
 
 	c.mu.Lock()
@@ -185,78 +466,186 @@ func (c *Client) Initialize(ctx context.Context) error {
 	c.mu.Unlock()
 
 	// Start event collector and telemetry
-	c.eventCollector.Start()
-	c.telemetryCollector.Start()
+	if c.eventCollector != nil {
+		c.eventCollector.Start()
+	}
+	if c.telemetryCollector != nil {
+		c.telemetryCollector.Start()
+	}
 
 	// Start background polling if interval > 0
 	if c.config.RefreshInterval > 0 {
-		go c.startPolling()
+		go c.startPolling(nil)
 	}
 
 	return nil
 }
 
+// fetchInitialFlags fetches the first flags for Init. It gives up after
+// StartupTimeout and leaves the client degraded, serving cached flags or
+// defaults while a background refresh keeps trying. Other failures are
+// fatal unless cached flags are available.
+func (c *Client) fetchInitialFlags(ctx context.Context) error {
+	fetchCtx := ctx
+	if c.config.StartupTimeout > 0 {
+		var cancel context.CancelFunc
+		fetchCtx, cancel = context.WithTimeout(ctx, c.config.StartupTimeout)
+		defer cancel()
+	}
+
+	err := c.Refresh(fetchCtx)
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() == nil && errors.Is(fetchCtx.Err(), context.DeadlineExceeded):
+		c.config.Logger.Warn("startup timeout elapsed before flags were fetched, starting degraded",
+			"timeout", c.config.StartupTimeout, "error", err)
+		c.mu.Lock()
+		c.degraded = true
+		c.mu.Unlock()
+		go c.refreshInBackground()
+		return nil
+	case c.cache.HasAnyForUser(c.currentUserID()):
+		// If we have cached data, we can continue
+		c.config.Logger.Warn("failed to fetch fresh flags, using cache", "error", err)
+		return nil
+	default:
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+}
+
+// refreshInBackground retries the initial fetch after a startup timeout,
+// so a degraded client does not wait a full polling interval for flags.
+// Close cancels it.
+func (c *Client) refreshInBackground() {
+	ctx, cancel := context.WithTimeout(c.ctx, c.config.Timeout)
+	defer cancel()
+	if err := c.Refresh(ctx); err != nil && c.ctx.Err() == nil {
+		c.config.Logger.Warn("background refresh after startup timeout failed", "error", err)
+	}
+}
+
 func (c *Client) initializeWithSSE(ctx context.Context) error {
 	// First, fetch flags via HTTP to have them immediately available
-	if err := c.Refresh(ctx); err != nil {
-		// If we have cached data, we can continue
-		if !c.cache.HasAny() {
-			return fmt.Errorf("failed to initialize: %w", err)
-		}
-		if c.config.Logger != nil {
-			c.config.Logger.Warn("failed to fetch fresh flags, using cache", "error", err)
-		}
+	if err := c.fetchInitialFlags(ctx); err != nil {
+		return err
 	}
 
 	c.mu.Lock()
 	c.ready = true
 	c.mu.Unlock()
 
+	// The API may ask clients to poll, e.g. while its stream servers are
+	// overloaded. The choice is made once, at initialization.
+	if c.streamingDisabledByServer() {
+		c.config.Logger.Info("server hint disables streaming, polling instead", "interval", c.pollInterval())
+		if c.config.RefreshInterval > 0 {
+			go c.startPolling(nil)
+		}
+		return nil
+	}
+
 	// Now set up SSE for real-time updates
 	sseConfig := c.config
 	if c.config.SSEURL != "" {
 		sseConfig.BaseURL = c.config.SSEURL
 	}
 
-	c.sseClient = NewSSEClient(sseConfig)
-	c.sseClient.SetUser(c.user)
+	sseClient := NewSSEClient(sseConfig)
 
 	// Set up flag update handler
-	c.sseClient.OnFlags(func(flags map[string]bool) {
-		c.mu.Lock()
-		// Merge flags (for single flag updates) or replace (for full updates)
-		if len(flags) == 1 {
-			for k, v := range flags {
-				c.flags[k] = v
-			}
-		} else {
-			c.flags = flags
-			// Update cache
-			if c.config.Cache.Enabled {
-				c.cache.Set(flags)
-			}
-		}
-		c.mu.Unlock()
-	})
+	sseClient.OnUpdate(c.applySSEUpdate)
+	sseClient.SetMetrics(c.metrics)
+	sseClient.setTokenSource(c.tokens)
 
-	c.sseClient.OnError(func(err error) {
-		if c.config.Logger != nil {
-			c.config.Logger.Warn("SSE error", "error", err)
-		}
+	sseClient.OnError(func(err error) {
+		c.config.Logger.Warn("SSE error", "error", err)
+		c.streamFailed()
 	})
 
-	c.sseClient.OnConnect(func() {
-		if c.config.Logger != nil {
-			c.config.Logger.Info("SSE connected")
-		}
+	sseClient.OnConnect(func() {
+		c.config.Logger.Info("SSE connected")
+		c.streamConnected()
 	})
 
+	// Publish the stream under the lock so Close either sees it or the
+	// stream is never started, and concurrent Init calls share one stream
 	c.mu.Lock()
+	if c.closed || c.sseClient != nil {
+		c.mu.Unlock()
+		return nil
+	}
+	sseClient.SetUser(c.user)
+	c.sseClient = sseClient
 	c.streaming = true
 	c.mu.Unlock()
 
-	// Start SSE in background for updates (non-blocking)
-	return c.sseClient.Connect(ctx)
+	// Start SSE in background for updates (non-blocking). The stream lives
+	// as long as the client, not the Init context, which callers usually
+	// cancel once Init returns; Close stops it
+	return sseClient.Connect(c.ctx)
+}
+
+// applySSEUpdate stores flags received over SSE together with their
+// reasons, so IsEnabledDetail never pairs a new value with a stale reason.
+// Flags updated without a reason lose their stored reason, and likewise
+// their version and update time. Typed values
+// from V2 events are stored as well; V1 events refetch them.
+func (c *Client) applySSEUpdate(update SSEFlagsUpdate) {
+	c.mu.Lock()
+	if c.heldByFreeze() {
+		c.mu.Unlock()
+		return
+	}
+	if update.Full {
+		c.flags = update.Flags
+		c.flagReasons = make(map[string]EvaluationReason, len(update.Reasons))
+		for k, reason := range update.Reasons {
+			c.flagReasons[k] = reason
+		}
+		c.flagMeta = make(map[string]FlagMetadata, len(update.Metadata))
+		for k, meta := range update.Metadata {
+			c.flagMeta[k] = meta
+		}
+	} else {
+		if c.flagMeta == nil {
+			c.flagMeta = make(map[string]FlagMetadata, len(update.Metadata))
+		}
+		for k, v := range update.Flags {
+			c.flags[k] = v
+			if reason, ok := update.Reasons[k]; ok {
+				c.flagReasons[k] = reason
+			} else {
+				delete(c.flagReasons, k)
+			}
+			if meta, ok := update.Metadata[k]; ok {
+				c.flagMeta[k] = meta
+			} else {
+				delete(c.flagMeta, k)
+			}
+		}
+	}
+	// V2 events carry the typed values; V1 events leave them to a refetch
+	typed := update.typed != nil && (update.Full || c.typedFlags != nil)
+	if typed {
+		if update.Full {
+			c.typedFlags = make(map[string]typedFlag, len(update.typed))
+		}
+		for k, flag := range update.typed {
+			c.typedFlags[k] = flag
+		}
+	}
+	c.mu.Unlock()
+	c.overrides.clear()
+	c.checkConsistency(updateSourceStream, typed)
+
+	// Update cache
+	if update.Full && c.config.Cache.Enabled {
+		c.saveCache(update.Flags, "")
+	}
+	if !typed {
+		c.refreshTypedFlags()
+	}
 }
 
 // evalOptions holds per-evaluation override options.
@@ -291,44 +680,180 @@ func (c *Client) IsEnabled(flagKey string, defaultValue bool, opts ...EvalOption
 // IsEnabledDetail returns the flag value along with the evaluation reason.
 // Accepts optional EvalOption to override user context for this evaluation.
 func (c *Client) IsEnabledDetail(flagKey string, defaultValue bool, opts ...EvalOption) BoolEvaluationDetail {
+	o := &evalOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return c.evaluateBool(flagKey, defaultValue, o)
+}
+
+// evaluateBool evaluates a boolean flag with hooks for the resolved
+// evaluation options.
+func (c *Client) evaluateBool(flagKey string, defaultValue bool, o *evalOptions) BoolEvaluationDetail {
+	return withHooks(c, flagKey, FlagTypeBoolean, defaultValue, o, func() BoolEvaluationDetail {
+		detail := c.isEnabledDetail(flagKey, defaultValue, o)
+		detail.Reason.Frozen = c.frozen()
+		detail.Reason.RateLimited = c.rateLimited()
+		return detail
+	})
+}
+
+func (c *Client) isEnabledDetail(flagKey string, defaultValue bool, o *evalOptions) BoolEvaluationDetail {
 	start := time.Now()
 	defer func() {
 		c.metrics.RecordEvaluation(time.Since(start).Nanoseconds())
 	}()
+	if c.metrics.RecordFlagEvaluation(flagKey) {
+		c.config.Logger.Warn("per-flag metrics label limit reached, further flags are counted as "+OtherFlagLabel,
+			"flag", flagKey, "maxFlags", c.config.FlagMetrics.MaxFlags)
+	}
+
+	// A remote override needs the override user's flags, which may take a
+	// request; fetch them before taking the lock.
+	c.mu.RLock()
+	override := c.overrideUser(o)
+	remoteOverride := override != nil && c.evaluator == nil && c.ready
+	c.mu.RUnlock()
+	var overrideEntry *overrideEntry
+	var overrideErr error
+	if remoteOverride {
+		overrideEntry, overrideErr = c.overrideFlags(override)
+	}
 
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
+	c.markEvaluated(flagKey)
+	defaultValue = c.boolDefault(flagKey, defaultValue)
+
+	// Local overrides win over every other source, for any user
+	if value, ok := c.overriddenFlag(flagKey); ok {
+		return BoolEvaluationDetail{Value: value, Reason: overrideReason()}
+	}
+
+	// Until flags are fetched, bootstrap values stand in for the current
+	// user's flags
+	if override == nil {
+		if value, ok := c.bootstrapFlag(flagKey); ok {
+			return BoolEvaluationDetail{Value: value, Reason: BootstrapReason()}
+		}
+	}
+
 	// Check if client is ready
 	if !c.ready {
+		kind := c.notReadyKind()
+		c.recordDefault(flagKey, kind)
 		return BoolEvaluationDetail{
-			Value:  defaultValue,
-			Reason: ErrorReason(ErrorClientNotReady),
+			Value:  c.policyValue(flagKey, defaultValue),
+			Reason: ErrorReason(kind),
 		}
 	}
 
-	// Check if flag exists
-	value, ok := c.flags[flagKey]
-	if !ok {
-		return BoolEvaluationDetail{
-			Value:  defaultValue,
-			Reason: UnknownReason(),
+	var detail BoolEvaluationDetail
+	userID := ""
+	switch {
+	case override != nil:
+		// Overrides run a real evaluation for the override user: the rules
+		// locally, or the user's own flag set remotely
+		userID = override.ID
+		if c.evaluator != nil {
+			detail = c.evaluator.EvaluateDetail(flagKey, override, defaultValue)
+		} else {
+			detail = overrideDetail(flagKey, defaultValue, overrideEntry, overrideErr)
+		}
+		// Like the current user's flags, an unknown flag is counted as a
+		// default rather than an evaluation
+		if detail.Reason.Kind == ReasonUnknown {
+			c.recordDefault(flagKey, ErrorFlagNotFound)
+			detail.Value = c.policyValue(flagKey, detail.Value)
+			return detail
+		}
+		if detail.Reason.Kind == ReasonError {
+			if c.telemetryCollector != nil {
+				c.telemetryCollector.RecordEvaluationDetail(flagKey, detail.Value, detail.Reason, "")
+			}
+			return detail
 		}
-	}
 
-	// Record telemetry for this evaluation
-	c.telemetryCollector.RecordEvaluation(flagKey, value)
+	default:
+		// Check if flag exists. After an unreadable response it may exist
+		// but be missing from the last known flags.
+		value, ok := c.flags[flagKey]
+		if !ok {
+			reason := UnknownReason()
+			if c.malformed {
+				reason = ErrorReason(ErrorMalformedResponse)
+			}
+			c.recordDefault(flagKey, defaultCause(reason))
+			return BoolEvaluationDetail{
+				Value:  c.policyValue(flagKey, defaultValue),
+				Reason: reason,
+			}
+		}
 
-	// Use stored reason from server, or FALLTHROUGH as default
-	if storedReason, ok := c.flagReasons[flagKey]; ok {
-		return BoolEvaluationDetail{
+		// Use stored reason from server, or FALLTHROUGH as default. Local
+		// evaluation runs the rules against the current user on every call.
+		detail = BoolEvaluationDetail{
 			Value:  value,
-			Reason: storedReason,
+			Reason: FallthroughReason(value),
+		}
+		if storedReason, ok := c.flagReasons[flagKey]; ok {
+			detail.Reason = storedReason
+			detail.VariationID = storedReason.VariationID
 		}
+		if c.evaluator != nil {
+			detail = c.evaluator.EvaluateDetail(flagKey, c.user, defaultValue)
+		}
+		detail = withMetadata(detail, c.flagMeta[flagKey])
 	}
-	return BoolEvaluationDetail{
-		Value:  value,
-		Reason: FallthroughReason(value),
+
+	// Record telemetry for this evaluation
+	if c.telemetryCollector != nil {
+		c.telemetryCollector.RecordEvaluationDetail(flagKey, detail.Value, detail.Reason, detail.VariationID)
+	}
+
+	c.recordServed(flagKey, detail.Value, detail.VariationID, detail.Reason, userID)
+	return detail
+}
+
+// recordDefault counts an evaluation that returned the default because no
+// value was available in telemetry.
+func (c *Client) recordDefault(flagKey string, cause EvaluationErrorKind) {
+	if c.telemetryCollector != nil {
+		c.telemetryCollector.RecordDefault(flagKey, cause)
+	}
+}
+
+// defaultCause returns why an evaluation with reason had no value: the
+// response was unreadable, or the flag is unknown.
+func defaultCause(reason EvaluationReason) EvaluationErrorKind {
+	if reason.ErrorKind != "" {
+		return reason.ErrorKind
+	}
+	return ErrorFlagNotFound
+}
+
+// recordServed remembers the evaluation for events tracked later on the
+// flag, and emits an exposure event if exposure tracking is enabled and
+// this flag/user/variation was not reported within the exposure interval.
+// Caller must hold c.mu.
+func (c *Client) recordServed(flagKey string, value any, variationID string, reason EvaluationReason, userID string) {
+	if c.evalContexts == nil && c.exposures == nil {
+		return
+	}
+	if userID == "" && c.user != nil {
+		userID = c.user.ID
+	}
+	if userID == "" {
+		return
+	}
+
+	event := exposureEvent(flagKey, userID, value, variationID, reason)
+	if c.evalContexts != nil {
+		c.evalContexts.record(flagKey, userID, evaluationContext{variationID: event.VariationID, reason: reason})
+	}
+	if c.exposures != nil && c.eventCollector != nil && c.exposures.shouldEmit(flagKey, userID, event.VariationID) {
+		c.eventCollector.Track(event)
 	}
 }
 
@@ -346,46 +871,78 @@ func (c *Client) GetAllFlags() map[string]bool {
 	for k, v := range c.flags {
 		result[k] = v
 	}
+	if c.bootstrapping() {
+		for k, v := range c.bootstrap {
+			result[k] = v
+		}
+	}
+	for k := range c.localOverrides {
+		if v, ok := c.overriddenFlag(k); ok {
+			result[k] = v
+		}
+	}
 	return result
 }
 
-// GetString returns a string flag value, or defaultValue if not found.
-// Note: Currently the API only supports boolean flags. String flags will be
-// added in a future version. For now, this always returns the default value.
+// GetString returns a string flag value, or defaultValue if the flag is not
+// found, not served or not a string flag.
 func (c *Client) GetString(flagKey string, defaultValue string) string {
-	// TODO: Implement when API supports typed flags
-	return defaultValue
+	return c.GetStringDetail(flagKey, defaultValue).Value
 }
 
-// GetNumber returns a numeric flag value, or defaultValue if not found.
-// Note: Currently the API only supports boolean flags. Number flags will be
-// added in a future version. For now, this always returns the default value.
+// GetNumber returns a numeric flag value, or defaultValue if the flag is not
+// found, not served or not a number flag.
 func (c *Client) GetNumber(flagKey string, defaultValue float64) float64 {
-	// TODO: Implement when API supports typed flags
-	return defaultValue
+	return c.GetNumberDetail(flagKey, defaultValue).Value
 }
 
-// GetJSON returns a JSON flag value, or defaultValue if not found.
-// Note: Currently the API only supports boolean flags. JSON flags will be
-// added in a future version. For now, this always returns the default value.
+// GetInt returns a numeric flag value as an int, or defaultValue if the
+// flag is not found, not served, not a number flag or not a whole number.
+func (c *Client) GetInt(flagKey string, defaultValue int) int {
+	return c.GetIntDetail(flagKey, defaultValue).Value
+}
+
+// GetDuration returns a duration flag value, or defaultValue if the flag is
+// not found, not served or not a valid duration. See GetDurationDetail for
+// the accepted values.
+func (c *Client) GetDuration(flagKey string, defaultValue time.Duration) time.Duration {
+	return c.GetDurationDetail(flagKey, defaultValue).Value
+}
+
+// GetJSON returns a JSON flag value, or defaultValue if the flag is not
+// found, not served or not a JSON flag.
 func (c *Client) GetJSON(flagKey string, defaultValue interface{}) interface{} {
-	// TODO: Implement when API supports typed flags
-	return defaultValue
+	return c.GetJSONDetail(flagKey, defaultValue).Value
 }
 
-// Identify sets the user context for flag targeting.
+// Identify sets the user context for flag targeting. With local
+// evaluation it only re-evaluates the stored rules and sends no request;
+// offline it only changes the user.
 func (c *Client) Identify(ctx context.Context, user *UserContext) error {
+	if c.config.Offline {
+		c.setOfflineUser(user)
+		return nil
+	}
+	if c.evaluator != nil {
+		c.setLocalUser(user)
+		return nil
+	}
+
 	c.mu.Lock()
 	c.user = user
+	c.userVersion++
+	c.clearValidators()
+	c.clearFreeze(0)
+	if c.sseClient != nil {
+		c.sseClient.SetUser(user)
+	}
 	c.mu.Unlock()
 
 	// Send identify request to server with user attributes
 	if user != nil && user.ID != "" {
 		if err := c.sendIdentify(ctx, user); err != nil {
 			// Log but don't fail - refresh will still work with user_id param
-			if c.config.Logger != nil {
-				c.config.Logger.Warn("failed to send identify", "error", err)
-			}
+			c.config.Logger.Warn("failed to send identify", "error", err)
 		}
 	}
 
@@ -393,15 +950,23 @@ func (c *Client) Identify(ctx context.Context, user *UserContext) error {
 }
 
 // sendIdentify sends user context to the server for server-side evaluation.
-func (c *Client) sendIdentify(ctx context.Context, user *UserContext) error {
-	u := c.config.BaseURL + "/api/v1/sdk/identify"
+func (c *Client) sendIdentify(ctx context.Context, user *UserContext) (err error) {
+	u := c.baseURL(ctx) + "/api/v1/sdk/identify"
+
+	start := time.Now()
+	var statusCode int
+	defer func() {
+		c.config.RequestObserver.notify(RequestInfo{
+			Method:     http.MethodPost,
+			Endpoint:   u,
+			Duration:   time.Since(start),
+			StatusCode: statusCode,
+			Error:      err,
+		})
+	}()
 
 	body := map[string]interface{}{
-		"user": map[string]interface{}{
-			"id":         user.ID,
-			"email":      user.Email,
-			"attributes": user.Attributes,
-		},
+		"user": identifyUser(user),
 	}
 
 	jsonBody, err := json.Marshal(body)
@@ -416,15 +981,25 @@ func (c *Client) sendIdentify(ctx context.Context, user *UserContext) error {
 		return err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	setRequestHeaders(req, c.config.CustomHeaders)
+	if err := c.tokens.authorize(req); err != nil {
+		return err
+	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.client.Do(req)
 	if err != nil {
+		if errors.Is(err, ErrClientRateLimited) {
+			return ErrClientRateLimited
+		}
 		return err
 	}
 	defer resp.Body.Close()
 
+	statusCode = resp.StatusCode
+	if resp.StatusCode == http.StatusUnauthorized {
+		c.tokens.rejected(req)
+	}
 	if resp.StatusCode >= 400 {
 		return fmt.Errorf("identify failed with status %d", resp.StatusCode)
 	}
@@ -449,9 +1024,24 @@ func (r *bytesReader) Read(p []byte) (n int, err error) {
 
 // Reset clears the user context.
 func (c *Client) Reset(ctx context.Context) error {
+	if c.config.Offline {
+		c.setOfflineUser(nil)
+		return nil
+	}
+	if c.evaluator != nil {
+		c.setLocalUser(nil)
+		return nil
+	}
+
 	c.mu.Lock()
 	oldUser := c.user
 	c.user = nil
+	c.userVersion++
+	c.clearValidators()
+	c.clearFreeze(0)
+	if c.sseClient != nil {
+		c.sseClient.SetUser(nil)
+	}
 	c.mu.Unlock()
 
 	// Clear user session on server
@@ -462,9 +1052,54 @@ func (c *Client) Reset(ctx context.Context) error {
 	return c.Refresh(ctx)
 }
 
-// Refresh forces a refresh of flag values from the server.
+// clearValidators drops the stored ETags and Last-Modified, and the typed
+// values fetched for the previous user. Flags are evaluated per user, so
+// validators from another user (or the same user with other attributes)
+// could answer 304 and keep the wrong flags.
+// Caller must hold c.mu.
+func (c *Client) clearValidators() {
+	c.lastETag = ""
+	c.lastModified = ""
+	c.typedETag = ""
+	c.typedFlags = nil
+}
+
+// currentUserID is userID for callers not holding c.mu.
+func (c *Client) currentUserID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.userID()
+}
+
+// userID returns the current user's ID, or "" if none.
+// Caller must hold c.mu.
+func (c *Client) userID() string {
+	if c.user == nil {
+		return ""
+	}
+	return c.user.ID
+}
+
+// Refresh forces a refresh of flag values from the server. Offline it
+// reloads FlagsFile, if any. OverridesFile, if any, is reloaded first if it
+// changed.
 func (c *Client) Refresh(ctx context.Context) error {
-	result, err := c.dedup.Dedupe("fetch-flags", func() (any, error) {
+	if c.config.OverridesFile != "" {
+		if err := c.loadOverridesFile(); err != nil {
+			return err
+		}
+	}
+	if c.config.Offline {
+		if c.config.FlagsFile == "" {
+			return nil
+		}
+		return c.loadFlagsFile()
+	}
+	// A refresh in flight for a previous user is not joined
+	c.mu.RLock()
+	key := "fetch-flags:" + strconv.FormatUint(c.userVersion, 10)
+	c.mu.RUnlock()
+	result, err := c.dedup.Dedupe(key, func() (any, error) {
 		return nil, c.fetchFlags(ctx)
 	})
 	_ = result
@@ -476,6 +1111,12 @@ func (c *Client) GetMetrics() MetricsSnapshot {
 	return c.metrics.Snapshot()
 }
 
+// PrometheusMetrics returns the SDK metrics in Prometheus text format,
+// with metric names prefixed by prefix (e.g. "rollgate_sdk").
+func (c *Client) PrometheusMetrics(prefix string) string {
+	return c.metrics.ToPrometheus(prefix)
+}
+
 // GetCircuitState returns the current circuit breaker state.
 func (c *Client) GetCircuitState() CircuitState {
 	return c.circuitBreaker.GetState()
@@ -488,33 +1129,107 @@ func (c *Client) IsReady() bool {
 	return c.ready
 }
 
+// IsDegraded returns true if Init returned after StartupTimeout without
+// fetching flags, until a later refresh succeeds. A degraded client serves
+// cached flags or defaults.
+func (c *Client) IsDegraded() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.degraded
+}
+
 // Track sends a conversion event for A/B testing.
+// Invalid events are dropped and logged; use TrackValidated to get the error.
 func (c *Client) Track(opts TrackEventOptions) {
+	if err := c.TrackValidated(opts); err != nil {
+		c.config.Logger.Warn("dropping invalid event", "error", err)
+	}
+}
+
+// TrackValidated sends a conversion event for A/B testing after validating it.
+// Returns a *ValidationError if a required field is missing. Duplicates
+// dropped by Config.EventDedup are not an error.
+func (c *Client) TrackValidated(opts TrackEventOptions) error {
+	if err := opts.Validate(); err != nil {
+		c.metrics.RecordRejectedEvent()
+		return err
+	}
+	if c.eventCollector == nil {
+		return nil
+	}
+	opts = c.withEvaluationContext(opts)
+	if c.eventDedup != nil && !c.eventDedup.add(eventDedupKey(opts)) {
+		c.metrics.RecordDedupedEvent()
+		return nil
+	}
 	c.eventCollector.Track(opts)
+	return nil
+}
+
+// FlushEvents flushes all buffered conversion events. The request is
+// bounded by ctx; events are kept for the next flush if it fails.
+func (c *Client) FlushEvents(ctx context.Context) error {
+	if c.eventCollector == nil {
+		return nil
+	}
+	return c.eventCollector.Flush(ctx)
 }
 
-// FlushEvents flushes all buffered conversion events.
-func (c *Client) FlushEvents() error {
-	return c.eventCollector.Flush()
+// FlushTelemetry flushes all buffered telemetry data. The request is
+// bounded by ctx; data is kept for the next flush if it fails.
+func (c *Client) FlushTelemetry(ctx context.Context) error {
+	if c.telemetryCollector == nil {
+		return nil
+	}
+	return c.telemetryCollector.Flush(ctx)
 }
 
-// FlushTelemetry flushes all buffered telemetry data.
-func (c *Client) FlushTelemetry() error {
-	return c.telemetryCollector.Flush()
+// GetEventStats returns the event buffer size, the number of events dropped
+// after failed flushes, and the outcome of the last flush.
+func (c *Client) GetEventStats() EventStats {
+	if c.eventCollector == nil {
+		return EventStats{}
+	}
+	return c.eventCollector.Stats()
 }
 
 // GetTelemetryStats returns current telemetry buffer statistics.
 func (c *Client) GetTelemetryStats() (flagCount, evaluationCount int) {
+	if c.telemetryCollector == nil {
+		return 0, 0
+	}
 	return c.telemetryCollector.GetBufferStats()
 }
 
 // Close stops background polling/streaming and releases resources.
+// It is safe to call more than once and concurrently with other methods.
 func (c *Client) Close() {
-	c.eventCollector.Stop()
-	c.telemetryCollector.Stop()
+	c.closeOnce.Do(c.close)
+}
+
+func (c *Client) close() {
+	if c.eventCollector != nil {
+		c.eventCollector.Stop()
+	}
+	if c.telemetryCollector != nil {
+		c.telemetryCollector.Stop()
+	}
+	// The final flushes above are done; cancel flushes still in flight
+	c.cancel()
+
+	c.mu.Lock()
+	c.closed = true
+	sseClient := c.sseClient
+	c.mu.Unlock()
+
 	close(c.stopPolling)
-	if c.sseClient != nil {
-		c.sseClient.Close()
+	if sseClient != nil {
+		sseClient.Close()
+	}
+	// Idle keep-alive connections would otherwise hold two goroutines each
+	// until the server hangs up
+	if c.transport != nil {
+		c.transport.CloseIdleConnections()
 	}
 }
 
@@ -540,23 +1255,89 @@ func (c *Client) IsStreaming() bool {
 }
 
 func (c *Client) fetchFlags(ctx context.Context) error {
-	// Check circuit breaker
-	if !c.circuitBreaker.IsAllowingRequests() {
-		if c.config.Logger != nil {
-			c.config.Logger.Warn("circuit breaker is open, using cached flags")
+	// While a fallback base URL is active, the primary gets a request now
+	// and then; flags it serves end the failover
+	if c.failover != nil && c.failover.probeDue() {
+		primary := c.failover.primary()
+		endpoint, do := c.flagsRequest(withBaseURL(ctx, primary))
+		attempt, err := c.probeFetch(withBaseURL(ctx, primary), endpoint, do)
+		if err == nil {
+			if c.failover.failBack() {
+				c.config.Logger.Info("primary base URL recovered, switching back", "url", primary)
+				c.metrics.RecordFailBack()
+			}
+			return c.flagsFetched(ctx, attempt)
 		}
+		c.config.Logger.Debug("primary base URL still failing", "url", primary, "error", err)
+	}
+
+	// Check circuit breaker
+	if !c.circuitBreaker.IsAllowingRequests() && !c.failOver(c.baseURL(ctx), ErrCircuitOpen) {
+		c.config.Logger.Warn("circuit breaker is open, using cached flags")
 		c.useCachedFallback()
 		return ErrCircuitOpen
 	}
 
+	endpoint, do := c.flagsRequest(ctx)
+	from := c.baseURL(ctx)
+	attempt, err := c.executeFetch(ctx, endpoint, do)
+	if err != nil && failsOver(ctx, err) && c.failOver(from, err) && ctx.Err() == nil {
+		endpoint, do = c.flagsRequest(ctx)
+		attempt, err = c.executeFetch(ctx, endpoint, do)
+	}
+	if err != nil {
+		var malformed *MalformedResponseError
+		if errors.As(err, &malformed) {
+			c.mu.Lock()
+			c.malformed = true
+			c.mu.Unlock()
+		}
+		c.useCachedFallback()
+		return err
+	}
+	return c.flagsFetched(ctx, attempt)
+}
+
+// flagsRequest returns the endpoint flags are fetched from and the function
+// performing one attempt: flags, or rules for local evaluation.
+func (c *Client) flagsRequest(ctx context.Context) (string, func(context.Context, *fetchAttempt) error) {
+	if c.evaluator != nil {
+		return c.baseURL(ctx) + "/api/v1/sdk/rules", c.doFetchRulesRequest
+	}
+	return c.baseURL(ctx) + "/api/v1/sdk/flags", c.doFetchRequest
+}
+
+// flagsFetched updates the client state after a successful flags fetch and
+// fetches typed values when they may have changed.
+func (c *Client) flagsFetched(ctx context.Context, attempt fetchAttempt) error {
+	c.mu.Lock()
+	c.degraded = false
+	c.malformed = false
+	c.fetched = true
+	// Typed values only change when the flags or the user did; a user
+	// change clears them
+	fetchTyped := c.evaluator == nil && !c.typedUnsupported && (attempt.statusCode == http.StatusOK || c.typedFlags == nil)
+	c.mu.Unlock()
+
+	if fetchTyped {
+		c.fetchTypedFlags(ctx)
+	}
+	return nil
+}
+
+// executeFetch sends a GET to a flags endpoint through the circuit breaker
+// and retryer, then reports it to the RequestObserver and request metrics.
+// do performs a single attempt and records it in the fetchAttempt.
+func (c *Client) executeFetch(ctx context.Context, endpoint string, do func(context.Context, *fetchAttempt) error) (fetchAttempt, error) {
 	startTime := time.Now()
-	var statusCode int
-	var errCategory ErrorCategory
+	var attempt fetchAttempt
+	var attempts int
 
 	err := c.circuitBreaker.Execute(func() error {
 		result := c.retryer.Do(ctx, func() error {
-			return c.doFetchRequest(ctx, &statusCode)
+			return do(ctx, &attempt)
 		})
+		attempts = result.Attempts
 
 		if !result.Success {
 			return result.Error
@@ -564,27 +1345,66 @@ func (c *Client) fetchFlags(ctx context.Context) error {
 		return nil
 	})
 
-	latencyMs := time.Since(startTime).Milliseconds()
+	retries := attempts - 1
+	if retries < 0 {
+		retries = 0
+	}
+	c.recordFetch(endpoint, time.Since(startTime), attempt, retries, err)
+	return attempt, err
+}
+
+// probeFetch sends a single flags request, bypassing the circuit breaker
+// and retryer, which belong to the active base URL.
+func (c *Client) probeFetch(ctx context.Context, endpoint string, do func(context.Context, *fetchAttempt) error) (fetchAttempt, error) {
+	startTime := time.Now()
+	var attempt fetchAttempt
+	err := do(ctx, &attempt)
+	c.recordFetch(endpoint, time.Since(startTime), attempt, 0, err)
+	return attempt, err
+}
 
+// recordFetch reports a flags request to the RequestObserver and request metrics.
+func (c *Client) recordFetch(endpoint string, elapsed time.Duration, attempt fetchAttempt, retries int, err error) {
+	c.config.RequestObserver.notify(RequestInfo{
+		Method:           http.MethodGet,
+		Endpoint:         endpoint,
+		Duration:         elapsed,
+		StatusCode:       attempt.statusCode,
+		Retries:          retries,
+		ETagSent:         attempt.etagSent,
+		LastModifiedSent: attempt.lastModifiedSent,
+		NotModified:      attempt.statusCode == http.StatusNotModified,
+		Error:            err,
+	})
+
+	latencyMs := elapsed.Milliseconds()
 	if err != nil {
-		classified := ClassifyError(err)
-		errCategory = classified.Category
-		c.metrics.RecordRequest(latencyMs, false, errCategory)
-		c.useCachedFallback()
-		return err
+		c.metrics.RecordRequest(latencyMs, false, ClassifyError(err).Category)
+		if kind := ClassifyNetworkError(err); kind != "" {
+			c.metrics.RecordNetworkError(kind)
+		}
+		return
 	}
-
 	c.metrics.RecordRequest(latencyMs, true, "")
-	return nil
 }
 
-func (c *Client) doFetchRequest(ctx context.Context, statusCode *int) error {
-	u, err := url.Parse(c.config.BaseURL + "/api/v1/sdk/flags")
+// fetchAttempt records what happened during the most recent flags request attempt.
+type fetchAttempt struct {
+	statusCode       int
+	etagSent         bool
+	lastModifiedSent bool
+}
+
+func (c *Client) doFetchRequest(ctx context.Context, attempt *fetchAttempt) error {
+	*attempt = fetchAttempt{}
+
+	u, err := url.Parse(c.baseURL(ctx) + "/api/v1/sdk/flags")
 	if err != nil {
 		return NewNetworkError("invalid URL", err)
 	}
 
 	c.mu.RLock()
+	userVersion := c.userVersion
 	q := u.Query()
 	if c.user != nil && c.user.ID != "" {
 		q.Set("user_id", c.user.ID)
@@ -599,27 +1419,54 @@ func (c *Client) doFetchRequest(ctx context.Context, statusCode *int) error {
 		return NewNetworkError("failed to create request", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	setRequestHeaders(req, c.config.CustomHeaders)
+	if err := c.tokens.authorize(req); err != nil {
+		return err
+	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-SDK-Name", "rollgate-go")
-	req.Header.Set("X-SDK-Version", "1.1.0")
+	c.mu.RLock()
+	setUserContextHeader(req, c.user)
+	c.mu.RUnlock()
 
+	// Prefer the ETag; fall back to Last-Modified when the server (or a CDN
+	// in front of it) did not provide one.
 	c.mu.RLock()
 	if c.lastETag != "" {
 		req.Header.Set("If-None-Match", c.lastETag)
+		attempt.etagSent = true
+	} else if c.lastModified != "" {
+		req.Header.Set("If-Modified-Since", c.lastModified)
+		attempt.lastModifiedSent = true
 	}
 	c.mu.RUnlock()
 
-	resp, err := c.client.Do(req)
+	sent := time.Now()
+	var resp *http.Response
+	if c.config.SharePolling {
+		// Polls may reuse a response another client received recently;
+		// Init and explicit refreshes only join a request in flight
+		var maxAge time.Duration
+		if isPoll(ctx) {
+			maxAge = c.config.RefreshInterval / 2
+		}
+		resp, err = sharedFetches.do(c.client, req, maxAge)
+	} else {
+		resp, err = c.client.Do(req)
+	}
 	if err != nil {
-		return NewNetworkError("request failed", err)
+		return requestError(err)
 	}
 	defer resp.Body.Close()
 
-	*statusCode = resp.StatusCode
+	attempt.statusCode = resp.StatusCode
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotModified {
+		c.clock.observe(resp.Header.Get("Date"), sent, time.Now())
+		c.rateLimits.lift(time.Now())
+	}
 
 	// Handle 304 Not Modified
 	if resp.StatusCode == http.StatusNotModified {
+		c.observeHints(resp, nil)
 		return nil
 	}
 
@@ -628,54 +1475,78 @@ func (c *Client) doFetchRequest(ctx context.Context, statusCode *int) error {
 		return c.handleErrorResponse(resp)
 	}
 
-	// Store ETag
-	if etag := resp.Header.Get("ETag"); etag != "" {
-		c.mu.Lock()
-		c.lastETag = etag
-		c.mu.Unlock()
-	}
-
 	// Parse response
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return NewNetworkError("failed to read response", err)
 	}
 
-	var flagsResp flagsResponse
-	if err := json.Unmarshal(body, &flagsResp); err != nil {
-		return NewNetworkError("failed to parse response", err)
+	parseStart := time.Now()
+	flagsResp, skipped, err := parseFlagsPayload(body)
+	c.metrics.RecordPayload(len(body), time.Since(parseStart))
+	if err != nil {
+		c.metrics.RecordPayloadError(1)
+		// Name the media type when it explains the failure, typically an
+		// error page from a proxy in front of the API
+		if ct := resp.Header.Get("Content-Type"); ct != "" && !isJSONContentType(ct) {
+			return NewMalformedResponseError("unexpected content type "+ct, err)
+		}
+		return NewMalformedResponseError("failed to parse response", err)
+	}
+	if skipped > 0 {
+		c.metrics.RecordPayloadError(skipped)
+		c.config.Logger.Warn("skipped invalid entries in flags payload", "count", skipped)
 	}
+	c.observeHints(resp, flagsResp.SDKConfig)
 
-	// Update flags and reasons
+	// Update flags, reasons and validators. Validators are only stored once the
+	// payload parsed, so a malformed response is not pinned by later 304s.
+	// Flags of a user replaced while the request was in flight are dropped;
+	// the new user's refresh brings its own. Frozen flags are kept, along
+	// with the validators describing them.
 	c.mu.Lock()
+	if c.userVersion != userVersion || c.heldByFreeze() {
+		c.mu.Unlock()
+		return nil
+	}
+	c.lastETag = resp.Header.Get("ETag")
+	c.lastModified = resp.Header.Get("Last-Modified")
 	c.flags = flagsResp.Flags
 	if flagsResp.Reasons != nil {
 		c.flagReasons = flagsResp.Reasons
 	}
+	c.flagMeta = flagsResp.Metadata
 	c.mu.Unlock()
+	c.overrides.clear()
+	c.checkConsistency(updateSourceFetch, false)
 
 	// Update cache
 	if c.config.Cache.Enabled {
-		c.cache.Set(flagsResp.Flags)
+		c.saveCache(flagsResp.Flags, resp.Header.Get("ETag"))
 	}
 
 	return nil
 }
 
+// saveCache stores a full flag set for the current user in the cache,
+// logging failures to write the persisted file.
+func (c *Client) saveCache(flags map[string]bool, etag string) {
+	err := c.cache.Save(CacheEntry{Flags: flags, ETag: etag, UserID: c.currentUserID()})
+	if err != nil {
+		c.config.Logger.Warn("failed to persist flag cache", "path", c.config.Cache.PersistencePath, "error", err)
+	}
+}
+
 func (c *Client) handleErrorResponse(resp *http.Response) error {
 	switch resp.StatusCode {
 	case http.StatusUnauthorized:
+		c.tokens.rejected(resp.Request)
 		return NewAuthenticationError("invalid API key")
 	case http.StatusForbidden:
 		return NewAuthenticationError("access denied")
 	case http.StatusTooManyRequests:
-		retryAfter := 60
-		if ra := resp.Header.Get("Retry-After"); ra != "" {
-			if v, err := strconv.Atoi(ra); err == nil {
-				retryAfter = v
-			}
-		}
-		return NewRateLimitError(retryAfter)
+		retryAfter := c.rateLimits.record(resp, time.Now())
+		return NewRateLimitError(retryAfterSeconds(retryAfter))
 	case http.StatusBadRequest:
 		return &ValidationError{
 			RollgateError: RollgateError{
@@ -703,33 +1574,51 @@ func (c *Client) useCachedFallback() {
 		return
 	}
 
-	cached := c.cache.Get()
+	cached := c.cache.GetForUser(c.currentUserID())
 	if cached.Found {
 		c.mu.Lock()
-		c.flags = cached.Flags
+		if !c.heldByFreeze() {
+			c.flags = cached.Flags
+		}
 		c.mu.Unlock()
 		c.metrics.RecordCacheHit(cached.Stale)
+		c.checkConsistency(updateSourceCache, false)
 	} else {
 		c.metrics.RecordCacheMiss()
 	}
 }
 
-func (c *Client) startPolling() {
-	ticker := time.NewTicker(c.config.RefreshInterval)
-	defer ticker.Stop()
+// startPolling refreshes the flags every RefreshInterval until the client
+// is closed or stop, if not nil, is closed.
+func (c *Client) startPolling(stop <-chan struct{}) {
+	backoff := newPollBackoff(c.pollInterval(), c.config.MaxRefreshInterval, c.config.RefreshBackoffMultiplier)
+	interval := backoff.Current()
+	c.metrics.RecordPollInterval(interval)
+
+	timer := c.timers.newTimer(interval)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-c.stopPolling:
 			return
-		case <-ticker.C:
-			ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout)
-			if err := c.Refresh(ctx); err != nil {
-				if c.config.Logger != nil {
-					c.config.Logger.Warn("failed to refresh flags", "error", err)
-				}
-			}
+		case <-stop:
+			return
+		case <-timer.C():
+			ctx, cancel := context.WithTimeout(withPoll(context.Background()), c.config.Timeout)
+			err := c.Refresh(ctx)
 			cancel()
+			if err != nil {
+				c.config.Logger.Warn("failed to refresh flags", "error", err)
+			}
+
+			// Slow down while the API keeps failing (including while the
+			// circuit is open), and go back to the base interval on success.
+			// The base follows the API's poll interval hint if honored.
+			backoff.SetBase(c.pollInterval())
+			interval = backoff.Next(err == nil)
+			c.metrics.RecordPollInterval(interval)
+			timer.Reset(interval)
 		}
 	}
 }
@@ -3,15 +3,22 @@ package rollgate
 import (
 	"context"
 	"encoding/json"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 func newTestServer(flags map[string]bool) *httptest.Server {
-	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return httptest.NewServer(testFlagsHandler(flags))
+}
+
+// testFlagsHandler serves flags from the flags endpoint.
+func testFlagsHandler(flags map[string]bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case "/api/v1/sdk/flags":
 			resp := map[string]interface{}{
@@ -24,7 +31,7 @@ func newTestServer(flags map[string]bool) *httptest.Server {
 		default:
 			w.WriteHeader(http.StatusNotFound)
 		}
-	}))
+	})
 }
 
 func TestClient_Init(t *testing.T) {
@@ -82,6 +89,49 @@ func TestClient_InitIsAliasForInitialize(t *testing.T) {
 	}
 }
 
+func TestClient_InitialUser(t *testing.T) {
+	var mu sync.Mutex
+	var paths, userIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		userIDs = append(userIDs, r.URL.Query().Get("user_id"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"flags":{"test-flag":true}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		User:            &UserContext{ID: "user-1"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i, path := range paths {
+		if path == "POST /api/v1/sdk/identify" {
+			t.Errorf("expected no identify request, got %q", path)
+		}
+		if userIDs[i] != "user-1" {
+			t.Errorf("expected %s to carry user_id=user-1, got %q", path, userIDs[i])
+		}
+	}
+	if len(paths) == 0 {
+		t.Error("expected flags to be fetched")
+	}
+}
+
 func TestClient_OnCircuitOpen(t *testing.T) {
 	client, err := NewClient(Config{
 		APIKey:          "test-key",
@@ -282,3 +332,391 @@ func TestEvalOptions(t *testing.T) {
 		}
 	})
 }
+
+func TestClient_RequestObserver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"flags":{"flag-a":true}}`))
+		case "/api/v1/sdk/identify", "/api/v1/sdk/events":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var infos []RequestInfo
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		RequestObserver: func(info RequestInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			infos = append(infos, info)
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := client.Identify(ctx, &UserContext{ID: "user-1"}); err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+	if err := client.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	client.Track(TrackEventOptions{FlagKey: "flag-a", EventName: "click", UserID: "user-1"})
+	if err := client.FlushEvents(context.Background()); err != nil {
+		t.Fatalf("FlushEvents failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The test server has no V2 flags endpoint, so typed flags are only
+	// requested once
+	if len(infos) != 6 {
+		t.Fatalf("expected 6 observed requests, got %d", len(infos))
+	}
+
+	first := infos[0]
+	if first.Endpoint != server.URL+"/api/v1/sdk/flags" || first.Method != http.MethodGet {
+		t.Errorf("unexpected first request: %s %s", first.Method, first.Endpoint)
+	}
+	if first.StatusCode != http.StatusOK || first.ETagSent || first.Retries != 0 {
+		t.Errorf("unexpected first request info: %+v", first)
+	}
+
+	if infos[1].Endpoint != server.URL+"/api/v1/sdk/v2/flags" || infos[1].StatusCode != http.StatusNotFound {
+		t.Errorf("expected typed flags request, got %+v", infos[1])
+	}
+
+	if infos[2].Endpoint != server.URL+"/api/v1/sdk/identify" || infos[2].StatusCode != http.StatusOK {
+		t.Errorf("expected identify request, got %+v", infos[2])
+	}
+
+	// A new user invalidates the validators
+	if identified := infos[3]; identified.ETagSent || identified.StatusCode != http.StatusOK {
+		t.Errorf("expected unconditional fetch after identify, got %+v", identified)
+	}
+
+	refresh := infos[4]
+	if !refresh.ETagSent || !refresh.NotModified || refresh.StatusCode != http.StatusNotModified {
+		t.Errorf("expected conditional refresh with 304, got %+v", refresh)
+	}
+
+	if infos[5].Endpoint != server.URL+"/api/v1/sdk/events" || infos[5].Method != http.MethodPost {
+		t.Errorf("expected events request, got %+v", infos[5])
+	}
+}
+
+func TestClient_ConditionalRequestValidators(t *testing.T) {
+	const lastModified = "Wed, 01 Jan 2025 00:00:00 GMT"
+
+	tests := []struct {
+		name             string
+		etag             string
+		wantHeader       string
+		wantValue        string
+		wantETagSent     bool
+		wantLastModified bool
+	}{
+		{"should send If-None-Match when ETag is provided", `"v1"`, "If-None-Match", `"v1"`, true, false},
+		{"should fall back to If-Modified-Since without ETag", "", "If-Modified-Since", lastModified, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var conditional []string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/sdk/flags" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				mu.Lock()
+				conditional = append(conditional, r.Header.Get(tt.wantHeader))
+				mu.Unlock()
+
+				if r.Header.Get(tt.wantHeader) == tt.wantValue {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				w.Header().Set("Last-Modified", lastModified)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"flags":{"flag-a":true}}`))
+			}))
+			defer server.Close()
+
+			var last RequestInfo
+			client, err := NewClient(Config{
+				APIKey:          "test-key",
+				BaseURL:         server.URL,
+				RefreshInterval: time.Hour,
+				RequestObserver: func(info RequestInfo) { last = info },
+			})
+			if err != nil {
+				t.Fatalf("NewClient failed: %v", err)
+			}
+			defer client.Close()
+
+			ctx := context.Background()
+			if err := client.Init(ctx); err != nil {
+				t.Fatalf("Init failed: %v", err)
+			}
+			if err := client.Refresh(ctx); err != nil {
+				t.Fatalf("Refresh failed: %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(conditional) != 2 || conditional[0] != "" || conditional[1] != tt.wantValue {
+				t.Errorf("expected %s on refresh only, got %q", tt.wantHeader, conditional)
+			}
+			if !last.NotModified || last.ETagSent != tt.wantETagSent || last.LastModifiedSent != tt.wantLastModified {
+				t.Errorf("unexpected request info: %+v", last)
+			}
+			if !client.IsEnabled("flag-a", false) {
+				t.Error("expected flags to be kept after 304")
+			}
+		})
+	}
+}
+
+func TestClient_CloseReleasesIdleConnections(t *testing.T) {
+	closed := make(chan struct{}, 1)
+	// ConnState must be set before the server starts serving
+	server := httptest.NewUnstartedServer(testFlagsHandler(map[string]bool{"flag-a": true}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			select {
+			case closed <- struct{}{}:
+			default:
+			}
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	client.Close()
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Error("expected Close to close idle keep-alive connections")
+	}
+}
+
+func TestClient_StartupTimeout(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first fetch hangs until the client gives up on it
+		if requests.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"flags": map[string]bool{"slow-flag": true}})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		StartupTimeout:  100 * time.Millisecond,
+		Retry:           RetryConfig{MaxRetries: 1, BaseDelay: time.Millisecond},
+		Cache:           CacheConfig{TTL: time.Minute, Enabled: false},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	start := time.Now()
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init should succeed degraded after the startup timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Init blocked for %v despite a 100ms startup timeout", elapsed)
+	}
+	if !client.IsReady() || !client.IsDegraded() {
+		t.Fatalf("expected a ready, degraded client (ready=%v degraded=%v)", client.IsReady(), client.IsDegraded())
+	}
+	if client.IsEnabled("slow-flag", false) {
+		t.Error("expected the default before flags are fetched")
+	}
+
+	// The background refresh fetches flags without waiting for polling
+	deadline := time.Now().Add(2 * time.Second)
+	for client.IsDegraded() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if client.IsDegraded() {
+		t.Fatal("expected the background refresh to clear the degraded state")
+	}
+	if !client.IsEnabled("slow-flag", false) {
+		t.Error("expected slow-flag after the background refresh")
+	}
+}
+
+func TestClient_CloseCancelsBackgroundRefresh(t *testing.T) {
+	var requests atomic.Int32
+	background := make(chan struct{})
+	cancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every fetch hangs; the second one is the background refresh
+		if requests.Add(1) == 2 {
+			close(background)
+			<-r.Context().Done()
+			close(cancelled)
+			return
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		Timeout:         time.Minute,
+		RefreshInterval: time.Hour,
+		StartupTimeout:  50 * time.Millisecond,
+		Retry:           RetryConfig{MaxRetries: 0, BaseDelay: time.Millisecond},
+		Cache:           CacheConfig{TTL: time.Minute, Enabled: false},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	select {
+	case <-background:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a background refresh after the startup timeout")
+	}
+	client.Close()
+
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Close to cancel the background refresh")
+	}
+}
+
+func TestClient_StartupTimeoutDoesNotMaskErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "bad-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		StartupTimeout:  time.Second,
+		Cache:           CacheConfig{TTL: time.Minute, Enabled: false},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Init(context.Background()); err == nil {
+		t.Fatal("expected Init to fail on an authentication error")
+	}
+	if client.IsDegraded() {
+		t.Error("a failed Init must not report a degraded client")
+	}
+}
+
+func TestClient_IdentifyDuringRefresh(t *testing.T) {
+	var oldRequests atomic.Int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/sdk/flags" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		userID := r.URL.Query().Get("user_id")
+		// The old user's second fetch is still in flight when the user changes
+		if userID == "old" && oldRequests.Add(1) == 2 {
+			close(entered)
+			select {
+			case <-release:
+			case <-time.After(2 * time.Second):
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"flags": map[string]bool{"new-only": userID == "new"}})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		User:            &UserContext{ID: "old"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+	if err := client.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	stale := make(chan error, 1)
+	go func() { stale <- client.Refresh(ctx) }()
+	<-entered
+
+	if err := client.Identify(ctx, &UserContext{ID: "new"}); err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+	if !client.IsEnabled("new-only", false) {
+		t.Error("expected Identify not to join the old user's refresh")
+	}
+
+	close(release)
+	if err := <-stale; err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if !client.IsEnabled("new-only", false) {
+		t.Error("expected the old user's late response to be dropped")
+	}
+}
@@ -0,0 +1,64 @@
+package rollgate
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// minClockSkew is the smallest server/client clock difference corrected.
+// The Date header has one-second resolution, so smaller differences are
+// indistinguishable from truncation and request latency.
+const minClockSkew = 2 * time.Second
+
+// serverClock tracks the offset between the local clock and the API's
+// clock, learned from the Date header of flags responses, and hands out
+// non-decreasing event timestamps.
+type serverClock struct {
+	offset atomic.Int64 // nanoseconds to add to the local clock
+
+	mu   sync.Mutex
+	last time.Time // latest adjusted time handed out
+}
+
+func newServerClock() *serverClock {
+	return &serverClock{}
+}
+
+// observe updates the offset from a response Date header. The request start
+// and response times bound when the server produced the header.
+func (sc *serverClock) observe(date string, sent, received time.Time) {
+	if date == "" {
+		return
+	}
+	serverTime, err := http.ParseTime(date)
+	if err != nil {
+		return
+	}
+	local := sent.Add(received.Sub(sent) / 2)
+	skew := serverTime.Sub(local)
+	if skew > -minClockSkew && skew < minClockSkew {
+		skew = 0
+	}
+	sc.offset.Store(int64(skew))
+}
+
+// currentOffset returns the current correction applied to local time.
+func (sc *serverClock) currentOffset() time.Duration {
+	return time.Duration(sc.offset.Load())
+}
+
+// now returns the server-adjusted time. It never goes backwards, so events
+// tracked in order carry ordered timestamps even if the wall clock is
+// stepped back or the offset shrinks.
+func (sc *serverClock) now() time.Time {
+	adjusted := time.Now().Round(0).Add(sc.currentOffset()) // compare wall clock, not monotonic
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if adjusted.Before(sc.last) {
+		adjusted = sc.last
+	}
+	sc.last = adjusted
+	return adjusted
+}
@@ -0,0 +1,107 @@
+package rollgate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServerClock_Observe(t *testing.T) {
+	sc := newServerClock()
+	now := time.Now()
+
+	sc.observe(now.Add(time.Hour).UTC().Format(http.TimeFormat), now, now)
+	if got := sc.currentOffset(); got < time.Hour-time.Second || got > time.Hour+time.Second {
+		t.Errorf("expected ~1h offset, got %v", got)
+	}
+
+	// Differences within the Date header's resolution are not corrected
+	sc.observe(now.Add(time.Second).UTC().Format(http.TimeFormat), now, now)
+	if got := sc.currentOffset(); got != 0 {
+		t.Errorf("expected small skew to be ignored, got %v", got)
+	}
+
+	// Unparseable headers keep the previous offset
+	sc.observe(now.Add(-time.Hour).UTC().Format(http.TimeFormat), now, now)
+	sc.observe("not a date", now, now)
+	if got := sc.currentOffset(); got > -time.Hour+time.Second {
+		t.Errorf("expected ~-1h offset to be kept, got %v", got)
+	}
+}
+
+func TestServerClock_NowMonotonic(t *testing.T) {
+	sc := newServerClock()
+	sc.last = time.Now().Add(time.Minute).Round(0)
+
+	if got := sc.now(); got.Before(sc.last) {
+		t.Errorf("expected timestamp not before %v, got %v", sc.last, got)
+	}
+
+	// A shrinking offset must not move timestamps back either
+	sc = newServerClock()
+	sc.offset.Store(int64(5 * time.Second))
+	before := sc.now()
+	sc.offset.Store(0)
+	if got := sc.now(); got.Before(before) {
+		t.Errorf("expected timestamp not before %v after the offset shrank, got %v", before, got)
+	}
+}
+
+func TestClient_EventTimestampsFollowServerClock(t *testing.T) {
+	skew := -time.Hour
+	var received []bufferedEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(skew).UTC().Format(http.TimeFormat))
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			json.NewEncoder(w).Encode(flagsResponse{Flags: map[string]bool{}})
+		case "/api/v1/sdk/events":
+			var body struct {
+				Events []bufferedEvent `json:"events"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			received = append(received, body.Events...)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	client.Track(NewTrackEvent("flag", "first", "user"))
+	client.Track(NewTrackEvent("flag", "second", "user"))
+	if err := client.FlushEvents(context.Background()); err != nil {
+		t.Fatalf("FlushEvents failed: %v", err)
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(received))
+	}
+	var prev time.Time
+	for _, ev := range received {
+		ts, err := time.Parse(time.RFC3339Nano, ev.Timestamp)
+		if err != nil {
+			t.Fatalf("invalid timestamp %q: %v", ev.Timestamp, err)
+		}
+		if delta := ts.Sub(time.Now().Add(skew)); delta < -minClockSkew || delta > minClockSkew {
+			t.Errorf("expected timestamp on the server clock, off by %v", delta)
+		}
+		if ts.Before(prev) {
+			t.Errorf("expected ordered timestamps, %v before %v", ts, prev)
+		}
+		prev = ts
+	}
+}
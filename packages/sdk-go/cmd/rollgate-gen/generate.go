@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/rollgate/sdks/packages/sdk-go/internal/flagname"
+)
+
+// accessor is the template data for one flag.
+type accessor struct {
+	Key         string
+	Name        string
+	Method      string // Client method to call
+	GoType      string
+	Default     string // Go expression
+	MapDefault  string // Go expression typed for the Defaults map
+	DocDefault  string // default as shown in the doc comment
+	Doc         []string
+	Deprecated  []string // Deprecated paragraph, one comment line each
+	JSONDefault string   // JSON literal for json flags
+}
+
+type fileData struct {
+	Package   string
+	Source    string
+	Accessors []accessor
+	HasJSON   bool
+}
+
+// Generate renders the Go source for a manifest. source is recorded in the
+// generated header.
+func Generate(m *Manifest, source string) ([]byte, error) {
+	data := fileData{Package: m.Package, Source: source}
+
+	for _, f := range m.Flags {
+		a := accessor{
+			Key:  f.Key,
+			Name: flagname.GoName(f.Key),
+		}
+		if f.Description != "" {
+			a.Doc = strings.Split(strings.TrimSpace(f.Description), "\n")
+		}
+		if reason := strings.TrimSpace(f.Deprecated); reason != "" {
+			a.Deprecated = strings.Split("Deprecated: "+reason, "\n")
+		}
+
+		switch f.Type {
+		case FlagTypeBoolean:
+			a.Method, a.GoType = "IsEnabled", "bool"
+			a.Default = strconv.FormatBool(f.Default.(bool))
+		case FlagTypeString:
+			a.Method, a.GoType = "GetString", "string"
+			a.Default = strconv.Quote(f.Default.(string))
+		case FlagTypeNumber:
+			a.Method, a.GoType = "GetNumber", "float64"
+			a.Default = strconv.FormatFloat(f.Default.(float64), 'g', -1, 64)
+			a.MapDefault = "float64(" + a.Default + ")"
+		case FlagTypeJSON:
+			raw, err := json.Marshal(f.Default)
+			if err != nil {
+				return nil, fmt.Errorf("flag %q: encode default: %w", f.Key, err)
+			}
+			a.Method, a.GoType = "GetJSON", "interface{}"
+			a.Default = "default" + a.Name
+			a.JSONDefault = "`" + string(raw) + "`"
+			a.DocDefault = string(raw)
+			data.HasJSON = true
+		}
+		if a.MapDefault == "" {
+			a.MapDefault = a.Default
+		}
+		if a.DocDefault == "" {
+			a.DocDefault = a.Default
+		}
+		data.Accessors = append(data.Accessors, a)
+	}
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w\n%s", err, buf.String())
+	}
+	return src, nil
+}
+
+var fileTemplate = template.Must(template.New("file").Parse(`// Code generated by rollgate-gen from {{.Source}}. DO NOT EDIT.
+
+// Package {{.Package}} provides typed accessors for Rollgate feature flags.
+package {{.Package}}
+
+import (
+	"context"
+{{- if .HasJSON}}
+	"encoding/json"
+{{- end}}
+
+	rollgate "github.com/rollgate/sdks/packages/sdk-go"
+)
+
+// Flag keys.
+const (
+{{- range .Accessors}}
+{{- if .Deprecated}}
+	// Key{{.Name}} is the key of {{printf "%q" .Key}}.
+	//
+{{- range .Deprecated}}
+	// {{.}}
+{{- end}}
+{{- end}}
+	Key{{.Name}} = {{printf "%q" .Key}}
+{{- end}}
+)
+
+// Keys lists every flag key in the manifest.
+var Keys = []string{
+{{- range .Accessors}}
+	Key{{.Name}},
+{{- end}}
+}
+{{if .HasJSON}}
+var (
+{{- range .Accessors}}{{if .JSONDefault}}
+	default{{.Name}} = decodeDefault({{.JSONDefault}})
+{{- end}}{{end}}
+)
+
+func decodeDefault(raw string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		panic(err)
+	}
+	return v
+}
+{{end}}
+// Defaults returns the manifest default of every flag, suitable for
+// Client.RegisterDefaults.
+func Defaults() map[string]any {
+	return map[string]any{
+{{- range .Accessors}}
+		Key{{.Name}}: {{.MapDefault}},
+{{- end}}
+	}
+}
+
+// Flags provides typed accessors bound to a Rollgate client. Accessors take
+// the caller's context so call sites stay unchanged as evaluation becomes
+// context-aware; the SDK evaluates flags from memory and does not use it yet.
+type Flags struct {
+	client *rollgate.Client
+}
+
+// New returns typed accessors for client.
+func New(client *rollgate.Client) *Flags {
+	return &Flags{client: client}
+}
+{{range .Accessors}}
+// {{.Name}} returns the value of {{printf "%q" .Key}} (default: {{.DocDefault}}).
+{{- range .Doc}}
+// {{.}}
+{{- end}}
+{{- if .Deprecated}}
+//
+{{- range .Deprecated}}
+// {{.}}
+{{- end}}
+{{- end}}
+func (f *Flags) {{.Name}}(ctx context.Context{{if eq .Method "IsEnabled"}}, opts ...rollgate.EvalOption{{end}}) {{.GoType}} {
+	return f.client.{{.Method}}(Key{{.Name}}, {{.Default}}{{if eq .Method "IsEnabled"}}, opts...{{end}})
+}
+{{end}}`))
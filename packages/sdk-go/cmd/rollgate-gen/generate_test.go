@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestParseManifest(t *testing.T) {
+	t.Run("should apply type and default zero values", func(t *testing.T) {
+		m, err := ParseManifest([]byte(`{"flags":[{"key":"a"},{"key":"b","type":"number","default":3}]}`))
+		if err != nil {
+			t.Fatalf("ParseManifest: %v", err)
+		}
+		if m.Package != "flags" {
+			t.Errorf("expected default package flags, got %q", m.Package)
+		}
+		if m.Flags[0].Type != FlagTypeBoolean || m.Flags[0].Default != false {
+			t.Errorf("expected boolean default false, got %v %v", m.Flags[0].Type, m.Flags[0].Default)
+		}
+		if m.Flags[1].Default != float64(3) {
+			t.Errorf("expected number default 3.0, got %#v", m.Flags[1].Default)
+		}
+	})
+
+	errorCases := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{"missing key", `flags: [{type: boolean}]`, "missing key"},
+		{"duplicate key", `flags: [{key: a}, {key: a}]`, "duplicate key"},
+		{"name collision", `flags: [{key: new-flow}, {key: new_flow}]`, "both map to Go name NewFlow"},
+		{"wrong default type", `flags: [{key: a, type: string, default: 1}]`, "not a string"},
+		{"unknown type", `flags: [{key: a, type: date}]`, "unknown type"},
+	}
+	for _, tc := range errorCases {
+		t.Run("should reject "+tc.name, func(t *testing.T) {
+			_, err := ParseManifest([]byte(tc.manifest))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestGenerate_Golden(t *testing.T) {
+	m, err := LoadManifest("testdata/flags.yaml")
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+	got, err := Generate(m, "flags.yaml")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "flags_gen.go", got, parser.ParseComments); err != nil {
+		t.Fatalf("generated code does not parse: %v", err)
+	}
+
+	const golden = "testdata/flags_gen.go.golden"
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read golden file (run with -update to create): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("generated code differs from %s (run with -update to refresh):\n%s", golden, got)
+	}
+}
+
+func TestGenerate_Deprecated(t *testing.T) {
+	m, err := ParseManifest([]byte("flags:\n  - key: old-flow\n    deprecated: |\n      replaced by new-flow\n      remove after Q3\n"))
+	if err != nil {
+		t.Fatalf("ParseManifest: %v", err)
+	}
+	src, err := Generate(m, "flags.yaml")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "flags_gen.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v", err)
+	}
+
+	// Tools such as staticcheck look for a doc paragraph starting with
+	// "Deprecated: " on the declaration.
+	const want = "\n\nDeprecated: replaced by new-flow\nremove after Q3\n"
+	docs := map[string]*ast.CommentGroup{}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			docs[d.Name.Name] = d.Doc
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if vs, ok := spec.(*ast.ValueSpec); ok {
+					docs[vs.Names[0].Name] = vs.Doc
+				}
+			}
+		}
+	}
+	for _, name := range []string{"OldFlow", "KeyOldFlow"} {
+		if doc := docs[name]; doc == nil || !strings.HasSuffix(doc.Text(), want) {
+			t.Errorf("expected %s to carry a Deprecated paragraph, got %q", name, doc.Text())
+		}
+	}
+}
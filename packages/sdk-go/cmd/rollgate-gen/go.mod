@@ -0,0 +1,10 @@
+module github.com/rollgate/sdks/packages/sdk-go/cmd/rollgate-gen
+
+go 1.21
+
+require (
+	github.com/rollgate/sdks/packages/sdk-go v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+replace github.com/rollgate/sdks/packages/sdk-go => ../..
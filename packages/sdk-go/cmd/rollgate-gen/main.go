@@ -0,0 +1,70 @@
+// Command rollgate-gen generates strongly typed accessors for Rollgate flags
+// from a flag manifest, so application code never spells out flag keys.
+//
+// Usage:
+//
+//	rollgate-gen -manifest flags.yaml -out flags/flags_gen.go [-package flags]
+//
+// The manifest is YAML, or the JSON flag export from the dashboard:
+//
+//	package: flags
+//	flags:
+//	  - key: new-checkout-flow
+//	    description: Enables the redesigned checkout.
+//	  - key: checkout-theme
+//	    type: string
+//	    default: classic
+//	  - key: legacy-search
+//	    deprecated: replaced by search-v2
+//
+// Each flag becomes a method on flags.Flags, such as
+// NewCheckoutFlow(ctx context.Context) bool, that evaluates the flag with its
+// manifest default. Deprecated flags carry a "Deprecated:" comment so linters
+// and editors flag remaining call sites.
+//
+// The command shares code with the SDK module through a replace directive, so
+// it is installed from a checkout (go install . in this directory). Typical
+// use is then a go:generate directive next to the manifest:
+//
+//	//go:generate rollgate-gen -manifest flags.yaml -out flags_gen.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	manifestPath := flag.String("manifest", "flags.yaml", "Path to the flag manifest (YAML or JSON)")
+	out := flag.String("out", "", "Output file (default: stdout)")
+	pkg := flag.String("package", "", "Go package name (overrides the manifest)")
+	flag.Parse()
+
+	if err := run(*manifestPath, *out, *pkg); err != nil {
+		fmt.Fprintf(os.Stderr, "rollgate-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(manifestPath, out, pkg string) error {
+	m, err := LoadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	if pkg != "" {
+		m.Package = pkg
+	}
+
+	src, err := Generate(m, filepath.Base(manifestPath))
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
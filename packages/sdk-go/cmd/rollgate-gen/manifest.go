@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/rollgate/sdks/packages/sdk-go/internal/flagname"
+)
+
+// FlagType is the value type of a flag in the manifest.
+type FlagType string
+
+const (
+	FlagTypeBoolean FlagType = "boolean"
+	FlagTypeString  FlagType = "string"
+	FlagTypeNumber  FlagType = "number"
+	FlagTypeJSON    FlagType = "json"
+)
+
+// Manifest describes the flags to generate accessors for. It is read from
+// YAML or from JSON exported by the server (JSON is valid YAML).
+type Manifest struct {
+	// Package is the Go package name for the generated file (default: flags)
+	Package string `yaml:"package" json:"package"`
+
+	// Flags lists the flags in declaration order
+	Flags []FlagSpec `yaml:"flags" json:"flags"`
+}
+
+// FlagSpec describes a single flag.
+type FlagSpec struct {
+	Key         string   `yaml:"key" json:"key"`
+	Type        FlagType `yaml:"type" json:"type"`
+	Default     any      `yaml:"default" json:"default"`
+	Description string   `yaml:"description" json:"description"`
+
+	// Deprecated marks the flag for removal; the value is the reason shown
+	// in the generated Deprecated comment
+	Deprecated string `yaml:"deprecated" json:"deprecated"`
+}
+
+// LoadManifest reads and validates a manifest file.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifest(data)
+}
+
+// ParseManifest parses and validates a YAML or JSON manifest.
+func ParseManifest(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	if m.Package == "" {
+		m.Package = "flags"
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *Manifest) validate() error {
+	seenKeys := make(map[string]bool, len(m.Flags))
+	seenNames := make(map[string]string, len(m.Flags))
+
+	for i := range m.Flags {
+		f := &m.Flags[i]
+		if f.Key == "" {
+			return fmt.Errorf("flag %d: missing key", i)
+		}
+		if seenKeys[f.Key] {
+			return fmt.Errorf("flag %q: duplicate key", f.Key)
+		}
+		seenKeys[f.Key] = true
+
+		name := flagname.GoName(f.Key)
+		if other, ok := seenNames[name]; ok {
+			return fmt.Errorf("flags %q and %q both map to Go name %s", other, f.Key, name)
+		}
+		seenNames[name] = f.Key
+
+		if f.Type == "" {
+			f.Type = FlagTypeBoolean
+		}
+		if err := f.normalizeDefault(); err != nil {
+			return fmt.Errorf("flag %q: %w", f.Key, err)
+		}
+	}
+	return nil
+}
+
+// normalizeDefault checks the default against the flag type and fills in the
+// zero value when no default is given.
+func (f *FlagSpec) normalizeDefault() error {
+	switch f.Type {
+	case FlagTypeBoolean:
+		if f.Default == nil {
+			f.Default = false
+		}
+		if _, ok := f.Default.(bool); !ok {
+			return fmt.Errorf("default %v is not a boolean", f.Default)
+		}
+	case FlagTypeString:
+		if f.Default == nil {
+			f.Default = ""
+		}
+		if _, ok := f.Default.(string); !ok {
+			return fmt.Errorf("default %v is not a string", f.Default)
+		}
+	case FlagTypeNumber:
+		switch v := f.Default.(type) {
+		case nil:
+			f.Default = float64(0)
+		case int:
+			f.Default = float64(v)
+		case float64:
+		default:
+			return fmt.Errorf("default %v is not a number", f.Default)
+		}
+	case FlagTypeJSON:
+		// Any value, including nil
+	default:
+		return fmt.Errorf("unknown type %q (want boolean, string, number or json)", f.Type)
+	}
+	return nil
+}
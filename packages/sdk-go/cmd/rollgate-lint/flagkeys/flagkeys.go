@@ -0,0 +1,224 @@
+// Package flagkeys defines an analyzer that checks flag keys passed to the
+// Rollgate SDK against a flag manifest.
+//
+// It reports:
+//
+//   - keys that are not declared in the manifest
+//   - string literals for keys that have a generated constant (see rollgate-gen)
+//   - the same key evaluated with different constant defaults in one package,
+//     or with a default that differs from the manifest
+//
+// Without -manifest only the inconsistent-default check runs.
+package flagkeys
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"os"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"gopkg.in/yaml.v3"
+
+	"github.com/rollgate/sdks/packages/sdk-go/internal/flagname"
+)
+
+// sdkPath is the import path of the Rollgate Go SDK.
+const sdkPath = "github.com/rollgate/sdks/packages/sdk-go"
+
+// Analyzer checks flag key usage against a manifest.
+var Analyzer = &analysis.Analyzer{
+	Name:     "flagkeys",
+	Doc:      "check Rollgate flag keys against the flag manifest",
+	Run:      run,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+}
+
+var manifestPath string
+
+func init() {
+	Analyzer.Flags.StringVar(&manifestPath, "manifest", "", "path to the rollgate-gen flag manifest (YAML or JSON)")
+}
+
+// evalMethods maps SDK evaluation methods to the position of their
+// defaultValue argument.
+var evalMethods = map[string]int{
+	"IsEnabled":       1,
+	"IsEnabledDetail": 1,
+	"GetString":       1,
+	"GetNumber":       1,
+	"GetInt":          1,
+	"GetJSON":         1,
+	"IsEnabledFor":    2,
+}
+
+// manifest is the subset of the rollgate-gen manifest the analyzer needs.
+type manifest struct {
+	Package string `yaml:"package"`
+	Flags   []struct {
+		Key     string `yaml:"key"`
+		Type    string `yaml:"type"`
+		Default any    `yaml:"default"`
+	} `yaml:"flags"`
+}
+
+// manifestFlag is a declared flag with its constant default, if any.
+type manifestFlag struct {
+	constName string
+	defValue  constant.Value
+}
+
+// loadManifest reads the manifest; it is re-read per package so the analyzer
+// stays stateless.
+func loadManifest(path string) (map[string]manifestFlag, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	var m manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, "", fmt.Errorf("parse manifest: %w", err)
+	}
+	if m.Package == "" {
+		m.Package = "flags"
+	}
+
+	flags := make(map[string]manifestFlag, len(m.Flags))
+	for _, f := range m.Flags {
+		mf := manifestFlag{constName: "Key" + flagname.GoName(f.Key)}
+		if f.Default == nil {
+			// rollgate-gen uses the zero value when no default is given
+			switch f.Type {
+			case "", "boolean":
+				f.Default = false
+			case "string":
+				f.Default = ""
+			case "number":
+				f.Default = 0
+			}
+		}
+		switch v := f.Default.(type) {
+		case bool:
+			mf.defValue = constant.MakeBool(v)
+		case string:
+			mf.defValue = constant.MakeString(v)
+		case int:
+			mf.defValue = constant.MakeFloat64(float64(v))
+		case float64:
+			mf.defValue = constant.MakeFloat64(v)
+		}
+		flags[f.Key] = mf
+	}
+	return flags, m.Package, nil
+}
+
+// evalSite records where a key was first evaluated with a constant default.
+type evalSite struct {
+	pos      token.Pos
+	defValue constant.Value
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	var flags map[string]manifestFlag
+	var flagsPkg string
+	if manifestPath != "" {
+		var err error
+		if flags, flagsPkg, err = loadManifest(manifestPath); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]evalSite)
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		defaultIdx, ok := sdkEvalMethod(pass, call)
+		if !ok || len(call.Args) <= defaultIdx {
+			return
+		}
+
+		keyArg := call.Args[0]
+		key, ok := constString(pass, keyArg)
+		if !ok {
+			return
+		}
+
+		if flags != nil {
+			mf, declared := flags[key]
+			if !declared {
+				pass.Reportf(keyArg.Pos(), "unknown flag key %q (not in manifest)", key)
+				return
+			}
+			if _, isLiteral := keyArg.(*ast.BasicLit); isLiteral {
+				pass.Reportf(keyArg.Pos(), "use %s.%s instead of the string literal %q", flagsPkg, mf.constName, key)
+			}
+		}
+
+		defValue := pass.TypesInfo.Types[call.Args[defaultIdx]].Value
+		if defValue == nil {
+			return
+		}
+		if flags != nil {
+			if want := flags[key].defValue; want != nil && !sameValue(want, defValue) {
+				pass.Reportf(call.Args[defaultIdx].Pos(), "flag %q evaluated with default %s, manifest declares %s", key, defValue, want)
+				return
+			}
+		}
+		if first, ok := seen[key]; ok {
+			if !sameValue(first.defValue, defValue) {
+				pass.Reportf(call.Args[defaultIdx].Pos(), "flag %q evaluated with default %s, but with %s at %s",
+					key, defValue, first.defValue, pass.Fset.Position(first.pos))
+			}
+			return
+		}
+		seen[key] = evalSite{pos: call.Args[defaultIdx].Pos(), defValue: defValue}
+	})
+
+	return nil, nil
+}
+
+// sdkEvalMethod reports whether call is an evaluation method on a type from
+// the SDK package and returns the index of its default argument.
+func sdkEvalMethod(pass *analysis.Pass, call *ast.CallExpr) (int, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return 0, false
+	}
+	idx, ok := evalMethods[sel.Sel.Name]
+	if !ok {
+		return 0, false
+	}
+	fn, ok := pass.TypesInfo.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != sdkPath {
+		return 0, false
+	}
+	if sig, ok := fn.Type().(*types.Signature); !ok || sig.Recv() == nil {
+		return 0, false
+	}
+	return idx, true
+}
+
+func constString(pass *analysis.Pass, expr ast.Expr) (string, bool) {
+	tv := pass.TypesInfo.Types[expr]
+	if tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// sameValue compares constant defaults, treating integer and float
+// representations of the same number as equal.
+func sameValue(a, b constant.Value) bool {
+	if a.Kind() != b.Kind() {
+		numeric := func(k constant.Kind) bool { return k == constant.Int || k == constant.Float }
+		if !numeric(a.Kind()) || !numeric(b.Kind()) {
+			return false
+		}
+	}
+	return constant.Compare(a, token.EQL, b)
+}
@@ -0,0 +1,29 @@
+package flagkeys
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	t.Run("should check keys and defaults against the manifest", func(t *testing.T) {
+		setManifest(t, filepath.Join(testdata, "flags.yaml"))
+		analysistest.Run(t, testdata, Analyzer, "app")
+	})
+
+	t.Run("should only check default consistency without a manifest", func(t *testing.T) {
+		setManifest(t, "")
+		analysistest.Run(t, testdata, Analyzer, "nomanifest")
+	})
+}
+
+func setManifest(t *testing.T, path string) {
+	t.Helper()
+	old := manifestPath
+	manifestPath = path
+	t.Cleanup(func() { manifestPath = old })
+}
@@ -0,0 +1,18 @@
+package app
+
+import rollgate "github.com/rollgate/sdks/packages/sdk-go"
+
+const KeyNewCheckoutFlow = "new-checkout-flow"
+
+func use(c *rollgate.Client, s *rollgate.FlagSnapshot, dynamic string) {
+	c.IsEnabled(KeyNewCheckoutFlow, false)
+	c.IsEnabled("new-checkout-flow", false) // want `use flags.KeyNewCheckoutFlow instead of the string literal "new-checkout-flow"`
+	c.IsEnabled("no-such-flag", false)      // want `unknown flag key "no-such-flag" \(not in manifest\)`
+	c.IsEnabled(KeyNewCheckoutFlow, true)   // want `flag "new-checkout-flow" evaluated with default true, manifest declares false`
+	c.IsEnabled(dynamic, false)
+
+	c.GetNumber("max-items", 50)          // want `use flags.KeyMaxItems`
+	c.GetString("checkout-theme", "dark") // want `use flags.KeyCheckoutTheme` `flag "checkout-theme" evaluated with default "dark", manifest declares "classic"`
+
+	s.IsEnabledFor(KeyNewCheckoutFlow, nil, false)
+}
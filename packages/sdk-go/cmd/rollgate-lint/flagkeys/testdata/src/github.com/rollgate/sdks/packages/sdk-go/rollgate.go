@@ -0,0 +1,20 @@
+// Package rollgate is a minimal stand-in for the SDK used by analyzer tests.
+package rollgate
+
+type EvalOption func()
+
+type UserContext struct{ ID string }
+
+type Client struct{}
+
+func (c *Client) IsEnabled(flagKey string, defaultValue bool, opts ...EvalOption) bool {
+	return defaultValue
+}
+func (c *Client) GetString(flagKey string, defaultValue string) string   { return defaultValue }
+func (c *Client) GetNumber(flagKey string, defaultValue float64) float64 { return defaultValue }
+
+type FlagSnapshot struct{}
+
+func (s *FlagSnapshot) IsEnabledFor(flagKey string, user *UserContext, defaultValue bool) bool {
+	return defaultValue
+}
@@ -0,0 +1,11 @@
+package nomanifest
+
+import rollgate "github.com/rollgate/sdks/packages/sdk-go"
+
+func use(c *rollgate.Client) {
+	c.IsEnabled("beta", false)
+	c.IsEnabled("beta", false)
+	c.IsEnabled("beta", true) // want `flag "beta" evaluated with default true, but with false at .*nomanifest.go:6:22`
+	c.GetString("theme", "a")
+	c.GetString("theme", "b") // want `flag "theme" evaluated with default "b", but with "a" at`
+}
@@ -0,0 +1,16 @@
+module github.com/rollgate/sdks/packages/sdk-go/cmd/rollgate-lint
+
+go 1.22.0
+
+require (
+	github.com/rollgate/sdks/packages/sdk-go v0.0.0
+	golang.org/x/tools v0.29.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/mod v0.22.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+)
+
+replace github.com/rollgate/sdks/packages/sdk-go => ../..
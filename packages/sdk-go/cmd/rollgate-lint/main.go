@@ -0,0 +1,21 @@
+// Command rollgate-lint checks Rollgate flag keys against a flag manifest.
+//
+// It reports unknown flag keys, string literals where a rollgate-gen
+// constant exists, and inconsistent defaults for the same key:
+//
+//	rollgate-lint -manifest flags.yaml ./...
+//
+// It can also run under go vet:
+//
+//	go vet -vettool=$(which rollgate-lint) -flagkeys.manifest=$PWD/flags.yaml ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/rollgate/sdks/packages/sdk-go/cmd/rollgate-lint/flagkeys"
+)
+
+func main() {
+	singlechecker.Main(flagkeys.Analyzer)
+}
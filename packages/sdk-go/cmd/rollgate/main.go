@@ -0,0 +1,378 @@
+// Command rollgate is a small CLI for inspecting Rollgate flags from a terminal.
+//
+// Usage:
+//
+//	rollgate flags   [-user JSON] [-json]          fetch and print all flags
+//	rollgate eval    [-user JSON] <flag-key>       evaluate one flag with its reason
+//	rollgate stream  [-user JSON]                  tail flag updates from the SSE stream
+//	rollgate metrics [-format json|prometheus]     fetch flags once and dump SDK metrics
+//
+// Common flags: -api-key (or ROLLGATE_API_KEY), -base-url (or ROLLGATE_BASE_URL),
+// -timeout. A -user value starting with "@" is read from a file. With
+// -snapshot FILE, eval reproduces an evaluation offline against a rules
+// payload saved from /api/v1/sdk/rules, without an API key.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	rollgate "github.com/rollgate/sdks/packages/sdk-go"
+)
+
+const usage = `rollgate - inspect Rollgate feature flags
+
+Usage:
+  rollgate <command> [flags]
+
+Commands:
+  flags    Fetch and print all flags
+  eval     Evaluate a single flag and print its reason
+  stream   Tail flag updates from the SSE stream
+  metrics  Fetch flags once and dump SDK metrics
+
+Run 'rollgate <command> -h' for command flags.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "flags":
+		err = runFlags(args, os.Stdout)
+	case "eval":
+		err = runEval(args, os.Stdout)
+	case "stream":
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err = runStream(ctx, args, os.Stdout)
+		stop()
+	case "metrics":
+		err = runMetrics(args, os.Stdout)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "rollgate %s: %v\n", cmd, err)
+		os.Exit(1)
+	}
+}
+
+// commonFlags holds flags shared by all commands.
+type commonFlags struct {
+	apiKey  string
+	baseURL string
+	user    string
+	timeout time.Duration
+}
+
+func newFlagSet(name string, withUser bool) (*flag.FlagSet, *commonFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	c := &commonFlags{}
+	fs.StringVar(&c.apiKey, "api-key", os.Getenv("ROLLGATE_API_KEY"), "API key (default: $ROLLGATE_API_KEY)")
+	fs.StringVar(&c.baseURL, "base-url", envOr("ROLLGATE_BASE_URL", "https://api.rollgate.io"), "API base URL (default: $ROLLGATE_BASE_URL)")
+	fs.DurationVar(&c.timeout, "timeout", 10*time.Second, "Request timeout")
+	if withUser {
+		fs.StringVar(&c.user, "user", "", `User context as JSON ({"id":"u1","email":"...","attributes":{...}}) or @file.json`)
+	}
+	return fs, c
+}
+
+// newClient creates a client for one-shot CLI use. Events and telemetry are
+// disabled so inspecting flags does not skew production statistics, and the
+// user is set on the client rather than identified, so no user is registered
+// with the server.
+func (c *commonFlags) newClient(user *rollgate.UserContext) (*rollgate.Client, error) {
+	if c.apiKey == "" {
+		return nil, errors.New("missing API key (use -api-key or ROLLGATE_API_KEY)")
+	}
+
+	return rollgate.NewClient(rollgate.Config{
+		APIKey:          c.apiKey,
+		BaseURL:         c.baseURL,
+		Timeout:         c.timeout,
+		RefreshInterval: time.Hour,
+		User:            user,
+		Cache:           rollgate.CacheConfig{TTL: time.Minute, StaleTTL: time.Minute, Enabled: false},
+		Events:          rollgate.EventCollectorConfig{FlushIntervalMs: 60000, MaxBufferSize: 100, Enabled: false},
+		Telemetry:       rollgate.TelemetryConfig{FlushIntervalMs: 60000, MaxBufferSize: 1000, Enabled: false},
+	})
+}
+
+// parseUser parses the -user value, reading it from a file if it starts with "@".
+func (c *commonFlags) parseUser() (*rollgate.UserContext, error) {
+	if c.user == "" {
+		return nil, nil
+	}
+
+	data := []byte(c.user)
+	if strings.HasPrefix(c.user, "@") {
+		var err error
+		if data, err = os.ReadFile(strings.TrimPrefix(c.user, "@")); err != nil {
+			return nil, fmt.Errorf("read user file: %w", err)
+		}
+	}
+
+	var user rollgate.UserContext
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, fmt.Errorf("parse user JSON: %w", err)
+	}
+	if user.ID == "" {
+		return nil, errors.New(`user JSON must include "id"`)
+	}
+	return &user, nil
+}
+
+// connect creates and initializes a client for the user, if one was given.
+func (c *commonFlags) connect(ctx context.Context) (*rollgate.Client, error) {
+	user, err := c.parseUser()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := c.newClient(user)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Init(ctx); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+func runFlags(args []string, out io.Writer) error {
+	fs, common := newFlagSet("flags", true)
+	asJSON := fs.Bool("json", false, "Print flags as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	client, err := common.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	flags := client.GetAllFlags()
+	keys := make([]string, 0, len(flags))
+	for k := range flags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if *asJSON {
+		details := make(map[string]rollgate.BoolEvaluationDetail, len(keys))
+		for _, k := range keys {
+			details[k] = client.IsEnabledDetail(k, false)
+		}
+		return writeJSON(out, details)
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tREASON")
+	for _, k := range keys {
+		detail := client.IsEnabledDetail(k, false)
+		fmt.Fprintf(tw, "%s\t%t\t%s\n", k, detail.Value, formatReason(detail.Reason))
+	}
+	return tw.Flush()
+}
+
+func runEval(args []string, out io.Writer) error {
+	fs, common := newFlagSet("eval", true)
+	defaultValue := fs.Bool("default", false, "Default value if the flag is unknown")
+	snapshotFile := fs.String("snapshot", "", "Evaluate against a rules payload saved from /api/v1/sdk/rules instead of the API")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: rollgate eval [flags] <flag-key>")
+	}
+	flagKey := fs.Arg(0)
+
+	if *snapshotFile != "" {
+		user, err := common.parseUser()
+		if err != nil {
+			return err
+		}
+		snapshot, err := rollgate.LoadSnapshotFile(*snapshotFile)
+		if err != nil {
+			return err
+		}
+		detail := snapshot.IsEnabledDetailFor(flagKey, user, *defaultValue)
+		return writeJSON(out, map[string]any{
+			"flagKey": flagKey,
+			"value":   detail.Value,
+			"reason":  detail.Reason,
+			"version": snapshot.Version(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	client, err := common.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	detail := client.IsEnabledDetail(flagKey, *defaultValue)
+	return writeJSON(out, map[string]any{
+		"flagKey": flagKey,
+		"value":   detail.Value,
+		"reason":  detail.Reason,
+	})
+}
+
+// runStream prints stream events until ctx is cancelled.
+func runStream(ctx context.Context, args []string, out io.Writer) error {
+	fs, common := newFlagSet("stream", true)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if common.apiKey == "" {
+		return errors.New("missing API key (use -api-key or ROLLGATE_API_KEY)")
+	}
+	user, err := common.parseUser()
+	if err != nil {
+		return err
+	}
+
+	sse := rollgate.NewSSEClient(rollgate.Config{
+		APIKey:  common.apiKey,
+		BaseURL: common.baseURL,
+		Logger:  streamLogger{ctx: ctx, out: out},
+	})
+	sse.SetUser(user)
+	sse.OnConnect(func() {
+		fmt.Fprintf(out, "%s connected\n", timestamp())
+	})
+	sse.OnError(func(err error) {
+		if ctx.Err() == nil {
+			fmt.Fprintf(out, "%s error: %v\n", timestamp(), err)
+		}
+	})
+	sse.OnFlags(func(flags map[string]bool) {
+		data, _ := json.Marshal(flags)
+		fmt.Fprintf(out, "%s flags %s\n", timestamp(), data)
+	})
+
+	if err := sse.Connect(ctx); err != nil {
+		return err
+	}
+	defer sse.Close()
+
+	<-ctx.Done()
+	return nil
+}
+
+func runMetrics(args []string, out io.Writer) error {
+	fs, common := newFlagSet("metrics", true)
+	format := fs.String("format", "json", "Output format: json or prometheus")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "json" && *format != "prometheus" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.timeout)
+	defer cancel()
+
+	client, err := common.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if *format == "prometheus" {
+		_, err := io.WriteString(out, client.PrometheusMetrics("rollgate_sdk"))
+		return err
+	}
+	return writeJSON(out, client.GetMetrics())
+}
+
+// streamLogger prints SSE client log lines (such as flag-changed
+// notifications) alongside stream output. Lines are dropped once the
+// stream is shutting down.
+type streamLogger struct {
+	ctx context.Context
+	out io.Writer
+}
+
+func (l streamLogger) log(level, msg string, args ...any) {
+	if l.ctx.Err() != nil {
+		return
+	}
+	fmt.Fprintf(l.out, "%s %s %s", timestamp(), level, msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(l.out, " %v=%v", args[i], args[i+1])
+	}
+	fmt.Fprintln(l.out)
+}
+
+func (l streamLogger) Debug(msg string, args ...any) { l.log("debug", msg, args...) }
+func (l streamLogger) Info(msg string, args ...any)  { l.log("info", msg, args...) }
+func (l streamLogger) Warn(msg string, args ...any)  { l.log("warn", msg, args...) }
+func (l streamLogger) Error(msg string, args ...any) { l.log("error", msg, args...) }
+
+func formatReason(r rollgate.EvaluationReason) string {
+	s := string(r.Kind)
+	if r.RuleID != "" {
+		s += " rule=" + r.RuleID
+	}
+	if r.PrerequisiteKey != "" {
+		s += " prerequisite=" + r.PrerequisiteKey
+	}
+	if r.ErrorKind != "" {
+		s += " error=" + string(r.ErrorKind)
+	}
+	if r.InRollout {
+		s += " inRollout"
+	}
+	return s
+}
+
+func writeJSON(out io.Writer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func timestamp() string {
+	return time.Now().Format("15:04:05.000")
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testFlags = `{"flags":{"flag-a":true,"flag-b":false},"reasons":{"flag-a":{"kind":"TARGET_MATCH"},"flag-b":{"kind":"OFF"}}}`
+
+// testServer serves the flags and stream endpoints and records requests.
+type testServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	s := &testServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		s.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(testFlags))
+		case "/api/v1/sdk/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("event: init\ndata: " + testFlags + "\n\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// paths returns the method and path of every request received so far.
+func (s *testServer) paths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paths := make([]string, len(s.requests))
+	for i, r := range s.requests {
+		paths[i] = r.Method + " " + r.URL.Path
+	}
+	return paths
+}
+
+// syncBuffer is a bytes.Buffer safe for use by the stream callbacks.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func commonArgs(s *testServer) []string {
+	return []string{"-api-key", "test-key", "-base-url", s.URL, "-timeout", "5s"}
+}
+
+func TestNewFlagSet(t *testing.T) {
+	t.Setenv("ROLLGATE_API_KEY", "env-key")
+	t.Setenv("ROLLGATE_BASE_URL", "http://env.example")
+
+	t.Run("should default to the environment", func(t *testing.T) {
+		fs, common := newFlagSet("flags", true)
+		if err := fs.Parse(nil); err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if common.apiKey != "env-key" || common.baseURL != "http://env.example" || common.timeout != 10*time.Second {
+			t.Errorf("unexpected defaults: %+v", common)
+		}
+	})
+
+	t.Run("should let flags override the environment", func(t *testing.T) {
+		fs, common := newFlagSet("flags", true)
+		err := fs.Parse([]string{"-api-key", "k", "-base-url", "http://flag.example", "-timeout", "2s", "-user", `{"id":"u1"}`})
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if common.apiKey != "k" || common.baseURL != "http://flag.example" || common.timeout != 2*time.Second || common.user != `{"id":"u1"}` {
+			t.Errorf("unexpected flags: %+v", common)
+		}
+	})
+
+	t.Run("should reject -user when the command has no user", func(t *testing.T) {
+		fs, _ := newFlagSet("metrics", false)
+		fs.SetOutput(&bytes.Buffer{})
+		if err := fs.Parse([]string{"-user", `{"id":"u1"}`}); err == nil {
+			t.Error("expected -user to be undefined")
+		}
+	})
+}
+
+func TestParseUser(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user.json")
+	if err := os.WriteFile(path, []byte(`{"id":"file-user","email":"a@example.com"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		user    string
+		wantID  string
+		wantErr string
+	}{
+		{"should return no user when unset", "", "", ""},
+		{"should parse inline JSON", `{"id":"u1","attributes":{"plan":"pro"}}`, "u1", ""},
+		{"should read a file prefixed with @", "@" + path, "file-user", ""},
+		{"should require an id", `{"email":"a@example.com"}`, "", `must include "id"`},
+		{"should reject invalid JSON", `{"id":`, "", "parse user JSON"},
+		{"should report a missing file", "@" + path + ".missing", "", "read user file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := (&commonFlags{user: tt.user}).parseUser()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseUser failed: %v", err)
+			}
+			if tt.wantID == "" {
+				if user != nil {
+					t.Errorf("expected no user, got %+v", user)
+				}
+				return
+			}
+			if user == nil || user.ID != tt.wantID {
+				t.Errorf("expected user %q, got %+v", tt.wantID, user)
+			}
+		})
+	}
+}
+
+func TestRunFlags(t *testing.T) {
+	s := newTestServer(t)
+
+	t.Run("should print a table", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := runFlags(commonArgs(s), &out); err != nil {
+			t.Fatalf("runFlags failed: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 3 || !strings.HasPrefix(lines[0], "KEY") {
+			t.Fatalf("unexpected table:\n%s", out.String())
+		}
+		if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "flag-a true TARGET_MATCH" {
+			t.Errorf("unexpected row %q", lines[1])
+		}
+		if fields := strings.Fields(lines[2]); strings.Join(fields, " ") != "flag-b false OFF" {
+			t.Errorf("unexpected row %q", lines[2])
+		}
+	})
+
+	t.Run("should print JSON", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := runFlags(append(commonArgs(s), "-json"), &out); err != nil {
+			t.Fatalf("runFlags failed: %v", err)
+		}
+		var details map[string]struct {
+			Value  bool `json:"value"`
+			Reason struct {
+				Kind string `json:"kind"`
+			} `json:"reason"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &details); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, out.String())
+		}
+		if !details["flag-a"].Value || details["flag-a"].Reason.Kind != "TARGET_MATCH" || len(details) != 2 {
+			t.Errorf("unexpected details: %+v", details)
+		}
+	})
+
+	t.Run("should fail without an API key", func(t *testing.T) {
+		t.Setenv("ROLLGATE_API_KEY", "")
+		err := runFlags([]string{"-base-url", s.URL}, &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), "missing API key") {
+			t.Errorf("expected missing API key error, got %v", err)
+		}
+	})
+}
+
+func TestRunEval(t *testing.T) {
+	t.Run("should evaluate for the user without identifying it", func(t *testing.T) {
+		s := newTestServer(t)
+		var out bytes.Buffer
+		args := append(commonArgs(s), "-user", `{"id":"u1"}`, "flag-a")
+		if err := runEval(args, &out); err != nil {
+			t.Fatalf("runEval failed: %v", err)
+		}
+
+		var result struct {
+			FlagKey string `json:"flagKey"`
+			Value   bool   `json:"value"`
+			Reason  struct {
+				Kind string `json:"kind"`
+			} `json:"reason"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, out.String())
+		}
+		if result.FlagKey != "flag-a" || !result.Value || result.Reason.Kind != "TARGET_MATCH" {
+			t.Errorf("unexpected result: %+v", result)
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, r := range s.requests {
+			if r.URL.Path == "/api/v1/sdk/identify" {
+				t.Error("eval must not send an identify request")
+			}
+			if r.URL.Path == "/api/v1/sdk/flags" && r.URL.Query().Get("user_id") != "u1" {
+				t.Errorf("expected flags to be fetched for u1, got %q", r.URL.RawQuery)
+			}
+		}
+	})
+
+	t.Run("should return the default for an unknown flag", func(t *testing.T) {
+		s := newTestServer(t)
+		var out bytes.Buffer
+		if err := runEval(append(commonArgs(s), "-default", "missing"), &out); err != nil {
+			t.Fatalf("runEval failed: %v", err)
+		}
+		if !strings.Contains(out.String(), `"value": true`) || !strings.Contains(out.String(), `"kind": "UNKNOWN"`) {
+			t.Errorf("expected default with UNKNOWN reason, got:\n%s", out.String())
+		}
+	})
+
+	t.Run("should require exactly one flag key", func(t *testing.T) {
+		s := newTestServer(t)
+		err := runEval(commonArgs(s), &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), "usage") {
+			t.Errorf("expected usage error, got %v", err)
+		}
+		if paths := s.paths(); len(paths) != 0 {
+			t.Errorf("expected no requests, got %v", paths)
+		}
+	})
+
+	t.Run("should evaluate against a snapshot file offline", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "rules.json")
+		rules := `{"version":"v7","flags":{"flag-a":{"enabled":true,"rollout":0,"targetUsers":["u1"]}}}`
+		if err := os.WriteFile(path, []byte(rules), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		args := []string{"-api-key", "", "-snapshot", path, "-user", `{"id":"u1"}`, "flag-a"}
+		if err := runEval(args, &out); err != nil {
+			t.Fatalf("runEval failed: %v", err)
+		}
+		var result struct {
+			Value   bool   `json:"value"`
+			Version string `json:"version"`
+			Reason  struct {
+				Kind string `json:"kind"`
+			} `json:"reason"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, out.String())
+		}
+		if !result.Value || result.Reason.Kind != "TARGET_MATCH" || result.Version != "v7" {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+}
+
+func TestRunMetrics(t *testing.T) {
+	s := newTestServer(t)
+
+	t.Run("should print JSON metrics", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := runMetrics(commonArgs(s), &out); err != nil {
+			t.Fatalf("runMetrics failed: %v", err)
+		}
+		var metrics map[string]any
+		if err := json.Unmarshal(out.Bytes(), &metrics); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, out.String())
+		}
+		if len(metrics) == 0 {
+			t.Error("expected metrics")
+		}
+	})
+
+	t.Run("should print Prometheus metrics", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := runMetrics(append(commonArgs(s), "-format", "prometheus"), &out); err != nil {
+			t.Fatalf("runMetrics failed: %v", err)
+		}
+		if !strings.Contains(out.String(), "rollgate_sdk_") {
+			t.Errorf("expected rollgate_sdk_ metrics, got:\n%s", out.String())
+		}
+	})
+
+	t.Run("should reject an unknown format", func(t *testing.T) {
+		err := runMetrics(append(commonArgs(s), "-format", "xml"), &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), "unknown format") {
+			t.Errorf("expected unknown format error, got %v", err)
+		}
+	})
+}
+
+func TestRunStream(t *testing.T) {
+	s := newTestServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var out syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- runStream(ctx, append(commonArgs(s), "-user", `{"id":"u1"}`), &out)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), " flags ") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("runStream failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runStream did not return after cancellation")
+	}
+
+	output := out.String()
+	if !strings.Contains(output, "connected") || !strings.Contains(output, `flags {"flag-a":true,"flag-b":false}`) {
+		t.Errorf("unexpected stream output:\n%s", output)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) != 1 {
+		t.Fatalf("expected one stream request, got %d", len(s.requests))
+	}
+	if q := s.requests[0].URL.Query(); q.Get("token") != "test-key" || q.Get("user_id") != "u1" {
+		t.Errorf("expected the stream to be opened for u1 with the API key, got %q", s.requests[0].URL.RawQuery)
+	}
+}
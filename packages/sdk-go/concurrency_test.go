@@ -0,0 +1,122 @@
+package rollgate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// These tests are meant to run under the race detector (go test -race);
+// without it they only check for deadlocks and panics.
+
+// stressGoroutines is the number of goroutines per operation.
+const stressGoroutines = 8
+
+// stress runs each op from stressGoroutines goroutines, iterations times.
+func stress(iterations int, ops ...func(i int)) {
+	var wg sync.WaitGroup
+	for _, op := range ops {
+		for g := 0; g < stressGoroutines; g++ {
+			wg.Add(1)
+			go func(op func(int)) {
+				defer wg.Done()
+				for i := 0; i < iterations; i++ {
+					op(i)
+				}
+			}(op)
+		}
+	}
+	wg.Wait()
+}
+
+func stressMockServer(t *testing.T) *mockServer {
+	return newMockServer(t,
+		&mockFlag{Key: "everyone", Enabled: true, Rollout: 100},
+		&mockFlag{Key: "half", Enabled: true, Rollout: 50},
+		&mockFlag{Key: "pro", Enabled: true, Rules: []mockRule{{
+			ID:         "pro",
+			Conditions: []mockCondition{{Attribute: "plan", Operator: "eq", Value: "pro"}},
+			Rollout:    100,
+		}}},
+	)
+}
+
+func TestConcurrency_EvaluateIdentifyRefresh(t *testing.T) {
+	for _, streaming := range []bool{false, true} {
+		t.Run(fmt.Sprintf("streaming=%v", streaming), func(t *testing.T) {
+			m := stressMockServer(t)
+			config := m.config()
+			config.EnableStreaming = streaming
+			config.FlagMetrics = FlagMetricsConfig{Enabled: true, MaxFlags: 2}
+			config.Exposure.Enabled = true
+			client := newIntegrationClient(t, config)
+			ctx := context.Background()
+
+			stress(20,
+				func(i int) {
+					client.IsEnabled("everyone", false)
+					client.IsEnabledDetail("half", false, WithUser(fmt.Sprint("user-", i)))
+					client.GetString("missing", "default")
+				},
+				func(i int) {
+					plan := []string{"free", "pro"}[i%2]
+					_ = client.Identify(ctx, &UserContext{ID: fmt.Sprint("user-", i), Attributes: map[string]any{"plan": plan}})
+				},
+				func(i int) {
+					_ = client.Refresh(ctx)
+					client.IsReady()
+					client.IsStreaming()
+					client.IsDegraded()
+				},
+				func(i int) {
+					client.Track(NewTrackEvent("everyone", "purchase", fmt.Sprint("user-", i)))
+					_ = client.FlushEvents(ctx)
+					_ = client.FlushTelemetry(ctx)
+				},
+				func(i int) {
+					client.GetAllFlags()
+					client.GetMetrics()
+					client.Snapshot()
+					client.UnusedFlags()
+				},
+				func(i int) {
+					if i%5 == 0 {
+						_ = client.Reset(ctx)
+					}
+				},
+			)
+		})
+	}
+}
+
+func TestConcurrency_InitAndClose(t *testing.T) {
+	for _, streaming := range []bool{false, true} {
+		t.Run(fmt.Sprintf("streaming=%v", streaming), func(t *testing.T) {
+			m := stressMockServer(t)
+			config := m.config()
+			config.EnableStreaming = streaming
+
+			for round := 0; round < 5; round++ {
+				client, err := NewClient(config)
+				if err != nil {
+					t.Fatalf("NewClient failed: %v", err)
+				}
+				ctx := context.Background()
+
+				stress(3,
+					func(int) { _ = client.Init(ctx) },
+					func(int) { client.IsEnabled("everyone", false) },
+					func(int) { _ = client.Identify(ctx, &UserContext{ID: "user-1"}) },
+					func(i int) {
+						if i == 2 {
+							client.Close()
+						}
+					},
+				)
+				// Closing again after the concurrent calls must be harmless
+				client.Close()
+			}
+		})
+	}
+}
@@ -1,29 +1,155 @@
 package rollgate
 
-import "time"
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
 
 // Config holds the configuration for the Rollgate client.
 type Config struct {
-	// APIKey is your Rollgate API key (required)
+	// APIKey is your Rollgate API key (required unless TokenProvider or
+	// Offline is set)
 	APIKey string
 
+	// TokenProvider supplies expiring SDK tokens in place of APIKey. The
+	// token is cached and replaced shortly before it expires, or after the
+	// server rejects it, for flags, streaming, identify, events and
+	// telemetry requests alike (optional)
+	TokenProvider TokenProvider
+
 	// BaseURL is the base URL for Rollgate API (default: https://api.rollgate.io)
 	BaseURL string
 
+	// FallbackBaseURLs are base URLs, typically other regions, tried in
+	// order when the active one fails persistently: a flags fetch still
+	// fails with a network or server error after its retries, or the
+	// circuit breaker is open. The fetch is then repeated against the next
+	// URL, and after the last one the client goes back to BaseURL. Flags,
+	// rules and identify requests follow the active URL; streaming, events
+	// and telemetry keep their own (optional)
+	FallbackBaseURLs []string
+
+	// FailBackInterval is how often the primary BaseURL is probed with a
+	// single flags request while a fallback is active. The client switches
+	// back as soon as the primary serves flags (default: 1m)
+	FailBackInterval time.Duration
+
 	// Timeout is the request timeout (default: 5s)
 	Timeout time.Duration
 
+	// StartupTimeout bounds how long Init blocks fetching the first flags,
+	// including retries (default: 0, bounded only by Init's context). When it
+	// elapses Init returns nil with the client ready but degraded (see
+	// Client.IsDegraded), serving cached flags or defaults until a
+	// background refresh succeeds.
+	StartupTimeout time.Duration
+
+	// StartWaitTimeout makes Init return immediately and initialize in the
+	// background, and is how long WaitForInitialization waits for it at
+	// most (default: 0, Init blocks until initialized). Unlike
+	// StartupTimeout it does not give up on the first fetch: the client is
+	// not ready until the flags arrive or initialization fails.
+	StartWaitTimeout time.Duration
+
+	// User is the initial user context (optional). Flags are fetched for it
+	// from the first request without sending an identify request; call
+	// Client.Identify to change it later.
+	User *UserContext
+
+	// Bootstrap seeds boolean flags with values known at construction time,
+	// typically evaluated for User by the server that rendered the page.
+	// Evaluations return them with a BOOTSTRAP reason until the client is
+	// ready with fetched flags, instead of CLIENT_NOT_READY errors or stale
+	// cached values; fetched flags then replace them all (optional)
+	Bootstrap map[string]bool
+
+	// BootstrapValues seeds string, number and JSON flags like Bootstrap.
+	// A value the accessor cannot convert (GetString on a number) returns
+	// the default with a WRONG_TYPE error, and NewClient rejects values
+	// that cannot be encoded as JSON (optional)
+	BootstrapValues map[string]any
+
+	// EvaluationMode selects where boolean flags are evaluated (default:
+	// EvaluationModeRemote). EvaluationModeLocal fetches targeting rules and
+	// evaluates IsEnabled against the current user without a request per
+	// user; it polls the rules (EnableStreaming is ignored), Identify and
+	// Reset send no request, and string, number and JSON flags return their
+	// defaults.
+	EvaluationMode EvaluationMode
+
+	// Offline serves flags only from FlagsFile and Client.SetOfflineFlags,
+	// for CI and air-gapped environments. No request is ever sent: polling,
+	// streaming, events, telemetry and the cache are disabled, Identify and
+	// Reset only change the user, and APIKey may be empty (default: false)
+	Offline bool
+
+	// FlagsFile is a JSON file of flag values loaded by Init and Refresh in
+	// offline mode: a flags response ({"flags": {...}}) or a plain object
+	// of booleans. See WithFileDataSource (default: "")
+	FlagsFile string
+
+	// OverridesFile is a JSON file of flag values layered over the server's,
+	// for forcing features on in development: a flag in the file always
+	// evaluates to its value, with a warning logged when the file is
+	// loaded, and every other flag comes from the server as usual. It is an
+	// object of values ({"beta": true, "theme": "dark"}), optionally
+	// wrapped in {"flags": {...}}, loaded by Init and reloaded by Refresh
+	// and polling when it changes. An object overriding a JSON flag is
+	// merged into the server's value (default: "")
+	OverridesFile string
+
 	// RefreshInterval is the interval for polling flag updates (default: 30s)
-	// Set to 0 to disable polling (ignored when EnableStreaming is true)
+	// Set to 0 to disable polling (with EnableStreaming, only used while the stream is failing)
 	RefreshInterval time.Duration
 
+	// MaxRefreshInterval caps the polling interval while refreshes keep failing (default: 5m)
+	// Set it equal to RefreshInterval to disable polling backoff
+	MaxRefreshInterval time.Duration
+
+	// RefreshBackoffMultiplier is applied to the polling interval after each failed refresh
+	// and reset on the first successful one (default: 2)
+	RefreshBackoffMultiplier float64
+
+	// SharePolling lets clients in this process share flags requests when
+	// they send identical ones (same BaseURL, credentials, user and headers):
+	// a poll reuses a response another client received within half of
+	// RefreshInterval, and concurrent fetches wait for a single request.
+	// Each client still applies the response and records it in its own
+	// metrics (default: false)
+	SharePolling bool
+
+	// HonorServerHints lets the API tune the client through flags response
+	// headers (X-Rollgate-Poll-Interval, X-Rollgate-Streaming) or the
+	// payload's sdkConfig: a suggested poll interval replaces
+	// RefreshInterval (at least 1s), and a streaming client that is told
+	// streaming is unavailable when it initializes polls instead. Polling
+	// that is disabled stays disabled (default: false)
+	HonorServerHints bool
+
 	// EnableStreaming enables SSE streaming for real-time updates (default: false)
-	// When enabled, polling is disabled and updates are received via SSE
+	// When enabled, polling is disabled and updates are received via SSE,
+	// unless the stream keeps failing (see StreamFallbackThreshold)
 	EnableStreaming bool
 
 	// SSEURL is the URL for SSE streaming (default: same as BaseURL)
 	SSEURL string
 
+	// StreamFallbackThreshold is the number of stream connection attempts in
+	// a row that may fail before a streaming client starts polling every
+	// RefreshInterval. It polls until the stream, which keeps reconnecting,
+	// connects again (default: 3). Set it below 0 to never fall back
+	StreamFallbackThreshold int
+
+	// EventsURL is the endpoint conversion events are posted to (default: BaseURL + /api/v1/sdk/events)
+	// A value without a scheme and host is treated as a path relative to BaseURL
+	EventsURL string
+
+	// TelemetryURL is the endpoint telemetry is posted to (default: BaseURL + /api/v1/sdk/telemetry)
+	// A value without a scheme and host is treated as a path relative to BaseURL
+	TelemetryURL string
+
 	// Retry configuration
 	Retry RetryConfig
 
@@ -33,14 +159,75 @@ type Config struct {
 	// Cache configuration
 	Cache CacheConfig
 
-	// Logger for debug output (optional)
+	// CacheDir is the directory the flag cache is persisted to when
+	// Cache.Persist is set and Cache.PersistencePath is not (default:
+	// DefaultCacheDir(), e.g. $XDG_CACHE_HOME/rollgate or
+	// %LOCALAPPDATA%\rollgate). Setting it implies Cache.Persist. When it
+	// is not writable, as on read-only root filesystems, the cache stays in
+	// memory and a warning is logged.
+	CacheDir string
+
+	// RateLimit configuration for outbound API requests
+	RateLimit RateLimitConfig
+
+	// Transport tunes the connection pool of the default HTTP transport
+	Transport TransportConfig
+
+	// HTTPTransport replaces the default HTTP transport for API requests (optional)
+	// When set, Transport settings are ignored.
+	HTTPTransport http.RoundTripper
+
+	// CustomHeaders are added to every request the SDK sends (polling, SSE,
+	// identify, events and telemetry), e.g. routing or auth headers required
+	// by an internal gateway. They cannot replace Authorization, Content-Type
+	// or the X-SDK-Name/X-SDK-Version headers; a User-Agent here replaces the
+	// default rollgate-go/<version>.
+	CustomHeaders map[string]string
+
+	// Logger receives the client's messages with their context as
+	// alternating key/value arguments (optional, default: discard). Use
+	// NewSlogLogger or NewJSONLogger for structured output
 	Logger Logger
 
-	// Events configuration for conversion tracking
+	// LogLevel is the minimum level passed to Logger (default: LogLevelInfo)
+	LogLevel LogLevel
+
+	// UnusedFlagsLogInterval is how often flags that were never evaluated are
+	// logged at Info level (default: 0, disabled; requires Logger)
+	UnusedFlagsLogInterval time.Duration
+
+	// ConsistencyChecks compares the flag values GetAllFlags serves with
+	// the reasons, versions and typed values evaluations use after every
+	// update, and logs each discrepancy at Warn level with the flag's
+	// versions. A debugging aid: each check walks every flag (default: false)
+	ConsistencyChecks bool
+
+	// RequestObserver is invoked after every outbound API request (optional)
+	RequestObserver RequestObserver
+
+	// Events configuration for conversion tracking. An all-zero value means
+	// defaults (enabled); to disable events start from DefaultConfig and set
+	// Events.Enabled = false
 	Events EventCollectorConfig
 
-	// Telemetry configuration for client-side evaluation stats
+	// Telemetry configuration for client-side evaluation stats. An all-zero
+	// value means defaults (enabled); to disable telemetry start from
+	// DefaultConfig and set Telemetry.Enabled = false
 	Telemetry TelemetryConfig
+
+	// Exposure configuration for automatic exposure events
+	Exposure ExposureConfig
+
+	// EventDedup drops repeated Track calls for the same conversion
+	EventDedup EventDedupConfig
+
+	// FlagMetrics configuration for per-flag evaluation metrics
+	FlagMetrics FlagMetricsConfig
+
+	// TestMode makes retry backoff, polling, stream reconnects and flushes
+	// wait on a manually advanced TestClock, for unit tests of code that
+	// uses the client (default: disabled)
+	TestMode TestModeConfig
 }
 
 // RetryConfig holds retry settings.
@@ -83,6 +270,28 @@ type CacheConfig struct {
 
 	// Enabled controls whether caching is enabled (default: true)
 	Enabled bool
+
+	// PersistencePath is a file the last-known flags, their ETag and fetch
+	// time are written to after every full update, and loaded from by
+	// NewClient, so Init can succeed offline after a restart while the
+	// file is within StaleTTL. Writes are atomic (temp file and rename).
+	// Empty keeps the cache in memory only (default: "")
+	PersistencePath string
+
+	// Persist writes the cache to a file named after the API key in
+	// Config.CacheDir, or the OS cache directory, when PersistencePath is
+	// empty (default: false)
+	Persist bool
+}
+
+// RateLimitConfig holds client-side rate limiting settings.
+// The limit is shared by flag refreshes, identify calls, events and telemetry.
+type RateLimitConfig struct {
+	// RequestsPerSecond is the sustained request rate (default: 0, disabled)
+	RequestsPerSecond float64
+
+	// Burst is the number of requests allowed at once before throttling (default: 10)
+	Burst int
 }
 
 // Logger interface for custom logging.
@@ -103,6 +312,15 @@ func DefaultConfig(apiKey string) Config {
 		Retry:           DefaultRetryConfig(),
 		CircuitBreaker:  DefaultCircuitBreakerConfig(),
 		Cache:           DefaultCacheConfig(),
+		RateLimit:       DefaultRateLimitConfig(),
+		Transport:       DefaultTransportConfig(),
+		Events:          DefaultEventCollectorConfig(),
+		Telemetry:       DefaultTelemetryConfig(),
+
+		MaxRefreshInterval:       5 * time.Minute,
+		RefreshBackoffMultiplier: 2,
+		StreamFallbackThreshold:  3,
+		FailBackInterval:         time.Minute,
 	}
 }
 
@@ -126,6 +344,14 @@ func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
 	}
 }
 
+// DefaultRateLimitConfig returns default rate limit settings (disabled).
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		RequestsPerSecond: 0,
+		Burst:             10,
+	}
+}
+
 // DefaultCacheConfig returns default cache settings.
 func DefaultCacheConfig() CacheConfig {
 	return CacheConfig{
@@ -134,3 +360,17 @@ func DefaultCacheConfig() CacheConfig {
 		Enabled:  true,
 	}
 }
+
+// resolveEndpoint returns the URL for an endpoint, applying an optional override.
+// An empty override uses BaseURL + defaultPath, an absolute URL (with scheme
+// and host) is used as is, and anything else is a path appended to BaseURL,
+// with or without a leading "/".
+func resolveEndpoint(baseURL, override, defaultPath string) string {
+	if override == "" {
+		return baseURL + defaultPath
+	}
+	if u, err := url.Parse(override); err == nil && u.Scheme != "" && u.Host != "" {
+		return override
+	}
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(override, "/")
+}
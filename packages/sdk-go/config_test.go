@@ -0,0 +1,65 @@
+package rollgate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResolveEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string
+		override string
+		want     string
+	}{
+		{"default", "https://api.rollgate.io", "", "https://api.rollgate.io/api/v1/sdk/events"},
+		{"relative path", "https://flags.internal", "/rollgate/api/v1/sdk/events", "https://flags.internal/rollgate/api/v1/sdk/events"},
+		{"relative path with trailing slash base", "https://flags.internal/", "/ingest/events", "https://flags.internal/ingest/events"},
+		{"absolute URL", "https://api.rollgate.io", "https://ingest.internal/events", "https://ingest.internal/events"},
+		{"path without leading slash", "https://flags.internal", "rollgate/events", "https://flags.internal/rollgate/events"},
+		{"host without scheme", "https://flags.internal", "ingest.internal/events", "https://flags.internal/ingest.internal/events"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveEndpoint(tt.baseURL, tt.override, "/api/v1/sdk/events")
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_EventsURLOverride(t *testing.T) {
+	var hits int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ingest/events" {
+			atomic.AddInt32(&hits, 1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         "http://localhost:1",
+		EventsURL:       proxy.URL + "/ingest/events",
+		RefreshInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	client.Track(NewTrackEvent("flag", "event", "user"))
+	if err := client.FlushEvents(context.Background()); err != nil {
+		t.Fatalf("FlushEvents failed: %v", err)
+	}
+	if atomic.LoadInt32(&hits) != 1 {
+		t.Errorf("expected events to be sent to the override URL")
+	}
+}
@@ -0,0 +1,85 @@
+package rollgate
+
+import "sort"
+
+// Update sources named in consistency warnings.
+const (
+	updateSourceFetch  = "fetch"
+	updateSourceStream = "stream"
+	updateSourceTyped  = "typed-fetch"
+	updateSourceCache  = "cache"
+	updateSourceLocal  = "local-rules"
+)
+
+// flagInconsistency is one way the client's flag maps disagree.
+type flagInconsistency struct {
+	flag    string
+	problem string
+}
+
+// checkConsistency logs every flag on which GetAllFlags, evaluations and
+// cached reasons would disagree after an update from source, when
+// Config.ConsistencyChecks is set. withTyped also compares the typed
+// values, which V1 stream events leave to a later fetch.
+func (c *Client) checkConsistency(source string, withTyped bool) {
+	if !c.config.ConsistencyChecks {
+		return
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, issue := range c.flagInconsistencies(withTyped) {
+		meta := c.flagMeta[issue.flag]
+		args := []any{"source", source, "flag", issue.flag, "problem", issue.problem, "version", meta.Version}
+		if typed, ok := c.typedFlags[issue.flag]; ok {
+			args = append(args, "typedVersion", typed.Metadata.Version)
+		}
+		if c.evaluator != nil {
+			args = append(args, "rulesVersion", c.evaluator.version)
+		}
+		c.config.Logger.Warn("flag state inconsistent", args...)
+	}
+}
+
+// flagInconsistencies compares the boolean values GetAllFlags serves with
+// the reasons, metadata and typed values evaluations pair them with.
+// Caller must hold c.mu.
+func (c *Client) flagInconsistencies(withTyped bool) []flagInconsistency {
+	var issues []flagInconsistency
+	add := func(flag, problem string) {
+		issues = append(issues, flagInconsistency{flag, problem})
+	}
+	for key, value := range c.flags {
+		if reason, ok := c.flagReasons[key]; ok && reason.Kind == ReasonOff && value {
+			add(key, "enabled flag has an OFF reason")
+		}
+		if c.evaluator != nil {
+			if detail := c.evaluator.EvaluateDetail(key, c.user, false); detail.Value != value {
+				add(key, "local evaluation disagrees with the flag value")
+			}
+		}
+		if !withTyped || c.typedFlags == nil {
+			continue
+		}
+		typed, ok := c.typedFlags[key]
+		switch {
+		case !ok:
+			add(key, "flag missing from typed values")
+		case typed.Type == FlagTypeBoolean && (typed.Enabled && typed.Value == true) != value:
+			add(key, "typed value disagrees with the flag value")
+		case typed.Metadata.Version != c.flagMeta[key].Version:
+			add(key, "typed value has a different version")
+		}
+	}
+	for key := range c.flagReasons {
+		if _, ok := c.flags[key]; !ok {
+			add(key, "reason cached for a flag without a value")
+		}
+	}
+	for key := range c.flagMeta {
+		if _, ok := c.flags[key]; !ok {
+			add(key, "metadata cached for a flag without a value")
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].flag < issues[j].flag })
+	return issues
+}
@@ -0,0 +1,112 @@
+package rollgate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_ConsistencyChecks(t *testing.T) {
+	t.Run("should log nothing for consistent updates", func(t *testing.T) {
+		m := newMockServer(t,
+			&mockFlag{Key: "beta", Enabled: true, Rollout: 100},
+			&mockFlag{Key: "legacy", Enabled: false},
+		)
+		logger := &recordingLogger{}
+		config := m.config()
+		config.ConsistencyChecks = true
+		config.Logger = logger
+		client := newIntegrationClient(t, config)
+		if err := client.Refresh(context.Background()); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+		if n := logger.count("flag state inconsistent"); n != 0 {
+			t.Errorf("expected no inconsistencies, got %d", n)
+		}
+	})
+
+	// The first response carries reasons and the second does not, so the
+	// client keeps reasons that no longer match its flags
+	var responses atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/sdk/flags" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if responses.Add(1) == 1 {
+			fmt.Fprint(w, `{"flags":{"beta":false,"old":true},"reasons":{"beta":{"kind":"OFF"},"old":{"kind":"FALLTHROUGH"}}}`)
+			return
+		}
+		fmt.Fprint(w, `{"flags":{"beta":true}}`)
+	}))
+	defer server.Close()
+
+	newClient := func(t *testing.T, checks bool) (*Client, *recordingLogger) {
+		logger := &recordingLogger{}
+		client := newIntegrationClient(t, Config{
+			APIKey:            "test-key",
+			BaseURL:           server.URL,
+			RefreshInterval:   time.Hour,
+			ConsistencyChecks: checks,
+			Logger:            logger,
+		})
+		if err := client.Refresh(context.Background()); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+		return client, logger
+	}
+
+	t.Run("should log flags whose reasons diverged", func(t *testing.T) {
+		responses.Store(0)
+		client, logger := newClient(t, true)
+		if n := logger.count("flag state inconsistent"); n != 2 {
+			t.Errorf("expected 2 inconsistencies, got %d", n)
+		}
+		client.mu.RLock()
+		issues := client.flagInconsistencies(false)
+		client.mu.RUnlock()
+		want := []flagInconsistency{
+			{"beta", "enabled flag has an OFF reason"},
+			{"old", "reason cached for a flag without a value"},
+		}
+		if !reflect.DeepEqual(issues, want) {
+			t.Errorf("expected %v, got %v", want, issues)
+		}
+	})
+
+	t.Run("should compare typed values", func(t *testing.T) {
+		responses.Store(1)
+		client, _ := newClient(t, false)
+		client.mu.Lock()
+		client.flagReasons = map[string]EvaluationReason{}
+		client.flagMeta = map[string]FlagMetadata{"beta": {Version: 3}}
+		client.typedFlags = map[string]typedFlag{
+			"beta": {Type: FlagTypeBoolean, Value: false, Enabled: true, Metadata: FlagMetadata{Version: 2}},
+		}
+		issues := client.flagInconsistencies(true)
+		client.typedFlags["beta"] = typedFlag{Type: FlagTypeBoolean, Value: true, Enabled: true, Metadata: FlagMetadata{Version: 2}}
+		stale := client.flagInconsistencies(true)
+		client.mu.Unlock()
+
+		if want := []flagInconsistency{{"beta", "typed value disagrees with the flag value"}}; !reflect.DeepEqual(issues, want) {
+			t.Errorf("expected %v, got %v", want, issues)
+		}
+		if want := []flagInconsistency{{"beta", "typed value has a different version"}}; !reflect.DeepEqual(stale, want) {
+			t.Errorf("expected %v, got %v", want, stale)
+		}
+	})
+
+	t.Run("should not check without ConsistencyChecks", func(t *testing.T) {
+		responses.Store(0)
+		_, logger := newClient(t, false)
+		if n := logger.count("flag state inconsistent"); n != 0 {
+			t.Errorf("expected no checks, got %d", n)
+		}
+	})
+}
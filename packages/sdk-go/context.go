@@ -0,0 +1,114 @@
+package rollgate
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Context kinds with a conventional meaning. Any other kind is targeted
+// the same way; ContextKindUser always names the UserContext itself.
+const (
+	ContextKindUser         = "user"
+	ContextKindOrganization = "org"
+	ContextKindDevice       = "device"
+)
+
+// Context is an evaluation context other than the user, such as the user's
+// organization or device. Targeting rules reach its key and attributes as
+// "<kind>.key" and "<kind>.<attribute>", e.g. "org.plan".
+type Context struct {
+	Kind       string         `json:"kind"`
+	Key        string         `json:"key"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// MultiContext is the set of contexts evaluated along with a user, at most
+// one per kind.
+type MultiContext []Context
+
+// NewMultiContext builds a MultiContext. A later context replaces an
+// earlier one of the same kind; contexts without a kind or of kind
+// ContextKindUser are dropped.
+func NewMultiContext(contexts ...Context) MultiContext {
+	m := make(MultiContext, 0, len(contexts))
+	for _, ctx := range contexts {
+		if ctx.Kind == "" || ctx.Kind == ContextKindUser {
+			continue
+		}
+		replaced := false
+		for i := range m {
+			if m[i].Kind == ctx.Kind {
+				m[i] = ctx
+				replaced = true
+			}
+		}
+		if !replaced {
+			m = append(m, ctx)
+		}
+	}
+	return m
+}
+
+// Get returns the context of kind.
+func (m MultiContext) Get(kind string) (Context, bool) {
+	for _, ctx := range m {
+		if ctx.Kind == kind {
+			return ctx, true
+		}
+	}
+	return Context{}, false
+}
+
+// contextAttributeValue resolves a kind-qualified attribute such as
+// "org.plan" or "user.email". found is false when attribute is not
+// qualified by "user" or by a kind present in user.Contexts, so plain
+// attributes containing dots keep working.
+func contextAttributeValue(attribute string, user *UserContext) (value interface{}, found bool) {
+	kind, name, ok := strings.Cut(attribute, ".")
+	if !ok {
+		return nil, false
+	}
+	if kind == ContextKindUser {
+		return userAttributeValue(name, user), true
+	}
+	ctx, ok := user.Contexts.Get(kind)
+	if !ok {
+		return nil, false
+	}
+	if name == "key" {
+		return ctx.Key, true
+	}
+	return ctx.Attributes[name], true
+}
+
+// setUserContextHeader sends a user with contexts in the X-User-Context
+// header (base64 JSON), since the user_id parameter cannot carry them.
+// Users without contexts are left to user_id and identify.
+func setUserContextHeader(req *http.Request, user *UserContext) {
+	if user == nil || len(user.Contexts) == 0 {
+		return
+	}
+	req.Header.Set("X-User-Context", encodeUserContext(user))
+}
+
+// encodeUserContext returns user as an X-User-Context header value.
+func encodeUserContext(user *UserContext) string {
+	data, _ := json.Marshal(identifyUser(user))
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// identifyUser is the wire form of a user in identify bodies and the
+// X-User-Context header.
+func identifyUser(user *UserContext) map[string]interface{} {
+	body := map[string]interface{}{
+		"id":         user.ID,
+		"email":      user.Email,
+		"attributes": user.Attributes,
+	}
+	if len(user.Contexts) > 0 {
+		body["contexts"] = user.Contexts
+	}
+	return body
+}
@@ -0,0 +1,133 @@
+package rollgate
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestNewMultiContext(t *testing.T) {
+	m := NewMultiContext(
+		Context{Kind: ContextKindOrganization, Key: "org-1"},
+		Context{Kind: ContextKindDevice, Key: "device-1"},
+		Context{Kind: ContextKindUser, Key: "ignored"},
+		Context{Key: "no-kind"},
+		Context{Kind: ContextKindOrganization, Key: "org-2"},
+	)
+
+	if len(m) != 2 {
+		t.Fatalf("expected 2 contexts, got %+v", m)
+	}
+	if org, ok := m.Get(ContextKindOrganization); !ok || org.Key != "org-2" {
+		t.Errorf("expected the later org context to win, got %+v", org)
+	}
+	if _, ok := m.Get(ContextKindUser); ok {
+		t.Error("expected user contexts to be dropped")
+	}
+}
+
+func TestEvaluateFlag_MultiContext(t *testing.T) {
+	user := &UserContext{
+		ID:         "user-1",
+		Email:      "dev@example.com",
+		Attributes: map[string]any{"app.version": "2.0.0"},
+		Contexts: NewMultiContext(
+			Context{Kind: ContextKindOrganization, Key: "acme", Attributes: map[string]any{"plan": "enterprise", "seats": 250}},
+			Context{Kind: ContextKindDevice, Key: "device-42", Attributes: map[string]any{"os": "ios"}},
+		),
+	}
+
+	tests := []struct {
+		name      string
+		condition Condition
+		expected  bool
+	}{
+		{"should match an org attribute", Condition{Attribute: "org.plan", Operator: "eq", Value: "enterprise"}, true},
+		{"should compare org attributes numerically", Condition{Attribute: "org.seats", Operator: "gt", Value: "100"}, true},
+		{"should match a context key", Condition{Attribute: "device.key", Operator: "eq", Value: "device-42"}, true},
+		{"should match a device attribute", Condition{Attribute: "device.os", Operator: "in", Value: "android, ios"}, true},
+		{"should not match a missing context attribute", Condition{Attribute: "org.region", Operator: "is_set"}, false},
+		{"should qualify user attributes", Condition{Attribute: "user.email", Operator: "ends_with", Value: "@example.com"}, true},
+		{"should qualify the user ID", Condition{Attribute: "user.id", Operator: "eq", Value: "user-1"}, true},
+		{"should keep dotted plain attributes", Condition{Attribute: "app.version", Operator: "semver_eq", Value: "2.0.0"}, true},
+		{"should not match a missing kind", Condition{Attribute: "team.key", Operator: "is_set"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := FlagRule{
+				Key:     "contexts",
+				Enabled: true,
+				Rules:   []TargetingRule{{ID: "rule", Enabled: true, Rollout: 100, Conditions: []Condition{tt.condition}}},
+			}
+			if got := EvaluateFlag(rule, user); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestClient_MultiContext(t *testing.T) {
+	m := newMockServer(t, &mockFlag{Key: "enterprise-only", Enabled: true, Rules: []mockRule{{
+		ID:         "enterprise",
+		Conditions: []mockCondition{{Attribute: "org.plan", Operator: "eq", Value: "enterprise"}},
+		Rollout:    100,
+	}}})
+	client := newIntegrationClient(t, m.config())
+	ctx := context.Background()
+
+	user := &UserContext{ID: "user-1", Contexts: NewMultiContext(
+		Context{Kind: ContextKindOrganization, Key: "acme", Attributes: map[string]any{"plan": "enterprise"}},
+	)}
+	if err := client.Identify(ctx, user); err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+	if detail := client.IsEnabledDetail("enterprise-only", false); !detail.Value || detail.Reason.RuleID != "enterprise" {
+		t.Errorf("expected the org rule to match, got %+v", detail)
+	}
+
+	user = &UserContext{ID: "user-1", Contexts: NewMultiContext(
+		Context{Kind: ContextKindOrganization, Key: "startup", Attributes: map[string]any{"plan": "free"}},
+	)}
+	if err := client.Identify(ctx, user); err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+	if client.IsEnabled("enterprise-only", false) {
+		t.Error("expected a free org not to match")
+	}
+}
+
+func TestSetUserContextHeader(t *testing.T) {
+	t.Run("should send users with contexts", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		user := &UserContext{ID: "user-1", Contexts: MultiContext{{Kind: ContextKindDevice, Key: "device-1"}}}
+		setUserContextHeader(req, user)
+
+		data, err := base64.StdEncoding.DecodeString(req.Header.Get("X-User-Context"))
+		if err != nil {
+			t.Fatalf("expected base64, got %q", req.Header.Get("X-User-Context"))
+		}
+		var sent struct {
+			ID       string    `json:"id"`
+			Contexts []Context `json:"contexts"`
+		}
+		if err := json.Unmarshal(data, &sent); err != nil {
+			t.Fatalf("expected JSON, got %s", data)
+		}
+		if sent.ID != "user-1" || !reflect.DeepEqual(sent.Contexts, []Context{{Kind: ContextKindDevice, Key: "device-1"}}) {
+			t.Errorf("unexpected header content %s", data)
+		}
+	})
+
+	t.Run("should leave users without contexts to user_id", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		setUserContextHeader(req, &UserContext{ID: "user-1"})
+		setUserContextHeader(req, nil)
+		if req.Header.Get("X-User-Context") != "" {
+			t.Error("expected no header")
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package rollgate
+
+import "time"
+
+// RegisterDefaults declares application-wide default values for flags, so
+// fallbacks are defined once at startup instead of at every call site.
+//
+// Evaluation methods use the registered default when the caller passes the
+// zero value for its type (false, "", 0 or nil). A default passed explicitly
+// at the call site always wins. Registering a key again replaces its default.
+//
+// Values should be bool (IsEnabled), string (GetString), a numeric type
+// (GetNumber, and GetInt for whole numbers), a time.Duration (GetDuration)
+// or any value (GetJSON). A registered default whose type does not match
+// the evaluation method is ignored by that method.
+func (c *Client) RegisterDefaults(defaults map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.defaults == nil {
+		c.defaults = make(map[string]any, len(defaults))
+	}
+	for k, v := range defaults {
+		c.defaults[k] = v
+	}
+}
+
+// registeredDefault returns the registered default for a flag.
+// Caller must hold c.mu.
+func (c *Client) registeredDefault(flagKey string) (any, bool) {
+	v, ok := c.defaults[flagKey]
+	return v, ok
+}
+
+// boolDefault resolves the default for a boolean evaluation.
+// Caller must hold c.mu.
+func (c *Client) boolDefault(flagKey string, defaultValue bool) bool {
+	if defaultValue {
+		return defaultValue
+	}
+	if v, ok := c.registeredDefault(flagKey); ok {
+		if b, ok := v.(bool); ok {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// stringDefault resolves the default for a string evaluation.
+func (c *Client) stringDefault(flagKey string, defaultValue string) string {
+	if defaultValue != "" {
+		return defaultValue
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if v, ok := c.registeredDefault(flagKey); ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return defaultValue
+}
+
+// numberDefault resolves the default for a numeric evaluation.
+func (c *Client) numberDefault(flagKey string, defaultValue float64) float64 {
+	if defaultValue != 0 {
+		return defaultValue
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if v, ok := c.registeredDefault(flagKey); ok {
+		if n, ok := asNumber(v); ok {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// intDefault resolves the default for an int evaluation.
+func (c *Client) intDefault(flagKey string, defaultValue int) int {
+	if defaultValue != 0 {
+		return defaultValue
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if v, ok := c.registeredDefault(flagKey); ok {
+		if n, ok := intValue(asNumber(v)); ok {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// durationDefault resolves the default for a duration evaluation.
+func (c *Client) durationDefault(flagKey string, defaultValue time.Duration) time.Duration {
+	if defaultValue != 0 {
+		return defaultValue
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if v, ok := c.registeredDefault(flagKey); ok {
+		if d, ok := v.(time.Duration); ok {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// jsonDefault resolves the default for a JSON evaluation.
+func (c *Client) jsonDefault(flagKey string, defaultValue interface{}) interface{} {
+	if defaultValue != nil {
+		return defaultValue
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if v, ok := c.registeredDefault(flagKey); ok {
+		return v
+	}
+	return defaultValue
+}
+
+// asNumber converts any Go numeric type to float64. Unlike toFloat64 it does
+// not parse strings.
+func asNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
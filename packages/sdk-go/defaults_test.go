@@ -0,0 +1,82 @@
+package rollgate
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClient_RegisterDefaults(t *testing.T) {
+	server := newTestServer(map[string]bool{"known-flag": false})
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	client.RegisterDefaults(map[string]any{
+		"missing-flag": true,
+		"known-flag":   true,
+		"banner-text":  "welcome",
+		"max-items":    25,
+		"layout":       map[string]any{"columns": 3},
+		"wrong-type":   "yes",
+	})
+
+	t.Run("should use registered default before initialization", func(t *testing.T) {
+		detail := client.IsEnabledDetail("missing-flag", false)
+		if !detail.Value {
+			t.Error("expected registered default true")
+		}
+		if detail.Reason.Kind != ReasonError {
+			t.Errorf("expected ERROR reason, got %s", detail.Reason.Kind)
+		}
+	})
+
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	t.Run("should use registered default for unknown flag", func(t *testing.T) {
+		if !client.IsEnabled("missing-flag", false) {
+			t.Error("expected registered default true")
+		}
+	})
+
+	t.Run("should prefer server value over registered default", func(t *testing.T) {
+		if client.IsEnabled("known-flag", false) {
+			t.Error("expected server value false")
+		}
+	})
+
+	t.Run("should prefer explicit call-site default", func(t *testing.T) {
+		if client.GetString("banner-text", "hello") != "hello" {
+			t.Error("expected explicit default to win")
+		}
+	})
+
+	t.Run("should resolve typed defaults", func(t *testing.T) {
+		if got := client.GetString("banner-text", ""); got != "welcome" {
+			t.Errorf("expected welcome, got %q", got)
+		}
+		if got := client.GetNumber("max-items", 0); got != 25 {
+			t.Errorf("expected 25, got %v", got)
+		}
+		layout, ok := client.GetJSON("layout", nil).(map[string]any)
+		if !ok || layout["columns"] != 3 {
+			t.Errorf("expected layout default, got %v", layout)
+		}
+	})
+
+	t.Run("should ignore registered default of the wrong type", func(t *testing.T) {
+		if client.IsEnabled("wrong-type", false) {
+			t.Error("expected mismatched default to be ignored")
+		}
+	})
+}
@@ -1,8 +1,12 @@
 package rollgate
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 )
@@ -17,6 +21,9 @@ const (
 	ErrorCategoryRateLimit ErrorCategory = "rate_limit"
 	ErrorCategoryValidation ErrorCategory = "validation"
 	ErrorCategoryServer    ErrorCategory = "server"
+	// ErrorCategoryThrottled is a request held back by the client-side rate
+	// limiter (RateLimitConfig); it never reached the server
+	ErrorCategoryThrottled ErrorCategory = "throttled"
 	ErrorCategoryUnknown   ErrorCategory = "unknown"
 )
 
@@ -40,9 +47,60 @@ func (e *RollgateError) Unwrap() error {
 	return e.Cause
 }
 
+// base returns e; the typed errors embedding RollgateError inherit it, which
+// lets ClassifyError find their RollgateError through errors.As.
+func (e *RollgateError) base() *RollgateError {
+	return e
+}
+
+// NetworkErrorKind tells network failures apart, so an expired certificate
+// is not mistaken for broken DNS.
+type NetworkErrorKind string
+
+const (
+	// NetworkErrorDNS is a failed host name lookup.
+	NetworkErrorDNS NetworkErrorKind = "dns"
+	// NetworkErrorTLS is a failed TLS handshake, such as an expired or
+	// untrusted certificate.
+	NetworkErrorTLS NetworkErrorKind = "tls"
+	// NetworkErrorConnectTimeout is a connection that was not established in time.
+	NetworkErrorConnectTimeout NetworkErrorKind = "connect_timeout"
+	// NetworkErrorReadTimeout is a connected request whose response did not
+	// arrive in time.
+	NetworkErrorReadTimeout NetworkErrorKind = "read_timeout"
+	// NetworkErrorConnection is a refused, reset or unreachable connection.
+	NetworkErrorConnection NetworkErrorKind = "connection"
+	// NetworkErrorOther is any other network failure.
+	NetworkErrorOther NetworkErrorKind = "other"
+)
+
+// NetworkErrorKinds returns every network error kind, in declaration order.
+func NetworkErrorKinds() []NetworkErrorKind {
+	return []NetworkErrorKind{
+		NetworkErrorDNS,
+		NetworkErrorTLS,
+		NetworkErrorConnectTimeout,
+		NetworkErrorReadTimeout,
+		NetworkErrorConnection,
+		NetworkErrorOther,
+	}
+}
+
 // NetworkError represents a network-level error.
 type NetworkError struct {
 	RollgateError
+	Kind NetworkErrorKind
+}
+
+// Error includes the kind, so logs tell a certificate problem from a DNS one.
+func (e *NetworkError) Error() string {
+	if e.Kind == "" || e.Kind == NetworkErrorOther {
+		return e.RollgateError.Error()
+	}
+	if e.Cause != nil {
+		return fmt.Sprintf("%s (%s): %v", e.Message, e.Kind, e.Cause)
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, e.Kind)
 }
 
 // AuthenticationError represents an authentication failure.
@@ -67,6 +125,12 @@ type ServerError struct {
 	RollgateError
 }
 
+// MalformedResponseError is returned when a successful response cannot be
+// parsed: invalid or truncated JSON, or a body that is not JSON at all.
+type MalformedResponseError struct {
+	RollgateError
+}
+
 // CircuitOpenError is returned when the circuit breaker is open.
 type CircuitOpenError struct {
 	RollgateError
@@ -83,9 +147,17 @@ var (
 			Retryable: false,
 		},
 	}
+	ErrClientRateLimited = &RateLimitError{
+		RollgateError: RollgateError{
+			Message:   "client-side rate limit exceeded",
+			Category:  ErrorCategoryThrottled,
+			Retryable: false,
+		},
+	}
 )
 
-// NewNetworkError creates a new network error.
+// NewNetworkError creates a new network error, classifying its kind from
+// cause.
 func NewNetworkError(message string, cause error) *NetworkError {
 	return &NetworkError{
 		RollgateError: RollgateError{
@@ -94,6 +166,7 @@ func NewNetworkError(message string, cause error) *NetworkError {
 			Retryable: true,
 			Cause:     cause,
 		},
+		Kind: networkErrorKind(cause),
 	}
 }
 
@@ -122,6 +195,18 @@ func NewRateLimitError(retryAfter int) *RateLimitError {
 	}
 }
 
+// NewValidationError creates a new validation error for the given field.
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{
+		RollgateError: RollgateError{
+			Message:   message,
+			Category:  ErrorCategoryValidation,
+			Retryable: false,
+		},
+		Field: field,
+	}
+}
+
 // NewServerError creates a new server error.
 func NewServerError(statusCode int, message string) *ServerError {
 	return &ServerError{
@@ -134,16 +219,33 @@ func NewServerError(statusCode int, message string) *ServerError {
 	}
 }
 
+// NewMalformedResponseError creates a new malformed response error.
+func NewMalformedResponseError(message string, cause error) *MalformedResponseError {
+	return &MalformedResponseError{
+		RollgateError: RollgateError{
+			Message:   message,
+			Category:  ErrorCategoryServer,
+			Retryable: false,
+			Cause:     cause,
+		},
+	}
+}
+
 // ClassifyError categorizes an error based on its characteristics.
 func ClassifyError(err error) *RollgateError {
 	if err == nil {
 		return nil
 	}
 
-	// Already a RollgateError
-	var rollgateErr *RollgateError
+	// Held back by the client-side rate limiter
+	if errors.Is(err, ErrClientRateLimited) {
+		return &ErrClientRateLimited.RollgateError
+	}
+
+	// Already a RollgateError, or one of the typed errors embedding it
+	var rollgateErr interface{ base() *RollgateError }
 	if errors.As(err, &rollgateErr) {
-		return rollgateErr
+		return rollgateErr.base()
 	}
 
 	msg := err.Error()
@@ -174,6 +276,78 @@ func ClassifyError(err error) *RollgateError {
 	}
 }
 
+// ClassifyNetworkError returns the kind of a network error, or "" when err
+// is not a network error.
+func ClassifyNetworkError(err error) NetworkErrorKind {
+	if err == nil {
+		return ""
+	}
+	var networkErr *NetworkError
+	if errors.As(err, &networkErr) {
+		return networkErr.Kind
+	}
+	// An open circuit made no request
+	if errors.Is(err, ErrCircuitOpen) || ClassifyError(err).Category != ErrorCategoryNetwork {
+		return ""
+	}
+	return networkErrorKind(err)
+}
+
+// networkErrorKind classifies the cause of a network error from the
+// standard library's error types, falling back to the message for causes
+// that lost them.
+func networkErrorKind(cause error) NetworkErrorKind {
+	if cause == nil {
+		return NetworkErrorOther
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(cause, &dnsErr) {
+		return NetworkErrorDNS
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidCert x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	if errors.As(cause, &certErr) || errors.As(cause, &recordErr) || errors.As(cause, &alertErr) ||
+		errors.As(cause, &unknownAuthority) || errors.As(cause, &invalidCert) || errors.As(cause, &hostnameErr) {
+		return NetworkErrorTLS
+	}
+
+	msg := strings.ToLower(cause.Error())
+	switch {
+	case strings.Contains(msg, "no such host"):
+		return NetworkErrorDNS
+	case strings.Contains(msg, "tls handshake") || strings.Contains(msg, "tls:") ||
+		strings.Contains(msg, "x509:") || strings.Contains(msg, "certificate"):
+		return NetworkErrorTLS
+	}
+
+	// A timeout while dialing never connected; any other timed out once
+	// the request was sent
+	var opErr *net.OpError
+	dialing := errors.As(cause, &opErr) && opErr.Op == "dial"
+	var netErr net.Error
+	timedOut := (errors.As(cause, &netErr) && netErr.Timeout()) ||
+		errors.Is(cause, context.DeadlineExceeded) || strings.Contains(msg, "timeout")
+	if timedOut {
+		if dialing || strings.Contains(msg, "dial tcp") {
+			return NetworkErrorConnectTimeout
+		}
+		return NetworkErrorReadTimeout
+	}
+
+	for _, pattern := range []string{"connection refused", "connection reset", "network is unreachable", "broken pipe", "eof"} {
+		if strings.Contains(msg, pattern) {
+			return NetworkErrorConnection
+		}
+	}
+	return NetworkErrorOther
+}
+
 // IsRetryable checks if an error should be retried.
 func IsRetryable(err error) bool {
 	if err == nil {
@@ -0,0 +1,147 @@
+package rollgate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// timeoutError is a net.Error that timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// roundTripError performs one request to url and returns its error.
+func roundTripError(t *testing.T, client *http.Client, url string) error {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	resp, err := client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected %s to fail", url)
+	}
+	return err
+}
+
+func TestClassifyNetworkError(t *testing.T) {
+	t.Run("should classify DNS failures", func(t *testing.T) {
+		err := requestError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "api.invalid", IsNotFound: true}})
+		if got := ClassifyNetworkError(err); got != NetworkErrorDNS {
+			t.Errorf("expected %s, got %s", NetworkErrorDNS, got)
+		}
+	})
+
+	t.Run("should classify untrusted certificates as TLS", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.Config.ErrorLog = log.New(io.Discard, "", 0)
+		server.StartTLS()
+		defer server.Close()
+
+		err := requestError(roundTripError(t, &http.Client{}, server.URL))
+		if got := ClassifyNetworkError(err); got != NetworkErrorTLS {
+			t.Errorf("expected %s, got %s for %v", NetworkErrorTLS, got, err)
+		}
+		if !strings.Contains(err.Error(), "(tls)") {
+			t.Errorf("expected the kind in the message, got %q", err.Error())
+		}
+	})
+
+	t.Run("should classify dial timeouts as connect timeouts", func(t *testing.T) {
+		err := requestError(&net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}})
+		if got := ClassifyNetworkError(err); got != NetworkErrorConnectTimeout {
+			t.Errorf("expected %s, got %s", NetworkErrorConnectTimeout, got)
+		}
+	})
+
+	t.Run("should classify slow responses as read timeouts", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		err := requestError(roundTripError(t, &http.Client{Timeout: 50 * time.Millisecond}, server.URL))
+		if got := ClassifyNetworkError(err); got != NetworkErrorReadTimeout {
+			t.Errorf("expected %s, got %s for %v", NetworkErrorReadTimeout, got, err)
+		}
+	})
+
+	t.Run("should classify refused connections", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := listener.Addr().String()
+		listener.Close()
+
+		err = requestError(roundTripError(t, &http.Client{}, "http://"+addr))
+		if got := ClassifyNetworkError(err); got != NetworkErrorConnection {
+			t.Errorf("expected %s, got %s for %v", NetworkErrorConnection, got, err)
+		}
+	})
+
+	t.Run("should leave other errors unclassified", func(t *testing.T) {
+		for _, err := range []error{nil, NewServerError(http.StatusBadGateway, "bad gateway"), ErrCircuitOpen, errors.New("boom")} {
+			if got := ClassifyNetworkError(err); got != "" {
+				t.Errorf("expected no kind for %v, got %s", err, got)
+			}
+		}
+	})
+}
+
+func TestClassifyError_TypedErrors(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected ErrorCategory
+	}{
+		{NewNetworkError("request failed", nil), ErrorCategoryNetwork},
+		{NewServerError(http.StatusInternalServerError, "internal"), ErrorCategoryServer},
+		{NewAuthenticationError("invalid key"), ErrorCategoryAuth},
+		{fmt.Errorf("refresh: %w", NewRateLimitError(1)), ErrorCategoryRateLimit},
+	}
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err).Category; got != tt.expected {
+			t.Errorf("ClassifyError(%v) = %s, want %s", tt.err, got, tt.expected)
+		}
+	}
+}
+
+func TestClient_NetworkErrorMetrics(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         "http://" + addr,
+		RefreshInterval: time.Hour,
+		Retry:           RetryConfig{MaxRetries: 1, BaseDelay: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	err = client.Init(context.Background())
+	var networkErr *NetworkError
+	if !errors.As(err, &networkErr) || networkErr.Kind != NetworkErrorConnection {
+		t.Fatalf("expected a connection NetworkError, got %v", err)
+	}
+	if got := client.GetMetrics().NetworkErrorsByKind[NetworkErrorConnection]; got != 1 {
+		t.Errorf("expected 1 connection error in the metrics, got %d", got)
+	}
+}
@@ -22,15 +22,36 @@ type TargetingRule struct {
 	Enabled    bool        `json:"enabled"`
 	Rollout    int         `json:"rollout"`
 	Conditions []Condition `json:"conditions"`
+	// WeightedVariations splits the matching users between variations in
+	// place of Rollout (optional)
+	WeightedVariations []WeightedVariation `json:"weightedVariations,omitempty"`
+}
+
+// WeightedVariation is one arm of a weighted split: users are assigned to
+// it in proportion to Weight among the split's total weight.
+type WeightedVariation struct {
+	ID     string `json:"id"`
+	Weight int    `json:"weight"`
+}
+
+// Prerequisite is a flag that must evaluate to RequiredValue for the user
+// before a dependent flag's targeting is evaluated.
+type Prerequisite struct {
+	FlagKey       string `json:"flagKey"`
+	RequiredValue bool   `json:"requiredValue"`
 }
 
 // FlagRule represents a feature flag with targeting rules.
 type FlagRule struct {
-	Key         string          `json:"key"`
-	Enabled     bool            `json:"enabled"`
-	Rollout     int             `json:"rollout"`
-	TargetUsers []string        `json:"targetUsers,omitempty"`
-	Rules       []TargetingRule `json:"rules,omitempty"`
+	Key           string          `json:"key"`
+	Enabled       bool            `json:"enabled"`
+	Rollout       int             `json:"rollout"`
+	TargetUsers   []string        `json:"targetUsers,omitempty"`
+	Rules         []TargetingRule `json:"rules,omitempty"`
+	Prerequisites []Prerequisite  `json:"prerequisites,omitempty"`
+	// WeightedVariations splits the users reaching the default rollout
+	// between variations in place of Rollout (optional)
+	WeightedVariations []WeightedVariation `json:"weightedVariations,omitempty"`
 }
 
 // RulesPayload represents the rules response from the API.
@@ -50,52 +71,121 @@ type EvaluationResult struct {
 //
 // Evaluation priority:
 // 1. If flag is disabled, return false
-// 2. If user is in targetUsers list, return true
-// 3. If user matches any enabled targeting rule, use rule's rollout
-// 4. Otherwise, use flag's default rollout percentage
+// 2. If a prerequisite flag does not evaluate to its required value, return false
+// 3. If user is in targetUsers list, return true
+// 4. If user matches any enabled targeting rule, use rule's rollout
+// 5. Otherwise, use flag's default rollout percentage
+//
+// A rule or flag with WeightedVariations assigns every user with an ID a
+// variation instead of applying its rollout: the value is true and
+// EvaluateFlagDetail reports the variation in VariationID.
+//
+// A single rule cannot see the flags it depends on, so a flag with
+// prerequisites evaluates to false; use EvaluateFlagInSet or
+// LocalEvaluator for those.
 func EvaluateFlag(rule FlagRule, user *UserContext) bool {
+	return EvaluateFlagDetail(rule, user).Value
+}
+
+// EvaluateFlagDetail evaluates a flag like EvaluateFlag and returns the
+// reason: OFF, PREREQUISITE_FAILED with the failed prerequisite,
+// TARGET_MATCH, RULE_MATCH with the matched rule, or FALLTHROUGH.
+// InRollout reports whether the user fell inside the rollout percentage
+// of the matched rule or of the flag.
+func EvaluateFlagDetail(rule FlagRule, user *UserContext) BoolEvaluationDetail {
+	return evaluateFlagDetail(rule, user, nil, nil)
+}
+
+// EvaluateFlagInSet evaluates the flag flagKey of rules like
+// EvaluateFlagDetail, checking its prerequisites against the other flags
+// of rules. An unknown flag returns false with an UNKNOWN reason.
+func EvaluateFlagInSet(rules map[string]FlagRule, flagKey string, user *UserContext) BoolEvaluationDetail {
+	rule, ok := rules[flagKey]
+	if !ok {
+		return BoolEvaluationDetail{Value: false, Reason: UnknownReason()}
+	}
+	return evaluateFlagDetail(rule, user, rules, nil)
+}
+
+// evaluateFlagDetail evaluates rule, looking up its prerequisites in rules.
+// visiting holds the flags being evaluated further up the prerequisite
+// chain: a prerequisite that is missing, or that depends on the flag
+// itself, fails.
+func evaluateFlagDetail(rule FlagRule, user *UserContext, rules map[string]FlagRule, visiting map[string]bool) BoolEvaluationDetail {
 	// 1. If flag is disabled, always return false
 	if !rule.Enabled {
-		return false
+		return BoolEvaluationDetail{Value: false, Reason: OffReason()}
+	}
+
+	// 2. Check prerequisites, in order
+	if len(rule.Prerequisites) > 0 {
+		if visiting == nil {
+			visiting = make(map[string]bool)
+		}
+		visiting[rule.Key] = true
+		defer delete(visiting, rule.Key)
+		for _, prereq := range rule.Prerequisites {
+			prereqRule, ok := rules[prereq.FlagKey]
+			if !ok || visiting[prereq.FlagKey] ||
+				evaluateFlagDetail(prereqRule, user, rules, visiting).Value != prereq.RequiredValue {
+				return BoolEvaluationDetail{Value: false, Reason: PrerequisiteFailedReason(prereq.FlagKey)}
+			}
+		}
 	}
 
-	// 2. Check if user is in target list
+	// 3. Check if user is in target list
 	if user != nil && user.ID != "" {
 		for _, targetUser := range rule.TargetUsers {
 			if targetUser == user.ID {
-				return true
+				return BoolEvaluationDetail{Value: true, Reason: TargetMatchReason()}
 			}
 		}
 	}
 
-	// 3. Check targeting rules
+	// 4. Check targeting rules
 	if user != nil && len(rule.Rules) > 0 {
-		for _, targetingRule := range rule.Rules {
+		for i, targetingRule := range rule.Rules {
 			if targetingRule.Enabled && matchesRule(targetingRule, user) {
-				if targetingRule.Rollout >= 100 {
-					return true
+				if len(targetingRule.WeightedVariations) > 0 {
+					variation := selectVariation(rule.Key, user.ID, targetingRule.WeightedVariations)
+					return splitDetail(variation, RuleMatchReason(targetingRule.ID, i, variation != ""))
 				}
-				if targetingRule.Rollout <= 0 {
-					return false
+				var inRollout bool
+				switch {
+				case targetingRule.Rollout >= 100:
+					inRollout = true
+				case targetingRule.Rollout <= 0:
+					inRollout = false
+				default:
+					inRollout = isInRollout(rule.Key, user.ID, targetingRule.Rollout)
+				}
+				return BoolEvaluationDetail{
+					Value:  inRollout,
+					Reason: RuleMatchReason(targetingRule.ID, i, inRollout),
 				}
-				return isInRollout(rule.Key, user.ID, targetingRule.Rollout)
 			}
 		}
 	}
 
-	// 4. Default rollout percentage
-	if rule.Rollout >= 100 {
-		return true
-	}
-	if rule.Rollout <= 0 {
-		return false
+	// 5. Default rollout percentage or weighted split. Partial rollouts and
+	// splits use consistent hashing and require a user ID.
+	if len(rule.WeightedVariations) > 0 {
+		var variation string
+		if user != nil {
+			variation = selectVariation(rule.Key, user.ID, rule.WeightedVariations)
+		}
+		return splitDetail(variation, FallthroughReason(variation != ""))
 	}
-
-	// Use consistent hashing for rollout (requires user ID)
-	if user == nil || user.ID == "" {
-		return false
+	var inRollout bool
+	switch {
+	case rule.Rollout >= 100:
+		inRollout = true
+	case rule.Rollout <= 0, user == nil || user.ID == "":
+		inRollout = false
+	default:
+		inRollout = isInRollout(rule.Key, user.ID, rule.Rollout)
 	}
-	return isInRollout(rule.Key, user.ID, rule.Rollout)
+	return BoolEvaluationDetail{Value: inRollout, Reason: FallthroughReason(inRollout)}
 }
 
 // matchesRule checks if a user matches a targeting rule.
@@ -188,11 +278,20 @@ func matchesCondition(condition Condition, user *UserContext) bool {
 	}
 }
 
-// getAttributeValue gets an attribute value from user context.
+// getAttributeValue gets an attribute value from user context. Attributes
+// qualified by a context kind, like "org.plan", come from that context.
 func getAttributeValue(attribute string, user *UserContext) interface{} {
 	if user == nil {
 		return nil
 	}
+	if value, found := contextAttributeValue(attribute, user); found {
+		return value
+	}
+	return userAttributeValue(attribute, user)
+}
+
+// userAttributeValue gets an unqualified attribute value of the user.
+func userAttributeValue(attribute string, user *UserContext) interface{} {
 	switch attribute {
 	case "id":
 		return user.ID
@@ -333,11 +432,41 @@ func isInRollout(flagKey, userID string, percentage int) bool {
 	return int(value) < percentage
 }
 
+// selectVariation assigns the user a variation of a weighted split, by the
+// same hash as isInRollout scaled to the total weight. Users without an ID,
+// and splits without weight, get none.
+func selectVariation(flagKey, userID string, variations []WeightedVariation) string {
+	total := 0
+	for _, v := range variations {
+		total += max(v.Weight, 0)
+	}
+	if total == 0 || userID == "" {
+		return ""
+	}
+
+	hash := sha256.Sum256([]byte(flagKey + ":" + userID))
+	bucket := int(binary.BigEndian.Uint32(hash[:4]) % uint32(total))
+	for _, v := range variations {
+		bucket -= max(v.Weight, 0)
+		if bucket < 0 {
+			return v.ID
+		}
+	}
+	return ""
+}
+
+// splitDetail is the result of a weighted split: true with the selected
+// variation, or false if none was selected.
+func splitDetail(variation string, reason EvaluationReason) BoolEvaluationDetail {
+	reason.VariationID = variation
+	return BoolEvaluationDetail{Value: variation != "", Reason: reason, VariationID: variation}
+}
+
 // EvaluateAllFlags evaluates all flags for a user context.
 func EvaluateAllFlags(rules map[string]FlagRule, user *UserContext) map[string]bool {
 	result := make(map[string]bool)
 	for key, rule := range rules {
-		result[key] = EvaluateFlag(rule, user)
+		result[key] = evaluateFlagDetail(rule, user, rules, nil).Value
 	}
 	return result
 }
@@ -372,7 +501,17 @@ func (e *LocalEvaluator) Evaluate(flagKey string, user *UserContext, defaultValu
 	if !ok {
 		return defaultValue
 	}
-	return EvaluateFlag(rule, user)
+	return evaluateFlagDetail(rule, user, e.rules, nil).Value
+}
+
+// EvaluateDetail evaluates a single flag along with the reason. An unknown
+// flag returns defaultValue with an UNKNOWN reason.
+func (e *LocalEvaluator) EvaluateDetail(flagKey string, user *UserContext, defaultValue bool) BoolEvaluationDetail {
+	rule, ok := e.rules[flagKey]
+	if !ok {
+		return BoolEvaluationDetail{Value: defaultValue, Reason: UnknownReason()}
+	}
+	return evaluateFlagDetail(rule, user, e.rules, nil)
 }
 
 // EvaluateAll evaluates all flags.
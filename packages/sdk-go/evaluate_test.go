@@ -1,6 +1,7 @@
 package rollgate
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -348,3 +349,157 @@ func TestLocalEvaluator(t *testing.T) {
 		t.Error("Unknown flag should return default value")
 	}
 }
+
+func TestEvaluateFlagDetail(t *testing.T) {
+	rule := FlagRule{
+		Key:         "detail-flag",
+		Enabled:     true,
+		Rollout:     0,
+		TargetUsers: []string{"vip"},
+		Rules: []TargetingRule{
+			{ID: "disabled", Enabled: false, Rollout: 100, Conditions: []Condition{{Attribute: "plan", Operator: "eq", Value: "pro"}}},
+			{ID: "pro", Enabled: true, Rollout: 100, Conditions: []Condition{{Attribute: "plan", Operator: "eq", Value: "pro"}}},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		rule   FlagRule
+		user   *UserContext
+		value  bool
+		reason EvaluationReason
+	}{
+		{"should report OFF for a disabled flag", FlagRule{Key: "off", Rollout: 100}, &UserContext{ID: "vip"}, false, OffReason()},
+		{"should report TARGET_MATCH for a target user", rule, &UserContext{ID: "vip"}, true, TargetMatchReason()},
+		{"should report the matched rule and its index", rule, &UserContext{ID: "u1", Attributes: map[string]any{"plan": "pro"}}, true, RuleMatchReason("pro", 1, true)},
+		{"should fall through when no rule matches", rule, &UserContext{ID: "u1"}, false, FallthroughReason(false)},
+		{"should fall through without a user", FlagRule{Key: "all", Enabled: true, Rollout: 100}, nil, true, FallthroughReason(true)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detail := EvaluateFlagDetail(tt.rule, tt.user)
+			if detail.Value != tt.value || detail.Reason != tt.reason {
+				t.Errorf("expected %v with %+v, got %v with %+v", tt.value, tt.reason, detail.Value, detail.Reason)
+			}
+			if EvaluateFlag(tt.rule, tt.user) != detail.Value {
+				t.Error("EvaluateFlag disagrees with EvaluateFlagDetail")
+			}
+		})
+	}
+}
+
+func TestEvaluateFlagInSet_Prerequisites(t *testing.T) {
+	rules := map[string]FlagRule{
+		"billing":  {Key: "billing", Enabled: true, Rollout: 0, TargetUsers: []string{"beta-user"}},
+		"legacy":   {Key: "legacy", Enabled: false},
+		"checkout": {Key: "checkout", Enabled: true, Rollout: 100, Prerequisites: []Prerequisite{{FlagKey: "billing", RequiredValue: true}}},
+		"old-ui":   {Key: "old-ui", Enabled: true, Rollout: 100, Prerequisites: []Prerequisite{{FlagKey: "legacy", RequiredValue: false}}},
+		"upsell":   {Key: "upsell", Enabled: true, Rollout: 100, Prerequisites: []Prerequisite{{FlagKey: "checkout", RequiredValue: true}}},
+		"orphan":   {Key: "orphan", Enabled: true, Rollout: 100, Prerequisites: []Prerequisite{{FlagKey: "deleted", RequiredValue: true}}},
+		"cycle-a":  {Key: "cycle-a", Enabled: true, Rollout: 100, Prerequisites: []Prerequisite{{FlagKey: "cycle-b", RequiredValue: true}}},
+		"cycle-b":  {Key: "cycle-b", Enabled: true, Rollout: 100, Prerequisites: []Prerequisite{{FlagKey: "cycle-a", RequiredValue: true}}},
+		"off":      {Key: "off", Enabled: false, Prerequisites: []Prerequisite{{FlagKey: "billing", RequiredValue: true}}},
+	}
+	beta := &UserContext{ID: "beta-user"}
+	other := &UserContext{ID: "other-user"}
+
+	tests := []struct {
+		name   string
+		flag   string
+		user   *UserContext
+		value  bool
+		reason EvaluationReason
+	}{
+		{"should evaluate the flag when its prerequisite passes", "checkout", beta, true, FallthroughReason(true)},
+		{"should fail when the prerequisite has another value", "checkout", other, false, PrerequisiteFailedReason("billing")},
+		{"should accept a required false value", "old-ui", other, true, FallthroughReason(true)},
+		{"should check prerequisites transitively", "upsell", other, false, PrerequisiteFailedReason("checkout")},
+		{"should pass transitive prerequisites", "upsell", beta, true, FallthroughReason(true)},
+		{"should fail on a missing prerequisite", "orphan", beta, false, PrerequisiteFailedReason("deleted")},
+		{"should fail on a cycle", "cycle-a", beta, false, PrerequisiteFailedReason("cycle-b")},
+		{"should report OFF before checking prerequisites", "off", beta, false, OffReason()},
+		{"should report UNKNOWN for a missing flag", "nope", beta, false, UnknownReason()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detail := EvaluateFlagInSet(rules, tt.flag, tt.user)
+			if detail.Value != tt.value || detail.Reason != tt.reason {
+				t.Errorf("expected %v with %+v, got %v with %+v", tt.value, tt.reason, detail.Value, detail.Reason)
+			}
+		})
+	}
+
+	t.Run("should fail prerequisites of a single rule", func(t *testing.T) {
+		if detail := EvaluateFlagDetail(rules["checkout"], beta); detail.Value || detail.Reason != PrerequisiteFailedReason("billing") {
+			t.Errorf("expected a failed prerequisite, got %+v", detail)
+		}
+	})
+
+	t.Run("should check prerequisites in the local evaluator", func(t *testing.T) {
+		evaluator := NewLocalEvaluator()
+		evaluator.SetRules(RulesPayload{Flags: rules})
+		if !evaluator.Evaluate("checkout", beta, false) || evaluator.Evaluate("checkout", other, true) {
+			t.Error("expected checkout to follow billing")
+		}
+		if all := EvaluateAllFlags(rules, beta); !all["upsell"] {
+			t.Error("expected EvaluateAllFlags to check prerequisites")
+		}
+	})
+}
+
+func TestEvaluateFlag_WeightedVariations(t *testing.T) {
+	split := []WeightedVariation{{ID: "control", Weight: 50}, {ID: "treatment-a", Weight: 30}, {ID: "treatment-b", Weight: 20}}
+	rule := FlagRule{
+		Key:                "experiment",
+		Enabled:            true,
+		WeightedVariations: split,
+		Rules: []TargetingRule{{
+			ID:                 "beta",
+			Enabled:            true,
+			Conditions:         []Condition{{Attribute: "beta", Operator: "eq", Value: "true"}},
+			WeightedVariations: []WeightedVariation{{ID: "beta-only", Weight: 1}},
+		}},
+	}
+
+	t.Run("should split users by weight", func(t *testing.T) {
+		counts := make(map[string]int)
+		totalUsers := 10000
+		for i := 0; i < totalUsers; i++ {
+			detail := EvaluateFlagDetail(rule, &UserContext{ID: fmt.Sprintf("user-%d", i)})
+			if !detail.Value || detail.Reason != (EvaluationReason{Kind: ReasonFallthrough, InRollout: true, VariationID: detail.VariationID}) {
+				t.Fatalf("expected a FALLTHROUGH into a variation, got %+v", detail)
+			}
+			counts[detail.VariationID]++
+		}
+		for _, v := range split {
+			percentage := float64(counts[v.ID]) / float64(totalUsers) * 100
+			if percentage < float64(v.Weight)-3 || percentage > float64(v.Weight)+3 {
+				t.Errorf("expected about %d%% in %s, got %.2f%%", v.Weight, v.ID, percentage)
+			}
+		}
+	})
+
+	t.Run("should assign a user the same variation every time", func(t *testing.T) {
+		user := &UserContext{ID: "user-42"}
+		first := EvaluateFlagDetail(rule, user).VariationID
+		for i := 0; i < 100; i++ {
+			if got := EvaluateFlagDetail(rule, user).VariationID; got != first {
+				t.Fatalf("expected %s, got %s", first, got)
+			}
+		}
+	})
+
+	t.Run("should use the split of a matching rule", func(t *testing.T) {
+		detail := EvaluateFlagDetail(rule, &UserContext{ID: "user-1", Attributes: map[string]any{"beta": "true"}})
+		if detail.VariationID != "beta-only" || detail.Reason.Kind != ReasonRuleMatch || detail.Reason.RuleID != "beta" {
+			t.Errorf("expected the rule's variation, got %+v", detail)
+		}
+	})
+
+	t.Run("should select no variation without a user", func(t *testing.T) {
+		detail := EvaluateFlagDetail(rule, nil)
+		if detail.Value || detail.VariationID != "" || detail.Reason != FallthroughReason(false) {
+			t.Errorf("expected false without a variation, got %+v", detail)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package rollgate
+
+import "sync"
+
+// maxEvaluationContexts bounds how many flag/user pairs the client
+// remembers the last evaluation of. The oldest pair is forgotten first.
+const maxEvaluationContexts = 10000
+
+// evaluationContext is what a tracked event inherits from the most recent
+// evaluation of its flag for its user.
+type evaluationContext struct {
+	variationID string
+	reason      EvaluationReason
+}
+
+// evaluationContexts remembers the most recent evaluation per flag and
+// user, so Track can attribute a conversion to the variation the user saw.
+type evaluationContexts struct {
+	mu      sync.Mutex
+	max     int
+	entries map[string]evaluationContext
+	order   []string // Keys in first-recorded order, for eviction
+}
+
+func newEvaluationContexts(max int) *evaluationContexts {
+	return &evaluationContexts{max: max, entries: make(map[string]evaluationContext)}
+}
+
+func evaluationContextKey(flagKey, userID string) string {
+	return flagKey + "\x00" + userID
+}
+
+// record stores the evaluation of flagKey for userID, replacing an earlier
+// one.
+func (e *evaluationContexts) record(flagKey, userID string, ctx evaluationContext) {
+	key := evaluationContextKey(flagKey, userID)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.entries[key]; !ok {
+		if len(e.order) >= e.max {
+			delete(e.entries, e.order[0])
+			e.order = e.order[1:]
+		}
+		e.order = append(e.order, key)
+	}
+	e.entries[key] = ctx
+}
+
+// lookup returns the most recent evaluation of flagKey for userID.
+func (e *evaluationContexts) lookup(flagKey, userID string) (evaluationContext, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ctx, ok := e.entries[evaluationContextKey(flagKey, userID)]
+	return ctx, ok
+}
+
+// withEvaluationContext fills in an event's variation and reason from the
+// last evaluation of its flag for its user, unless the caller set the
+// variation or opted out.
+func (c *Client) withEvaluationContext(opts TrackEventOptions) TrackEventOptions {
+	if c.evalContexts == nil || opts.NoEvaluationContext || opts.VariationID != "" {
+		return opts
+	}
+	ctx, ok := c.evalContexts.lookup(opts.FlagKey, opts.UserID)
+	if !ok {
+		return opts
+	}
+	opts.VariationID = ctx.variationID
+	if opts.Reason == nil {
+		reason := ctx.reason
+		opts.Reason = &reason
+	}
+	return opts
+}
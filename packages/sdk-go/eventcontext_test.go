@@ -0,0 +1,126 @@
+package rollgate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEvaluationContexts(t *testing.T) {
+	contexts := newEvaluationContexts(2)
+	contexts.record("flag-a", "user-1", evaluationContext{variationID: "true"})
+	contexts.record("flag-b", "user-1", evaluationContext{variationID: "false"})
+	contexts.record("flag-a", "user-1", evaluationContext{variationID: "false"})
+
+	t.Run("should keep the most recent evaluation", func(t *testing.T) {
+		if ctx, ok := contexts.lookup("flag-a", "user-1"); !ok || ctx.variationID != "false" {
+			t.Errorf("expected variation 'false', got %+v", ctx)
+		}
+		if _, ok := contexts.lookup("flag-a", "user-2"); ok {
+			t.Error("expected no evaluation for another user")
+		}
+	})
+
+	t.Run("should forget the oldest pair when full", func(t *testing.T) {
+		contexts.record("flag-c", "user-1", evaluationContext{variationID: "true"})
+		if _, ok := contexts.lookup("flag-a", "user-1"); ok {
+			t.Error("expected flag-a to be forgotten")
+		}
+		if _, ok := contexts.lookup("flag-c", "user-1"); !ok {
+			t.Error("expected flag-c to be remembered")
+		}
+	})
+}
+
+func TestClient_TrackEvaluationContext(t *testing.T) {
+	server := newTestServer(map[string]bool{"flag-a": true})
+	defer server.Close()
+
+	client := newIntegrationClient(t, Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		User:            &UserContext{ID: "user-1"},
+		Events:          EventCollectorConfig{Enabled: true, FlushIntervalMs: 60000, MaxBufferSize: 100},
+	})
+
+	client.IsEnabled("flag-a", false)
+	client.IsEnabled("flag-a", false, WithUser("user-2"))
+	client.Track(NewTrackEvent("flag-a", "purchase", "user-1"))
+	client.Track(NewTrackEvent("flag-a", "purchase", "user-3"))
+	client.Track(NewTrackEvent("flag-a", "purchase", "user-1").WithVariation("manual"))
+	client.Track(NewTrackEvent("flag-a", "purchase", "user-1").WithoutEvaluationContext())
+	client.Track(NewTrackEvent("flag-a", "purchase", "user-2"))
+
+	client.eventCollector.mu.Lock()
+	events := append([]bufferedEvent(nil), client.eventCollector.buffer...)
+	client.eventCollector.mu.Unlock()
+	if len(events) != 5 {
+		t.Fatalf("expected 5 events, got %d", len(events))
+	}
+
+	t.Run("should attach the last evaluation for the user", func(t *testing.T) {
+		if events[0].VariationID != "true" || events[0].Reason == nil || events[0].Reason.Kind != ReasonFallthrough {
+			t.Errorf("expected variation 'true' with a FALLTHROUGH reason, got %+v", events[0])
+		}
+		if events[4].VariationID != "true" || events[4].Reason == nil {
+			t.Errorf("expected the override user's evaluation, got %+v", events[4])
+		}
+	})
+
+	t.Run("should leave events without an evaluation unchanged", func(t *testing.T) {
+		if events[1].VariationID != "" || events[1].Reason != nil {
+			t.Errorf("expected no variation for an unevaluated user, got %+v", events[1])
+		}
+	})
+
+	t.Run("should keep a variation set by the caller", func(t *testing.T) {
+		if events[2].VariationID != "manual" || events[2].Reason != nil {
+			t.Errorf("expected only the manual variation, got %+v", events[2])
+		}
+	})
+
+	t.Run("should respect the opt-out", func(t *testing.T) {
+		if events[3].VariationID != "" || events[3].Reason != nil {
+			t.Errorf("expected no evaluation context, got %+v", events[3])
+		}
+	})
+}
+
+func TestClient_TrackExperimentVariation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"flags":   map[string]bool{"experiment": true},
+			"reasons": map[string]interface{}{"experiment": map[string]interface{}{"kind": "FALLTHROUGH", "inRollout": true, "variationId": "treatment-a"}},
+		})
+	}))
+	defer server.Close()
+
+	client := newIntegrationClient(t, Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		User:            &UserContext{ID: "user-1"},
+		Events:          EventCollectorConfig{Enabled: true, FlushIntervalMs: 60000, MaxBufferSize: 100},
+	})
+
+	t.Run("should report the server's variation in the detail", func(t *testing.T) {
+		detail := client.IsEnabledDetail("experiment", false)
+		if !detail.Value || detail.VariationID != "treatment-a" {
+			t.Errorf("expected variation treatment-a, got %+v", detail)
+		}
+	})
+
+	t.Run("should attach the variation to tracked events", func(t *testing.T) {
+		client.Track(NewTrackEvent("experiment", "purchase", "user-1"))
+		client.eventCollector.mu.Lock()
+		events := append([]bufferedEvent(nil), client.eventCollector.buffer...)
+		client.eventCollector.mu.Unlock()
+		if len(events) != 1 || events[0].VariationID != "treatment-a" {
+			t.Errorf("expected an event for treatment-a, got %+v", events)
+		}
+	})
+}
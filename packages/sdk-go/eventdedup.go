@@ -0,0 +1,72 @@
+package rollgate
+
+import (
+	"sync"
+	"time"
+)
+
+// EventDedupConfig configures deduplication of conversion events, so retry
+// loops in application code do not count a conversion twice.
+type EventDedupConfig struct {
+	// Enabled drops Track events identical to one tracked within Window:
+	// same flag, event name, user and variation (default: false)
+	Enabled bool
+
+	// Window is how long a tracked event suppresses identical ones (default: 10s)
+	Window time.Duration
+}
+
+// DefaultEventDedupConfig returns default event deduplication settings (disabled).
+func DefaultEventDedupConfig() EventDedupConfig {
+	return EventDedupConfig{
+		Enabled: false,
+		Window:  10 * time.Second,
+	}
+}
+
+// eventDedupKey identifies events that count as the same conversion.
+// Value and metadata are deliberately ignored: a retried call may carry a
+// different timestamp or request ID in its metadata.
+func eventDedupKey(opts TrackEventOptions) string {
+	return opts.FlagKey + "\x00" + opts.EventName + "\x00" + opts.UserID + "\x00" + opts.VariationID
+}
+
+// recentKeys remembers keys for a window so repeats within it can be dropped.
+type recentKeys struct {
+	mu        sync.Mutex
+	window    time.Duration
+	seen      map[string]time.Time
+	lastPrune time.Time
+}
+
+func newRecentKeys(window time.Duration) *recentKeys {
+	return &recentKeys{
+		window:    window,
+		seen:      make(map[string]time.Time),
+		lastPrune: time.Now(),
+	}
+}
+
+// add returns true if key was not seen within the window, and marks it as
+// seen. A repeat does not extend the window.
+func (r *recentKeys) add(key string) bool {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Sub(r.lastPrune) > r.window {
+		for k, at := range r.seen {
+			if now.Sub(at) > r.window {
+				delete(r.seen, k)
+			}
+		}
+		r.lastPrune = now
+	}
+
+	if at, ok := r.seen[key]; ok && now.Sub(at) <= r.window {
+		return false
+	}
+	r.seen[key] = now
+	return true
+}
@@ -0,0 +1,67 @@
+package rollgate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClient_EventDedup(t *testing.T) {
+	newClient := func(t *testing.T, dedup EventDedupConfig) *Client {
+		t.Helper()
+		client, err := NewClient(Config{
+			APIKey:          "test-key",
+			BaseURL:         "http://localhost:1",
+			RefreshInterval: time.Hour,
+			EventDedup:      dedup,
+		})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		t.Cleanup(client.Close)
+		return client
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		client := newClient(t, EventDedupConfig{})
+		for i := 0; i < 2; i++ {
+			client.Track(NewTrackEvent("flag", "purchase", "user-1"))
+		}
+		if got := client.eventCollector.GetBufferSize(); got != 2 {
+			t.Errorf("expected 2 buffered events, got %d", got)
+		}
+	})
+
+	t.Run("drops identical events within the window", func(t *testing.T) {
+		client := newClient(t, EventDedupConfig{Enabled: true})
+		event := NewTrackEvent("flag", "purchase", "user-1").WithVariation("b")
+
+		client.Track(event)
+		if err := client.TrackValidated(event.WithValue(10)); err != nil {
+			t.Errorf("duplicates should not be an error, got %v", err)
+		}
+		client.Track(event.WithVariation("a"))
+		client.Track(NewTrackEvent("flag", "purchase", "user-2").WithVariation("b"))
+		client.Track(NewTrackEvent("flag", "signup", "user-1").WithVariation("b"))
+
+		if got := client.eventCollector.GetBufferSize(); got != 4 {
+			t.Errorf("expected 4 buffered events, got %d", got)
+		}
+		if got := client.GetMetrics().DedupedEvents; got != 1 {
+			t.Errorf("expected 1 deduplicated event, got %d", got)
+		}
+	})
+
+	t.Run("tracks again after the window", func(t *testing.T) {
+		client := newClient(t, EventDedupConfig{Enabled: true, Window: 10 * time.Millisecond})
+		client.Track(NewTrackEvent("flag", "purchase", "user-1"))
+		time.Sleep(15 * time.Millisecond)
+		client.Track(NewTrackEvent("flag", "purchase", "user-1"))
+
+		if got := client.eventCollector.GetBufferSize(); got != 2 {
+			t.Errorf("expected 2 buffered events, got %d", got)
+		}
+		if got := client.GetMetrics().DedupedEvents; got != 0 {
+			t.Errorf("expected no deduplicated events, got %d", got)
+		}
+	})
+}
@@ -4,27 +4,123 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
 
-// TrackEventOptions holds the data for a conversion event.
+const (
+	// eventFlushTimeout bounds each flush request.
+	eventFlushTimeout = 10 * time.Second
+	// defaultEventRetryBaseDelay is the delay before the first flush retry.
+	defaultEventRetryBaseDelay = 200 * time.Millisecond
+	// maxEventRetryDelay caps the delay between flush retries.
+	maxEventRetryDelay = 30 * time.Second
+	// defaultMaxSpillEvents caps the events kept in the spill file.
+	defaultMaxSpillEvents = 10000
+)
+
+// TrackEventOptions holds the data for a conversion event. When
+// VariationID is empty, Client.Track fills it and Reason in from the most
+// recent evaluation of the flag for the user, unless NoEvaluationContext is
+// set.
 type TrackEventOptions struct {
-	FlagKey     string         `json:"flagKey"`
-	EventName   string         `json:"eventName"`
-	UserID      string         `json:"userId"`
-	VariationID string         `json:"variationId,omitempty"`
-	Value       *float64       `json:"value,omitempty"`
-	Metadata    map[string]any `json:"metadata,omitempty"`
+	FlagKey     string            `json:"flagKey"`
+	EventName   string            `json:"eventName"`
+	UserID      string            `json:"userId"`
+	VariationID string            `json:"variationId,omitempty"`
+	Reason      *EvaluationReason `json:"reason,omitempty"`
+	Value       *float64          `json:"value,omitempty"`
+	Metadata    map[string]any    `json:"metadata,omitempty"`
+
+	// NoEvaluationContext sends the event with only the variation and
+	// reason set here
+	NoEvaluationContext bool `json:"-"`
+}
+
+// NewTrackEvent creates TrackEventOptions with the required fields set.
+// Optional fields can be added with the With* methods.
+func NewTrackEvent(flagKey, eventName, userID string) TrackEventOptions {
+	return TrackEventOptions{
+		FlagKey:   flagKey,
+		EventName: eventName,
+		UserID:    userID,
+	}
+}
+
+// WithVariation returns a copy of the options with the variation ID set.
+func (o TrackEventOptions) WithVariation(variationID string) TrackEventOptions {
+	o.VariationID = variationID
+	return o
+}
+
+// WithoutEvaluationContext returns a copy of the options that Track sends
+// without the variation and reason of the flag's last evaluation.
+func (o TrackEventOptions) WithoutEvaluationContext() TrackEventOptions {
+	o.NoEvaluationContext = true
+	return o
+}
+
+// WithValue returns a copy of the options with the numeric value set.
+func (o TrackEventOptions) WithValue(value float64) TrackEventOptions {
+	o.Value = &value
+	return o
+}
+
+// WithMetadata returns a copy of the options with a metadata entry added.
+func (o TrackEventOptions) WithMetadata(key string, value any) TrackEventOptions {
+	metadata := make(map[string]any, len(o.Metadata)+1)
+	for k, v := range o.Metadata {
+		metadata[k] = v
+	}
+	metadata[key] = value
+	o.Metadata = metadata
+	return o
+}
+
+// Validate checks that all required fields are set.
+func (o TrackEventOptions) Validate() error {
+	switch {
+	case strings.TrimSpace(o.FlagKey) == "":
+		return NewValidationError("flagKey", "flagKey is required")
+	case strings.TrimSpace(o.EventName) == "":
+		return NewValidationError("eventName", "eventName is required")
+	case strings.TrimSpace(o.UserID) == "":
+		return NewValidationError("userId", "userId is required")
+	}
+	return nil
 }
 
 // EventCollectorConfig configures the event collector.
 type EventCollectorConfig struct {
 	FlushIntervalMs int
 	MaxBufferSize   int
-	Enabled         bool
+	// Enabled controls whether events are collected (default: true). When
+	// false the client builds no collector: no goroutine and no requests.
+	Enabled bool
+
+	// MaxRetries is how many times a flush failing with a network error, a
+	// 429 or a 5xx is retried, with exponential backoff, before its events
+	// go back to the buffer (default: 2). The final flush on Stop is not
+	// retried.
+	MaxRetries int
+	// RetryBaseDelay is the delay before the first retry, doubled for each
+	// retry after it (default: 200ms)
+	RetryBaseDelay time.Duration
+	// SpillFile, if set, is a file that keeps undelivered events: those the
+	// buffer cannot hold after failed flushes and those still buffered when
+	// the collector stops. The next Start, in this process or a later one,
+	// sends them again.
+	SpillFile string
+	// MaxSpillEvents caps the events kept in SpillFile; more are dropped
+	// (default: 10000)
+	MaxSpillEvents int
 }
 
 // DefaultEventCollectorConfig returns default event collector configuration.
@@ -33,17 +129,31 @@ func DefaultEventCollectorConfig() EventCollectorConfig {
 		FlushIntervalMs: 30000,
 		MaxBufferSize:   100,
 		Enabled:         true,
+		MaxRetries:      2,
 	}
 }
 
+// EventStats describes the event buffer and the most recent flush, to tell
+// whether flushes are keeping up.
+type EventStats struct {
+	Buffered        int       // Events waiting for the next flush
+	Delivered       int64     // Events accepted by the events endpoint
+	Spilled         int64     // Undelivered events written to the spill file
+	Dropped         int64     // Undelivered events discarded because the buffer and spill file were full
+	LastFlushTime   time.Time // When the last flush request completed (zero before the first)
+	LastFlushStatus int       // HTTP status of the last flush; 0 if it got no response
+	LastFlushError  error     // Error of the last flush; nil if it succeeded
+}
+
 type bufferedEvent struct {
-	FlagKey     string         `json:"flagKey"`
-	EventName   string         `json:"eventName"`
-	UserID      string         `json:"userId"`
-	VariationID string         `json:"variationId,omitempty"`
-	Value       *float64       `json:"value,omitempty"`
-	Metadata    map[string]any `json:"metadata,omitempty"`
-	Timestamp   string         `json:"timestamp"`
+	FlagKey     string            `json:"flagKey"`
+	EventName   string            `json:"eventName"`
+	UserID      string            `json:"userId"`
+	VariationID string            `json:"variationId,omitempty"`
+	Reason      *EvaluationReason `json:"reason,omitempty"`
+	Value       *float64          `json:"value,omitempty"`
+	Metadata    map[string]any    `json:"metadata,omitempty"`
+	Timestamp   string            `json:"timestamp"`
 }
 
 // EventCollector buffers and batches conversion events.
@@ -51,11 +161,25 @@ type EventCollector struct {
 	mu       sync.Mutex
 	config   EventCollectorConfig
 	endpoint string
-	apiKey   string
+	tokens   *tokenSource
 	client   *http.Client
 	buffer   []bufferedEvent
 	stop     chan struct{}
 	stopped  bool
+	observer RequestObserver
+	clock    *serverClock
+	headers  map[string]string
+	ctx      context.Context // Parent of background flush requests
+	stats    EventStats      // Buffered is filled in by Stats
+	metrics  *SDKMetrics
+	retryer  *Retryer
+	timers   timeSource // Flush ticker; the TestClock in test mode
+
+	rateLimits *rateLimitTracker // Records 429 responses; may be nil
+
+	spillMu     sync.Mutex // Serializes spill file access; taken before mu
+	spilled     int        // Events this collector wrote to the spill file
+	spillUnread bool       // The spill file may hold events of an earlier collector
 }
 
 // NewEventCollector creates a new event collector.
@@ -63,22 +187,126 @@ func NewEventCollector(endpoint, apiKey string, config EventCollectorConfig, htt
 	return &EventCollector{
 		config:   config,
 		endpoint: endpoint,
-		apiKey:   apiKey,
+		tokens:   newTokenSource(apiKey, nil),
 		client:   httpClient,
 		buffer:   make([]bufferedEvent, 0, config.MaxBufferSize),
 		stop:     make(chan struct{}),
+		ctx:      context.Background(),
+		retryer:  newEventRetryer(config),
+		timers:   systemTime{},
+
+		spillUnread: config.SpillFile != "",
 	}
 }
 
-// Start begins the periodic flush goroutine.
+// newEventRetryer returns the retryer of event flushes.
+func newEventRetryer(config EventCollectorConfig) *Retryer {
+	baseDelay := config.RetryBaseDelay
+	if baseDelay <= 0 {
+		baseDelay = defaultEventRetryBaseDelay
+	}
+	return NewRetryer(RetryConfig{
+		MaxRetries:   max(config.MaxRetries, 0),
+		BaseDelay:    baseDelay,
+		MaxDelay:     maxEventRetryDelay,
+		JitterFactor: 0.1,
+	})
+}
+
+// SetRequestObserver sets a callback invoked after each flush request.
+func (ec *EventCollector) SetRequestObserver(fn RequestObserver) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.observer = fn
+}
+
+// setHeaders sets extra headers sent with each flush request.
+func (ec *EventCollector) setHeaders(headers map[string]string) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.headers = headers
+}
+
+// setTokenSource makes flushes authorize with the client's tokens, so a
+// TokenProvider covers events too.
+func (ec *EventCollector) setTokenSource(ts *tokenSource) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.tokens = ts
+}
+
+// setContext sets the parent context of periodic, threshold and final
+// flushes, so they are cancelled with the client.
+func (ec *EventCollector) setContext(ctx context.Context) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.ctx = ctx
+}
+
+// parentContext returns the parent context of background flushes.
+func (ec *EventCollector) parentContext() context.Context {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	return ec.ctx
+}
+
+// setMetrics sets the metrics that record delivered and undelivered events.
+func (ec *EventCollector) setMetrics(m *SDKMetrics) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.metrics = m
+}
+
+// setRateLimits makes flushes report 429 responses to the client's
+// RateLimitState.
+func (ec *EventCollector) setRateLimits(t *rateLimitTracker) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.rateLimits = t
+}
+
+// setClock makes event timestamps follow the server's clock.
+func (ec *EventCollector) setClock(clock *serverClock) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.clock = clock
+}
+
+// setTimeSource makes flushes and their retries wait on ts, without
+// jitter. It must be called before Start.
+func (ec *EventCollector) setTimeSource(ts timeSource) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.timers = ts
+	ec.retryer.config.JitterFactor = 0
+	ec.retryer.timers = ts
+}
+
+// now returns the timestamp for a new event.
+func (ec *EventCollector) now() time.Time {
+	ec.mu.Lock()
+	clock := ec.clock
+	ec.mu.Unlock()
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.now()
+}
+
+// Start begins the periodic flush goroutine. Events left in the spill file
+// are sent right away.
 func (ec *EventCollector) Start() {
 	if !ec.config.Enabled {
 		return
 	}
+	if ec.restoreSpilled() > 0 {
+		go func() { _ = ec.Flush(ec.parentContext()) }()
+	}
 	go ec.flushLoop()
 }
 
-// Stop flushes remaining events and stops the collector.
+// Stop flushes remaining events and stops the collector. Events the final
+// flush cannot deliver are written to the spill file, if configured.
 func (ec *EventCollector) Stop() {
 	ec.mu.Lock()
 	if ec.stopped {
@@ -89,8 +317,17 @@ func (ec *EventCollector) Stop() {
 	ec.mu.Unlock()
 
 	close(ec.stop)
-	// Best-effort final flush
-	_ = ec.Flush()
+	// Best-effort final flush, not retried so Close does not wait on an
+	// unreachable server
+	_ = ec.flush(ec.parentContext(), false)
+
+	if ec.config.SpillFile != "" {
+		ec.mu.Lock()
+		events := ec.buffer
+		ec.buffer = nil
+		ec.mu.Unlock()
+		ec.spill(events)
+	}
 }
 
 // Track adds an event to the buffer.
@@ -104,9 +341,10 @@ func (ec *EventCollector) Track(opts TrackEventOptions) {
 		EventName:   opts.EventName,
 		UserID:      opts.UserID,
 		VariationID: opts.VariationID,
+		Reason:      opts.Reason,
 		Value:       opts.Value,
 		Metadata:    opts.Metadata,
-		Timestamp:   time.Now().UTC().Format(time.RFC3339Nano),
+		Timestamp:   ec.now().UTC().Format(time.RFC3339Nano),
 	}
 
 	ec.mu.Lock()
@@ -115,12 +353,18 @@ func (ec *EventCollector) Track(opts TrackEventOptions) {
 	ec.mu.Unlock()
 
 	if shouldFlush {
-		go func() { _ = ec.Flush() }()
+		go func() { _ = ec.Flush(ec.parentContext()) }()
 	}
 }
 
-// Flush sends all buffered events to the server.
-func (ec *EventCollector) Flush() error {
+// Flush sends all buffered events to the server. Each request is bounded
+// by ctx and a 10s timeout; transient failures are retried with backoff,
+// and events are kept for the next flush if the last attempt fails.
+func (ec *EventCollector) Flush(ctx context.Context) error {
+	return ec.flush(ctx, true)
+}
+
+func (ec *EventCollector) flush(ctx context.Context, retry bool) error {
 	ec.mu.Lock()
 	if len(ec.buffer) == 0 {
 		ec.mu.Unlock()
@@ -128,6 +372,9 @@ func (ec *EventCollector) Flush() error {
 	}
 	events := ec.buffer
 	ec.buffer = make([]bufferedEvent, 0, ec.config.MaxBufferSize)
+	headers := ec.headers
+	tokens := ec.tokens
+	metrics := ec.metrics
 	ec.mu.Unlock()
 
 	payload := map[string]any{"events": events}
@@ -138,31 +385,115 @@ func (ec *EventCollector) Flush() error {
 		return fmt.Errorf("failed to marshal events: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	retryer := ec.retryer
+	if !retry {
+		retryer = NewRetryer(RetryConfig{})
+	}
+	start := time.Now()
+	var statusCode int
+	result := retryer.Do(ctx, func() error {
+		var err error
+		statusCode, err = ec.send(ctx, body, headers, tokens)
+		return err
+	})
+	retries := result.Attempts - 1
+
+	if metrics != nil {
+		delivered := 0
+		if result.Success {
+			delivered = len(events)
+		}
+		metrics.RecordEventFlush(delivered, retries)
+	}
+	if !result.Success {
+		ec.reBuffer(events)
+		ec.notify(start, statusCode, retries, result.Error)
+		return result.Error
+	}
+
+	ec.mu.Lock()
+	ec.stats.Delivered += int64(len(events))
+	stopped := ec.stopped
+	ec.mu.Unlock()
+	ec.notify(start, statusCode, retries, nil)
+
+	// The server is reachable again: send the next chunk of spilled events
+	if !stopped && ec.restoreSpilled() > 0 {
+		go func() { _ = ec.Flush(ec.parentContext()) }()
+	}
+	return nil
+}
+
+// send makes one flush request and returns its status code (0 if it got
+// no response). Network errors, 429s and 5xx are retryable.
+func (ec *EventCollector) send(ctx context.Context, body []byte, headers map[string]string, tokens *tokenSource) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, eventFlushTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ec.endpoint, bytes.NewReader(body))
 	if err != nil {
-		ec.reBuffer(events)
-		return fmt.Errorf("failed to create request: %w", err)
+		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+ec.apiKey)
+	setRequestHeaders(req, headers)
+	if err := tokens.authorize(req); err != nil {
+		return 0, err
+	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := ec.client.Do(req)
 	if err != nil {
-		ec.reBuffer(events)
-		return fmt.Errorf("failed to send events: %w", err)
+		return 0, NewNetworkError("failed to send events", err)
 	}
 	defer resp.Body.Close()
 
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		tokens.rejected(req)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		ec.mu.Lock()
+		rateLimits := ec.rateLimits
+		ec.mu.Unlock()
+		retryAfter := 0
+		if rateLimits != nil {
+			retryAfter = retryAfterSeconds(rateLimits.record(resp, time.Now()))
+		}
+		return resp.StatusCode, NewRateLimitError(retryAfter)
+	case resp.StatusCode >= 500:
+		return resp.StatusCode, NewServerError(resp.StatusCode, fmt.Sprintf("event flush failed with status %d", resp.StatusCode))
+	}
 	if resp.StatusCode >= 400 {
-		ec.reBuffer(events)
-		return fmt.Errorf("event flush failed with status %d", resp.StatusCode)
+		return resp.StatusCode, fmt.Errorf("event flush failed with status %d", resp.StatusCode)
 	}
+	return resp.StatusCode, nil
+}
 
-	return nil
+// notify records the outcome of a flush request and reports it to the observer.
+func (ec *EventCollector) notify(start time.Time, statusCode, retries int, err error) {
+	ec.mu.Lock()
+	ec.stats.LastFlushTime = time.Now()
+	ec.stats.LastFlushStatus = statusCode
+	ec.stats.LastFlushError = err
+	observer := ec.observer
+	ec.mu.Unlock()
+
+	observer.notify(RequestInfo{
+		Method:     http.MethodPost,
+		Endpoint:   ec.endpoint,
+		Duration:   time.Since(start),
+		StatusCode: statusCode,
+		Retries:    retries,
+		Error:      err,
+	})
+}
+
+// Stats returns the buffer size, delivery counts and last flush outcome.
+func (ec *EventCollector) Stats() EventStats {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	stats := ec.stats
+	stats.Buffered = len(ec.buffer)
+	return stats
 }
 
 // GetBufferSize returns the current number of buffered events.
@@ -172,27 +503,151 @@ func (ec *EventCollector) GetBufferSize() int {
 	return len(ec.buffer)
 }
 
+// reBuffer puts the events of a failed flush back in front of the buffer.
+// The buffer holds at most twice MaxBufferSize events; the oldest events
+// over that, or all of them once the collector has stopped, are spilled.
 func (ec *EventCollector) reBuffer(events []bufferedEvent) {
 	ec.mu.Lock()
-	defer ec.mu.Unlock()
-	// Prepend failed events, but respect max buffer size
 	combined := append(events, ec.buffer...)
-	if len(combined) > ec.config.MaxBufferSize*2 {
-		combined = combined[len(combined)-ec.config.MaxBufferSize*2:]
+	var overflow []bufferedEvent
+	if ec.stopped && ec.config.SpillFile != "" {
+		overflow, combined = combined, nil
+	} else if over := len(combined) - ec.config.MaxBufferSize*2; over > 0 {
+		overflow, combined = combined[:over], combined[over:]
 	}
 	ec.buffer = combined
+	ec.mu.Unlock()
+
+	ec.spill(overflow)
+}
+
+// spill writes undelivered events to the spill file, keeping the newest
+// when it is full, and drops those it cannot keep.
+func (ec *EventCollector) spill(events []bufferedEvent) {
+	if len(events) == 0 {
+		return
+	}
+	ec.spillMu.Lock()
+	defer ec.spillMu.Unlock()
+
+	spilled := 0
+	if ec.config.SpillFile != "" {
+		limit := ec.config.MaxSpillEvents
+		if limit <= 0 {
+			limit = defaultMaxSpillEvents
+		}
+		keep := events[len(events)-min(len(events), max(limit-ec.spilled, 0)):]
+		if err := appendSpillFile(ec.config.SpillFile, keep); err == nil {
+			spilled = len(keep)
+			ec.spilled += spilled
+		}
+	}
+	dropped := len(events) - spilled
+
+	ec.mu.Lock()
+	ec.stats.Spilled += int64(spilled)
+	ec.stats.Dropped += int64(dropped)
+	metrics := ec.metrics
+	ec.mu.Unlock()
+	if metrics != nil {
+		metrics.RecordUndeliveredEvents(spilled, dropped)
+	}
+}
+
+// restoreSpilled moves the events of the spill file back into the buffer
+// and returns how many there were. Events the buffer cannot hold go back
+// to the file, for the flush after the next one.
+func (ec *EventCollector) restoreSpilled() int {
+	if ec.config.SpillFile == "" {
+		return 0
+	}
+	ec.spillMu.Lock()
+	if ec.spilled == 0 && !ec.spillUnread {
+		ec.spillMu.Unlock()
+		return 0
+	}
+	ec.spillUnread = false
+	events, _ := readSpillFile(ec.config.SpillFile)
+	_ = os.Remove(ec.config.SpillFile)
+	ec.spilled = 0
+	ec.spillMu.Unlock()
+
+	if len(events) > 0 {
+		ec.reBuffer(events)
+	}
+	return len(events)
+}
+
+// appendSpillFile appends events to path, one JSON object per line.
+func appendSpillFile(path string, events []bufferedEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return fmt.Errorf("failed to encode spilled event: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create spill directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open spill file: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	return f.Close()
+}
+
+// readSpillFile reads the events of a spill file. A truncated last line,
+// left by a process that died while spilling, ends the read.
+func readSpillFile(path string) ([]bufferedEvent, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spill file: %w", err)
+	}
+	defer f.Close()
+
+	var events []bufferedEvent
+	dec := json.NewDecoder(f)
+	for {
+		var event bufferedEvent
+		if err := dec.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return events, nil
+			}
+			return events, fmt.Errorf("failed to parse spill file: %w", err)
+		}
+		events = append(events, event)
+	}
 }
 
 func (ec *EventCollector) flushLoop() {
-	ticker := time.NewTicker(time.Duration(ec.config.FlushIntervalMs) * time.Millisecond)
+	ec.mu.Lock()
+	timers := ec.timers
+	ec.mu.Unlock()
+	ticker := timers.newTicker(time.Duration(ec.config.FlushIntervalMs) * time.Millisecond)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ec.stop:
 			return
-		case <-ticker.C:
-			_ = ec.Flush()
+		case <-ticker.C():
+			_ = ec.Flush(ec.parentContext())
 		}
 	}
 }
@@ -0,0 +1,426 @@
+package rollgate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTrackEventOptions_Builder(t *testing.T) {
+	opts := NewTrackEvent("checkout", "purchase", "user-1").
+		WithVariation("variant-b").
+		WithValue(29.99).
+		WithMetadata("currency", "EUR")
+
+	if opts.FlagKey != "checkout" || opts.EventName != "purchase" || opts.UserID != "user-1" {
+		t.Errorf("unexpected required fields: %+v", opts)
+	}
+	if opts.VariationID != "variant-b" {
+		t.Errorf("expected variation 'variant-b', got '%s'", opts.VariationID)
+	}
+	if opts.Value == nil || *opts.Value != 29.99 {
+		t.Errorf("expected value 29.99, got %v", opts.Value)
+	}
+	if opts.Metadata["currency"] != "EUR" {
+		t.Errorf("expected metadata currency 'EUR', got '%v'", opts.Metadata["currency"])
+	}
+}
+
+func TestTrackEventOptions_WithMetadataCopies(t *testing.T) {
+	base := NewTrackEvent("flag", "event", "user").WithMetadata("a", 1)
+	derived := base.WithMetadata("b", 2)
+
+	if _, ok := base.Metadata["b"]; ok {
+		t.Error("expected WithMetadata not to mutate the original options")
+	}
+	if len(derived.Metadata) != 2 {
+		t.Errorf("expected 2 metadata entries, got %d", len(derived.Metadata))
+	}
+}
+
+func TestTrackEventOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  TrackEventOptions
+		field string
+	}{
+		{"valid", NewTrackEvent("flag", "event", "user"), ""},
+		{"missing flag key", NewTrackEvent("", "event", "user"), "flagKey"},
+		{"missing event name", NewTrackEvent("flag", " ", "user"), "eventName"},
+		{"missing user ID", NewTrackEvent("flag", "event", ""), "userId"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected *ValidationError, got %v", err)
+			}
+			if validationErr.Field != tt.field {
+				t.Errorf("expected field '%s', got '%s'", tt.field, validationErr.Field)
+			}
+		})
+	}
+}
+
+func TestClient_TrackValidated(t *testing.T) {
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         "http://localhost:1",
+		RefreshInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.TrackValidated(NewTrackEvent("flag", "event", "")); err == nil {
+		t.Error("expected error for missing userId")
+	}
+	client.Track(TrackEventOptions{EventName: "event", UserID: "user"})
+
+	if err := client.TrackValidated(NewTrackEvent("flag", "event", "user")); err != nil {
+		t.Errorf("expected valid event to be accepted, got %v", err)
+	}
+
+	if got := client.eventCollector.GetBufferSize(); got != 1 {
+		t.Errorf("expected 1 buffered event, got %d", got)
+	}
+	if got := client.GetMetrics().RejectedEvents; got != 2 {
+		t.Errorf("expected 2 rejected events, got %d", got)
+	}
+}
+
+func TestClient_EventsAndTelemetryDisabled(t *testing.T) {
+	var analytics atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"flags":{"flag":true}}`))
+		case "/api/v1/sdk/v2/flags":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			analytics.Add(1)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig("test-key")
+	config.BaseURL = server.URL
+	config.RefreshInterval = time.Hour
+	config.Events.Enabled = false
+	config.Telemetry.Enabled = false
+	config.Exposure.Enabled = true
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if client.eventCollector != nil || client.telemetryCollector != nil {
+		t.Fatal("disabled collectors should not be constructed")
+	}
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	client.IsEnabled("flag", false, WithUser("user-1"))
+	client.Track(NewTrackEvent("flag", "purchase", "user-1"))
+	if err := client.FlushEvents(context.Background()); err != nil {
+		t.Errorf("FlushEvents failed: %v", err)
+	}
+	if err := client.FlushTelemetry(context.Background()); err != nil {
+		t.Errorf("FlushTelemetry failed: %v", err)
+	}
+	if flags, evals := client.GetTelemetryStats(); flags != 0 || evals != 0 {
+		t.Errorf("expected empty telemetry stats, got %d flags %d evaluations", flags, evals)
+	}
+	client.Close()
+
+	if n := analytics.Load(); n != 0 {
+		t.Errorf("expected no analytics requests, got %d", n)
+	}
+}
+
+func TestClient_FlushContext(t *testing.T) {
+	var hangTelemetry atomic.Bool
+	hangTelemetry.Store(true)
+	eventsReceived := make(chan struct{}, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			w.Write([]byte(`{"flags":{"flag":true}}`))
+			return
+		case "/api/v1/sdk/v2/flags":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		case "/api/v1/sdk/events":
+			eventsReceived <- struct{}{}
+		case "/api/v1/sdk/telemetry":
+			if !hangTelemetry.Load() {
+				return
+			}
+		}
+		// Hang until the client gives up on the request; the body must be
+		// read for the server to notice the disconnect
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	config := DefaultConfig("test-key")
+	config.BaseURL = server.URL
+	config.RefreshInterval = time.Hour
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	t.Run("per-flush deadline", func(t *testing.T) {
+		client.Track(NewTrackEvent("flag", "purchase", "user-1"))
+		client.IsEnabled("flag", false)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := client.FlushEvents(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected FlushEvents to hit the deadline, got %v", err)
+		}
+		if err := client.FlushTelemetry(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected FlushTelemetry to hit the deadline, got %v", err)
+		}
+		if got := client.eventCollector.GetBufferSize(); got != 1 {
+			t.Errorf("expected the event to be kept for the next flush, got %d", got)
+		}
+		<-eventsReceived
+		hangTelemetry.Store(false)
+	})
+
+	t.Run("Close cancels background flushes", func(t *testing.T) {
+		// Stand-in for a periodic flush: the buffer is handed to a request
+		// derived from the client context
+		done := make(chan error, 1)
+		go func() { done <- client.eventCollector.Flush(client.eventCollector.parentContext()) }()
+		select {
+		case <-eventsReceived:
+		case <-time.After(2 * time.Second):
+			t.Fatal("flush request never arrived")
+		}
+
+		client.Close()
+		select {
+		case err := <-done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected the flush to be cancelled, got %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Close did not cancel the in-flight flush")
+		}
+	})
+}
+
+func TestClient_GetEventStats(t *testing.T) {
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		Events:          EventCollectorConfig{Enabled: true, FlushIntervalMs: 60000, MaxBufferSize: 10},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if stats := client.GetEventStats(); !stats.LastFlushTime.IsZero() || stats.Buffered != 0 {
+		t.Errorf("expected empty stats before any flush, got %+v", stats)
+	}
+
+	fail.Store(true)
+	for i := 0; i < 3; i++ {
+		client.Track(NewTrackEvent("flag", "purchase", "user-1"))
+	}
+	if err := client.FlushEvents(context.Background()); err == nil {
+		t.Fatal("expected the flush to fail")
+	}
+	stats := client.GetEventStats()
+	if stats.Buffered != 3 || stats.LastFlushStatus != http.StatusServiceUnavailable || stats.LastFlushError == nil {
+		t.Errorf("expected 3 buffered events after a 503, got %+v", stats)
+	}
+	if stats.LastFlushTime.IsZero() {
+		t.Error("expected the failed flush to be timestamped")
+	}
+
+	// Failed flushes keep at most twice MaxBufferSize events
+	client.eventCollector.reBuffer(make([]bufferedEvent, 20))
+	if stats := client.GetEventStats(); stats.Buffered != 20 || stats.Dropped != 3 {
+		t.Errorf("expected 20 buffered and 3 dropped events, got %+v", stats)
+	}
+
+	fail.Store(false)
+	if err := client.FlushEvents(context.Background()); err != nil {
+		t.Fatalf("FlushEvents failed: %v", err)
+	}
+	stats = client.GetEventStats()
+	if stats.Buffered != 0 || stats.LastFlushStatus != http.StatusOK || stats.LastFlushError != nil || stats.Dropped != 3 {
+		t.Errorf("expected an empty buffer after a successful flush, got %+v", stats)
+	}
+}
+
+func TestEventCollector_Retry(t *testing.T) {
+	var requests, failures atomic.Int32
+	var status atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failures.Add(-1) >= 0 {
+			w.WriteHeader(int(status.Load()))
+		}
+	}))
+	defer server.Close()
+
+	var infos []RequestInfo
+	metrics := NewSDKMetrics()
+	ec := NewEventCollector(server.URL, "test-key", EventCollectorConfig{
+		Enabled:         true,
+		FlushIntervalMs: 60000,
+		MaxBufferSize:   10,
+		MaxRetries:      3,
+		RetryBaseDelay:  time.Millisecond,
+	}, server.Client())
+	ec.setMetrics(metrics)
+	ec.SetRequestObserver(func(info RequestInfo) { infos = append(infos, info) })
+
+	t.Run("should retry transient failures with backoff", func(t *testing.T) {
+		status.Store(http.StatusServiceUnavailable)
+		failures.Store(2)
+		ec.Track(NewTrackEvent("flag", "purchase", "user-1"))
+		if err := ec.Flush(context.Background()); err != nil {
+			t.Fatalf("expected the flush to succeed after retries, got %v", err)
+		}
+		if got := requests.Load(); got != 3 {
+			t.Errorf("expected 3 requests, got %d", got)
+		}
+		if stats := ec.Stats(); stats.Delivered != 1 || stats.Buffered != 0 {
+			t.Errorf("expected 1 delivered event, got %+v", stats)
+		}
+		if infos[0].Retries != 2 {
+			t.Errorf("expected 2 retries reported, got %d", infos[0].Retries)
+		}
+		if snap := metrics.Snapshot(); snap.DeliveredEvents != 1 || snap.EventFlushRetries != 2 {
+			t.Errorf("expected 1 delivered event and 2 retries, got %d and %d", snap.DeliveredEvents, snap.EventFlushRetries)
+		}
+	})
+
+	t.Run("should not retry client errors", func(t *testing.T) {
+		requests.Store(0)
+		status.Store(http.StatusBadRequest)
+		failures.Store(1)
+		ec.Track(NewTrackEvent("flag", "purchase", "user-1"))
+		if err := ec.Flush(context.Background()); err == nil {
+			t.Fatal("expected the flush to fail")
+		}
+		if got := requests.Load(); got != 1 {
+			t.Errorf("expected 1 request, got %d", got)
+		}
+		if got := ec.GetBufferSize(); got != 1 {
+			t.Errorf("expected the event to be kept, got %d buffered", got)
+		}
+	})
+}
+
+func TestEventCollector_SpillFile(t *testing.T) {
+	var fail atomic.Bool
+	received := make(chan int, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var payload struct {
+			Events []bufferedEvent `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- len(payload.Events)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "events", "spill.jsonl")
+	config := EventCollectorConfig{
+		Enabled:         true,
+		FlushIntervalMs: 60000,
+		MaxBufferSize:   2,
+		SpillFile:       path,
+		MaxSpillEvents:  5,
+	}
+
+	t.Run("should spill undelivered events on Stop", func(t *testing.T) {
+		fail.Store(true)
+		ec := NewEventCollector(server.URL, "test-key", config, server.Client())
+		for i := 0; i < 3; i++ {
+			ec.reBuffer([]bufferedEvent{{FlagKey: "flag", EventName: "purchase", UserID: "user-1"}})
+		}
+		ec.Stop()
+
+		events, err := readSpillFile(path)
+		if err != nil || len(events) != 3 {
+			t.Fatalf("expected 3 spilled events, got %d (%v)", len(events), err)
+		}
+		if stats := ec.Stats(); stats.Spilled != 3 || stats.Dropped != 0 {
+			t.Errorf("expected 3 spilled and no dropped events, got %+v", stats)
+		}
+	})
+
+	t.Run("should drop events over MaxSpillEvents", func(t *testing.T) {
+		ec := NewEventCollector(server.URL, "test-key", config, server.Client())
+		ec.spilled = 3 // Left by the collector above
+		ec.spill(make([]bufferedEvent, 4))
+		if stats := ec.Stats(); stats.Spilled != 2 || stats.Dropped != 2 {
+			t.Errorf("expected 2 spilled and 2 dropped events, got %+v", stats)
+		}
+	})
+
+	t.Run("should deliver spilled events once the server is back", func(t *testing.T) {
+		fail.Store(false)
+		ec := NewEventCollector(server.URL, "test-key", config, server.Client())
+		ec.Start()
+		defer ec.Stop()
+
+		total := 0
+		for total < 5 {
+			select {
+			case n := <-received:
+				total += n
+			case <-time.After(2 * time.Second):
+				t.Fatalf("expected 5 redelivered events, got %d", total)
+			}
+		}
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected the spill file to be removed, got %v", err)
+		}
+	})
+}
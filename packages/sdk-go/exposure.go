@@ -0,0 +1,90 @@
+package rollgate
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// ExposureEventName is the event name used for automatic exposure events.
+const ExposureEventName = "$exposure"
+
+// ExposureConfig configures automatic exposure events.
+type ExposureConfig struct {
+	// Enabled emits an exposure event to the events pipeline the first time
+	// a flag is evaluated for a user within Interval (default: false)
+	Enabled bool
+
+	// Interval is how long an exposure is remembered before it is emitted again (default: 1h)
+	Interval time.Duration
+}
+
+// DefaultExposureConfig returns default exposure settings (disabled).
+func DefaultExposureConfig() ExposureConfig {
+	return ExposureConfig{
+		Enabled:  false,
+		Interval: time.Hour,
+	}
+}
+
+// exposureTracker remembers which flag/user/variation combinations were
+// already reported so each one is emitted at most once per interval.
+type exposureTracker struct {
+	seen *recentKeys
+}
+
+func newExposureTracker(interval time.Duration) *exposureTracker {
+	return &exposureTracker{seen: newRecentKeys(interval)}
+}
+
+// shouldEmit returns true if the exposure has not been reported within the interval,
+// and marks it as reported.
+func (t *exposureTracker) shouldEmit(flagKey, userID, variation string) bool {
+	return t.seen.add(flagKey + "\x00" + userID + "\x00" + variation)
+}
+
+// exposureEvent builds the exposure event for an evaluation that served
+// value. The variation is variationID when the server sent one, otherwise
+// the value itself.
+func exposureEvent(flagKey, userID string, value any, variationID string, reason EvaluationReason) TrackEventOptions {
+	variation := variationID
+	if variation == "" {
+		variation = variationName(value)
+	}
+
+	metadata := map[string]any{"reason": string(reason.Kind)}
+	if reason.RuleID != "" {
+		metadata["ruleId"] = reason.RuleID
+	}
+	if reason.InRollout {
+		metadata["inRollout"] = true
+	}
+
+	return TrackEventOptions{
+		FlagKey:     flagKey,
+		EventName:   ExposureEventName,
+		UserID:      userID,
+		VariationID: variation,
+		Metadata:    metadata,
+	}
+}
+
+// variationName formats a served value as a variation name: booleans as
+// "true"/"false", strings as is, numbers in their shortest form and JSON
+// values as compact JSON.
+func variationName(value any) string {
+	switch v := value.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,110 @@
+package rollgate
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExposureTracker_ShouldEmit(t *testing.T) {
+	t.Run("should emit once per interval", func(t *testing.T) {
+		tracker := newExposureTracker(time.Hour)
+		if !tracker.shouldEmit("flag", "user-1", "true") {
+			t.Error("expected first exposure to be emitted")
+		}
+		if tracker.shouldEmit("flag", "user-1", "true") {
+			t.Error("expected repeated exposure to be suppressed")
+		}
+	})
+
+	t.Run("should emit per user and variation", func(t *testing.T) {
+		tracker := newExposureTracker(time.Hour)
+		tracker.shouldEmit("flag", "user-1", "true")
+		if !tracker.shouldEmit("flag", "user-2", "true") {
+			t.Error("expected exposure for a different user to be emitted")
+		}
+		if !tracker.shouldEmit("flag", "user-1", "false") {
+			t.Error("expected exposure for a different variation to be emitted")
+		}
+	})
+
+	t.Run("should emit again after interval", func(t *testing.T) {
+		tracker := newExposureTracker(10 * time.Millisecond)
+		tracker.shouldEmit("flag", "user-1", "true")
+		time.Sleep(15 * time.Millisecond)
+		if !tracker.shouldEmit("flag", "user-1", "true") {
+			t.Error("expected exposure to be emitted after the interval")
+		}
+	})
+}
+
+func TestExposureEvent(t *testing.T) {
+	event := exposureEvent("flag", "user-1", true, "", RuleMatchReason("rule-1", 0, true))
+
+	if event.EventName != ExposureEventName {
+		t.Errorf("expected event name %s, got %s", ExposureEventName, event.EventName)
+	}
+	if event.VariationID != "true" {
+		t.Errorf("expected variation 'true', got '%s'", event.VariationID)
+	}
+	if event.Metadata["reason"] != "RULE_MATCH" || event.Metadata["ruleId"] != "rule-1" {
+		t.Errorf("unexpected metadata: %v", event.Metadata)
+	}
+	if err := event.Validate(); err != nil {
+		t.Errorf("expected exposure event to be valid, got %v", err)
+	}
+}
+
+func TestVariationName(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{false, "false"},
+		{"blue", "blue"},
+		{10.0, "10"},
+		{0.25, "0.25"},
+		{map[string]any{"theme": "dark"}, `{"theme":"dark"}`},
+		{[]any{1.0, "a"}, `[1,"a"]`},
+	}
+	for _, tt := range tests {
+		if got := variationName(tt.value); got != tt.want {
+			t.Errorf("variationName(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestClient_ExposureEvents(t *testing.T) {
+	server := newTestServer(map[string]bool{"flag-a": true})
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		Exposure:        ExposureConfig{Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	// No user yet: nothing to attribute the exposure to
+	client.IsEnabled("flag-a", false)
+	if got := client.eventCollector.GetBufferSize(); got != 0 {
+		t.Errorf("expected no exposure without a user, got %d", got)
+	}
+
+	client.IsEnabled("flag-a", false, WithUser("user-1"))
+	client.IsEnabled("flag-a", false, WithUser("user-1"))
+	client.IsEnabled("flag-a", false, WithUser("user-2"))
+	client.IsEnabled("missing-flag", false, WithUser("user-1"))
+
+	if got := client.eventCollector.GetBufferSize(); got != 2 {
+		t.Errorf("expected 2 exposure events, got %d", got)
+	}
+}
@@ -0,0 +1,138 @@
+package rollgate
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// baseURLFailover tracks which base URL flags, rules and identify requests
+// go to: BaseURL first, then each of FallbackBaseURLs in order. The client
+// moves to the next URL when the active one fails persistently and, while a
+// fallback is active, probes the primary every failBackInterval to return
+// to it once it recovers.
+type baseURLFailover struct {
+	mu               sync.Mutex
+	urls             []string // BaseURL followed by the fallbacks
+	active           int
+	failBackInterval time.Duration
+	lastProbe        time.Time // Last fail-back probe, or the failover away from the primary
+}
+
+func newBaseURLFailover(primary string, fallbacks []string, failBackInterval time.Duration) *baseURLFailover {
+	urls := []string{primary}
+	for _, u := range fallbacks {
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return &baseURLFailover{urls: urls, failBackInterval: failBackInterval}
+}
+
+// current returns the active base URL.
+func (f *baseURLFailover) current() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.urls[f.active]
+}
+
+// primary returns BaseURL.
+func (f *baseURLFailover) primary() string {
+	return f.urls[0]
+}
+
+// advance moves from the failing URL from to the next one, wrapping around
+// to the primary after the last fallback. It reports false when there is no
+// other URL or a concurrent request already moved away from from.
+func (f *baseURLFailover) advance(from string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.urls) < 2 || f.urls[f.active] != from {
+		return "", false
+	}
+	if f.active == 0 {
+		f.lastProbe = time.Now()
+	}
+	f.active = (f.active + 1) % len(f.urls)
+	return f.urls[f.active], true
+}
+
+// probeDue reports whether a fallback is active and the primary has not
+// been probed for failBackInterval. It starts the next interval, so
+// concurrent fetches send a single probe.
+func (f *baseURLFailover) probeDue() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.active == 0 || time.Since(f.lastProbe) < f.failBackInterval {
+		return false
+	}
+	f.lastProbe = time.Now()
+	return true
+}
+
+// failBack makes the primary active again. It reports false if it already was.
+func (f *baseURLFailover) failBack() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.active == 0 {
+		return false
+	}
+	f.active = 0
+	return true
+}
+
+// failsOver reports whether err from a flags fetch means the base URL is
+// down or failing, rather than rejecting the request or being cancelled.
+func failsOver(ctx context.Context, err error) bool {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return false
+	}
+	switch ClassifyError(err).Category {
+	case ErrorCategoryNetwork, ErrorCategoryServer:
+		return true
+	}
+	return false
+}
+
+type baseURLContextKey struct{}
+
+// withBaseURL makes requests built from ctx go to baseURL instead of the
+// active base URL, for fail-back probes.
+func withBaseURL(ctx context.Context, baseURL string) context.Context {
+	return context.WithValue(ctx, baseURLContextKey{}, baseURL)
+}
+
+// baseURL returns the base URL for requests built from ctx.
+func (c *Client) baseURL(ctx context.Context) string {
+	if u, ok := ctx.Value(baseURLContextKey{}).(string); ok {
+		return u
+	}
+	if c.failover != nil {
+		return c.failover.current()
+	}
+	return c.config.BaseURL
+}
+
+// failOver moves flags requests from the failing base URL to the next one
+// and resets the circuit breaker, which now guards the new URL. It reports
+// whether the URL changed.
+func (c *Client) failOver(from string, cause error) bool {
+	if c.failover == nil {
+		return false
+	}
+	next, ok := c.failover.advance(from)
+	if !ok {
+		return false
+	}
+	c.config.Logger.Warn("base URL failing, switching to the next one", "from", from, "to", next, "error", cause)
+	c.metrics.RecordFailover()
+	c.circuitBreaker.ForceReset()
+	return true
+}
+
+// ActiveBaseURL returns the base URL flags, rules and identify requests
+// currently go to: BaseURL, or one of FallbackBaseURLs after a failover.
+func (c *Client) ActiveBaseURL() string {
+	return c.baseURL(context.Background())
+}
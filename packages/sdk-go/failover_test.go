@@ -0,0 +1,176 @@
+package rollgate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBaseURLFailover(t *testing.T) {
+	t.Run("should move through the fallbacks in order and wrap around", func(t *testing.T) {
+		f := newBaseURLFailover("http://a", []string{"http://b", "", "http://c"}, time.Minute)
+		for _, want := range []string{"http://b", "http://c", "http://a"} {
+			next, ok := f.advance(f.current())
+			if !ok || next != want {
+				t.Fatalf("expected to move to %s, got %q (%v)", want, next, ok)
+			}
+		}
+	})
+
+	t.Run("should not move twice for the same failure", func(t *testing.T) {
+		f := newBaseURLFailover("http://a", []string{"http://b", "http://c"}, time.Minute)
+		f.advance("http://a")
+		if _, ok := f.advance("http://a"); ok {
+			t.Error("expected a stale failure to be ignored")
+		}
+		if got := f.current(); got != "http://b" {
+			t.Errorf("expected http://b, got %s", got)
+		}
+	})
+
+	t.Run("should probe the primary once per interval while failed over", func(t *testing.T) {
+		f := newBaseURLFailover("http://a", []string{"http://b"}, 50*time.Millisecond)
+		if f.probeDue() {
+			t.Error("expected no probe while the primary is active")
+		}
+		f.advance("http://a")
+		if f.probeDue() {
+			t.Error("expected no probe right after failing over")
+		}
+		time.Sleep(60 * time.Millisecond)
+		if !f.probeDue() {
+			t.Error("expected a probe after the interval")
+		}
+		if f.probeDue() {
+			t.Error("expected a single probe per interval")
+		}
+		if !f.failBack() || f.current() != "http://a" {
+			t.Errorf("expected to fail back to http://a, got %s", f.current())
+		}
+	})
+}
+
+// newRegionServer serves flag-a with the given value while up is true and
+// answers 503 otherwise, counting flags requests.
+func newRegionServer(value bool, up *atomic.Bool, requests *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/sdk/flags" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests.Add(1)
+		if !up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"flags":{"flag-a":%v}}`, value)
+	}))
+}
+
+func TestClient_Failover(t *testing.T) {
+	retry := RetryConfig{MaxRetries: 1, BaseDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+	t.Run("should initialize from a fallback when the primary is unreachable", func(t *testing.T) {
+		var up atomic.Bool
+		var requests atomic.Int32
+		up.Store(true)
+		fallback := newRegionServer(true, &up, &requests)
+		defer fallback.Close()
+		primary := httptest.NewServer(http.NotFoundHandler())
+		primary.Close()
+
+		client := newIntegrationClient(t, Config{
+			APIKey:           "test-key",
+			BaseURL:          primary.URL,
+			FallbackBaseURLs: []string{fallback.URL},
+			RefreshInterval:  time.Hour,
+			Retry:            retry,
+		})
+		if !client.IsEnabled("flag-a", false) {
+			t.Error("expected flags from the fallback")
+		}
+		if got := client.ActiveBaseURL(); got != fallback.URL {
+			t.Errorf("expected the fallback to be active, got %s", got)
+		}
+		if got := client.GetMetrics().Failovers; got != 1 {
+			t.Errorf("expected 1 failover, got %d", got)
+		}
+	})
+
+	t.Run("should not fail over on auth errors", func(t *testing.T) {
+		var up atomic.Bool
+		var requests atomic.Int32
+		up.Store(true)
+		fallback := newRegionServer(true, &up, &requests)
+		defer fallback.Close()
+		primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer primary.Close()
+
+		client, err := NewClient(Config{
+			APIKey:           "test-key",
+			BaseURL:          primary.URL,
+			FallbackBaseURLs: []string{fallback.URL},
+			Retry:            retry,
+		})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+		if err := client.Refresh(context.Background()); err == nil {
+			t.Error("expected the refresh to fail")
+		}
+		if got := requests.Load(); got != 0 {
+			t.Errorf("expected no fallback requests, got %d", got)
+		}
+	})
+
+	t.Run("should fail back once the primary recovers", func(t *testing.T) {
+		var primaryUp, fallbackUp atomic.Bool
+		var primaryRequests, fallbackRequests atomic.Int32
+		primaryUp.Store(true)
+		fallbackUp.Store(true)
+		primary := newRegionServer(false, &primaryUp, &primaryRequests)
+		defer primary.Close()
+		fallback := newRegionServer(true, &fallbackUp, &fallbackRequests)
+		defer fallback.Close()
+
+		client := newIntegrationClient(t, Config{
+			APIKey:             "test-key",
+			BaseURL:            primary.URL,
+			FallbackBaseURLs:   []string{fallback.URL},
+			RefreshInterval:    50 * time.Millisecond,
+			MaxRefreshInterval: 50 * time.Millisecond,
+			FailBackInterval:   200 * time.Millisecond,
+			Retry:              retry,
+		})
+
+		primaryUp.Store(false)
+		time.Sleep(150 * time.Millisecond)
+		if got := client.ActiveBaseURL(); got != fallback.URL {
+			t.Fatalf("expected a failover to the fallback, got %s", got)
+		}
+		if !client.IsEnabled("flag-a", false) {
+			t.Error("expected flags from the fallback")
+		}
+
+		primaryUp.Store(true)
+		time.Sleep(350 * time.Millisecond)
+		if got := client.ActiveBaseURL(); got != primary.URL {
+			t.Fatalf("expected a fail-back to the primary, got %s", got)
+		}
+		if client.IsEnabled("flag-a", true) {
+			t.Error("expected flags from the primary")
+		}
+		metrics := client.GetMetrics()
+		if metrics.Failovers != 1 || metrics.FailBacks != 1 {
+			t.Errorf("expected 1 failover and 1 fail-back, got %d and %d", metrics.Failovers, metrics.FailBacks)
+		}
+	})
+}
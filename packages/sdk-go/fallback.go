@@ -0,0 +1,77 @@
+package rollgate
+
+// ConnectionMode is the transport a client receives flag updates over.
+type ConnectionMode string
+
+const (
+	// ConnectionStreaming receives updates over the SSE stream.
+	ConnectionStreaming ConnectionMode = "streaming"
+	// ConnectionPolling polls every RefreshInterval, because streaming is
+	// disabled or because the stream keeps failing to connect.
+	ConnectionPolling ConnectionMode = "polling"
+	// ConnectionOffline receives no updates: the client is in offline mode.
+	ConnectionOffline ConnectionMode = "offline"
+	// ConnectionNone receives no updates: the client is not initialized or
+	// has been closed.
+	ConnectionNone ConnectionMode = "none"
+)
+
+// GetConnectionMode returns the transport the client currently receives
+// flag updates over. A streaming client reports ConnectionPolling while it
+// polls in place of a failing stream.
+func (c *Client) GetConnectionMode() ConnectionMode {
+	if c.config.Offline {
+		return ConnectionOffline
+	}
+
+	c.mu.RLock()
+	ready, closed, streaming := c.ready, c.closed, c.streaming
+	c.mu.RUnlock()
+	switch {
+	case closed || !ready:
+		return ConnectionNone
+	case !streaming:
+		return ConnectionPolling
+	}
+
+	c.fallbackMu.Lock()
+	defer c.fallbackMu.Unlock()
+	if c.fallbackStop != nil {
+		return ConnectionPolling
+	}
+	return ConnectionStreaming
+}
+
+// streamFailed counts a failed stream connection, and starts polling once
+// StreamFallbackThreshold attempts in a row have failed. The stream keeps
+// reconnecting in the background. It runs in SSE callbacks, so it does
+// not take c.mu.
+func (c *Client) streamFailed() {
+	c.fallbackMu.Lock()
+	defer c.fallbackMu.Unlock()
+
+	c.streamFailures++
+	threshold := c.config.StreamFallbackThreshold
+	if threshold < 0 || c.streamFailures < threshold || c.fallbackStop != nil {
+		return
+	}
+	c.config.Logger.Warn("SSE keeps failing, falling back to polling",
+		"failures", c.streamFailures, "interval", c.config.RefreshInterval)
+	c.fallbackStop = make(chan struct{})
+	go c.startPolling(c.fallbackStop)
+}
+
+// streamConnected stops polling in place of the stream. The stream sends
+// every flag when it connects, so nothing is missed.
+func (c *Client) streamConnected() {
+	c.fallbackMu.Lock()
+	defer c.fallbackMu.Unlock()
+
+	c.streamFailures = 0
+	if c.fallbackStop == nil {
+		return
+	}
+	c.config.Logger.Info("SSE recovered, stopping fallback polling")
+	close(c.fallbackStop)
+	c.fallbackStop = nil
+}
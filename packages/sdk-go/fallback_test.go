@@ -0,0 +1,103 @@
+package rollgate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_StreamFallback(t *testing.T) {
+	var streamUp, enabled atomic.Bool
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			polls.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"flags":{"beta":%t}}`, enabled.Load())
+		case "/api/v1/sdk/stream":
+			if !streamUp.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: init\ndata: {\"flags\":{\"beta\":%t}}\n\n", enabled.Load())
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	client, err := NewClient(Config{
+		APIKey:                  "test-key",
+		BaseURL:                 server.URL,
+		EnableStreaming:         true,
+		RefreshInterval:         20 * time.Millisecond,
+		StreamFallbackThreshold: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if mode := client.GetConnectionMode(); mode != ConnectionNone {
+		t.Errorf("expected no connection before Init, got %s", mode)
+	}
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer client.Close()
+
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	t.Run("should poll once the stream keeps failing", func(t *testing.T) {
+		waitFor("the fallback", func() bool { return client.GetConnectionMode() == ConnectionPolling })
+		enabled.Store(true)
+		waitFor("a polled update", func() bool { return client.IsEnabled("beta", false) })
+	})
+
+	t.Run("should stop polling when the stream recovers", func(t *testing.T) {
+		streamUp.Store(true)
+		waitFor("the stream", func() bool { return client.GetConnectionMode() == ConnectionStreaming })
+		time.Sleep(50 * time.Millisecond)
+		before := polls.Load()
+		time.Sleep(100 * time.Millisecond)
+		if n := polls.Load() - before; n != 0 {
+			t.Errorf("expected no polls while streaming, got %d", n)
+		}
+	})
+
+	client.Close()
+	if mode := client.GetConnectionMode(); mode != ConnectionNone {
+		t.Errorf("expected no connection after Close, got %s", mode)
+	}
+}
+
+func TestClient_GetConnectionMode(t *testing.T) {
+	server := newTestServer(map[string]bool{"beta": true})
+	defer server.Close()
+
+	polling := newIntegrationClient(t, Config{APIKey: "test-key", BaseURL: server.URL, RefreshInterval: time.Hour})
+	if mode := polling.GetConnectionMode(); mode != ConnectionPolling {
+		t.Errorf("expected polling, got %s", mode)
+	}
+
+	offline := newIntegrationClient(t, Config{Offline: true})
+	if mode := offline.GetConnectionMode(); mode != ConnectionOffline {
+		t.Errorf("expected offline, got %s", mode)
+	}
+}
@@ -0,0 +1,107 @@
+package rollgate
+
+import "sync"
+
+// OtherFlagLabel is the flag label that evaluations of flags beyond the
+// label limit, or outside the allowlist, are counted under.
+const OtherFlagLabel = "other"
+
+// FlagMetricsConfig configures per-flag evaluation metrics. Each distinct
+// flag key becomes a label value, so the number of labels is capped to keep
+// Prometheus cardinality bounded.
+type FlagMetricsConfig struct {
+	// Enabled counts evaluations per flag key (default: false)
+	Enabled bool
+
+	// Allowlist restricts labels to these flag keys; other keys are counted
+	// under OtherFlagLabel (optional, default: all keys)
+	Allowlist []string
+
+	// MaxFlags caps the number of distinct flag labels; keys seen after the
+	// limit is reached are counted under OtherFlagLabel (default: 100)
+	MaxFlags int
+}
+
+// DefaultFlagMetricsConfig returns default per-flag metrics settings (disabled).
+func DefaultFlagMetricsConfig() FlagMetricsConfig {
+	return FlagMetricsConfig{
+		Enabled:  false,
+		MaxFlags: 100,
+	}
+}
+
+// flagLabelGuard maps flag keys to bounded label values and counts
+// evaluations per label.
+type flagLabelGuard struct {
+	mu        sync.Mutex
+	allowlist map[string]bool // nil allows every key
+	maxFlags  int
+	labels    map[string]bool  // Keys that got their own label
+	counts    map[string]int64 // Evaluations per label
+	dropped   int64            // Evaluations folded into OtherFlagLabel
+	overLimit bool             // A key was folded in because of maxFlags
+}
+
+func newFlagLabelGuard(config FlagMetricsConfig) *flagLabelGuard {
+	g := &flagLabelGuard{
+		maxFlags: config.MaxFlags,
+		labels:   make(map[string]bool),
+		counts:   make(map[string]int64),
+	}
+	if len(config.Allowlist) > 0 {
+		g.allowlist = make(map[string]bool, len(config.Allowlist))
+		for _, key := range config.Allowlist {
+			g.allowlist[key] = true
+		}
+	}
+	return g
+}
+
+// record counts an evaluation of flagKey under its label. limitHit is true
+// the first time a key is folded into OtherFlagLabel because the limit was
+// reached. Keys turned away are not remembered, so memory stays bounded by
+// maxFlags whatever keys are evaluated.
+func (g *flagLabelGuard) record(flagKey string) (limitHit bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	label := flagKey
+	switch {
+	case g.labels[flagKey]:
+	case g.allowlist != nil && !g.allowlist[flagKey]:
+		label = OtherFlagLabel
+	case len(g.labels) >= g.maxFlags:
+		label = OtherFlagLabel
+		limitHit = !g.overLimit
+		g.overLimit = true
+	default:
+		g.labels[flagKey] = true
+	}
+	if label == OtherFlagLabel && !g.labels[flagKey] {
+		g.dropped++
+	}
+	g.counts[label]++
+	return limitHit
+}
+
+// snapshot returns a copy of the per-label counts, the number of
+// evaluations folded into OtherFlagLabel and whether the limit was reached.
+func (g *flagLabelGuard) snapshot() (counts map[string]int64, dropped int64, limitReached bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	counts = make(map[string]int64, len(g.counts))
+	for label, n := range g.counts {
+		counts[label] = n
+	}
+	return counts, g.dropped, g.overLimit
+}
+
+// reset forgets every key and count seen so far.
+func (g *flagLabelGuard) reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.labels = make(map[string]bool)
+	g.counts = make(map[string]int64)
+	g.dropped = 0
+	g.overLimit = false
+}
@@ -0,0 +1,97 @@
+package rollgate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSDKMetrics_FlagMetricsDisabled(t *testing.T) {
+	m := NewSDKMetrics()
+
+	if m.RecordFlagEvaluation("flag-a") {
+		t.Error("expected no limit hit when per-flag metrics are disabled")
+	}
+	if snap := m.Snapshot(); snap.FlagEvaluations != nil {
+		t.Errorf("expected no per-flag counts, got %v", snap.FlagEvaluations)
+	}
+	if strings.Contains(m.ToPrometheus("rollgate"), "flag_evaluations_total") {
+		t.Error("per-flag metrics should not be exported when disabled")
+	}
+}
+
+func TestSDKMetrics_FlagLabelLimit(t *testing.T) {
+	m := NewSDKMetrics()
+	m.EnableFlagMetrics(FlagMetricsConfig{Enabled: true, MaxFlags: 3})
+
+	hits := 0
+	for i := 0; i < 5; i++ {
+		for j := 0; j <= i; j++ {
+			if m.RecordFlagEvaluation(fmt.Sprintf("flag-%d", i)) {
+				hits++
+			}
+		}
+	}
+	if hits != 1 {
+		t.Errorf("expected the limit to be reported once, got %d", hits)
+	}
+
+	snap := m.Snapshot()
+	want := map[string]int64{"flag-0": 1, "flag-1": 2, "flag-2": 3, OtherFlagLabel: 4 + 5}
+	if len(snap.FlagEvaluations) != len(want) {
+		t.Fatalf("expected labels %v, got %v", want, snap.FlagEvaluations)
+	}
+	for label, n := range want {
+		if snap.FlagEvaluations[label] != n {
+			t.Errorf("%s: expected %d evaluations, got %d", label, n, snap.FlagEvaluations[label])
+		}
+	}
+	if snap.FlagLabelsDropped != 4+5 {
+		t.Errorf("expected 9 dropped evaluations, got %d", snap.FlagLabelsDropped)
+	}
+	if !snap.FlagLabelLimitReached {
+		t.Error("expected the limit to be reported as reached")
+	}
+
+	prom := m.ToPrometheus("rollgate")
+	for _, line := range []string{
+		`rollgate_flag_evaluations_total{flag="flag-2"} 3`,
+		`rollgate_flag_evaluations_total{flag="other"} 9`,
+		"rollgate_flag_labels_dropped_total 9",
+		"rollgate_flag_label_limit_reached 1",
+	} {
+		if !strings.Contains(prom, line) {
+			t.Errorf("expected %q in Prometheus output", line)
+		}
+	}
+
+	m.Reset()
+	snap = m.Snapshot()
+	if len(snap.FlagEvaluations) != 0 || snap.FlagLabelsDropped != 0 || snap.FlagLabelLimitReached {
+		t.Errorf("expected per-flag metrics to be cleared, got %+v", snap)
+	}
+}
+
+func TestSDKMetrics_FlagAllowlist(t *testing.T) {
+	m := NewSDKMetrics()
+	m.EnableFlagMetrics(FlagMetricsConfig{Enabled: true, Allowlist: []string{"checkout"}})
+
+	m.RecordFlagEvaluation("checkout")
+	if m.RecordFlagEvaluation("user-123-experiment") {
+		t.Error("keys outside the allowlist should not report the label limit")
+	}
+
+	snap := m.Snapshot()
+	if snap.FlagEvaluations["checkout"] != 1 || snap.FlagEvaluations[OtherFlagLabel] != 1 {
+		t.Errorf("unexpected per-flag counts: %v", snap.FlagEvaluations)
+	}
+	if snap.FlagLabelLimitReached {
+		t.Error("allowlist filtering is not the label limit")
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	if got := escapeLabelValue("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Errorf("unexpected escaping: %s", got)
+	}
+}
@@ -0,0 +1,101 @@
+package rollgate
+
+import (
+	"context"
+	"time"
+)
+
+// FreezeFlags pins evaluations to the flags the client holds now for d,
+// for flag stability during a deployment window. Polling and streaming
+// continue, but the updates they receive are not applied; when the freeze
+// ends the client refreshes and applies the latest flags. Calling it again
+// replaces the deadline, and a d of zero or less ends the freeze.
+//
+// Identify and Reset end the freeze, since the frozen flags were evaluated
+// for the previous user. With local evaluation the frozen targeting rules
+// keep applying to the new user.
+func (c *Client) FreezeFlags(d time.Duration) {
+	if d <= 0 {
+		c.UnfreezeFlags()
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.freezeTimer != nil {
+		c.freezeTimer.Stop()
+	}
+	until := time.Now().Add(d).UnixNano()
+	c.frozenUntil.Store(until)
+	c.freezeTimer = time.AfterFunc(d, func() { c.endFreeze(until) })
+	c.metrics.RecordFrozen(true)
+}
+
+// UnfreezeFlags ends a freeze started by FreezeFlags before its deadline
+// and refreshes the flags in the background.
+func (c *Client) UnfreezeFlags() {
+	c.endFreeze(0)
+}
+
+// IsFrozen reports whether FreezeFlags is in effect.
+func (c *Client) IsFrozen() bool {
+	return c.frozen()
+}
+
+// frozen reports whether updates are currently held back. It is read on
+// every evaluation, so it does not take c.mu.
+func (c *Client) frozen() bool {
+	until := c.frozenUntil.Load()
+	return until != 0 && time.Now().UnixNano() < until
+}
+
+// heldByFreeze reports whether an update must be dropped because the flags
+// are frozen, and counts it if so. Caller must hold c.mu.
+func (c *Client) heldByFreeze() bool {
+	if !c.frozen() {
+		return false
+	}
+	c.metrics.RecordFrozenUpdate()
+	return true
+}
+
+// endFreeze ends the freeze with deadline until, or any freeze if until is
+// 0, then refreshes in the background to apply the updates held back. A
+// timer firing for a deadline that FreezeFlags has since replaced does
+// nothing.
+func (c *Client) endFreeze(until int64) {
+	c.mu.Lock()
+	ended := c.clearFreeze(until)
+	refresh := ended && c.ready && !c.closed
+	c.mu.Unlock()
+
+	if refresh {
+		go c.refreshAfterFreeze()
+	}
+}
+
+// clearFreeze ends the freeze with deadline until, or any freeze if until
+// is 0, and reports whether one was ended. Caller must hold c.mu.
+func (c *Client) clearFreeze(until int64) bool {
+	current := c.frozenUntil.Load()
+	if current == 0 || (until != 0 && current != until) {
+		return false
+	}
+	if c.freezeTimer != nil {
+		c.freezeTimer.Stop()
+		c.freezeTimer = nil
+	}
+	c.frozenUntil.Store(0)
+	c.metrics.RecordFrozen(false)
+	return true
+}
+
+// refreshAfterFreeze fetches the flags held back while frozen. Close
+// cancels it.
+func (c *Client) refreshAfterFreeze() {
+	ctx, cancel := context.WithTimeout(c.ctx, c.config.Timeout)
+	defer cancel()
+	if err := c.Refresh(ctx); err != nil && c.ctx.Err() == nil {
+		c.config.Logger.Warn("refresh after flag freeze failed", "error", err)
+	}
+}
@@ -0,0 +1,80 @@
+package rollgate
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClient_FreezeFlags(t *testing.T) {
+	m := newMockServer(t, &mockFlag{Key: "beta", Enabled: true, Rollout: 100})
+	config := m.config()
+	config.User = &UserContext{ID: "user-1"}
+	client := newIntegrationClient(t, config)
+	ctx := context.Background()
+
+	waitFor := func(t *testing.T, what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	t.Run("should keep serving the frozen flags", func(t *testing.T) {
+		client.FreezeFlags(time.Hour)
+		m.setFlag(&mockFlag{Key: "beta", Enabled: false})
+		before := m.requestCount("/api/v1/sdk/flags")
+		if err := client.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+		if m.requestCount("/api/v1/sdk/flags") == before {
+			t.Error("expected the client to keep fetching while frozen")
+		}
+
+		detail := client.IsEnabledDetail("beta", false)
+		if !detail.Value || !detail.Reason.Frozen {
+			t.Errorf("expected the frozen value with a frozen reason, got %+v", detail)
+		}
+		metrics := client.GetMetrics()
+		if !metrics.FlagsFrozen || metrics.FrozenUpdates != 1 {
+			t.Errorf("expected a frozen client with 1 held update, got %v and %d", metrics.FlagsFrozen, metrics.FrozenUpdates)
+		}
+	})
+
+	t.Run("should apply the held updates when unfrozen", func(t *testing.T) {
+		client.UnfreezeFlags()
+		if client.IsFrozen() || client.GetMetrics().FlagsFrozen {
+			t.Error("expected the freeze to end")
+		}
+		waitFor(t, "the held update", func() bool { return !client.IsEnabled("beta", true) })
+		if client.IsEnabledDetail("beta", true).Reason.Frozen {
+			t.Error("expected reasons without the frozen mark")
+		}
+	})
+
+	t.Run("should end at the deadline", func(t *testing.T) {
+		client.FreezeFlags(time.Hour)
+		client.FreezeFlags(50 * time.Millisecond)
+		m.setFlag(&mockFlag{Key: "beta", Enabled: true, Rollout: 100})
+		client.Refresh(ctx)
+		if !client.IsFrozen() || client.IsEnabled("beta", false) {
+			t.Fatal("expected the update to be held")
+		}
+		waitFor(t, "the freeze to expire", func() bool { return client.IsEnabled("beta", false) })
+	})
+
+	t.Run("should end when the user changes", func(t *testing.T) {
+		client.FreezeFlags(time.Hour)
+		m.setFlag(&mockFlag{Key: "beta", Enabled: true, TargetUsers: []string{"user-1"}})
+		if err := client.Identify(ctx, &UserContext{ID: "user-2"}); err != nil {
+			t.Fatalf("Identify failed: %v", err)
+		}
+		if client.IsFrozen() || client.IsEnabled("beta", true) {
+			t.Error("expected Identify to end the freeze and apply user-2's flags")
+		}
+	})
+}
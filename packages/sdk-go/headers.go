@@ -0,0 +1,44 @@
+package rollgate
+
+import "net/http"
+
+const (
+	sdkName    = "rollgate-go"
+	sdkVersion = "1.1.0"
+)
+
+// userAgent identifies the SDK on every outbound request unless
+// Config.CustomHeaders sets its own User-Agent.
+const userAgent = sdkName + "/" + sdkVersion
+
+// setRequestHeaders applies custom headers and the SDK identification
+// headers to req. Custom headers go first so they can never replace the
+// SDK name and version; callers set Content-Type and other protocol
+// headers afterwards for the same reason. Authorization is always skipped,
+// since the stream authenticates with a query parameter instead.
+func setRequestHeaders(req *http.Request, custom map[string]string) {
+	for name, value := range custom {
+		if http.CanonicalHeaderKey(name) == "Authorization" {
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	req.Header.Set("X-SDK-Name", sdkName)
+	req.Header.Set("X-SDK-Version", sdkVersion)
+}
+
+// copyHeaders returns a copy of headers so later changes to the caller's
+// map do not race with in-flight requests.
+func copyHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(headers))
+	for name, value := range headers {
+		out[name] = value
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+package rollgate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetRequestHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	setRequestHeaders(req, map[string]string{
+		"X-Gateway-Route": "flags",
+		"X-SDK-Name":      "spoofed",
+		"authorization":   "Basic spoofed",
+	})
+
+	if got := req.Header.Get("X-Gateway-Route"); got != "flags" {
+		t.Errorf("expected custom header, got %q", got)
+	}
+	if got := req.Header.Get("X-SDK-Name"); got != sdkName {
+		t.Errorf("custom headers must not replace X-SDK-Name, got %q", got)
+	}
+	if got := req.Header.Get("Authorization"); got != "" {
+		t.Errorf("custom headers must not set Authorization, got %q", got)
+	}
+	if got := req.Header.Get("User-Agent"); got != userAgent {
+		t.Errorf("expected default User-Agent, got %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	setRequestHeaders(req, map[string]string{"User-Agent": "my-service/2.0"})
+	if got := req.Header.Get("User-Agent"); got != "my-service/2.0" {
+		t.Errorf("expected custom User-Agent, got %q", got)
+	}
+}
+
+func TestClient_CustomHeaders(t *testing.T) {
+	var mu sync.Mutex
+	seen := make(map[string]http.Header)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.Path] = r.Header.Clone()
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"flags":{"flag":true}}`))
+		case "/api/v1/sdk/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	headers := map[string]string{"X-Gateway-Route": "rollgate", "Authorization": "Basic spoofed"}
+	config := DefaultConfig("test-key")
+	config.BaseURL = server.URL
+	config.RefreshInterval = time.Hour
+	config.EnableStreaming = true
+	config.CustomHeaders = headers
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+	// Changing the caller's map afterwards must not affect requests
+	headers["X-Gateway-Route"] = "changed"
+
+	ctx := context.Background()
+	if err := client.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := client.Identify(ctx, &UserContext{ID: "user-1"}); err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+	client.IsEnabled("flag", false)
+	client.Track(NewTrackEvent("flag", "purchase", "user-1"))
+	if err := client.FlushEvents(ctx); err != nil {
+		t.Fatalf("FlushEvents failed: %v", err)
+	}
+	if err := client.FlushTelemetry(ctx); err != nil {
+		t.Fatalf("FlushTelemetry failed: %v", err)
+	}
+
+	paths := []string{
+		"/api/v1/sdk/flags",
+		"/api/v1/sdk/stream",
+		"/api/v1/sdk/identify",
+		"/api/v1/sdk/events",
+		"/api/v1/sdk/telemetry",
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(seen)
+		mu.Unlock()
+		if n >= len(paths) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range paths {
+		h, ok := seen[path]
+		if !ok {
+			t.Errorf("%s: no request received", path)
+			continue
+		}
+		if got := h.Get("X-Gateway-Route"); got != "rollgate" {
+			t.Errorf("%s: expected X-Gateway-Route rollgate, got %q", path, got)
+		}
+		want := "Bearer test-key"
+		if path == "/api/v1/sdk/stream" {
+			want = "" // Authenticated by the token query parameter
+		}
+		if got := h.Get("Authorization"); got != want {
+			t.Errorf("%s: custom headers must not replace Authorization, got %q", path, got)
+		}
+		if got := h.Get("User-Agent"); got != userAgent {
+			t.Errorf("%s: expected User-Agent %s, got %q", path, userAgent, got)
+		}
+	}
+}
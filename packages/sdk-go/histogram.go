@@ -0,0 +1,75 @@
+package rollgate
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// RequestLatencyBucketsMs are the upper bounds, in milliseconds, of
+// MetricsSnapshot.RequestLatencyHistogram.
+var RequestLatencyBucketsMs = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// EvaluationTimeBucketsMs are the upper bounds, in milliseconds, of
+// MetricsSnapshot.EvaluationTimeHistogram.
+var EvaluationTimeBucketsMs = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// Histogram is a cumulative histogram of observations since the metrics
+// were created or reset, in the shape Prometheus histograms expect.
+type Histogram struct {
+	Count   uint64             // Number of observations
+	Sum     float64            // Sum of all observations
+	Buckets map[float64]uint64 // Observations <= each upper bound; +Inf is implied by Count
+}
+
+// histogram records observations into fixed buckets without locks, so it
+// can sit on the evaluation path.
+type histogram struct {
+	bounds  []float64
+	counts  []uint64 // Per bucket, not cumulative; the last one is +Inf
+	sumBits uint64   // math.Float64bits of the sum
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// observe records one observation.
+func (h *histogram) observe(v float64) {
+	i := 0
+	for i < len(h.bounds) && v > h.bounds[i] {
+		i++
+	}
+	atomic.AddUint64(&h.counts[i], 1)
+	for {
+		old := atomic.LoadUint64(&h.sumBits)
+		if atomic.CompareAndSwapUint64(&h.sumBits, old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// snapshot returns the cumulative histogram. Count is derived from the
+// buckets so the two always agree.
+func (h *histogram) snapshot() Histogram {
+	s := Histogram{
+		Sum:     math.Float64frombits(atomic.LoadUint64(&h.sumBits)),
+		Buckets: make(map[float64]uint64, len(h.bounds)),
+	}
+	for i, bound := range h.bounds {
+		s.Count += atomic.LoadUint64(&h.counts[i])
+		s.Buckets[bound] = s.Count
+	}
+	s.Count += atomic.LoadUint64(&h.counts[len(h.bounds)])
+	return s
+}
+
+// reset clears every observation.
+func (h *histogram) reset() {
+	for i := range h.counts {
+		atomic.StoreUint64(&h.counts[i], 0)
+	}
+	atomic.StoreUint64(&h.sumBits, 0)
+}
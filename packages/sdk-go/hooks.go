@@ -0,0 +1,95 @@
+package rollgate
+
+import "fmt"
+
+// Hook observes flag evaluations, for logging, tracing or audit
+// integrations. Register hooks with Client.AddHook.
+//
+// Hooks run synchronously on the evaluating goroutine, outside the
+// client's locks, so they may call the client but must be fast and safe
+// for concurrent use.
+type Hook interface {
+	// BeforeEvaluation is called before flagKey is evaluated.
+	BeforeEvaluation(flagKey string, ctx HookContext)
+	// AfterEvaluation is called with the result. err is an
+	// *EvaluationError when the result has an ERROR reason, and nil
+	// otherwise.
+	AfterEvaluation(flagKey string, detail EvaluationDetail[any], err error)
+}
+
+// HookContext describes the evaluation a Hook is called for.
+type HookContext struct {
+	// FlagType is the type the caller asked for: FlagTypeBoolean,
+	// FlagTypeString, FlagTypeNumber (also for GetInt), FlagTypeJSON or
+	// FlagTypeDuration.
+	FlagType string
+	// DefaultValue is the default passed by the caller.
+	DefaultValue any
+	// User is the user being evaluated: the WithUser/WithAttributes
+	// override if any, otherwise the current user. It may be nil and must
+	// not be modified.
+	User *UserContext
+}
+
+// EvaluationError is the error Hook.AfterEvaluation receives for an
+// evaluation that served the default because of an error.
+type EvaluationError struct {
+	FlagKey string
+	Kind    EvaluationErrorKind
+}
+
+func (e *EvaluationError) Error() string {
+	return fmt.Sprintf("rollgate: evaluating %q: %s", e.FlagKey, e.Kind)
+}
+
+// AddHook registers a hook for every later evaluation. BeforeEvaluation
+// hooks run in registration order and AfterEvaluation hooks in reverse
+// order, so the first hook registered wraps all the others.
+func (c *Client) AddHook(hook Hook) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Copy on write, so evaluations can iterate the slice they read
+	// without holding the lock
+	hooks := make([]Hook, len(c.hooks), len(c.hooks)+1)
+	copy(hooks, c.hooks)
+	c.hooks = append(hooks, hook)
+}
+
+// withHooks runs the registered hooks around evaluate.
+func withHooks[T any](c *Client, flagKey, flagType string, defaultValue T, o *evalOptions, evaluate func() EvaluationDetail[T]) EvaluationDetail[T] {
+	c.mu.RLock()
+	hooks := c.hooks
+	var user *UserContext
+	if len(hooks) > 0 {
+		if user = c.overrideUser(o); user == nil {
+			user = c.user
+		}
+	}
+	c.mu.RUnlock()
+
+	if len(hooks) == 0 {
+		return evaluate()
+	}
+
+	ctx := HookContext{FlagType: flagType, DefaultValue: defaultValue, User: user}
+	for _, hook := range hooks {
+		hook.BeforeEvaluation(flagKey, ctx)
+	}
+
+	detail := evaluate()
+
+	result := EvaluationDetail[any]{
+		Value:          detail.Value,
+		Reason:         detail.Reason,
+		VariationIndex: detail.VariationIndex,
+		VariationID:    detail.VariationID,
+	}
+	var err error
+	if detail.Reason.Kind == ReasonError {
+		err = &EvaluationError{FlagKey: flagKey, Kind: detail.Reason.ErrorKind}
+	}
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].AfterEvaluation(flagKey, result, err)
+	}
+	return detail
+}
@@ -0,0 +1,134 @@
+package rollgate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingHook appends "<name>:before:<flag>" and "<name>:after:<flag>"
+// to a shared log and keeps the last context and result it saw.
+type recordingHook struct {
+	name string
+	log  *[]string
+	mu   *sync.Mutex
+
+	ctx    HookContext
+	detail EvaluationDetail[any]
+	err    error
+}
+
+func (h *recordingHook) BeforeEvaluation(flagKey string, ctx HookContext) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.log = append(*h.log, fmt.Sprintf("%s:before:%s", h.name, flagKey))
+	h.ctx = ctx
+}
+
+func (h *recordingHook) AfterEvaluation(flagKey string, detail EvaluationDetail[any], err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.log = append(*h.log, fmt.Sprintf("%s:after:%s", h.name, flagKey))
+	h.detail, h.err = detail, err
+}
+
+func TestClient_Hooks(t *testing.T) {
+	m := newMockServer(t, &mockFlag{Key: "beta", Enabled: true, TargetUsers: []string{"vip"}})
+	config := m.config()
+	config.User = &UserContext{ID: "user-1"}
+
+	var mu sync.Mutex
+	var log []string
+	first := &recordingHook{name: "first", log: &log, mu: &mu}
+	second := &recordingHook{name: "second", log: &log, mu: &mu}
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+	client.AddHook(first)
+	client.AddHook(second)
+
+	t.Run("should report an error before Init", func(t *testing.T) {
+		client.IsEnabled("beta", true)
+		var evalErr *EvaluationError
+		if !errors.As(first.err, &evalErr) || evalErr.Kind != ErrorClientNotReady || evalErr.FlagKey != "beta" {
+			t.Errorf("expected a CLIENT_NOT_READY EvaluationError, got %v", first.err)
+		}
+		if first.detail.Value != true {
+			t.Errorf("expected the default in the result, got %+v", first.detail)
+		}
+	})
+
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	t.Run("should wrap evaluations in registration order", func(t *testing.T) {
+		mu.Lock()
+		log = nil
+		mu.Unlock()
+		client.IsEnabled("beta", false)
+		want := []string{"first:before:beta", "second:before:beta", "second:after:beta", "first:after:beta"}
+		if fmt.Sprint(log) != fmt.Sprint(want) {
+			t.Errorf("expected %v, got %v", want, log)
+		}
+		if first.err != nil || first.detail.Reason.Kind == ReasonError {
+			t.Errorf("expected a successful evaluation, got %+v, %v", first.detail, first.err)
+		}
+	})
+
+	t.Run("should describe the evaluated user", func(t *testing.T) {
+		client.IsEnabled("beta", false)
+		if first.ctx.FlagType != FlagTypeBoolean || first.ctx.DefaultValue != false || first.ctx.User.ID != "user-1" {
+			t.Errorf("unexpected hook context: %+v", first.ctx)
+		}
+
+		detail := client.IsEnabledDetail("beta", false, WithUser("vip"))
+		if first.ctx.User.ID != "vip" {
+			t.Errorf("expected the override user, got %+v", first.ctx.User)
+		}
+		if first.detail.Value != detail.Value || first.detail.Reason != detail.Reason {
+			t.Errorf("expected the hook to see %+v, got %+v", detail, first.detail)
+		}
+	})
+}
+
+func TestClient_HooksTyped(t *testing.T) {
+	var typedRequests atomic.Int32
+	server := newTypedServer(t, &typedRequests)
+	client, err := NewClient(Config{APIKey: "test-key", BaseURL: server.URL, RefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	var log []string
+	hook := &recordingHook{name: "hook", log: &log, mu: &mu}
+	client.AddHook(hook)
+
+	if got := client.GetString("banner-text", "default"); got != "Welcome" {
+		t.Fatalf("expected Welcome, got %q", got)
+	}
+	if hook.ctx.FlagType != FlagTypeString || hook.detail.Value != "Welcome" || hook.detail.VariationID != "welcome" {
+		t.Errorf("unexpected hook call: %+v, %+v", hook.ctx, hook.detail)
+	}
+
+	client.GetNumber("banner-text", 1)
+	var evalErr *EvaluationError
+	if !errors.As(hook.err, &evalErr) || evalErr.Kind != ErrorWrongType {
+		t.Errorf("expected a WRONG_TYPE EvaluationError, got %v", hook.err)
+	}
+	if len(log) != 4 {
+		t.Errorf("expected 2 hooked evaluations, got %v", log)
+	}
+}
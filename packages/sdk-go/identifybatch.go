@@ -0,0 +1,187 @@
+package rollgate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// maxIdentifyBatchSize is the most users sent in one identify batch
+	// request; larger batches are split.
+	maxIdentifyBatchSize = 500
+	// prefetchConcurrency bounds the flags requests IdentifyBatch sends at
+	// once while prefetching.
+	prefetchConcurrency = 8
+)
+
+// identifyBatchOptions holds the options of one IdentifyBatch call.
+type identifyBatchOptions struct {
+	prefetch bool
+}
+
+// IdentifyBatchOption is a functional option for Client.IdentifyBatch.
+type IdentifyBatchOption func(*identifyBatchOptions)
+
+// WithPrefetch makes IdentifyBatch also fetch each user's flags into the
+// per-user cache, so evaluations with WithUser(user.ID) are served
+// without a request while the entry is fresh (one minute). The cache keeps
+// the flags of the last 1000 users.
+func WithPrefetch() IdentifyBatchOption {
+	return func(o *identifyBatchOptions) {
+		o.prefetch = true
+	}
+}
+
+// IdentifyBatch registers users server-side, up to 500 per request, for
+// workers that evaluate flags for many known users. Unlike Identify it
+// leaves the client's own user and flags unchanged. Users without an ID
+// are skipped, and nothing is sent in offline or local evaluation mode.
+//
+// Registration stops at the first failed request. Prefetching, with
+// WithPrefetch, fetches every user even if some fail and reports how many
+// did.
+func (c *Client) IdentifyBatch(ctx context.Context, users []UserContext, opts ...IdentifyBatchOption) error {
+	o := &identifyBatchOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if c.config.Offline || c.evaluator != nil {
+		return nil
+	}
+
+	batch := make([]*UserContext, 0, len(users))
+	for i := range users {
+		if users[i].ID != "" {
+			batch = append(batch, &users[i])
+		}
+	}
+	for start := 0; start < len(batch); start += maxIdentifyBatchSize {
+		end := min(start+maxIdentifyBatchSize, len(batch))
+		if err := c.sendIdentifyBatch(ctx, batch[start:end]); err != nil {
+			return err
+		}
+	}
+
+	if !o.prefetch {
+		return nil
+	}
+	return c.prefetchUsers(ctx, batch)
+}
+
+// sendIdentifyBatch registers one request's worth of users.
+func (c *Client) sendIdentifyBatch(ctx context.Context, users []*UserContext) (err error) {
+	u := c.baseURL(ctx) + "/api/v1/sdk/identify/batch"
+
+	start := time.Now()
+	var statusCode int
+	defer func() {
+		c.config.RequestObserver.notify(RequestInfo{
+			Method:     http.MethodPost,
+			Endpoint:   u,
+			Duration:   time.Since(start),
+			StatusCode: statusCode,
+			Error:      err,
+		})
+	}()
+
+	payload := make([]map[string]interface{}, len(users))
+	for i, user := range users {
+		payload[i] = identifyUser(user)
+	}
+	body, err := json.Marshal(map[string]interface{}{"users": payload})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	setRequestHeaders(req, c.config.CustomHeaders)
+	if err := c.tokens.authorize(req); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrClientRateLimited) {
+			return ErrClientRateLimited
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	statusCode = resp.StatusCode
+	if resp.StatusCode == http.StatusUnauthorized {
+		c.tokens.rejected(req)
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("identify batch failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// prefetchUsers fetches the flags of users into the per-user cache, a few
+// at a time.
+func (c *Client) prefetchUsers(ctx context.Context, users []*UserContext) error {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed int
+		first  error
+	)
+	sem := make(chan struct{}, prefetchConcurrency)
+	for _, user := range users {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(user *UserContext) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := c.prefetchUser(ctx, user); err != nil {
+				mu.Lock()
+				failed++
+				if first == nil {
+					first = err
+				}
+				mu.Unlock()
+			}
+		}(user)
+	}
+	wg.Wait()
+
+	if failed > 0 {
+		return fmt.Errorf("failed to prefetch flags for %d of %d users: %w", failed, len(users), first)
+	}
+	return nil
+}
+
+// prefetchUser fetches the flags of user and caches them where an
+// evaluation with WithUser(user.ID) looks them up.
+func (c *Client) prefetchUser(ctx context.Context, user *UserContext) error {
+	c.mu.RLock()
+	key := overrideKey(c.overrideUser(&evalOptions{userID: user.ID}))
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+	var entry *overrideEntry
+	_, err := c.executeFetch(ctx, c.baseURL(ctx)+"/api/v1/sdk/flags", func(ctx context.Context, attempt *fetchAttempt) error {
+		var err error
+		entry, err = c.doFetchOverrideRequest(ctx, user, attempt)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	c.overrides.set(key, entry)
+	return nil
+}
@@ -0,0 +1,99 @@
+package rollgate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClient_IdentifyBatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should register users in batches without changing the client's user", func(t *testing.T) {
+		m := newMockServer(t)
+		client := newIntegrationClient(t, m.config())
+
+		users := make([]UserContext, 0, 1201)
+		for i := 0; i < 1200; i++ {
+			users = append(users, UserContext{ID: fmt.Sprintf("user-%d", i), Attributes: map[string]any{"n": i}})
+		}
+		users = append(users, UserContext{Email: "anonymous@example.com"})
+		if err := client.IdentifyBatch(ctx, users); err != nil {
+			t.Fatalf("IdentifyBatch failed: %v", err)
+		}
+
+		if got := m.requestCount("/api/v1/sdk/identify/batch"); got != 3 {
+			t.Errorf("expected 3 batch requests, got %d", got)
+		}
+		m.mu.Lock()
+		sessions := len(m.sessions)
+		m.mu.Unlock()
+		if sessions != 1200 {
+			t.Errorf("expected 1200 identified users, got %d", sessions)
+		}
+		if got := m.requestCount("/api/v1/sdk/flags"); got != 1 {
+			t.Errorf("expected no flags request after Init, got %d", got-1)
+		}
+		client.mu.RLock()
+		user := client.user
+		client.mu.RUnlock()
+		if user != nil {
+			t.Error("expected the client's user to be unchanged")
+		}
+	})
+
+	t.Run("should prefetch flags for WithUser evaluations", func(t *testing.T) {
+		m := newMockServer(t, &mockFlag{Key: "pro-only", Enabled: true, Rules: []mockRule{{
+			ID:         "pro-plan",
+			Conditions: []mockCondition{{Attribute: "plan", Operator: "eq", Value: "pro"}},
+			Rollout:    100,
+		}}})
+		client := newIntegrationClient(t, m.config())
+
+		err := client.IdentifyBatch(ctx, []UserContext{
+			{ID: "pro-user", Attributes: map[string]any{"plan": "pro"}},
+			{ID: "free-user", Attributes: map[string]any{"plan": "free"}},
+		}, WithPrefetch())
+		if err != nil {
+			t.Fatalf("IdentifyBatch failed: %v", err)
+		}
+		if got := m.requestCount("/api/v1/sdk/flags"); got != 3 {
+			t.Fatalf("expected a flags request per user, got %d", got-1)
+		}
+
+		if !client.IsEnabled("pro-only", false, WithUser("pro-user")) {
+			t.Error("expected pro-only for the pro user")
+		}
+		if client.IsEnabled("pro-only", true, WithUser("free-user")) {
+			t.Error("expected no pro-only for the free user")
+		}
+		if got := m.requestCount("/api/v1/sdk/flags"); got != 3 {
+			t.Errorf("expected evaluations from the cache, got %d more requests", got-3)
+		}
+	})
+
+	t.Run("should report users whose flags could not be prefetched", func(t *testing.T) {
+		m := newMockServer(t)
+		client := newIntegrationClient(t, m.config())
+
+		m.failNext(http.StatusForbidden, 1)
+		err := client.IdentifyBatch(ctx, []UserContext{{ID: "user-1"}, {ID: "user-2"}}, WithPrefetch())
+		if err == nil || !strings.Contains(err.Error(), "1 of 2 users") {
+			t.Errorf("expected one failed prefetch, got %v", err)
+		}
+	})
+
+	t.Run("should send nothing offline", func(t *testing.T) {
+		server := newForbiddenServer(t)
+		client, err := NewClient(Config{BaseURL: server.URL, Offline: true})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+		if err := client.IdentifyBatch(ctx, []UserContext{{ID: "user-1"}}, WithPrefetch()); err != nil {
+			t.Errorf("expected no error offline, got %v", err)
+		}
+	})
+}
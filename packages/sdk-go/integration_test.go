@@ -0,0 +1,261 @@
+package rollgate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newIntegrationClient creates and initializes a client against m.
+func newIntegrationClient(t *testing.T, config Config) *Client {
+	t.Helper()
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	return client
+}
+
+func TestIntegration_Targeting(t *testing.T) {
+	m := newMockServer(t,
+		&mockFlag{Key: "everyone", Enabled: true, Rollout: 100},
+		&mockFlag{Key: "disabled", Enabled: false, Rollout: 100},
+		&mockFlag{Key: "beta", Enabled: true, TargetUsers: []string{"user-1"}},
+		&mockFlag{Key: "pro-only", Enabled: true, Rules: []mockRule{{
+			ID:         "pro-plan",
+			Conditions: []mockCondition{{Attribute: "plan", Operator: "eq", Value: "pro"}},
+			Rollout:    100,
+		}}},
+	)
+	client := newIntegrationClient(t, m.config())
+
+	anonymous := map[string]bool{"everyone": true, "disabled": false, "beta": false, "pro-only": false}
+	for key, want := range anonymous {
+		if got := client.IsEnabled(key, !want); got != want {
+			t.Errorf("anonymous %s = %v, want %v", key, got, want)
+		}
+	}
+
+	ctx := context.Background()
+	if err := client.Identify(ctx, &UserContext{ID: "user-1", Attributes: map[string]any{"plan": "pro"}}); err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+	if detail := client.IsEnabledDetail("beta", false); !detail.Value || detail.Reason.Kind != ReasonTargetMatch {
+		t.Errorf("expected beta to target user-1, got %+v", detail)
+	}
+	detail := client.IsEnabledDetail("pro-only", false)
+	if !detail.Value || detail.Reason.Kind != ReasonRuleMatch || detail.Reason.RuleID != "pro-plan" {
+		t.Errorf("expected pro-only to match the pro-plan rule, got %+v", detail)
+	}
+	if detail := client.IsEnabledDetail("disabled", true); detail.Value || detail.Reason.Kind != ReasonOff {
+		t.Errorf("expected disabled to be off, got %+v", detail)
+	}
+
+	if err := client.Identify(ctx, &UserContext{ID: "user-2", Attributes: map[string]any{"plan": "free"}}); err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+	if client.IsEnabled("beta", false) || client.IsEnabled("pro-only", false) {
+		t.Error("expected user-2 to match neither targets nor rules")
+	}
+}
+
+func TestIntegration_Segments(t *testing.T) {
+	m := newMockServer(t, &mockFlag{Key: "bulk-export", Enabled: true, Rules: []mockRule{{
+		ID:         "enterprise",
+		Conditions: []mockCondition{{Attribute: "segment", Operator: "in", Value: "enterprise"}},
+		Rollout:    100,
+	}}})
+	m.setSegment("enterprise",
+		mockCondition{Attribute: "seats", Operator: "gt", Value: 100},
+		mockCondition{Attribute: "email", Operator: "contains", Value: "@"},
+	)
+	client := newIntegrationClient(t, m.config())
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		user *UserContext
+		want bool
+	}{
+		{"in segment", &UserContext{ID: "big", Email: "ops@big.example", Attributes: map[string]any{"seats": 500}}, true},
+		{"below threshold", &UserContext{ID: "small", Email: "ops@small.example", Attributes: map[string]any{"seats": 5}}, false},
+		{"missing attribute", &UserContext{ID: "unknown", Attributes: map[string]any{"seats": 500}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := client.Identify(ctx, tt.user); err != nil {
+				t.Fatalf("Identify failed: %v", err)
+			}
+			if got := client.IsEnabled("bulk-export", !tt.want); got != tt.want {
+				t.Errorf("bulk-export = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntegration_Errors(t *testing.T) {
+	t.Run("invalid API key fails Init", func(t *testing.T) {
+		m := newMockServer(t, &mockFlag{Key: "flag", Enabled: true, Rollout: 100})
+		config := m.config()
+		config.APIKey = "wrong-key"
+		config.Cache.Enabled = false
+
+		client, err := NewClient(config)
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+
+		err = client.Init(context.Background())
+		var authErr *AuthenticationError
+		if !errors.As(err, &authErr) {
+			t.Fatalf("expected an AuthenticationError, got %v", err)
+		}
+		if got := m.requestCount("/api/v1/sdk/flags"); got != 1 {
+			t.Errorf("authentication errors should not be retried, got %d requests", got)
+		}
+	})
+
+	t.Run("server errors are retried", func(t *testing.T) {
+		m := newMockServer(t, &mockFlag{Key: "flag", Enabled: true, Rollout: 100})
+		m.failNext(http.StatusServiceUnavailable, 2)
+
+		client := newIntegrationClient(t, m.config())
+		if !client.IsEnabled("flag", false) {
+			t.Error("expected flag after retrying through the failures")
+		}
+		if got := m.requestCount("/api/v1/sdk/flags"); got != 3 {
+			t.Errorf("expected 3 requests, got %d", got)
+		}
+	})
+
+	t.Run("failed refresh keeps last known flags", func(t *testing.T) {
+		m := newMockServer(t, &mockFlag{Key: "flag", Enabled: true, Rollout: 100})
+		config := m.config()
+		client := newIntegrationClient(t, config)
+
+		m.failNext(http.StatusInternalServerError, config.Retry.MaxRetries+1)
+		if err := client.Refresh(context.Background()); err == nil {
+			t.Fatal("expected Refresh to fail")
+		}
+		if !client.IsEnabled("flag", false) {
+			t.Error("expected the last known value after a failed refresh")
+		}
+	})
+}
+
+func TestIntegration_ETag(t *testing.T) {
+	m := newMockServer(t, &mockFlag{Key: "flag", Enabled: true, Rollout: 100})
+	client := newIntegrationClient(t, m.config())
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := client.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+	}
+	if got := m.notModifiedCount(); got != 2 {
+		t.Errorf("expected 2 not-modified responses, got %d", got)
+	}
+	if !client.IsEnabled("flag", false) {
+		t.Error("expected flags to survive 304 responses")
+	}
+
+	m.setFlag(&mockFlag{Key: "flag", Enabled: false})
+	if err := client.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if client.IsEnabled("flag", true) {
+		t.Error("expected the changed flag after the ETag changed")
+	}
+	if got := m.notModifiedCount(); got != 2 {
+		t.Errorf("expected a full response for the changed flags, got %d not-modified", got)
+	}
+}
+
+func TestIntegration_SSE(t *testing.T) {
+	m := newMockServer(t,
+		&mockFlag{Key: "flag", Enabled: true, Rollout: 100},
+		&mockFlag{Key: "rollout", Enabled: true},
+	)
+	config := m.config()
+	config.EnableStreaming = true
+	client := newIntegrationClient(t, config)
+
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor("the stream to connect", func() bool { return m.streamCount() == 1 })
+
+	m.setFlag(&mockFlag{Key: "flag", Enabled: false})
+	m.broadcast("flag")
+	waitFor("flag to turn off", func() bool { return !client.IsEnabled("flag", true) })
+	if detail := client.IsEnabledDetail("flag", true); detail.Reason.Kind != ReasonOff {
+		t.Errorf("expected the streamed OFF reason, got %+v", detail.Reason)
+	}
+
+	m.setFlag(&mockFlag{Key: "rollout", Enabled: true, Rollout: 100})
+	m.broadcast("rollout")
+	waitFor("rollout to turn on", func() bool { return client.IsEnabled("rollout", false) })
+	detail := client.IsEnabledDetail("rollout", false)
+	if detail.Reason.Kind != ReasonFallthrough || !detail.Reason.InRollout {
+		t.Errorf("expected a fallthrough reason in rollout, got %+v", detail.Reason)
+	}
+}
+
+func TestIntegration_SSEIdentify(t *testing.T) {
+	m := newMockServer(t, &mockFlag{Key: "beta", Enabled: true, TargetUsers: []string{"user-1"}})
+	config := m.config()
+	config.EnableStreaming = true
+	config.User = &UserContext{ID: "user-1"}
+	client := newIntegrationClient(t, config)
+	ctx := context.Background()
+
+	streamsFor := func(userID string) func() bool {
+		return func() bool {
+			users := m.streamUsers()
+			return len(users) == 1 && users[0] == userID
+		}
+	}
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor("the stream to connect", streamsFor("user-1"))
+
+	if err := client.Identify(ctx, &UserContext{ID: "user-2"}); err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+	waitFor("the stream to reconnect for user-2", streamsFor("user-2"))
+
+	// An update evaluated for the previous user would turn beta back on
+	m.broadcast("beta")
+	time.Sleep(100 * time.Millisecond)
+	if client.IsEnabled("beta", false) {
+		t.Error("expected the stream to follow the identified user")
+	}
+
+	if err := client.Reset(ctx); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	waitFor("the stream to reconnect without a user", streamsFor(""))
+}
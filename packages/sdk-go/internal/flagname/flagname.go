@@ -0,0 +1,32 @@
+// Package flagname maps flag keys to the Go identifiers used by rollgate-gen
+// and checked by rollgate-lint.
+package flagname
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GoName converts a flag key such as "new-checkout.flow_v2" into an exported
+// Go identifier ("NewCheckoutFlowV2").
+func GoName(key string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	name := b.String()
+	if first, _ := utf8.DecodeRuneInString(name); !unicode.IsLetter(first) {
+		name = "Flag" + name
+	}
+	return name
+}
@@ -0,0 +1,22 @@
+package flagname
+
+import "testing"
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"new-checkout-flow": "NewCheckoutFlow",
+		"checkout.theme":    "CheckoutTheme",
+		"max_cart_items":    "MaxCartItems",
+		"v2":                "V2",
+		"2fa-enabled":       "Flag2faEnabled",
+		"already-Camel":     "AlreadyCamel",
+		"über-flag":         "ÜberFlag",
+		"٣-flag":            "Flag٣Flag",
+		"--":                "Flag",
+	}
+	for key, want := range tests {
+		if got := GoName(key); got != want {
+			t.Errorf("GoName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package rollgate
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// EvaluationMode selects where boolean flags are evaluated.
+type EvaluationMode string
+
+const (
+	// EvaluationModeRemote fetches flag values the server evaluated for the
+	// current user (default).
+	EvaluationModeRemote EvaluationMode = "remote"
+
+	// EvaluationModeLocal fetches targeting rules from /api/v1/sdk/rules
+	// and evaluates every IsEnabled call in process against the current
+	// user, including target users, targeting rules and rollout hashing.
+	EvaluationModeLocal EvaluationMode = "local"
+)
+
+// doFetchRulesRequest performs one rules request for local evaluation.
+// Rules are the same for every user, so the request carries no user_id and
+// the validators survive Identify.
+func (c *Client) doFetchRulesRequest(ctx context.Context, attempt *fetchAttempt) error {
+	*attempt = fetchAttempt{}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL(ctx)+"/api/v1/sdk/rules", nil)
+	if err != nil {
+		return NewNetworkError("failed to create request", err)
+	}
+	setRequestHeaders(req, c.config.CustomHeaders)
+	if err := c.tokens.authorize(req); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	c.mu.RLock()
+	if c.lastETag != "" {
+		req.Header.Set("If-None-Match", c.lastETag)
+		attempt.etagSent = true
+	}
+	c.mu.RUnlock()
+
+	sent := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return requestError(err)
+	}
+	defer resp.Body.Close()
+
+	attempt.statusCode = resp.StatusCode
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotModified {
+		c.clock.observe(resp.Header.Get("Date"), sent, time.Now())
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return NewNetworkError("failed to read response", err)
+	}
+	parseStart := time.Now()
+	payload, skipped, err := parseRulesPayload(body)
+	c.metrics.RecordPayload(len(body), time.Since(parseStart))
+	if err != nil {
+		c.metrics.RecordPayloadError(1)
+		return NewMalformedResponseError("failed to parse rules", err)
+	}
+	if skipped > 0 {
+		c.metrics.RecordPayloadError(skipped)
+		c.config.Logger.Warn("skipped invalid entries in rules payload", "count", skipped)
+	}
+
+	c.mu.Lock()
+	if c.heldByFreeze() {
+		c.mu.Unlock()
+		return nil
+	}
+	c.lastETag = resp.Header.Get("ETag")
+	c.evaluator.SetRules(payload)
+	c.evaluateLocalFlags()
+	c.mu.Unlock()
+	c.checkConsistency(updateSourceLocal, false)
+	return nil
+}
+
+// evaluateLocalFlags evaluates every rule for the current user into the
+// flag values and reasons, so GetAllFlags, Snapshot and UnusedFlags see
+// the same flags in both evaluation modes. Called whenever the rules or
+// the user change. Caller must hold c.mu.
+func (c *Client) evaluateLocalFlags() {
+	flags := make(map[string]bool, len(c.evaluator.rules))
+	reasons := make(map[string]EvaluationReason, len(c.evaluator.rules))
+	for key, rule := range c.evaluator.rules {
+		detail := evaluateFlagDetail(rule, c.user, c.evaluator.rules, nil)
+		flags[key] = detail.Value
+		reasons[key] = detail.Reason
+	}
+	c.flags = flags
+	c.flagReasons = reasons
+}
+
+// setLocalUser changes the user of a local evaluation client. No request
+// is sent: the rules already cover every user.
+func (c *Client) setLocalUser(user *UserContext) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.user = user
+	c.evaluateLocalFlags()
+}
@@ -0,0 +1,147 @@
+package rollgate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const rulesPayload = `{"version":"3","flags":{
+	"beta":{"enabled":true,"rollout":0,"targetUsers":["user-1"],
+		"rules":[{"id":"pro-plan","enabled":true,"rollout":100,"conditions":[{"attribute":"plan","operator":"eq","value":"pro"}]}]},
+	"half":{"enabled":true,"rollout":50},
+	"off":{"enabled":false,"rollout":100}
+}}`
+
+// newRulesServer serves rulesPayload with an ETag and records the path and
+// If-None-Match header of every request.
+func newRulesServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.URL.Path+" "+r.Header.Get("If-None-Match"))
+		mu.Unlock()
+
+		if r.URL.Path != "/api/v1/sdk/rules" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"rules-3"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"rules-3"`)
+		w.Write([]byte(rulesPayload))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requests...)
+	}
+}
+
+func TestClient_LocalEvaluation(t *testing.T) {
+	server, requests := newRulesServer(t)
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		EvaluationMode:  EvaluationModeLocal,
+		User:            &UserContext{ID: "user-1"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	t.Run("should evaluate the rules for the current user", func(t *testing.T) {
+		if detail := client.IsEnabledDetail("beta", false); !detail.Value || detail.Reason != TargetMatchReason() {
+			t.Errorf("expected user-1 to be targeted, got %+v", detail)
+		}
+		if detail := client.IsEnabledDetail("off", true); detail.Value || detail.Reason != OffReason() {
+			t.Errorf("expected a disabled flag to be OFF, got %+v", detail)
+		}
+		if detail := client.IsEnabledDetail("missing", true); !detail.Value || detail.Reason != UnknownReason() {
+			t.Errorf("expected the default for an unknown flag, got %+v", detail)
+		}
+	})
+
+	t.Run("should re-evaluate on Identify without a request", func(t *testing.T) {
+		before := len(requests())
+		if err := client.Identify(ctx, &UserContext{ID: "user-2", Attributes: map[string]any{"plan": "pro"}}); err != nil {
+			t.Fatalf("Identify failed: %v", err)
+		}
+		if detail := client.IsEnabledDetail("beta", false); !detail.Value || detail.Reason != RuleMatchReason("pro-plan", 0, true) {
+			t.Errorf("expected user-2 to match the pro-plan rule, got %+v", detail)
+		}
+		want := isInRollout("half", "user-2", 50)
+		if got := client.IsEnabled("half", !want); got != want {
+			t.Errorf("expected the rollout hash result %v for user-2, got %v", want, got)
+		}
+		if !reflect.DeepEqual(client.GetAllFlags(), map[string]bool{"beta": true, "half": want, "off": false}) {
+			t.Errorf("expected GetAllFlags to reflect user-2, got %v", client.GetAllFlags())
+		}
+
+		if err := client.Reset(ctx); err != nil {
+			t.Fatalf("Reset failed: %v", err)
+		}
+		if detail := client.IsEnabledDetail("beta", true); detail.Value || detail.Reason != FallthroughReason(false) {
+			t.Errorf("expected no targeting without a user, got %+v", detail)
+		}
+		if n := len(requests()); n != before {
+			t.Errorf("expected no requests on Identify and Reset, got %d", n-before)
+		}
+	})
+
+	t.Run("should revalidate the rules with the ETag", func(t *testing.T) {
+		if err := client.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+		got := requests()
+		want := []string{`/api/v1/sdk/rules `, `/api/v1/sdk/rules "rules-3"`}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected only rules requests, got %q", got)
+		}
+		if detail := client.IsEnabledDetail("off", true); detail.Reason != OffReason() {
+			t.Errorf("expected the rules to survive a 304, got %+v", detail)
+		}
+	})
+
+	t.Run("should snapshot the rules", func(t *testing.T) {
+		snap := client.Snapshot()
+		if snap.Version() != "3" || !snap.IsEnabledFor("beta", &UserContext{ID: "user-1"}, false) {
+			t.Errorf("expected the snapshot to evaluate the rules, version %q", snap.Version())
+		}
+	})
+}
+
+func TestNewClient_EvaluationMode(t *testing.T) {
+	client, err := NewClient(Config{APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	client.Close()
+	if client.config.EvaluationMode != EvaluationModeRemote || client.evaluator != nil {
+		t.Errorf("expected remote evaluation by default, got %q", client.config.EvaluationMode)
+	}
+
+	_, err = NewClient(Config{APIKey: "test-key", EvaluationMode: "edge"})
+	if err == nil || !strings.Contains(err.Error(), `unknown evaluation mode "edge"`) {
+		t.Errorf("expected an unknown evaluation mode error, got %v", err)
+	}
+}
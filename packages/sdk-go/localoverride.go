@@ -0,0 +1,165 @@
+package rollgate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// loadOverridesFile replaces the local overrides with the contents of
+// OverridesFile, warning about every flag it overrides. Every refresh calls
+// it, so an unchanged file (same modification time and size) is not read
+// again. A file that cannot be read or parsed leaves the current overrides
+// in place.
+func (c *Client) loadOverridesFile() error {
+	info, err := os.Stat(c.config.OverridesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read overrides file: %w", err)
+	}
+	c.mu.RLock()
+	unchanged := c.localOverrides != nil && info.ModTime().Equal(c.overridesModTime) && info.Size() == c.overridesSize
+	c.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.config.OverridesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read overrides file: %w", err)
+	}
+	overrides, err := parseOverridesFile(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse overrides file %s: %w", c.config.OverridesFile, err)
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		c.config.Logger.Warn("flag overridden by local file, server value ignored",
+			"flag", k, "value", overrides[k], "file", c.config.OverridesFile)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.localOverrides = overrides
+	c.overridesModTime = info.ModTime()
+	c.overridesSize = info.Size()
+	return nil
+}
+
+// parseOverridesFile decodes an overrides file: an object of flag values,
+// optionally wrapped in {"flags": {...}}. Like flags files it is strict,
+// and null values are rejected as they override nothing.
+func parseOverridesFile(data []byte) (map[string]any, error) {
+	var overrides map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&overrides); err != nil {
+		return nil, err
+	}
+	if wrapped, ok := overrides["flags"].(map[string]any); ok && len(overrides) == 1 {
+		overrides = wrapped
+	}
+	for k, v := range overrides {
+		if v == nil {
+			return nil, fmt.Errorf("flag %q: null value", k)
+		}
+		normalized, err := normalizeNumbers(v)
+		if err != nil {
+			return nil, fmt.Errorf("flag %q: %w", k, err)
+		}
+		overrides[k] = normalized
+	}
+	return overrides, nil
+}
+
+// normalizeNumbers converts the json.Numbers of a decoded value to float64,
+// as the V2 flags endpoint decodes them.
+func normalizeNumbers(v any) (any, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return v.Float64()
+	case map[string]any:
+		for k, item := range v {
+			normalized, err := normalizeNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = normalized
+		}
+	case []any:
+		for i, item := range v {
+			normalized, err := normalizeNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = normalized
+		}
+	}
+	return v, nil
+}
+
+// overriddenFlag returns the local override of a boolean flag. Caller must
+// hold c.mu.
+func (c *Client) overriddenFlag(flagKey string) (bool, bool) {
+	value, ok := c.localOverrides[flagKey].(bool)
+	return value, ok
+}
+
+// overriddenValue returns the local override of a string, number or JSON
+// flag. An object overriding a JSON object flag is merged into the server
+// value as a JSON merge patch (RFC 7386): its fields replace the server's,
+// null fields remove them, and fields it leaves out keep the server's
+// value. Caller must hold c.mu.
+func (c *Client) overriddenValue(flagKey string) (any, bool) {
+	value, ok := c.localOverrides[flagKey]
+	if !ok {
+		return nil, false
+	}
+	if _, isBool := value.(bool); isBool {
+		return nil, false
+	}
+	if patch, ok := value.(map[string]any); ok {
+		if flag, ok := c.typedFlags[flagKey]; ok && flag.Enabled {
+			if target, ok := flag.Value.(map[string]any); ok {
+				return mergePatch(target, patch), true
+			}
+		}
+	}
+	return value, true
+}
+
+// overrideReason is the reason of a value from OverridesFile.
+func overrideReason() EvaluationReason {
+	reason := FallthroughReason(true)
+	reason.Overridden = true
+	return reason
+}
+
+// mergePatch applies patch to a copy of target as a JSON merge patch.
+func mergePatch(target, patch map[string]any) map[string]any {
+	merged := make(map[string]any, len(target)+len(patch))
+	for k, v := range target {
+		merged[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		if patchObject, ok := v.(map[string]any); ok {
+			if targetObject, ok := merged[k].(map[string]any); ok {
+				merged[k] = mergePatch(targetObject, patchObject)
+				continue
+			}
+			v = mergePatch(nil, patchObject)
+		}
+		merged[k] = v
+	}
+	return merged
+}
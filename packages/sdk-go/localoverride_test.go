@@ -0,0 +1,159 @@
+package rollgate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_OverridesFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			fmt.Fprint(w, `{"flags":{"beta":false,"checkout":true}}`)
+		case "/api/v1/sdk/v2/flags":
+			fmt.Fprint(w, `{"flags":{
+				"theme":{"type":"string","value":"light","enabled":true},
+				"limits":{"type":"json","value":{"items":10,"retries":{"max":3,"backoff":"1s"},"legacy":true},"enabled":true}
+			}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	if err := os.WriteFile(path, []byte(`{
+		"beta": true,
+		"theme": "dark",
+		"limits": {"items": 50, "retries": {"max": 5}, "legacy": null}
+	}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := &recordingLogger{}
+	client := newIntegrationClient(t, Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		OverridesFile:   path,
+		Logger:          logger,
+	})
+	ctx := context.Background()
+
+	t.Run("should serve the local value over the server's", func(t *testing.T) {
+		detail := client.IsEnabledDetail("beta", false)
+		if !detail.Value || !detail.Reason.Overridden {
+			t.Errorf("expected the overridden value, got %+v", detail)
+		}
+		if !client.IsEnabled("beta", false, WithUser("user-2")) {
+			t.Error("expected the override to apply to every user")
+		}
+		if got := client.GetString("theme", ""); got != "dark" {
+			t.Errorf("expected the overridden string, got %q", got)
+		}
+		if n := logger.count("flag overridden by local file, server value ignored"); n != 3 {
+			t.Errorf("expected a warning per overridden flag, got %d", n)
+		}
+	})
+
+	t.Run("should keep the server's other flags", func(t *testing.T) {
+		if detail := client.IsEnabledDetail("checkout", false); !detail.Value || detail.Reason.Overridden {
+			t.Errorf("expected the server's value, got %+v", detail)
+		}
+		want := map[string]bool{"beta": true, "checkout": true}
+		if flags := client.GetAllFlags(); !reflect.DeepEqual(flags, want) {
+			t.Errorf("expected %v, got %v", want, flags)
+		}
+		if detail := client.GetAllFlagsDetail()["beta"]; !detail.Reason.Overridden {
+			t.Errorf("expected GetAllFlagsDetail to report the override, got %+v", detail)
+		}
+		if !client.Snapshot().IsEnabled("beta", false) {
+			t.Error("expected snapshots to include the override")
+		}
+		snap := client.Snapshot().WithRules(RulesPayload{Flags: map[string]FlagRule{
+			"beta": {Key: "beta", Enabled: false},
+		}})
+		if detail := snap.IsEnabledDetailFor("beta", &UserContext{ID: "user-2"}, false); !detail.Value || !detail.Reason.Overridden {
+			t.Errorf("expected the override to win over the snapshot's rules, got %+v", detail)
+		}
+	})
+
+	t.Run("should merge objects into JSON flags", func(t *testing.T) {
+		want := map[string]any{"items": float64(50), "retries": map[string]any{"max": float64(5), "backoff": "1s"}}
+		if got := client.GetJSON("limits", nil); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("should reload the file on Refresh", func(t *testing.T) {
+		os.WriteFile(path, []byte(`{"flags":{"checkout":false}}`), 0o600)
+		if err := client.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+		if client.IsEnabled("beta", true) || client.IsEnabled("checkout", true) {
+			t.Error("expected the reloaded overrides")
+		}
+
+		os.WriteFile(path, []byte(`{"checkout":null}`), 0o600)
+		if err := client.Refresh(ctx); err == nil || !strings.Contains(err.Error(), "failed to parse overrides file") {
+			t.Errorf("expected a parse error, got %v", err)
+		}
+		if client.IsEnabled("checkout", true) {
+			t.Error("expected a bad file to keep the previous overrides")
+		}
+	})
+}
+
+func TestClient_OverridesFileInit(t *testing.T) {
+	t.Run("should fail Init on a missing file", func(t *testing.T) {
+		client, err := NewClient(Config{Offline: true, OverridesFile: filepath.Join(t.TempDir(), "missing.json")})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+		if err := client.Init(context.Background()); err == nil || !strings.Contains(err.Error(), "failed to read overrides file") {
+			t.Errorf("expected a read error, got %v", err)
+		}
+	})
+
+	t.Run("should layer over offline flags", func(t *testing.T) {
+		dir := t.TempDir()
+		flagsPath := filepath.Join(dir, "flags.json")
+		overridesPath := filepath.Join(dir, "overrides.json")
+		os.WriteFile(flagsPath, []byte(`{"beta":false,"checkout":true}`), 0o600)
+		os.WriteFile(overridesPath, []byte(`{"beta":true}`), 0o600)
+
+		client, err := NewClient(Config{OverridesFile: overridesPath}, WithFileDataSource(flagsPath))
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+		if err := client.Init(context.Background()); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		if !client.IsEnabled("beta", false) || !client.IsEnabled("checkout", false) {
+			t.Errorf("expected the override over the file's flags, got %v", client.GetAllFlags())
+		}
+	})
+}
+
+func TestMergePatch(t *testing.T) {
+	target := map[string]any{"a": "b", "c": map[string]any{"d": "e", "f": "g"}}
+	patch := map[string]any{"a": "z", "c": map[string]any{"f": nil}, "h": map[string]any{"i": nil, "j": 1.0}}
+	want := map[string]any{"a": "z", "c": map[string]any{"d": "e"}, "h": map[string]any{"j": 1.0}}
+	if got := mergePatch(target, patch); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if target["a"] != "b" {
+		t.Error("expected the target to be unchanged")
+	}
+}
@@ -1,11 +1,50 @@
 package rollgate
 
 import (
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"strings"
 )
 
+// LogLevel is the minimum severity a client logs. The values match the
+// log/slog levels, and the zero value is LogLevelInfo.
+type LogLevel int
+
+const (
+	// LogLevelDebug logs everything, including individual stream events.
+	LogLevelDebug LogLevel = -4
+	// LogLevelInfo logs connection changes and above.
+	LogLevelInfo LogLevel = 0
+	// LogLevelWarn logs failures the client recovers from and above.
+	LogLevelWarn LogLevel = 4
+	// LogLevelError logs only failures that lose data, such as unparsable
+	// stream events.
+	LogLevelError LogLevel = 8
+	// LogLevelOff logs nothing.
+	LogLevelOff LogLevel = 12
+)
+
+// String returns the level's name.
+func (l LogLevel) String() string {
+	switch {
+	case l >= LogLevelOff:
+		return "OFF"
+	case l >= LogLevelError:
+		return "ERROR"
+	case l >= LogLevelWarn:
+		return "WARN"
+	case l >= LogLevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
 // DefaultLogger is a simple logger implementation using the standard library.
+// Arguments are alternating keys and values, printed as key=value.
 type DefaultLogger struct {
 	debug *log.Logger
 	info  *log.Logger
@@ -25,22 +64,78 @@ func NewDefaultLogger() *DefaultLogger {
 
 // Debug logs a debug message.
 func (l *DefaultLogger) Debug(msg string, args ...any) {
-	l.debug.Printf(msg+" %v", args...)
+	l.debug.Print(formatLogLine(msg, args))
 }
 
 // Info logs an info message.
 func (l *DefaultLogger) Info(msg string, args ...any) {
-	l.info.Printf(msg+" %v", args...)
+	l.info.Print(formatLogLine(msg, args))
 }
 
 // Warn logs a warning message.
 func (l *DefaultLogger) Warn(msg string, args ...any) {
-	l.warn.Printf(msg+" %v", args...)
+	l.warn.Print(formatLogLine(msg, args))
 }
 
 // Error logs an error message.
 func (l *DefaultLogger) Error(msg string, args ...any) {
-	l.err.Printf(msg+" %v", args...)
+	l.err.Print(formatLogLine(msg, args))
+}
+
+// formatLogLine appends alternating keys and values to msg as key=value.
+// A trailing key without a value is printed as !BADKEY=key, like slog.
+func formatLogLine(msg string, args []any) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			fmt.Fprintf(&b, " !BADKEY=%v", args[i])
+			break
+		}
+		fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+	}
+	return b.String()
+}
+
+// SlogLogger adapts a log/slog logger to Logger, so the client's messages
+// carry their arguments as structured attributes.
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger creates a Logger that writes to logger, or to slog.Default()
+// if logger is nil. Config.LogLevel filters messages before they reach it.
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{logger: logger.With("component", "rollgate")}
+}
+
+// NewJSONLogger creates a Logger that writes one JSON object per message
+// to w. Config.LogLevel selects the messages written.
+func NewJSONLogger(w io.Writer) *SlogLogger {
+	return NewSlogLogger(slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})))
+}
+
+// Debug logs a debug message.
+func (l *SlogLogger) Debug(msg string, args ...any) {
+	l.logger.Debug(msg, args...)
+}
+
+// Info logs an info message.
+func (l *SlogLogger) Info(msg string, args ...any) {
+	l.logger.Info(msg, args...)
+}
+
+// Warn logs a warning message.
+func (l *SlogLogger) Warn(msg string, args ...any) {
+	l.logger.Warn(msg, args...)
+}
+
+// Error logs an error message.
+func (l *SlogLogger) Error(msg string, args ...any) {
+	l.logger.Error(msg, args...)
 }
 
 // NopLogger is a logger that discards all output.
@@ -57,3 +152,51 @@ func (l *NopLogger) Warn(msg string, args ...any) {}
 
 // Error does nothing.
 func (l *NopLogger) Error(msg string, args ...any) {}
+
+// levelLogger drops messages below a minimum level before they reach the
+// configured logger.
+type levelLogger struct {
+	next Logger
+	min  LogLevel
+}
+
+// newLevelLogger wraps logger so it only receives messages at level or
+// above. A nil logger becomes a NopLogger, so the client never checks for
+// one. Wrapping a wrapped logger returns it unchanged.
+func newLevelLogger(logger Logger, level LogLevel) Logger {
+	switch l := logger.(type) {
+	case nil:
+		return &NopLogger{}
+	case *NopLogger, *levelLogger:
+		return l
+	}
+	return &levelLogger{next: logger, min: level}
+}
+
+// Debug logs a debug message at LogLevelDebug.
+func (l *levelLogger) Debug(msg string, args ...any) {
+	if l.min <= LogLevelDebug {
+		l.next.Debug(msg, args...)
+	}
+}
+
+// Info logs an info message at LogLevelInfo.
+func (l *levelLogger) Info(msg string, args ...any) {
+	if l.min <= LogLevelInfo {
+		l.next.Info(msg, args...)
+	}
+}
+
+// Warn logs a warning message at LogLevelWarn.
+func (l *levelLogger) Warn(msg string, args ...any) {
+	if l.min <= LogLevelWarn {
+		l.next.Warn(msg, args...)
+	}
+}
+
+// Error logs an error message at LogLevelError.
+func (l *levelLogger) Error(msg string, args ...any) {
+	if l.min <= LogLevelError {
+		l.next.Error(msg, args...)
+	}
+}
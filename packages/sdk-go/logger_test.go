@@ -0,0 +1,88 @@
+package rollgate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLevelLogger(t *testing.T) {
+	t.Run("should drop messages below the level", func(t *testing.T) {
+		logger := &recordingLogger{}
+		leveled := newLevelLogger(logger, LogLevelWarn)
+		leveled.Debug("debug")
+		leveled.Info("info")
+		leveled.Warn("warn")
+		leveled.Error("error")
+		if logger.count("debug") != 0 || logger.count("info") != 0 {
+			t.Errorf("expected debug and info to be dropped, got %v", logger.messages)
+		}
+		if logger.count("warn") != 1 || logger.count("error") != 1 {
+			t.Errorf("expected warn and error to be logged, got %v", logger.messages)
+		}
+	})
+
+	t.Run("should default to info", func(t *testing.T) {
+		logger := &recordingLogger{}
+		leveled := newLevelLogger(logger, 0)
+		leveled.Debug("debug")
+		leveled.Info("info")
+		if logger.count("debug") != 0 || logger.count("info") != 1 {
+			t.Errorf("expected only info to be logged, got %v", logger.messages)
+		}
+	})
+
+	t.Run("should log nothing when off", func(t *testing.T) {
+		logger := &recordingLogger{}
+		newLevelLogger(logger, LogLevelOff).Error("error")
+		if len(logger.messages) != 0 {
+			t.Errorf("expected no messages, got %v", logger.messages)
+		}
+	})
+
+	t.Run("should replace a nil logger with a no-op", func(t *testing.T) {
+		if _, ok := newLevelLogger(nil, LogLevelDebug).(*NopLogger); !ok {
+			t.Error("expected a NopLogger")
+		}
+	})
+}
+
+func TestFormatLogLine(t *testing.T) {
+	got := formatLogLine("SSE error", []any{"error", errors.New("boom"), "attempt", 3, "dangling"})
+	want := "SSE error error=boom attempt=3 !BADKEY=dangling"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf)
+	logger.Warn("failed to refresh flags", "error", errors.New("timeout"), "attempt", 2)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected one JSON object, got %q: %v", buf.String(), err)
+	}
+	if entry["level"] != "WARN" || entry["msg"] != "failed to refresh flags" {
+		t.Errorf("unexpected level or message: %v", entry)
+	}
+	if entry["error"] != "timeout" || entry["attempt"] != float64(2) || entry["component"] != "rollgate" {
+		t.Errorf("expected structured fields, got %v", entry)
+	}
+}
+
+func TestClient_LogLevel(t *testing.T) {
+	tests := map[LogLevel]bool{LogLevelWarn: true, LogLevelError: false}
+	for level, logged := range tests {
+		var buf bytes.Buffer
+		client := newIntegrationClient(t, Config{Offline: true, Logger: NewJSONLogger(&buf), LogLevel: level})
+		client.Track(TrackEventOptions{})
+
+		if got := strings.Contains(buf.String(), "dropping invalid event"); got != logged {
+			t.Errorf("%s: expected logged=%v, got %q", level, logged, buf.String())
+		}
+	}
+}
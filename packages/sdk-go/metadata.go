@@ -0,0 +1,56 @@
+package rollgate
+
+import "time"
+
+// FlagMetadata identifies the configuration of a flag that a value was
+// served from, as sent by the server with the flags.
+type FlagMetadata struct {
+	// Version goes up by one on every change to the flag's configuration.
+	Version int `json:"version"`
+	// UpdatedAt is when the flag's configuration last changed.
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// IsZero reports whether the server sent no metadata for the flag.
+func (m FlagMetadata) IsZero() bool {
+	return m.Version == 0 && m.UpdatedAt.IsZero()
+}
+
+// withMetadata sets the flag version and update time of a detail.
+func withMetadata[T any](detail EvaluationDetail[T], meta FlagMetadata) EvaluationDetail[T] {
+	detail.FlagVersion = meta.Version
+	detail.FlagUpdatedAt = meta.UpdatedAt
+	return detail
+}
+
+// GetAllFlagsDetail returns the detail of every current flag: its value,
+// reason, and the version and update time of its configuration. Unlike
+// IsEnabledDetail it records no metrics, telemetry or exposure events, and
+// runs no hooks, so it is safe for diagnostics such as incident reviews.
+func (c *Client) GetAllFlagsDetail() map[string]BoolEvaluationDetail {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make(map[string]BoolEvaluationDetail, len(c.flags))
+	rateLimited := c.rateLimited()
+	for k, v := range c.flags {
+		detail := BoolEvaluationDetail{Value: v, Reason: FallthroughReason(v)}
+		if reason, ok := c.flagReasons[k]; ok {
+			detail.Reason = reason
+		}
+		detail.Reason.Frozen = c.frozen()
+		detail.Reason.RateLimited = rateLimited
+		result[k] = withMetadata(detail, c.flagMeta[k])
+	}
+	if c.bootstrapping() {
+		for k, v := range c.bootstrap {
+			result[k] = BoolEvaluationDetail{Value: v, Reason: BootstrapReason()}
+		}
+	}
+	for k := range c.localOverrides {
+		if v, ok := c.overriddenFlag(k); ok {
+			result[k] = BoolEvaluationDetail{Value: v, Reason: overrideReason()}
+		}
+	}
+	return result
+}
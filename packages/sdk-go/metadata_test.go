@@ -0,0 +1,103 @@
+package rollgate
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_FlagMetadata(t *testing.T) {
+	updatedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			fmt.Fprint(w, `{"flags":{"beta":true,"legacy":false},`+
+				`"metadata":{"beta":{"version":7,"updatedAt":"2026-03-01T12:00:00Z"},"legacy":"nope","gone":{"version":2}}}`)
+		case "/api/v1/sdk/v2/flags":
+			fmt.Fprint(w, `{"flags":{"banner":{"type":"string","value":"Hi","enabled":true,"version":3,"updatedAt":"2026-03-01T12:00:00Z"}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := newIntegrationClient(t, Config{APIKey: "test-key", BaseURL: server.URL, RefreshInterval: time.Hour})
+
+	t.Run("should add the version to boolean details", func(t *testing.T) {
+		detail := client.IsEnabledDetail("beta", false)
+		if detail.FlagVersion != 7 || !detail.FlagUpdatedAt.Equal(updatedAt) {
+			t.Errorf("expected version 7 updated at %v, got %d at %v", updatedAt, detail.FlagVersion, detail.FlagUpdatedAt)
+		}
+	})
+
+	t.Run("should leave the version zero when the server sent none", func(t *testing.T) {
+		detail := client.IsEnabledDetail("legacy", true)
+		if detail.FlagVersion != 0 || !detail.FlagUpdatedAt.IsZero() {
+			t.Errorf("expected no version, got %d at %v", detail.FlagVersion, detail.FlagUpdatedAt)
+		}
+		if detail := client.IsEnabledDetail("missing", false); detail.FlagVersion != 0 {
+			t.Errorf("expected no version for an unknown flag, got %d", detail.FlagVersion)
+		}
+	})
+
+	t.Run("should add the version to typed details", func(t *testing.T) {
+		detail := client.GetStringDetail("banner", "")
+		if detail.Value != "Hi" || detail.FlagVersion != 3 || !detail.FlagUpdatedAt.Equal(updatedAt) {
+			t.Errorf("expected Hi at version 3, got %+v", detail)
+		}
+	})
+
+	t.Run("should list every flag with its version", func(t *testing.T) {
+		details := client.GetAllFlagsDetail()
+		if len(details) != 2 {
+			t.Fatalf("expected 2 flags, got %v", details)
+		}
+		if d := details["beta"]; !d.Value || d.FlagVersion != 7 || d.Reason.Kind != ReasonFallthrough {
+			t.Errorf("unexpected detail for beta: %+v", d)
+		}
+		if d := details["legacy"]; d.Value || d.FlagVersion != 0 {
+			t.Errorf("unexpected detail for legacy: %+v", d)
+		}
+	})
+
+	t.Run("should keep the version in snapshots", func(t *testing.T) {
+		if detail := client.Snapshot().IsEnabledDetail("beta", false); detail.FlagVersion != 7 {
+			t.Errorf("expected version 7, got %d", detail.FlagVersion)
+		}
+	})
+}
+
+func TestClient_FlagMetadataStreaming(t *testing.T) {
+	client := newIntegrationClient(t, Config{Offline: true})
+	client.applySSEUpdate(SSEFlagsUpdate{
+		Flags:    map[string]bool{"beta": true, "legacy": true},
+		Metadata: map[string]FlagMetadata{"beta": {Version: 1}, "legacy": {Version: 4}},
+		Full:     true,
+	})
+
+	t.Run("should replace the versions on a full update", func(t *testing.T) {
+		if v := client.IsEnabledDetail("legacy", false).FlagVersion; v != 4 {
+			t.Errorf("expected version 4, got %d", v)
+		}
+	})
+
+	t.Run("should update the version of a changed flag", func(t *testing.T) {
+		client.applySSEUpdate(SSEFlagsUpdate{
+			Flags:    map[string]bool{"beta": false},
+			Metadata: map[string]FlagMetadata{"beta": {Version: 2}},
+		})
+		if v := client.IsEnabledDetail("beta", true).FlagVersion; v != 2 {
+			t.Errorf("expected version 2, got %d", v)
+		}
+	})
+
+	t.Run("should drop the version of a flag changed without one", func(t *testing.T) {
+		client.applySSEUpdate(SSEFlagsUpdate{Flags: map[string]bool{"legacy": false}})
+		if v := client.IsEnabledDetail("legacy", true).FlagVersion; v != 0 {
+			t.Errorf("expected no version, got %d", v)
+		}
+	})
+}
@@ -1,10 +1,12 @@
 package rollgate
 
 import (
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // MetricsSnapshot holds a snapshot of SDK metrics.
@@ -22,6 +24,9 @@ type MetricsSnapshot struct {
 	P95Latency     int64
 	P99Latency     int64
 
+	// Latency distribution of every request, bucketed by RequestLatencyBucketsMs
+	RequestLatencyHistogram Histogram
+
 	// Cache metrics
 	CacheHits      int64
 	CacheMisses    int64
@@ -36,9 +41,52 @@ type MetricsSnapshot struct {
 	// Evaluation metrics
 	TotalEvaluations int64
 	EvaluationTimeAvgMs float64
+	// Evaluation time distribution, bucketed by EvaluationTimeBucketsMs
+	EvaluationTimeHistogram Histogram
+
+	// Polling metrics
+	PollIntervalMs int64 // Current effective polling interval, including failure backoff
+
+	// Freeze metrics (Client.FreezeFlags)
+	FlagsFrozen   bool  // Flag updates are currently held back
+	FrozenUpdates int64 // Updates dropped while frozen
+
+	// Client-side rate limiting
+	ThrottledRequests int64 // Requests delayed or rejected by the client-side rate limiter
+
+	// Base URL failover (Config.FallbackBaseURLs)
+	Failovers int64 // Switches to the next base URL after persistent failures
+	FailBacks int64 // Returns to the primary BaseURL after a successful probe
+
+	// Event metrics
+	RejectedEvents    int64 // Events dropped by Track validation
+	DedupedEvents     int64 // Events dropped as duplicates within the EventDedup window
+	DeliveredEvents   int64 // Events accepted by the events endpoint
+	DroppedEvents     int64 // Undelivered events discarded because the buffer and spill file were full
+	SpilledEvents     int64 // Undelivered events written to EventCollectorConfig.SpillFile
+	EventFlushRetries int64 // Event flush requests retried after a transient failure
+
+	// Telemetry metrics
+	TelemetryBufferHighWater  int64 // Largest number of evaluations buffered between telemetry flushes
+	TelemetryThresholdFlushes int64 // Telemetry flushes triggered by MaxBufferSize rather than the interval
+
+	// Payload metrics
+	PayloadErrors    int64   // Unparseable flags payloads plus invalid entries skipped within them
+	PayloadBytesLast int64   // Size of the most recent flags payload
+	PayloadBytesAvg  float64 // Average flags payload size over recent fetches
+	PayloadBytesP95  int64   // 95th percentile flags payload size
+	ParseTimeAvgMs   float64 // Average JSON decode time of flags payloads
+	ParseTimeP95Ms   float64 // 95th percentile JSON decode time
+
+	// Per-flag metrics (FlagMetricsConfig)
+	FlagEvaluations       map[string]int64 // Evaluations per flag label; nil when disabled
+	FlagLabelsDropped     int64            // Evaluations of flag keys counted under OtherFlagLabel
+	FlagLabelLimitReached bool             // MaxFlags turned at least one flag key away
 
 	// Error breakdown
 	NetworkErrors    int64
+	// Network errors by kind (ClassifyNetworkError); every kind is present
+	NetworkErrorsByKind map[NetworkErrorKind]int64
 	AuthErrors       int64
 	RateLimitErrors  int64
 	ServerErrors     int64
@@ -54,7 +102,8 @@ type SDKMetrics struct {
 	failedRequests    int64
 
 	// Latency tracking
-	latencies []int64
+	latencies        []int64
+	latencyHistogram *histogram
 
 	// Cache metrics
 	cacheHits      int64
@@ -67,11 +116,48 @@ type SDKMetrics struct {
 	circuitHalfOpenCount int64
 
 	// Evaluations
-	totalEvaluations  int64
-	evaluationTimeSum int64
+	totalEvaluations    int64
+	evaluationTimeSum   int64
+	evaluationHistogram *histogram
+
+	// Polling
+	pollIntervalMs int64
+
+	// Freeze
+	flagsFrozen   int32
+	frozenUpdates int64
+
+	// Rate limiting
+	throttledRequests int64
+
+	// Failover
+	failovers int64
+	failBacks int64
+
+	// Events
+	rejectedEvents    int64
+	dedupedEvents     int64
+	deliveredEvents   int64
+	droppedEvents     int64
+	spilledEvents     int64
+	eventFlushRetries int64
+
+	// Telemetry
+	telemetryHighWater        int64
+	telemetryThresholdFlushes int64
+
+	// Payloads
+	payloadErrors int64
+	payloadSizes  []int64 // bytes
+	parseTimes    []int64 // microseconds
+
+	// Per-flag evaluations, keyed by bounded label; nil when disabled.
+	// Read without m.mu so evaluations do not contend on it.
+	flagLabels atomic.Pointer[flagLabelGuard]
 
 	// Errors
 	networkErrors   int64
+	networkKinds    [6]int64 // Indexed like NetworkErrorKinds()
 	authErrors      int64
 	rateLimitErrors int64
 	serverErrors    int64
@@ -80,8 +166,12 @@ type SDKMetrics struct {
 // NewSDKMetrics creates a new SDKMetrics instance.
 func NewSDKMetrics() *SDKMetrics {
 	return &SDKMetrics{
-		latencies:    make([]int64, 0, 1000),
-		circuitState: CircuitStateClosed,
+		latencies:           make([]int64, 0, 1000),
+		latencyHistogram:    newHistogram(RequestLatencyBucketsMs),
+		evaluationHistogram: newHistogram(EvaluationTimeBucketsMs),
+		payloadSizes:        make([]int64, 0, 100),
+		parseTimes:          make([]int64, 0, 100),
+		circuitState:        CircuitStateClosed,
 	}
 }
 
@@ -106,6 +196,8 @@ func (m *SDKMetrics) RecordRequest(latencyMs int64, success bool, errCategory Er
 		}
 	}
 
+	m.latencyHistogram.observe(float64(latencyMs))
+
 	m.mu.Lock()
 	m.latencies = append(m.latencies, latencyMs)
 	// Keep only last 1000 latencies
@@ -115,6 +207,16 @@ func (m *SDKMetrics) RecordRequest(latencyMs int64, success bool, errCategory Er
 	m.mu.Unlock()
 }
 
+// RecordNetworkError records the kind of a failed request's network error.
+func (m *SDKMetrics) RecordNetworkError(kind NetworkErrorKind) {
+	for i, k := range NetworkErrorKinds() {
+		if k == kind {
+			atomic.AddInt64(&m.networkKinds[i], 1)
+			return
+		}
+	}
+}
+
 // RecordCacheHit records a cache hit.
 func (m *SDKMetrics) RecordCacheHit(stale bool) {
 	if stale {
@@ -147,6 +249,120 @@ func (m *SDKMetrics) RecordCircuitStateChange(state CircuitState) {
 func (m *SDKMetrics) RecordEvaluation(durationNs int64) {
 	atomic.AddInt64(&m.totalEvaluations, 1)
 	atomic.AddInt64(&m.evaluationTimeSum, durationNs/1000000) // Convert to ms
+	m.evaluationHistogram.observe(float64(durationNs) / 1e6)
+}
+
+// EnableFlagMetrics starts counting evaluations per flag, with labels
+// bounded by config.
+func (m *SDKMetrics) EnableFlagMetrics(config FlagMetricsConfig) {
+	if config.MaxFlags <= 0 {
+		config.MaxFlags = DefaultFlagMetricsConfig().MaxFlags
+	}
+	m.flagLabels.Store(newFlagLabelGuard(config))
+}
+
+// RecordFlagEvaluation counts an evaluation of flagKey when per-flag
+// metrics are enabled. It returns true the first time a key is counted
+// under OtherFlagLabel because the label limit was reached.
+func (m *SDKMetrics) RecordFlagEvaluation(flagKey string) (limitHit bool) {
+	g := m.flagLabels.Load()
+	if g == nil {
+		return false
+	}
+	return g.record(flagKey)
+}
+
+// RecordPollInterval records the current effective polling interval.
+func (m *SDKMetrics) RecordPollInterval(interval time.Duration) {
+	atomic.StoreInt64(&m.pollIntervalMs, interval.Milliseconds())
+}
+
+// RecordFrozen records whether flag updates are held back by FreezeFlags.
+func (m *SDKMetrics) RecordFrozen(frozen bool) {
+	var v int32
+	if frozen {
+		v = 1
+	}
+	atomic.StoreInt32(&m.flagsFrozen, v)
+}
+
+// RecordFrozenUpdate records an update dropped while the flags were frozen.
+func (m *SDKMetrics) RecordFrozenUpdate() {
+	atomic.AddInt64(&m.frozenUpdates, 1)
+}
+
+// RecordThrottledRequest records a request delayed or rejected by the client-side rate limiter.
+func (m *SDKMetrics) RecordThrottledRequest() {
+	atomic.AddInt64(&m.throttledRequests, 1)
+}
+
+// RecordFailover records a switch to the next base URL.
+func (m *SDKMetrics) RecordFailover() {
+	atomic.AddInt64(&m.failovers, 1)
+}
+
+// RecordFailBack records a return to the primary base URL.
+func (m *SDKMetrics) RecordFailBack() {
+	atomic.AddInt64(&m.failBacks, 1)
+}
+
+// RecordRejectedEvent records an event rejected by validation.
+func (m *SDKMetrics) RecordRejectedEvent() {
+	atomic.AddInt64(&m.rejectedEvents, 1)
+}
+
+// RecordDedupedEvent records an event dropped as a duplicate.
+func (m *SDKMetrics) RecordDedupedEvent() {
+	atomic.AddInt64(&m.dedupedEvents, 1)
+}
+
+// RecordEventFlush records the outcome of an event flush: events
+// delivered and retries made.
+func (m *SDKMetrics) RecordEventFlush(delivered, retries int) {
+	atomic.AddInt64(&m.deliveredEvents, int64(delivered))
+	atomic.AddInt64(&m.eventFlushRetries, int64(retries))
+}
+
+// RecordUndeliveredEvents records undelivered events written to the spill
+// file or dropped.
+func (m *SDKMetrics) RecordUndeliveredEvents(spilled, dropped int) {
+	atomic.AddInt64(&m.spilledEvents, int64(spilled))
+	atomic.AddInt64(&m.droppedEvents, int64(dropped))
+}
+
+// RecordTelemetryBuffer records the current telemetry buffer size, keeping the high-water mark.
+func (m *SDKMetrics) RecordTelemetryBuffer(size int) {
+	for {
+		current := atomic.LoadInt64(&m.telemetryHighWater)
+		if int64(size) <= current || atomic.CompareAndSwapInt64(&m.telemetryHighWater, current, int64(size)) {
+			return
+		}
+	}
+}
+
+// RecordTelemetryThresholdFlush records a telemetry flush triggered by a full buffer.
+func (m *SDKMetrics) RecordTelemetryThresholdFlush() {
+	atomic.AddInt64(&m.telemetryThresholdFlushes, 1)
+}
+
+// RecordPayloadError records invalid entries skipped in a flags payload,
+// or an unparseable payload (count 1).
+func (m *SDKMetrics) RecordPayloadError(count int) {
+	atomic.AddInt64(&m.payloadErrors, int64(count))
+}
+
+// RecordPayload records the size and JSON decode time of a fetched flags payload.
+func (m *SDKMetrics) RecordPayload(sizeBytes int, parseTime time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.payloadSizes = append(m.payloadSizes, int64(sizeBytes))
+	m.parseTimes = append(m.parseTimes, parseTime.Microseconds())
+	// Keep only last 100 payloads
+	if len(m.payloadSizes) > 100 {
+		m.payloadSizes = m.payloadSizes[len(m.payloadSizes)-100:]
+		m.parseTimes = m.parseTimes[len(m.parseTimes)-100:]
+	}
 }
 
 // Snapshot returns a snapshot of all metrics.
@@ -169,12 +385,39 @@ func (m *SDKMetrics) Snapshot() MetricsSnapshot {
 
 		TotalEvaluations: atomic.LoadInt64(&m.totalEvaluations),
 
+		RequestLatencyHistogram: m.latencyHistogram.snapshot(),
+		EvaluationTimeHistogram: m.evaluationHistogram.snapshot(),
+
+		PollIntervalMs:    atomic.LoadInt64(&m.pollIntervalMs),
+		FlagsFrozen:       atomic.LoadInt32(&m.flagsFrozen) == 1,
+		FrozenUpdates:     atomic.LoadInt64(&m.frozenUpdates),
+		ThrottledRequests: atomic.LoadInt64(&m.throttledRequests),
+		RejectedEvents:    atomic.LoadInt64(&m.rejectedEvents),
+		DedupedEvents:     atomic.LoadInt64(&m.dedupedEvents),
+		PayloadErrors:     atomic.LoadInt64(&m.payloadErrors),
+
+		Failovers: atomic.LoadInt64(&m.failovers),
+		FailBacks: atomic.LoadInt64(&m.failBacks),
+
+		DeliveredEvents:   atomic.LoadInt64(&m.deliveredEvents),
+		DroppedEvents:     atomic.LoadInt64(&m.droppedEvents),
+		SpilledEvents:     atomic.LoadInt64(&m.spilledEvents),
+		EventFlushRetries: atomic.LoadInt64(&m.eventFlushRetries),
+
+		TelemetryBufferHighWater:  atomic.LoadInt64(&m.telemetryHighWater),
+		TelemetryThresholdFlushes: atomic.LoadInt64(&m.telemetryThresholdFlushes),
+
 		NetworkErrors:   atomic.LoadInt64(&m.networkErrors),
+		NetworkErrorsByKind: make(map[NetworkErrorKind]int64, len(m.networkKinds)),
 		AuthErrors:      atomic.LoadInt64(&m.authErrors),
 		RateLimitErrors: atomic.LoadInt64(&m.rateLimitErrors),
 		ServerErrors:    atomic.LoadInt64(&m.serverErrors),
 	}
 
+	for i, kind := range NetworkErrorKinds() {
+		snapshot.NetworkErrorsByKind[kind] = atomic.LoadInt64(&m.networkKinds[i])
+	}
+
 	// Calculate cache hit rate
 	totalCacheOps := snapshot.CacheHits + snapshot.CacheMisses + snapshot.CacheStaleHits
 	if totalCacheOps > 0 {
@@ -189,6 +432,20 @@ func (m *SDKMetrics) Snapshot() MetricsSnapshot {
 		snapshot.P99Latency = m.percentile(99)
 	}
 
+	// Calculate payload stats
+	if n := len(m.payloadSizes); n > 0 {
+		snapshot.PayloadBytesLast = m.payloadSizes[n-1]
+		snapshot.PayloadBytesAvg = average(m.payloadSizes)
+		snapshot.PayloadBytesP95 = percentileOf(m.payloadSizes, 95)
+		snapshot.ParseTimeAvgMs = average(m.parseTimes) / 1000
+		snapshot.ParseTimeP95Ms = float64(percentileOf(m.parseTimes, 95)) / 1000
+	}
+
+	// Copy per-flag counts
+	if g := m.flagLabels.Load(); g != nil {
+		snapshot.FlagEvaluations, snapshot.FlagLabelsDropped, snapshot.FlagLabelLimitReached = g.snapshot()
+	}
+
 	// Calculate evaluation time average
 	if snapshot.TotalEvaluations > 0 {
 		snapshot.EvaluationTimeAvgMs = float64(atomic.LoadInt64(&m.evaluationTimeSum)) / float64(snapshot.TotalEvaluations)
@@ -221,13 +478,18 @@ func (m *SDKMetrics) calculateLatencyStats() (min, max int64, avg float64) {
 }
 
 func (m *SDKMetrics) percentile(p int) int64 {
-	if len(m.latencies) == 0 {
+	return percentileOf(m.latencies, p)
+}
+
+// percentileOf returns the p-th percentile of values.
+func percentileOf(values []int64, p int) int64 {
+	if len(values) == 0 {
 		return 0
 	}
 
 	// Simple percentile calculation (not perfectly accurate but good enough)
-	sorted := make([]int64, len(m.latencies))
-	copy(sorted, m.latencies)
+	sorted := make([]int64, len(values))
+	copy(sorted, values)
 
 	// Simple bubble sort for small arrays
 	for i := 0; i < len(sorted)-1; i++ {
@@ -246,6 +508,18 @@ func (m *SDKMetrics) percentile(p int) int64 {
 	return sorted[idx]
 }
 
+// average returns the arithmetic mean of values.
+func average(values []int64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+	return float64(sum) / float64(len(values))
+}
+
 // Reset clears all metrics.
 func (m *SDKMetrics) Reset() {
 	m.mu.Lock()
@@ -255,6 +529,7 @@ func (m *SDKMetrics) Reset() {
 	atomic.StoreInt64(&m.successfulRequests, 0)
 	atomic.StoreInt64(&m.failedRequests, 0)
 	m.latencies = make([]int64, 0, 1000)
+	m.latencyHistogram.reset()
 	atomic.StoreInt64(&m.cacheHits, 0)
 	atomic.StoreInt64(&m.cacheMisses, 0)
 	atomic.StoreInt64(&m.cacheStaleHits, 0)
@@ -262,7 +537,29 @@ func (m *SDKMetrics) Reset() {
 	m.circuitHalfOpenCount = 0
 	atomic.StoreInt64(&m.totalEvaluations, 0)
 	atomic.StoreInt64(&m.evaluationTimeSum, 0)
+	m.evaluationHistogram.reset()
+	atomic.StoreInt64(&m.frozenUpdates, 0)
+	atomic.StoreInt64(&m.throttledRequests, 0)
+	atomic.StoreInt64(&m.failovers, 0)
+	atomic.StoreInt64(&m.failBacks, 0)
+	atomic.StoreInt64(&m.rejectedEvents, 0)
+	atomic.StoreInt64(&m.dedupedEvents, 0)
+	atomic.StoreInt64(&m.deliveredEvents, 0)
+	atomic.StoreInt64(&m.droppedEvents, 0)
+	atomic.StoreInt64(&m.spilledEvents, 0)
+	atomic.StoreInt64(&m.eventFlushRetries, 0)
+	atomic.StoreInt64(&m.telemetryHighWater, 0)
+	atomic.StoreInt64(&m.telemetryThresholdFlushes, 0)
+	atomic.StoreInt64(&m.payloadErrors, 0)
+	m.payloadSizes = make([]int64, 0, 100)
+	m.parseTimes = make([]int64, 0, 100)
+	if g := m.flagLabels.Load(); g != nil {
+		g.reset()
+	}
 	atomic.StoreInt64(&m.networkErrors, 0)
+	for i := range m.networkKinds {
+		atomic.StoreInt64(&m.networkKinds[i], 0)
+	}
 	atomic.StoreInt64(&m.authErrors, 0)
 	atomic.StoreInt64(&m.rateLimitErrors, 0)
 	atomic.StoreInt64(&m.serverErrors, 0)
@@ -333,11 +630,79 @@ func (m *SDKMetrics) ToPrometheus(prefix string) string {
 	metric("evaluations_total", snap.TotalEvaluations, "Total flag evaluations", "counter")
 	metric("evaluation_avg_time_ms", snap.EvaluationTimeAvgMs, "Average evaluation time in milliseconds", "gauge")
 
+	// Polling metrics
+	metric("poll_interval_ms", snap.PollIntervalMs, "Current effective polling interval in milliseconds", "gauge")
+	var frozen int64
+	if snap.FlagsFrozen {
+		frozen = 1
+	}
+	metric("flags_frozen", frozen, "Whether flag updates are held back by FreezeFlags (1=yes)", "gauge")
+	metric("frozen_updates_total", snap.FrozenUpdates, "Total flag updates dropped while frozen", "counter")
+	metric("requests_throttled_total", snap.ThrottledRequests, "Total requests delayed or rejected by the client-side rate limiter", "counter")
+	metric("failovers_total", snap.Failovers, "Total switches to the next base URL after persistent failures", "counter")
+	metric("failbacks_total", snap.FailBacks, "Total returns to the primary base URL", "counter")
+
+	// Event metrics
+	metric("events_rejected_total", snap.RejectedEvents, "Total events rejected by validation", "counter")
+	metric("events_deduplicated_total", snap.DedupedEvents, "Total events dropped as duplicates", "counter")
+	metric("events_delivered_total", snap.DeliveredEvents, "Total events accepted by the events endpoint", "counter")
+	metric("events_undelivered_dropped_total", snap.DroppedEvents, "Total undelivered events discarded because the buffer and spill file were full", "counter")
+	metric("events_spilled_total", snap.SpilledEvents, "Total undelivered events written to the spill file", "counter")
+	metric("event_flush_retries_total", snap.EventFlushRetries, "Total event flush requests retried after a transient failure", "counter")
+
+	// Telemetry metrics
+	metric("telemetry_buffer_high_water", snap.TelemetryBufferHighWater, "Largest number of evaluations buffered between telemetry flushes", "gauge")
+	metric("telemetry_threshold_flushes_total", snap.TelemetryThresholdFlushes, "Total telemetry flushes triggered by a full buffer", "counter")
+
+	// Payload metrics
+	metric("payload_errors_total", snap.PayloadErrors, "Total malformed flags payloads and skipped invalid flag entries", "counter")
+	metric("payload_bytes_last", snap.PayloadBytesLast, "Size of the most recent flags payload in bytes", "gauge")
+	metric("payload_bytes_avg", snap.PayloadBytesAvg, "Average flags payload size in bytes", "gauge")
+	metric("payload_bytes_p95", snap.PayloadBytesP95, "95th percentile flags payload size in bytes", "gauge")
+	metric("parse_time_avg_ms", snap.ParseTimeAvgMs, "Average flags payload decode time in milliseconds", "gauge")
+	metric("parse_time_p95_ms", snap.ParseTimeP95Ms, "95th percentile flags payload decode time in milliseconds", "gauge")
+
+	// Per-flag metrics
+	if snap.FlagEvaluations != nil {
+		name := prefix + "_flag_evaluations_total"
+		b.WriteString("# HELP " + name + " Flag evaluations per flag key (keys over the label limit are counted as " + OtherFlagLabel + ")\n")
+		b.WriteString("# TYPE " + name + " counter\n")
+		labels := make([]string, 0, len(snap.FlagEvaluations))
+		for label := range snap.FlagEvaluations {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			b.WriteString(name + `{flag="` + escapeLabelValue(label) + `"} `)
+			b.WriteString(strconv.FormatInt(snap.FlagEvaluations[label], 10))
+			b.WriteString("\n")
+		}
+		metric("flag_labels_dropped_total", snap.FlagLabelsDropped, "Evaluations of flag keys counted under the other label", "counter")
+		var limitReached int64
+		if snap.FlagLabelLimitReached {
+			limitReached = 1
+		}
+		metric("flag_label_limit_reached", limitReached, "Whether the per-flag label limit was reached (1=yes)", "gauge")
+	}
+
 	// Error metrics
 	metric("errors_network_total", snap.NetworkErrors, "Total network errors", "counter")
+	name := prefix + "_errors_network_kind_total"
+	b.WriteString("# HELP " + name + " Network errors by kind\n")
+	b.WriteString("# TYPE " + name + " counter\n")
+	for _, kind := range NetworkErrorKinds() {
+		b.WriteString(name + `{kind="` + string(kind) + `"} `)
+		b.WriteString(strconv.FormatInt(snap.NetworkErrorsByKind[kind], 10))
+		b.WriteString("\n")
+	}
 	metric("errors_auth_total", snap.AuthErrors, "Total authentication errors", "counter")
 	metric("errors_ratelimit_total", snap.RateLimitErrors, "Total rate limit errors", "counter")
 	metric("errors_server_total", snap.ServerErrors, "Total server errors", "counter")
 
 	return b.String()
 }
+
+// escapeLabelValue escapes a Prometheus label value.
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
@@ -3,6 +3,7 @@ package rollgate
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestSDKMetrics_RecordRequest(t *testing.T) {
@@ -234,3 +235,83 @@ func TestSDKMetrics_Percentiles(t *testing.T) {
 		t.Errorf("Expected P99 ~99, got %d", snap.P99Latency)
 	}
 }
+
+func TestSDKMetrics_PayloadStats(t *testing.T) {
+	m := NewSDKMetrics()
+
+	for i := 1; i <= 100; i++ {
+		m.RecordPayload(i*1000, time.Duration(i)*time.Millisecond)
+	}
+	m.RecordPayload(500, 2*time.Millisecond)
+
+	snap := m.Snapshot()
+
+	if snap.PayloadBytesLast != 500 {
+		t.Errorf("Expected last payload 500 bytes, got %d", snap.PayloadBytesLast)
+	}
+	if snap.PayloadBytesP95 < 90000 || snap.PayloadBytesP95 > 100000 {
+		t.Errorf("Expected P95 payload ~95000 bytes, got %d", snap.PayloadBytesP95)
+	}
+	if snap.ParseTimeP95Ms < 90 || snap.ParseTimeP95Ms > 100 {
+		t.Errorf("Expected P95 parse time ~95ms, got %.2f", snap.ParseTimeP95Ms)
+	}
+	if snap.ParseTimeAvgMs <= 0 || snap.PayloadBytesAvg <= 0 {
+		t.Errorf("Expected positive averages, got %.2f bytes / %.2fms", snap.PayloadBytesAvg, snap.ParseTimeAvgMs)
+	}
+
+	m.Reset()
+	if snap := m.Snapshot(); snap.PayloadBytesLast != 0 || snap.ParseTimeP95Ms != 0 {
+		t.Error("Expected payload stats to be cleared by Reset")
+	}
+}
+
+func TestSDKMetrics_Histograms(t *testing.T) {
+	m := NewSDKMetrics()
+
+	m.RecordRequest(5, true, ErrorCategoryNone)
+	m.RecordRequest(30, true, ErrorCategoryNone)
+	m.RecordRequest(20000, false, ErrorCategoryServer)
+	m.RecordEvaluation(2000)    // 0.002ms
+	m.RecordEvaluation(3000000) // 3ms
+
+	snap := m.Snapshot()
+
+	latency := snap.RequestLatencyHistogram
+	if latency.Count != 3 || latency.Sum != 20035 {
+		t.Errorf("Expected 3 requests summing to 20035ms, got %d and %.0f", latency.Count, latency.Sum)
+	}
+	for bound, want := range map[float64]uint64{5: 1, 25: 1, 50: 2, 10000: 2} {
+		if got := latency.Buckets[bound]; got != want {
+			t.Errorf("Expected %d requests <= %.0fms, got %d", want, bound, got)
+		}
+	}
+
+	evaluation := snap.EvaluationTimeHistogram
+	if evaluation.Count != 2 || evaluation.Buckets[0.005] != 1 || evaluation.Buckets[5] != 2 {
+		t.Errorf("Unexpected evaluation histogram %+v", evaluation)
+	}
+
+	m.Reset()
+	if snap := m.Snapshot(); snap.RequestLatencyHistogram.Count != 0 || snap.EvaluationTimeHistogram.Sum != 0 {
+		t.Errorf("Expected Reset to clear histograms, got %+v", snap.RequestLatencyHistogram)
+	}
+}
+
+func TestSDKMetrics_NetworkErrorKinds(t *testing.T) {
+	m := NewSDKMetrics()
+
+	m.RecordNetworkError(NetworkErrorTLS)
+	m.RecordNetworkError(NetworkErrorTLS)
+	m.RecordNetworkError(NetworkErrorDNS)
+
+	snap := m.Snapshot()
+	if snap.NetworkErrorsByKind[NetworkErrorTLS] != 2 || snap.NetworkErrorsByKind[NetworkErrorDNS] != 1 {
+		t.Errorf("Unexpected network error kinds %v", snap.NetworkErrorsByKind)
+	}
+	if n, ok := snap.NetworkErrorsByKind[NetworkErrorReadTimeout]; !ok || n != 0 {
+		t.Errorf("Expected every kind to be reported, got %v", snap.NetworkErrorsByKind)
+	}
+	if output := m.ToPrometheus("rollgate"); !strings.Contains(output, `rollgate_errors_network_kind_total{kind="tls"} 2`) {
+		t.Errorf("Expected network error kinds in the Prometheus output, got:\n%s", output)
+	}
+}
@@ -0,0 +1,453 @@
+package rollgate
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockServer is a slim port of the test-harness mock API: server-side
+// targeting with segments, ETags, error injection and an SSE stream. It
+// keeps the SDK's integration tests hermetic; the full contract suite
+// still runs against the harness.
+type mockServer struct {
+	*httptest.Server
+	apiKey string
+
+	mu          sync.Mutex
+	flags       map[string]*mockFlag
+	segments    map[string][]mockCondition
+	sessions    map[string]map[string]any // Identified user attributes by ID
+	failStatus  int
+	failCount   int
+	requests    map[string]int
+	notModified int
+	streams     map[chan string]string // Open streams and their user ID
+}
+
+// mockFlag is a flag's server-side targeting.
+type mockFlag struct {
+	Key         string
+	Enabled     bool
+	Rollout     int // Percentage of users outside targets and rules (0-100)
+	TargetUsers []string
+	Rules       []mockRule
+}
+
+// mockRule serves the flag to Rollout percent of users matching all conditions.
+type mockRule struct {
+	ID         string
+	Conditions []mockCondition
+	Rollout    int
+}
+
+// mockCondition compares a user attribute; the "segment" attribute with
+// the "in" operator expands to the segment's conditions.
+type mockCondition struct {
+	Attribute string
+	Operator  string // eq, neq, contains, in, gt, lt
+	Value     any
+}
+
+// newMockServer starts a mock API serving flags and closes it with the test.
+func newMockServer(t *testing.T, flags ...*mockFlag) *mockServer {
+	t.Helper()
+	m := &mockServer{
+		apiKey:   "test-key",
+		flags:    make(map[string]*mockFlag),
+		segments: make(map[string][]mockCondition),
+		sessions: make(map[string]map[string]any),
+		requests: make(map[string]int),
+		streams:  make(map[chan string]string),
+	}
+	for _, f := range flags {
+		m.flags[f.Key] = f
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/sdk/flags", m.handleFlags)
+	mux.HandleFunc("/api/v1/sdk/identify", m.handleIdentify)
+	mux.HandleFunc("/api/v1/sdk/identify/batch", m.handleIdentifyBatch)
+	mux.HandleFunc("/api/v1/sdk/stream", m.handleStream)
+	mux.HandleFunc("/api/v1/sdk/events", m.handleAccept)
+	mux.HandleFunc("/api/v1/sdk/telemetry", m.handleAccept)
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		m.requests[r.URL.Path]++
+		m.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+
+	t.Cleanup(func() {
+		m.CloseClientConnections()
+		m.Close()
+	})
+	return m
+}
+
+// config returns a client config pointed at the mock with fast retries.
+func (m *mockServer) config() Config {
+	config := DefaultConfig(m.apiKey)
+	config.BaseURL = m.URL
+	config.RefreshInterval = time.Hour
+	config.Retry.BaseDelay = time.Millisecond
+	config.Retry.MaxDelay = 5 * time.Millisecond
+	return config
+}
+
+// setFlag adds or replaces a flag.
+func (m *mockServer) setFlag(f *mockFlag) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flags[f.Key] = f
+}
+
+// setSegment defines a segment usable in rule conditions.
+func (m *mockServer) setSegment(id string, conditions ...mockCondition) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.segments[id] = conditions
+}
+
+// failNext answers the next count flags requests with status.
+func (m *mockServer) failNext(status, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failStatus = status
+	m.failCount = count
+}
+
+// requestCount returns how many requests were made to path.
+func (m *mockServer) requestCount(path string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.requests[path]
+}
+
+// notModifiedCount returns how many flags requests were answered with 304.
+func (m *mockServer) notModifiedCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.notModified
+}
+
+// streamCount returns the number of open SSE connections.
+func (m *mockServer) streamCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.streams)
+}
+
+// streamUsers returns the user IDs of the open SSE connections.
+func (m *mockServer) streamUsers() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	users := make([]string, 0, len(m.streams))
+	for _, userID := range m.streams {
+		users = append(users, userID)
+	}
+	return users
+}
+
+// broadcast sends a flag-changed event for key, evaluated per stream user.
+func (m *mockServer) broadcast(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for ch, userID := range m.streams {
+		value, reason := m.evaluate(m.flags[key], userID, m.sessions[userID])
+		data, _ := json.Marshal(map[string]any{"key": key, "enabled": value, "reason": reason})
+		select {
+		case ch <- "event: flag-changed\ndata: " + string(data) + "\n\n":
+		default:
+		}
+	}
+}
+
+func (m *mockServer) authenticate(w http.ResponseWriter, r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if token != m.apiKey {
+		http.Error(w, `{"error":"AuthenticationError","message":"Invalid API key"}`, http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func (m *mockServer) handleFlags(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	if m.failCount > 0 {
+		m.failCount--
+		status := m.failStatus
+		m.mu.Unlock()
+		http.Error(w, `{"error":"simulated"}`, status)
+		return
+	}
+	m.mu.Unlock()
+
+	if !m.authenticate(w, r) {
+		return
+	}
+
+	userID := r.URL.Query().Get("user_id")
+	m.mu.Lock()
+	attrs := m.sessions[userID]
+	// Like the harness mock, a user in X-User-Context is evaluated as sent,
+	// without touching its session
+	if user, ok := decodeUserContext(r.Header.Get("X-User-Context")); ok {
+		userID, attrs = user.ID, mockUserAttributes(user)
+	}
+	flags, reasons := m.evaluateAll(userID, attrs)
+	m.mu.Unlock()
+
+	data, _ := json.Marshal(flags)
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(data))
+	if r.Header.Get("If-None-Match") == etag {
+		m.mu.Lock()
+		m.notModified++
+		m.mu.Unlock()
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	response := map[string]any{"flags": flags}
+	if r.URL.Query().Get("withReasons") == "true" {
+		response["reasons"] = reasons
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (m *mockServer) handleIdentify(w http.ResponseWriter, r *http.Request) {
+	if !m.authenticate(w, r) {
+		return
+	}
+	var body struct {
+		User mockUser `json:"user"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.User.ID == "" {
+		http.Error(w, `{"error":"invalid user"}`, http.StatusBadRequest)
+		return
+	}
+
+	attrs := mockUserAttributes(body.User)
+	m.mu.Lock()
+	m.sessions[body.User.ID] = attrs
+	m.mu.Unlock()
+	w.Write([]byte(`{"success":true}`))
+}
+
+func (m *mockServer) handleIdentifyBatch(w http.ResponseWriter, r *http.Request) {
+	if !m.authenticate(w, r) {
+		return
+	}
+	var body struct {
+		Users []mockUser `json:"users"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"invalid users"}`, http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	for _, user := range body.Users {
+		m.sessions[user.ID] = mockUserAttributes(user)
+	}
+	m.mu.Unlock()
+	fmt.Fprintf(w, `{"received":%d}`, len(body.Users))
+}
+
+func (m *mockServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !m.authenticate(w, r) {
+		return
+	}
+	flusher := w.(http.Flusher)
+	userID := r.URL.Query().Get("user_id")
+
+	ch := make(chan string, 16)
+	m.mu.Lock()
+	m.streams[ch] = userID
+	flags, reasons := m.evaluateAll(userID, m.sessions[userID])
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.streams, ch)
+		m.mu.Unlock()
+	}()
+
+	init := map[string]any{"flags": flags}
+	if r.URL.Query().Get("withReasons") == "true" {
+		init["reasons"] = reasons
+	}
+	data, _ := json.Marshal(init)
+	w.Header().Set("Content-Type", "text/event-stream")
+	fmt.Fprintf(w, "event: init\ndata: %s\n\n", data)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-ch:
+			fmt.Fprint(w, event)
+			flusher.Flush()
+		}
+	}
+}
+
+func (m *mockServer) handleAccept(w http.ResponseWriter, r *http.Request) {
+	if !m.authenticate(w, r) {
+		return
+	}
+	w.Write([]byte(`{"success":true}`))
+}
+
+// mockUser is a user as sent in identify bodies and X-User-Context.
+type mockUser struct {
+	ID         string         `json:"id"`
+	Email      string         `json:"email"`
+	Attributes map[string]any `json:"attributes"`
+	Contexts   []Context      `json:"contexts"`
+}
+
+// decodeUserContext decodes an X-User-Context header.
+func decodeUserContext(header string) (mockUser, bool) {
+	var user mockUser
+	data, err := base64.StdEncoding.DecodeString(header)
+	if header == "" || err != nil || json.Unmarshal(data, &user) != nil || user.ID == "" {
+		return mockUser{}, false
+	}
+	return user, true
+}
+
+// mockUserAttributes flattens a user's targeting attributes; contexts are
+// targeted by kind-qualified names ("org.plan").
+func mockUserAttributes(user mockUser) map[string]any {
+	attrs := make(map[string]any, len(user.Attributes)+1)
+	for k, v := range user.Attributes {
+		attrs[k] = v
+	}
+	if user.Email != "" {
+		attrs["email"] = user.Email
+	}
+	for _, ctx := range user.Contexts {
+		attrs[ctx.Kind+".key"] = ctx.Key
+		for k, v := range ctx.Attributes {
+			attrs[ctx.Kind+"."+k] = v
+		}
+	}
+	return attrs
+}
+
+// evaluateAll evaluates every flag for userID with attrs. Callers hold m.mu.
+func (m *mockServer) evaluateAll(userID string, attrs map[string]any) (map[string]bool, map[string]map[string]any) {
+	flags := make(map[string]bool, len(m.flags))
+	reasons := make(map[string]map[string]any, len(m.flags))
+	for key, f := range m.flags {
+		flags[key], reasons[key] = m.evaluate(f, userID, attrs)
+	}
+	return flags, reasons
+}
+
+// evaluate mirrors the harness mock: OFF, then target users, then the first
+// matching rule, then the fallthrough rollout. Callers hold m.mu.
+func (m *mockServer) evaluate(f *mockFlag, userID string, userAttrs map[string]any) (bool, map[string]any) {
+	if f == nil {
+		return false, map[string]any{"kind": "ERROR", "errorKind": "FLAG_NOT_FOUND"}
+	}
+	if !f.Enabled {
+		return false, map[string]any{"kind": "OFF"}
+	}
+	for _, target := range f.TargetUsers {
+		if target == userID {
+			return true, map[string]any{"kind": "TARGET_MATCH"}
+		}
+	}
+
+	attrs := map[string]any{"id": userID}
+	for k, v := range userAttrs {
+		attrs[k] = v
+	}
+	for i, rule := range f.Rules {
+		if m.matches(rule.Conditions, attrs) {
+			in := inRollout(rule.Rollout, userID, f.Key)
+			return in, map[string]any{"kind": "RULE_MATCH", "ruleId": rule.ID, "ruleIndex": i, "inRollout": in}
+		}
+	}
+	in := inRollout(f.Rollout, userID, f.Key)
+	return in, map[string]any{"kind": "FALLTHROUGH", "inRollout": in}
+}
+
+func (m *mockServer) matches(conditions []mockCondition, attrs map[string]any) bool {
+	for _, cond := range conditions {
+		if cond.Attribute == "segment" && cond.Operator == "in" {
+			segment, ok := m.segments[fmt.Sprint(cond.Value)]
+			if !ok || !m.matches(segment, attrs) {
+				return false
+			}
+			continue
+		}
+		value, ok := attrs[cond.Attribute]
+		if !ok || !matchCondition(cond, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchCondition(cond mockCondition, value any) bool {
+	attr, want := fmt.Sprint(value), fmt.Sprint(cond.Value)
+	switch cond.Operator {
+	case "eq":
+		return attr == want
+	case "neq":
+		return attr != want
+	case "contains":
+		return strings.Contains(attr, want)
+	case "in":
+		if values, ok := cond.Value.([]string); ok {
+			for _, v := range values {
+				if attr == v {
+					return true
+				}
+			}
+		}
+		return false
+	case "gt", "lt":
+		a, errA := strconv.ParseFloat(attr, 64)
+		b, errB := strconv.ParseFloat(want, 64)
+		if errA != nil || errB != nil {
+			return false
+		}
+		if cond.Operator == "gt" {
+			return a > b
+		}
+		return a < b
+	default:
+		return false
+	}
+}
+
+// inRollout buckets users the same way as the harness mock.
+func inRollout(percentage int, userID, flagKey string) bool {
+	if percentage >= 100 {
+		return true
+	}
+	if percentage <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(userID + ":" + flagKey))
+	return int(h.Sum32()%100) < percentage
+}
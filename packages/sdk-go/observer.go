@@ -0,0 +1,48 @@
+package rollgate
+
+import "time"
+
+// RequestInfo describes a completed outbound API request.
+// It is passed to Config.RequestObserver once per logical request,
+// after all retries have finished.
+type RequestInfo struct {
+	// Method is the HTTP method (GET, POST)
+	Method string
+
+	// Endpoint is the request URL without query parameters
+	Endpoint string
+
+	// Duration is the total time spent, including retries and backoff
+	Duration time.Duration
+
+	// StatusCode is the final HTTP status code (0 if no response was received)
+	StatusCode int
+
+	// Retries is the number of attempts made after the first one
+	Retries int
+
+	// ETagSent is true if an If-None-Match validator was sent
+	ETagSent bool
+
+	// LastModifiedSent is true if an If-Modified-Since validator was sent
+	// (used when the server provided Last-Modified but no ETag)
+	LastModifiedSent bool
+
+	// NotModified is true if the server answered 304 Not Modified
+	NotModified bool
+
+	// Error is the final error, if the request failed
+	Error error
+}
+
+// RequestObserver is called after each outbound API request.
+// It runs synchronously on the requesting goroutine, so it must be fast
+// and safe for concurrent use.
+type RequestObserver func(RequestInfo)
+
+// notify invokes the observer if one is set.
+func (o RequestObserver) notify(info RequestInfo) {
+	if o != nil {
+		o(info)
+	}
+}
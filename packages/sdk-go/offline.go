@@ -0,0 +1,110 @@
+package rollgate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ClientOption adjusts the Config passed to NewClient.
+type ClientOption func(*Config)
+
+// WithFileDataSource puts the client in offline mode and serves the flag
+// values in the JSON file at path, loaded by Init and again by Refresh.
+// The file is a flags response ({"flags": {...}}) or a plain object of
+// booleans.
+func WithFileDataSource(path string) ClientOption {
+	return func(config *Config) {
+		config.Offline = true
+		config.FlagsFile = path
+	}
+}
+
+// applyOfflineConfig switches off everything that talks to the API.
+func applyOfflineConfig(config *Config) {
+	config.EnableStreaming = false
+	config.RefreshInterval = 0
+	config.Events.Enabled = false
+	config.Telemetry.Enabled = false
+	config.Cache.Enabled = false
+	config.EvaluationMode = EvaluationModeRemote
+}
+
+// initializeOffline loads FlagsFile, if any, and marks the client ready.
+func (c *Client) initializeOffline() error {
+	if c.config.FlagsFile != "" {
+		if err := c.loadFlagsFile(); err != nil {
+			return fmt.Errorf("failed to initialize: %w", err)
+		}
+	}
+	c.mu.Lock()
+	c.ready = true
+	c.mu.Unlock()
+	return nil
+}
+
+// loadFlagsFile replaces the flags with the contents of FlagsFile. A file
+// that cannot be read or parsed leaves the current flags in place.
+func (c *Client) loadFlagsFile() error {
+	data, err := os.ReadFile(c.config.FlagsFile)
+	if err != nil {
+		return fmt.Errorf("failed to read flags file: %w", err)
+	}
+	flags, err := parseFlagsFile(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse flags file %s: %w", c.config.FlagsFile, err)
+	}
+	c.replaceFlags(flags)
+	return nil
+}
+
+// parseFlagsFile decodes a flags file: a flags response or a plain object
+// of booleans. Unlike API payloads it is strict, as a typo in a local file
+// should fail loudly rather than serve defaults.
+func parseFlagsFile(data []byte) (map[string]bool, error) {
+	var wrapped struct {
+		Flags map[string]bool `json:"flags"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err == nil && wrapped.Flags != nil {
+		return wrapped.Flags, nil
+	}
+	var flags map[string]bool
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// SetOfflineFlags replaces the flag values of an offline client and marks
+// it ready, so Init is optional. Online clients ignore it, as their next
+// fetch would overwrite the values.
+func (c *Client) SetOfflineFlags(flags map[string]bool) {
+	if !c.config.Offline {
+		c.config.Logger.Warn("SetOfflineFlags ignored, the client is not in offline mode")
+		return
+	}
+	c.replaceFlags(flags)
+}
+
+// replaceFlags stores a copy of flags as the offline flag values.
+func (c *Client) replaceFlags(flags map[string]bool) {
+	copied := make(map[string]bool, len(flags))
+	for k, v := range flags {
+		copied[k] = v
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flags = copied
+	c.flagReasons = make(map[string]EvaluationReason)
+	c.flagMeta = nil
+	c.fetched = true
+	c.ready = true
+}
+
+// setOfflineUser changes the user of an offline client. Offline values do
+// not depend on the user, so nothing is re-evaluated.
+func (c *Client) setOfflineUser(user *UserContext) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.user = user
+}
@@ -0,0 +1,159 @@
+package rollgate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newForbiddenServer fails the test on any request it receives.
+func newForbiddenServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(func() {
+		server.Close()
+		if n := requests.Load(); n > 0 {
+			t.Errorf("expected no requests in offline mode, got %d", n)
+		}
+	})
+	return server
+}
+
+func TestClient_OfflineFileDataSource(t *testing.T) {
+	server := newForbiddenServer(t)
+	path := filepath.Join(t.TempDir(), "flags.json")
+	if err := os.WriteFile(path, []byte(`{"flags":{"beta":true,"legacy":false}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewClient(Config{
+		BaseURL:         server.URL,
+		EnableStreaming: true,
+		Events:          EventCollectorConfig{Enabled: true, FlushIntervalMs: 10, MaxBufferSize: 1},
+		Telemetry:       TelemetryConfig{Enabled: true, FlushIntervalMs: 10, MaxBufferSize: 1},
+		Exposure:        ExposureConfig{Enabled: true},
+	}, WithFileDataSource(path))
+	if err != nil {
+		t.Fatalf("expected no API key to be needed offline, got %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	t.Run("should serve the file", func(t *testing.T) {
+		if !reflect.DeepEqual(client.GetAllFlags(), map[string]bool{"beta": true, "legacy": false}) {
+			t.Errorf("expected the file's flags, got %v", client.GetAllFlags())
+		}
+		if client.IsStreaming() {
+			t.Error("expected streaming to be disabled")
+		}
+	})
+
+	t.Run("should send nothing on identify, track and flush", func(t *testing.T) {
+		if err := client.Identify(ctx, &UserContext{ID: "user-1"}); err != nil {
+			t.Fatalf("Identify failed: %v", err)
+		}
+		client.IsEnabled("beta", false)
+		client.Track(NewTrackEvent("beta", "purchase", "user-1"))
+		if err := client.FlushEvents(ctx); err != nil {
+			t.Errorf("FlushEvents failed: %v", err)
+		}
+		if err := client.FlushTelemetry(ctx); err != nil {
+			t.Errorf("FlushTelemetry failed: %v", err)
+		}
+		if err := client.Reset(ctx); err != nil {
+			t.Fatalf("Reset failed: %v", err)
+		}
+		if !client.IsEnabled("beta", false) {
+			t.Error("expected offline values not to depend on the user")
+		}
+	})
+
+	t.Run("should reload the file on Refresh", func(t *testing.T) {
+		os.WriteFile(path, []byte(`{"beta":false,"new":true}`), 0o600)
+		if err := client.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+		if !reflect.DeepEqual(client.GetAllFlags(), map[string]bool{"beta": false, "new": true}) {
+			t.Errorf("expected the reloaded flags, got %v", client.GetAllFlags())
+		}
+
+		os.WriteFile(path, []byte(`{"beta":"yes"}`), 0o600)
+		if err := client.Refresh(ctx); err == nil || !strings.Contains(err.Error(), "failed to parse flags file") {
+			t.Errorf("expected a parse error, got %v", err)
+		}
+		if !client.IsEnabled("new", false) {
+			t.Error("expected a bad file to keep the previous flags")
+		}
+	})
+}
+
+func TestClient_SetOfflineFlags(t *testing.T) {
+	server := newForbiddenServer(t)
+
+	t.Run("should serve the flags without Init", func(t *testing.T) {
+		client, err := NewClient(Config{BaseURL: server.URL, Offline: true})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+
+		flags := map[string]bool{"beta": true}
+		client.SetOfflineFlags(flags)
+		flags["beta"] = false
+
+		if !client.IsReady() {
+			t.Error("expected the client to be ready")
+		}
+		if detail := client.IsEnabledDetail("beta", false); !detail.Value || detail.Reason != FallthroughReason(true) {
+			t.Errorf("expected the offline value, got %+v", detail)
+		}
+		if err := client.Init(context.Background()); err != nil {
+			t.Fatalf("Init without a file failed: %v", err)
+		}
+		if !client.IsEnabled("beta", false) {
+			t.Error("expected Init without a file to keep the offline flags")
+		}
+	})
+
+	t.Run("should fail Init on a missing file", func(t *testing.T) {
+		client, err := NewClient(Config{BaseURL: server.URL}, WithFileDataSource(filepath.Join(t.TempDir(), "missing.json")))
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+		if err := client.Init(context.Background()); err == nil || !strings.Contains(err.Error(), "failed to read flags file") {
+			t.Errorf("expected a read error, got %v", err)
+		}
+	})
+
+	t.Run("should be ignored by online clients", func(t *testing.T) {
+		// Telemetry would report the evaluation before Init when closing
+		client, err := NewClient(Config{
+			APIKey:    "test-key",
+			BaseURL:   server.URL,
+			Telemetry: TelemetryConfig{FlushIntervalMs: 60000, MaxBufferSize: 1000},
+		})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+		client.SetOfflineFlags(map[string]bool{"beta": true})
+		if client.IsReady() || client.IsEnabled("beta", false) {
+			t.Error("expected an online client to ignore offline flags")
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package rollgate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// overrideCacheTTL is how long flags fetched for a WithUser or
+	// WithAttributes override are reused.
+	overrideCacheTTL = time.Minute
+	// maxOverrideUsers bounds the per-user flag cache; the oldest entry is
+	// evicted first.
+	maxOverrideUsers = 1000
+)
+
+// overrideEntry is the flag set evaluated remotely for one override user.
+type overrideEntry struct {
+	flags     map[string]bool
+	reasons   map[string]EvaluationReason
+	fetchedAt time.Time
+}
+
+// overrideCache holds flag sets of override users, keyed by overrideKey.
+type overrideCache struct {
+	mu      sync.Mutex
+	entries map[string]*overrideEntry
+	order   []string // Insertion order, for eviction
+}
+
+func newOverrideCache() *overrideCache {
+	return &overrideCache{entries: make(map[string]*overrideEntry)}
+}
+
+// get returns the unexpired entry for key, or nil.
+func (oc *overrideCache) get(key string) *overrideEntry {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	entry, ok := oc.entries[key]
+	if !ok || time.Since(entry.fetchedAt) > overrideCacheTTL {
+		return nil
+	}
+	return entry
+}
+
+// set stores entry under key, evicting the oldest entries past
+// maxOverrideUsers.
+func (oc *overrideCache) set(key string, entry *overrideEntry) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	if _, ok := oc.entries[key]; !ok {
+		oc.order = append(oc.order, key)
+	}
+	oc.entries[key] = entry
+	for len(oc.order) > maxOverrideUsers {
+		delete(oc.entries, oc.order[0])
+		oc.order = oc.order[1:]
+	}
+}
+
+// clear drops every entry.
+func (oc *overrideCache) clear() {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	oc.entries = make(map[string]*overrideEntry)
+	oc.order = nil
+}
+
+// overrideUser returns the user a per-evaluation override evaluates, or nil
+// without overrides. WithUser evaluates another user, keeping nothing of
+// the current one; WithAttributes is layered over the attributes of the
+// current user, or of the WithUser user. Offline values do not depend on
+// the user, so overrides are ignored there.
+// Caller must hold c.mu.
+func (c *Client) overrideUser(o *evalOptions) *UserContext {
+	if (o.userID == "" && o.attributes == nil) || c.config.Offline {
+		return nil
+	}
+	user := &UserContext{}
+	if c.user != nil && (o.userID == "" || o.userID == c.user.ID) {
+		*user = *c.user
+	}
+	if o.userID != "" {
+		user.ID = o.userID
+	}
+	if o.attributes != nil {
+		attrs := make(map[string]any, len(user.Attributes)+len(o.attributes))
+		for k, v := range user.Attributes {
+			attrs[k] = v
+		}
+		for k, v := range o.attributes {
+			attrs[k] = v
+		}
+		user.Attributes = attrs
+	}
+	return user
+}
+
+// overrideKey identifies an override user and everything it is targeted
+// on. Map keys marshal sorted, so equal users get equal keys.
+func overrideKey(user *UserContext) string {
+	data, _ := json.Marshal(identifyUser(user))
+	return string(data)
+}
+
+// overrideFlags returns the flags evaluated remotely for an override user,
+// from the per-user cache or a synchronous request bounded by
+// Config.Timeout. Concurrent misses for the same user share one request.
+func (c *Client) overrideFlags(user *UserContext) (*overrideEntry, error) {
+	key := overrideKey(user)
+	if entry := c.overrides.get(key); entry != nil {
+		return entry, nil
+	}
+	v, err := c.dedup.Dedupe("override:"+key, func() (any, error) {
+		ctx, cancel := context.WithTimeout(c.ctx, c.config.Timeout)
+		defer cancel()
+		var entry *overrideEntry
+		_, err := c.executeFetch(ctx, c.baseURL(ctx)+"/api/v1/sdk/flags", func(ctx context.Context, attempt *fetchAttempt) error {
+			var err error
+			entry, err = c.doFetchOverrideRequest(ctx, user, attempt)
+			return err
+		})
+		return entry, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	entry := v.(*overrideEntry)
+	c.overrides.set(key, entry)
+	return entry, nil
+}
+
+// doFetchOverrideRequest performs one flags request for an override user.
+// The user travels in X-User-Context rather than through identify, so the
+// server's session for that user is left untouched.
+func (c *Client) doFetchOverrideRequest(ctx context.Context, user *UserContext, attempt *fetchAttempt) (*overrideEntry, error) {
+	*attempt = fetchAttempt{}
+
+	u, err := url.Parse(c.baseURL(ctx) + "/api/v1/sdk/flags")
+	if err != nil {
+		return nil, NewNetworkError("invalid URL", err)
+	}
+	q := u.Query()
+	if user.ID != "" {
+		q.Set("user_id", user.ID)
+	}
+	q.Set("withReasons", "true")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, NewNetworkError("failed to create request", err)
+	}
+	setRequestHeaders(req, c.config.CustomHeaders)
+	if err := c.tokens.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-Context", encodeUserContext(user))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, requestError(err)
+	}
+	defer resp.Body.Close()
+
+	attempt.statusCode = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, NewNetworkError("failed to read response", err)
+	}
+	flagsResp, _, err := parseFlagsPayload(body)
+	if err != nil {
+		return nil, NewMalformedResponseError("failed to parse response", err)
+	}
+	return &overrideEntry{flags: flagsResp.Flags, reasons: flagsResp.Reasons, fetchedAt: time.Now()}, nil
+}
+
+// overrideDetail evaluates flagKey from an override user's flag set.
+func overrideDetail(flagKey string, defaultValue bool, entry *overrideEntry, fetchErr error) BoolEvaluationDetail {
+	if fetchErr != nil {
+		kind := ErrorException
+		var malformed *MalformedResponseError
+		var rateLimited *RateLimitError
+		switch {
+		case errors.As(fetchErr, &malformed):
+			kind = ErrorMalformedResponse
+		case errors.As(fetchErr, &rateLimited):
+			kind = ErrorRateLimited
+		}
+		return BoolEvaluationDetail{Value: defaultValue, Reason: ErrorReason(kind)}
+	}
+	value, ok := entry.flags[flagKey]
+	if !ok {
+		return BoolEvaluationDetail{Value: defaultValue, Reason: UnknownReason()}
+	}
+	detail := BoolEvaluationDetail{Value: value, Reason: FallthroughReason(value)}
+	if reason, ok := entry.reasons[flagKey]; ok {
+		detail.Reason = reason
+		detail.VariationID = reason.VariationID
+	}
+	return detail
+}
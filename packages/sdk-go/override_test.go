@@ -0,0 +1,103 @@
+package rollgate
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClient_EvalOptionsRemote(t *testing.T) {
+	m := newMockServer(t,
+		&mockFlag{Key: "beta", Enabled: true, TargetUsers: []string{"vip"}},
+		&mockFlag{Key: "pro-only", Enabled: true, Rules: []mockRule{{
+			ID:         "pro-plan",
+			Conditions: []mockCondition{{Attribute: "plan", Operator: "eq", Value: "pro"}},
+			Rollout:    100,
+		}}},
+	)
+	config := m.config()
+	config.User = &UserContext{ID: "user-1", Attributes: map[string]any{"plan": "free", "country": "IT"}}
+	client := newIntegrationClient(t, config)
+	if err := client.Identify(context.Background(), config.User); err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+
+	t.Run("should evaluate another user with WithUser", func(t *testing.T) {
+		if detail := client.IsEnabledDetail("beta", false, WithUser("vip")); !detail.Value || detail.Reason != TargetMatchReason() {
+			t.Errorf("expected vip to be targeted, got %+v", detail)
+		}
+		if client.IsEnabled("beta", false) {
+			t.Error("expected the current user to keep its own value")
+		}
+	})
+
+	t.Run("should layer WithAttributes over the current user", func(t *testing.T) {
+		detail := client.IsEnabledDetail("pro-only", false, WithAttributes(map[string]any{"plan": "pro"}))
+		if !detail.Value || detail.Reason.RuleID != "pro-plan" {
+			t.Errorf("expected the pro-plan rule to match, got %+v", detail)
+		}
+		if client.IsEnabled("pro-only", false) {
+			t.Error("expected the override not to change the current user's session")
+		}
+	})
+
+	t.Run("should reuse the override user's flags", func(t *testing.T) {
+		before := m.requestCount("/api/v1/sdk/flags")
+		client.IsEnabled("beta", false, WithUser("vip"))
+		client.IsEnabled("pro-only", false, WithUser("vip"))
+		if n := m.requestCount("/api/v1/sdk/flags") - before; n != 0 {
+			t.Errorf("expected cached override flags, got %d requests", n)
+		}
+
+		client.IsEnabled("beta", false, WithUser("vip"), WithAttributes(map[string]any{"plan": "pro"}))
+		if n := m.requestCount("/api/v1/sdk/flags") - before; n != 1 {
+			t.Errorf("expected other attributes to fetch once, got %d requests", n)
+		}
+	})
+
+	t.Run("should return the default when the override cannot be evaluated", func(t *testing.T) {
+		m.failNext(http.StatusBadRequest, 1)
+		detail := client.IsEnabledDetail("beta", true, WithUser("unreachable"))
+		if !detail.Value || detail.Reason != ErrorReason(ErrorException) {
+			t.Errorf("expected the default with an ERROR reason, got %+v", detail)
+		}
+	})
+}
+
+func TestClient_EvalOptionsLocal(t *testing.T) {
+	server, requests := newRulesServer(t)
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		EvaluationMode:  EvaluationModeLocal,
+		User:            &UserContext{ID: "user-2"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	before := len(requests())
+
+	if detail := client.IsEnabledDetail("beta", false, WithUser("user-1")); detail.Reason != TargetMatchReason() {
+		t.Errorf("expected user-1 to be targeted, got %+v", detail)
+	}
+	if detail := client.IsEnabledDetail("beta", false, WithAttributes(map[string]any{"plan": "pro"})); detail.Reason != RuleMatchReason("pro-plan", 0, true) {
+		t.Errorf("expected the pro-plan rule to match, got %+v", detail)
+	}
+	want := isInRollout("half", "user-3", 50)
+	if got := client.IsEnabled("half", !want, WithUser("user-3")); got != want {
+		t.Errorf("expected the rollout hash of user-3, got %v", got)
+	}
+	if detail := client.IsEnabledDetail("beta", true); detail.Value {
+		t.Errorf("expected the current user to keep its own value, got %+v", detail)
+	}
+	if n := len(requests()); n != before {
+		t.Errorf("expected local overrides to send no requests, got %d", n-before)
+	}
+}
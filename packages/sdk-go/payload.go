@@ -0,0 +1,191 @@
+package rollgate
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"strings"
+	"time"
+)
+
+// errInvalidPayload is returned when a flags payload cannot be used at all.
+var errInvalidPayload = errors.New("flags payload is not a JSON object with a flags object")
+
+// parseFlagsPayload decodes a flags payload tolerantly. Entries that are not
+// valid (a non-boolean flag value, a malformed reason) are skipped and counted
+// so one bad flag cannot fail the whole refresh. An error is returned only if
+// the payload itself is unusable.
+func parseFlagsPayload(body []byte) (resp flagsResponse, skipped int, err error) {
+	var raw struct {
+		Flags     map[string]json.RawMessage `json:"flags"`
+		Reasons   map[string]json.RawMessage `json:"reasons"`
+		Metadata  map[string]json.RawMessage `json:"metadata"`
+		SDKConfig json.RawMessage            `json:"sdkConfig"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return flagsResponse{}, 0, err
+	}
+	if raw.Flags == nil {
+		return flagsResponse{}, 0, errInvalidPayload
+	}
+
+	resp.Flags = make(map[string]bool, len(raw.Flags))
+	for key, value := range raw.Flags {
+		var enabled bool
+		if key == "" || json.Unmarshal(value, &enabled) != nil {
+			skipped++
+			continue
+		}
+		resp.Flags[key] = enabled
+	}
+
+	if raw.Reasons != nil {
+		resp.Reasons = make(map[string]EvaluationReason, len(raw.Reasons))
+		for key, value := range raw.Reasons {
+			var reason EvaluationReason
+			if json.Unmarshal(value, &reason) != nil || reason.Kind == "" {
+				skipped++
+				continue
+			}
+			if _, ok := resp.Flags[key]; ok {
+				resp.Reasons[key] = reason
+			}
+		}
+	}
+
+	if raw.Metadata != nil {
+		resp.Metadata = make(map[string]FlagMetadata, len(raw.Metadata))
+		for key, value := range raw.Metadata {
+			var meta FlagMetadata
+			if json.Unmarshal(value, &meta) != nil || meta.Version < 0 {
+				skipped++
+				continue
+			}
+			if _, ok := resp.Flags[key]; ok {
+				resp.Metadata[key] = meta
+			}
+		}
+	}
+
+	// Hints are optional: an unreadable sdkConfig is counted and ignored
+	if len(raw.SDKConfig) > 0 && string(raw.SDKConfig) != "null" {
+		var config sdkConfigPayload
+		if json.Unmarshal(raw.SDKConfig, &config) != nil {
+			skipped++
+		} else {
+			resp.SDKConfig = &config
+		}
+	}
+
+	return resp, skipped, nil
+}
+
+// parseTypedFlagsPayload decodes a V2 flags payload with the same tolerance
+// as parseFlagsPayload: invalid entries are skipped and counted.
+func parseTypedFlagsPayload(body []byte) (flags map[string]typedFlag, skipped int, err error) {
+	var raw struct {
+		Flags map[string]json.RawMessage `json:"flags"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, 0, err
+	}
+	if raw.Flags == nil {
+		return nil, 0, errInvalidPayload
+	}
+
+	flags = make(map[string]typedFlag, len(raw.Flags))
+	for key, value := range raw.Flags {
+		flag, ok := parseTypedFlag(value)
+		if key == "" || !ok {
+			skipped++
+			continue
+		}
+		flags[key] = flag
+	}
+	return flags, skipped, nil
+}
+
+// v2FlagEntry is one flag in the V2 flags payload.
+type v2FlagEntry struct {
+	Type      string            `json:"type"`
+	Value     json.RawMessage   `json:"value"`
+	Enabled   bool              `json:"enabled"`
+	Reason    *EvaluationReason `json:"reason,omitempty"`
+	Version   int               `json:"version,omitempty"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// parseTypedFlag decodes one V2 flag entry. Values are checked against the
+// declared type, except that a disabled or unserved flag carries false.
+func parseTypedFlag(raw json.RawMessage) (typedFlag, bool) {
+	var entry v2FlagEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return typedFlag{}, false
+	}
+	flag := typedFlag{
+		Type:     entry.Type,
+		Enabled:  entry.Enabled,
+		Metadata: FlagMetadata{Version: entry.Version, UpdatedAt: entry.UpdatedAt},
+	}
+	if entry.Reason != nil {
+		flag.Reason = entry.Reason
+		flag.VariationID = entry.Reason.VariationID
+	}
+	if len(entry.Value) > 0 {
+		if err := json.Unmarshal(entry.Value, &flag.Value); err != nil {
+			return typedFlag{}, false
+		}
+	}
+
+	switch entry.Type {
+	case FlagTypeBoolean:
+		_, ok := flag.Value.(bool)
+		return flag, ok
+	case FlagTypeString, FlagTypeNumber, FlagTypeJSON:
+		return flag, true
+	default:
+		return typedFlag{}, false
+	}
+}
+
+// isJSONContentType reports whether a Content-Type header names JSON
+// (application/json or a +json media type).
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// parseRulesPayload decodes a rules payload for local evaluation with the
+// same tolerance as parseFlagsPayload: invalid entries are skipped and
+// counted. A rule without a key takes its map key, since rollout hashing
+// depends on it.
+func parseRulesPayload(body []byte) (payload RulesPayload, skipped int, err error) {
+	var raw struct {
+		Version string                     `json:"version"`
+		Flags   map[string]json.RawMessage `json:"flags"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return RulesPayload{}, 0, err
+	}
+	if raw.Flags == nil {
+		return RulesPayload{}, 0, errInvalidPayload
+	}
+
+	payload.Version = raw.Version
+	payload.Flags = make(map[string]FlagRule, len(raw.Flags))
+	for key, value := range raw.Flags {
+		var rule FlagRule
+		if key == "" || json.Unmarshal(value, &rule) != nil {
+			skipped++
+			continue
+		}
+		if rule.Key == "" {
+			rule.Key = key
+		}
+		payload.Flags[key] = rule
+	}
+	return payload, skipped, nil
+}
@@ -0,0 +1,223 @@
+package rollgate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseFlagsPayload(t *testing.T) {
+	t.Run("should parse a valid payload", func(t *testing.T) {
+		resp, skipped, err := parseFlagsPayload([]byte(`{"flags":{"a":true,"b":false},"reasons":{"a":{"kind":"RULE_MATCH","ruleId":"r1"}}}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if skipped != 0 {
+			t.Errorf("expected 0 skipped, got %d", skipped)
+		}
+		if !resp.Flags["a"] || resp.Flags["b"] {
+			t.Errorf("unexpected flags: %v", resp.Flags)
+		}
+		if resp.Reasons["a"].RuleID != "r1" {
+			t.Errorf("expected reason for a, got %+v", resp.Reasons["a"])
+		}
+	})
+
+	t.Run("should skip invalid entries and keep valid ones", func(t *testing.T) {
+		resp, skipped, err := parseFlagsPayload([]byte(`{"flags":{"good":true,"bad":"yes","worse":{"x":1},"":true},"reasons":{"good":"nope"}}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if skipped != 4 {
+			t.Errorf("expected 4 skipped, got %d", skipped)
+		}
+		if len(resp.Flags) != 1 || !resp.Flags["good"] {
+			t.Errorf("expected only good flag, got %v", resp.Flags)
+		}
+		if _, ok := resp.Reasons["good"]; ok {
+			t.Error("expected malformed reason to be dropped")
+		}
+	})
+
+	t.Run("should keep valid metadata of known flags", func(t *testing.T) {
+		resp, skipped, err := parseFlagsPayload([]byte(`{"flags":{"a":true,"b":true},"metadata":{"a":{"version":3},"b":{"version":-1},"c":{"version":1}}}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if skipped != 1 {
+			t.Errorf("expected 1 skipped, got %d", skipped)
+		}
+		if len(resp.Metadata) != 1 || resp.Metadata["a"].Version != 3 {
+			t.Errorf("expected only the metadata of a, got %v", resp.Metadata)
+		}
+	})
+
+	t.Run("should ignore unknown top-level fields", func(t *testing.T) {
+		resp, _, err := parseFlagsPayload([]byte(`{"flags":{"a":true},"version":7,"segments":[1,2]}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !resp.Flags["a"] {
+			t.Error("expected flag a")
+		}
+	})
+
+	t.Run("should reject unusable payloads", func(t *testing.T) {
+		for _, body := range []string{``, `null`, `[]`, `{"flags":null}`, `{"flags":[true]}`, `{"other":{}}`, `{"flags":`} {
+			if _, _, err := parseFlagsPayload([]byte(body)); err == nil {
+				t.Errorf("expected error for %q", body)
+			}
+		}
+	})
+}
+
+func FuzzParseFlagsPayload(f *testing.F) {
+	f.Add([]byte(`{"flags":{"a":true,"b":false}}`))
+	f.Add([]byte(`{"flags":{"a":true},"reasons":{"a":{"kind":"OFF"}}}`))
+	f.Add([]byte(`{"flags":{"a":"true","b":1,"c":null}}`))
+	f.Add([]byte(`{"flags":{},"reasons":{"x":[]}}`))
+	f.Add([]byte(`not json`))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		resp, skipped, err := parseFlagsPayload(body)
+		if err != nil {
+			if resp.Flags != nil || skipped != 0 {
+				t.Fatalf("expected empty result on error, got %v (%d skipped)", resp.Flags, skipped)
+			}
+			return
+		}
+		if resp.Flags == nil {
+			t.Fatal("expected non-nil flags on success")
+		}
+		for key := range resp.Reasons {
+			if _, ok := resp.Flags[key]; !ok {
+				t.Fatalf("reason for unknown flag %q", key)
+			}
+		}
+	})
+}
+
+func TestParseRulesPayload(t *testing.T) {
+	payload, skipped, err := parseRulesPayload([]byte(`{"version":"7","flags":{"a":{"enabled":true,"rollout":50},"b":{"key":"b","enabled":"yes"},"":{}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if skipped != 2 {
+		t.Errorf("expected 2 skipped, got %d", skipped)
+	}
+	if payload.Version != "7" || len(payload.Flags) != 1 {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+	if rule := payload.Flags["a"]; rule.Key != "a" || !rule.Enabled || rule.Rollout != 50 {
+		t.Errorf("expected rule a to take its map key, got %+v", rule)
+	}
+
+	for _, body := range []string{``, `null`, `{"flags":null}`, `{"flags":[]}`} {
+		if _, _, err := parseRulesPayload([]byte(body)); err == nil {
+			t.Errorf("expected error for %q", body)
+		}
+	}
+}
+
+func TestClient_MalformedPayload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/sdk/flags" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"flags":{"good-flag":true,"bad-flag":"enabled"}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	if !client.IsEnabled("good-flag", false) {
+		t.Error("expected valid flag to survive a bad sibling")
+	}
+	if _, ok := client.GetAllFlags()["bad-flag"]; ok {
+		t.Error("expected invalid flag to be skipped")
+	}
+	metrics := client.GetMetrics()
+	if metrics.PayloadErrors != 1 {
+		t.Errorf("expected 1 payload error, got %d", metrics.PayloadErrors)
+	}
+	if metrics.PayloadBytesLast != int64(len(`{"flags":{"good-flag":true,"bad-flag":"enabled"}}`)) {
+		t.Errorf("expected payload size to be recorded, got %d", metrics.PayloadBytesLast)
+	}
+}
+
+func TestClient_MalformedResponse(t *testing.T) {
+	bodies := []struct {
+		name, contentType, body string
+	}{
+		{"invalid JSON", "application/json", `{"flags":{"flag":tru}}`},
+		{"truncated", "application/json", `{"flags":{"flag":true,"oth`},
+		{"HTML error page", "text/html", `<html><body>Bad Gateway</body></html>`},
+	}
+	for _, tt := range bodies {
+		t.Run(tt.name, func(t *testing.T) {
+			var corrupt atomic.Bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if corrupt.Load() {
+					w.Header().Set("Content-Type", tt.contentType)
+					w.Write([]byte(tt.body))
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"flags":{"flag":true}}`))
+			}))
+			defer server.Close()
+
+			client, err := NewClient(Config{APIKey: "test-key", BaseURL: server.URL, RefreshInterval: time.Hour})
+			if err != nil {
+				t.Fatalf("NewClient failed: %v", err)
+			}
+			defer client.Close()
+			ctx := context.Background()
+			if err := client.Init(ctx); err != nil {
+				t.Fatalf("Init failed: %v", err)
+			}
+			if detail := client.IsEnabledDetail("missing", false); detail.Reason.Kind != ReasonUnknown {
+				t.Errorf("expected UNKNOWN before a malformed response, got %+v", detail.Reason)
+			}
+
+			corrupt.Store(true)
+			var malformed *MalformedResponseError
+			if err := client.Refresh(ctx); !errors.As(err, &malformed) {
+				t.Fatalf("expected a MalformedResponseError, got %v", err)
+			}
+			if !client.IsEnabled("flag", false) {
+				t.Error("expected the last known value after a malformed response")
+			}
+			detail := client.IsEnabledDetail("missing", false)
+			if detail.Reason.Kind != ReasonError || detail.Reason.ErrorKind != ErrorMalformedResponse {
+				t.Errorf("expected ERROR/MALFORMED_RESPONSE, got %+v", detail.Reason)
+			}
+
+			corrupt.Store(false)
+			if err := client.Refresh(ctx); err != nil {
+				t.Fatalf("Refresh failed: %v", err)
+			}
+			if detail := client.IsEnabledDetail("missing", false); detail.Reason.Kind != ReasonUnknown {
+				t.Errorf("expected UNKNOWN after a good response, got %+v", detail.Reason)
+			}
+		})
+	}
+}
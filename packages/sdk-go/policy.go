@@ -0,0 +1,66 @@
+package rollgate
+
+// FlagPolicy decides what IsEnabled returns for a boolean flag the client
+// cannot evaluate: when the flag is unknown or the client is not ready.
+type FlagPolicy int
+
+const (
+	// PolicyDefault returns the default value, as if no policy were set.
+	PolicyDefault FlagPolicy = iota
+	// FailOpen returns true, whatever default the caller passes.
+	FailOpen
+	// FailClosed returns false, whatever default the caller passes. Use it
+	// for kill switches and other security-sensitive gates that must not
+	// turn on because of a typo in a default or a slow Init.
+	FailClosed
+)
+
+// String returns the policy's name.
+func (p FlagPolicy) String() string {
+	switch p {
+	case FailOpen:
+		return "FailOpen"
+	case FailClosed:
+		return "FailClosed"
+	default:
+		return "Default"
+	}
+}
+
+// RegisterFlagPolicies sets the policy of boolean flags, so the value of a
+// gate the client cannot evaluate is decided once, centrally, instead of by
+// the default at each call site.
+//
+// A policy applies when the client is not ready and when the flag is not in
+// the client's flags, and overrides both the caller's default and a
+// registered default. The evaluation reason still tells which of the two
+// happened. Registering a key again replaces its policy, and PolicyDefault
+// removes it.
+func (c *Client) RegisterFlagPolicies(policies map[string]FlagPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.policies == nil {
+		c.policies = make(map[string]FlagPolicy, len(policies))
+	}
+	for k, p := range policies {
+		if p == PolicyDefault {
+			delete(c.policies, k)
+			continue
+		}
+		c.policies[k] = p
+	}
+}
+
+// policyValue resolves the value of a boolean flag the client cannot
+// evaluate. Caller must hold c.mu.
+func (c *Client) policyValue(flagKey string, defaultValue bool) bool {
+	switch c.policies[flagKey] {
+	case FailOpen:
+		return true
+	case FailClosed:
+		return false
+	default:
+		return defaultValue
+	}
+}
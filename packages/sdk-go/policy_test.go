@@ -0,0 +1,73 @@
+package rollgate
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClient_RegisterFlagPolicies(t *testing.T) {
+	server := newTestServer(map[string]bool{"known-switch": true})
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	client.RegisterDefaults(map[string]any{"admin-panel": true})
+	client.RegisterFlagPolicies(map[string]FlagPolicy{
+		"admin-panel":  FailClosed,
+		"known-switch": FailClosed,
+		"maintenance":  FailOpen,
+		"removed":      FailClosed,
+	})
+	client.RegisterFlagPolicies(map[string]FlagPolicy{"removed": PolicyDefault})
+
+	t.Run("should fail closed before initialization", func(t *testing.T) {
+		detail := client.IsEnabledDetail("admin-panel", true)
+		if detail.Value {
+			t.Error("expected false despite the default")
+		}
+		if detail.Reason.ErrorKind != ErrorClientNotReady {
+			t.Errorf("expected CLIENT_NOT_READY, got %+v", detail.Reason)
+		}
+	})
+
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	t.Run("should apply policies to unknown flags", func(t *testing.T) {
+		detail := client.IsEnabledDetail("admin-panel", true)
+		if detail.Value || detail.Reason.Kind != ReasonUnknown {
+			t.Errorf("expected false with an UNKNOWN reason, got %+v", detail)
+		}
+		if !client.IsEnabled("maintenance", false) {
+			t.Error("expected FailOpen to return true")
+		}
+	})
+
+	t.Run("should not apply policies to known flags", func(t *testing.T) {
+		if !client.IsEnabled("known-switch", false) {
+			t.Error("expected the server value")
+		}
+	})
+
+	t.Run("should apply policies to override users", func(t *testing.T) {
+		if client.IsEnabled("admin-panel", true, WithUser("user-2")) {
+			t.Error("expected false for an unknown flag of the override user")
+		}
+	})
+
+	t.Run("should use the default once a policy is removed", func(t *testing.T) {
+		if !client.IsEnabled("removed", true) {
+			t.Error("expected the default")
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package rollgate
+
+import "time"
+
+// pollBackoff computes the background polling interval based on the
+// outcome of the previous refresh.
+type pollBackoff struct {
+	base       time.Duration
+	max        time.Duration
+	multiplier float64
+	current    time.Duration
+}
+
+// newPollBackoff creates a pollBackoff starting at the base interval.
+func newPollBackoff(base, max time.Duration, multiplier float64) *pollBackoff {
+	if max < base {
+		max = base
+	}
+	return &pollBackoff{
+		base:       base,
+		max:        max,
+		multiplier: multiplier,
+		current:    base,
+	}
+}
+
+// SetBase changes the interval used while refreshes succeed. The backoff
+// cap is raised to base if it is lower.
+func (p *pollBackoff) SetBase(base time.Duration) {
+	if p.current == p.base {
+		p.current = base
+	}
+	p.base = base
+	if p.max < base {
+		p.max = base
+	}
+}
+
+// Current returns the interval to wait before the next poll.
+func (p *pollBackoff) Current() time.Duration {
+	return p.current
+}
+
+// Next records the outcome of a refresh and returns the next interval.
+// Failures multiply the interval up to max; a success resets it to base.
+func (p *pollBackoff) Next(success bool) time.Duration {
+	if success {
+		p.current = p.base
+		return p.current
+	}
+
+	next := time.Duration(float64(p.current) * p.multiplier)
+	if next > p.max || next <= 0 {
+		next = p.max
+	}
+	p.current = next
+	return p.current
+}
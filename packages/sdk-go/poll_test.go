@@ -0,0 +1,88 @@
+package rollgate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPollBackoff(t *testing.T) {
+	t.Run("should start at base interval", func(t *testing.T) {
+		p := newPollBackoff(time.Second, 10*time.Second, 2)
+		if p.Current() != time.Second {
+			t.Errorf("expected 1s, got %v", p.Current())
+		}
+	})
+
+	t.Run("should multiply interval on failure", func(t *testing.T) {
+		p := newPollBackoff(time.Second, 10*time.Second, 2)
+		if got := p.Next(false); got != 2*time.Second {
+			t.Errorf("expected 2s, got %v", got)
+		}
+		if got := p.Next(false); got != 4*time.Second {
+			t.Errorf("expected 4s, got %v", got)
+		}
+	})
+
+	t.Run("should cap interval at max", func(t *testing.T) {
+		p := newPollBackoff(time.Second, 5*time.Second, 2)
+		for i := 0; i < 10; i++ {
+			p.Next(false)
+		}
+		if p.Current() != 5*time.Second {
+			t.Errorf("expected 5s, got %v", p.Current())
+		}
+	})
+
+	t.Run("should reset on success", func(t *testing.T) {
+		p := newPollBackoff(time.Second, 10*time.Second, 2)
+		p.Next(false)
+		p.Next(false)
+		if got := p.Next(true); got != time.Second {
+			t.Errorf("expected 1s, got %v", got)
+		}
+	})
+
+	t.Run("should not go below base when max is smaller", func(t *testing.T) {
+		p := newPollBackoff(time.Second, 100*time.Millisecond, 2)
+		if got := p.Next(false); got != time.Second {
+			t.Errorf("expected 1s, got %v", got)
+		}
+	})
+}
+
+func TestClient_PollingBackoffOnFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:                   "test-key",
+		BaseURL:                  server.URL,
+		RefreshInterval:          10 * time.Millisecond,
+		MaxRefreshInterval:       40 * time.Millisecond,
+		RefreshBackoffMultiplier: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	// Seed the cache so the failing initial fetch doesn't abort Init
+	client.cache.Set(map[string]bool{"flag": true})
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if client.GetMetrics().PollIntervalMs == 40 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("expected poll interval to back off to 40ms, got %dms", client.GetMetrics().PollIntervalMs)
+}
@@ -0,0 +1,193 @@
+// Package prometheus exposes Rollgate SDK metrics as a prometheus.Collector,
+// so they can be served from an existing /metrics endpoint:
+//
+//	import rollgateprom "github.com/rollgate/sdks/packages/sdk-go/prometheus"
+//
+//	prometheus.MustRegister(rollgateprom.NewCollector(client, "rollgate"))
+//
+// It lives in its own module so the SDK itself does not depend on the
+// Prometheus client library.
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	rollgate "github.com/rollgate/sdks/packages/sdk-go"
+)
+
+// MetricsSource provides SDK metrics snapshots. *rollgate.Client
+// implements it.
+type MetricsSource interface {
+	GetMetrics() rollgate.MetricsSnapshot
+}
+
+// MetricsSourceFunc adapts a function, such as (*rollgate.SDKMetrics).Snapshot,
+// to a MetricsSource.
+type MetricsSourceFunc func() rollgate.MetricsSnapshot
+
+// GetMetrics calls f.
+func (f MetricsSourceFunc) GetMetrics() rollgate.MetricsSnapshot {
+	return f()
+}
+
+// Collector is a prometheus.Collector reporting the metrics of one SDK
+// client. Every scrape takes a fresh snapshot, so values are never counted
+// twice and nothing is recorded between scrapes.
+type Collector struct {
+	source MetricsSource
+
+	requests           *prometheus.Desc
+	requestErrors      *prometheus.Desc
+	networkErrors      *prometheus.Desc
+	requestDuration    *prometheus.Desc
+	requestsThrottled  *prometheus.Desc
+	baseURLSwitches    *prometheus.Desc
+	cacheHits          *prometheus.Desc
+	cacheMisses        *prometheus.Desc
+	circuitState       *prometheus.Desc
+	circuitTransitions *prometheus.Desc
+	evaluations        *prometheus.Desc
+	evaluationDuration *prometheus.Desc
+	flagEvaluations    *prometheus.Desc
+	pollInterval       *prometheus.Desc
+	flagsFrozen        *prometheus.Desc
+	frozenUpdates      *prometheus.Desc
+	payloadErrors      *prometheus.Desc
+	eventsDropped      *prometheus.Desc
+	eventsDelivered    *prometheus.Desc
+	eventsSpilled      *prometheus.Desc
+	eventFlushRetries  *prometheus.Desc
+}
+
+// NewCollector returns a Collector for source. Metric names are prefixed
+// with namespace, e.g. "rollgate_requests_total"; an empty namespace
+// leaves them unprefixed.
+func NewCollector(source MetricsSource, namespace string) *Collector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil)
+	}
+	return &Collector{
+		source:             source,
+		requests:           desc("requests_total", "Requests to the Rollgate API by outcome.", "outcome"),
+		requestErrors:      desc("request_errors_total", "Failed requests by error category.", "category"),
+		networkErrors:      desc("network_errors_total", "Network errors by kind: dns, tls, connect_timeout, read_timeout, connection or other.", "kind"),
+		requestDuration:    desc("request_duration_seconds", "Latency of requests to the Rollgate API."),
+		requestsThrottled:  desc("requests_throttled_total", "Requests delayed or rejected by the client-side rate limiter."),
+		baseURLSwitches:    desc("base_url_switches_total", "Switches between base URLs by direction: failover or failback.", "direction"),
+		cacheHits:          desc("cache_hits_total", "Flag cache hits by freshness.", "state"),
+		cacheMisses:        desc("cache_misses_total", "Flag cache misses."),
+		circuitState:       desc("circuit_state", "Circuit breaker state (1 for the current state).", "state"),
+		circuitTransitions: desc("circuit_transitions_total", "Circuit breaker transitions by target state.", "state"),
+		evaluations:        desc("evaluations_total", "Flag evaluations."),
+		evaluationDuration: desc("evaluation_duration_seconds", "Time spent evaluating flags."),
+		flagEvaluations:    desc("flag_evaluations_total", "Flag evaluations per flag key (keys over the label limit are counted as "+rollgate.OtherFlagLabel+").", "flag"),
+		pollInterval:       desc("poll_interval_seconds", "Current effective polling interval, including failure backoff."),
+		flagsFrozen:        desc("flags_frozen", "Whether flag updates are held back by FreezeFlags (1 for yes)."),
+		frozenUpdates:      desc("frozen_updates_total", "Flag updates dropped while frozen."),
+		payloadErrors:      desc("payload_errors_total", "Malformed flags payloads and skipped invalid flag entries."),
+		eventsDropped:      desc("events_dropped_total", "Events dropped before sending by reason.", "reason"),
+		eventsDelivered:    desc("events_delivered_total", "Events accepted by the events endpoint."),
+		eventsSpilled:      desc("events_spilled_total", "Undelivered events written to the spill file."),
+		eventFlushRetries:  desc("event_flush_retries_total", "Event flush requests retried after a transient failure."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.requests
+	ch <- c.requestErrors
+	ch <- c.networkErrors
+	ch <- c.requestDuration
+	ch <- c.requestsThrottled
+	ch <- c.baseURLSwitches
+	ch <- c.cacheHits
+	ch <- c.cacheMisses
+	ch <- c.circuitState
+	ch <- c.circuitTransitions
+	ch <- c.evaluations
+	ch <- c.evaluationDuration
+	ch <- c.flagEvaluations
+	ch <- c.pollInterval
+	ch <- c.flagsFrozen
+	ch <- c.frozenUpdates
+	ch <- c.payloadErrors
+	ch <- c.eventsDropped
+	ch <- c.eventsDelivered
+	ch <- c.eventsSpilled
+	ch <- c.eventFlushRetries
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	snap := c.source.GetMetrics()
+
+	counter := func(desc *prometheus.Desc, value int64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value), labels...)
+	}
+	gauge := func(desc *prometheus.Desc, value float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+	}
+
+	// Requests
+	counter(c.requests, snap.SuccessfulRequests, "success")
+	counter(c.requests, snap.FailedRequests, "failure")
+	counter(c.requestErrors, snap.NetworkErrors, "network")
+	counter(c.requestErrors, snap.AuthErrors, "auth")
+	counter(c.requestErrors, snap.RateLimitErrors, "rate_limit")
+	counter(c.requestErrors, snap.ServerErrors, "server")
+	for kind, count := range snap.NetworkErrorsByKind {
+		counter(c.networkErrors, count, string(kind))
+	}
+	ch <- histogramMetric(c.requestDuration, snap.RequestLatencyHistogram)
+	counter(c.requestsThrottled, snap.ThrottledRequests)
+	counter(c.baseURLSwitches, snap.Failovers, "failover")
+	counter(c.baseURLSwitches, snap.FailBacks, "failback")
+
+	// Cache
+	counter(c.cacheHits, snap.CacheHits, "fresh")
+	counter(c.cacheHits, snap.CacheStaleHits, "stale")
+	counter(c.cacheMisses, snap.CacheMisses)
+
+	// Circuit breaker
+	for _, state := range []rollgate.CircuitState{rollgate.CircuitStateClosed, rollgate.CircuitStateOpen, rollgate.CircuitStateHalfOpen} {
+		var value float64
+		if snap.CircuitState == state {
+			value = 1
+		}
+		gauge(c.circuitState, value, string(state))
+	}
+	counter(c.circuitTransitions, snap.CircuitOpenCount, string(rollgate.CircuitStateOpen))
+	counter(c.circuitTransitions, snap.CircuitHalfOpenCount, string(rollgate.CircuitStateHalfOpen))
+
+	// Evaluations
+	counter(c.evaluations, snap.TotalEvaluations)
+	ch <- histogramMetric(c.evaluationDuration, snap.EvaluationTimeHistogram)
+	for flag, count := range snap.FlagEvaluations {
+		counter(c.flagEvaluations, count, flag)
+	}
+
+	// Polling, freezes, payloads and events
+	gauge(c.pollInterval, float64(snap.PollIntervalMs)/1000)
+	var frozen float64
+	if snap.FlagsFrozen {
+		frozen = 1
+	}
+	gauge(c.flagsFrozen, frozen)
+	counter(c.frozenUpdates, snap.FrozenUpdates)
+	counter(c.payloadErrors, snap.PayloadErrors)
+	counter(c.eventsDropped, snap.RejectedEvents, "invalid")
+	counter(c.eventsDropped, snap.DedupedEvents, "duplicate")
+	counter(c.eventsDropped, snap.DroppedEvents, "overflow")
+	counter(c.eventsDelivered, snap.DeliveredEvents)
+	counter(c.eventsSpilled, snap.SpilledEvents)
+	counter(c.eventFlushRetries, snap.EventFlushRetries)
+}
+
+// histogramMetric converts a millisecond histogram to seconds, the
+// Prometheus base unit.
+func histogramMetric(desc *prometheus.Desc, h rollgate.Histogram) prometheus.Metric {
+	buckets := make(map[float64]uint64, len(h.Buckets))
+	for bound, count := range h.Buckets {
+		buckets[bound/1000] = count
+	}
+	return prometheus.MustNewConstHistogram(desc, h.Count, h.Sum/1000, buckets)
+}
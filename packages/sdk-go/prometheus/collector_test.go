@@ -0,0 +1,109 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	rollgate "github.com/rollgate/sdks/packages/sdk-go"
+)
+
+var _ MetricsSource = (*rollgate.Client)(nil)
+
+func TestCollector(t *testing.T) {
+	m := rollgate.NewSDKMetrics()
+	m.EnableFlagMetrics(rollgate.FlagMetricsConfig{Enabled: true})
+	m.RecordRequest(20, true, rollgate.ErrorCategoryNone)
+	m.RecordRequest(300, false, rollgate.ErrorCategoryServer)
+	m.RecordNetworkError(rollgate.NetworkErrorTLS)
+	m.RecordCacheHit(false)
+	m.RecordCacheHit(true)
+	m.RecordCacheMiss()
+	m.RecordCircuitStateChange(rollgate.CircuitStateOpen)
+	m.RecordEvaluation(2000)
+	m.RecordFlagEvaluation("checkout")
+	m.RecordFlagEvaluation("checkout")
+
+	collector := NewCollector(MetricsSourceFunc(m.Snapshot), "rollgate")
+
+	t.Run("should pass the registry's consistency checks", func(t *testing.T) {
+		registry := prometheus.NewPedanticRegistry()
+		if err := registry.Register(collector); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+		if _, err := registry.Gather(); err != nil {
+			t.Fatalf("Gather failed: %v", err)
+		}
+	})
+
+	t.Run("should report counters by label", func(t *testing.T) {
+		expected := `
+# HELP rollgate_requests_total Requests to the Rollgate API by outcome.
+# TYPE rollgate_requests_total counter
+rollgate_requests_total{outcome="failure"} 1
+rollgate_requests_total{outcome="success"} 1
+# HELP rollgate_network_errors_total Network errors by kind: dns, tls, connect_timeout, read_timeout, connection or other.
+# TYPE rollgate_network_errors_total counter
+rollgate_network_errors_total{kind="connect_timeout"} 0
+rollgate_network_errors_total{kind="connection"} 0
+rollgate_network_errors_total{kind="dns"} 0
+rollgate_network_errors_total{kind="other"} 0
+rollgate_network_errors_total{kind="read_timeout"} 0
+rollgate_network_errors_total{kind="tls"} 1
+# HELP rollgate_cache_hits_total Flag cache hits by freshness.
+# TYPE rollgate_cache_hits_total counter
+rollgate_cache_hits_total{state="fresh"} 1
+rollgate_cache_hits_total{state="stale"} 1
+# HELP rollgate_circuit_state Circuit breaker state (1 for the current state).
+# TYPE rollgate_circuit_state gauge
+rollgate_circuit_state{state="closed"} 0
+rollgate_circuit_state{state="half_open"} 0
+rollgate_circuit_state{state="open"} 1
+# HELP rollgate_flag_evaluations_total Flag evaluations per flag key (keys over the label limit are counted as other).
+# TYPE rollgate_flag_evaluations_total counter
+rollgate_flag_evaluations_total{flag="checkout"} 2
+`
+		err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+			"rollgate_requests_total", "rollgate_network_errors_total", "rollgate_cache_hits_total", "rollgate_circuit_state", "rollgate_flag_evaluations_total")
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("should report latencies as histograms in seconds", func(t *testing.T) {
+		expected := `
+# HELP rollgate_request_duration_seconds Latency of requests to the Rollgate API.
+# TYPE rollgate_request_duration_seconds histogram
+rollgate_request_duration_seconds_bucket{le="0.005"} 0
+rollgate_request_duration_seconds_bucket{le="0.01"} 0
+rollgate_request_duration_seconds_bucket{le="0.025"} 1
+rollgate_request_duration_seconds_bucket{le="0.05"} 1
+rollgate_request_duration_seconds_bucket{le="0.1"} 1
+rollgate_request_duration_seconds_bucket{le="0.25"} 1
+rollgate_request_duration_seconds_bucket{le="0.5"} 2
+rollgate_request_duration_seconds_bucket{le="1"} 2
+rollgate_request_duration_seconds_bucket{le="2.5"} 2
+rollgate_request_duration_seconds_bucket{le="5"} 2
+rollgate_request_duration_seconds_bucket{le="10"} 2
+rollgate_request_duration_seconds_bucket{le="+Inf"} 2
+rollgate_request_duration_seconds_sum 0.32
+rollgate_request_duration_seconds_count 2
+`
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rollgate_request_duration_seconds"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("should follow the metrics between scrapes", func(t *testing.T) {
+		m.RecordEvaluation(1000)
+		expected := `
+# HELP rollgate_evaluations_total Flag evaluations.
+# TYPE rollgate_evaluations_total counter
+rollgate_evaluations_total 2
+`
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "rollgate_evaluations_total"); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package rollgate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting the rate of outbound API requests.
+// A nil RateLimiter allows every request.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a new RateLimiter with the given config.
+// Returns nil if RequestsPerSecond is not positive (rate limiting disabled).
+func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	if config.RequestsPerSecond <= 0 {
+		return nil
+	}
+	burst := config.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   config.RequestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow reports whether a request may be sent now, consuming a token if so.
+func (l *RateLimiter) Allow() bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	if l.tokens >= 1 {
+		l.tokens--
+		return true
+	}
+	return false
+}
+
+// Wait blocks until a request may be sent or the context is done.
+// If the context deadline would expire before a token is available,
+// it returns ErrClientRateLimited without waiting.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	_, err := l.wait(ctx)
+	return err
+}
+
+// wait is like Wait but also reports whether the caller had to be delayed.
+func (l *RateLimiter) wait(ctx context.Context) (bool, error) {
+	if l == nil {
+		return false, nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+	if l.tokens >= 1 {
+		l.tokens--
+		l.mu.Unlock()
+		return false, nil
+	}
+
+	delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(delay)) {
+		l.mu.Unlock()
+		return true, ErrClientRateLimited
+	}
+	// Reserve the token now so concurrent waiters queue up behind us
+	l.tokens--
+	l.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return true, ctx.Err()
+	case <-timer.C:
+		return true, nil
+	}
+}
+
+// refill adds the tokens accumulated since the last call. Caller must hold mu.
+func (l *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	if elapsed <= 0 {
+		return
+	}
+	l.tokens += elapsed * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// rateLimitedTransport applies a RateLimiter to every request made
+// through the wrapped RoundTripper.
+type rateLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *RateLimiter
+	metrics *SDKMetrics
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	throttled, err := t.limiter.wait(req.Context())
+	if throttled && t.metrics != nil {
+		t.metrics.RecordThrottledRequest()
+	}
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// requestError converts a failed round trip into a NetworkError. The SDK's
+// own throttling is returned as ErrClientRateLimited instead: the request
+// never left the process, so it is not a network failure and must not count
+// against the circuit breaker.
+func requestError(err error) error {
+	if errors.Is(err, ErrClientRateLimited) {
+		return ErrClientRateLimited
+	}
+	return NewNetworkError("request failed", err)
+}
@@ -0,0 +1,120 @@
+package rollgate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{RequestsPerSecond: 0, Burst: 1})
+	if limiter != nil {
+		t.Fatal("expected nil limiter when RequestsPerSecond is 0")
+	}
+
+	for i := 0; i < 100; i++ {
+		if !limiter.Allow() {
+			t.Fatal("expected nil limiter to allow every request")
+		}
+	}
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Errorf("expected no error from nil limiter, got %v", err)
+	}
+}
+
+func TestRateLimiter_Burst(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{RequestsPerSecond: 1, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow() {
+			t.Fatalf("expected request %d within burst to be allowed", i+1)
+		}
+	}
+	if limiter.Allow() {
+		t.Error("expected request beyond burst to be rejected")
+	}
+}
+
+func TestRateLimiter_WaitDelays(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{RequestsPerSecond: 20, Burst: 1})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+	}
+	elapsed := time.Since(start)
+
+	// First request uses the burst token, the next two wait ~50ms each
+	if elapsed < 80*time.Millisecond {
+		t.Errorf("expected Wait to throttle to ~100ms, took %v", elapsed)
+	}
+}
+
+func TestRateLimiter_WaitRespectsDeadline(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{RequestsPerSecond: 0.1, Burst: 1})
+	limiter.Allow()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := limiter.Wait(ctx)
+	if !errors.Is(err, ErrClientRateLimited) {
+		t.Errorf("expected ErrClientRateLimited, got %v", err)
+	}
+	if time.Since(start) > 5*time.Millisecond {
+		t.Error("expected Wait to fail fast when the deadline cannot be met")
+	}
+}
+
+func TestClient_RateLimitSharedAcrossRequests(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"flags":{}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		RateLimit:       RateLimitConfig{RequestsPerSecond: 1, Burst: 2},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// Identify sends both an identify and a refresh request, exhausting the burst
+	if err := client.Identify(ctx, &UserContext{ID: "user-1"}); err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+	err = client.Refresh(ctx)
+	if err != ErrClientRateLimited {
+		t.Errorf("expected unwrapped ErrClientRateLimited, got %v", err)
+	}
+	if got := ClassifyError(err).Category; got != ErrorCategoryThrottled {
+		t.Errorf("expected category %q, got %q", ErrorCategoryThrottled, got)
+	}
+	// Client-side throttling must not count against the circuit breaker
+	if failures := client.circuitBreaker.GetStats().Failures; failures != 0 {
+		t.Errorf("expected no circuit breaker failures, got %d", failures)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected 2 requests to reach the server, got %d", got)
+	}
+	if client.GetMetrics().ThrottledRequests == 0 {
+		t.Error("expected throttled requests to be recorded")
+	}
+}
@@ -0,0 +1,117 @@
+package rollgate
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultRetryAfter is assumed when a 429 response has no usable
+// Retry-After header.
+const defaultRetryAfter = 60 * time.Second
+
+// RateLimitState describes the server's rate limiting of the client, as
+// learned from 429 responses.
+type RateLimitState struct {
+	Limited     bool          // The latest limit has not yet ended
+	Until       time.Time     // When the latest limit ends; zero if never limited
+	RetryAfter  time.Duration // The latest 429's Retry-After
+	Endpoint    string        // Path of the latest rate-limited request
+	LastLimited time.Time     // When the latest 429 was received
+	Count       int64         // 429 responses received
+}
+
+// rateLimitTracker records the 429 responses of every API the client calls.
+type rateLimitTracker struct {
+	mu    sync.Mutex
+	state RateLimitState // Limited is computed on read
+}
+
+func newRateLimitTracker() *rateLimitTracker {
+	return &rateLimitTracker{}
+}
+
+// record notes a 429 response, returning how long the server asked the
+// client to wait.
+func (t *rateLimitTracker) record(resp *http.Response, now time.Time) time.Duration {
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), now)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state.Until = now.Add(retryAfter)
+	t.state.RetryAfter = retryAfter
+	t.state.Endpoint = resp.Request.URL.Path
+	t.state.LastLimited = now
+	t.state.Count++
+	return retryAfter
+}
+
+// lift ends the limit early on a successful response: the server is
+// answering again, whatever the Retry-After said.
+func (t *rateLimitTracker) lift(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.state.Until.After(now) {
+		t.state.Until = now
+	}
+}
+
+// get returns the state as of now.
+func (t *rateLimitTracker) get(now time.Time) RateLimitState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state := t.state
+	state.Limited = state.Until.After(now)
+	return state
+}
+
+// limited reports whether the latest limit has not yet ended.
+func (t *rateLimitTracker) limited(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.state.Until.After(now)
+}
+
+// parseRetryAfter parses a Retry-After header in either of its forms,
+// delay-seconds or an HTTP date, falling back to defaultRetryAfter.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return defaultRetryAfter
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return defaultRetryAfter
+}
+
+// retryAfterSeconds rounds d up to the whole seconds of
+// RateLimitError.RetryAfter.
+func retryAfterSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// RateLimitState returns the server's rate limiting of the client, so that
+// applications can degrade gracefully while it lasts. Any 429 response
+// (flags, events or telemetry) starts a limit; a successful flags request
+// ends it early.
+func (c *Client) RateLimitState() RateLimitState {
+	return c.rateLimits.get(time.Now())
+}
+
+// rateLimited reports whether the server is currently rate limiting the
+// client.
+func (c *Client) rateLimited() bool {
+	return c.rateLimits.limited(time.Now())
+}
+
+// notReadyKind is the error kind of evaluations before the first flags:
+// RATE_LIMITED if the server is refusing the client's requests.
+func (c *Client) notReadyKind() EvaluationErrorKind {
+	if c.rateLimited() {
+		return ErrorRateLimited
+	}
+	return ErrorClientNotReady
+}
@@ -0,0 +1,138 @@
+package rollgate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"30", 30 * time.Second},
+		{"0", 0},
+		{"-5", 0},
+		{"Sun, 01 Mar 2026 12:02:00 GMT", 2 * time.Minute},
+		{"Sun, 01 Mar 2026 11:00:00 GMT", 0},
+		{"", defaultRetryAfter},
+		{"soon", defaultRetryAfter},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestClient_RateLimitState(t *testing.T) {
+	var limited atomic.Bool
+	limited.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/sdk/events":
+			w.Header().Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+			w.WriteHeader(http.StatusTooManyRequests)
+		case limited.Load():
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case r.URL.Path == "/api/v1/sdk/flags":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"flags":{"beta":true}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		Retry:           RetryConfig{MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		CircuitBreaker:  CircuitBreakerConfig{FailureThreshold: 100},
+		Events:          EventCollectorConfig{Enabled: true, FlushIntervalMs: 60000, MaxBufferSize: 100},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	t.Run("should report a limit from a 429 response", func(t *testing.T) {
+		if client.RateLimitState().Limited {
+			t.Fatal("expected no limit before any request")
+		}
+		var rateLimitErr *RateLimitError
+		if err := client.Init(ctx); !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter != 120 {
+			t.Fatalf("expected a RateLimitError with RetryAfter 120, got %v", err)
+		}
+		state := client.RateLimitState()
+		if !state.Limited || state.RetryAfter != 2*time.Minute || state.Endpoint != "/api/v1/sdk/flags" {
+			t.Errorf("expected a 2 minute limit on the flags endpoint, got %+v", state)
+		}
+		if until := time.Until(state.Until); until < time.Minute || until > 2*time.Minute {
+			t.Errorf("expected the limit to end in about 2 minutes, got %v", until)
+		}
+		if state.Count < 1 {
+			t.Errorf("expected the 429s to be counted, got %d", state.Count)
+		}
+	})
+
+	t.Run("should evaluate without flags as RATE_LIMITED", func(t *testing.T) {
+		detail := client.IsEnabledDetail("beta", false)
+		if detail.Value || detail.Reason.ErrorKind != ErrorRateLimited || !detail.Reason.RateLimited {
+			t.Errorf("expected the default with RATE_LIMITED, got %+v", detail)
+		}
+		if detail := client.GetStringDetail("theme", "light"); detail.Reason.ErrorKind != ErrorRateLimited {
+			t.Errorf("expected typed flags to report RATE_LIMITED, got %+v", detail)
+		}
+	})
+
+	t.Run("should end the limit on a successful request", func(t *testing.T) {
+		limited.Store(false)
+		if err := client.Init(ctx); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		state := client.RateLimitState()
+		if state.Limited || time.Until(state.Until) > 0 {
+			t.Errorf("expected the limit to be lifted, got %+v", state)
+		}
+		if detail := client.IsEnabledDetail("beta", false); !detail.Value || detail.Reason.RateLimited {
+			t.Errorf("expected a fresh value, got %+v", detail)
+		}
+	})
+
+	t.Run("should mark values served while limited", func(t *testing.T) {
+		limited.Store(true)
+		client.Refresh(ctx)
+		detail := client.IsEnabledDetail("beta", false)
+		if !detail.Value || !detail.Reason.RateLimited || detail.Reason.Kind == ReasonError {
+			t.Errorf("expected the last known value marked as rate limited, got %+v", detail)
+		}
+		if !client.GetAllFlagsDetail()["beta"].Reason.RateLimited {
+			t.Error("expected GetAllFlagsDetail to mark the value too")
+		}
+		limited.Store(false)
+		client.Refresh(ctx)
+	})
+
+	t.Run("should report limits from event flushes", func(t *testing.T) {
+		client.Track(TrackEventOptions{FlagKey: "beta", EventName: "purchase", UserID: "user-1"})
+		var rateLimitErr *RateLimitError
+		if err := client.FlushEvents(ctx); !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter < 3590 {
+			t.Fatalf("expected a RateLimitError with the date's delay, got %v", err)
+		}
+		state := client.RateLimitState()
+		if !state.Limited || state.Endpoint != "/api/v1/sdk/events" {
+			t.Errorf("expected a limit on the events endpoint, got %+v", state)
+		}
+	})
+}
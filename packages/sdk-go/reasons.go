@@ -1,8 +1,15 @@
 package rollgate
 
+import "time"
+
 // EvaluationReasonKind represents the category of reason for a flag evaluation.
+// The set of kinds is stable: new kinds are only added, and ReasonKinds lists
+// them all for exhaustive switches.
 type EvaluationReasonKind string
 
+// ReasonKind is a shorter name for EvaluationReasonKind.
+type ReasonKind = EvaluationReasonKind
+
 const (
 	// ReasonOff indicates the flag is disabled.
 	ReasonOff EvaluationReasonKind = "OFF"
@@ -12,12 +19,43 @@ const (
 	ReasonRuleMatch EvaluationReasonKind = "RULE_MATCH"
 	// ReasonFallthrough indicates no rules matched, using default rollout.
 	ReasonFallthrough EvaluationReasonKind = "FALLTHROUGH"
+	// ReasonPrerequisiteFailed indicates a prerequisite flag did not return
+	// the required variation.
+	ReasonPrerequisiteFailed EvaluationReasonKind = "PREREQUISITE_FAILED"
 	// ReasonError indicates an error occurred during evaluation.
 	ReasonError EvaluationReasonKind = "ERROR"
 	// ReasonUnknown indicates the flag was not found or reason is unknown.
 	ReasonUnknown EvaluationReasonKind = "UNKNOWN"
+	// ReasonBootstrap indicates the value came from Config.Bootstrap or
+	// Config.BootstrapValues because no flags had been fetched yet.
+	ReasonBootstrap EvaluationReasonKind = "BOOTSTRAP"
 )
 
+// ReasonKinds returns every reason kind, in declaration order.
+func ReasonKinds() []EvaluationReasonKind {
+	return []EvaluationReasonKind{
+		ReasonOff,
+		ReasonTargetMatch,
+		ReasonRuleMatch,
+		ReasonFallthrough,
+		ReasonPrerequisiteFailed,
+		ReasonError,
+		ReasonUnknown,
+		ReasonBootstrap,
+	}
+}
+
+// IsValid reports whether k is one of the kinds returned by ReasonKinds.
+// Reasons decoded from a newer server may carry kinds this SDK predates.
+func (k EvaluationReasonKind) IsValid() bool {
+	for _, kind := range ReasonKinds() {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
 // EvaluationErrorKind represents types of errors during evaluation.
 type EvaluationErrorKind string
 
@@ -30,6 +68,15 @@ const (
 	ErrorUserNotSpecified EvaluationErrorKind = "USER_NOT_SPECIFIED"
 	// ErrorClientNotReady indicates the SDK client is not initialized.
 	ErrorClientNotReady EvaluationErrorKind = "CLIENT_NOT_READY"
+	// ErrorMalformedResponse indicates the latest flags response could not be
+	// parsed, so the flag may exist but is missing from the cached flags.
+	ErrorMalformedResponse EvaluationErrorKind = "MALFORMED_RESPONSE"
+	// ErrorWrongType indicates the flag's type does not match the
+	// evaluation method, such as GetString on a number flag.
+	ErrorWrongType EvaluationErrorKind = "WRONG_TYPE"
+	// ErrorRateLimited indicates the server was rate limiting the client,
+	// so no flags were available; see Client.RateLimitState.
+	ErrorRateLimited EvaluationErrorKind = "RATE_LIMITED"
 	// ErrorException indicates an unexpected error occurred.
 	ErrorException EvaluationErrorKind = "EXCEPTION"
 )
@@ -44,8 +91,22 @@ type EvaluationReason struct {
 	RuleIndex int `json:"ruleIndex,omitempty"`
 	// InRollout indicates whether the user was included in the rollout percentage.
 	InRollout bool `json:"inRollout,omitempty"`
+	// PrerequisiteKey is the key of the failed prerequisite flag (for PREREQUISITE_FAILED).
+	PrerequisiteKey string `json:"prerequisiteKey,omitempty"`
 	// ErrorKind is the specific error type if Kind is ERROR.
 	ErrorKind EvaluationErrorKind `json:"errorKind,omitempty"`
+	// VariationID is the variation the evaluation selected, for typed flags
+	// and weighted splits; EvaluationDetail.VariationID carries it too.
+	VariationID string `json:"variationId,omitempty"`
+	// Frozen indicates the value was served while Client.FreezeFlags held
+	// back flag updates.
+	Frozen bool `json:"frozen,omitempty"`
+	// Overridden indicates the value came from Config.OverridesFile rather
+	// than the server.
+	Overridden bool `json:"overridden,omitempty"`
+	// RateLimited indicates the value was served while the server was rate
+	// limiting the client, so it may be stale.
+	RateLimited bool `json:"rateLimited,omitempty"`
 }
 
 // EvaluationDetail contains the full result of a flag evaluation.
@@ -58,6 +119,12 @@ type EvaluationDetail[T any] struct {
 	VariationIndex int `json:"variationIndex,omitempty"`
 	// VariationID is the ID of the selected variation (for multi-variate flags).
 	VariationID string `json:"variationId,omitempty"`
+	// FlagVersion is the version of the flag's configuration the value was
+	// evaluated from, or 0 when the server sent none.
+	FlagVersion int `json:"flagVersion,omitempty"`
+	// FlagUpdatedAt is when that configuration last changed, or zero when
+	// the server sent none.
+	FlagUpdatedAt time.Time `json:"flagUpdatedAt"`
 }
 
 // BoolEvaluationDetail is an alias for EvaluationDetail[bool] for convenience.
@@ -93,6 +160,14 @@ func FallthroughReason(inRollout bool) EvaluationReason {
 	}
 }
 
+// PrerequisiteFailedReason creates a reason for a failed prerequisite flag.
+func PrerequisiteFailedReason(prerequisiteKey string) EvaluationReason {
+	return EvaluationReason{
+		Kind:            ReasonPrerequisiteFailed,
+		PrerequisiteKey: prerequisiteKey,
+	}
+}
+
 // ErrorReason creates a reason for an error.
 func ErrorReason(errorKind EvaluationErrorKind) EvaluationReason {
 	return EvaluationReason{
@@ -105,3 +180,8 @@ func ErrorReason(errorKind EvaluationErrorKind) EvaluationReason {
 func UnknownReason() EvaluationReason {
 	return EvaluationReason{Kind: ReasonUnknown}
 }
+
+// BootstrapReason creates a reason for a bootstrap value.
+func BootstrapReason() EvaluationReason {
+	return EvaluationReason{Kind: ReasonBootstrap}
+}
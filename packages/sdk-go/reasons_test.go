@@ -0,0 +1,55 @@
+package rollgate
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestReasonKinds(t *testing.T) {
+	seen := make(map[EvaluationReasonKind]bool)
+	for _, kind := range ReasonKinds() {
+		if seen[kind] {
+			t.Errorf("duplicate kind %s", kind)
+		}
+		seen[kind] = true
+		if !kind.IsValid() {
+			t.Errorf("%s should be valid", kind)
+		}
+	}
+	if len(seen) != 8 {
+		t.Errorf("expected 8 kinds, got %d", len(seen))
+	}
+	if ReasonKind("NOT_A_KIND").IsValid() {
+		t.Error("unknown kinds should not be valid")
+	}
+}
+
+func TestReasonConstructors(t *testing.T) {
+	tests := []struct {
+		reason EvaluationReason
+		want   string
+	}{
+		{OffReason(), `{"kind":"OFF"}`},
+		{TargetMatchReason(), `{"kind":"TARGET_MATCH"}`},
+		{RuleMatchReason("rule-1", 2, true), `{"kind":"RULE_MATCH","ruleId":"rule-1","ruleIndex":2,"inRollout":true}`},
+		{FallthroughReason(true), `{"kind":"FALLTHROUGH","inRollout":true}`},
+		{PrerequisiteFailedReason("parent"), `{"kind":"PREREQUISITE_FAILED","prerequisiteKey":"parent"}`},
+		{ErrorReason(ErrorFlagNotFound), `{"kind":"ERROR","errorKind":"FLAG_NOT_FOUND"}`},
+		{UnknownReason(), `{"kind":"UNKNOWN"}`},
+		{BootstrapReason(), `{"kind":"BOOTSTRAP"}`},
+	}
+
+	for _, tt := range tests {
+		got, err := json.Marshal(tt.reason)
+		if err != nil {
+			t.Fatalf("marshal %s: %v", tt.reason.Kind, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.reason.Kind, got, tt.want)
+		}
+		var back EvaluationReason
+		if err := json.Unmarshal(got, &back); err != nil || back != tt.reason {
+			t.Errorf("%s: round trip got %+v (err %v)", tt.reason.Kind, back, err)
+		}
+	}
+}
@@ -17,11 +17,12 @@ type RetryResult struct {
 // Retryer handles retry logic with exponential backoff.
 type Retryer struct {
 	config RetryConfig
+	timers timeSource // Waits out the delays; the system clock unless in test mode
 }
 
 // NewRetryer creates a new Retryer with the given config.
 func NewRetryer(config RetryConfig) *Retryer {
-	return &Retryer{config: config}
+	return &Retryer{config: config, timers: systemTime{}}
 }
 
 // Do executes the function with retry logic.
@@ -60,15 +61,12 @@ func (r *Retryer) Do(ctx context.Context, fn func() error) RetryResult {
 		delay := r.calculateBackoff(attempts - 1)
 
 		// Wait with context cancellation support
-		select {
-		case <-ctx.Done():
+		if !sleep(ctx, r.timers, delay) {
 			return RetryResult{
 				Success:  false,
 				Attempts: attempts,
 				Error:    ctx.Err(),
 			}
-		case <-time.After(delay):
-			// Continue to next attempt
 		}
 	}
 
@@ -116,15 +114,12 @@ func (r *Retryer) DoWithResult(ctx context.Context, fn func() (any, error)) (any
 		delay := r.calculateBackoff(attempts - 1)
 
 		// Wait with context cancellation support
-		select {
-		case <-ctx.Done():
+		if !sleep(ctx, r.timers, delay) {
 			return nil, RetryResult{
 				Success:  false,
 				Attempts: attempts,
 				Error:    ctx.Err(),
 			}
-		case <-time.After(delay):
-			// Continue to next attempt
 		}
 	}
 
@@ -0,0 +1,76 @@
+package rollgate
+
+// ScopedClient evaluates flags for one user, so worker code can call
+// scoped.IsEnabled(key, def) without passing WithUser and WithAttributes on
+// every call. It is a small value bound to its Client: creating one per task
+// costs no allocation or request, and it is safe for concurrent use.
+//
+// Evaluations behave exactly like the Client's with the user's options: the
+// client's own user is left unchanged, and flags for other users are
+// fetched and cached as for WithUser.
+type ScopedClient struct {
+	client *Client
+	opts   evalOptions
+}
+
+// For returns a ScopedClient bound to user's ID and attributes, or to the
+// client's current user if user is nil. The attributes map is used as is,
+// and must not be modified while the ScopedClient is in use.
+func (c *Client) For(user *UserContext) ScopedClient {
+	s := ScopedClient{client: c}
+	if user != nil {
+		s.opts = evalOptions{userID: user.ID, attributes: user.Attributes}
+	}
+	return s
+}
+
+// UserID returns the ID of the user the ScopedClient is bound to, or ""
+// for the client's current user.
+func (s ScopedClient) UserID() string {
+	return s.opts.userID
+}
+
+// IsEnabled checks if a flag is enabled for the scoped user. Options
+// override the scoped user's for this evaluation.
+func (s ScopedClient) IsEnabled(flagKey string, defaultValue bool, opts ...EvalOption) bool {
+	return s.IsEnabledDetail(flagKey, defaultValue, opts...).Value
+}
+
+// IsEnabledDetail returns the flag value for the scoped user along with the
+// evaluation reason. Options override the scoped user's for this evaluation.
+func (s ScopedClient) IsEnabledDetail(flagKey string, defaultValue bool, opts ...EvalOption) BoolEvaluationDetail {
+	o := s.opts
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return s.client.evaluateBool(flagKey, defaultValue, &o)
+}
+
+// Track sends a conversion event, attributed to the scoped user if
+// opts.UserID is empty.
+func (s ScopedClient) Track(opts TrackEventOptions) {
+	s.client.Track(s.withUser(opts))
+}
+
+// TrackValidated is Client.TrackValidated with the scoped user filled in
+// like Track.
+func (s ScopedClient) TrackValidated(opts TrackEventOptions) error {
+	return s.client.TrackValidated(s.withUser(opts))
+}
+
+// withUser fills in the scoped user's ID as the event's user, or the
+// client's current user's when the ScopedClient is not bound to one.
+func (s ScopedClient) withUser(opts TrackEventOptions) TrackEventOptions {
+	if opts.UserID != "" {
+		return opts
+	}
+	opts.UserID = s.opts.userID
+	if opts.UserID == "" {
+		s.client.mu.RLock()
+		if s.client.user != nil {
+			opts.UserID = s.client.user.ID
+		}
+		s.client.mu.RUnlock()
+	}
+	return opts
+}
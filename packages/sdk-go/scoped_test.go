@@ -0,0 +1,111 @@
+package rollgate
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestClient_For(t *testing.T) {
+	m := newMockServer(t,
+		&mockFlag{Key: "beta", Enabled: true, TargetUsers: []string{"vip"}},
+		&mockFlag{Key: "pro-only", Enabled: true, Rules: []mockRule{{
+			ID:         "pro-plan",
+			Conditions: []mockCondition{{Attribute: "plan", Operator: "eq", Value: "pro"}},
+			Rollout:    100,
+		}}},
+	)
+	config := m.config()
+	config.User = &UserContext{ID: "user-1"}
+	config.Events = EventCollectorConfig{Enabled: true, FlushIntervalMs: 60000, MaxBufferSize: 100}
+	client := newIntegrationClient(t, config)
+
+	t.Run("should evaluate flags for the scoped user", func(t *testing.T) {
+		vip := client.For(&UserContext{ID: "vip"})
+		if detail := vip.IsEnabledDetail("beta", false); !detail.Value || detail.Reason != TargetMatchReason() {
+			t.Errorf("expected vip to be targeted, got %+v", detail)
+		}
+		pro := client.For(&UserContext{ID: "user-2", Attributes: map[string]any{"plan": "pro"}})
+		if !pro.IsEnabled("pro-only", false) {
+			t.Error("expected the scoped attributes to match the pro-plan rule")
+		}
+		if client.IsEnabled("beta", false) || client.IsEnabled("pro-only", false) {
+			t.Error("expected the client's user to keep its own values")
+		}
+	})
+
+	t.Run("should let options override the scoped user", func(t *testing.T) {
+		scoped := client.For(&UserContext{ID: "user-2"})
+		if !scoped.IsEnabled("beta", false, WithUser("vip")) {
+			t.Error("expected WithUser to override the scoped user")
+		}
+		if scoped.IsEnabled("beta", false) {
+			t.Error("expected the override not to change the scoped user")
+		}
+	})
+
+	t.Run("should use the client's user without one", func(t *testing.T) {
+		scoped := client.For(nil)
+		if scoped.UserID() != "" || scoped.IsEnabled("beta", false) {
+			t.Error("expected the client's current user")
+		}
+	})
+
+	t.Run("should attribute tracked events to the scoped user", func(t *testing.T) {
+		client.For(&UserContext{ID: "vip"}).Track(TrackEventOptions{FlagKey: "beta", EventName: "purchase"})
+		client.For(nil).Track(TrackEventOptions{FlagKey: "beta", EventName: "purchase"})
+		client.For(&UserContext{ID: "vip"}).Track(NewTrackEvent("beta", "purchase", "user-3"))
+
+		client.eventCollector.mu.Lock()
+		events := append([]bufferedEvent(nil), client.eventCollector.buffer...)
+		client.eventCollector.mu.Unlock()
+		if len(events) != 3 {
+			t.Fatalf("expected 3 events, got %d", len(events))
+		}
+		for i, want := range []string{"vip", "user-1", "user-3"} {
+			if events[i].UserID != want {
+				t.Errorf("expected event %d for %s, got %s", i, want, events[i].UserID)
+			}
+		}
+		if events[0].VariationID != "true" {
+			t.Errorf("expected the scoped evaluation's variation, got %q", events[0].VariationID)
+		}
+	})
+
+	t.Run("should be safe to create per task across goroutines", func(t *testing.T) {
+		var wg sync.WaitGroup
+		errs := make(chan error, 50)
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				user := &UserContext{ID: fmt.Sprintf("worker-%d", i%5), Attributes: map[string]any{"plan": "pro"}}
+				if i%2 == 0 {
+					user = &UserContext{ID: "vip"}
+				}
+				scoped := client.For(user)
+				if got := scoped.IsEnabled("beta", false); got != (i%2 == 0) {
+					errs <- fmt.Errorf("task %d: expected beta=%v", i, i%2 == 0)
+				}
+				if got := scoped.IsEnabled("pro-only", false); got != (i%2 != 0) {
+					errs <- fmt.Errorf("task %d: expected pro-only=%v", i, i%2 != 0)
+				}
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Error(err)
+		}
+	})
+
+	t.Run("should cost no allocation to create", func(t *testing.T) {
+		user := &UserContext{ID: "vip"}
+		allocs := testing.AllocsPerRun(100, func() {
+			_ = client.For(user)
+		})
+		if allocs != 0 {
+			t.Errorf("expected no allocations, got %v", allocs)
+		}
+	})
+}
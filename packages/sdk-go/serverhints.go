@@ -0,0 +1,127 @@
+package rollgate
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers the API may set on flags responses to tune SDK behavior across
+// the fleet. The same hints may be sent in the payload's "sdkConfig"
+// object; headers win when both are present.
+const (
+	// HeaderPollInterval suggests the polling interval, in seconds.
+	HeaderPollInterval = "X-Rollgate-Poll-Interval"
+	// HeaderStreaming is "false" when clients should poll rather than
+	// open the stream, and "true" when streaming is available.
+	HeaderStreaming = "X-Rollgate-Streaming"
+)
+
+// minHintedPollInterval is the shortest polling interval a server hint can
+// set, so a bad hint cannot make the fleet hammer the API.
+const minHintedPollInterval = time.Second
+
+// ServerHints is the SDK configuration the API suggested with the last
+// flags response. The client only acts on it with Config.HonorServerHints.
+type ServerHints struct {
+	// PollInterval is the suggested polling interval; zero if none was
+	// suggested
+	PollInterval time.Duration
+	// Streaming reports whether the API suggested streaming; nil if it
+	// did not say
+	Streaming *bool
+}
+
+// sdkConfigPayload is the "sdkConfig" object of a flags payload.
+type sdkConfigPayload struct {
+	PollIntervalSeconds float64 `json:"pollIntervalSeconds"`
+	Streaming           *bool   `json:"streaming"`
+}
+
+// parseServerHints reads the hints of a flags response from its headers,
+// falling back to the payload's sdkConfig object. Values that cannot be
+// parsed are ignored, and poll intervals are raised to
+// minHintedPollInterval.
+func parseServerHints(header http.Header, body *sdkConfigPayload) ServerHints {
+	var hints ServerHints
+	if body != nil {
+		hints.PollInterval = time.Duration(body.PollIntervalSeconds * float64(time.Second))
+		hints.Streaming = body.Streaming
+	}
+	if v := header.Get(HeaderPollInterval); v != "" {
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+			hints.PollInterval = time.Duration(seconds * float64(time.Second))
+		}
+	}
+	if v := header.Get(HeaderStreaming); v != "" {
+		if streaming, err := strconv.ParseBool(v); err == nil {
+			hints.Streaming = &streaming
+		}
+	}
+
+	switch {
+	case hints.PollInterval < 0:
+		hints.PollInterval = 0
+	case hints.PollInterval > 0 && hints.PollInterval < minHintedPollInterval:
+		hints.PollInterval = minHintedPollInterval
+	}
+	return hints
+}
+
+// ServerHints returns the SDK configuration the API suggested with the
+// last flags response, whether or not the client honors it.
+func (c *Client) ServerHints() ServerHints {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hints := c.hints
+	if hints.Streaming != nil {
+		streaming := *hints.Streaming
+		hints.Streaming = &streaming
+	}
+	return hints
+}
+
+// observeHints stores the hints of a flags response. A 304 carries no
+// payload, so it only replaces the hints its headers repeat.
+func (c *Client) observeHints(resp *http.Response, body *sdkConfigPayload) {
+	hints := parseServerHints(resp.Header, body)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if resp.StatusCode == http.StatusNotModified {
+		if hints.PollInterval == 0 {
+			hints.PollInterval = c.hints.PollInterval
+		}
+		if hints.Streaming == nil {
+			hints.Streaming = c.hints.Streaming
+		}
+	}
+	c.hints = hints
+}
+
+// pollInterval returns the base polling interval: the API's suggestion
+// with Config.HonorServerHints, otherwise RefreshInterval. Hints never
+// enable polling that RefreshInterval disables.
+func (c *Client) pollInterval() time.Duration {
+	if !c.config.HonorServerHints || c.config.RefreshInterval <= 0 {
+		return c.config.RefreshInterval
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.hints.PollInterval > 0 {
+		return c.hints.PollInterval
+	}
+	return c.config.RefreshInterval
+}
+
+// streamingDisabledByServer reports whether the client should poll instead
+// of opening the stream, because it honors server hints and the API said
+// streaming is unavailable.
+func (c *Client) streamingDisabledByServer() bool {
+	if !c.config.HonorServerHints {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hints.Streaming != nil && !*c.hints.Streaming
+}
@@ -0,0 +1,128 @@
+package rollgate
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseServerHints(t *testing.T) {
+	streamingOff := false
+	tests := []struct {
+		name      string
+		headers   map[string]string
+		body      *sdkConfigPayload
+		interval  time.Duration
+		streaming string
+	}{
+		{"none", nil, nil, 0, "unset"},
+		{"headers", map[string]string{HeaderPollInterval: "60", HeaderStreaming: "false"}, nil, time.Minute, "false"},
+		{"body", nil, &sdkConfigPayload{PollIntervalSeconds: 90, Streaming: &streamingOff}, 90 * time.Second, "false"},
+		{"header wins", map[string]string{HeaderPollInterval: "10", HeaderStreaming: "true"}, &sdkConfigPayload{PollIntervalSeconds: 90, Streaming: &streamingOff}, 10 * time.Second, "true"},
+		{"raised to the minimum", map[string]string{HeaderPollInterval: "0.1"}, nil, time.Second, "unset"},
+		{"invalid ignored", map[string]string{HeaderPollInterval: "soon", HeaderStreaming: "maybe"}, nil, 0, "unset"},
+		{"negative ignored", map[string]string{HeaderPollInterval: "-5"}, nil, 0, "unset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tt.headers {
+				header.Set(k, v)
+			}
+			hints := parseServerHints(header, tt.body)
+			if hints.PollInterval != tt.interval {
+				t.Errorf("expected interval %v, got %v", tt.interval, hints.PollInterval)
+			}
+			streaming := "unset"
+			if hints.Streaming != nil {
+				streaming = fmt.Sprint(*hints.Streaming)
+			}
+			if streaming != tt.streaming {
+				t.Errorf("expected streaming %s, got %s", tt.streaming, streaming)
+			}
+		})
+	}
+}
+
+// newHintsServer serves flags with the given hint headers and counts flags
+// and stream requests.
+func newHintsServer(headers map[string]string, flagsRequests, streamRequests *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			flagsRequests.Add(1)
+			for k, v := range headers {
+				w.Header().Set(k, v)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"flags":{"flag-a":true}}`)
+		case "/api/v1/sdk/stream":
+			streamRequests.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestClient_ServerHints(t *testing.T) {
+	t.Run("should poll at the hinted interval when honored", func(t *testing.T) {
+		var flagsRequests, streamRequests atomic.Int32
+		server := newHintsServer(map[string]string{HeaderPollInterval: "1"}, &flagsRequests, &streamRequests)
+		defer server.Close()
+
+		client := newIntegrationClient(t, Config{
+			APIKey:           "test-key",
+			BaseURL:          server.URL,
+			RefreshInterval:  time.Hour,
+			HonorServerHints: true,
+		})
+		if got := client.ServerHints().PollInterval; got != time.Second {
+			t.Errorf("expected a 1s hint, got %v", got)
+		}
+		time.Sleep(2500 * time.Millisecond)
+		if got := flagsRequests.Load(); got < 2 {
+			t.Errorf("expected polling every second, got %d requests", got)
+		}
+	})
+
+	t.Run("should ignore hints unless honored", func(t *testing.T) {
+		var flagsRequests, streamRequests atomic.Int32
+		server := newHintsServer(map[string]string{HeaderPollInterval: "1"}, &flagsRequests, &streamRequests)
+		defer server.Close()
+
+		client := newIntegrationClient(t, Config{APIKey: "test-key", BaseURL: server.URL, RefreshInterval: time.Hour})
+		time.Sleep(1500 * time.Millisecond)
+		if got := flagsRequests.Load(); got != 1 {
+			t.Errorf("expected only the initial request, got %d", got)
+		}
+		if got := client.ServerHints().PollInterval; got != time.Second {
+			t.Errorf("expected the hint to be reported anyway, got %v", got)
+		}
+	})
+
+	t.Run("should poll instead of streaming when told to", func(t *testing.T) {
+		var flagsRequests, streamRequests atomic.Int32
+		server := newHintsServer(map[string]string{HeaderStreaming: "false"}, &flagsRequests, &streamRequests)
+		defer server.Close()
+
+		client := newIntegrationClient(t, Config{
+			APIKey:           "test-key",
+			BaseURL:          server.URL,
+			RefreshInterval:  time.Hour,
+			EnableStreaming:  true,
+			HonorServerHints: true,
+		})
+		if mode := client.GetConnectionMode(); mode != ConnectionPolling {
+			t.Errorf("expected polling, got %s", mode)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if got := streamRequests.Load(); got != 0 {
+			t.Errorf("expected no stream connection, got %d", got)
+		}
+	})
+}
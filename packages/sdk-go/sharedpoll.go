@@ -0,0 +1,187 @@
+package rollgate
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sharedEntryTTL is how long an idle entry is kept after its response was
+// last confirmed by the server.
+const sharedEntryTTL = 10 * time.Minute
+
+// sharedFetches coalesces the flags requests of every client in the
+// process that has Config.SharePolling set.
+var sharedFetches = newFetchBroker()
+
+// pollContextKey marks the context of a background poll, which may be
+// answered from a response another client received recently.
+type pollContextKey struct{}
+
+func withPoll(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pollContextKey{}, true)
+}
+
+func isPoll(ctx context.Context) bool {
+	poll, _ := ctx.Value(pollContextKey{}).(bool)
+	return poll
+}
+
+// fetchBroker shares flags responses between clients that send identical
+// requests: same URL, credentials, user and headers. It keeps the last 200
+// response per request and revalidates it with its own validators, so each
+// client still sees a 200 or a 304 matching the validators it sent.
+type fetchBroker struct {
+	mu      sync.Mutex
+	entries map[string]*fetchEntry
+}
+
+// fetchEntry is the last response for one request key.
+type fetchEntry struct {
+	inflight chan struct{} // Closed when the request in flight completes; nil when idle
+	fetched  time.Time     // When the server last returned or confirmed body
+	header   http.Header
+	body     []byte // nil until a 200 was received
+}
+
+func newFetchBroker() *fetchBroker {
+	return &fetchBroker{entries: make(map[string]*fetchEntry)}
+}
+
+// do sends req with client, or answers it from the response of an
+// identical request that is in flight, or that was received less than
+// maxAge ago. Errors and responses other than 200 and 304 are not shared.
+func (b *fetchBroker) do(client *http.Client, req *http.Request, maxAge time.Duration) (*http.Response, error) {
+	key := fetchKey(req)
+	start := time.Now()
+
+	b.mu.Lock()
+	e := b.entry(key)
+	for {
+		if e.body != nil && (e.fetched.After(start) || time.Since(e.fetched) < maxAge) {
+			resp := e.response(req, "")
+			b.mu.Unlock()
+			return resp, nil
+		}
+		if e.inflight == nil {
+			break
+		}
+		wait := e.inflight
+		b.mu.Unlock()
+		select {
+		case <-wait:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		b.mu.Lock()
+	}
+
+	done := make(chan struct{})
+	e.inflight = done
+	out := req.Clone(req.Context())
+	out.Header.Del("If-None-Match")
+	out.Header.Del("If-Modified-Since")
+	if e.body != nil {
+		if etag := e.header.Get("ETag"); etag != "" {
+			out.Header.Set("If-None-Match", etag)
+		} else if lastModified := e.header.Get("Last-Modified"); lastModified != "" {
+			out.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+	b.mu.Unlock()
+
+	resp, err := client.Do(out)
+	var body []byte
+	if err == nil && resp.StatusCode == http.StatusOK {
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer close(done)
+	e.inflight = nil
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		e.header, e.body, e.fetched = resp.Header.Clone(), body, time.Now()
+	case resp.StatusCode == http.StatusNotModified && e.body != nil:
+		resp.Body.Close()
+		e.fetched = time.Now()
+	default:
+		return resp, nil
+	}
+	return e.response(req, resp.Header.Get("Date")), nil
+}
+
+// entry returns the entry for key, creating it and dropping entries that
+// have been idle for sharedEntryTTL. Caller must hold b.mu.
+func (b *fetchBroker) entry(key string) *fetchEntry {
+	if e, ok := b.entries[key]; ok {
+		return e
+	}
+	for k, e := range b.entries {
+		if e.inflight == nil && time.Since(e.fetched) > sharedEntryTTL {
+			delete(b.entries, k)
+		}
+	}
+	e := &fetchEntry{}
+	b.entries[key] = e
+	return e
+}
+
+// response answers req from the entry: 304 when req carries the entry's
+// validator, the stored body otherwise. The Date header is only set when
+// the server has just answered, so cached responses do not skew the
+// client's estimate of the server clock. Caller must hold the broker lock.
+func (e *fetchEntry) response(req *http.Request, date string) *http.Response {
+	header := e.header.Clone()
+	header.Del("Date")
+	if date != "" {
+		header.Set("Date", date)
+	}
+
+	status, body := http.StatusOK, e.body
+	etag, lastModified := e.header.Get("ETag"), e.header.Get("Last-Modified")
+	if (etag != "" && req.Header.Get("If-None-Match") == etag) ||
+		(etag == "" && lastModified != "" && req.Header.Get("If-Modified-Since") == lastModified) {
+		status, body = http.StatusNotModified, nil
+	}
+	return &http.Response{
+		Status:        http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// fetchKey identifies requests that get the same response: the URL and
+// every header except the validators.
+func fetchKey(req *http.Request) string {
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		if name != "If-None-Match" && name != "If-Modified-Since" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var key strings.Builder
+	key.WriteString(req.Method + " " + req.URL.String())
+	for _, name := range names {
+		key.WriteString("\n" + name + ": " + strings.Join(req.Header[name], ", "))
+	}
+	return key.String()
+}
@@ -0,0 +1,150 @@
+package rollgate
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newValidatorServer serves a fixed body with an ETag, answering matching
+// If-None-Match requests with 304. release, if not nil, holds every
+// response until it is closed.
+func newValidatorServer(t *testing.T, release chan struct{}) (*httptest.Server, *atomic.Int32, *atomic.Int32) {
+	t.Helper()
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if release != nil {
+			<-release
+		}
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`{"flags":{"beta":true}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests, &notModified
+}
+
+func TestFetchBroker(t *testing.T) {
+	get := func(t *testing.T, b *fetchBroker, url, token, etag string, maxAge time.Duration) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		resp, err := b.do(http.DefaultClient, req, maxAge)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	t.Run("should reuse a recent response", func(t *testing.T) {
+		server, requests, _ := newValidatorServer(t, nil)
+		b := newFetchBroker()
+		get(t, b, server.URL, "key", "", time.Hour)
+		status, body := get(t, b, server.URL, "key", "", time.Hour)
+		if status != http.StatusOK || body != `{"flags":{"beta":true}}` {
+			t.Errorf("expected the shared body, got %d %q", status, body)
+		}
+		if status, _ := get(t, b, server.URL, "key", `"v1"`, time.Hour); status != http.StatusNotModified {
+			t.Errorf("expected 304 for a matching validator, got %d", status)
+		}
+		if n := requests.Load(); n != 1 {
+			t.Errorf("expected 1 request, got %d", n)
+		}
+	})
+
+	t.Run("should revalidate with its own validator once the response is too old", func(t *testing.T) {
+		server, requests, notModified := newValidatorServer(t, nil)
+		b := newFetchBroker()
+		get(t, b, server.URL, "key", "", 0)
+		status, body := get(t, b, server.URL, "key", "", 0)
+		if status != http.StatusOK || body == "" {
+			t.Errorf("expected a client without a validator to get the body, got %d %q", status, body)
+		}
+		if requests.Load() != 2 || notModified.Load() != 1 {
+			t.Errorf("expected a conditional second request, got %d requests, %d not modified", requests.Load(), notModified.Load())
+		}
+	})
+
+	t.Run("should not share responses across credentials", func(t *testing.T) {
+		server, requests, _ := newValidatorServer(t, nil)
+		b := newFetchBroker()
+		get(t, b, server.URL, "key-1", "", time.Hour)
+		get(t, b, server.URL, "key-2", "", time.Hour)
+		if n := requests.Load(); n != 2 {
+			t.Errorf("expected 2 requests, got %d", n)
+		}
+	})
+
+	t.Run("should coalesce concurrent requests", func(t *testing.T) {
+		release := make(chan struct{})
+		server, requests, _ := newValidatorServer(t, release)
+		b := newFetchBroker()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if status, _ := get(t, b, server.URL, "key", "", 0); status != http.StatusOK {
+					t.Errorf("expected 200, got %d", status)
+				}
+			}()
+		}
+		for requests.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		if n := requests.Load(); n != 1 {
+			t.Errorf("expected 1 request, got %d", n)
+		}
+	})
+}
+
+func TestClient_SharePolling(t *testing.T) {
+	m := newMockServer(t, &mockFlag{Key: "beta", Enabled: true, Rollout: 100})
+	config := m.config()
+	config.SharePolling = true
+	config.User = &UserContext{ID: "user-1"}
+	first := newIntegrationClient(t, config)
+	second := newIntegrationClient(t, config)
+
+	before := m.requestCount("/api/v1/sdk/flags")
+	ctx := withPoll(context.Background())
+	if err := first.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if err := second.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if n := m.requestCount("/api/v1/sdk/flags") - before; n != 0 {
+		t.Errorf("expected polls to reuse the Init response, got %d requests", n)
+	}
+	if !second.IsEnabled("beta", false) {
+		t.Error("expected the shared flags to be applied")
+	}
+
+	// An explicit refresh always reaches the server
+	if err := second.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if n := m.requestCount("/api/v1/sdk/flags") - before; n != 1 {
+		t.Errorf("expected an explicit refresh to send 1 request, got %d", n)
+	}
+}
@@ -0,0 +1,217 @@
+package rollgate
+
+import (
+	"sort"
+	"time"
+)
+
+// FlagSnapshot is an immutable, point-in-time copy of the client's flag state.
+//
+// A snapshot is unaffected by later refreshes, streaming updates or Identify
+// calls, so a batch job evaluating many users sees one consistent set of
+// flags from start to finish. It is safe for concurrent use without locking,
+// and evaluations on it do not record metrics, telemetry or exposure events.
+type FlagSnapshot struct {
+	flags    map[string]bool
+	reasons  map[string]EvaluationReason
+	metadata map[string]FlagMetadata
+	defaults map[string]any
+	rules    map[string]FlagRule
+	// overridden are the flags set by Config.OverridesFile, which win
+	// over the rules for every user
+	overridden map[string]bool
+	version    string
+	userID     string
+	ready      bool
+	malformed  bool
+	createdAt  time.Time
+}
+
+// Snapshot returns an immutable copy of the current flags, reasons, flag
+// versions and registered defaults. Use WithRules to add targeting rules for
+// per-user evaluation; with local evaluation the snapshot already holds the
+// rules.
+func (c *Client) Snapshot() *FlagSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s := &FlagSnapshot{
+		flags:      make(map[string]bool, len(c.flags)),
+		reasons:    make(map[string]EvaluationReason, len(c.flagReasons)),
+		metadata:   make(map[string]FlagMetadata, len(c.flagMeta)),
+		defaults:   make(map[string]any, len(c.defaults)),
+		overridden: make(map[string]bool, len(c.localOverrides)),
+		ready:      c.ready,
+		malformed:  c.malformed,
+		createdAt:  time.Now(),
+	}
+	for k, v := range c.flags {
+		s.flags[k] = v
+	}
+	for k, v := range c.flagReasons {
+		s.reasons[k] = v
+	}
+	for k := range c.localOverrides {
+		if v, ok := c.overriddenFlag(k); ok {
+			s.flags[k] = v
+			s.reasons[k] = overrideReason()
+			s.overridden[k] = true
+		}
+	}
+	for k, v := range c.flagMeta {
+		s.metadata[k] = v
+	}
+	for k, v := range c.defaults {
+		s.defaults[k] = v
+	}
+	if c.user != nil {
+		s.userID = c.user.ID
+	}
+	if c.evaluator != nil {
+		return s.WithRules(RulesPayload{Version: c.evaluator.version, Flags: c.evaluator.rules})
+	}
+	return s
+}
+
+// CreatedAt returns when the snapshot was taken.
+func (s *FlagSnapshot) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+// UserID returns the ID of the user the client was identified as when the
+// snapshot was taken, or "" if none.
+func (s *FlagSnapshot) UserID() string {
+	return s.userID
+}
+
+// Version returns the version of the frozen targeting rules, or "" if the
+// snapshot holds no rules.
+func (s *FlagSnapshot) Version() string {
+	return s.version
+}
+
+// Keys returns the sorted keys of all flags in the snapshot.
+func (s *FlagSnapshot) Keys() []string {
+	keys := make([]string, 0, len(s.flags))
+	for k := range s.flags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetAllFlags returns a copy of all flag values in the snapshot.
+func (s *FlagSnapshot) GetAllFlags() map[string]bool {
+	result := make(map[string]bool, len(s.flags))
+	for k, v := range s.flags {
+		result[k] = v
+	}
+	return result
+}
+
+// IsEnabled returns the frozen value of a flag, or defaultValue if unknown.
+func (s *FlagSnapshot) IsEnabled(flagKey string, defaultValue bool) bool {
+	return s.IsEnabledDetail(flagKey, defaultValue).Value
+}
+
+// IsEnabledDetail returns the frozen value of a flag along with its reason.
+func (s *FlagSnapshot) IsEnabledDetail(flagKey string, defaultValue bool) BoolEvaluationDetail {
+	defaultValue = s.boolDefault(flagKey, defaultValue)
+
+	if !s.ready {
+		return BoolEvaluationDetail{
+			Value:  defaultValue,
+			Reason: ErrorReason(ErrorClientNotReady),
+		}
+	}
+
+	value, ok := s.flags[flagKey]
+	if !ok {
+		reason := UnknownReason()
+		if s.malformed {
+			reason = ErrorReason(ErrorMalformedResponse)
+		}
+		return BoolEvaluationDetail{
+			Value:  defaultValue,
+			Reason: reason,
+		}
+	}
+
+	detail := BoolEvaluationDetail{
+		Value:  value,
+		Reason: FallthroughReason(value),
+	}
+	if reason, ok := s.reasons[flagKey]; ok {
+		detail.Reason = reason
+	}
+	return withMetadata(detail, s.metadata[flagKey])
+}
+
+// IsEnabledFor evaluates a flag for an arbitrary user. When the snapshot holds
+// targeting rules for the flag they are evaluated locally, so results are
+// deterministic per user. Otherwise the frozen value is only returned for the
+// snapshot's UserID, since the server evaluated it for that user; any other
+// user gets defaultValue.
+func (s *FlagSnapshot) IsEnabledFor(flagKey string, user *UserContext, defaultValue bool) bool {
+	return s.IsEnabledDetailFor(flagKey, user, defaultValue).Value
+}
+
+// IsEnabledDetailFor is IsEnabledFor with the evaluation reason. A user the
+// snapshot has no value for gets defaultValue with an UNKNOWN reason.
+// Local overrides win over the rules for every user, as they do on the
+// client.
+func (s *FlagSnapshot) IsEnabledDetailFor(flagKey string, user *UserContext, defaultValue bool) BoolEvaluationDetail {
+	if s.overridden[flagKey] {
+		return BoolEvaluationDetail{Value: s.flags[flagKey], Reason: overrideReason()}
+	}
+	if rule, ok := s.rules[flagKey]; ok {
+		return withMetadata(evaluateFlagDetail(rule, user, s.rules, nil), s.metadata[flagKey])
+	}
+	if user == nil || user.ID != s.userID {
+		return BoolEvaluationDetail{
+			Value:  s.boolDefault(flagKey, defaultValue),
+			Reason: UnknownReason(),
+		}
+	}
+	return s.IsEnabledDetail(flagKey, defaultValue)
+}
+
+// WithRules returns a copy of the snapshot that holds the given targeting
+// rules, so IsEnabledFor evaluates users locally. The rules are deep-copied.
+func (s *FlagSnapshot) WithRules(payload RulesPayload) *FlagSnapshot {
+	clone := *s
+	clone.version = payload.Version
+	clone.rules = make(map[string]FlagRule, len(payload.Flags))
+	for k, rule := range payload.Flags {
+		clone.rules[k] = copyFlagRule(rule)
+	}
+	return &clone
+}
+
+// copyFlagRule deep-copies a flag rule so later changes by the caller
+// cannot leak into a snapshot.
+func copyFlagRule(rule FlagRule) FlagRule {
+	rule.TargetUsers = append([]string(nil), rule.TargetUsers...)
+	rule.Prerequisites = append([]Prerequisite(nil), rule.Prerequisites...)
+	rule.WeightedVariations = append([]WeightedVariation(nil), rule.WeightedVariations...)
+	rules := make([]TargetingRule, len(rule.Rules))
+	for i, r := range rule.Rules {
+		r.Conditions = append([]Condition(nil), r.Conditions...)
+		r.WeightedVariations = append([]WeightedVariation(nil), r.WeightedVariations...)
+		rules[i] = r
+	}
+	rule.Rules = rules
+	return rule
+}
+
+// boolDefault resolves the default for a boolean evaluation using the
+// defaults registered when the snapshot was taken.
+func (s *FlagSnapshot) boolDefault(flagKey string, defaultValue bool) bool {
+	if defaultValue {
+		return defaultValue
+	}
+	if b, ok := s.defaults[flagKey].(bool); ok {
+		return b
+	}
+	return defaultValue
+}
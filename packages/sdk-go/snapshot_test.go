@@ -0,0 +1,141 @@
+package rollgate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Snapshot(t *testing.T) {
+	var enabled atomic.Bool
+	enabled.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if enabled.Load() {
+			w.Write([]byte(`{"flags":{"feature":true},"reasons":{"feature":{"kind":"RULE_MATCH","ruleId":"r1"}}}`))
+		} else {
+			w.Write([]byte(`{"flags":{"feature":false}}`))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	t.Run("should report not ready before initialization", func(t *testing.T) {
+		detail := client.Snapshot().IsEnabledDetail("feature", false)
+		if detail.Reason.Kind != ReasonError || detail.Reason.ErrorKind != ErrorClientNotReady {
+			t.Errorf("expected CLIENT_NOT_READY, got %+v", detail.Reason)
+		}
+	})
+
+	ctx := context.Background()
+	if err := client.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	client.RegisterDefaults(map[string]any{"missing": true})
+
+	snap := client.Snapshot()
+
+	t.Run("should not change after a refresh", func(t *testing.T) {
+		enabled.Store(false)
+		if err := client.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+		if client.IsEnabled("feature", true) {
+			t.Fatal("expected live client to see the refreshed value")
+		}
+
+		detail := snap.IsEnabledDetail("feature", false)
+		if !detail.Value || detail.Reason.RuleID != "r1" {
+			t.Errorf("expected frozen value and reason, got %+v", detail)
+		}
+		if !snap.GetAllFlags()["feature"] {
+			t.Error("expected frozen flags from GetAllFlags")
+		}
+	})
+
+	t.Run("should use defaults registered at snapshot time", func(t *testing.T) {
+		if !snap.IsEnabled("missing", false) {
+			t.Error("expected registered default")
+		}
+	})
+
+	t.Run("should evaluate users locally with rules", func(t *testing.T) {
+		rules := RulesPayload{
+			Version: "v7",
+			Flags: map[string]FlagRule{
+				"feature": {Key: "feature", Enabled: true, TargetUsers: []string{"vip"}},
+			},
+		}
+		withRules := snap.WithRules(rules)
+		rules.Flags["feature"].TargetUsers[0] = "someone-else"
+
+		if withRules.Version() != "v7" {
+			t.Errorf("expected version v7, got %q", withRules.Version())
+		}
+		if !withRules.IsEnabledFor("feature", &UserContext{ID: "vip"}, false) {
+			t.Error("expected target user to be enabled")
+		}
+		if withRules.IsEnabledFor("feature", &UserContext{ID: "other"}, false) {
+			t.Error("expected other user to be disabled")
+		}
+		if snap.IsEnabledFor("feature", &UserContext{ID: "other"}, false) {
+			t.Error("expected snapshot without rules to return the default for another user")
+		}
+		if !snap.IsEnabledFor("feature", &UserContext{ID: snap.UserID()}, false) {
+			t.Error("expected snapshot without rules to return the frozen value for its own user")
+		}
+	})
+	t.Run("should not share prerequisites with the payload", func(t *testing.T) {
+		rules := RulesPayload{
+			Flags: map[string]FlagRule{
+				"base":      {Key: "base", Enabled: true, Rollout: 100},
+				"dependent": {Key: "dependent", Enabled: true, Rollout: 100, Prerequisites: []Prerequisite{{FlagKey: "base", RequiredValue: true}}},
+			},
+		}
+		withRules := snap.WithRules(rules)
+		rules.Flags["dependent"].Prerequisites[0].RequiredValue = false
+
+		if !withRules.IsEnabledFor("dependent", &UserContext{ID: "any"}, false) {
+			t.Error("expected the frozen prerequisite to still be met")
+		}
+	})
+	t.Run("should not share weighted variations with the payload", func(t *testing.T) {
+		rules := RulesPayload{
+			Flags: map[string]FlagRule{
+				"split": {
+					Key: "split", Enabled: true,
+					WeightedVariations: []WeightedVariation{{ID: "control", Weight: 100}},
+					Rules: []TargetingRule{{
+						ID: "beta", Enabled: true,
+						Conditions:         []Condition{{Attribute: "plan", Operator: "equals", Value: "beta"}},
+						WeightedVariations: []WeightedVariation{{ID: "treatment", Weight: 100}},
+					}},
+				},
+			},
+		}
+		withRules := snap.WithRules(rules)
+		rules.Flags["split"].WeightedVariations[0].ID = "changed"
+		rules.Flags["split"].Rules[0].WeightedVariations[0].ID = "changed"
+
+		user := &UserContext{ID: "user-1"}
+		if got := withRules.IsEnabledDetailFor("split", user, false).VariationID; got != "control" {
+			t.Errorf("expected variation control, got %q", got)
+		}
+		beta := &UserContext{ID: "user-1", Attributes: map[string]any{"plan": "beta"}}
+		if got := withRules.IsEnabledDetailFor("split", beta, false).VariationID; got != "treatment" {
+			t.Errorf("expected variation treatment, got %q", got)
+		}
+	})
+}
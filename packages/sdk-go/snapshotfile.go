@@ -0,0 +1,96 @@
+package rollgate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// LoadSnapshotFile reads a rules payload saved from the API (the response
+// of /api/v1/sdk/rules) into a snapshot, so an evaluation can be reproduced
+// locally against exactly the rules that were live. Evaluate users against
+// it with IsEnabledFor, IsEnabledDetailFor or Client.EvaluateAgainstSnapshot;
+// IsEnabled and GetAllFlags return the values for no user.
+//
+// Unlike API payloads the file is parsed strictly: an entry that is not a
+// valid rule fails the load rather than evaluating differently from
+// production. CreatedAt returns the file's modification time.
+func LoadSnapshotFile(path string) (*FlagSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+	payload, err := parseSnapshotFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot file %s: %w", path, err)
+	}
+
+	s := &FlagSnapshot{
+		flags:     make(map[string]bool, len(payload.Flags)),
+		reasons:   make(map[string]EvaluationReason, len(payload.Flags)),
+		ready:     true,
+		createdAt: info.ModTime(),
+	}
+	for key, rule := range payload.Flags {
+		detail := evaluateFlagDetail(rule, nil, payload.Flags, nil)
+		s.flags[key] = detail.Value
+		s.reasons[key] = detail.Reason
+	}
+	return s.WithRules(payload), nil
+}
+
+// parseSnapshotFile decodes a rules payload strictly. A flags payload of
+// booleans is rejected with a hint, as it holds values already evaluated
+// for one user rather than rules.
+func parseSnapshotFile(data []byte) (RulesPayload, error) {
+	var raw struct {
+		Version string                     `json:"version"`
+		Flags   map[string]json.RawMessage `json:"flags"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return RulesPayload{}, err
+	}
+	if raw.Flags == nil {
+		return RulesPayload{}, errors.New("not a rules payload: no flags object")
+	}
+
+	payload := RulesPayload{Version: raw.Version, Flags: make(map[string]FlagRule, len(raw.Flags))}
+	for key, value := range raw.Flags {
+		var enabled bool
+		if json.Unmarshal(value, &enabled) == nil {
+			return RulesPayload{}, fmt.Errorf("flag %q is a value, not a rule: save /api/v1/sdk/rules rather than /api/v1/sdk/flags", key)
+		}
+		var rule FlagRule
+		if err := json.Unmarshal(value, &rule); err != nil {
+			return RulesPayload{}, fmt.Errorf("flag %q: %w", key, err)
+		}
+		if rule.Key == "" {
+			rule.Key = key
+		}
+		payload.Flags[key] = rule
+	}
+	return payload, nil
+}
+
+// EvaluateAgainstSnapshot evaluates a boolean flag for user against
+// snapshot instead of the client's flags, applying the client's registered
+// defaults and flag policies as IsEnabledDetail would. With
+// LoadSnapshotFile it reproduces a production evaluation from a saved
+// payload. Like snapshot evaluations it records no metrics, telemetry or
+// exposures and runs no hooks.
+func (c *Client) EvaluateAgainstSnapshot(snapshot *FlagSnapshot, flagKey string, user *UserContext, defaultValue bool) BoolEvaluationDetail {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	defaultValue = c.boolDefault(flagKey, defaultValue)
+	detail := snapshot.IsEnabledDetailFor(flagKey, user, defaultValue)
+	if detail.Reason.Kind == ReasonUnknown {
+		detail.Value = c.policyValue(flagKey, detail.Value)
+	}
+	return detail
+}
@@ -0,0 +1,107 @@
+package rollgate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const snapshotRules = `{
+	"version": "v42",
+	"flags": {
+		"checkout": {
+			"enabled": true,
+			"rollout": 0,
+			"targetUsers": ["vip"],
+			"rules": [{"id": "eu", "enabled": true, "rollout": 100,
+				"conditions": [{"attribute": "country", "operator": "equals", "value": "IT"}]}]
+		},
+		"maintenance": {"enabled": false, "rollout": 100}
+	}
+}`
+
+func writeSnapshotFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write snapshot file: %v", err)
+	}
+	return path
+}
+
+func TestLoadSnapshotFile(t *testing.T) {
+	snapshot, err := LoadSnapshotFile(writeSnapshotFile(t, snapshotRules))
+	if err != nil {
+		t.Fatalf("LoadSnapshotFile failed: %v", err)
+	}
+
+	t.Run("should evaluate users against the saved rules", func(t *testing.T) {
+		if snapshot.Version() != "v42" {
+			t.Errorf("expected version v42, got %q", snapshot.Version())
+		}
+		detail := snapshot.IsEnabledDetailFor("checkout", &UserContext{ID: "u1", Attributes: map[string]any{"country": "IT"}}, false)
+		if !detail.Value || detail.Reason.Kind != ReasonRuleMatch || detail.Reason.RuleID != "eu" {
+			t.Errorf("expected RULE_MATCH on eu, got %+v", detail)
+		}
+		if detail := snapshot.IsEnabledDetailFor("checkout", &UserContext{ID: "vip"}, false); detail.Reason.Kind != ReasonTargetMatch {
+			t.Errorf("expected TARGET_MATCH, got %+v", detail.Reason)
+		}
+		if snapshot.IsEnabledFor("checkout", &UserContext{ID: "u2"}, true) {
+			t.Error("expected the 0% rollout to exclude u2")
+		}
+	})
+
+	t.Run("should list the flags evaluated for no user", func(t *testing.T) {
+		if keys := snapshot.Keys(); len(keys) != 2 {
+			t.Errorf("expected 2 keys, got %v", keys)
+		}
+		if detail := snapshot.IsEnabledDetail("maintenance", true); detail.Value || detail.Reason.Kind != ReasonOff {
+			t.Errorf("expected OFF, got %+v", detail)
+		}
+	})
+
+	t.Run("should reject files that are not rules payloads", func(t *testing.T) {
+		tests := map[string]string{
+			"flags payload": `{"flags":{"checkout":true}}`,
+			"invalid rule":  `{"flags":{"checkout":{"enabled":"yes"}}}`,
+			"no flags":      `{"version":"v1"}`,
+			"not JSON":      `flags`,
+		}
+		for name, content := range tests {
+			if _, err := LoadSnapshotFile(writeSnapshotFile(t, content)); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+		_, err := LoadSnapshotFile(writeSnapshotFile(t, `{"flags":{"checkout":true}}`))
+		if err == nil || !strings.Contains(err.Error(), "/api/v1/sdk/rules") {
+			t.Errorf("expected a hint to save the rules payload, got %v", err)
+		}
+	})
+}
+
+func TestClient_EvaluateAgainstSnapshot(t *testing.T) {
+	snapshot, err := LoadSnapshotFile(writeSnapshotFile(t, snapshotRules))
+	if err != nil {
+		t.Fatalf("LoadSnapshotFile failed: %v", err)
+	}
+	client := newIntegrationClient(t, Config{Offline: true})
+	client.RegisterDefaults(map[string]any{"missing": true})
+	client.RegisterFlagPolicies(map[string]FlagPolicy{"kill-switch": FailClosed})
+
+	t.Run("should evaluate the snapshot's rules", func(t *testing.T) {
+		detail := client.EvaluateAgainstSnapshot(snapshot, "checkout", &UserContext{ID: "vip"}, false)
+		if !detail.Value || detail.Reason.Kind != ReasonTargetMatch {
+			t.Errorf("expected TARGET_MATCH, got %+v", detail)
+		}
+	})
+
+	t.Run("should apply the client's defaults and policies", func(t *testing.T) {
+		if detail := client.EvaluateAgainstSnapshot(snapshot, "missing", nil, false); !detail.Value || detail.Reason.Kind != ReasonUnknown {
+			t.Errorf("expected the registered default, got %+v", detail)
+		}
+		if client.EvaluateAgainstSnapshot(snapshot, "kill-switch", nil, true).Value {
+			t.Error("expected FailClosed to return false")
+		}
+	})
+}
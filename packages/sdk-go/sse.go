@@ -19,15 +19,25 @@ type SSEClient struct {
 
 	config    Config
 	client    *http.Client
+	tokens    *tokenSource
 	url       string
 	user      *UserContext
 	connected bool
 	stopChan  chan struct{}
 
+	// cancelConn aborts the current connection; SetUser uses it to
+	// reconnect for the new user, flagging userChanged so no backoff applies
+	cancelConn  context.CancelFunc
+	userChanged bool
+
 	onFlags    func(map[string]bool)
+	onUpdate   func(SSEFlagsUpdate)
 	onError    func(error)
 	onConnect  func()
 	reconnects int
+
+	// metrics counts invalid payload entries, like polling does (optional)
+	metrics *SDKMetrics
 }
 
 // SSEEvent represents a parsed SSE event.
@@ -38,21 +48,67 @@ type SSEEvent struct {
 	Retry int
 }
 
+// SSEFlagsUpdate is a flags update received over SSE, with the reasons the
+// server sent for those flags.
+type SSEFlagsUpdate struct {
+	// Flags holds every flag (Full) or only the updated ones.
+	Flags map[string]bool
+	// Reasons holds the reasons sent with Flags; flags without an entry had
+	// no reason in the event.
+	Reasons map[string]EvaluationReason
+	// Metadata holds the version and update time sent with Flags; flags
+	// without an entry had none in the event.
+	Metadata map[string]FlagMetadata
+	// Full reports whether Flags replaces all flags rather than merging.
+	Full bool
+
+	// typed holds the typed values of V2 events, keyed like Flags; nil for
+	// V1 events, which only carry booleans
+	typed map[string]typedFlag
+}
+
 // NewSSEClient creates a new SSE client.
 func NewSSEClient(config Config) *SSEClient {
 	sseURL := config.BaseURL
 	if config.BaseURL == "" {
 		sseURL = "https://api.rollgate.io"
 	}
+	config.Logger = newLevelLogger(config.Logger, config.LogLevel)
 
 	return &SSEClient{
 		config:   config,
 		client:   &http.Client{Timeout: 0}, // No timeout for SSE
+		tokens:   newTokenSource(config.APIKey, config.TokenProvider),
 		url:      sseURL,
 		stopChan: make(chan struct{}),
 	}
 }
 
+// setTokenSource shares the client's tokens, so the stream and the other
+// requests refresh one token together.
+func (s *SSEClient) setTokenSource(ts *tokenSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = ts
+}
+
+// SetMetrics sets the metrics that invalid event payloads are counted in.
+func (s *SSEClient) SetMetrics(m *SDKMetrics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = m
+}
+
+// recordPayloadErrors counts invalid payloads or payload entries.
+func (s *SSEClient) recordPayloadErrors(count int) {
+	s.mu.RLock()
+	m := s.metrics
+	s.mu.RUnlock()
+	if m != nil {
+		m.RecordPayloadError(count)
+	}
+}
+
 // OnFlags sets the callback for flag updates.
 func (s *SSEClient) OnFlags(fn func(map[string]bool)) {
 	s.mu.Lock()
@@ -60,6 +116,14 @@ func (s *SSEClient) OnFlags(fn func(map[string]bool)) {
 	s.onFlags = fn
 }
 
+// OnUpdate sets the callback for flag updates with their reasons. It is
+// called before the OnFlags callback.
+func (s *SSEClient) OnUpdate(fn func(SSEFlagsUpdate)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onUpdate = fn
+}
+
 // OnError sets the callback for errors.
 func (s *SSEClient) OnError(fn func(error)) {
 	s.mu.Lock()
@@ -74,11 +138,17 @@ func (s *SSEClient) OnConnect(fn func()) {
 	s.onConnect = fn
 }
 
-// SetUser sets the user context for the SSE connection.
+// SetUser sets the user context for the SSE connection. An open
+// connection is dropped and reopened for the new user, so the server stops
+// streaming the previous user's flags.
 func (s *SSEClient) SetUser(user *UserContext) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.user = user
+	if s.cancelConn != nil {
+		s.userChanged = true
+		s.cancelConn()
+	}
 }
 
 // Connect starts the SSE connection with automatic reconnection.
@@ -113,6 +183,14 @@ func (s *SSEClient) connectLoop(ctx context.Context) {
 		}
 
 		err := s.connect(ctx)
+		s.mu.Lock()
+		switched := s.userChanged
+		s.userChanged = false
+		s.mu.Unlock()
+		if switched {
+			// Dropped by SetUser: reconnect for the new user right away
+			continue
+		}
 		if err != nil {
 			s.mu.Lock()
 			s.connected = false
@@ -122,17 +200,18 @@ func (s *SSEClient) connectLoop(ctx context.Context) {
 			}
 			s.mu.Unlock()
 
-			if s.config.Logger != nil {
-				s.config.Logger.Warn("SSE connection error, reconnecting", "error", err, "backoff", backoff)
-			}
+			s.config.Logger.Warn("SSE connection error, reconnecting", "error", err, "backoff", backoff)
 
 			// Wait before reconnecting
+			wait := timeSourceFor(s.config.TestMode).newTimer(backoff)
 			select {
 			case <-ctx.Done():
+				wait.Stop()
 				return
 			case <-s.stopChan:
+				wait.Stop()
 				return
-			case <-time.After(backoff):
+			case <-wait.C():
 			}
 
 			// Exponential backoff
@@ -148,19 +227,40 @@ func (s *SSEClient) connectLoop(ctx context.Context) {
 }
 
 func (s *SSEClient) connect(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	u, err := url.Parse(s.url + "/api/v1/sdk/stream")
 	if err != nil {
 		return fmt.Errorf("invalid URL: %w", err)
 	}
 
+	s.mu.Lock()
+	tokens := s.tokens
+	s.mu.Unlock()
+	token, err := tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+
 	q := u.Query()
-	q.Set("token", s.config.APIKey)
+	q.Set("token", token)
+	q.Set("withReasons", "true")
+	// Ask for typed values; servers without V2 events keep sending V1
+	q.Set("format", "v2")
 
-	s.mu.RLock()
-	if s.user != nil && s.user.ID != "" {
-		q.Set("user_id", s.user.ID)
+	s.mu.Lock()
+	user := s.user
+	if user != nil && user.ID != "" {
+		q.Set("user_id", user.ID)
 	}
-	s.mu.RUnlock()
+	s.cancelConn = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.cancelConn = nil
+		s.mu.Unlock()
+	}()
 
 	u.RawQuery = q.Encode()
 
@@ -169,11 +269,11 @@ func (s *SSEClient) connect(ctx context.Context) error {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
+	setRequestHeaders(req, s.config.CustomHeaders)
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("X-SDK-Name", "rollgate-go")
-	req.Header.Set("X-SDK-Version", "0.1.0")
+	setUserContextHeader(req, user)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -181,6 +281,9 @@ func (s *SSEClient) connect(ctx context.Context) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		tokens.invalidate(token)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
@@ -248,49 +351,115 @@ func (s *SSEClient) readEvents(ctx context.Context, body io.Reader) error {
 func (s *SSEClient) handleEvent(event SSEEvent) {
 	s.mu.RLock()
 	onFlags := s.onFlags
+	onUpdate := s.onUpdate
 	s.mu.RUnlock()
 
-	if onFlags == nil {
+	if onFlags == nil && onUpdate == nil {
 		return
 	}
 
+	var update SSEFlagsUpdate
 	switch event.Event {
 	case "init", "flags":
 		// Full flags payload
+		data, skipped, err := parseFlagsPayload([]byte(event.Data))
+		if err != nil {
+			s.recordPayloadErrors(1)
+			s.config.Logger.Error("failed to parse flags event", "error", err)
+			return
+		}
+		if skipped > 0 {
+			s.recordPayloadErrors(skipped)
+			s.config.Logger.Warn("skipped invalid entries in flags event", "count", skipped)
+		}
+		update = SSEFlagsUpdate{Flags: data.Flags, Reasons: data.Reasons, Metadata: data.Metadata, Full: true}
+
+	case "flags-v2":
+		// Full V2 payload with typed values
+		typed, skipped, err := parseTypedFlagsPayload([]byte(event.Data))
+		if err != nil {
+			s.recordPayloadErrors(1)
+			s.config.Logger.Error("failed to parse flags-v2 event", "error", err)
+			return
+		}
+		if skipped > 0 {
+			s.recordPayloadErrors(skipped)
+			s.config.Logger.Warn("skipped invalid entries in flags-v2 event", "count", skipped)
+		}
+		update = typedUpdate(typed)
+		update.Full = true
+
+	case "flag-update-v2":
+		// Single V2 flag with its typed value
 		var data struct {
-			Flags map[string]bool `json:"flags"`
+			Key string `json:"key"`
 		}
-		if err := json.Unmarshal([]byte(event.Data), &data); err != nil {
-			if s.config.Logger != nil {
-				s.config.Logger.Error("failed to parse flags event", "error", err)
-			}
+		flag, ok := parseTypedFlag(json.RawMessage(event.Data))
+		if err := json.Unmarshal([]byte(event.Data), &data); err != nil || data.Key == "" || !ok {
+			s.recordPayloadErrors(1)
+			s.config.Logger.Error("failed to parse flag-update-v2 event", "data", event.Data)
 			return
 		}
-		onFlags(data.Flags)
+		update = typedUpdate(map[string]typedFlag{data.Key: flag})
 
-	case "flag-update":
-		// Single flag update
+	case "flag-update", "flag-changed":
+		// Single flag update; flag-changed without a key only signals that
+		// several flags changed and the caller should refresh
 		var data struct {
-			Key     string `json:"key"`
-			Enabled bool   `json:"enabled"`
+			Key       string            `json:"key"`
+			Enabled   bool              `json:"enabled"`
+			Reason    *EvaluationReason `json:"reason"`
+			Version   int               `json:"version"`
+			UpdatedAt time.Time         `json:"updatedAt"`
 		}
 		if err := json.Unmarshal([]byte(event.Data), &data); err != nil {
-			if s.config.Logger != nil {
-				s.config.Logger.Error("failed to parse flag-update event", "error", err)
-			}
+			s.recordPayloadErrors(1)
+			s.config.Logger.Error("failed to parse stream event", "event", event.Event, "error", err)
+			return
+		}
+		if data.Key == "" {
+			s.config.Logger.Debug("stream event without a flag key, caller should refresh", "event", event.Event)
 			return
 		}
-		// For single flag updates, we call with just that flag
-		// The caller should merge this with existing flags
-		onFlags(map[string]bool{data.Key: data.Enabled})
-
-	case "flag-changed":
-		// Signal to refresh all flags
-		// This is typically used when multiple flags change at once
-		if s.config.Logger != nil {
-			s.config.Logger.Debug("flag-changed event received, caller should refresh")
+		update = SSEFlagsUpdate{Flags: map[string]bool{data.Key: data.Enabled}}
+		if data.Reason != nil && data.Reason.Kind != "" {
+			update.Reasons = map[string]EvaluationReason{data.Key: *data.Reason}
+		}
+		if meta := (FlagMetadata{Version: data.Version, UpdatedAt: data.UpdatedAt}); !meta.IsZero() {
+			update.Metadata = map[string]FlagMetadata{data.Key: meta}
+		}
+
+	default:
+		return
+	}
+
+	if onUpdate != nil {
+		onUpdate(update)
+	}
+	if onFlags != nil {
+		onFlags(update.Flags)
+	}
+}
+
+// typedUpdate builds an update from V2 flags: the booleans and reasons
+// and metadata that V1 events carry, plus the typed values.
+func typedUpdate(typed map[string]typedFlag) SSEFlagsUpdate {
+	update := SSEFlagsUpdate{
+		Flags:    make(map[string]bool, len(typed)),
+		Reasons:  make(map[string]EvaluationReason, len(typed)),
+		Metadata: make(map[string]FlagMetadata, len(typed)),
+		typed:    typed,
+	}
+	for key, flag := range typed {
+		update.Flags[key] = flag.Enabled
+		if flag.Reason != nil && flag.Reason.Kind != "" {
+			update.Reasons[key] = *flag.Reason
+		}
+		if !flag.Metadata.IsZero() {
+			update.Metadata[key] = flag.Metadata
 		}
 	}
+	return update
 }
 
 // GetReconnectCount returns the number of reconnection attempts.
@@ -0,0 +1,271 @@
+package rollgate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSSEClient_HandleEventReasons(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   SSEEvent
+		want    *SSEFlagsUpdate
+		reasons int
+	}{
+		{
+			name:    "init with reasons",
+			event:   SSEEvent{Event: "init", Data: `{"flags":{"a":true,"b":false},"reasons":{"a":{"kind":"TARGET_MATCH"},"b":{"kind":"OFF"}}}`},
+			want:    &SSEFlagsUpdate{Full: true},
+			reasons: 2,
+		},
+		{
+			name:  "init without reasons",
+			event: SSEEvent{Event: "init", Data: `{"flags":{"a":true}}`},
+			want:  &SSEFlagsUpdate{Full: true},
+		},
+		{
+			name:    "flag-changed with reason",
+			event:   SSEEvent{Event: "flag-changed", Data: `{"key":"a","enabled":false,"reason":{"kind":"OFF"}}`},
+			want:    &SSEFlagsUpdate{},
+			reasons: 1,
+		},
+		{
+			name:  "flag-update without reason",
+			event: SSEEvent{Event: "flag-update", Data: `{"key":"a","enabled":true}`},
+			want:  &SSEFlagsUpdate{},
+		},
+		{
+			name:    "flags-v2 with typed values",
+			event:   SSEEvent{Event: "flags-v2", Data: `{"flags":{"a":{"key":"a","type":"string","value":"x","enabled":true,"reason":{"kind":"FALLTHROUGH"}},"b":{"key":"b","type":"boolean","value":false,"enabled":false,"reason":{"kind":"OFF"}}}}`},
+			want:    &SSEFlagsUpdate{Full: true},
+			reasons: 2,
+		},
+		{
+			name:    "flag-update-v2",
+			event:   SSEEvent{Event: "flag-update-v2", Data: `{"key":"a","type":"number","value":3,"enabled":true,"reason":{"kind":"RULE_MATCH","ruleId":"r"}}`},
+			want:    &SSEFlagsUpdate{},
+			reasons: 1,
+		},
+		{
+			name:  "flag-changed without key",
+			event: SSEEvent{Event: "flag-changed", Data: `{}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSSEClient(Config{})
+			var got *SSEFlagsUpdate
+			var flagsCalls int
+			s.OnUpdate(func(u SSEFlagsUpdate) { got = &u })
+			s.OnFlags(func(map[string]bool) { flagsCalls++ })
+
+			s.handleEvent(tt.event)
+
+			if tt.want == nil {
+				if got != nil || flagsCalls != 0 {
+					t.Fatalf("expected no update, got %+v", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("expected an update")
+			}
+			if got.Full != tt.want.Full {
+				t.Errorf("Full = %v, want %v", got.Full, tt.want.Full)
+			}
+			if len(got.Reasons) != tt.reasons {
+				t.Errorf("got %d reasons, want %d", len(got.Reasons), tt.reasons)
+			}
+			if flagsCalls != 1 {
+				t.Errorf("OnFlags called %d times, want 1", flagsCalls)
+			}
+		})
+	}
+}
+
+func TestSSEClient_PayloadErrors(t *testing.T) {
+	metrics := NewSDKMetrics()
+	s := NewSSEClient(Config{})
+	s.SetMetrics(metrics)
+	s.OnUpdate(func(SSEFlagsUpdate) {})
+
+	s.handleEvent(SSEEvent{Event: "init", Data: `{"flags":{"good":true,"bad":"yes","worse":1}}`})
+	s.handleEvent(SSEEvent{Event: "init", Data: `{"flags":`})
+	s.handleEvent(SSEEvent{Event: "flag-changed", Data: `not json`})
+	s.handleEvent(SSEEvent{Event: "flags-v2", Data: `{"flags":{"good":{"type":"string","value":"x","enabled":true},"bad":{"type":"color"}}}`})
+	s.handleEvent(SSEEvent{Event: "flag-update-v2", Data: `{"type":"string","value":"no key"}`})
+
+	// Three skipped entries, one unparseable payload, two unparseable updates
+	if got := metrics.Snapshot().PayloadErrors; got != 6 {
+		t.Errorf("expected 6 payload errors, got %d", got)
+	}
+}
+
+func TestClient_SSEUpdatesReasons(t *testing.T) {
+	events := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"flags":{"a":true,"b":true},"reasons":{"a":{"kind":"TARGET_MATCH"},"b":{"kind":"FALLTHROUGH","inRollout":true}}}`)
+		case "/api/v1/sdk/stream":
+			if r.URL.Query().Get("withReasons") != "true" {
+				t.Errorf("stream requested without withReasons")
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case <-r.Context().Done():
+					return
+				case event := <-events:
+					fmt.Fprint(w, event)
+					w.(http.Flusher).Flush()
+				}
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		EnableStreaming: true,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	waitFor := func(flag string, kind EvaluationReasonKind) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if client.IsEnabledDetail(flag, false).Reason.Kind == kind {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("%s reason never became %s, got %+v", flag, kind, client.IsEnabledDetail(flag, false))
+	}
+
+	// A single update replaces the reason with the one sent alongside
+	events <- "event: flag-changed\ndata: {\"key\":\"a\",\"enabled\":false,\"reason\":{\"kind\":\"OFF\"}}\n\n"
+	waitFor("a", ReasonOff)
+	if client.IsEnabled("a", true) {
+		t.Error("expected a to be disabled after the update")
+	}
+
+	// An update without a reason drops the stale one
+	events <- "event: flag-update\ndata: {\"key\":\"b\",\"enabled\":false}\n\n"
+	deadline := time.Now().Add(2 * time.Second)
+	for client.IsEnabled("b", true) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if detail := client.IsEnabledDetail("b", true); detail.Value || detail.Reason.InRollout {
+		t.Errorf("expected b's stored reason to be invalidated, got %+v", detail)
+	}
+
+	// A full payload replaces every reason
+	init, _ := json.Marshal(map[string]any{
+		"flags":   map[string]bool{"a": true},
+		"reasons": map[string]any{"a": map[string]any{"kind": "RULE_MATCH", "ruleId": "r1"}},
+	})
+	events <- "event: init\ndata: " + string(init) + "\n\n"
+	waitFor("a", ReasonRuleMatch)
+	if got := client.IsEnabledDetail("b", true); got.Reason.Kind != ReasonUnknown {
+		t.Errorf("expected b to be unknown after a full update, got %+v", got)
+	}
+}
+
+func TestClient_SSETypedValues(t *testing.T) {
+	events := make(chan string, 4)
+	var typedRequests int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"flags":{"banner":true}}`)
+		case "/api/v1/sdk/v2/flags":
+			mu.Lock()
+			typedRequests++
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"flags":{"banner":{"key":"banner","type":"string","value":"Welcome","enabled":true}}}`)
+		case "/api/v1/sdk/stream":
+			if r.URL.Query().Get("format") != "v2" {
+				t.Errorf("stream requested without format=v2")
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case <-r.Context().Done():
+					return
+				case event := <-events:
+					fmt.Fprint(w, event)
+					w.(http.Flusher).Flush()
+				}
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	client, err := NewClient(Config{APIKey: "test-key", BaseURL: server.URL, EnableStreaming: true})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	waitFor := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for client.GetString("banner", "") != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("banner never became %q, got %q", want, client.GetString("banner", ""))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	events <- "event: flags-v2\ndata: {\"flags\":{\"banner\":{\"key\":\"banner\",\"type\":\"string\",\"value\":\"Hello\",\"enabled\":true},\"limit\":{\"key\":\"limit\",\"type\":\"number\",\"value\":5,\"enabled\":true}}}\n\n"
+	waitFor("Hello")
+	if got := client.GetNumber("limit", 0); got != 5 {
+		t.Errorf("expected the streamed number flag, got %v", got)
+	}
+
+	events <- "event: flag-update-v2\ndata: {\"key\":\"banner\",\"type\":\"string\",\"value\":\"Goodbye\",\"enabled\":true,\"reason\":{\"kind\":\"RULE_MATCH\",\"ruleId\":\"late\"}}\n\n"
+	waitFor("Goodbye")
+	if detail := client.GetStringDetail("banner", ""); detail.Reason.RuleID != "late" {
+		t.Errorf("expected the streamed reason, got %+v", detail)
+	}
+	if got := client.GetNumber("limit", 0); got != 5 {
+		t.Errorf("expected a single update to keep the other typed flags, got %v", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if typedRequests != 1 {
+		t.Errorf("expected typed values to come from the stream after Init, got %d V2 requests", typedRequests)
+	}
+}
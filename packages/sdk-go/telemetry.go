@@ -2,6 +2,7 @@ package rollgate
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,7 +18,8 @@ type TelemetryConfig struct {
 	// MaxBufferSize is the maximum evaluations to buffer before forcing a flush (default: 1000)
 	MaxBufferSize int
 
-	// Enabled controls whether telemetry collection is active (default: true)
+	// Enabled controls whether telemetry collection is active (default: true).
+	// When false the client builds no collector: no goroutine and no requests.
 	Enabled bool
 }
 
@@ -35,11 +37,48 @@ type TelemetryEvalStats struct {
 	Total int `json:"total"`
 	True  int `json:"true"`
 	False int `json:"false"`
+
+	// Reasons counts evaluations by reason kind (RULE_MATCH, FALLTHROUGH,
+	// TARGET_MATCH, ERROR, ...); evaluations recorded without a reason are
+	// only in the totals
+	Reasons map[ReasonKind]int `json:"reasons,omitempty"`
+	// Variations counts evaluations by the variation ID served
+	Variations map[string]int `json:"variations,omitempty"`
+}
+
+// add merges other into s.
+func (s *TelemetryEvalStats) add(other TelemetryEvalStats) {
+	s.Total += other.Total
+	s.True += other.True
+	s.False += other.False
+	for kind, n := range other.Reasons {
+		if s.Reasons == nil {
+			s.Reasons = make(map[ReasonKind]int)
+		}
+		s.Reasons[kind] += n
+	}
+	for id, n := range other.Variations {
+		if s.Variations == nil {
+			s.Variations = make(map[string]int)
+		}
+		s.Variations[id] += n
+	}
+}
+
+// TelemetryDefaultStats counts evaluations of a flag key that returned the
+// caller's default because no value was available, so stale or misspelled
+// flag keys show up in production. They are not in the evaluation stats.
+type TelemetryDefaultStats struct {
+	Total int `json:"total"`
+	// Causes counts the evaluations by why no value was available:
+	// FLAG_NOT_FOUND, CLIENT_NOT_READY or MALFORMED_RESPONSE
+	Causes map[EvaluationErrorKind]int `json:"causes"`
 }
 
 type telemetryPayload struct {
-	Evaluations map[string]TelemetryEvalStats `json:"evaluations"`
-	PeriodMs    int64                         `json:"period_ms"`
+	Evaluations map[string]TelemetryEvalStats    `json:"evaluations"`
+	Defaults    map[string]TelemetryDefaultStats `json:"defaults,omitempty"`
+	PeriodMs    int64                            `json:"period_ms"`
 }
 
 // TelemetryCollector tracks flag evaluations and sends them to the server in batches.
@@ -47,14 +86,24 @@ type TelemetryCollector struct {
 	mu            sync.Mutex
 	config        TelemetryConfig
 	endpoint      string
-	apiKey        string
+	tokens        *tokenSource
 	httpClient    *http.Client
 	evaluations   map[string]*TelemetryEvalStats
+	defaults      map[string]*TelemetryDefaultStats
 	totalBuffered int
 	lastFlushTime time.Time
 	isFlushing    bool
+	flushQueued   bool
+	highWater     int
 	stopCh        chan struct{}
 	stopped       bool
+	observer      RequestObserver
+	metrics       *SDKMetrics
+	headers       map[string]string
+	ctx           context.Context // Parent of background flush requests
+	timers        timeSource      // Flush ticker; the TestClock in test mode
+
+	rateLimits *rateLimitTracker // Records 429 responses; may be nil
 }
 
 // NewTelemetryCollector creates a new telemetry collector.
@@ -62,31 +111,97 @@ func NewTelemetryCollector(endpoint, apiKey string, config TelemetryConfig, http
 	return &TelemetryCollector{
 		config:        config,
 		endpoint:      endpoint,
-		apiKey:        apiKey,
+		tokens:        newTokenSource(apiKey, nil),
 		httpClient:    httpClient,
 		evaluations:   make(map[string]*TelemetryEvalStats),
+		defaults:      make(map[string]*TelemetryDefaultStats),
 		lastFlushTime: time.Now(),
 		stopCh:        make(chan struct{}),
+		ctx:           context.Background(),
+		timers:        systemTime{},
 	}
 }
 
+// SetRequestObserver sets a callback invoked after each flush request.
+func (tc *TelemetryCollector) SetRequestObserver(fn RequestObserver) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.observer = fn
+}
+
+// setHeaders sets extra headers sent with each flush request.
+func (tc *TelemetryCollector) setHeaders(headers map[string]string) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.headers = headers
+}
+
+// setTokenSource makes flushes authorize with the client's tokens, so a
+// TokenProvider covers telemetry too.
+func (tc *TelemetryCollector) setTokenSource(ts *tokenSource) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.tokens = ts
+}
+
+// setContext sets the parent context of periodic, threshold and final
+// flushes, so they are cancelled with the client.
+func (tc *TelemetryCollector) setContext(ctx context.Context) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.ctx = ctx
+}
+
+// setTimeSource makes periodic flushes wait on ts. It must be called
+// before Start.
+func (tc *TelemetryCollector) setTimeSource(ts timeSource) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.timers = ts
+}
+
+// parentContext returns the parent context of background flushes.
+func (tc *TelemetryCollector) parentContext() context.Context {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.ctx
+}
+
+// setMetrics sets the metrics that record buffer high-water marks and threshold flushes.
+func (tc *TelemetryCollector) setMetrics(m *SDKMetrics) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.metrics = m
+}
+
+// setRateLimits makes flushes report 429 responses to the client's
+// RateLimitState.
+func (tc *TelemetryCollector) setRateLimits(t *rateLimitTracker) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.rateLimits = t
+}
+
 // Start begins periodic flushing.
 func (tc *TelemetryCollector) Start() {
-	if !tc.config.Enabled || tc.endpoint == "" || tc.apiKey == "" {
+	if !tc.config.Enabled || tc.endpoint == "" || !tc.tokens.configured() {
 		return
 	}
 
 	interval := time.Duration(tc.config.FlushIntervalMs) * time.Millisecond
+	tc.mu.Lock()
+	timers := tc.timers
+	tc.mu.Unlock()
 	go func() {
-		ticker := time.NewTicker(interval)
+		ticker := timers.newTicker(interval)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-tc.stopCh:
 				return
-			case <-ticker.C:
-				_ = tc.Flush()
+			case <-ticker.C():
+				_ = tc.Flush(tc.parentContext())
 			}
 		}
 	}()
@@ -103,11 +218,18 @@ func (tc *TelemetryCollector) Stop() {
 	tc.mu.Unlock()
 
 	close(tc.stopCh)
-	_ = tc.Flush()
+	_ = tc.Flush(tc.parentContext())
 }
 
-// RecordEvaluation records a single flag evaluation.
+// RecordEvaluation records a single flag evaluation without a reason or
+// variation.
 func (tc *TelemetryCollector) RecordEvaluation(flagKey string, result bool) {
+	tc.RecordEvaluationDetail(flagKey, result, EvaluationReason{}, "")
+}
+
+// RecordEvaluationDetail records a single flag evaluation, counted by the
+// kind of reason and by variationID when they are set.
+func (tc *TelemetryCollector) RecordEvaluationDetail(flagKey string, result bool, reason EvaluationReason, variationID string) {
 	if !tc.config.Enabled {
 		return
 	}
@@ -125,23 +247,77 @@ func (tc *TelemetryCollector) RecordEvaluation(flagKey string, result bool) {
 	} else {
 		stats.False++
 	}
+	if reason.Kind != "" {
+		if stats.Reasons == nil {
+			stats.Reasons = make(map[ReasonKind]int)
+		}
+		stats.Reasons[reason.Kind]++
+	}
+	if variationID != "" {
+		if stats.Variations == nil {
+			stats.Variations = make(map[string]int)
+		}
+		stats.Variations[variationID]++
+	}
+	tc.buffered()
+}
+
+// RecordDefault records an evaluation of flagKey that returned the default
+// because no value was available, counted by cause.
+func (tc *TelemetryCollector) RecordDefault(flagKey string, cause EvaluationErrorKind) {
+	if !tc.config.Enabled {
+		return
+	}
+
+	tc.mu.Lock()
+	stats, ok := tc.defaults[flagKey]
+	if !ok {
+		stats = &TelemetryDefaultStats{Causes: make(map[EvaluationErrorKind]int)}
+		tc.defaults[flagKey] = stats
+	}
+	stats.Total++
+	stats.Causes[cause]++
+	tc.buffered()
+}
+
+// buffered counts an evaluation added to the buffer and flushes early once
+// the buffer is full. It is called with tc.mu held and releases it.
+func (tc *TelemetryCollector) buffered() {
 	tc.totalBuffered++
-	shouldFlush := tc.totalBuffered >= tc.config.MaxBufferSize
+	buffered := tc.totalBuffered
+	if buffered > tc.highWater {
+		tc.highWater = buffered
+	}
+	// Flush early once the buffer is full, without blocking the evaluation
+	// and without queueing more than one flush at a time
+	shouldFlush := buffered >= tc.config.MaxBufferSize && !tc.isFlushing && !tc.flushQueued
+	if shouldFlush {
+		tc.flushQueued = true
+	}
+	metrics := tc.metrics
 	tc.mu.Unlock()
 
+	if metrics != nil {
+		metrics.RecordTelemetryBuffer(buffered)
+	}
 	if shouldFlush {
-		_ = tc.Flush()
+		if metrics != nil {
+			metrics.RecordTelemetryThresholdFlush()
+		}
+		go func() { _ = tc.Flush(tc.parentContext()) }()
 	}
 }
 
-// Flush sends buffered evaluations to the server.
-func (tc *TelemetryCollector) Flush() error {
+// Flush sends buffered evaluations to the server. The request is bounded by
+// ctx; evaluations are kept for the next flush if it fails.
+func (tc *TelemetryCollector) Flush(ctx context.Context) error {
 	tc.mu.Lock()
-	if tc.isFlushing || len(tc.evaluations) == 0 {
+	tc.flushQueued = false
+	if tc.isFlushing || (len(tc.evaluations) == 0 && len(tc.defaults) == 0) {
 		tc.mu.Unlock()
 		return nil
 	}
-	if tc.endpoint == "" || tc.apiKey == "" {
+	if tc.endpoint == "" || !tc.tokens.configured() {
 		tc.mu.Unlock()
 		return nil
 	}
@@ -153,79 +329,144 @@ func (tc *TelemetryCollector) Flush() error {
 	for key, stats := range tc.evaluations {
 		evaluationsToSend[key] = *stats
 	}
+	var defaultsToSend map[string]TelemetryDefaultStats
+	if len(tc.defaults) > 0 {
+		defaultsToSend = make(map[string]TelemetryDefaultStats, len(tc.defaults))
+		for key, stats := range tc.defaults {
+			defaultsToSend[key] = *stats
+		}
+	}
 	periodMs := time.Since(tc.lastFlushTime).Milliseconds()
 	tc.evaluations = make(map[string]*TelemetryEvalStats)
+	tc.defaults = make(map[string]*TelemetryDefaultStats)
 	tc.totalBuffered = 0
 	tc.lastFlushTime = time.Now()
+	headers := tc.headers
+	tokens := tc.tokens
 	tc.mu.Unlock()
 
 	payload := telemetryPayload{
 		Evaluations: evaluationsToSend,
+		Defaults:    defaultsToSend,
 		PeriodMs:    periodMs,
 	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		tc.restoreBuffer(evaluationsToSend)
+		tc.restoreBuffer(evaluationsToSend, defaultsToSend)
 		tc.mu.Lock()
 		tc.isFlushing = false
 		tc.mu.Unlock()
 		return fmt.Errorf("marshal telemetry: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, tc.endpoint, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tc.endpoint, bytes.NewReader(body))
 	if err != nil {
-		tc.restoreBuffer(evaluationsToSend)
+		tc.restoreBuffer(evaluationsToSend, defaultsToSend)
 		tc.mu.Lock()
 		tc.isFlushing = false
 		tc.mu.Unlock()
 		return fmt.Errorf("create request: %w", err)
 	}
+	setRequestHeaders(req, headers)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+tc.apiKey)
+	if err := tokens.authorize(req); err != nil {
+		tc.restoreBuffer(evaluationsToSend, defaultsToSend)
+		tc.mu.Lock()
+		tc.isFlushing = false
+		tc.mu.Unlock()
+		tc.notify(time.Now(), 0, err)
+		return err
+	}
 
+	start := time.Now()
 	resp, err := tc.httpClient.Do(req)
 	if err != nil {
-		tc.restoreBuffer(evaluationsToSend)
+		tc.restoreBuffer(evaluationsToSend, defaultsToSend)
 		tc.mu.Lock()
 		tc.isFlushing = false
 		tc.mu.Unlock()
-		return fmt.Errorf("send telemetry: %w", err)
+		err = fmt.Errorf("send telemetry: %w", err)
+		tc.notify(start, 0, err)
+		return err
 	}
 	defer resp.Body.Close()
 
 	tc.mu.Lock()
 	tc.isFlushing = false
+	rateLimits := tc.rateLimits
 	tc.mu.Unlock()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		tokens.rejected(req)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests && rateLimits != nil {
+		rateLimits.record(resp, time.Now())
+	}
 	if resp.StatusCode != http.StatusOK {
-		tc.restoreBuffer(evaluationsToSend)
-		return fmt.Errorf("telemetry request failed: %d", resp.StatusCode)
+		tc.restoreBuffer(evaluationsToSend, defaultsToSend)
+		err = fmt.Errorf("telemetry request failed: %d", resp.StatusCode)
+		tc.notify(start, resp.StatusCode, err)
+		return err
 	}
 
+	tc.notify(start, resp.StatusCode, nil)
 	return nil
 }
 
-// GetBufferStats returns current buffer statistics.
+func (tc *TelemetryCollector) notify(start time.Time, statusCode int, err error) {
+	tc.mu.Lock()
+	observer := tc.observer
+	tc.mu.Unlock()
+
+	observer.notify(RequestInfo{
+		Method:     http.MethodPost,
+		Endpoint:   tc.endpoint,
+		Duration:   time.Since(start),
+		StatusCode: statusCode,
+		Error:      err,
+	})
+}
+
+// GetBufferStats returns current buffer statistics. flagCount is the
+// number of flags with evaluation stats; evaluationCount includes
+// evaluations that returned the default.
 func (tc *TelemetryCollector) GetBufferStats() (flagCount, evaluationCount int) {
 	tc.mu.Lock()
 	defer tc.mu.Unlock()
 	return len(tc.evaluations), tc.totalBuffered
 }
 
-func (tc *TelemetryCollector) restoreBuffer(data map[string]TelemetryEvalStats) {
+// HighWaterMark returns the largest number of evaluations buffered between flushes.
+func (tc *TelemetryCollector) HighWaterMark() int {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.highWater
+}
+
+func (tc *TelemetryCollector) restoreBuffer(data map[string]TelemetryEvalStats, defaults map[string]TelemetryDefaultStats) {
 	tc.mu.Lock()
 	defer tc.mu.Unlock()
 	for key, stats := range data {
 		existing, ok := tc.evaluations[key]
 		if ok {
-			existing.Total += stats.Total
-			existing.True += stats.True
-			existing.False += stats.False
+			existing.add(stats)
 		} else {
 			s := stats
 			tc.evaluations[key] = &s
 		}
 		tc.totalBuffered += stats.Total
 	}
+	for key, stats := range defaults {
+		existing, ok := tc.defaults[key]
+		if !ok {
+			existing = &TelemetryDefaultStats{Causes: make(map[EvaluationErrorKind]int)}
+			tc.defaults[key] = existing
+		}
+		existing.Total += stats.Total
+		for cause, n := range stats.Causes {
+			existing.Causes[cause] += n
+		}
+		tc.totalBuffered += stats.Total
+	}
 }
@@ -0,0 +1,219 @@
+package rollgate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTelemetryCollector_ThresholdFlush(t *testing.T) {
+	var mu sync.Mutex
+	var payloads []telemetryPayload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p telemetryPayload
+		json.NewDecoder(r.Body).Decode(&p)
+		mu.Lock()
+		payloads = append(payloads, p)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	metrics := NewSDKMetrics()
+	tc := NewTelemetryCollector(server.URL, "test-key", TelemetryConfig{
+		FlushIntervalMs: 3600000,
+		MaxBufferSize:   10,
+		Enabled:         true,
+	}, &http.Client{})
+	tc.setMetrics(metrics)
+
+	t.Run("should flush early when the buffer is full", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			tc.RecordEvaluation("hot-flag", i%2 == 0)
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for {
+			mu.Lock()
+			n := len(payloads)
+			mu.Unlock()
+			if n > 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(payloads) != 1 {
+			t.Fatalf("expected 1 threshold flush, got %d", len(payloads))
+		}
+		if got := payloads[0].Evaluations["hot-flag"]; got.Total != 10 || got.True != 5 {
+			t.Errorf("unexpected stats: %+v", got)
+		}
+	})
+
+	t.Run("should record the high-water mark", func(t *testing.T) {
+		if tc.HighWaterMark() != 10 {
+			t.Errorf("expected high-water mark 10, got %d", tc.HighWaterMark())
+		}
+		snap := metrics.Snapshot()
+		if snap.TelemetryBufferHighWater != 10 {
+			t.Errorf("expected metrics high-water mark 10, got %d", snap.TelemetryBufferHighWater)
+		}
+		if snap.TelemetryThresholdFlushes != 1 {
+			t.Errorf("expected 1 threshold flush, got %d", snap.TelemetryThresholdFlushes)
+		}
+	})
+}
+
+func TestSDKMetrics_TelemetryBufferHighWater(t *testing.T) {
+	m := NewSDKMetrics()
+	m.RecordTelemetryBuffer(5)
+	m.RecordTelemetryBuffer(12)
+	m.RecordTelemetryBuffer(3)
+
+	if got := m.Snapshot().TelemetryBufferHighWater; got != 12 {
+		t.Errorf("expected high-water mark 12, got %d", got)
+	}
+}
+
+func TestTelemetryCollector_Breakdown(t *testing.T) {
+	var payload telemetryPayload
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	tc := NewTelemetryCollector(server.URL, "test-key", TelemetryConfig{
+		FlushIntervalMs: 3600000,
+		MaxBufferSize:   100,
+		Enabled:         true,
+	}, server.Client())
+	tc.RecordEvaluationDetail("checkout", true, EvaluationReason{Kind: ReasonTargetMatch}, "treatment")
+	tc.RecordEvaluationDetail("checkout", false, EvaluationReason{Kind: ReasonFallthrough}, "control")
+	tc.RecordEvaluation("checkout", true)
+
+	// A failed flush restores the breakdown along with the totals
+	if err := tc.Flush(context.Background()); err == nil {
+		t.Fatal("expected the first flush to fail")
+	}
+	fail = false
+	tc.RecordEvaluationDetail("checkout", false, ErrorReason(ErrorWrongType), "")
+	if err := tc.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	stats := payload.Evaluations["checkout"]
+	if stats.Total != 4 || stats.True != 2 {
+		t.Errorf("unexpected totals: %+v", stats)
+	}
+	wantReasons := map[ReasonKind]int{ReasonTargetMatch: 1, ReasonFallthrough: 1, ReasonError: 1}
+	if !reflect.DeepEqual(stats.Reasons, wantReasons) {
+		t.Errorf("expected reasons %v, got %v", wantReasons, stats.Reasons)
+	}
+	wantVariations := map[string]int{"treatment": 1, "control": 1}
+	if !reflect.DeepEqual(stats.Variations, wantVariations) {
+		t.Errorf("expected variations %v, got %v", wantVariations, stats.Variations)
+	}
+}
+
+func TestTelemetryCollector_Defaults(t *testing.T) {
+	var payload telemetryPayload
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	tc := NewTelemetryCollector(server.URL, "test-key", TelemetryConfig{
+		FlushIntervalMs: 3600000,
+		MaxBufferSize:   100,
+		Enabled:         true,
+	}, server.Client())
+	tc.RecordDefault("chekout", ErrorFlagNotFound)
+	tc.RecordDefault("chekout", ErrorClientNotReady)
+
+	// A failed flush restores the defaults too
+	if err := tc.Flush(context.Background()); err == nil {
+		t.Fatal("expected the first flush to fail")
+	}
+	if _, evaluations := tc.GetBufferStats(); evaluations != 2 {
+		t.Errorf("expected 2 buffered evaluations, got %d", evaluations)
+	}
+	fail = false
+	tc.RecordDefault("chekout", ErrorFlagNotFound)
+	if err := tc.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	want := TelemetryDefaultStats{Total: 3, Causes: map[EvaluationErrorKind]int{ErrorFlagNotFound: 2, ErrorClientNotReady: 1}}
+	if got := payload.Defaults["chekout"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if _, ok := payload.Evaluations["chekout"]; ok {
+		t.Error("defaults should not be counted as evaluations")
+	}
+}
+
+func TestClient_TelemetryDefaults(t *testing.T) {
+	payloads := make(chan telemetryPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			w.Write([]byte(`{"flags":{"checkout":true}}`))
+		case "/api/v1/sdk/telemetry":
+			var payload telemetryPayload
+			json.NewDecoder(r.Body).Decode(&payload)
+			payloads <- payload
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig("test-key")
+	config.BaseURL = server.URL
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	client.IsEnabled("checkout", false)
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	client.IsEnabled("checkout", false)
+	client.IsEnabled("chekout", false)
+	client.GetString("chekout", "")
+	if err := client.FlushTelemetry(context.Background()); err != nil {
+		t.Fatalf("FlushTelemetry failed: %v", err)
+	}
+
+	payload := <-payloads
+	if got := payload.Defaults["checkout"].Causes[ErrorClientNotReady]; got != 1 {
+		t.Errorf("expected 1 not-ready default for checkout, got %d", got)
+	}
+	if got := payload.Defaults["chekout"].Causes[ErrorFlagNotFound]; got != 2 {
+		t.Errorf("expected 2 not-found defaults for chekout, got %d", got)
+	}
+	if got := payload.Evaluations["checkout"].Total; got != 1 {
+		t.Errorf("expected 1 evaluation of checkout, got %d", got)
+	}
+}
@@ -0,0 +1,310 @@
+package rollgate
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TestModeConfig makes the client's background timing deterministic, for
+// unit tests of code that uses the client. Retry backoff, polling, stream
+// reconnects and event and telemetry flushes wait on Clock instead of the
+// system clock, and retry jitter is disabled: nothing happens in the
+// background until the test advances the clock.
+type TestModeConfig struct {
+	// Enabled turns test mode on (default: false)
+	Enabled bool
+
+	// Clock drives the client's timers (default: a new TestClock starting
+	// at the current time, available from Client.TestClock)
+	Clock *TestClock
+}
+
+// applyTestModeConfig fills in the clock and removes randomness from the
+// retry delays.
+func applyTestModeConfig(config *Config) {
+	if config.TestMode.Clock == nil {
+		config.TestMode.Clock = NewTestClock(time.Now())
+	}
+	config.Retry.JitterFactor = 0
+}
+
+// TestClock returns the clock driving the client's timers in test mode,
+// or nil if test mode is disabled.
+func (c *Client) TestClock() *TestClock {
+	if !c.config.TestMode.Enabled {
+		return nil
+	}
+	return c.config.TestMode.Clock
+}
+
+// timeSource creates the timers of background work: the system clock, or
+// the TestClock in test mode.
+type timeSource interface {
+	newTimer(d time.Duration) sourceTimer
+	newTicker(d time.Duration) sourceTicker
+}
+
+// sourceTimer is a timer of a timeSource; it works like time.Timer.
+type sourceTimer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// sourceTicker is a ticker of a timeSource; it works like time.Ticker.
+type sourceTicker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// timeSourceFor returns the time source selected by config.
+func timeSourceFor(config TestModeConfig) timeSource {
+	if config.Enabled && config.Clock != nil {
+		return config.Clock
+	}
+	return systemTime{}
+}
+
+// sleep waits for d on ts, returning false if ctx is done first.
+func sleep(ctx context.Context, ts timeSource, d time.Duration) bool {
+	timer := ts.newTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C():
+		return true
+	}
+}
+
+// systemTime is the timeSource of the system clock.
+type systemTime struct{}
+
+func (systemTime) newTimer(d time.Duration) sourceTimer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemTime) newTicker(d time.Duration) sourceTicker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTimer struct{ timer *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time        { return t.timer.C }
+func (t systemTimer) Stop() bool                 { return t.timer.Stop() }
+func (t systemTimer) Reset(d time.Duration) bool { return t.timer.Reset(d) }
+
+type systemTicker struct{ ticker *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time { return t.ticker.C }
+func (t systemTicker) Stop()               { t.ticker.Stop() }
+
+// TestClock is a manually advanced clock for TestModeConfig. Timers fire
+// only when Advance or Tick moves the clock past their deadline; what the
+// client does in response runs on its own goroutines, so tests use
+// BlockUntil to wait for it to schedule its next timer.
+//
+//	clock := client.TestClock()
+//	clock.BlockUntil(ctx, 1) // the poll timer
+//	clock.Advance(30 * time.Second)
+//	clock.BlockUntil(ctx, 1) // polled, next poll scheduled
+type TestClock struct {
+	mu      sync.Mutex
+	changed chan struct{} // Closed and replaced when the timers change
+	now     time.Time
+	timers  map[*testTimer]struct{} // Active timers and tickers
+}
+
+// NewTestClock creates a test clock set to start.
+func NewTestClock(start time.Time) *TestClock {
+	return &TestClock{
+		changed: make(chan struct{}),
+		now:     start,
+		timers:  make(map[*testTimer]struct{}),
+	}
+}
+
+// Now returns the clock's current time.
+func (tc *TestClock) Now() time.Time {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.now
+}
+
+// Advance moves the clock forward by d, firing every timer and ticker due
+// by then in deadline order. A ticker fires at most once per Advance and
+// then skips the ticks it missed, like a time.Ticker whose receiver is slow.
+func (tc *TestClock) Advance(d time.Duration) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	target := tc.now.Add(d)
+	for {
+		next := tc.nextLocked(target)
+		if next == nil {
+			break
+		}
+		tc.now = next.at
+		tc.fireLocked(next, target)
+	}
+	tc.now = target
+}
+
+// Tick moves the clock to the earliest timer deadline and fires the timers
+// due then, returning how far the clock moved. It returns 0 without moving
+// the clock if no timer is active.
+func (tc *TestClock) Tick() time.Duration {
+	tc.mu.Lock()
+	var next *testTimer
+	for t := range tc.timers {
+		if next == nil || t.at.Before(next.at) {
+			next = t
+		}
+	}
+	if next == nil {
+		tc.mu.Unlock()
+		return 0
+	}
+	d := max(next.at.Sub(tc.now), 0)
+	tc.mu.Unlock()
+	tc.Advance(d)
+	return d
+}
+
+// Timers returns the number of active timers and tickers.
+func (tc *TestClock) Timers() int {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return len(tc.timers)
+}
+
+// BlockUntil waits until at least n timers and tickers are active, or ctx
+// is done.
+func (tc *TestClock) BlockUntil(ctx context.Context, n int) error {
+	for {
+		tc.mu.Lock()
+		active, changed := len(tc.timers), tc.changed
+		tc.mu.Unlock()
+		if active >= n {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// nextLocked returns the active timer with the earliest deadline not after
+// target. Caller must hold tc.mu.
+func (tc *TestClock) nextLocked(target time.Time) *testTimer {
+	var next *testTimer
+	for t := range tc.timers {
+		if t.at.After(target) {
+			continue
+		}
+		if next == nil || t.at.Before(next.at) {
+			next = t
+		}
+	}
+	return next
+}
+
+// fireLocked delivers the current time to t, dropping it if the last one
+// was not received, like time.Ticker. A ticker is rescheduled for its
+// first tick after target. Caller must hold tc.mu.
+func (tc *TestClock) fireLocked(t *testTimer, target time.Time) {
+	select {
+	case t.c <- tc.now:
+	default:
+	}
+	if t.period > 0 {
+		for !t.at.After(target) {
+			t.at = t.at.Add(t.period)
+		}
+		return
+	}
+	tc.removeLocked(t)
+}
+
+// addLocked schedules t after d; a timer that is already due fires right
+// away, like a time.Timer. Caller must hold tc.mu.
+func (tc *TestClock) addLocked(t *testTimer, d time.Duration) {
+	t.at = tc.now.Add(d)
+	if d <= 0 && t.period == 0 {
+		select {
+		case t.c <- tc.now:
+		default:
+		}
+		return
+	}
+	tc.timers[t] = struct{}{}
+	tc.notifyLocked()
+}
+
+// removeLocked cancels t, reporting whether it was active. Caller must
+// hold tc.mu.
+func (tc *TestClock) removeLocked(t *testTimer) bool {
+	if _, ok := tc.timers[t]; !ok {
+		return false
+	}
+	delete(tc.timers, t)
+	tc.notifyLocked()
+	return true
+}
+
+// notifyLocked wakes BlockUntil callers. Caller must hold tc.mu.
+func (tc *TestClock) notifyLocked() {
+	close(tc.changed)
+	tc.changed = make(chan struct{})
+}
+
+func (tc *TestClock) newTimer(d time.Duration) sourceTimer {
+	t := &testTimer{clock: tc, c: make(chan time.Time, 1)}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.addLocked(t, d)
+	return t
+}
+
+func (tc *TestClock) newTicker(d time.Duration) sourceTicker {
+	if d <= 0 {
+		panic("rollgate: non-positive interval for TestClock ticker")
+	}
+	t := &testTimer{clock: tc, c: make(chan time.Time, 1), period: d}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.addLocked(t, d)
+	return testTicker{t}
+}
+
+// testTimer is a timer or, with a period, a ticker of a TestClock.
+type testTimer struct {
+	clock  *TestClock
+	c      chan time.Time
+	at     time.Time
+	period time.Duration
+}
+
+func (t *testTimer) C() <-chan time.Time { return t.c }
+
+func (t *testTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.removeLocked(t)
+}
+
+func (t *testTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.removeLocked(t)
+	t.clock.addLocked(t, d)
+	return active
+}
+
+// testTicker is the sourceTicker of a periodic testTimer.
+type testTicker struct{ timer *testTimer }
+
+func (t testTicker) C() <-chan time.Time { return t.timer.c }
+func (t testTicker) Stop()               { t.timer.Stop() }
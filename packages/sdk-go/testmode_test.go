@@ -0,0 +1,189 @@
+package rollgate
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTestClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should fire a timer only once the clock passes its deadline", func(t *testing.T) {
+		clock := NewTestClock(start)
+		timer := clock.newTimer(time.Second)
+		clock.Advance(999 * time.Millisecond)
+		select {
+		case <-timer.C():
+			t.Fatal("expected the timer not to fire early")
+		default:
+		}
+		clock.Advance(time.Millisecond)
+		select {
+		case at := <-timer.C():
+			if !at.Equal(start.Add(time.Second)) {
+				t.Errorf("expected to fire at the deadline, got %v", at)
+			}
+		default:
+			t.Fatal("expected the timer to fire")
+		}
+		if clock.Timers() != 0 {
+			t.Errorf("expected no active timers, got %d", clock.Timers())
+		}
+	})
+
+	t.Run("should fire a ticker once per Advance and keep it scheduled", func(t *testing.T) {
+		clock := NewTestClock(start)
+		ticker := clock.newTicker(time.Second)
+		defer ticker.Stop()
+		clock.Advance(5 * time.Second)
+		<-ticker.C()
+		clock.Advance(time.Second)
+		select {
+		case at := <-ticker.C():
+			if !at.Equal(start.Add(6 * time.Second)) {
+				t.Errorf("expected to tick at 6s, got %v", at.Sub(start))
+			}
+		default:
+			t.Fatal("expected the ticker to fire again")
+		}
+		if clock.Timers() != 1 {
+			t.Errorf("expected the ticker to stay active, got %d timers", clock.Timers())
+		}
+	})
+
+	t.Run("should jump to the earliest deadline on Tick", func(t *testing.T) {
+		clock := NewTestClock(start)
+		late := clock.newTimer(5 * time.Second)
+		early := clock.newTimer(2 * time.Second)
+		if d := clock.Tick(); d != 2*time.Second {
+			t.Errorf("expected to move 2s, got %v", d)
+		}
+		<-early.C()
+		if !late.Stop() {
+			t.Error("expected the later timer to still be active")
+		}
+		if d := clock.Tick(); d != 0 || !clock.Now().Equal(start.Add(2*time.Second)) {
+			t.Errorf("expected Tick without timers not to move the clock, got %v", d)
+		}
+	})
+
+	t.Run("should fire a timer that is already due right away", func(t *testing.T) {
+		clock := NewTestClock(start)
+		select {
+		case <-clock.newTimer(0).C():
+		default:
+			t.Fatal("expected a zero timer to fire")
+		}
+	})
+
+	t.Run("should block until enough timers are active", func(t *testing.T) {
+		clock := NewTestClock(start)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			clock.newTimer(time.Second)
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := clock.BlockUntil(ctx, 1); err != nil {
+			t.Fatalf("BlockUntil failed: %v", err)
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := clock.BlockUntil(ctx, 2); err == nil {
+			t.Error("expected BlockUntil to give up with the context")
+		}
+	})
+}
+
+func TestClient_TestMode(t *testing.T) {
+	newConfig := func(baseURL string) Config {
+		config := DefaultConfig("test-key")
+		config.BaseURL = baseURL
+		config.Events.Enabled = false
+		config.Telemetry.Enabled = false
+		config.Cache.Enabled = false
+		config.TestMode.Enabled = true
+		return config
+	}
+
+	t.Run("should have no test clock outside test mode", func(t *testing.T) {
+		client, err := NewClient(Config{APIKey: "test-key"})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+		if client.TestClock() != nil {
+			t.Error("expected no test clock")
+		}
+	})
+
+	t.Run("should poll only when the clock is advanced", func(t *testing.T) {
+		var up atomic.Bool
+		var requests atomic.Int32
+		up.Store(true)
+		server := newRegionServer(true, &up, &requests)
+		defer server.Close()
+
+		config := newConfig(server.URL)
+		config.RefreshInterval = 30 * time.Second
+		client := newIntegrationClient(t, config)
+		clock := client.TestClock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := clock.BlockUntil(ctx, 1); err != nil {
+			t.Fatalf("expected the poll timer: %v", err)
+		}
+		clock.Advance(29 * time.Second)
+		if got := requests.Load(); got != 1 {
+			t.Fatalf("expected no poll before the interval, got %d requests", got)
+		}
+
+		clock.Advance(time.Second)
+		if err := clock.BlockUntil(ctx, 1); err != nil {
+			t.Fatalf("expected the next poll to be scheduled: %v", err)
+		}
+		if got := requests.Load(); got != 2 {
+			t.Errorf("expected one poll, got %d requests", got)
+		}
+	})
+
+	t.Run("should wait on the clock between retries without jitter", func(t *testing.T) {
+		var up atomic.Bool
+		var requests atomic.Int32
+		up.Store(true)
+		server := newRegionServer(true, &up, &requests)
+		defer server.Close()
+
+		config := newConfig(server.URL)
+		config.RefreshInterval = time.Hour
+		config.Retry = RetryConfig{MaxRetries: 2, BaseDelay: time.Second, MaxDelay: time.Minute, JitterFactor: 0.5}
+		client := newIntegrationClient(t, config)
+		clock := client.TestClock()
+
+		up.Store(false)
+		done := make(chan error, 1)
+		go func() { done <- client.Refresh(context.Background()) }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		for _, want := range []time.Duration{time.Second, 2 * time.Second} {
+			// The poll timer and the retry delay
+			if err := clock.BlockUntil(ctx, 2); err != nil {
+				t.Fatalf("expected a retry delay: %v", err)
+			}
+			if d := clock.Tick(); d != want {
+				t.Errorf("expected a %v retry delay, got %v", want, d)
+			}
+		}
+		if err := <-done; err == nil {
+			t.Error("expected the refresh to fail")
+		}
+		if got := requests.Load(); got != 4 {
+			t.Errorf("expected the initial fetch and 3 attempts, got %d requests", got)
+		}
+	})
+}
@@ -18,7 +18,9 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -36,15 +38,37 @@ type UserContext struct {
 	ID         string                 `json:"id"`
 	Email      string                 `json:"email,omitempty"`
 	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Contexts   []rollgate.Context     `json:"contexts,omitempty"`
+}
+
+// sdkUser converts a protocol user to the SDK's UserContext.
+func sdkUser(u *UserContext) *rollgate.UserContext {
+	user := &rollgate.UserContext{
+		ID:       u.ID,
+		Email:    u.Email,
+		Contexts: rollgate.NewMultiContext(u.Contexts...),
+	}
+	if u.Attributes != nil {
+		user.Attributes = make(map[string]any)
+		for k, v := range u.Attributes {
+			user.Attributes[k] = v
+		}
+	}
+	return user
 }
 
 // Config represents SDK initialization configuration.
 type Config struct {
-	APIKey          string `json:"apiKey"`
-	BaseURL         string `json:"baseUrl"`
-	RefreshInterval int    `json:"refreshInterval,omitempty"` // ms
-	EnableStreaming bool   `json:"enableStreaming,omitempty"`
-	Timeout         int    `json:"timeout,omitempty"` // ms
+	APIKey          string          `json:"apiKey"`
+	BaseURL         string          `json:"baseUrl"`
+	RefreshInterval int             `json:"refreshInterval,omitempty"` // ms
+	EnableStreaming bool            `json:"enableStreaming,omitempty"`
+	Timeout         int             `json:"timeout,omitempty"`         // ms
+	StartWaitTimeMs int             `json:"startWaitTimeMs,omitempty"` // ms
+	Bootstrap       map[string]bool `json:"bootstrap,omitempty"`
+
+	HonorServerHints bool     `json:"honorServerHints,omitempty"`
+	FallbackBaseURLs []string `json:"fallbackBaseUrls,omitempty"`
 }
 
 // Command represents a command sent to the test service.
@@ -62,6 +86,11 @@ type Command struct {
 	VariationID        string                 `json:"variationId,omitempty"`
 	EventValue         *float64               `json:"eventValue,omitempty"`
 	EventMetadata      map[string]interface{} `json:"eventMetadata,omitempty"`
+
+	NoEvaluationContext bool `json:"noEvaluationContext,omitempty"`
+
+	Users    []UserContext `json:"users,omitempty"`
+	Prefetch bool          `json:"prefetch,omitempty"`
 }
 
 // EvaluationReason represents the reason for a flag evaluation.
@@ -71,6 +100,8 @@ type EvaluationReason struct {
 	RuleIndex int    `json:"ruleIndex,omitempty"`
 	InRollout bool   `json:"inRollout,omitempty"`
 	ErrorKind string `json:"errorKind,omitempty"`
+
+	PrerequisiteKey string `json:"prerequisiteKey,omitempty"`
 }
 
 // Response represents a response from the test service.
@@ -88,8 +119,30 @@ type Response struct {
 	Message      string            `json:"message,omitempty"`
 	Reason          *EvaluationReason `json:"reason,omitempty"`
 	VariationID     string            `json:"variationId,omitempty"`
+	FlagVersion     int               `json:"flagVersion,omitempty"`
+	FlagUpdatedAt   string            `json:"flagUpdatedAt,omitempty"`
 	FlagCount       *int              `json:"flagCount,omitempty"`
 	EvaluationCount *int              `json:"evaluationCount,omitempty"`
+	ProcessStats    *ProcessStats     `json:"processStats,omitempty"`
+	EventStats      *EventStats       `json:"eventStats,omitempty"`
+}
+
+// ProcessStats is a resource snapshot used by the harness to detect leaks.
+type ProcessStats struct {
+	Goroutines int    `json:"goroutines"`
+	HeapBytes  uint64 `json:"heapBytes"`
+	RSSBytes   uint64 `json:"rssBytes,omitempty"`
+}
+
+// EventStats reports the event buffer and the last flush.
+type EventStats struct {
+	Buffered        int    `json:"buffered"`
+	Dropped         int64  `json:"dropped"`
+	LastFlushTime   string `json:"lastFlushTime,omitempty"`
+	LastFlushStatus int    `json:"lastFlushStatus,omitempty"`
+	LastFlushError  string `json:"lastFlushError,omitempty"`
+
+	Delivered int64 `json:"delivered"`
 }
 
 // CacheStats represents cache statistics.
@@ -200,6 +253,8 @@ func handleCommand(cmd Command) Response {
 		return handleGetValueDetail(cmd)
 	case "identify":
 		return handleIdentify(cmd)
+	case "identifyBatch":
+		return handleIdentifyBatch(cmd)
 	case "reset":
 		return handleReset(cmd)
 	case "getAllFlags":
@@ -214,6 +269,8 @@ func handleCommand(cmd Command) Response {
 		return handleFlushTelemetry(cmd)
 	case "getTelemetryStats":
 		return handleGetTelemetryStats(cmd)
+	case "getProcessStats":
+		return handleGetProcessStats(cmd)
 	case "close":
 		return handleClose(cmd)
 	default:
@@ -243,6 +300,13 @@ func handleInit(cmd Command) Response {
 
 	config.EnableStreaming = cmd.Config.EnableStreaming
 
+	if cmd.Config.StartWaitTimeMs > 0 {
+		config.StartWaitTimeout = time.Duration(cmd.Config.StartWaitTimeMs) * time.Millisecond
+	}
+	config.Bootstrap = cmd.Config.Bootstrap
+	config.HonorServerHints = cmd.Config.HonorServerHints
+	config.FallbackBaseURLs = cmd.Config.FallbackBaseURLs
+
 	// Create client
 	c, err := rollgate.NewClient(config)
 	if err != nil {
@@ -269,27 +333,29 @@ func handleInit(cmd Command) Response {
 	defer cancel()
 
 	if err := c.Initialize(ctx); err != nil {
+		c.Close()
+		return Response{Error: "InitError", Message: err.Error()}
+	}
+	// With StartWaitTimeout, Initialize returns before the flags arrive
+	if err := c.WaitForInitialization(ctx); err != nil {
+		c.Close()
 		return Response{Error: "InitError", Message: err.Error()}
 	}
 
 	// If user was provided, identify
 	if cmd.User != nil {
-		user := &rollgate.UserContext{
-			ID:    cmd.User.ID,
-			Email: cmd.User.Email,
-		}
-		if cmd.User.Attributes != nil {
-			user.Attributes = make(map[string]any)
-			for k, v := range cmd.User.Attributes {
-				user.Attributes[k] = v
-			}
-		}
-		if err := c.Identify(ctx, user); err != nil {
+		if err := c.Identify(ctx, sdkUser(cmd.User)); err != nil {
+			c.Close()
 			return Response{Error: "IdentifyError", Message: err.Error()}
 		}
 	}
 
+	// A second init replaces the client; close the old one so it stops
+	// polling into later tests
 	clientMu.Lock()
+	if client != nil {
+		client.Close()
+	}
 	client = c
 	clientMu.Unlock()
 
@@ -337,7 +403,7 @@ func handleIsEnabledDetail(cmd Command) Response {
 	}
 
 	detail := c.IsEnabledDetail(cmd.FlagKey, defaultValue)
-	return Response{
+	resp := Response{
 		Value: boolPtr(detail.Value),
 		Reason: &EvaluationReason{
 			Kind:      string(detail.Reason.Kind),
@@ -345,9 +411,16 @@ func handleIsEnabledDetail(cmd Command) Response {
 			RuleIndex: detail.Reason.RuleIndex,
 			InRollout: detail.Reason.InRollout,
 			ErrorKind: string(detail.Reason.ErrorKind),
+
+			PrerequisiteKey: detail.Reason.PrerequisiteKey,
 		},
 		VariationID: detail.VariationID,
+		FlagVersion: detail.FlagVersion,
+	}
+	if !detail.FlagUpdatedAt.IsZero() {
+		resp.FlagUpdatedAt = detail.FlagUpdatedAt.UTC().Format(time.RFC3339Nano)
 	}
+	return resp
 }
 
 func handleGetString(cmd Command) Response {
@@ -419,27 +492,47 @@ func handleGetValueDetail(cmd Command) Response {
 		return Response{Error: "ValidationError", Message: "flagKey is required"}
 	}
 
-	// For now, Go SDK only supports boolean flags with detail
-	// Return boolean detail
+	// The default's type selects the evaluation method
+	switch def := cmd.DefaultJSONValue.(type) {
+	case string:
+		detail := c.GetStringDetail(cmd.FlagKey, def)
+		return Response{StringValue: &detail.Value, Reason: toReason(detail.Reason), VariationID: detail.VariationID}
+	case float64:
+		detail := c.GetNumberDetail(cmd.FlagKey, def)
+		return Response{NumberValue: &detail.Value, Reason: toReason(detail.Reason), VariationID: detail.VariationID}
+	case map[string]interface{}, []interface{}:
+		detail := c.GetJSONDetail(cmd.FlagKey, def)
+		return Response{JSONValue: detail.Value, Reason: toReason(detail.Reason), VariationID: detail.VariationID}
+	}
+
 	defaultValue := false
 	if cmd.DefaultValue != nil {
 		defaultValue = *cmd.DefaultValue
+	} else if def, ok := cmd.DefaultJSONValue.(bool); ok {
+		defaultValue = def
 	}
 
 	detail := c.IsEnabledDetail(cmd.FlagKey, defaultValue)
 	return Response{
-		Value: boolPtr(detail.Value),
-		Reason: &EvaluationReason{
-			Kind:      string(detail.Reason.Kind),
-			RuleID:    detail.Reason.RuleID,
-			RuleIndex: detail.Reason.RuleIndex,
-			InRollout: detail.Reason.InRollout,
-			ErrorKind: string(detail.Reason.ErrorKind),
-		},
+		Value:       boolPtr(detail.Value),
+		Reason:      toReason(detail.Reason),
 		VariationID: detail.VariationID,
 	}
 }
 
+// toReason converts an SDK evaluation reason to its wire form.
+func toReason(reason rollgate.EvaluationReason) *EvaluationReason {
+	return &EvaluationReason{
+		Kind:      string(reason.Kind),
+		RuleID:    reason.RuleID,
+		RuleIndex: reason.RuleIndex,
+		InRollout: reason.InRollout,
+		ErrorKind: string(reason.ErrorKind),
+
+		PrerequisiteKey: reason.PrerequisiteKey,
+	}
+}
+
 func handleIdentify(cmd Command) Response {
 	clientMu.Lock()
 	c := client
@@ -453,21 +546,38 @@ func handleIdentify(cmd Command) Response {
 		return Response{Error: "ValidationError", Message: "user is required"}
 	}
 
-	user := &rollgate.UserContext{
-		ID:    cmd.User.ID,
-		Email: cmd.User.Email,
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.Identify(ctx, sdkUser(cmd.User)); err != nil {
+		return Response{Error: "IdentifyError", Message: err.Error()}
 	}
-	if cmd.User.Attributes != nil {
-		user.Attributes = make(map[string]any)
-		for k, v := range cmd.User.Attributes {
-			user.Attributes[k] = v
-		}
+
+	return Response{Success: boolPtr(true)}
+}
+
+func handleIdentifyBatch(cmd Command) Response {
+	clientMu.Lock()
+	c := client
+	clientMu.Unlock()
+
+	if c == nil {
+		return Response{Error: "NotInitializedError", Message: "Client not initialized"}
+	}
+
+	users := make([]rollgate.UserContext, len(cmd.Users))
+	for i := range cmd.Users {
+		users[i] = *sdkUser(&cmd.Users[i])
+	}
+	var opts []rollgate.IdentifyBatchOption
+	if cmd.Prefetch {
+		opts = append(opts, rollgate.WithPrefetch())
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := c.Identify(ctx, user); err != nil {
+	if err := c.IdentifyBatch(ctx, users, opts...); err != nil {
 		return Response{Error: "IdentifyError", Message: err.Error()}
 	}
 
@@ -521,6 +631,20 @@ func handleGetState(cmd Command) Response {
 	circuitState := c.GetCircuitState()
 	metrics := c.GetMetrics()
 
+	stats := c.GetEventStats()
+	eventStats := &EventStats{
+		Buffered:        stats.Buffered,
+		Dropped:         stats.Dropped,
+		LastFlushStatus: stats.LastFlushStatus,
+		Delivered:       stats.Delivered,
+	}
+	if !stats.LastFlushTime.IsZero() {
+		eventStats.LastFlushTime = stats.LastFlushTime.UTC().Format(time.RFC3339Nano)
+	}
+	if stats.LastFlushError != nil {
+		eventStats.LastFlushError = stats.LastFlushError.Error()
+	}
+
 	return Response{
 		IsReady:      boolPtr(c.IsReady()),
 		CircuitState: string(circuitState),
@@ -528,6 +652,7 @@ func handleGetState(cmd Command) Response {
 			Hits:   metrics.CacheHits,
 			Misses: metrics.CacheMisses,
 		},
+		EventStats: eventStats,
 	}
 }
 
@@ -540,29 +665,23 @@ func handleTrack(cmd Command) Response {
 		return Response{Error: "NotInitializedError", Message: "Client not initialized"}
 	}
 
-	if cmd.FlagKey == "" || cmd.EventName == "" || cmd.UserID == "" {
-		return Response{Error: "ValidationError", Message: "flagKey, eventName, and userId are required"}
-	}
-
-	opts := rollgate.TrackEventOptions{
-		FlagKey:   cmd.FlagKey,
-		EventName: cmd.EventName,
-		UserID:    cmd.UserID,
-	}
+	opts := rollgate.NewTrackEvent(cmd.FlagKey, cmd.EventName, cmd.UserID)
 	if cmd.VariationID != "" {
-		opts.VariationID = cmd.VariationID
+		opts = opts.WithVariation(cmd.VariationID)
 	}
 	if cmd.EventValue != nil {
-		opts.Value = cmd.EventValue
+		opts = opts.WithValue(*cmd.EventValue)
 	}
-	if cmd.EventMetadata != nil {
-		opts.Metadata = make(map[string]any)
-		for k, v := range cmd.EventMetadata {
-			opts.Metadata[k] = v
-		}
+	for k, v := range cmd.EventMetadata {
+		opts = opts.WithMetadata(k, v)
+	}
+	if cmd.NoEvaluationContext {
+		opts = opts.WithoutEvaluationContext()
 	}
 
-	c.Track(opts)
+	if err := c.TrackValidated(opts); err != nil {
+		return Response{Error: "ValidationError", Message: err.Error()}
+	}
 	return Response{Success: boolPtr(true)}
 }
 
@@ -575,7 +694,10 @@ func handleFlushEvents(cmd Command) Response {
 		return Response{Error: "NotInitializedError", Message: "Client not initialized"}
 	}
 
-	if err := c.FlushEvents(); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.FlushEvents(ctx); err != nil {
 		return Response{Error: "FlushError", Message: err.Error()}
 	}
 
@@ -591,7 +713,10 @@ func handleFlushTelemetry(cmd Command) Response {
 		return Response{Error: "NotInitializedError", Message: "Client not initialized"}
 	}
 
-	if err := c.FlushTelemetry(); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.FlushTelemetry(ctx); err != nil {
 		return Response{Error: "FlushError", Message: err.Error()}
 	}
 
@@ -611,6 +736,36 @@ func handleGetTelemetryStats(cmd Command) Response {
 	return Response{FlagCount: &flagCount, EvaluationCount: &evaluationCount}
 }
 
+func handleGetProcessStats(cmd Command) Response {
+	// Collect first so the heap reflects live objects only
+	runtime.GC()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return Response{ProcessStats: &ProcessStats{
+		Goroutines: runtime.NumGoroutine(),
+		HeapBytes:  mem.HeapAlloc,
+		RSSBytes:   residentSetSize(),
+	}}
+}
+
+// residentSetSize reads the RSS from /proc; it returns 0 where /proc is unavailable.
+func residentSetSize() uint64 {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return pages * uint64(os.Getpagesize())
+}
+
 func handleClose(cmd Command) Response {
 	clientMu.Lock()
 	if client != nil {
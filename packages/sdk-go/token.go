@@ -0,0 +1,104 @@
+package rollgate
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenProvider returns an SDK token and the time it expires, for
+// deployments that issue time-limited tokens instead of a static APIKey. A
+// zero expiresAt means the token does not expire.
+type TokenProvider func(ctx context.Context) (token string, expiresAt time.Time, err error)
+
+// tokenRefreshMargin is how long before expiry a token is replaced, so
+// requests in flight never carry an expired one.
+const tokenRefreshMargin = 30 * time.Second
+
+// tokenSource supplies the token of every request: the static API key, or
+// the TokenProvider's token, cached until tokenRefreshMargin before it
+// expires.
+type tokenSource struct {
+	apiKey   string
+	provider TokenProvider
+
+	mu        sync.Mutex // Held while the provider runs, so callers share one refresh
+	token     string
+	expiresAt time.Time
+}
+
+func newTokenSource(apiKey string, provider TokenProvider) *tokenSource {
+	return &tokenSource{apiKey: apiKey, provider: provider}
+}
+
+// configured reports whether requests can be authorized at all.
+func (ts *tokenSource) configured() bool {
+	return ts.apiKey != "" || ts.provider != nil
+}
+
+// Token returns a token that is valid for at least tokenRefreshMargin,
+// asking the provider for a new one when needed. If the provider fails
+// while the cached token has not expired yet, the cached token is used.
+func (ts *tokenSource) Token(ctx context.Context) (string, error) {
+	if ts.provider == nil {
+		return ts.apiKey, nil
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	now := time.Now()
+	if ts.token != "" && (ts.expiresAt.IsZero() || now.Before(ts.expiresAt.Add(-tokenRefreshMargin))) {
+		return ts.token, nil
+	}
+
+	token, expiresAt, err := ts.provider(ctx)
+	if err == nil && token == "" {
+		err = ErrInvalidAPIKey
+	}
+	if err != nil {
+		if ts.token != "" && (ts.expiresAt.IsZero() || now.Before(ts.expiresAt)) {
+			return ts.token, nil
+		}
+		return "", &AuthenticationError{RollgateError: RollgateError{
+			Message:  "token provider failed",
+			Category: ErrorCategoryAuth,
+			Cause:    err,
+		}}
+	}
+	ts.token, ts.expiresAt = token, expiresAt
+	return token, nil
+}
+
+// invalidate drops token after the server rejected it, so the next request
+// asks the provider again instead of waiting for the expiry.
+func (ts *tokenSource) invalidate(token string) {
+	if ts.provider == nil {
+		return
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.token == token {
+		ts.token = ""
+	}
+}
+
+// authorize sets the Authorization header of req.
+func (ts *tokenSource) authorize(req *http.Request) error {
+	token, err := ts.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// rejected invalidates the token a request was authorized with after the
+// server answered it with 401.
+func (ts *tokenSource) rejected(req *http.Request) {
+	if req != nil {
+		ts.invalidate(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+	}
+}
@@ -0,0 +1,171 @@
+package rollgate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingProvider is a TokenProvider that hands out "token-1", "token-2",
+// ... each valid for ttl, failing while fail is set.
+type countingProvider struct {
+	mu    sync.Mutex
+	calls int
+	ttl   time.Duration
+	fail  error
+}
+
+func (p *countingProvider) provide(ctx context.Context) (string, time.Time, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.fail != nil {
+		return "", time.Time{}, p.fail
+	}
+	return fmt.Sprintf("token-%d", p.calls), time.Now().Add(p.ttl), nil
+}
+
+func (p *countingProvider) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
+
+func TestTokenSource(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return the static API key without a provider", func(t *testing.T) {
+		ts := newTokenSource("static-key", nil)
+		if token, err := ts.Token(ctx); err != nil || token != "static-key" {
+			t.Errorf("expected static-key, got %q, %v", token, err)
+		}
+	})
+
+	t.Run("should cache the provider's token until it nears expiry", func(t *testing.T) {
+		p := &countingProvider{ttl: time.Hour}
+		ts := newTokenSource("", p.provide)
+		for i := 0; i < 3; i++ {
+			if token, err := ts.Token(ctx); err != nil || token != "token-1" {
+				t.Fatalf("expected token-1, got %q, %v", token, err)
+			}
+		}
+		if p.count() != 1 {
+			t.Errorf("expected 1 provider call, got %d", p.count())
+		}
+	})
+
+	t.Run("should refresh a token within the refresh margin", func(t *testing.T) {
+		p := &countingProvider{ttl: tokenRefreshMargin / 2}
+		ts := newTokenSource("", p.provide)
+		ts.Token(ctx)
+		if token, _ := ts.Token(ctx); token != "token-2" {
+			t.Errorf("expected a refreshed token, got %q", token)
+		}
+	})
+
+	t.Run("should keep an unexpired token when the provider fails", func(t *testing.T) {
+		p := &countingProvider{ttl: tokenRefreshMargin / 2}
+		ts := newTokenSource("", p.provide)
+		ts.Token(ctx)
+		p.fail = errors.New("issuer unavailable")
+		if token, err := ts.Token(ctx); err != nil || token != "token-1" {
+			t.Errorf("expected the cached token, got %q, %v", token, err)
+		}
+	})
+
+	t.Run("should return an AuthenticationError without a usable token", func(t *testing.T) {
+		cause := errors.New("issuer unavailable")
+		ts := newTokenSource("", (&countingProvider{fail: cause}).provide)
+		_, err := ts.Token(ctx)
+		var authErr *AuthenticationError
+		if !errors.As(err, &authErr) || !errors.Is(err, cause) {
+			t.Errorf("expected an AuthenticationError wrapping the cause, got %v", err)
+		}
+	})
+
+	t.Run("should ask the provider again after a rejection", func(t *testing.T) {
+		p := &countingProvider{ttl: time.Hour}
+		ts := newTokenSource("", p.provide)
+		ts.Token(ctx)
+		ts.invalidate("token-1")
+		if token, _ := ts.Token(ctx); token != "token-2" {
+			t.Errorf("expected a new token, got %q", token)
+		}
+	})
+}
+
+func TestClient_TokenProvider(t *testing.T) {
+	m := newMockServer(t, &mockFlag{Key: "beta", Enabled: true, Rollout: 100})
+	ctx := context.Background()
+
+	// provider returns each of tokens once, then repeats the last one
+	provider := func(tokens ...string) (TokenProvider, func() int) {
+		var mu sync.Mutex
+		calls := 0
+		return func(ctx context.Context) (string, time.Time, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				token := tokens[min(calls, len(tokens)-1)]
+				calls++
+				return token, time.Now().Add(time.Hour), nil
+			}, func() int {
+				mu.Lock()
+				defer mu.Unlock()
+				return calls
+			}
+	}
+
+	t.Run("should authorize every transport with the provider's token", func(t *testing.T) {
+		config := m.config()
+		config.APIKey = ""
+		config.EnableStreaming = true
+		config.User = &UserContext{ID: "user-1"}
+		var calls func() int
+		config.TokenProvider, calls = provider(m.apiKey)
+		client := newIntegrationClient(t, config)
+
+		if err := client.Identify(ctx, &UserContext{ID: "user-2"}); err != nil {
+			t.Fatalf("Identify failed: %v", err)
+		}
+		client.Track(TrackEventOptions{FlagKey: "beta", EventName: "checkout", UserID: "user-2"})
+		if err := client.FlushEvents(ctx); err != nil {
+			t.Fatalf("FlushEvents failed: %v", err)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for len(m.streamUsers()) == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for the stream to connect")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if calls() != 1 {
+			t.Errorf("expected the token to be cached, got %d provider calls", calls())
+		}
+	})
+
+	t.Run("should fetch a new token after a 401", func(t *testing.T) {
+		config := m.config()
+		config.APIKey = ""
+		config.User = &UserContext{ID: "user-1"}
+		var calls func() int
+		config.TokenProvider, calls = provider("revoked-key", m.apiKey)
+		client, err := NewClient(config)
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+
+		if err := client.Init(ctx); ClassifyError(err).Category != ErrorCategoryAuth {
+			t.Fatalf("expected the revoked token to be rejected, got %v", err)
+		}
+		if err := client.Init(ctx); err != nil {
+			t.Fatalf("Init with a new token failed: %v", err)
+		}
+		if !client.IsEnabled("beta", false) || calls() != 2 {
+			t.Errorf("expected flags fetched with a second token, got %d provider calls", calls())
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package rollgate
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes the connection pool of the default HTTP transport.
+// It is ignored when Config.HTTPTransport is set. Zero fields take their
+// defaults individually.
+type TransportConfig struct {
+	// MaxIdleConns is the maximum number of idle connections across all hosts (default: 100)
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost is the maximum number of idle connections kept per host (default: 10)
+	// The Go default of 2 causes frequent re-dials when refreshes, events and telemetry overlap.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection stays in the pool (default: 90s)
+	IdleConnTimeout time.Duration
+
+	// KeepAlive is the TCP keep-alive period for new connections (default: 30s)
+	KeepAlive time.Duration
+
+	// ForceHTTP2 attempts HTTP/2 on TLS connections so requests are multiplexed
+	// over one long-lived connection (default: true when nil)
+	ForceHTTP2 *bool
+}
+
+// DefaultTransportConfig returns default transport settings.
+func DefaultTransportConfig() TransportConfig {
+	forceHTTP2 := true
+	return TransportConfig{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		KeepAlive:           30 * time.Second,
+		ForceHTTP2:          &forceHTTP2,
+	}
+}
+
+// newTransport builds an *http.Transport from the standard library defaults
+// with the pool settings from config applied.
+func newTransport(config TransportConfig) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: config.KeepAlive,
+	}).DialContext
+	t.MaxIdleConns = config.MaxIdleConns
+	t.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	t.IdleConnTimeout = config.IdleConnTimeout
+	t.ForceAttemptHTTP2 = config.ForceHTTP2 == nil || *config.ForceHTTP2
+	return t
+}
@@ -0,0 +1,105 @@
+package rollgate
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingTransport struct {
+	requests int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestNewTransport(t *testing.T) {
+	tr := newTransport(TransportConfig{
+		MaxIdleConns:        20,
+		MaxIdleConnsPerHost: 5,
+		IdleConnTimeout:     time.Minute,
+		KeepAlive:           15 * time.Second,
+	})
+
+	if tr.MaxIdleConns != 20 || tr.MaxIdleConnsPerHost != 5 {
+		t.Errorf("unexpected pool sizes: %d/%d", tr.MaxIdleConns, tr.MaxIdleConnsPerHost)
+	}
+	if tr.IdleConnTimeout != time.Minute {
+		t.Errorf("expected idle timeout 1m, got %v", tr.IdleConnTimeout)
+	}
+	if !tr.ForceAttemptHTTP2 {
+		t.Error("expected ForceAttemptHTTP2")
+	}
+	if tr.Proxy == nil {
+		t.Error("expected proxy settings from the default transport to be kept")
+	}
+}
+
+func TestClient_TransportConfig(t *testing.T) {
+	t.Run("should apply defaults for the default transport", func(t *testing.T) {
+		client, err := NewClient(Config{APIKey: "test-key", Transport: TransportConfig{MaxIdleConns: 7}})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+
+		tr, ok := client.client.Transport.(*http.Transport)
+		if !ok {
+			t.Fatalf("expected *http.Transport, got %T", client.client.Transport)
+		}
+		if tr.MaxIdleConns != 7 {
+			t.Errorf("expected MaxIdleConns 7, got %d", tr.MaxIdleConns)
+		}
+		if tr.MaxIdleConnsPerHost != DefaultTransportConfig().MaxIdleConnsPerHost {
+			t.Errorf("expected default MaxIdleConnsPerHost, got %d", tr.MaxIdleConnsPerHost)
+		}
+		if !tr.ForceAttemptHTTP2 {
+			t.Error("setting one field must keep the ForceHTTP2 default")
+		}
+	})
+
+	t.Run("should allow disabling HTTP/2", func(t *testing.T) {
+		disabled := false
+		client, err := NewClient(Config{APIKey: "test-key", Transport: TransportConfig{ForceHTTP2: &disabled}})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+
+		if tr := client.client.Transport.(*http.Transport); tr.ForceAttemptHTTP2 {
+			t.Error("expected ForceAttemptHTTP2 to be disabled")
+		}
+		if tr := client.client.Transport.(*http.Transport); tr.MaxIdleConns != DefaultTransportConfig().MaxIdleConns {
+			t.Errorf("expected default MaxIdleConns, got %d", tr.MaxIdleConns)
+		}
+	})
+
+	t.Run("should use an injected transport", func(t *testing.T) {
+		server := newTestServer(map[string]bool{"flag-a": true})
+		defer server.Close()
+
+		custom := &countingTransport{}
+		client, err := NewClient(Config{
+			APIKey:          "test-key",
+			BaseURL:         server.URL,
+			RefreshInterval: time.Hour,
+			HTTPTransport:   custom,
+		})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+
+		if err := client.Init(context.Background()); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		// Flags and typed flags
+		if atomic.LoadInt32(&custom.requests) != 2 {
+			t.Errorf("expected requests through injected transport, got %d", custom.requests)
+		}
+	})
+}
@@ -0,0 +1,334 @@
+package rollgate
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Flag types reported by the V2 flags endpoint.
+const (
+	FlagTypeBoolean = "boolean"
+	FlagTypeString  = "string"
+	FlagTypeNumber  = "number"
+	FlagTypeJSON    = "json"
+)
+
+// FlagTypeDuration is the flag type GetDuration asks for. It matches both
+// string and number flags.
+const FlagTypeDuration = "duration"
+
+// typedFlag is a flag value from the V2 flags endpoint.
+type typedFlag struct {
+	Type        string
+	Value       any
+	Enabled     bool
+	Reason      *EvaluationReason
+	VariationID string
+	Metadata    FlagMetadata
+}
+
+// fetchTypedFlags fetches typed values from the V2 flags endpoint through
+// the same circuit breaker, retry and metrics path as the boolean flags. It
+// is a best-effort companion to fetchFlags: failures are logged and leave
+// the previous typed values in place, so boolean flags never depend on it.
+// A server without the endpoint (404) is not asked again.
+func (c *Client) fetchTypedFlags(ctx context.Context) {
+	attempt, err := c.executeFetch(ctx, c.baseURL(ctx)+"/api/v1/sdk/v2/flags", c.doFetchTypedRequest)
+	if attempt.statusCode == http.StatusNotFound {
+		c.mu.Lock()
+		c.typedUnsupported = true
+		c.mu.Unlock()
+		c.config.Logger.Info("server does not serve typed flags, string, number and JSON flags return defaults")
+		return
+	}
+	if err != nil && c.ctx.Err() == nil {
+		c.config.Logger.Warn("failed to fetch typed flags", "error", err)
+	}
+}
+
+// refreshTypedFlags refetches typed values in the background. SSE events
+// only carry boolean values, so streaming clients call it on every flag
+// update; updates that arrive during a fetch trigger one more fetch.
+func (c *Client) refreshTypedFlags() {
+	c.mu.Lock()
+	if c.typedUnsupported || c.closed {
+		c.mu.Unlock()
+		return
+	}
+	if c.typedRefreshing {
+		c.typedRefreshPending = true
+		c.mu.Unlock()
+		return
+	}
+	c.typedRefreshing = true
+	c.mu.Unlock()
+
+	go func() {
+		for {
+			ctx, cancel := context.WithTimeout(c.ctx, c.config.Timeout)
+			c.fetchTypedFlags(ctx)
+			cancel()
+
+			c.mu.Lock()
+			if !c.typedRefreshPending || c.typedUnsupported || c.closed {
+				c.typedRefreshing = false
+				c.typedRefreshPending = false
+				c.mu.Unlock()
+				return
+			}
+			c.typedRefreshPending = false
+			c.mu.Unlock()
+		}
+	}()
+}
+
+// doFetchTypedRequest performs one V2 flags request. A 404 is not an error
+// so that a server without the endpoint does not count against the circuit
+// breaker; fetchTypedFlags checks the status instead.
+func (c *Client) doFetchTypedRequest(ctx context.Context, attempt *fetchAttempt) error {
+	*attempt = fetchAttempt{}
+
+	u, err := url.Parse(c.baseURL(ctx) + "/api/v1/sdk/v2/flags")
+	if err != nil {
+		return NewNetworkError("invalid URL", err)
+	}
+
+	c.mu.RLock()
+	user := c.user
+	userID := c.userID()
+	etag := c.typedETag
+	c.mu.RUnlock()
+	q := u.Query()
+	if userID != "" {
+		q.Set("user_id", userID)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return NewNetworkError("failed to create request", err)
+	}
+	setRequestHeaders(req, c.config.CustomHeaders)
+	if err := c.tokens.authorize(req); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setUserContextHeader(req, user)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+		attempt.etagSent = true
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return requestError(err)
+	}
+	defer resp.Body.Close()
+
+	attempt.statusCode = resp.StatusCode
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified, http.StatusNotFound:
+		return nil
+	default:
+		return c.handleErrorResponse(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return NewNetworkError("failed to read response", err)
+	}
+	parseStart := time.Now()
+	flags, skipped, err := parseTypedFlagsPayload(body)
+	c.metrics.RecordPayload(len(body), time.Since(parseStart))
+	if err != nil {
+		c.metrics.RecordPayloadError(1)
+		return NewMalformedResponseError("failed to parse typed flags", err)
+	}
+	if skipped > 0 {
+		c.metrics.RecordPayloadError(skipped)
+		c.config.Logger.Warn("skipped invalid entries in typed flags payload", "count", skipped)
+	}
+
+	// Values fetched for a user the client has since moved away from are
+	// dropped; Identify and Reset fetch again for the new user.
+	c.mu.Lock()
+	if c.userID() == userID && !c.heldByFreeze() {
+		c.typedFlags = flags
+		c.typedETag = resp.Header.Get("ETag")
+	}
+	c.mu.Unlock()
+	c.checkConsistency(updateSourceTyped, true)
+	return nil
+}
+
+// evaluateTyped evaluates a string, number or JSON flag. A flag that is not
+// enabled for the user serves the default. convert reports whether the
+// served value is usable as T; a value it rejects returns the default with
+// an ERROR reason of kind MALFORMED_FLAG.
+func evaluateTyped[T any](c *Client, flagKey, flagType string, defaultValue T, convert func(any) (T, bool)) EvaluationDetail[T] {
+	return withHooks(c, flagKey, flagType, defaultValue, &evalOptions{}, func() EvaluationDetail[T] {
+		detail := evaluateTypedFlag(c, flagKey, flagType, defaultValue, convert)
+		detail.Reason.Frozen = c.frozen()
+		detail.Reason.RateLimited = c.rateLimited()
+		return detail
+	})
+}
+
+func evaluateTypedFlag[T any](c *Client, flagKey, flagType string, defaultValue T, convert func(any) (T, bool)) EvaluationDetail[T] {
+	start := time.Now()
+	defer func() {
+		c.metrics.RecordEvaluation(time.Since(start).Nanoseconds())
+	}()
+	if c.metrics.RecordFlagEvaluation(flagKey) {
+		c.config.Logger.Warn("per-flag metrics label limit reached, further flags are counted as "+OtherFlagLabel,
+			"flag", flagKey, "maxFlags", c.config.FlagMetrics.MaxFlags)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	c.markEvaluated(flagKey)
+	if override, ok := c.overriddenValue(flagKey); ok {
+		if value, ok := convert(override); ok {
+			return EvaluationDetail[T]{Value: value, Reason: overrideReason()}
+		}
+		return EvaluationDetail[T]{Value: defaultValue, Reason: ErrorReason(ErrorWrongType)}
+	}
+	if bootstrap, ok := c.bootstrapValue(flagKey); ok {
+		if value, ok := convert(bootstrap); ok {
+			return EvaluationDetail[T]{Value: value, Reason: BootstrapReason()}
+		}
+		return EvaluationDetail[T]{Value: defaultValue, Reason: ErrorReason(ErrorWrongType)}
+	}
+	if !c.ready {
+		kind := c.notReadyKind()
+		c.recordDefault(flagKey, kind)
+		return EvaluationDetail[T]{Value: defaultValue, Reason: ErrorReason(kind)}
+	}
+
+	flag, ok := c.typedFlags[flagKey]
+	if !ok {
+		reason := UnknownReason()
+		if c.malformed {
+			reason = ErrorReason(ErrorMalformedResponse)
+		}
+		c.recordDefault(flagKey, defaultCause(reason))
+		return EvaluationDetail[T]{Value: defaultValue, Reason: reason}
+	}
+	// Telemetry counts evaluations of known flags, errors included
+	recordTelemetry := func(reason EvaluationReason, variationID string) {
+		if c.telemetryCollector != nil {
+			c.telemetryCollector.RecordEvaluationDetail(flagKey, flag.Enabled, reason, variationID)
+		}
+	}
+	if flag.Type != flagType && !(flagType == FlagTypeDuration && (flag.Type == FlagTypeString || flag.Type == FlagTypeNumber)) {
+		recordTelemetry(ErrorReason(ErrorWrongType), "")
+		return EvaluationDetail[T]{Value: defaultValue, Reason: ErrorReason(ErrorWrongType)}
+	}
+
+	reason := FallthroughReason(false)
+	if flag.Reason != nil {
+		reason = *flag.Reason
+	} else if !flag.Enabled {
+		reason = OffReason()
+	}
+	if !flag.Enabled {
+		recordTelemetry(reason, flag.VariationID)
+		return withMetadata(EvaluationDetail[T]{Value: defaultValue, Reason: reason}, flag.Metadata)
+	}
+	value, ok := convert(flag.Value)
+	if !ok {
+		recordTelemetry(ErrorReason(ErrorMalformedFlag), "")
+		return withMetadata(EvaluationDetail[T]{Value: defaultValue, Reason: ErrorReason(ErrorMalformedFlag)}, flag.Metadata)
+	}
+	recordTelemetry(reason, flag.VariationID)
+
+	c.recordServed(flagKey, flag.Value, flag.VariationID, reason, "")
+	return withMetadata(EvaluationDetail[T]{Value: value, Reason: reason, VariationID: flag.VariationID}, flag.Metadata)
+}
+
+// GetStringDetail returns a string flag value along with the evaluation
+// reason. A flag of another type returns defaultValue with an ERROR reason
+// of kind WRONG_TYPE.
+func (c *Client) GetStringDetail(flagKey string, defaultValue string) EvaluationDetail[string] {
+	defaultValue = c.stringDefault(flagKey, defaultValue)
+	return evaluateTyped(c, flagKey, FlagTypeString, defaultValue, func(v any) (string, bool) {
+		s, ok := v.(string)
+		return s, ok
+	})
+}
+
+// GetNumberDetail returns a numeric flag value along with the evaluation
+// reason. A flag of another type returns defaultValue with an ERROR reason
+// of kind WRONG_TYPE.
+func (c *Client) GetNumberDetail(flagKey string, defaultValue float64) EvaluationDetail[float64] {
+	defaultValue = c.numberDefault(flagKey, defaultValue)
+	return evaluateTyped(c, flagKey, FlagTypeNumber, defaultValue, func(v any) (float64, bool) {
+		n, ok := v.(float64)
+		return n, ok
+	})
+}
+
+// GetIntDetail returns a numeric flag value as an int along with the
+// evaluation reason. A value with a fractional part or out of the int range
+// returns defaultValue with an ERROR reason of kind MALFORMED_FLAG, and a
+// flag of another type one of kind WRONG_TYPE.
+func (c *Client) GetIntDetail(flagKey string, defaultValue int) EvaluationDetail[int] {
+	defaultValue = c.intDefault(flagKey, defaultValue)
+	return evaluateTyped(c, flagKey, FlagTypeNumber, defaultValue, func(v any) (int, bool) {
+		n, ok := v.(float64)
+		return intValue(n, ok)
+	})
+}
+
+// GetDurationDetail returns a duration flag value along with the evaluation
+// reason. String flags hold a time.ParseDuration string such as "500ms" or
+// "2h", and number flags a number of milliseconds. An unparseable or
+// negative duration returns defaultValue with an ERROR reason of kind
+// MALFORMED_FLAG, and a flag of another type one of kind WRONG_TYPE.
+func (c *Client) GetDurationDetail(flagKey string, defaultValue time.Duration) EvaluationDetail[time.Duration] {
+	defaultValue = c.durationDefault(flagKey, defaultValue)
+	return evaluateTyped(c, flagKey, FlagTypeDuration, defaultValue, durationValue)
+}
+
+// intValue converts a float64 to an int if it is a whole number in range.
+func intValue(n float64, ok bool) (int, bool) {
+	if !ok || n != math.Trunc(n) || n < math.MinInt || n >= -math.MinInt {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// durationValue converts a duration flag value: a time.ParseDuration string
+// or a number of milliseconds. Negative and out of range durations are
+// rejected.
+func durationValue(v any) (time.Duration, bool) {
+	switch v := v.(type) {
+	case string:
+		d, err := time.ParseDuration(v)
+		return d, err == nil && d >= 0
+	case float64:
+		ns := v * float64(time.Millisecond)
+		if ns < 0 || ns >= math.MaxInt64 {
+			return 0, false
+		}
+		return time.Duration(ns), true
+	default:
+		return 0, false
+	}
+}
+
+// GetJSONDetail returns a JSON flag value along with the evaluation reason.
+// Objects decode to map[string]interface{} as with encoding/json. A flag of
+// another type returns defaultValue with an ERROR reason of kind WRONG_TYPE.
+func (c *Client) GetJSONDetail(flagKey string, defaultValue interface{}) EvaluationDetail[interface{}] {
+	defaultValue = c.jsonDefault(flagKey, defaultValue)
+	return evaluateTyped(c, flagKey, FlagTypeJSON, defaultValue, func(v any) (interface{}, bool) {
+		return v, v != nil
+	})
+}
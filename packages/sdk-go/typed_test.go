@@ -0,0 +1,524 @@
+package rollgate
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const typedFlagsPayload = `{"flags":{
+	"enabled-flag":{"key":"enabled-flag","type":"boolean","value":true,"enabled":true,"reason":{"kind":"FALLTHROUGH"}},
+	"banner-text":{"key":"banner-text","type":"string","value":"Welcome","enabled":true,"reason":{"kind":"FALLTHROUGH","variationId":"welcome"}},
+	"max-items":{"key":"max-items","type":"number","value":10,"enabled":true,"reason":{"kind":"RULE_MATCH","ruleId":"pro","variationId":"ten"}},
+	"config":{"key":"config","type":"json","value":{"theme":"dark"},"enabled":true,"reason":{"kind":"FALLTHROUGH"}},
+	"kill-switch":{"key":"kill-switch","type":"json","value":false,"enabled":true,"reason":{"kind":"FALLTHROUGH"}},
+	"old-banner":{"key":"old-banner","type":"string","value":false,"enabled":false,"reason":{"kind":"OFF"}},
+	"broken":{"key":"broken","type":"color","value":"red","enabled":true}
+}}`
+
+// newTypedServer serves both flags endpoints and counts typed flags requests.
+func newTypedServer(t *testing.T, typedRequests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			w.Write([]byte(`{"flags":{"enabled-flag":true,"banner-text":true,"max-items":true,"config":true,"old-banner":false}}`))
+		case "/api/v1/sdk/v2/flags":
+			typedRequests.Add(1)
+			w.Write([]byte(typedFlagsPayload))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_TypedFlags(t *testing.T) {
+	var typedRequests atomic.Int32
+	server := newTypedServer(t, &typedRequests)
+
+	client, err := NewClient(Config{APIKey: "test-key", BaseURL: server.URL, RefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if got := client.GetStringDetail("banner-text", "default").Reason.ErrorKind; got != ErrorClientNotReady {
+		t.Errorf("expected CLIENT_NOT_READY before Init, got %q", got)
+	}
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	str := client.GetStringDetail("banner-text", "default")
+	if str.Value != "Welcome" || str.Reason.Kind != ReasonFallthrough || str.VariationID != "welcome" {
+		t.Errorf("unexpected string detail: %+v", str)
+	}
+	num := client.GetNumberDetail("max-items", 1)
+	if num.Value != 10 || num.Reason.Kind != ReasonRuleMatch || num.Reason.RuleID != "pro" || num.VariationID != "ten" {
+		t.Errorf("unexpected number detail: %+v", num)
+	}
+	if got := client.GetJSON("config", nil); !reflect.DeepEqual(got, map[string]interface{}{"theme": "dark"}) {
+		t.Errorf("expected JSON object, got %#v", got)
+	}
+	if got := client.GetJSONDetail("kill-switch", true); got.Value != false || got.Reason.Kind != ReasonFallthrough {
+		t.Errorf("expected the served JSON false, got %+v", got)
+	}
+
+	// A disabled flag serves the default with its reason
+	off := client.GetStringDetail("old-banner", "classic")
+	if off.Value != "classic" || off.Reason.Kind != ReasonOff {
+		t.Errorf("expected default with OFF reason, got %+v", off)
+	}
+
+	// An invalid entry is skipped rather than failing the payload
+	if got := client.GetStringDetail("broken", "fallback"); got.Value != "fallback" || got.Reason.Kind != ReasonUnknown {
+		t.Errorf("expected invalid entry to be unknown, got %+v", got)
+	}
+	if got := client.GetString("missing", "fallback"); got != "fallback" {
+		t.Errorf("expected default for missing flag, got %q", got)
+	}
+
+	// Registered defaults apply to typed flags too
+	client.RegisterDefaults(map[string]any{"missing-number": 3})
+	if got := client.GetNumber("missing-number", 0); got != 3 {
+		t.Errorf("expected registered default, got %v", got)
+	}
+
+	if n := typedRequests.Load(); n != 1 {
+		t.Errorf("expected 1 typed flags request after Init, got %d", n)
+	}
+}
+
+func TestClient_TypedFlagsUnused(t *testing.T) {
+	var typedRequests atomic.Int32
+	server := newTypedServer(t, &typedRequests)
+
+	client, err := NewClient(Config{APIKey: "test-key", BaseURL: server.URL, RefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	// kill-switch is only served by the typed flags endpoint
+	if !reflect.DeepEqual(client.UnusedFlags(), []string{"banner-text", "config", "enabled-flag", "kill-switch", "max-items", "old-banner"}) {
+		t.Errorf("expected typed flags to be listed as unused, got %v", client.UnusedFlags())
+	}
+	client.GetJSON("kill-switch", nil)
+	client.GetString("banner-text", "")
+	if !reflect.DeepEqual(client.UnusedFlags(), []string{"config", "enabled-flag", "max-items", "old-banner"}) {
+		t.Errorf("expected evaluated typed flags to be used, got %v", client.UnusedFlags())
+	}
+}
+
+func TestClient_TypedFlagsExposure(t *testing.T) {
+	var typedRequests atomic.Int32
+	server := newTypedServer(t, &typedRequests)
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		User:            &UserContext{ID: "user-1"},
+		Exposure:        ExposureConfig{Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	client.GetString("banner-text", "default")
+	client.GetJSON("config", nil)
+	client.GetString("old-banner", "classic")
+
+	client.eventCollector.mu.Lock()
+	defer client.eventCollector.mu.Unlock()
+	variations := map[string]string{}
+	for _, event := range client.eventCollector.buffer {
+		variations[event.FlagKey] = event.VariationID
+	}
+	want := map[string]string{"banner-text": "welcome", "config": `{"theme":"dark"}`}
+	if !reflect.DeepEqual(variations, want) {
+		t.Errorf("expected exposures with the served variations %v, got %v", want, variations)
+	}
+}
+
+func TestClient_TypedFlagsWrongType(t *testing.T) {
+	var typedRequests atomic.Int32
+	server := newTypedServer(t, &typedRequests)
+
+	client, err := NewClient(Config{APIKey: "test-key", BaseURL: server.URL, RefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		detail EvaluationDetail[any]
+		want   any
+	}{
+		{"string on number flag", asAnyDetail(client.GetStringDetail("max-items", "default")), "default"},
+		{"number on string flag", asAnyDetail(client.GetNumberDetail("banner-text", 5)), 5.0},
+		{"JSON on boolean flag", client.GetJSONDetail("enabled-flag", "default"), "default"},
+		{"string on JSON flag", asAnyDetail(client.GetStringDetail("config", "default")), "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.detail.Value != tt.want {
+				t.Errorf("expected default %v, got %v", tt.want, tt.detail.Value)
+			}
+			if tt.detail.Reason.Kind != ReasonError || tt.detail.Reason.ErrorKind != ErrorWrongType {
+				t.Errorf("expected ERROR/WRONG_TYPE, got %+v", tt.detail.Reason)
+			}
+		})
+	}
+}
+
+func TestClient_TypedFlagsRefetch(t *testing.T) {
+	var typedRequests atomic.Int32
+	server := newTypedServer(t, &typedRequests)
+
+	client, err := NewClient(Config{APIKey: "test-key", BaseURL: server.URL, RefreshInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := client.Identify(ctx, &UserContext{ID: "user-1"}); err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+	if n := typedRequests.Load(); n != 2 {
+		t.Errorf("expected typed flags to be refetched for the new user, got %d requests", n)
+	}
+}
+
+func TestClient_TypedFlagsUserChange(t *testing.T) {
+	var typedRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			w.Write([]byte(`{"flags":{"banner-text":true}}`))
+		case "/api/v1/sdk/v2/flags":
+			typedRequests.Add(1)
+			// Only user-1 is targeted with a banner
+			if r.URL.Query().Get("user_id") == "user-1" {
+				w.Write([]byte(`{"flags":{"banner-text":{"type":"string","value":"Hello user-1","enabled":true}}}`))
+				return
+			}
+			w.Write([]byte(`{"flags":{}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		User:            &UserContext{ID: "user-1"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if got := client.GetString("banner-text", "default"); got != "Hello user-1" {
+		t.Fatalf("expected user-1's banner, got %q", got)
+	}
+
+	if err := client.Identify(ctx, &UserContext{ID: "user-2"}); err != nil {
+		t.Fatalf("Identify failed: %v", err)
+	}
+	if got := client.GetString("banner-text", "default"); got != "default" {
+		t.Errorf("expected user-2 not to see user-1's banner, got %q", got)
+	}
+	if err := client.Reset(ctx); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if got := client.GetString("banner-text", "default"); got != "default" {
+		t.Errorf("expected no banner after Reset, got %q", got)
+	}
+	if n := typedRequests.Load(); n != 3 {
+		t.Errorf("expected typed flags to be refetched for each user, got %d requests", n)
+	}
+}
+
+func TestClient_TypedFlagsFetchPath(t *testing.T) {
+	var typedRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			w.Write([]byte(`{"flags":{"banner-text":true}}`))
+		case "/api/v1/sdk/v2/flags":
+			// The first attempt fails, the retry succeeds, and later
+			// requests revalidate with the ETag
+			switch typedRequests.Add(1) {
+			case 1:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			default:
+				if r.Header.Get("If-None-Match") == `"typed-v1"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", `"typed-v1"`)
+				w.Write([]byte(`{"flags":{"banner-text":{"type":"string","value":"Welcome","enabled":true}}}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var infos []RequestInfo
+	var mu sync.Mutex
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		Retry:           RetryConfig{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		RequestObserver: func(info RequestInfo) {
+			if strings.HasSuffix(info.Endpoint, "/v2/flags") {
+				mu.Lock()
+				infos = append(infos, info)
+				mu.Unlock()
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	// The boolean flags did not change, so force a typed refetch
+	client.mu.Lock()
+	client.clearValidators()
+	client.typedETag = `"typed-v1"`
+	client.mu.Unlock()
+	if err := client.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 typed flags requests, got %+v", infos)
+	}
+	if infos[0].Retries != 1 || infos[0].StatusCode != http.StatusOK || infos[0].Error != nil {
+		t.Errorf("expected the typed fetch to be retried, got %+v", infos[0])
+	}
+	if !infos[1].ETagSent || !infos[1].NotModified {
+		t.Errorf("expected the typed fetch to revalidate with its ETag, got %+v", infos[1])
+	}
+	if snap := client.GetMetrics(); snap.TotalRequests != 4 {
+		t.Errorf("expected typed fetches in the request metrics, got %d requests", snap.TotalRequests)
+	}
+}
+
+func TestClient_TypedFlagsStreaming(t *testing.T) {
+	var banner atomic.Value
+	banner.Store("Welcome")
+	events := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"flags":{"banner-text":true}}`))
+		case "/api/v1/sdk/v2/flags":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"flags":{"banner-text":{"type":"string","value":%q,"enabled":true}}}`, banner.Load())
+		case "/api/v1/sdk/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case <-r.Context().Done():
+					return
+				case event := <-events:
+					fmt.Fprint(w, event)
+					w.(http.Flusher).Flush()
+				}
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer server.CloseClientConnections()
+
+	client, err := NewClient(Config{APIKey: "test-key", BaseURL: server.URL, EnableStreaming: true})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if got := client.GetString("banner-text", "default"); got != "Welcome" {
+		t.Fatalf("expected the initial banner, got %q", got)
+	}
+
+	// SSE events carry only the boolean value; the typed value is refetched
+	banner.Store("Summer sale")
+	events <- "event: flag-changed\ndata: {\"key\":\"banner-text\",\"enabled\":true}\n\n"
+
+	deadline := time.Now().Add(2 * time.Second)
+	for client.GetString("banner-text", "default") != "Summer sale" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := client.GetString("banner-text", "default"); got != "Summer sale" {
+		t.Errorf("expected the streamed change to refetch typed values, got %q", got)
+	}
+}
+
+func TestClient_TypedFlagsUnsupported(t *testing.T) {
+	server := newTestServer(map[string]bool{"banner-text": true})
+	defer server.Close()
+
+	var typedRequests atomic.Int32
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+		RequestObserver: func(info RequestInfo) {
+			if info.Endpoint == server.URL+"/api/v1/sdk/v2/flags" {
+				typedRequests.Add(1)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Init(ctx); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := client.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	if !client.IsEnabled("banner-text", false) {
+		t.Error("boolean flags must not depend on the typed flags endpoint")
+	}
+	if got := client.GetString("banner-text", "default"); got != "default" {
+		t.Errorf("expected default without typed flags, got %q", got)
+	}
+	if n := typedRequests.Load(); n != 1 {
+		t.Errorf("expected a missing endpoint to be requested once, got %d", n)
+	}
+}
+
+// asAnyDetail widens a typed detail for table-driven assertions.
+func asAnyDetail[T any](d EvaluationDetail[T]) EvaluationDetail[any] {
+	return EvaluationDetail[any]{Value: d.Value, Reason: d.Reason, VariationID: d.VariationID}
+}
+
+func TestClient_IntAndDurationFlags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/sdk/flags":
+			w.Write([]byte(`{"flags":{}}`))
+		case "/api/v1/sdk/v2/flags":
+			w.Write([]byte(`{"flags":{
+				"max-items":{"type":"number","value":10,"enabled":true},
+				"ratio":{"type":"number","value":0.5,"enabled":true},
+				"huge":{"type":"number","value":1e300,"enabled":true},
+				"timeout":{"type":"string","value":"500ms","enabled":true},
+				"ttl-ms":{"type":"number","value":1500,"enabled":true},
+				"negative":{"type":"string","value":"-2s","enabled":true},
+				"typo":{"type":"string","value":"2 hours","enabled":true},
+				"flag-config":{"type":"json","value":{"timeout":"1s"},"enabled":true}
+			}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := newIntegrationClient(t, Config{APIKey: "test-key", BaseURL: server.URL, RefreshInterval: time.Hour})
+
+	t.Run("should return whole numbers as int", func(t *testing.T) {
+		if got := client.GetInt("max-items", 5); got != 10 {
+			t.Errorf("expected 10, got %d", got)
+		}
+	})
+
+	t.Run("should reject numbers that are not ints", func(t *testing.T) {
+		for _, key := range []string{"ratio", "huge"} {
+			detail := client.GetIntDetail(key, 5)
+			if detail.Value != 5 || detail.Reason.ErrorKind != ErrorMalformedFlag {
+				t.Errorf("%s: expected the default with MALFORMED_FLAG, got %+v", key, detail)
+			}
+		}
+		if got := client.GetIntDetail("timeout", 5).Reason.ErrorKind; got != ErrorWrongType {
+			t.Errorf("expected WRONG_TYPE for a string flag, got %q", got)
+		}
+	})
+
+	t.Run("should parse duration strings and milliseconds", func(t *testing.T) {
+		if got := client.GetDuration("timeout", time.Second); got != 500*time.Millisecond {
+			t.Errorf("expected 500ms, got %v", got)
+		}
+		if got := client.GetDuration("ttl-ms", time.Second); got != 1500*time.Millisecond {
+			t.Errorf("expected 1.5s, got %v", got)
+		}
+	})
+
+	t.Run("should reject invalid durations", func(t *testing.T) {
+		for _, key := range []string{"negative", "typo", "huge"} {
+			detail := client.GetDurationDetail(key, time.Second)
+			if detail.Value != time.Second || detail.Reason.ErrorKind != ErrorMalformedFlag {
+				t.Errorf("%s: expected the default with MALFORMED_FLAG, got %+v", key, detail)
+			}
+		}
+		if got := client.GetDurationDetail("flag-config", time.Second).Reason.ErrorKind; got != ErrorWrongType {
+			t.Errorf("expected WRONG_TYPE for a JSON flag, got %q", got)
+		}
+	})
+
+	t.Run("should use registered defaults", func(t *testing.T) {
+		client.RegisterDefaults(map[string]any{"missing-int": 7, "missing-duration": 3 * time.Second})
+		if got := client.GetInt("missing-int", 0); got != 7 {
+			t.Errorf("expected 7, got %d", got)
+		}
+		if got := client.GetDuration("missing-duration", 0); got != 3*time.Second {
+			t.Errorf("expected 3s, got %v", got)
+		}
+	})
+}
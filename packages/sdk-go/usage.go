@@ -0,0 +1,91 @@
+package rollgate
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// flagUsage records which flags were evaluated during the process lifetime.
+// Keys are only ever added, so a sync.Map keeps repeated evaluations of the
+// same flag lock-free.
+type flagUsage struct {
+	evaluated sync.Map // flag key -> struct{}
+}
+
+func newFlagUsage() *flagUsage {
+	return &flagUsage{}
+}
+
+// markEvaluated records that a flag was evaluated.
+func (u *flagUsage) markEvaluated(flagKey string) {
+	if _, ok := u.evaluated.Load(flagKey); !ok {
+		u.evaluated.Store(flagKey, struct{}{})
+	}
+}
+
+// wasEvaluated reports whether a flag was evaluated at least once.
+func (u *flagUsage) wasEvaluated(flagKey string) bool {
+	_, ok := u.evaluated.Load(flagKey)
+	return ok
+}
+
+// markEvaluated records an evaluation of a known flag: one returned by the
+// server or with a registered default. Unknown keys are not recorded, so
+// arbitrary keys cannot grow the set. Caller must hold c.mu.
+func (c *Client) markEvaluated(flagKey string) {
+	_, known := c.flags[flagKey]
+	if !known {
+		_, known = c.typedFlags[flagKey]
+	}
+	if !known {
+		_, known = c.defaults[flagKey]
+	}
+	if known {
+		c.usage.markEvaluated(flagKey)
+	}
+}
+
+// UnusedFlags returns the sorted keys of known flags that have never been
+// evaluated by this client. Known flags are those returned by the server plus
+// any registered with RegisterDefaults. Use it to find stale flags to delete.
+func (c *Client) UnusedFlags() []string {
+	c.mu.RLock()
+	known := make(map[string]struct{}, len(c.flags)+len(c.typedFlags)+len(c.defaults))
+	for k := range c.flags {
+		known[k] = struct{}{}
+	}
+	for k := range c.typedFlags {
+		known[k] = struct{}{}
+	}
+	for k := range c.defaults {
+		known[k] = struct{}{}
+	}
+	c.mu.RUnlock()
+
+	unused := make([]string, 0)
+	for k := range known {
+		if !c.usage.wasEvaluated(k) {
+			unused = append(unused, k)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// startUnusedFlagsLog periodically logs flags that have not been evaluated.
+func (c *Client) startUnusedFlagsLog(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if unused := c.UnusedFlags(); len(unused) > 0 {
+				c.config.Logger.Info("flags not evaluated since startup", "count", len(unused), "flags", unused)
+			}
+		case <-c.stopPolling:
+			return
+		}
+	}
+}
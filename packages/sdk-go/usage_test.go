@@ -0,0 +1,105 @@
+package rollgate
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *recordingLogger) record(msg string) {
+	l.mu.Lock()
+	l.messages = append(l.messages, msg)
+	l.mu.Unlock()
+}
+
+func (l *recordingLogger) Debug(msg string, args ...any) { l.record(msg) }
+func (l *recordingLogger) Info(msg string, args ...any)  { l.record(msg) }
+func (l *recordingLogger) Warn(msg string, args ...any)  { l.record(msg) }
+func (l *recordingLogger) Error(msg string, args ...any) { l.record(msg) }
+
+func (l *recordingLogger) count(msg string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, m := range l.messages {
+		if m == msg {
+			n++
+		}
+	}
+	return n
+}
+
+func TestClient_UnusedFlags(t *testing.T) {
+	server := newTestServer(map[string]bool{"flag-a": true, "flag-b": false, "flag-c": true})
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		APIKey:          "test-key",
+		BaseURL:         server.URL,
+		RefreshInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	client.RegisterDefaults(map[string]any{"local-only": "x"})
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	t.Run("should report all known flags before any evaluation", func(t *testing.T) {
+		want := []string{"flag-a", "flag-b", "flag-c", "local-only"}
+		if got := client.UnusedFlags(); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("should drop flags once evaluated", func(t *testing.T) {
+		client.IsEnabled("flag-a", false)
+		client.GetString("local-only", "")
+		client.IsEnabled("not-a-flag", false)
+
+		want := []string{"flag-b", "flag-c"}
+		if got := client.UnusedFlags(); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+		if client.usage.wasEvaluated("not-a-flag") {
+			t.Error("unknown keys must not be recorded")
+		}
+	})
+}
+
+func TestClient_UnusedFlagsPeriodicLog(t *testing.T) {
+	server := newTestServer(map[string]bool{"stale-flag": true})
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	client, err := NewClient(Config{
+		APIKey:                 "test-key",
+		BaseURL:                server.URL,
+		RefreshInterval:        time.Hour,
+		Logger:                 logger,
+		UnusedFlagsLogInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Init(context.Background()); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if logger.count("flags not evaluated since startup") == 0 {
+		t.Error("expected unused flags to be logged")
+	}
+}
@@ -0,0 +1,51 @@
+package rollgate
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInitializationTimeout is returned by WaitForInitialization when the
+// client is not initialized within StartWaitTimeout or ctx's deadline.
+var ErrInitializationTimeout = errors.New("rollgate client initialization timed out")
+
+// initAttempt is one Init call, which WaitForInitialization waits for.
+type initAttempt struct {
+	done chan struct{} // Closed once err is set
+	err  error
+}
+
+func (a *initAttempt) finish(err error) {
+	a.err = err
+	close(a.done)
+}
+
+// WaitForInitialization blocks until the latest Init call has completed,
+// and returns its error. It gives up with ErrInitializationTimeout once
+// StartWaitTimeout, if set, or ctx's deadline passes, and with ctx's error
+// if ctx is canceled; initialization goes on in the background, so callers
+// may serve defaults and wait again later. It returns ErrNotInitialized if
+// Init was never called.
+func (c *Client) WaitForInitialization(ctx context.Context) error {
+	c.mu.RLock()
+	attempt := c.initAttempt
+	c.mu.RUnlock()
+	if attempt == nil {
+		return ErrNotInitialized
+	}
+
+	if c.config.StartWaitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.StartWaitTimeout)
+		defer cancel()
+	}
+	select {
+	case <-attempt.done:
+		return attempt.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ErrInitializationTimeout
+		}
+		return ctx.Err()
+	}
+}
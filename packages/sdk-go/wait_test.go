@@ -0,0 +1,101 @@
+package rollgate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_WaitForInitialization(t *testing.T) {
+	t.Run("should fail before Init", func(t *testing.T) {
+		client, err := NewClient(Config{APIKey: "test-key"})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+		if err := client.WaitForInitialization(context.Background()); !errors.Is(err, ErrNotInitialized) {
+			t.Errorf("expected ErrNotInitialized, got %v", err)
+		}
+	})
+
+	t.Run("should return at once after a blocking Init", func(t *testing.T) {
+		server := newTestServer(map[string]bool{"beta": true})
+		defer server.Close()
+		client := newIntegrationClient(t, Config{APIKey: "test-key", BaseURL: server.URL, RefreshInterval: time.Hour})
+		if err := client.WaitForInitialization(context.Background()); err != nil {
+			t.Errorf("expected nil, got %v", err)
+		}
+	})
+
+	t.Run("should initialize in the background with StartWaitTimeout", func(t *testing.T) {
+		release := make(chan struct{})
+		var releaseOnce sync.Once
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/v1/sdk/flags" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			<-release
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"flags":{"beta":true}}`))
+		}))
+		defer server.Close()
+		defer releaseOnce.Do(func() { close(release) })
+
+		client, err := NewClient(Config{
+			APIKey:           "test-key",
+			BaseURL:          server.URL,
+			RefreshInterval:  time.Hour,
+			StartWaitTimeout: 50 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+
+		// Init's context is canceled right away, as callers usually do
+		ctx, cancel := context.WithCancel(context.Background())
+		if err := client.Init(ctx); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		cancel()
+		if client.IsReady() {
+			t.Fatal("expected Init to return before the flags arrive")
+		}
+		if err := client.WaitForInitialization(context.Background()); !errors.Is(err, ErrInitializationTimeout) {
+			t.Errorf("expected ErrInitializationTimeout, got %v", err)
+		}
+
+		releaseOnce.Do(func() { close(release) })
+		if err := client.WaitForInitialization(context.Background()); err != nil {
+			t.Fatalf("expected initialization to complete, got %v", err)
+		}
+		if !client.IsEnabled("beta", false) {
+			t.Error("expected the fetched flags")
+		}
+	})
+
+	t.Run("should return the initialization error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		client, err := NewClient(Config{APIKey: "test-key", BaseURL: server.URL, StartWaitTimeout: time.Second})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		defer client.Close()
+		if err := client.Init(context.Background()); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		err = client.WaitForInitialization(context.Background())
+		if err == nil || ClassifyError(err).Category != ErrorCategoryAuth {
+			t.Errorf("expected an authentication error, got %v", err)
+		}
+	})
+}
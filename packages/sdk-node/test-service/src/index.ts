@@ -94,6 +94,7 @@ interface Response {
   message?: string;
   flagCount?: number;
   evaluationCount?: number;
+  processStats?: { heapBytes?: number; rssBytes?: number };
 }
 
 function getRequestBody(req: IncomingMessage): Promise<string> {
@@ -422,6 +423,18 @@ async function handleCommand(cmd: Command): Promise<Response> {
       };
     }
 
+    case "getProcessStats": {
+      // heapUsed is only post-GC when node runs with --expose-gc
+      const gc = (globalThis as { gc?: () => void }).gc;
+      if (gc) {
+        gc();
+      }
+      const mem = process.memoryUsage();
+      return {
+        processStats: { heapBytes: mem.heapUsed, rssBytes: mem.rss },
+      };
+    }
+
     case "close": {
       if (client) {
         try {
@@ -11,6 +11,7 @@ Protocol:
 """
 
 import asyncio
+import gc
 import os
 import sys
 from typing import Any, Optional
@@ -36,6 +37,15 @@ shared_http_client: Optional[httpx.AsyncClient] = None
 shared_sdk_http_client: Optional[httpx.AsyncClient] = None
 
 
+def resident_set_size() -> int:
+    """Read the process RSS from /proc; 0 where /proc is unavailable."""
+    try:
+        with open("/proc/self/statm") as f:
+            return int(f.read().split()[1]) * os.sysconf("SC_PAGE_SIZE")
+    except (OSError, IndexError, ValueError):
+        return 0
+
+
 async def notify_mock_identify(user: UserContext, api_key: str) -> None:
     """Notify mock server about user context for remote evaluation."""
     global shared_http_client
@@ -359,6 +369,10 @@ async def handle_command(cmd: dict) -> dict:
         stats = client.get_telemetry_stats()
         return {"flagCount": stats["flagCount"], "evaluationCount": stats["evaluationCount"]}
 
+    elif command == "getProcessStats":
+        gc.collect()
+        return {"processStats": {"rssBytes": resident_set_size()}}
+
     elif command == "close":
         if client:
             await client.close()
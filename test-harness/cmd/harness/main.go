@@ -9,29 +9,57 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/report"
 )
 
 var (
 	mockPort = flag.Int("mock-port", 9000, "Port for mock Rollgate API server")
 	apiKey   = flag.String("api-key", "test-api-key", "API key for mock server")
+	network  = flag.String("mock-network", "localhost", "Mock listener network: localhost, ipv4, ipv6 or dual")
+	socket   = flag.String("mock-socket", "", "Also serve the mock on this unix domain socket")
 	services = flag.String("services", "", "Comma-separated list of name=url pairs (e.g., sdk-node=http://localhost:8001)")
 	scenario = flag.String("scenario", "basic", "Initial scenario to load (basic, targeting, rollout, empty)")
+	scenFile = flag.String("scenario-file", "", "YAML or JSON scenario file to load instead of -scenario (see scenarios/)")
 	verbose  = flag.Bool("verbose", false, "Enable verbose logging")
+	openapi  = flag.Bool("openapi", false, "Print the mock API OpenAPI document and exit")
+
+	reportDir = flag.String("report-dir", "", "Read go test -json contract test output from stdin, write JUnit XML and a compatibility matrix here, and exit")
+	testsDir  = flag.String("tests-dir", "internal/tests", "Contract test sources, grouped into capabilities by file for -report-dir")
 )
 
 func main() {
 	flag.Parse()
 
+	if *openapi {
+		data, _ := json.MarshalIndent(mock.NewServer(*apiKey).OpenAPI(""), "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
 	log.SetFlags(log.Ltime | log.Lmicroseconds)
 
+	if *reportDir != "" {
+		os.Exit(writeReports())
+	}
+
+	switch *network {
+	case harness.NetworkLocalhost, harness.NetworkIPv4, harness.NetworkIPv6, harness.NetworkDual:
+	default:
+		log.Fatalf("Invalid -mock-network: %s (expected localhost, ipv4, ipv6 or dual)", *network)
+	}
+
 	cfg := harness.Config{
-		MockPort: *mockPort,
-		APIKey:   *apiKey,
+		MockPort:    *mockPort,
+		MockNetwork: *network,
+		MockSocket:  *socket,
+		APIKey:      *apiKey,
 	}
 
 	h := harness.New(cfg)
@@ -59,10 +87,20 @@ func main() {
 		log.Fatalf("Failed to start mock server: %v", err)
 	}
 	log.Printf("Mock server started at %s", h.GetMockURL())
+	if h.GetMockSocket() != "" {
+		log.Printf("Mock server also listening on unix socket %s", h.GetMockSocket())
+	}
 
 	// Load initial scenario
-	h.SetScenario(*scenario)
-	log.Printf("Loaded scenario: %s", *scenario)
+	if *scenFile != "" {
+		if err := h.LoadScenarioFile(*scenFile); err != nil {
+			log.Fatalf("Failed to load scenario file: %v", err)
+		}
+		log.Printf("Loaded scenario file: %s", *scenFile)
+	} else {
+		h.SetScenario(*scenario)
+		log.Printf("Loaded scenario: %s", *scenario)
+	}
 
 	// Wait for services if specified
 	if len(h.GetServices()) > 0 {
@@ -96,6 +134,54 @@ func main() {
 	log.Println("Done")
 }
 
+// writeReports writes the -report-dir reports for the test output on stdin
+// and returns the exit code: 1 if any SDK failed a test.
+func writeReports() int {
+	names := *services
+	if names == "" {
+		names = os.Getenv("TEST_SERVICES")
+	}
+	var sdks []string
+	for _, svc := range strings.Split(names, ",") {
+		if name := strings.SplitN(strings.TrimSpace(svc), "=", 2)[0]; name != "" {
+			sdks = append(sdks, name)
+		}
+	}
+	if len(sdks) == 0 {
+		log.Fatalf("-report-dir needs the SDK names from -services or TEST_SERVICES")
+	}
+
+	capabilities, err := report.Capabilities(*testsDir)
+	if err != nil {
+		log.Printf("Tests will be grouped under %q: %v", report.OtherCapability, err)
+	}
+	results, err := report.Parse(os.Stdin, sdks, capabilities)
+	if err != nil {
+		log.Fatalf("Failed to read test results: %v", err)
+	}
+	if err := report.WriteDir(*reportDir, results); err != nil {
+		log.Fatalf("Failed to write reports: %v", err)
+	}
+
+	total := report.Count(results)
+	log.Printf("Wrote %s and %s: %d passed, %d failed, %d unsupported, %d skipped by the environment",
+		filepath.Join(*reportDir, "junit.xml"), filepath.Join(*reportDir, "compatibility.json"),
+		total.Passed, total.Failed, total.Unsupported, total.Skipped)
+	matrix := report.BuildMatrix(results)
+	for _, sdk := range matrix.SDKs {
+		if backlog := matrix.Backlog[sdk]; len(backlog) > 0 {
+			log.Printf("%s does not support %d test(s):", sdk, len(backlog))
+			for _, u := range backlog {
+				log.Printf("  [%s] %s: %s", u.Capability, u.Test, u.Reason)
+			}
+		}
+	}
+	if total.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
 func printConfig(h *harness.Harness) {
 	config := map[string]interface{}{
 		"mockUrl": h.GetMockURL(),
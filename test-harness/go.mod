@@ -2,10 +2,15 @@ module github.com/rollgate/test-harness
 
 go 1.21
 
-require github.com/stretchr/testify v1.9.0
+require (
+	github.com/rollgate/sdks/packages/sdk-go v0.0.0
+	github.com/stretchr/testify v1.9.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace github.com/rollgate/sdks/packages/sdk-go => ../packages/sdk-go
@@ -0,0 +1,284 @@
+// Package conformance defines the executable specification for flag
+// evaluation: every operator, evaluation reason and transport, with the
+// results the reference Go SDK produces against the mock server.
+//
+// The cases are exported as JSON fixtures (testdata/conformance/expected.json)
+// so authors of non-Go SDKs can check their evaluation and reason handling
+// without running the Go toolchain.
+package conformance
+
+import (
+	"fmt"
+
+	"github.com/rollgate/test-harness/internal/mock"
+)
+
+// Transport is how the SDK under test receives flags.
+type Transport string
+
+const (
+	// TransportPolling fetches flags over HTTP.
+	TransportPolling Transport = "polling"
+	// TransportStreaming receives flags over SSE after the initial fetch.
+	TransportStreaming Transport = "streaming"
+)
+
+// Transports lists every transport each case is run against.
+var Transports = []Transport{TransportPolling, TransportStreaming}
+
+// User is the user context a case is evaluated for.
+type User struct {
+	ID         string                 `json:"id"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Reason is the expected evaluation reason.
+type Reason struct {
+	Kind      string `json:"kind"`
+	RuleID    string `json:"ruleId,omitempty"`
+	RuleIndex int    `json:"ruleIndex,omitempty"`
+	InRollout bool   `json:"inRollout,omitempty"`
+}
+
+// Expected is the result an SDK must produce for a case.
+type Expected struct {
+	Value  bool   `json:"value"`
+	Reason Reason `json:"reason"`
+}
+
+// Case is a single evaluation scenario.
+type Case struct {
+	Name     string                      `json:"name"`
+	Category string                      `json:"category"` // operator, reason or segment
+	Flag     *mock.FlagState             `json:"flag,omitempty"`
+	Segments map[string][]mock.Condition `json:"segments,omitempty"`
+	FlagKey  string                      `json:"flagKey"`
+	Default  bool                        `json:"default"`
+	User     User                        `json:"user"`
+	Expected Expected                    `json:"expected"`
+}
+
+// Cases returns all conformance cases in a stable order.
+func Cases() []Case {
+	cases := operatorCases()
+	cases = append(cases, reasonCases()...)
+	cases = append(cases, segmentCases()...)
+	return cases
+}
+
+// ruleFlag returns a flag with a single enabled rule on one condition.
+func ruleFlag(key string, cond mock.Condition) *mock.FlagState {
+	return &mock.FlagState{
+		Key:     key,
+		Enabled: true,
+		Rules: []mock.Rule{
+			{ID: "rule-1", Enabled: true, Conditions: []mock.Condition{cond}, RolloutPercentage: 100},
+		},
+	}
+}
+
+func matched() Expected {
+	return Expected{Value: true, Reason: Reason{Kind: "RULE_MATCH", RuleID: "rule-1", InRollout: true}}
+}
+
+func notMatched() Expected {
+	return Expected{Value: false, Reason: Reason{Kind: "FALLTHROUGH"}}
+}
+
+func operatorCases() []Case {
+	type opCase struct {
+		op        string
+		attrValue interface{}
+		condValue interface{}
+		match     bool
+	}
+
+	ops := []opCase{
+		{"eq", "pro", "pro", true},
+		{"eq", "free", "pro", false},
+		{"neq", "pro", "free", true},
+		{"neq", "free", "free", false},
+		{"contains", "user@example.com", "example", true},
+		{"contains", "user@test.com", "example", false},
+		{"not_contains", "user@test.com", "example", true},
+		{"not_contains", "user@example.com", "example", false},
+		{"starts_with", "admin-42", "admin", true},
+		{"starts_with", "user-42", "admin", false},
+		{"ends_with", "user@example.com", "@example.com", true},
+		{"ends_with", "user@test.com", "@example.com", false},
+		{"gt", 30, 18, true},
+		{"gt", 18, 18, false},
+		{"gte", 18, 18, true},
+		{"gte", 17, 18, false},
+		{"lt", 10, 18, true},
+		{"lt", 18, 18, false},
+		{"lte", 18, 18, true},
+		{"lte", 19, 18, false},
+		{"in", "de", []interface{}{"de", "fr", "it"}, true},
+		{"in", "us", []interface{}{"de", "fr", "it"}, false},
+		{"not_in", "us", []interface{}{"de", "fr", "it"}, true},
+		{"not_in", "de", []interface{}{"de", "fr", "it"}, false},
+		{"regex", "order-1234", `^order-\d+$`, true},
+		{"regex", "order-abc", `^order-\d+$`, false},
+		{"semver_eq", "2.1.0", "2.1.0", true},
+		{"semver_eq", "2.1.1", "2.1.0", false},
+		{"semver_gt", "2.10.0", "2.9.0", true},
+		{"semver_gt", "2.9.0", "2.9.0", false},
+		{"semver_gte", "2.9.0", "2.9.0", true},
+		{"semver_gte", "2.8.9", "2.9.0", false},
+		{"semver_lt", "1.9.9", "2.0.0", true},
+		{"semver_lt", "2.0.0", "2.0.0", false},
+		{"semver_lte", "2.0.0", "2.0.0", true},
+		{"semver_lte", "2.0.1", "2.0.0", false},
+	}
+
+	cases := make([]Case, 0, len(ops)+1)
+	for i, oc := range ops {
+		suffix := "no-match"
+		expected := notMatched()
+		if oc.match {
+			suffix = "match"
+			expected = matched()
+		}
+		key := "op-" + oc.op
+		cases = append(cases, Case{
+			Name:     oc.op + "/" + suffix,
+			Category: "operator",
+			Flag:     ruleFlag(key, mock.Condition{Attribute: "attr", Operator: oc.op, Value: oc.condValue}),
+			FlagKey:  key,
+			User:     User{ID: fmt.Sprintf("op-user-%d", i), Attributes: map[string]interface{}{"attr": oc.attrValue}},
+			Expected: expected,
+		})
+	}
+
+	cases = append(cases, Case{
+		Name:     "missing-attribute/no-match",
+		Category: "operator",
+		Flag:     ruleFlag("op-missing", mock.Condition{Attribute: "plan", Operator: "neq", Value: "free"}),
+		FlagKey:  "op-missing",
+		User:     User{ID: "user-missing"},
+		Expected: notMatched(),
+	})
+	return cases
+}
+
+func reasonCases() []Case {
+	return []Case{
+		{
+			Name:     "off",
+			Category: "reason",
+			Flag:     &mock.FlagState{Key: "reason-off", Enabled: false, RolloutPercentage: 100},
+			FlagKey:  "reason-off",
+			User:     User{ID: "user-1"},
+			Expected: Expected{Value: false, Reason: Reason{Kind: "OFF"}},
+		},
+		{
+			Name:     "target-match",
+			Category: "reason",
+			Flag:     &mock.FlagState{Key: "reason-target", Enabled: true, TargetUsers: []string{"vip-1"}},
+			FlagKey:  "reason-target",
+			User:     User{ID: "vip-1"},
+			Expected: Expected{Value: true, Reason: Reason{Kind: "TARGET_MATCH"}},
+		},
+		{
+			Name:     "rule-match/second-rule",
+			Category: "reason",
+			Flag: &mock.FlagState{
+				Key:     "reason-rule-index",
+				Enabled: true,
+				Rules: []mock.Rule{
+					{ID: "beta", Enabled: true, Conditions: []mock.Condition{{Attribute: "beta", Operator: "eq", Value: "true"}}, RolloutPercentage: 100},
+					{ID: "pro", Enabled: true, Conditions: []mock.Condition{{Attribute: "plan", Operator: "eq", Value: "pro"}}, RolloutPercentage: 100},
+				},
+			},
+			FlagKey:  "reason-rule-index",
+			User:     User{ID: "user-2", Attributes: map[string]interface{}{"plan": "pro"}},
+			Expected: Expected{Value: true, Reason: Reason{Kind: "RULE_MATCH", RuleID: "pro", RuleIndex: 1, InRollout: true}},
+		},
+		{
+			Name:     "rule-match/out-of-rollout",
+			Category: "reason",
+			Flag: &mock.FlagState{
+				Key:     "reason-rule-rollout",
+				Enabled: true,
+				Rules: []mock.Rule{
+					{ID: "pro", Enabled: true, Conditions: []mock.Condition{{Attribute: "plan", Operator: "eq", Value: "pro"}}, RolloutPercentage: 0},
+				},
+				RolloutPercentage: 100,
+			},
+			FlagKey:  "reason-rule-rollout",
+			User:     User{ID: "user-3", Attributes: map[string]interface{}{"plan": "pro"}},
+			Expected: Expected{Value: false, Reason: Reason{Kind: "RULE_MATCH", RuleID: "pro"}},
+		},
+		{
+			Name:     "rule-disabled/fallthrough",
+			Category: "reason",
+			Flag: &mock.FlagState{
+				Key:     "reason-rule-disabled",
+				Enabled: true,
+				Rules: []mock.Rule{
+					{ID: "pro", Enabled: false, Conditions: []mock.Condition{{Attribute: "plan", Operator: "eq", Value: "pro"}}, RolloutPercentage: 100},
+				},
+				RolloutPercentage: 100,
+			},
+			FlagKey:  "reason-rule-disabled",
+			User:     User{ID: "user-4", Attributes: map[string]interface{}{"plan": "pro"}},
+			Expected: Expected{Value: true, Reason: Reason{Kind: "FALLTHROUGH", InRollout: true}},
+		},
+		{
+			Name:     "fallthrough/full-rollout",
+			Category: "reason",
+			Flag:     &mock.FlagState{Key: "reason-fallthrough-on", Enabled: true, RolloutPercentage: 100},
+			FlagKey:  "reason-fallthrough-on",
+			User:     User{ID: "user-5"},
+			Expected: Expected{Value: true, Reason: Reason{Kind: "FALLTHROUGH", InRollout: true}},
+		},
+		{
+			Name:     "fallthrough/zero-rollout",
+			Category: "reason",
+			Flag:     &mock.FlagState{Key: "reason-fallthrough-off", Enabled: true},
+			FlagKey:  "reason-fallthrough-off",
+			User:     User{ID: "user-6"},
+			Expected: Expected{Value: false, Reason: Reason{Kind: "FALLTHROUGH"}},
+		},
+		{
+			Name:     "unknown-flag",
+			Category: "reason",
+			FlagKey:  "reason-does-not-exist",
+			Default:  true,
+			User:     User{ID: "user-7"},
+			Expected: Expected{Value: true, Reason: Reason{Kind: "UNKNOWN"}},
+		},
+	}
+}
+
+func segmentCases() []Case {
+	segments := map[string][]mock.Condition{
+		"enterprise": {
+			{Attribute: "plan", Operator: "eq", Value: "enterprise"},
+			{Attribute: "seats", Operator: "gte", Value: 50},
+		},
+	}
+	flag := ruleFlag("segment-enterprise", mock.Condition{Attribute: "segment", Operator: "in", Value: "enterprise"})
+
+	return []Case{
+		{
+			Name:     "segment/match",
+			Category: "segment",
+			Flag:     flag,
+			Segments: segments,
+			FlagKey:  "segment-enterprise",
+			User:     User{ID: "user-8", Attributes: map[string]interface{}{"plan": "enterprise", "seats": 120}},
+			Expected: matched(),
+		},
+		{
+			Name:     "segment/partial-match",
+			Category: "segment",
+			Flag:     flag,
+			Segments: segments,
+			FlagKey:  "segment-enterprise",
+			User:     User{ID: "user-9", Attributes: map[string]interface{}{"plan": "enterprise", "seats": 10}},
+			Expected: notMatched(),
+		},
+	}
+}
@@ -0,0 +1,116 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	rollgate "github.com/rollgate/sdks/packages/sdk-go"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the published conformance fixtures")
+
+const apiKey = "conformance-key"
+
+// TestConformance runs every case against the reference Go SDK in-process.
+func TestConformance(t *testing.T) {
+	for _, transport := range Transports {
+		transport := transport
+		t.Run(string(transport), func(t *testing.T) {
+			server := mock.NewServer(apiKey)
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+
+			for _, tc := range Cases() {
+				tc := tc
+				t.Run(tc.Category+"/"+tc.Name, func(t *testing.T) {
+					runCase(t, server, ts.URL, transport, tc)
+				})
+			}
+		})
+	}
+}
+
+func runCase(t *testing.T, server *mock.Server, baseURL string, transport Transport, tc Case) {
+	server.GetFlagStore().Clear()
+	server.ClearSegments()
+	server.ClearUserSessions()
+	for id, conditions := range tc.Segments {
+		server.SetSegment(id, conditions)
+	}
+	if tc.Flag != nil {
+		server.SetFlag(tc.Flag)
+	}
+
+	client, err := rollgate.NewClient(rollgate.Config{
+		APIKey:          apiKey,
+		BaseURL:         baseURL,
+		EnableStreaming: transport == TransportStreaming,
+		Timeout:         2 * time.Second,
+		Events:          rollgate.EventCollectorConfig{FlushIntervalMs: 60000, MaxBufferSize: 100, Enabled: false},
+		Telemetry:       rollgate.TelemetryConfig{FlushIntervalMs: 60000, MaxBufferSize: 1000, Enabled: false},
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Identify before Init so the stream is opened for the case's user
+	require.NoError(t, client.Identify(ctx, &rollgate.UserContext{ID: tc.User.ID, Attributes: tc.User.Attributes}))
+	require.NoError(t, client.Init(ctx))
+
+	if transport == TransportStreaming {
+		waitForStream(t, server)
+	}
+
+	detail := client.IsEnabledDetail(tc.FlagKey, tc.Default)
+	assert.Equal(t, tc.Expected.Value, detail.Value, "value")
+	assert.Equal(t, tc.Expected.Reason, Reason{
+		Kind:      string(detail.Reason.Kind),
+		RuleID:    detail.Reason.RuleID,
+		RuleIndex: detail.Reason.RuleIndex,
+		InRollout: detail.Reason.InRollout,
+	}, "reason")
+}
+
+// waitForStream waits until the SDK's SSE connection is open and its init
+// event has had time to be applied.
+func waitForStream(t *testing.T, server *mock.Server) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for server.GetSSEClientCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("SDK did not open an SSE connection")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+}
+
+// TestFixturesUpToDate checks the published fixtures match the cases.
+// Run with -update after changing cases.
+func TestFixturesUpToDate(t *testing.T) {
+	want, err := BuildFixtures().Marshal()
+	require.NoError(t, err)
+
+	path := filepath.Join("..", "..", FixturesPath)
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, want, 0o644))
+	}
+
+	got, err := os.ReadFile(path)
+	require.NoError(t, err, "run go test ./internal/conformance -update to create the fixtures")
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s is out of date; run go test ./internal/conformance -update", FixturesPath)
+	}
+}
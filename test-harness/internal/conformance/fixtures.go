@@ -0,0 +1,35 @@
+package conformance
+
+import "encoding/json"
+
+// FixturesPath is the location of the published fixtures, relative to the
+// test-harness module root.
+const FixturesPath = "testdata/conformance/expected.json"
+
+// Fixtures is the published form of the conformance suite.
+type Fixtures struct {
+	Description string      `json:"description"`
+	Transports  []Transport `json:"transports"`
+	Cases       []Case      `json:"cases"`
+}
+
+// BuildFixtures returns the fixtures for the current set of cases.
+func BuildFixtures() Fixtures {
+	return Fixtures{
+		Description: "Expected evaluation results for every operator, reason and transport, " +
+			"produced by the reference Go SDK against the mock server. For each case: load " +
+			"segments and flag into the mock, identify the user, evaluate flagKey with default, " +
+			"and compare value and reason. Every case must pass on every transport.",
+		Transports: Transports,
+		Cases:      Cases(),
+	}
+}
+
+// Marshal encodes the fixtures as indented JSON.
+func (f Fixtures) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -58,11 +58,15 @@ func (bs *BrowserTestService) Health(ctx context.Context) error {
 // This is equivalent to the "init" command in the standard protocol.
 func (bs *BrowserTestService) CreateClient(ctx context.Context, config protocol.Config, user *protocol.UserContext) error {
 	// Build LaunchDarkly-style configuration
+	startWait := config.Timeout
+	if config.StartWaitTimeMs > 0 {
+		startWait = config.StartWaitTimeMs
+	}
 	createReq := map[string]interface{}{
 		"tag": bs.Name,
 		"configuration": map[string]interface{}{
 			"credential":      config.APIKey,
-			"startWaitTimeMs": config.Timeout,
+			"startWaitTimeMs": startWait,
 			"initCanFail":     false,
 			"serviceEndpoints": map[string]string{
 				"polling":   config.BaseURL,
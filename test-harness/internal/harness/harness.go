@@ -5,10 +5,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"os"
 	"time"
 
 	"github.com/rollgate/test-harness/internal/mock"
@@ -106,17 +109,33 @@ type Harness struct {
 	mockServer        *mock.Server
 	httpServer        *http.Server
 	mockURL           string
+	listenNetwork     string // net.Listen network for the mock ("tcp" or "tcp6")
+	listenAddr        string // net.Listen address for the mock
+	socketPath        string // Unix socket the mock also serves on, if any
 	apiKey            string
+	seed              int64
 	services          []SDKService
+	timings           *commandTimings
 	externalServerURL string // If set, use external server instead of mock
 }
 
+// Mock listener networks for Config.MockNetwork.
+const (
+	NetworkLocalhost = "localhost" // localhost:<port> (default)
+	NetworkIPv4      = "ipv4"      // 127.0.0.1:<port>
+	NetworkIPv6      = "ipv6"      // [::1]:<port>, IPv6 only
+	NetworkDual      = "dual"      // [::]:<port>, reachable over IPv4 and IPv6
+)
+
 // Config contains harness configuration.
 type Config struct {
 	MockPort          int      // Port for mock server (default: 9000)
+	MockNetwork       string   // Listener network: localhost, ipv4, ipv6 or dual (default: localhost)
+	MockSocket        string   // If set, the mock also serves on this unix domain socket
 	APIKey            string   // API key for mock server (default: "test-api-key")
 	Services          []string // Service URLs (e.g., ["http://localhost:8001", "http://localhost:8002"])
 	ExternalServerURL string   // If set, use external server instead of mock (e.g., "http://localhost:3000")
+	Seed              int64    // Seed for generated test data (default: derived from the current time)
 }
 
 // DefaultConfig returns the default configuration.
@@ -136,14 +155,34 @@ func New(cfg Config) *Harness {
 	if cfg.APIKey == "" {
 		cfg.APIKey = "test-api-key"
 	}
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
 
 	h := &Harness{
 		mockURL:           fmt.Sprintf("http://localhost:%d", cfg.MockPort),
+		listenNetwork:     "tcp",
+		listenAddr:        fmt.Sprintf("localhost:%d", cfg.MockPort),
+		socketPath:        cfg.MockSocket,
 		apiKey:            cfg.APIKey,
+		seed:              cfg.Seed,
 		services:          make([]SDKService, 0),
+		timings:           newCommandTimings(),
 		externalServerURL: cfg.ExternalServerURL,
 	}
 
+	switch cfg.MockNetwork {
+	case NetworkIPv4:
+		h.mockURL = fmt.Sprintf("http://127.0.0.1:%d", cfg.MockPort)
+		h.listenAddr = fmt.Sprintf("127.0.0.1:%d", cfg.MockPort)
+	case NetworkIPv6:
+		h.mockURL = fmt.Sprintf("http://[::1]:%d", cfg.MockPort)
+		h.listenNetwork, h.listenAddr = "tcp6", fmt.Sprintf("[::1]:%d", cfg.MockPort)
+	case NetworkDual:
+		// "tcp" on the unspecified IPv6 address accepts IPv4 connections too
+		h.listenAddr = fmt.Sprintf("[::]:%d", cfg.MockPort)
+	}
+
 	// Only create mock server if not using external server
 	if cfg.ExternalServerURL == "" {
 		h.mockServer = mock.NewServer(cfg.APIKey)
@@ -154,7 +193,7 @@ func New(cfg Config) *Harness {
 
 // AddService adds a test service.
 func (h *Harness) AddService(name, url string) {
-	h.services = append(h.services, NewTestService(name, url))
+	h.services = append(h.services, &timedService{NewTestService(name, url), h.timings})
 }
 
 // GetServices returns all registered test services.
@@ -164,7 +203,7 @@ func (h *Harness) GetServices() []SDKService {
 
 // AddBrowserService adds a browser test service (LaunchDarkly protocol).
 func (h *Harness) AddBrowserService(name, url string) {
-	h.services = append(h.services, NewBrowserTestService(name, url))
+	h.services = append(h.services, &timedService{NewBrowserTestService(name, url), h.timings})
 }
 
 // GetMockServer returns the mock server for configuration.
@@ -177,6 +216,11 @@ func (h *Harness) GetMockURL() string {
 	return h.mockURL
 }
 
+// GetMockSocket returns the unix socket the mock serves on, or "" if none.
+func (h *Harness) GetMockSocket() string {
+	return h.socketPath
+}
+
 // GetAPIKey returns the API key.
 func (h *Harness) GetAPIKey() string {
 	return h.apiKey
@@ -199,24 +243,37 @@ func (h *Harness) Start(ctx context.Context) error {
 		return nil
 	}
 
-	listener, err := net.Listen("tcp", h.mockURL[7:]) // Remove "http://"
+	listener, err := net.Listen(h.listenNetwork, h.listenAddr)
 	if err != nil {
 		return fmt.Errorf("listen: %w", err)
 	}
+	listeners := []net.Listener{listener}
+
+	if h.socketPath != "" {
+		os.Remove(h.socketPath) // Stale socket from an earlier run
+		unixListener, err := net.Listen("unix", h.socketPath)
+		if err != nil {
+			listener.Close()
+			return fmt.Errorf("listen on socket: %w", err)
+		}
+		listeners = append(listeners, unixListener)
+	}
 
 	h.httpServer = &http.Server{
 		Handler: h.mockServer,
 	}
 
-	go func() {
-		if err := h.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
-			fmt.Printf("Mock server error: %v\n", err)
-		}
-	}()
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			if err := h.httpServer.Serve(l); err != nil && err != http.ErrServerClosed {
+				fmt.Printf("Mock server error: %v\n", err)
+			}
+		}(l)
+	}
 
 	// Wait for server to be ready
 	for i := 0; i < 50; i++ {
-		conn, err := net.DialTimeout("tcp", listener.Addr().String(), 100*time.Millisecond)
+		conn, err := net.DialTimeout(listener.Addr().Network(), listener.Addr().String(), 100*time.Millisecond)
 		if err == nil {
 			conn.Close()
 			return nil
@@ -229,10 +286,14 @@ func (h *Harness) Start(ctx context.Context) error {
 
 // Stop stops the mock server.
 func (h *Harness) Stop(ctx context.Context) error {
-	if h.httpServer != nil {
-		return h.httpServer.Shutdown(ctx)
+	if h.httpServer == nil {
+		return nil
 	}
-	return nil
+	err := h.httpServer.Shutdown(ctx)
+	if h.socketPath != "" {
+		os.Remove(h.socketPath)
+	}
+	return err
 }
 
 // SetScenario sets a test scenario on the mock server.
@@ -243,6 +304,15 @@ func (h *Harness) SetScenario(scenario string) {
 	h.mockServer.SetScenario(scenario)
 }
 
+// LoadScenarioFile loads a YAML or JSON scenario file (see scenarios/) on
+// the mock server, replacing its flags, segments and error simulation.
+func (h *Harness) LoadScenarioFile(path string) error {
+	if h.mockServer == nil {
+		return fmt.Errorf("no mock server to load %s on", path)
+	}
+	return h.mockServer.LoadScenarioFile(path)
+}
+
 // SetFlag sets a single flag on the mock server.
 func (h *Harness) SetFlag(flag *mock.FlagState) {
 	if h.mockServer == nil {
@@ -251,6 +321,49 @@ func (h *Harness) SetFlag(flag *mock.FlagState) {
 	h.mockServer.SetFlag(flag)
 }
 
+// SetFlags sets several flags on the mock at once: no flags response
+// carries part of the batch.
+func (h *Harness) SetFlags(flags ...*mock.FlagState) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.SetFlags(flags)
+}
+
+// SetEnvironmentKey makes the mock accept apiKey and serve flags as
+// configured in env to SDKs using it.
+func (h *Harness) SetEnvironmentKey(apiKey, env string) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.SetEnvironmentKey(apiKey, env)
+}
+
+// ClearEnvironmentKeys removes all environment keys from the mock.
+func (h *Harness) ClearEnvironmentKeys() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ClearEnvironmentKeys()
+}
+
+// RotateAPIKey makes the mock accept only apiKey instead of the harness
+// key; ResetAPIKey restores it.
+func (h *Harness) RotateAPIKey(apiKey string) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.RotateAPIKey(apiKey)
+}
+
+// ResetAPIKey restores the harness key on the mock.
+func (h *Harness) ResetAPIKey() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.RotateAPIKey(h.apiKey)
+}
+
 // WaitForServices waits for all services to be healthy.
 func (h *Harness) WaitForServices(ctx context.Context, timeout time.Duration) error {
 	deadline := time.Now().Add(timeout)
@@ -285,6 +398,7 @@ func (h *Harness) InitSDKConfig() protocol.Config {
 	return protocol.Config{
 		APIKey:          h.apiKey,
 		BaseURL:         baseURL,
+		SocketPath:      h.socketPath,
 		RefreshInterval: 0, // Disable polling for tests
 		EnableStreaming: false,
 		Timeout:         5000,
@@ -326,6 +440,19 @@ func (h *Harness) SetErrorWithDelay(statusCode int, count int, delay time.Durati
 	})
 }
 
+// SetEndpointErrors fails every request to the given SDK paths with
+// statusCode, leaving other endpoints healthy.
+func (h *Harness) SetEndpointErrors(statusCode int, endpoints ...string) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.SetError(&mock.ErrorSimulation{
+		StatusCode: statusCode,
+		Count:      -1,
+		Endpoints:  endpoints,
+	})
+}
+
 // ClearError removes error simulation.
 func (h *Harness) ClearError() {
 	if h.mockServer == nil {
@@ -374,6 +501,31 @@ func (h *Harness) DisconnectSSEClients() int {
 	return h.mockServer.DisconnectSSEClients()
 }
 
+// GetSSEConnections returns the mock's log of SSE connection attempts.
+func (h *Harness) GetSSEConnections() []mock.SSEConnection {
+	if h.mockServer == nil {
+		return nil
+	}
+	return h.mockServer.GetSSEConnections()
+}
+
+// RejectSSEConnections makes the mock refuse the next count SSE connection
+// attempts with statusCode (-1 = always).
+func (h *Harness) RejectSSEConnections(statusCode int, count int) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.RejectSSEConnections(mock.SSERejectConfig{StatusCode: statusCode, Count: count})
+}
+
+// ResetSSEConnections clears the SSE connection log and stops rejecting.
+func (h *Harness) ResetSSEConnections() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ResetSSEConnections()
+}
+
 // BroadcastFlagChange broadcasts a flag change to all SSE clients.
 func (h *Harness) BroadcastFlagChange(flagKey string, enabled bool) {
 	if h.mockServer == nil {
@@ -382,6 +534,24 @@ func (h *Harness) BroadcastFlagChange(flagKey string, enabled bool) {
 	h.mockServer.BroadcastFlagChange(flagKey, enabled)
 }
 
+// BroadcastSegmentUpdate sends a segment-updated event with the segment's
+// current conditions to all SSE clients.
+func (h *Harness) BroadcastSegmentUpdate(id string) int {
+	if h.mockServer == nil {
+		return 0
+	}
+	return h.mockServer.BroadcastSegmentUpdate(id)
+}
+
+// BroadcastRulesChange sends a rules-changed event with the flag's current
+// targeting to all SSE clients.
+func (h *Harness) BroadcastRulesChange(flagKey string) int {
+	if h.mockServer == nil {
+		return 0
+	}
+	return h.mockServer.BroadcastRulesChange(flagKey)
+}
+
 // InitSDKConfigWithStreaming creates a config for SDK initialization with streaming enabled.
 func (h *Harness) InitSDKConfigWithStreaming() protocol.Config {
 	baseURL := h.mockURL
@@ -391,6 +561,7 @@ func (h *Harness) InitSDKConfigWithStreaming() protocol.Config {
 	return protocol.Config{
 		APIKey:          h.apiKey,
 		BaseURL:         baseURL,
+		SocketPath:      h.socketPath,
 		RefreshInterval: 0, // Disable polling
 		EnableStreaming: true,
 		Timeout:         5000,
@@ -413,8 +584,103 @@ func (h *Harness) ClearReceivedEvents() {
 	h.mockServer.ClearReceivedEvents()
 }
 
+// GetReceivedIdentifies returns the users of identify requests received by
+// the mock server, in order.
+func (h *Harness) GetReceivedIdentifies() []mock.IdentifyUser {
+	if h.mockServer == nil {
+		return nil
+	}
+	return h.mockServer.GetReceivedIdentifies()
+}
+
+// ClearReceivedIdentifies clears received identify requests.
+func (h *Harness) ClearReceivedIdentifies() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ClearReceivedIdentifies()
+}
+
+// GetEventTimings returns the mock's timestamp checks of received events.
+func (h *Harness) GetEventTimings() []mock.EventTiming {
+	if h.mockServer == nil {
+		return nil
+	}
+	return h.mockServer.GetEventTimings()
+}
+
+// SetClockOffset skews the mock's Date header on SDK endpoints; zero
+// removes the skew.
+func (h *Harness) SetClockOffset(offset time.Duration) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.SetClock(mock.ClockConfig{OffsetMs: offset.Milliseconds()})
+}
+
+// ScheduleFlagFlip turns a flag on or off when the mock's virtual clock
+// reaches at.
+func (h *Harness) ScheduleFlagFlip(at time.Duration, flagKey string, enabled bool) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ScheduleFlips(mock.ScheduledFlip{AtMs: at.Milliseconds(), FlagKey: flagKey, Enabled: enabled})
+}
+
+// AdvanceVirtualClock moves the mock's virtual clock forward by d and
+// returns the flips that fired.
+func (h *Harness) AdvanceVirtualClock(d time.Duration) []mock.FiredFlip {
+	if h.mockServer == nil {
+		return nil
+	}
+	return h.mockServer.AdvanceVirtualClock(d)
+}
+
+// GetVirtualClock returns the mock's virtual time and scheduled flips.
+func (h *Harness) GetVirtualClock() mock.VirtualClockResponse {
+	if h.mockServer == nil {
+		return mock.VirtualClockResponse{}
+	}
+	return h.mockServer.GetVirtualClock()
+}
+
+// ResetVirtualClock sets the mock's virtual clock back to 0 and drops all
+// scheduled flips.
+func (h *Harness) ResetVirtualClock() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ResetVirtualClock()
+}
+
+// ScheduleFlagChange replaces flag in the mock after d on the host clock,
+// broadcasting the change to SSE clients when it fires.
+func (h *Harness) ScheduleFlagChange(flag *mock.FlagState, d time.Duration) mock.ScheduledChange {
+	if h.mockServer == nil {
+		return mock.ScheduledChange{}
+	}
+	return h.mockServer.ScheduleChange(*flag, time.Now().Add(d))
+}
+
+// GetScheduledChanges returns the mock's pending and fired scheduled
+// changes.
+func (h *Harness) GetScheduledChanges() mock.ScheduledChangesResponse {
+	if h.mockServer == nil {
+		return mock.ScheduledChangesResponse{}
+	}
+	return h.mockServer.GetScheduledChanges()
+}
+
+// CancelScheduledChanges drops the mock's pending scheduled changes.
+func (h *Harness) CancelScheduledChanges() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.CancelScheduledChanges()
+}
+
 // GetReceivedTelemetry returns all telemetry payloads received by the mock server.
-func (h *Harness) GetReceivedTelemetry() []mock.TelemetryPayload {
+func (h *Harness) GetReceivedTelemetry() []mock.ReceivedTelemetry {
 	if h.mockServer == nil {
 		return nil
 	}
@@ -445,6 +711,280 @@ func (h *Harness) ClearSegments() {
 	h.mockServer.ClearSegments()
 }
 
+// SetValidators configures which cache validators (ETag, Last-Modified) the mock emits.
+func (h *Harness) SetValidators(etag, lastModified bool) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.SetValidators(mock.ValidatorConfig{ETag: etag, LastModified: lastModified})
+}
+
+// GetConditionalStats returns conditional request counters from the mock server.
+func (h *Harness) GetConditionalStats() mock.ConditionalStats {
+	if h.mockServer == nil {
+		return mock.ConditionalStats{}
+	}
+	return h.mockServer.GetConditionalStats()
+}
+
+// ResetConditional restores default validators and clears conditional request counters.
+func (h *Harness) ResetConditional() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ResetConditional()
+}
+
+// SetCORS configures the CORS headers the mock sends on SDK endpoints.
+func (h *Harness) SetCORS(config mock.CORSConfig) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.SetCORS(config)
+}
+
+// GetCORSStats returns CORS preflight and rejection counters from the mock server.
+func (h *Harness) GetCORSStats() mock.CORSStats {
+	if h.mockServer == nil {
+		return mock.CORSStats{}
+	}
+	return h.mockServer.GetCORSStats()
+}
+
+// ResetCORS restores permissive CORS headers and clears CORS counters.
+func (h *Harness) ResetCORS() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ResetCORS()
+}
+
+// SetAuthSchemes configures which auth schemes the mock accepts on SDK endpoints.
+func (h *Harness) SetAuthSchemes(schemes ...mock.AuthScheme) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.SetAuth(mock.AuthConfig{Schemes: schemes})
+}
+
+// GetAuthStats returns which auth schemes SDK requests presented.
+func (h *Harness) GetAuthStats() mock.AuthStats {
+	if h.mockServer == nil {
+		return mock.AuthStats{}
+	}
+	return h.mockServer.GetAuthStats()
+}
+
+// ResetAuth restores the default auth schemes and clears auth counters.
+func (h *Harness) ResetAuth() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ResetAuth()
+}
+
+// SetRedirect makes the mock answer SDK requests with redirects. With
+// crossHost the Location points at the same mock under a different host name
+// (127.0.0.1 for localhost, localhost for an IP address). count is the number
+// of requests to redirect (-1 = always).
+func (h *Harness) SetRedirect(statusCode int, crossHost bool, count int) error {
+	if h.mockServer == nil {
+		return nil
+	}
+	config := mock.RedirectConfig{StatusCode: statusCode, Count: count}
+	if crossHost {
+		target, ok := h.crossHostURL()
+		if !ok {
+			return ErrNoCrossHost
+		}
+		config.Target = target
+	}
+	return h.mockServer.SetRedirect(config)
+}
+
+// ErrNoCrossHost is returned by SetRedirect when the mock has no second host
+// name, as with an IPv6-only listener.
+var ErrNoCrossHost = errors.New("no alternate host name for the mock listener")
+
+// crossHostURL returns the mock URL under a different host name that reaches
+// the same listener.
+func (h *Harness) crossHostURL() (string, bool) {
+	u, err := url.Parse(h.mockURL)
+	if err != nil {
+		return "", false
+	}
+	switch u.Hostname() {
+	case "localhost":
+		u.Host = net.JoinHostPort("127.0.0.1", u.Port())
+	case "127.0.0.1":
+		u.Host = net.JoinHostPort("localhost", u.Port())
+	default:
+		return "", false
+	}
+	return u.String(), true
+}
+
+// SetProxy configures proxy-style request requirements on the mock.
+func (h *Harness) SetProxy(config mock.ProxyConfig) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.SetProxy(config)
+}
+
+// GetRedirectStats returns redirect and proxy counters.
+func (h *Harness) GetRedirectStats() mock.RedirectStats {
+	if h.mockServer == nil {
+		return mock.RedirectStats{}
+	}
+	return h.mockServer.GetRedirectStats()
+}
+
+// ResetRedirects disables redirects and proxy requirements on the mock.
+func (h *Harness) ResetRedirects() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ResetRedirects()
+}
+
+// SetLatency delays responses of one SDK endpoint ("*" for all) by an
+// exact duration; zero removes the delay.
+func (h *Harness) SetLatency(path string, delay time.Duration) {
+	if h.mockServer == nil {
+		return
+	}
+	config := mock.LatencyConfig{Endpoints: map[string]int{path: int(delay / time.Millisecond)}}
+	h.mockServer.SetLatency(config)
+}
+
+// SetLatencyDistribution draws the delays of one SDK endpoint ("*" for
+// all) from dist, replacing earlier distributions; a nonzero seed makes the
+// jitter reproducible.
+func (h *Harness) SetLatencyDistribution(path string, dist mock.LatencyDistribution, seed int64) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.SetLatencyDistributions(map[string]mock.LatencyDistribution{path: dist}, seed)
+}
+
+// GetLatencyStats returns delayed request counts and client aborts.
+func (h *Harness) GetLatencyStats() mock.LatencyStats {
+	if h.mockServer == nil {
+		return mock.LatencyStats{}
+	}
+	return h.mockServer.GetLatencyStats()
+}
+
+// ResetLatency removes all response delays and distributions on the mock.
+func (h *Harness) ResetLatency() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ResetLatency()
+}
+
+// SetHeaders adds response headers to one SDK endpoint ("*" for all) on
+// the mock, replacing any headers set before.
+func (h *Harness) SetHeaders(path string, headers map[string]string) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.SetHeaders(mock.HeadersConfig{Endpoints: map[string]map[string]string{path: headers}})
+}
+
+// GetHeadersStats returns how many responses carried injected headers.
+func (h *Harness) GetHeadersStats() mock.HeadersStats {
+	if h.mockServer == nil {
+		return mock.HeadersStats{}
+	}
+	return h.mockServer.GetHeadersStats()
+}
+
+// ResetHeaders removes all injected response headers on the mock.
+func (h *Harness) ResetHeaders() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ResetHeaders()
+}
+
+// SetSDKConfigHints makes the mock suggest SDK configuration on flags
+// responses.
+func (h *Harness) SetSDKConfigHints(hints mock.SDKConfigHints) {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.SetSDKConfigHints(hints)
+}
+
+// ResetSDKConfigHints stops the mock from sending SDK configuration hints.
+func (h *Harness) ResetSDKConfigHints() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ResetSDKConfigHints()
+}
+
+// GetRecordedRequests returns the SDK requests the mock received for path
+// ("" for all), exactly as sent.
+func (h *Harness) GetRecordedRequests(path string) []mock.RecordedRequest {
+	if h.mockServer == nil {
+		return nil
+	}
+	return h.mockServer.GetRecordedRequests(path)
+}
+
+// RequestSeq returns the sequence number of the mock's latest SDK request;
+// pass it as RequestAssertion.SinceSeq to check only later requests.
+func (h *Harness) RequestSeq() int {
+	if h.mockServer == nil {
+		return 0
+	}
+	return h.mockServer.RequestSeq()
+}
+
+// AssertRequests runs a's checks on the SDK requests the mock received.
+func (h *Harness) AssertRequests(a mock.RequestAssertion) mock.RequestAssertionResult {
+	if h.mockServer == nil {
+		return mock.RequestAssertionResult{}
+	}
+	return h.mockServer.AssertRequests(a)
+}
+
+// ClearRecordedRequests empties the mock's SDK request log.
+func (h *Harness) ClearRecordedRequests() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ClearRecordedRequests()
+}
+
+// SetCorruption makes the mock answer the next count flags requests (-1 =
+// all) with a body corrupted as mode (see mock.CorruptionConfig).
+func (h *Harness) SetCorruption(mode string, count int) error {
+	if h.mockServer == nil {
+		return nil
+	}
+	return h.mockServer.SetCorruption(mock.CorruptionConfig{Mode: mode, Count: count})
+}
+
+// GetCorruptionStats returns how many flags responses were corrupted.
+func (h *Harness) GetCorruptionStats() mock.CorruptionStats {
+	if h.mockServer == nil {
+		return mock.CorruptionStats{}
+	}
+	return h.mockServer.GetCorruptionStats()
+}
+
+// ResetCorruption stops corrupting flags responses on the mock.
+func (h *Harness) ResetCorruption() {
+	if h.mockServer == nil {
+		return
+	}
+	h.mockServer.ResetCorruption()
+}
+
 // IsUsingExternalServer returns true if using an external server instead of mock.
 func (h *Harness) IsUsingExternalServer() bool {
 	return h.externalServerURL != ""
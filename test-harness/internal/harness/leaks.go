@@ -0,0 +1,69 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rollgate/test-harness/internal/protocol"
+)
+
+// LeakThresholds bounds how much a test service may grow across a test
+// before the growth is reported as a leak. Zero disables a check.
+type LeakThresholds struct {
+	// MaxGoroutineGrowth is the allowed increase in goroutines (default: 5)
+	MaxGoroutineGrowth int
+
+	// MaxHeapGrowth is the allowed increase in live heap bytes (default: 16 MiB)
+	MaxHeapGrowth uint64
+
+	// MaxRSSGrowth is the allowed increase in resident set size (default: 64 MiB)
+	MaxRSSGrowth uint64
+}
+
+// DefaultLeakThresholds returns thresholds loose enough to ignore allocator
+// noise but tight enough to catch a poller or stream left running per test.
+func DefaultLeakThresholds() LeakThresholds {
+	return LeakThresholds{
+		MaxGoroutineGrowth: 5,
+		MaxHeapGrowth:      16 << 20,
+		MaxRSSGrowth:       64 << 20,
+	}
+}
+
+// SampleProcessStats asks every service for a process stats snapshot.
+// Services that do not implement getProcessStats are omitted.
+func (h *Harness) SampleProcessStats(ctx context.Context) map[string]protocol.ProcessStats {
+	samples := make(map[string]protocol.ProcessStats)
+	for _, svc := range h.services {
+		resp, err := svc.SendCommand(ctx, protocol.NewGetProcessStatsCommand())
+		if err != nil || resp.IsError() || resp.ProcessStats == nil {
+			continue
+		}
+		samples[svc.GetName()] = *resp.ProcessStats
+	}
+	return samples
+}
+
+// LeakViolations compares two samples and describes every threshold exceeded.
+func LeakViolations(before, after map[string]protocol.ProcessStats, th LeakThresholds) []string {
+	var violations []string
+	for name, b := range before {
+		a, ok := after[name]
+		if !ok {
+			continue
+		}
+		if th.MaxGoroutineGrowth > 0 && b.Goroutines > 0 && a.Goroutines-b.Goroutines > th.MaxGoroutineGrowth {
+			violations = append(violations, fmt.Sprintf("%s: goroutines grew %d -> %d (max +%d)",
+				name, b.Goroutines, a.Goroutines, th.MaxGoroutineGrowth))
+		}
+		if th.MaxHeapGrowth > 0 && b.HeapBytes > 0 && a.HeapBytes > b.HeapBytes+th.MaxHeapGrowth {
+			violations = append(violations, fmt.Sprintf("%s: heap grew %d -> %d bytes (max +%d)",
+				name, b.HeapBytes, a.HeapBytes, th.MaxHeapGrowth))
+		}
+		if th.MaxRSSGrowth > 0 && b.RSSBytes > 0 && a.RSSBytes > b.RSSBytes+th.MaxRSSGrowth {
+			violations = append(violations, fmt.Sprintf("%s: RSS grew %d -> %d bytes (max +%d)",
+				name, b.RSSBytes, a.RSSBytes, th.MaxRSSGrowth))
+		}
+	}
+	return violations
+}
@@ -0,0 +1,120 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+)
+
+// MockRegion is an extra mock server on its own port, standing in for one
+// region of the API so SDK failover between base URLs can be tested. Each
+// region has its own flags, errors and request log; Kill makes it refuse
+// connections as if it went down, and Revive brings it back on the same
+// URL.
+type MockRegion struct {
+	Name   string
+	URL    string
+	Server *mock.Server
+
+	addr string
+
+	mu         sync.Mutex
+	httpServer *http.Server // nil while killed
+}
+
+// StartRegion starts a mock server for the named region on a free port,
+// accepting the harness API key and serving the "basic" scenario. Callers
+// stop it with Stop.
+func (h *Harness) StartRegion(name string) (*MockRegion, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen for region %s: %w", name, err)
+	}
+	r := &MockRegion{
+		Name:   name,
+		URL:    "http://" + listener.Addr().String(),
+		Server: mock.NewServer(h.apiKey),
+		addr:   listener.Addr().String(),
+	}
+	r.Server.SetScenario("basic")
+	r.serve(listener)
+	return r, nil
+}
+
+// serve starts serving on listener. Must not be called while serving.
+func (r *MockRegion) serve(listener net.Listener) {
+	srv := &http.Server{Handler: r.Server}
+	r.mu.Lock()
+	r.httpServer = srv
+	r.mu.Unlock()
+
+	go func() {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Mock region %s error: %v\n", r.Name, err)
+		}
+	}()
+}
+
+// Kill takes the region down at once: the listener and every open
+// connection, streams included, are closed, so new requests are refused.
+func (r *MockRegion) Kill() {
+	r.mu.Lock()
+	srv := r.httpServer
+	r.httpServer = nil
+	r.mu.Unlock()
+	if srv != nil {
+		srv.Close()
+	}
+}
+
+// Revive brings a killed region back on its URL, with its flags and
+// request log intact.
+func (r *MockRegion) Revive() error {
+	if r.Alive() {
+		return nil
+	}
+	listener, err := net.Listen("tcp", r.addr)
+	if err != nil {
+		return fmt.Errorf("revive region %s: %w", r.Name, err)
+	}
+	r.serve(listener)
+	return nil
+}
+
+// Alive reports whether the region is serving.
+func (r *MockRegion) Alive() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.httpServer != nil
+}
+
+// Stop shuts the region down gracefully.
+func (r *MockRegion) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	srv := r.httpServer
+	r.httpServer = nil
+	r.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	r.Server.DisconnectSSEClients()
+	return srv.Shutdown(ctx)
+}
+
+// InitSDKConfigForRegions creates a config for SDK initialization against
+// primary, failing over to fallbacks in order. SDKs without fallback URL
+// support only ever talk to primary.
+func (h *Harness) InitSDKConfigForRegions(primary *MockRegion, fallbacks ...*MockRegion) protocol.Config {
+	config := h.InitSDKConfig()
+	config.BaseURL = primary.URL
+	config.SocketPath = "" // The socket reaches the main mock, not a region
+	for _, r := range fallbacks {
+		config.FallbackBaseURLs = append(config.FallbackBaseURLs, r.URL)
+	}
+	return config
+}
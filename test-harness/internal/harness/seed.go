@@ -0,0 +1,42 @@
+package harness
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+)
+
+// Seed returns the seed behind all generated test data. Passing it back via
+// Config.Seed (or -seed) reproduces a run exactly.
+func (h *Harness) Seed() int64 {
+	return h.seed
+}
+
+// Rand returns a generator for the named consumer (a test, a corpus, a chaos
+// schedule). Each name gets its own stream derived from the seed, so adding
+// or reordering consumers does not change the values the others see.
+func (h *Harness) Rand(name string) *rand.Rand {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return rand.New(rand.NewSource(h.seed ^ int64(hash.Sum64())))
+}
+
+// RandomUserID returns a user ID such as "user-3f9c2a17b4e0".
+func RandomUserID(r *rand.Rand, prefix string) string {
+	return fmt.Sprintf("%s-%012x", prefix, r.Int63()&0xffffffffffff)
+}
+
+// RandomUserIDs returns n distinct user IDs for the named consumer.
+func (h *Harness) RandomUserIDs(name string, n int) []string {
+	r := h.Rand(name)
+	seen := make(map[string]bool, n)
+	ids := make([]string, 0, n)
+	for len(ids) < n {
+		id := RandomUserID(r, "user")
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
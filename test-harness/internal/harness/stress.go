@@ -0,0 +1,165 @@
+package harness
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+)
+
+// StressConfig sizes a generated large-payload scenario. Zero fields use
+// the defaults from DefaultStressConfig.
+type StressConfig struct {
+	// Flags is the number of flags (default: 2000)
+	Flags int
+
+	// RulesPerFlag is the number of targeting rules on every flag (default: 100)
+	RulesPerFlag int
+
+	// JSONEvery makes every Nth flag a JSON flag (default: 10)
+	JSONEvery int
+
+	// JSONDepth is the nesting depth of JSON variations (default: 8)
+	JSONDepth int
+}
+
+// DefaultStressConfig returns a scenario well beyond what real projects
+// ship, so init time and memory regressions show up clearly.
+func DefaultStressConfig() StressConfig {
+	return StressConfig{
+		Flags:        2000,
+		RulesPerFlag: 100,
+		JSONEvery:    10,
+		JSONDepth:    8,
+	}
+}
+
+// StressScenario is a generated scenario together with the values every
+// flag must evaluate to for User.
+type StressScenario struct {
+	Flags []*mock.FlagState
+	User  protocol.UserContext
+
+	// Expected is the boolean value of every flag for User
+	Expected map[string]bool
+
+	// ExpectedJSON is the value of every JSON flag for User; flags that are
+	// off for User are missing, as SDKs serve the caller's default
+	ExpectedJSON map[string]interface{}
+}
+
+// stressScore is the User's score attribute. Rules match on score, so the
+// generator controls which rule, if any, matches.
+const stressScore = 50
+
+// GenerateStressScenario builds a scenario from r. Every rule compares the
+// user's score against a threshold; at most one rule per flag has a
+// threshold below stressScore, so the expected value follows from that
+// rule's rollout, or from the flag's own rollout when none matches.
+// Rollouts are 0 or 100 so the expected values do not depend on hashing.
+func GenerateStressScenario(r *rand.Rand, cfg StressConfig) *StressScenario {
+	def := DefaultStressConfig()
+	if cfg.Flags <= 0 {
+		cfg.Flags = def.Flags
+	}
+	if cfg.RulesPerFlag < 0 {
+		cfg.RulesPerFlag = 0
+	} else if cfg.RulesPerFlag == 0 {
+		cfg.RulesPerFlag = def.RulesPerFlag
+	}
+	if cfg.JSONEvery <= 0 {
+		cfg.JSONEvery = def.JSONEvery
+	}
+	if cfg.JSONDepth <= 0 {
+		cfg.JSONDepth = def.JSONDepth
+	}
+
+	scenario := &StressScenario{
+		User: protocol.UserContext{
+			ID:         "stress-user",
+			Attributes: map[string]interface{}{"score": stressScore, "plan": "stress"},
+		},
+		Expected:     make(map[string]bool, cfg.Flags),
+		ExpectedJSON: make(map[string]interface{}),
+	}
+
+	for i := 0; i < cfg.Flags; i++ {
+		flag := &mock.FlagState{
+			Key:               fmt.Sprintf("stress-flag-%d", i),
+			Enabled:           r.Intn(5) != 0,
+			RolloutPercentage: 100 * r.Intn(2),
+			Rules:             make([]mock.Rule, cfg.RulesPerFlag),
+		}
+
+		matched := -1
+		if cfg.RulesPerFlag > 0 && r.Intn(2) == 0 {
+			matched = r.Intn(cfg.RulesPerFlag)
+		}
+		for j := range flag.Rules {
+			threshold := stressScore + 1 + r.Intn(1000)
+			if j == matched {
+				threshold = r.Intn(stressScore)
+			}
+			flag.Rules[j] = mock.Rule{
+				ID:      fmt.Sprintf("rule-%d", j),
+				Enabled: true,
+				Conditions: []mock.Condition{
+					{Attribute: "score", Operator: "gt", Value: threshold},
+					{Attribute: "plan", Operator: "neq", Value: fmt.Sprintf("plan-%d", r.Intn(1000))},
+				},
+				RolloutPercentage: 100 * r.Intn(2),
+			}
+		}
+
+		expected := flag.Enabled && flag.RolloutPercentage == 100
+		if matched >= 0 {
+			expected = flag.Enabled && flag.Rules[matched].RolloutPercentage == 100
+		}
+		scenario.Expected[flag.Key] = expected
+
+		if i%cfg.JSONEvery == 0 {
+			on := stressJSON(r, cfg.JSONDepth)
+			flag.Variations = map[string]any{"on": on, "off": map[string]interface{}{}}
+			flag.DefaultVariation = "on"
+			if expected {
+				scenario.ExpectedJSON[flag.Key] = on
+			}
+		}
+
+		scenario.Flags = append(scenario.Flags, flag)
+	}
+	return scenario
+}
+
+// stressJSON returns an object nested depth levels deep, with values of
+// every JSON type at each level. Numbers are float64 so the value compares
+// equal to its decoded form.
+func stressJSON(r *rand.Rand, depth int) map[string]interface{} {
+	obj := map[string]interface{}{
+		"level":   float64(depth),
+		"name":    fmt.Sprintf("node-%d", r.Intn(1_000_000)),
+		"enabled": r.Intn(2) == 0,
+		"weights": []interface{}{float64(r.Intn(100)), float64(r.Intn(100)), float64(r.Intn(100))},
+		"note":    nil,
+	}
+	if depth > 1 {
+		obj["child"] = stressJSON(r, depth-1)
+		obj["siblings"] = []interface{}{
+			map[string]interface{}{"index": float64(0), "tag": "a"},
+			map[string]interface{}{"index": float64(1), "tag": "b"},
+		}
+	}
+	return obj
+}
+
+// LoadStressScenario replaces the mock's flags with a scenario generated
+// from the named random stream and returns it.
+func (h *Harness) LoadStressScenario(name string, cfg StressConfig) *StressScenario {
+	scenario := GenerateStressScenario(h.Rand(name), cfg)
+	h.SetScenario("empty")
+	for _, flag := range scenario.Flags {
+		h.SetFlag(flag)
+	}
+	return scenario
+}
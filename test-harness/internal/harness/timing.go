@@ -0,0 +1,234 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rollgate/test-harness/internal/protocol"
+)
+
+// DefaultBudget is the per-command budget key used for commands without
+// their own entry.
+const DefaultBudget = "*"
+
+// DefaultCommandBudgets returns how long each command may take on a test
+// service before it is reported as slow. Commands that hit the network
+// (init, identify, flushes) get more room than local evaluations.
+func DefaultCommandBudgets() map[string]time.Duration {
+	return map[string]time.Duration{
+		protocol.CommandInit:           3 * time.Second,
+		protocol.CommandIdentify:       time.Second,
+		protocol.CommandReset:          time.Second,
+		protocol.CommandClose:          time.Second,
+		protocol.CommandFlushEvents:    2 * time.Second,
+		protocol.CommandFlushTelemetry: 2 * time.Second,
+		DefaultBudget:                  250 * time.Millisecond,
+	}
+}
+
+// ParseCommandBudgets parses "command=ms" pairs separated by commas (e.g.
+// "init=5000,*=500") over the defaults.
+func ParseCommandBudgets(s string) (map[string]time.Duration, error) {
+	budgets := DefaultCommandBudgets()
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid budget %q (expected command=ms)", pair)
+		}
+		ms, err := strconv.Atoi(parts[1])
+		if err != nil || ms <= 0 {
+			return nil, fmt.Errorf("invalid budget %q: milliseconds must be a positive integer", pair)
+		}
+		budgets[parts[0]] = time.Duration(ms) * time.Millisecond
+	}
+	return budgets, nil
+}
+
+// CommandSample is one timed command sent to a test service.
+type CommandSample struct {
+	Service  string
+	Command  string
+	Test     string // Test running when the command was sent
+	Duration time.Duration
+	Failed   bool // Transport error or error response
+}
+
+// CommandStats summarizes one command on one service.
+type CommandStats struct {
+	Command    string
+	Count      int
+	Total      time.Duration
+	Avg        time.Duration
+	P95        time.Duration
+	Max        time.Duration
+	Budget     time.Duration
+	OverBudget int // Samples slower than Budget
+}
+
+// ServiceTimings summarizes every command sent to one service.
+type ServiceTimings struct {
+	Service    string
+	Total      time.Duration
+	OverBudget int
+	Commands   []CommandStats // Sorted by total time, slowest first
+}
+
+// TimingReport is the per-service latency summary of a run.
+type TimingReport struct {
+	Services []ServiceTimings // Sorted by total time, slowest first
+	Slowest  []CommandSample  // Slowest samples across all services
+}
+
+// commandTimings records the latency of every command sent to test services.
+type commandTimings struct {
+	mu      sync.Mutex
+	test    string
+	samples []CommandSample
+}
+
+func newCommandTimings() *commandTimings {
+	return &commandTimings{}
+}
+
+func (ct *commandTimings) record(service, command string, d time.Duration, failed bool) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.samples = append(ct.samples, CommandSample{
+		Service:  service,
+		Command:  command,
+		Test:     ct.test,
+		Duration: d,
+		Failed:   failed,
+	})
+}
+
+// timedService records the latency of every command sent through it.
+type timedService struct {
+	SDKService
+	timings *commandTimings
+}
+
+// SendCommand sends cmd and records how long the service took to answer.
+func (s *timedService) SendCommand(ctx context.Context, cmd protocol.Command) (protocol.Response, error) {
+	start := time.Now()
+	resp, err := s.SDKService.SendCommand(ctx, cmd)
+	s.timings.record(s.GetName(), cmd.Command, time.Since(start), err != nil || resp.IsError())
+	return resp, err
+}
+
+// Init initializes the SDK and records it as an init command.
+func (s *timedService) Init(ctx context.Context, config protocol.Config, user *protocol.UserContext) error {
+	start := time.Now()
+	err := s.SDKService.Init(ctx, config, user)
+	s.timings.record(s.GetName(), protocol.CommandInit, time.Since(start), err != nil)
+	return err
+}
+
+// Close closes the SDK and records it as a close command.
+func (s *timedService) Close(ctx context.Context) error {
+	start := time.Now()
+	err := s.SDKService.Close(ctx)
+	s.timings.record(s.GetName(), protocol.CommandClose, time.Since(start), err != nil)
+	return err
+}
+
+// SetCurrentTest labels subsequent command timings with a test name.
+func (h *Harness) SetCurrentTest(name string) {
+	h.timings.mu.Lock()
+	defer h.timings.mu.Unlock()
+	h.timings.test = name
+}
+
+// TimingReport summarizes command latencies per service against budgets,
+// keeping the top slowest samples.
+func (h *Harness) TimingReport(budgets map[string]time.Duration, top int) TimingReport {
+	h.timings.mu.Lock()
+	samples := append([]CommandSample(nil), h.timings.samples...)
+	h.timings.mu.Unlock()
+
+	budgetFor := func(command string) time.Duration {
+		if b, ok := budgets[command]; ok {
+			return b
+		}
+		return budgets[DefaultBudget]
+	}
+
+	durations := make(map[string]map[string][]time.Duration)
+	for _, s := range samples {
+		if durations[s.Service] == nil {
+			durations[s.Service] = make(map[string][]time.Duration)
+		}
+		durations[s.Service][s.Command] = append(durations[s.Service][s.Command], s.Duration)
+	}
+
+	var report TimingReport
+	for service, byCommand := range durations {
+		st := ServiceTimings{Service: service}
+		for command, ds := range byCommand {
+			sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+			cs := CommandStats{
+				Command: command,
+				Count:   len(ds),
+				P95:     ds[(len(ds)*95-1)/100],
+				Max:     ds[len(ds)-1],
+				Budget:  budgetFor(command),
+			}
+			for _, d := range ds {
+				cs.Total += d
+				if cs.Budget > 0 && d > cs.Budget {
+					cs.OverBudget++
+				}
+			}
+			cs.Avg = cs.Total / time.Duration(cs.Count)
+			st.Total += cs.Total
+			st.OverBudget += cs.OverBudget
+			st.Commands = append(st.Commands, cs)
+		}
+		sort.Slice(st.Commands, func(i, j int) bool { return st.Commands[i].Total > st.Commands[j].Total })
+		report.Services = append(report.Services, st)
+	}
+	sort.Slice(report.Services, func(i, j int) bool { return report.Services[i].Total > report.Services[j].Total })
+
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Duration > samples[j].Duration })
+	if len(samples) > top {
+		samples = samples[:top]
+	}
+	report.Slowest = samples
+	return report
+}
+
+// Write prints the report as plain text.
+func (r TimingReport) Write(w io.Writer) {
+	fmt.Fprintln(w, "=== Command timings per SDK ===")
+	for _, st := range r.Services {
+		fmt.Fprintf(w, "%s: total %v, %d over budget\n", st.Service, st.Total.Round(time.Millisecond), st.OverBudget)
+		for _, cs := range st.Commands {
+			marker := ""
+			if cs.OverBudget > 0 {
+				marker = fmt.Sprintf("  <- %d over %v", cs.OverBudget, cs.Budget)
+			}
+			fmt.Fprintf(w, "  %-18s n=%-5d avg=%-8v p95=%-8v max=%-8v%s\n", cs.Command, cs.Count,
+				cs.Avg.Round(time.Millisecond), cs.P95.Round(time.Millisecond), cs.Max.Round(time.Millisecond), marker)
+		}
+	}
+	if len(r.Slowest) > 0 {
+		fmt.Fprintln(w, "=== Slowest commands ===")
+		for _, s := range r.Slowest {
+			failed := ""
+			if s.Failed {
+				failed = " (failed)"
+			}
+			fmt.Fprintf(w, "  %-8v %s %s in %s%s\n", s.Duration.Round(time.Millisecond), s.Service, s.Command, s.Test, failed)
+		}
+	}
+}
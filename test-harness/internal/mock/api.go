@@ -0,0 +1,487 @@
+package mock
+
+import (
+	"net/http"
+	"time"
+)
+
+// Wire types for the mock API. Handlers encode and decode these types, and
+// the OpenAPI document is derived from them, so the two cannot drift apart.
+
+// FlagsResponse is the V1 flags payload (GET /api/v1/sdk/flags).
+type FlagsResponse struct {
+	Flags    map[string]bool             `json:"flags"`
+	Reasons  map[string]EvaluationReason `json:"reasons,omitempty"` // Only with ?withReasons=true
+	Metadata map[string]FlagMetadata     `json:"metadata,omitempty"`
+
+	SDKConfig *SDKConfig `json:"sdkConfig,omitempty"` // Only while SDK config hints are sent in the body
+}
+
+// FlagMetadata identifies the configuration of a flag that was evaluated.
+type FlagMetadata struct {
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// V2FlagValue is a typed flag value in the V2 flags payload.
+type V2FlagValue struct {
+	Key     string            `json:"key"`
+	Type    string            `json:"type"` // boolean, string, number or json
+	Value   interface{}       `json:"value"`
+	Enabled bool              `json:"enabled"`
+	Reason  *EvaluationReason `json:"reason,omitempty"` // Omitted with ?withReasons=false
+
+	Version   int        `json:"version,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// FlagChangedEvent is the data of an SSE flag-changed event.
+type FlagChangedEvent struct {
+	Key     string            `json:"key"`
+	Enabled bool              `json:"enabled"`
+	Reason  *EvaluationReason `json:"reason,omitempty"` // Only on streams opened with ?withReasons=true
+
+	// The changed flag's version and update time; omitted for unknown flags
+	Version   int        `json:"version,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// SegmentUpdatedEvent is the data of an SSE segment-updated event.
+type SegmentUpdatedEvent struct {
+	ID         string      `json:"id"`
+	Conditions []Condition `json:"conditions"` // Empty when the segment does not exist
+	Flags      []string    `json:"flags"`      // Keys of flags whose rules reference the segment
+}
+
+// RulesChangedEvent is the data of an SSE rules-changed event: the flag's
+// full targeting for SDKs evaluating locally, and its value for the
+// stream's user for SDKs that do not.
+type RulesChangedEvent struct {
+	Key     string            `json:"key"`
+	Flag    *FlagState        `json:"flag"` // Null when the flag does not exist
+	Enabled bool              `json:"enabled"`
+	Reason  *EvaluationReason `json:"reason,omitempty"` // Only on streams opened with ?withReasons=true
+}
+
+// FlagsV2Response is the V2 flags payload (GET /api/v1/sdk/v2/flags).
+type FlagsV2Response struct {
+	Flags map[string]V2FlagValue `json:"flags"`
+}
+
+// IdentifyUser is the user context sent to the identify endpoint.
+type IdentifyUser struct {
+	ID         string                 `json:"id"`
+	Email      string                 `json:"email,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Contexts   []EvaluationContext    `json:"contexts,omitempty"`
+}
+
+// EvaluationContext is a non-user context (organization, device, ...)
+// sent with a user. Rules target it as "<kind>.key" and "<kind>.<attribute>".
+type EvaluationContext struct {
+	Kind       string                 `json:"kind"`
+	Key        string                 `json:"key"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// IdentifyRequest is the body of POST /api/v1/sdk/identify.
+type IdentifyRequest struct {
+	User IdentifyUser `json:"user"`
+}
+
+// IdentifyBatchRequest is the body of POST /api/v1/sdk/identify/batch.
+type IdentifyBatchRequest struct {
+	Users []IdentifyUser `json:"users"`
+}
+
+// EventsRequest is a batch of tracked events (POST /api/v1/sdk/events).
+type EventsRequest struct {
+	Events []TrackEventItem `json:"events"`
+}
+
+// ReceivedResponse acknowledges an events, telemetry or identify batch.
+type ReceivedResponse struct {
+	Received int `json:"received"`
+}
+
+// SuccessResponse is returned by test control endpoints.
+type SuccessResponse struct {
+	Success bool `json:"success"`
+}
+
+// HealthResponse is returned by GET /health.
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+// ErrorResponse is returned for authentication and validation failures.
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// SimulatedError is the error body sent while an ErrorSimulation is active.
+type SimulatedError struct {
+	Code      string `json:"code"`
+	Category  string `json:"category"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+}
+
+// SimulatedErrorResponse wraps a SimulatedError.
+type SimulatedErrorResponse struct {
+	Error SimulatedError `json:"error"`
+}
+
+// EventsListResponse is returned by GET /api/v1/test/events.
+type EventsListResponse struct {
+	Events []TrackEventItem `json:"events"`
+	Count  int              `json:"count"`
+}
+
+// EnvironmentsResponse is returned by GET /api/v1/test/environments.
+type EnvironmentsResponse struct {
+	Keys []EnvironmentKey `json:"keys"`
+}
+
+// IdentifiesListResponse is returned by GET /api/v1/test/identifies.
+type IdentifiesListResponse struct {
+	Users []IdentifyUser `json:"users"`
+	Count int            `json:"count"`
+}
+
+// ClockResponse is returned by GET /api/v1/test/clock.
+type ClockResponse struct {
+	Clock  ClockConfig   `json:"clock"`
+	Events []EventTiming `json:"events"`
+}
+
+// VirtualClockResponse is returned by /api/v1/test/virtual-clock.
+type VirtualClockResponse struct {
+	NowMs   int64           `json:"nowMs"`
+	Pending []ScheduledFlip `json:"pending"`
+	Fired   []FiredFlip     `json:"fired"`
+}
+
+// TelemetryListResponse is returned by GET /api/v1/test/telemetry.
+type TelemetryListResponse struct {
+	Telemetry []ReceivedTelemetry `json:"telemetry"`
+	Count     int                 `json:"count"`
+}
+
+// SSESendEventRequest is the body of POST /api/v1/test/sse/send-event.
+type SSESendEventRequest struct {
+	Event string                 `json:"event"`
+	Data  map[string]interface{} `json:"data"`
+}
+
+// SSESegmentUpdatedRequest is the body of POST /api/v1/test/sse/segment-updated.
+type SSESegmentUpdatedRequest struct {
+	ID string `json:"id"`
+}
+
+// SSERulesChangedRequest is the body of POST /api/v1/test/sse/rules-changed.
+type SSERulesChangedRequest struct {
+	Key string `json:"key"`
+}
+
+// SSESendEventResponse reports how many SSE clients were connected.
+type SSESendEventResponse struct {
+	Success bool `json:"success"`
+	Clients int  `json:"clients"`
+}
+
+// SSEDisconnectResponse reports how many SSE clients were disconnected.
+type SSEDisconnectResponse struct {
+	Success      bool `json:"success"`
+	Disconnected int  `json:"disconnected"`
+}
+
+// SSEConnectionsResponse is returned by GET /api/v1/test/sse/connections.
+type SSEConnectionsResponse struct {
+	Connections []SSEConnection `json:"connections"`
+}
+
+// SSEClientsResponse is returned by GET /api/v1/test/sse/clients.
+type SSEClientsResponse struct {
+	Clients int `json:"clients"`
+	Evicted int `json:"evicted"` // Clients disconnected for falling behind since startup
+}
+
+// SegmentRequest is the body of POST /api/v1/test/set-segment.
+type SegmentRequest struct {
+	ID         string      `json:"id"`
+	Conditions []Condition `json:"conditions"`
+}
+
+// RotateKeyRequest is the body of POST /api/v1/test/rotate-key.
+type RotateKeyRequest struct {
+	APIKey string `json:"apiKey"`
+}
+
+// RotateKeyResponse reports the key that was replaced.
+type RotateKeyResponse struct {
+	Success  bool   `json:"success"`
+	Previous string `json:"previous"`
+}
+
+// ValidatorsResponse is returned by GET /api/v1/test/validators.
+type ValidatorsResponse struct {
+	Validators ValidatorConfig  `json:"validators"`
+	Stats      ConditionalStats `json:"stats"`
+}
+
+// CORSResponse is returned by GET /api/v1/test/cors.
+type CORSResponse struct {
+	CORS  CORSConfig `json:"cors"`
+	Stats CORSStats  `json:"stats"`
+}
+
+// AuthResponse is returned by GET /api/v1/test/auth.
+type AuthResponse struct {
+	Auth  AuthConfig `json:"auth"`
+	Stats AuthStats  `json:"stats"`
+}
+
+// RedirectResponse is returned by GET /api/v1/test/redirect.
+type RedirectResponse struct {
+	Redirect *RedirectConfig `json:"redirect"` // nil when redirects are off
+	Proxy    ProxyConfig     `json:"proxy"`
+	Stats    RedirectStats   `json:"stats"`
+}
+
+// LatencyResponse is returned by GET /api/v1/test/latency.
+type LatencyResponse struct {
+	Latency       LatencyConfig                  `json:"latency"`
+	Distributions map[string]LatencyDistribution `json:"distributions,omitempty"` // Set with /api/v1/test/set-latency
+	Stats         LatencyStats                   `json:"stats"`
+}
+
+// HeadersResponse is returned by GET /api/v1/test/headers.
+type HeadersResponse struct {
+	Headers HeadersConfig `json:"headers"`
+	Stats   HeadersStats  `json:"stats"`
+}
+
+// RecordedRequestsResponse is returned by GET /api/v1/test/requests.
+type RecordedRequestsResponse struct {
+	Requests []RecordedRequest `json:"requests"`
+	Count    int               `json:"count"`
+}
+
+// CorruptionResponse is returned by GET /api/v1/test/corruption.
+type CorruptionResponse struct {
+	Corruption *CorruptionConfig `json:"corruption"` // nil when corruption is off
+	Stats      CorruptionStats   `json:"stats"`
+}
+
+// authKind is how an endpoint authenticates the caller.
+type authKind int
+
+const (
+	authNone   authKind = iota
+	authBearer          // API key in an enabled AuthScheme (Bearer by default)
+	authToken           // ?token=<api key>, for EventSource clients
+)
+
+// param documents a query parameter.
+type param struct {
+	name        string
+	description string
+}
+
+// operation documents one method on a route. request and response hold a
+// zero value of the body type, or nil when there is no body.
+type operation struct {
+	method   string
+	summary  string
+	auth     authKind
+	query    []param
+	request  interface{}
+	response interface{}
+	stream   bool // Response is text/event-stream
+}
+
+// route is a registered path with its handler and documented operations.
+type route struct {
+	path    string
+	handler http.HandlerFunc
+	ops     []operation
+}
+
+var userQuery = []param{
+	{"user_id", "User ID; attributes come from a prior identify call"},
+}
+
+// routes returns every endpoint served by the mock, in documentation order.
+func (s *Server) routes() []route {
+	return []route{
+		{"/api/v1/sdk/flags", s.handleFlags, []operation{{
+			method: http.MethodGet, summary: "Evaluate all flags (V1 boolean payload)", auth: authBearer,
+			query:    append([]param{{"withReasons", "Set to true to include evaluation reasons"}}, userQuery...),
+			response: FlagsResponse{},
+		}}},
+		{"/api/v1/sdk/v2/flags", s.handleFlagsV2, []operation{{
+			method: http.MethodGet, summary: "Evaluate all flags (V2 typed payload with reasons)", auth: authBearer,
+			query:    append([]param{{"withReasons", "Set to false to omit evaluation reasons"}}, userQuery...),
+			response: FlagsV2Response{},
+		}}},
+		{"/api/v1/sdk/stream", s.handleSSE, []operation{{
+			method: http.MethodGet, summary: "SSE stream: init event with all flags, then flag-changed, segment-updated and rules-changed events", auth: authToken,
+			query: append([]param{
+				{"withReasons", "Set to true to include reasons in init and flag-changed events"},
+				{"format", "Set to v2 for typed values: a flags-v2 event (FlagsV2Response) replaces init, and flag-update-v2 events (V2FlagValue) replace flag-changed"},
+			}, userQuery...),
+			response: FlagsResponse{}, stream: true,
+		}}},
+		{"/api/v1/sdk/identify", s.handleIdentify, []operation{{
+			method: http.MethodPost, summary: "Store user attributes for later evaluations", auth: authBearer,
+			request: IdentifyRequest{}, response: SuccessResponse{},
+		}}},
+		{"/api/v1/sdk/identify/batch", s.handleIdentifyBatch, []operation{{
+			method: http.MethodPost, summary: "Store the attributes of many users, as one identify request each", auth: authBearer,
+			request: IdentifyBatchRequest{}, response: ReceivedResponse{},
+		}}},
+		{"/api/v1/sdk/events", s.handleEvents, []operation{{
+			method: http.MethodPost, summary: "Receive a batch of tracked events", auth: authBearer,
+			request: EventsRequest{}, response: ReceivedResponse{},
+		}}},
+		{"/api/v1/sdk/telemetry", s.handleTelemetry, []operation{{
+			method: http.MethodPost, summary: "Receive an evaluation telemetry batch", auth: authBearer,
+			request: TelemetryPayload{}, response: ReceivedResponse{},
+		}}},
+		{"/api/v1/test/set-error", s.handleSetError, []operation{{
+			method: http.MethodPost, summary: "Simulate errors on SDK endpoints (the flags endpoints unless endpoints lists paths)",
+			request: ErrorSimulation{}, response: SuccessResponse{},
+		}}},
+		{"/api/v1/test/clear-error", s.handleClearError, []operation{{
+			method: http.MethodPost, summary: "Stop simulating errors", response: SuccessResponse{},
+		}}},
+		{"/api/v1/test/sse/send-event", s.handleSSESendEvent, []operation{{
+			method: http.MethodPost, summary: "Send an event (flag-changed unless named) to all SSE clients",
+			request: SSESendEventRequest{}, response: SSESendEventResponse{},
+		}}},
+		{"/api/v1/test/sse/segment-updated", s.handleSSESegmentUpdated, []operation{{
+			method: http.MethodPost, summary: "Send a segment-updated event with the segment's conditions to all SSE clients",
+			request: SSESegmentUpdatedRequest{}, response: SSESendEventResponse{},
+		}}},
+		{"/api/v1/test/sse/rules-changed", s.handleSSERulesChanged, []operation{{
+			method: http.MethodPost, summary: "Send a rules-changed event with the flag's targeting to all SSE clients",
+			request: SSERulesChangedRequest{}, response: SSESendEventResponse{},
+		}}},
+		{"/api/v1/test/sse/disconnect", s.handleSSEDisconnect, []operation{{
+			method: http.MethodPost, summary: "Close all SSE connections", response: SSEDisconnectResponse{},
+		}}},
+		{"/api/v1/test/sse/clients", s.handleSSEClients, []operation{{
+			method: http.MethodGet, summary: "Count connected SSE clients", response: SSEClientsResponse{},
+		}}},
+		{"/api/v1/test/sse/connections", s.handleSSEConnections, []operation{
+			{method: http.MethodGet, summary: "List SSE connection attempts with connect and close times and the events sent on each", response: SSEConnectionsResponse{}},
+			{method: http.MethodPost, summary: "Reject the next SSE connection attempts", request: SSERejectConfig{}, response: SuccessResponse{}},
+			{method: http.MethodDelete, summary: "Clear the SSE connection log and stop rejecting", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/events", s.handleTestEvents, []operation{
+			{method: http.MethodGet, summary: "List received events", response: EventsListResponse{}},
+			{method: http.MethodDelete, summary: "Clear received events", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/environments", s.handleEnvironments, []operation{
+			{method: http.MethodPost, summary: "Accept an additional API key bound to a flag environment", request: EnvironmentKey{}, response: SuccessResponse{}},
+			{method: http.MethodGet, summary: "List API keys bound to environments", response: EnvironmentsResponse{}},
+			{method: http.MethodDelete, summary: "Remove all environment keys", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/rotate-key", s.handleRotateKey, []operation{{
+			method: http.MethodPost, summary: "Replace the server's API key; the old key gets 401 and SSE streams are closed",
+			request: RotateKeyRequest{}, response: RotateKeyResponse{},
+		}}},
+		{"/api/v1/test/identifies", s.handleTestIdentifies, []operation{
+			{method: http.MethodGet, summary: "List users of received identify requests", response: IdentifiesListResponse{}},
+			{method: http.MethodDelete, summary: "Clear received identify requests", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/clock", s.handleClock, []operation{
+			{method: http.MethodPost, summary: "Skew the Date header on SDK endpoints by an offset", request: ClockConfig{}, response: SuccessResponse{}},
+			{method: http.MethodGet, summary: "Get the clock offset and timestamp checks of received events", response: ClockResponse{}},
+			{method: http.MethodDelete, summary: "Remove the clock offset", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/virtual-clock", s.handleVirtualClock, []operation{
+			{method: http.MethodPost, summary: "Schedule flag flips at virtual times and advance the virtual clock, firing due flips", request: VirtualClockRequest{}, response: VirtualClockResponse{}},
+			{method: http.MethodGet, summary: "Get the virtual time and pending and fired flips", response: VirtualClockResponse{}},
+			{method: http.MethodDelete, summary: "Reset the virtual clock to 0 and drop all flips", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/schedule-change", s.handleScheduleChange, []operation{
+			{method: http.MethodPost, summary: "Replace a flag at a host-clock time or after a delay, broadcasting the change to SSE clients", request: ScheduleChangeRequest{}, response: ScheduledChange{}},
+			{method: http.MethodGet, summary: "List pending and fired scheduled changes", response: ScheduledChangesResponse{}},
+			{method: http.MethodDelete, summary: "Cancel pending scheduled changes and clear the fired ones", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/set-segment", s.handleSetSegment, []operation{{
+			method: http.MethodPost, summary: "Create or replace a segment",
+			request: SegmentRequest{}, response: SuccessResponse{},
+		}}},
+		{"/api/v1/test/telemetry", s.handleTestTelemetry, []operation{
+			{method: http.MethodGet, summary: "List received telemetry batches", response: TelemetryListResponse{}},
+			{method: http.MethodDelete, summary: "Clear received telemetry", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/validators", s.handleSetValidators, []operation{
+			{method: http.MethodPost, summary: "Choose which cache validators the flags endpoint emits", request: ValidatorConfig{}, response: SuccessResponse{}},
+			{method: http.MethodGet, summary: "Get validator settings and conditional request stats", response: ValidatorsResponse{}},
+			{method: http.MethodDelete, summary: "Restore default validators and reset stats", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/cors", s.handleCORS, []operation{
+			{method: http.MethodPost, summary: "Configure CORS headers on SDK endpoints", request: CORSConfig{}, response: SuccessResponse{}},
+			{method: http.MethodGet, summary: "Get CORS settings and preflight stats", response: CORSResponse{}},
+			{method: http.MethodDelete, summary: "Restore permissive CORS and reset stats", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/auth", s.handleAuth, []operation{
+			{method: http.MethodPost, summary: "Choose which auth schemes SDK endpoints accept", request: AuthConfig{}, response: SuccessResponse{}},
+			{method: http.MethodGet, summary: "Get accepted auth schemes and presented-scheme stats", response: AuthResponse{}},
+			{method: http.MethodDelete, summary: "Restore default auth schemes and reset stats", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/redirect", s.handleRedirect, []operation{
+			{method: http.MethodPost, summary: "Answer SDK requests with 301/302/307/308 redirects", request: RedirectConfig{}, response: SuccessResponse{}},
+			{method: http.MethodGet, summary: "Get redirect and proxy settings and stats", response: RedirectResponse{}},
+			{method: http.MethodDelete, summary: "Disable redirects and proxy requirements and reset stats", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/proxy", s.handleProxy, []operation{{
+			method: http.MethodPost, summary: "Require proxy-style absolute request URIs on SDK endpoints",
+			request: ProxyConfig{}, response: SuccessResponse{},
+		}}},
+		{"/api/v1/test/latency", s.handleLatency, []operation{
+			{method: http.MethodPost, summary: "Delay SDK endpoint responses by an exact number of milliseconds", request: LatencyConfig{}, response: SuccessResponse{}},
+			{method: http.MethodGet, summary: "Get delays, distributions, delayed request counts and client aborts", response: LatencyResponse{}},
+			{method: http.MethodDelete, summary: "Remove all delays and distributions and reset stats", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/set-latency", s.handleSetLatency, []operation{{
+			method: http.MethodPost, summary: "Draw SDK endpoint delays from a min/max/jitter distribution with an optional percentile tail",
+			request: SetLatencyRequest{}, response: SuccessResponse{},
+		}}},
+		{"/api/v1/test/headers", s.handleHeaders, []operation{
+			{method: http.MethodPost, summary: "Add response headers to SDK endpoints", request: HeadersConfig{}, response: SuccessResponse{}},
+			{method: http.MethodGet, summary: "Get injected headers and per-path injection counts", response: HeadersResponse{}},
+			{method: http.MethodDelete, summary: "Remove injected headers and reset stats", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/sdk-config", s.handleSDKConfig, []operation{
+			{method: http.MethodPost, summary: "Suggest a poll interval and streaming availability on flags responses", request: SDKConfigHints{}, response: SuccessResponse{}},
+			{method: http.MethodGet, summary: "Get the SDK configuration hints", response: SDKConfigHints{}},
+			{method: http.MethodDelete, summary: "Stop sending SDK configuration hints", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/requests", s.handleRecordedRequests, []operation{
+			{method: http.MethodGet, summary: "List SDK requests as received (raw path, query, headers and body) with their user and response status",
+				query: []param{{"path", "Only requests to this SDK path"}, {"since", "Only requests with a higher sequence number"}}, response: RecordedRequestsResponse{}},
+			{method: http.MethodDelete, summary: "Clear the SDK request log", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/requests/assert", s.handleAssertRequests, []operation{{
+			method: http.MethodPost, summary: "Check the logged SDK requests' count, headers, cadence and ETag revalidation",
+			request: RequestAssertion{}, response: RequestAssertionResult{},
+		}}},
+		{"/api/v1/test/corruption", s.handleCorruption, []operation{
+			{method: http.MethodPost, summary: "Answer flags requests with invalid JSON, truncated bodies or an HTML page", request: CorruptionConfig{}, response: SuccessResponse{}},
+			{method: http.MethodGet, summary: "Get corruption settings and the corrupted response count", response: CorruptionResponse{}},
+			{method: http.MethodDelete, summary: "Stop corrupting responses and reset stats", response: SuccessResponse{}},
+		}},
+		{"/api/v1/test/openapi.json", s.handleOpenAPI, []operation{{
+			method: http.MethodGet, summary: "This OpenAPI document", response: map[string]interface{}{},
+		}}},
+		{"/health", s.handleHealth, []operation{{
+			method: http.MethodGet, summary: "Health check", response: HealthResponse{},
+		}}},
+	}
+}
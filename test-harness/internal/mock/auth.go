@@ -0,0 +1,199 @@
+package mock
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// AuthScheme is a way of presenting the API key to SDK endpoints.
+type AuthScheme string
+
+const (
+	// AuthBearer is "Authorization: Bearer <key>".
+	AuthBearer AuthScheme = "bearer"
+	// AuthRaw is "Authorization: <key>" without a scheme.
+	AuthRaw AuthScheme = "raw"
+	// AuthHeader is "X-API-Key: <key>".
+	AuthHeader AuthScheme = "x-api-key"
+	// AuthBasic is "Authorization: Basic base64(<key>:)"; the key may be
+	// sent as either the username or the password.
+	AuthBasic AuthScheme = "basic"
+	// AuthQuery is "?api_key=<key>".
+	AuthQuery AuthScheme = "query"
+)
+
+// AllAuthSchemes lists every scheme the mock understands.
+var AllAuthSchemes = []AuthScheme{AuthBearer, AuthRaw, AuthHeader, AuthBasic, AuthQuery}
+
+// AuthConfig selects which schemes SDK endpoints accept. The SSE endpoint
+// always authenticates with ?token= because EventSource cannot send headers.
+type AuthConfig struct {
+	Schemes []AuthScheme `json:"schemes"`
+}
+
+// DefaultAuthConfig returns the schemes accepted by production today.
+func DefaultAuthConfig() AuthConfig {
+	return AuthConfig{Schemes: []AuthScheme{AuthBearer, AuthRaw}}
+}
+
+// AuthStats counts which scheme each SDK request presented.
+type AuthStats struct {
+	Presented map[AuthScheme]int `json:"presented"` // Requests carrying a credential in each scheme
+	Accepted  int                `json:"accepted"`
+	Rejected  int                `json:"rejected"`
+}
+
+// authState holds the auth configuration and counters.
+type authState struct {
+	mu     sync.Mutex
+	config AuthConfig
+	stats  AuthStats
+}
+
+func newAuthState() *authState {
+	return &authState{
+		config: DefaultAuthConfig(),
+		stats:  AuthStats{Presented: make(map[AuthScheme]int)},
+	}
+}
+
+// presentedSchemes returns the schemes a request carries a credential in,
+// with the credential for each.
+func presentedSchemes(r *http.Request) map[AuthScheme]string {
+	found := make(map[AuthScheme]string)
+
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		switch {
+		case strings.HasPrefix(auth, "Bearer "):
+			found[AuthBearer] = strings.TrimPrefix(auth, "Bearer ")
+		case strings.HasPrefix(auth, "Basic "):
+			if decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, "Basic ")); err == nil {
+				found[AuthBasic] = string(decoded)
+			}
+		default:
+			found[AuthRaw] = auth
+		}
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		found[AuthHeader] = key
+	}
+	if key := r.URL.Query().Get("api_key"); key != "" {
+		found[AuthQuery] = key
+	}
+	return found
+}
+
+// authenticate checks the request against the enabled schemes and records
+// which schemes were presented.
+func (s *Server) authenticate(r *http.Request) bool {
+	presented := presentedSchemes(r)
+
+	as := s.auth
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	for scheme := range presented {
+		as.stats.Presented[scheme]++
+	}
+
+	for _, scheme := range as.config.Schemes {
+		credential, ok := presented[scheme]
+		if !ok {
+			continue
+		}
+		if s.matchesAPIKey(scheme, credential) {
+			as.stats.Accepted++
+			return true
+		}
+	}
+	as.stats.Rejected++
+	return false
+}
+
+func (s *Server) matchesAPIKey(scheme AuthScheme, credential string) bool {
+	if scheme == AuthBasic {
+		// Either username or password may carry the key
+		user, pass, _ := strings.Cut(credential, ":")
+		return s.isAPIKey(user) || s.isAPIKey(pass)
+	}
+	return s.isAPIKey(credential)
+}
+
+// SetAuth replaces the accepted auth schemes.
+func (s *Server) SetAuth(config AuthConfig) {
+	s.auth.mu.Lock()
+	defer s.auth.mu.Unlock()
+	s.auth.config = config
+}
+
+// GetAuthStats returns a copy of the auth counters.
+func (s *Server) GetAuthStats() AuthStats {
+	s.auth.mu.Lock()
+	defer s.auth.mu.Unlock()
+	stats := s.auth.stats
+	stats.Presented = make(map[AuthScheme]int, len(s.auth.stats.Presented))
+	for k, v := range s.auth.stats.Presented {
+		stats.Presented[k] = v
+	}
+	return stats
+}
+
+// ResetAuth restores the default schemes and clears counters.
+func (s *Server) ResetAuth() {
+	s.auth.mu.Lock()
+	defer s.auth.mu.Unlock()
+	s.auth.config = DefaultAuthConfig()
+	s.auth.stats = AuthStats{Presented: make(map[AuthScheme]int)}
+}
+
+// handleAuth is the test control endpoint for auth schemes
+// (POST sets, GET returns settings and stats, DELETE resets).
+func (s *Server) handleAuth(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var config AuthConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, scheme := range config.Schemes {
+			if !validAuthScheme(scheme) {
+				http.Error(w, "unknown auth scheme: "+string(scheme), http.StatusBadRequest)
+				return
+			}
+		}
+		s.SetAuth(config)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	case http.MethodGet:
+		s.auth.mu.Lock()
+		config := s.auth.config
+		s.auth.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(AuthResponse{Auth: config, Stats: s.GetAuthStats()})
+
+	case http.MethodDelete:
+		s.ResetAuth()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func validAuthScheme(scheme AuthScheme) bool {
+	for _, known := range AllAuthSchemes {
+		if scheme == known {
+			return true
+		}
+	}
+	return false
+}
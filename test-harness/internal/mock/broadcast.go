@@ -0,0 +1,153 @@
+package mock
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// sseQueueSize is how many events an SSE connection may have queued before
+// it is treated as a slow client and evicted.
+const sseQueueSize = 64
+
+// sseConn is one open SSE connection. Broadcasts only enqueue events; the
+// connection's handler goroutine drains the queue and writes them, so a
+// slow client never holds up the broadcaster or the other clients.
+type sseConn struct {
+	sub   *sseSubscriber
+	queue chan sseMessage
+
+	// done is closed when the server ends the connection, by disconnect
+	// or eviction. The queue itself is never closed, so broadcasts can
+	// send to a connection that is going away without holding a lock.
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// close ends the connection once, reporting whether this call did.
+func (c *sseConn) close() bool {
+	closed := false
+	c.closeOnce.Do(func() {
+		close(c.done)
+		closed = true
+	})
+	return closed
+}
+
+// sseHub tracks the open SSE connections. The connection list is
+// copy-on-write: connecting and disconnecting replace it under mu, and
+// broadcasts read it without locking, so a broadcast to thousands of
+// clients neither blocks new connections nor is blocked by them.
+type sseHub struct {
+	mu        sync.Mutex
+	conns     atomic.Pointer[[]*sseConn]
+	queueSize int
+	evicted   atomic.Int64
+}
+
+func newSSEHub(queueSize int) *sseHub {
+	h := &sseHub{queueSize: queueSize}
+	h.conns.Store(&[]*sseConn{})
+	return h
+}
+
+// add registers a connection for sub.
+func (h *sseHub) add(sub *sseSubscriber) *sseConn {
+	c := &sseConn{
+		sub:   sub,
+		queue: make(chan sseMessage, h.queueSize),
+		done:  make(chan struct{}),
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	old := *h.conns.Load()
+	conns := make([]*sseConn, len(old), len(old)+1)
+	copy(conns, old)
+	conns = append(conns, c)
+	h.conns.Store(&conns)
+	return c
+}
+
+// remove unregisters c, reporting whether it was still registered.
+func (h *sseHub) remove(c *sseConn) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	old := *h.conns.Load()
+	for i, conn := range old {
+		if conn != c {
+			continue
+		}
+		conns := make([]*sseConn, 0, len(old)-1)
+		conns = append(conns, old[:i]...)
+		conns = append(conns, old[i+1:]...)
+		h.conns.Store(&conns)
+		return true
+	}
+	return false
+}
+
+// list returns the open connections. The slice must not be modified.
+func (h *sseHub) list() []*sseConn {
+	return *h.conns.Load()
+}
+
+// count returns the number of open connections.
+func (h *sseHub) count() int {
+	return len(h.list())
+}
+
+// send queues msg for c without blocking. A connection whose queue is
+// full is evicted rather than silently missing the event, so a test sees a
+// reconnect instead of stale flags.
+func (h *sseHub) send(c *sseConn, msg sseMessage) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.queue <- msg:
+		return true
+	default:
+		if c.sub.record != nil {
+			c.sub.record(msg, SSEDropped)
+		}
+		if h.remove(c) && c.close() {
+			h.evicted.Add(1)
+		}
+		return false
+	}
+}
+
+// broadcast queues the message render returns for each connection's
+// subscriber, skipping connections it returns false for. Returns the
+// number of connections the message was queued for.
+func (h *sseHub) broadcast(render func(sub *sseSubscriber) (sseMessage, bool)) int {
+	sent := 0
+	for _, c := range h.list() {
+		msg, ok := render(c.sub)
+		if ok && h.send(c, msg) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// disconnectAll ends every open connection and returns how many there
+// were.
+func (h *sseHub) disconnectAll() int {
+	h.mu.Lock()
+	conns := *h.conns.Load()
+	h.conns.Store(&[]*sseConn{})
+	h.mu.Unlock()
+
+	for _, c := range conns {
+		c.close()
+	}
+	return len(conns)
+}
+
+// evictedCount returns the number of connections evicted for falling
+// behind.
+func (h *sseHub) evictedCount() int {
+	return int(h.evicted.Load())
+}
@@ -0,0 +1,190 @@
+package mock
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSSEHubEviction checks that a client whose queue fills up is
+// disconnected without affecting clients that keep up.
+func TestSSEHubEviction(t *testing.T) {
+	hub := newSSEHub(2)
+	slow := hub.add(&sseSubscriber{})
+	fast := hub.add(&sseSubscriber{})
+	msg := sseMessage{event: "flag-changed", data: []byte(`{}`)}
+
+	for i := 0; i < 3; i++ {
+		sent := hub.broadcast(func(*sseSubscriber) (sseMessage, bool) { return msg, true })
+		<-fast.queue
+		if i < 2 {
+			assert.Equal(t, 2, sent)
+		} else {
+			assert.Equal(t, 1, sent, "the full queue should not count as sent")
+		}
+	}
+
+	select {
+	case <-slow.done:
+	default:
+		t.Fatal("slow client should be disconnected")
+	}
+	assert.Equal(t, 1, hub.count())
+	assert.Equal(t, 1, hub.evictedCount())
+	assert.False(t, hub.send(slow, msg), "evicted client should not get more events")
+	assert.Equal(t, 1, hub.evictedCount(), "eviction should be counted once")
+}
+
+// TestSSEHubConcurrent connects, broadcasts and disconnects at once; run
+// with -race.
+func TestSSEHubConcurrent(t *testing.T) {
+	hub := newSSEHub(sseQueueSize)
+	msg := sseMessage{event: "flag-changed", data: []byte(`{}`)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c := hub.add(&sseSubscriber{})
+			for j := 0; j < 10; j++ {
+				select {
+				case <-c.queue:
+				case <-c.done:
+				default:
+				}
+			}
+			hub.remove(c)
+		}()
+		go func() {
+			defer wg.Done()
+			hub.broadcast(func(*sseSubscriber) (sseMessage, bool) { return msg, true })
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 0, hub.count())
+	assert.Equal(t, 0, hub.disconnectAll())
+}
+
+// TestSSEStreamDeliversInOrder checks that events broadcast faster than the
+// stream writes arrive complete and in order.
+func TestSSEStreamDeliversInOrder(t *testing.T) {
+	server := NewServer("test-key")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/v1/sdk/stream?token=test-key")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	events := bufio.NewScanner(resp.Body)
+	nextEvent := func() string {
+		for events.Scan() {
+			if name, ok := strings.CutPrefix(events.Text(), "event: "); ok {
+				return name
+			}
+		}
+		return ""
+	}
+	require.Equal(t, "init", nextEvent())
+
+	const count = 40
+	for i := 0; i < count; i++ {
+		server.SendSSEEvent(map[string]interface{}{"seq": i})
+	}
+	for i := 0; i < count; i++ {
+		require.Equal(t, "flag-changed", nextEvent())
+		require.True(t, events.Scan())
+		assert.Equal(t, fmt.Sprintf(`data: {"seq":%d}`, i), events.Text())
+	}
+	assert.Equal(t, 0, server.GetSSEEvictedCount())
+}
+
+// TestSSEDeliveryLog checks that each connection logs the events written
+// to it and those dropped by a full queue.
+func TestSSEDeliveryLog(t *testing.T) {
+	t.Run("delivered", func(t *testing.T) {
+		server := NewServer("test-key")
+		ts := httptest.NewServer(server)
+		defer ts.Close()
+
+		resp, err := http.Get(ts.URL + "/api/v1/sdk/stream?token=test-key")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		for i := 0; i < 3; i++ {
+			server.SendSSEEvent(map[string]interface{}{"seq": i})
+		}
+
+		assert.Eventually(t, func() bool {
+			return server.GetSSEConnections()[0].Delivered("flag-changed") == 3
+		}, 2*time.Second, 10*time.Millisecond)
+		conn := server.GetSSEConnections()[0]
+		assert.Equal(t, 1, conn.Delivered("init"))
+		assert.Equal(t, `{"seq":2}`, conn.Deliveries[len(conn.Deliveries)-1].Data)
+	})
+
+	t.Run("dropped", func(t *testing.T) {
+		var statuses []string
+		hub := newSSEHub(1)
+		hub.add(&sseSubscriber{record: func(_ sseMessage, status string) { statuses = append(statuses, status) }})
+		msg := sseMessage{event: "flag-changed", data: []byte(`{}`)}
+
+		hub.broadcast(func(*sseSubscriber) (sseMessage, bool) { return msg, true })
+		hub.broadcast(func(*sseSubscriber) (sseMessage, bool) { return msg, true })
+		assert.Equal(t, []string{SSEDropped}, statuses, "only the event that did not fit should be logged so far")
+	})
+}
+
+// benchmarkBroadcast measures BroadcastFlagChange to clients subscribers.
+// Queues are emptied between broadcasts with the timer stopped, so no
+// client falls behind and the cost is the broadcast alone.
+func benchmarkBroadcast(b *testing.B, clients int, sub sseSubscriber) {
+	server := NewServer("test-key")
+	server.SetFlag(&FlagState{Key: "checkout", Enabled: true, RolloutPercentage: 50})
+
+	conns := make([]*sseConn, clients)
+	for i := range conns {
+		s := sub
+		s.userID = fmt.Sprintf("user-%d", i)
+		conns[i] = server.sse.add(&s)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		server.BroadcastFlagChange("checkout", i%2 == 0)
+
+		b.StopTimer()
+		for _, c := range conns {
+			<-c.queue
+		}
+		b.StartTimer()
+	}
+}
+
+func BenchmarkBroadcastFlagChange(b *testing.B) {
+	for _, clients := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprintf("clients=%d", clients), func(b *testing.B) {
+			benchmarkBroadcast(b, clients, sseSubscriber{})
+		})
+	}
+}
+
+func BenchmarkBroadcastFlagChangeWithReasons(b *testing.B) {
+	b.Run("clients=1000", func(b *testing.B) {
+		benchmarkBroadcast(b, 1000, sseSubscriber{withReasons: true})
+	})
+}
+
+func BenchmarkBroadcastFlagChangeV2(b *testing.B) {
+	b.Run("clients=1000", func(b *testing.B) {
+		benchmarkBroadcast(b, 1000, sseSubscriber{v2: true})
+	})
+}
@@ -0,0 +1,143 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClockConfig skews the mock's clock relative to the host, as seen by SDKs
+// through the Date header on SDK endpoints.
+type ClockConfig struct {
+	OffsetMs int64 `json:"offsetMs"` // Added to the host clock (negative = server behind)
+}
+
+// EventTiming is the mock's check of one received event's timestamp.
+type EventTiming struct {
+	Batch      int       `json:"batch"` // Events request number, from 1
+	Index      int       `json:"index"` // Position within the batch
+	EventName  string    `json:"eventName"`
+	Timestamp  time.Time `json:"timestamp"`  // Client timestamp; zero if missing
+	ReceivedAt time.Time `json:"receivedAt"` // Mock clock, including the offset
+	DeltaMs    int64     `json:"deltaMs"`    // Timestamp - ReceivedAt
+	// Monotonic is false if the timestamp is earlier than the previous
+	// event's in the same batch
+	Monotonic bool `json:"monotonic"`
+}
+
+// clockState holds the clock offset and the event timings recorded with it.
+type clockState struct {
+	mu      sync.Mutex
+	config  ClockConfig
+	batches int
+	timings []EventTiming
+}
+
+func newClockState() *clockState {
+	return &clockState{}
+}
+
+// now returns the mock's clock.
+func (cs *clockState) now() time.Time {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return time.Now().Add(time.Duration(cs.config.OffsetMs) * time.Millisecond)
+}
+
+// applyClock sets the Date header on SDK endpoints from the mock's clock.
+func (s *Server) applyClock(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/api/v1/sdk/") {
+		return
+	}
+	w.Header().Set("Date", s.clock.now().UTC().Format(http.TimeFormat))
+}
+
+// recordEventTimings checks the timestamps of one received events batch.
+func (s *Server) recordEventTimings(events []TrackEventItem) {
+	receivedAt := s.clock.now()
+
+	s.clock.mu.Lock()
+	defer s.clock.mu.Unlock()
+	s.clock.batches++
+
+	var prev time.Time
+	for i, ev := range events {
+		timing := EventTiming{
+			Batch:      s.clock.batches,
+			Index:      i,
+			EventName:  ev.EventName,
+			ReceivedAt: receivedAt,
+			Monotonic:  true,
+		}
+		if ev.Timestamp != nil {
+			timing.Timestamp = *ev.Timestamp
+			timing.DeltaMs = ev.Timestamp.Sub(receivedAt).Milliseconds()
+			timing.Monotonic = !ev.Timestamp.Before(prev)
+			prev = *ev.Timestamp
+		}
+		s.clock.timings = append(s.clock.timings, timing)
+	}
+}
+
+// SetClock replaces the clock offset.
+func (s *Server) SetClock(config ClockConfig) {
+	s.clock.mu.Lock()
+	defer s.clock.mu.Unlock()
+	s.clock.config = config
+}
+
+// GetEventTimings returns the timestamp checks of every received event.
+func (s *Server) GetEventTimings() []EventTiming {
+	s.clock.mu.Lock()
+	defer s.clock.mu.Unlock()
+	return append([]EventTiming(nil), s.clock.timings...)
+}
+
+// clearEventTimings drops recorded timings along with received events.
+func (s *Server) clearEventTimings() {
+	s.clock.mu.Lock()
+	defer s.clock.mu.Unlock()
+	s.clock.batches = 0
+	s.clock.timings = nil
+}
+
+// ResetClock removes the clock offset.
+func (s *Server) ResetClock() {
+	s.SetClock(ClockConfig{})
+}
+
+// handleClock is the test control endpoint for the mock clock
+// (POST sets the offset, GET returns it with event timings, DELETE resets).
+func (s *Server) handleClock(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var config ClockConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.SetClock(config)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	case http.MethodGet:
+		s.clock.mu.Lock()
+		config := s.clock.config
+		s.clock.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ClockResponse{Clock: config, Events: s.GetEventTimings()})
+
+	case http.MethodDelete:
+		s.ResetClock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
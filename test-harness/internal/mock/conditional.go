@@ -0,0 +1,167 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ValidatorConfig controls which cache validators the flags endpoint emits.
+// Disabling ETag simulates a CDN that strips it, forcing SDKs onto the
+// Last-Modified / If-Modified-Since fallback.
+type ValidatorConfig struct {
+	ETag         bool `json:"etag"`
+	LastModified bool `json:"lastModified"`
+}
+
+// DefaultValidatorConfig returns the default validators (both enabled).
+func DefaultValidatorConfig() ValidatorConfig {
+	return ValidatorConfig{ETag: true, LastModified: true}
+}
+
+// ConditionalStats counts conditional request outcomes on the flags endpoint.
+type ConditionalStats struct {
+	IfNoneMatch     int `json:"ifNoneMatch"`     // Requests carrying If-None-Match
+	IfModifiedSince int `json:"ifModifiedSince"` // Requests carrying If-Modified-Since
+	NotModified     int `json:"notModified"`     // 304 responses sent
+}
+
+// payloadVersion is the last payload served to a user, used to derive a
+// stable Last-Modified time that only advances when the payload changes.
+type payloadVersion struct {
+	etag     string
+	modified time.Time
+}
+
+// conditionalState holds validator settings and per-user payload versions.
+type conditionalState struct {
+	mu       sync.Mutex
+	config   ValidatorConfig
+	versions map[string]payloadVersion
+	stats    ConditionalStats
+}
+
+func newConditionalState() *conditionalState {
+	return &conditionalState{
+		config:   DefaultValidatorConfig(),
+		versions: make(map[string]payloadVersion),
+	}
+}
+
+// lastModified returns the Last-Modified time for a user's payload. The time
+// has second precision (as sent on the wire) and always moves forward when
+// the payload changes, even within the same second.
+func (cs *conditionalState) lastModified(userID, etag string) time.Time {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	prev, ok := cs.versions[userID]
+	if ok && prev.etag == etag {
+		return prev.modified
+	}
+
+	modified := time.Now().UTC().Truncate(time.Second)
+	if ok && !modified.After(prev.modified) {
+		modified = prev.modified.Add(time.Second)
+	}
+	cs.versions[userID] = payloadVersion{etag: etag, modified: modified}
+	return modified
+}
+
+// checkNotModified writes validator headers and reports whether the request
+// can be answered with 304. If-None-Match takes precedence over
+// If-Modified-Since (RFC 7232 section 6).
+func (s *Server) checkNotModified(w http.ResponseWriter, r *http.Request, userID, etag string) bool {
+	cs := s.conditional
+	modified := cs.lastModified(userID, etag)
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.config.ETag {
+		w.Header().Set("ETag", etag)
+	}
+	if cs.config.LastModified {
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+	}
+
+	inm := r.Header.Get("If-None-Match")
+	ims := r.Header.Get("If-Modified-Since")
+	if inm != "" {
+		cs.stats.IfNoneMatch++
+	}
+	if ims != "" {
+		cs.stats.IfModifiedSince++
+	}
+
+	notModified := false
+	if inm != "" {
+		notModified = cs.config.ETag && inm == etag
+	} else if ims != "" && cs.config.LastModified {
+		if since, err := http.ParseTime(ims); err == nil {
+			notModified = !modified.After(since)
+		}
+	}
+	if notModified {
+		cs.stats.NotModified++
+	}
+	return notModified
+}
+
+// SetValidators configures which validators the flags endpoint emits.
+func (s *Server) SetValidators(config ValidatorConfig) {
+	s.conditional.mu.Lock()
+	defer s.conditional.mu.Unlock()
+	s.conditional.config = config
+}
+
+// GetConditionalStats returns conditional request counters.
+func (s *Server) GetConditionalStats() ConditionalStats {
+	s.conditional.mu.Lock()
+	defer s.conditional.mu.Unlock()
+	return s.conditional.stats
+}
+
+// ResetConditional restores default validators and clears counters.
+func (s *Server) ResetConditional() {
+	s.conditional.mu.Lock()
+	defer s.conditional.mu.Unlock()
+	s.conditional.config = DefaultValidatorConfig()
+	s.conditional.stats = ConditionalStats{}
+}
+
+// handleSetValidators is the test control endpoint for validators
+// (POST sets, GET returns settings and stats, DELETE resets).
+func (s *Server) handleSetValidators(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var config ValidatorConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.SetValidators(config)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	case http.MethodGet:
+		s.conditional.mu.Lock()
+		config := s.conditional.config
+		stats := s.conditional.stats
+		s.conditional.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ValidatorsResponse{Validators: config, Stats: stats})
+
+	case http.MethodDelete:
+		s.ResetConditional()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,193 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Corruption modes for CorruptionConfig.Mode.
+const (
+	CorruptInvalidJSON      = "invalid-json"       // Syntactically invalid JSON
+	CorruptTruncated        = "truncated"          // The real payload cut in half
+	CorruptWrongContentType = "wrong-content-type" // An HTML error page served as text/html
+)
+
+// CorruptionConfig makes flags endpoints answer 200 with a body SDKs cannot
+// parse, so parse resilience can be tested. Only responses that would have
+// been 200 are corrupted; auth failures and other errors pass through.
+type CorruptionConfig struct {
+	// Mode is invalid-json, truncated or wrong-content-type
+	Mode string `json:"mode"`
+
+	// Paths limits corruption to these paths (default: /api/v1/sdk/flags and
+	// /api/v1/sdk/v2/flags)
+	Paths []string `json:"paths,omitempty"`
+
+	// Count is the number of responses to corrupt (-1 = always)
+	Count int `json:"count"`
+}
+
+// CorruptionStats counts corrupted responses.
+type CorruptionStats struct {
+	Corrupted int `json:"corrupted"`
+}
+
+// corruptionState holds the corruption settings and counters.
+type corruptionState struct {
+	mu     sync.Mutex
+	config *CorruptionConfig
+	stats  CorruptionStats
+}
+
+func newCorruptionState() *corruptionState {
+	return &corruptionState{}
+}
+
+// claim reports whether the response to path should be corrupted, and the
+// mode to use.
+func (cs *corruptionState) claim(path string) (string, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cc := cs.config
+	if cc == nil || (cc.Count != -1 && cs.stats.Corrupted >= cc.Count) || !corruptsPath(cc, path) {
+		return "", false
+	}
+	cs.stats.Corrupted++
+	return cc.Mode, true
+}
+
+func corruptsPath(cc *CorruptionConfig, path string) bool {
+	if len(cc.Paths) == 0 {
+		return path == "/api/v1/sdk/flags" || path == "/api/v1/sdk/v2/flags"
+	}
+	for _, p := range cc.Paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// applyCorruption serves a corrupted version of the response to r when
+// corruption is enabled for its path. It reports whether a response has
+// been written.
+func (s *Server) applyCorruption(w http.ResponseWriter, r *http.Request) bool {
+	s.corruption.mu.Lock()
+	enabled := s.corruption.config != nil && corruptsPath(s.corruption.config, r.URL.Path)
+	s.corruption.mu.Unlock()
+	if !enabled {
+		return false
+	}
+
+	// Render the real response without validators, so a cached client still
+	// gets a body instead of a 304
+	r.Header.Del("If-None-Match")
+	r.Header.Del("If-Modified-Since")
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK {
+		copyRecorded(w, rec, rec.Body.Bytes())
+		return true
+	}
+
+	mode, ok := s.corruption.claim(r.URL.Path)
+	if !ok {
+		copyRecorded(w, rec, rec.Body.Bytes())
+		return true
+	}
+	switch mode {
+	case CorruptTruncated:
+		body := rec.Body.Bytes()
+		copyRecorded(w, rec, body[:len(body)/2])
+	case CorruptWrongContentType:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "<html><head><title>502 Bad Gateway</title></head><body>Bad Gateway</body></html>\n")
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"flags":{"corrupted":tru,}`)
+	}
+	return true
+}
+
+// copyRecorded writes a recorded response with body in place of its own.
+func copyRecorded(w http.ResponseWriter, rec *httptest.ResponseRecorder, body []byte) {
+	for name, values := range rec.Header() {
+		w.Header()[name] = values
+	}
+	w.Header().Del("Content-Length")
+	w.WriteHeader(rec.Code)
+	w.Write(body)
+}
+
+// SetCorruption enables corrupted responses on flags endpoints.
+func (s *Server) SetCorruption(config CorruptionConfig) error {
+	switch config.Mode {
+	case CorruptInvalidJSON, CorruptTruncated, CorruptWrongContentType:
+	default:
+		return fmt.Errorf("unsupported corruption mode %q (want %s, %s or %s)",
+			config.Mode, CorruptInvalidJSON, CorruptTruncated, CorruptWrongContentType)
+	}
+
+	s.corruption.mu.Lock()
+	defer s.corruption.mu.Unlock()
+	s.corruption.config = &config
+	s.corruption.stats = CorruptionStats{}
+	return nil
+}
+
+// GetCorruptionStats returns corruption counters.
+func (s *Server) GetCorruptionStats() CorruptionStats {
+	s.corruption.mu.Lock()
+	defer s.corruption.mu.Unlock()
+	return s.corruption.stats
+}
+
+// ResetCorruption stops corrupting responses and clears counters.
+func (s *Server) ResetCorruption() {
+	s.corruption.mu.Lock()
+	defer s.corruption.mu.Unlock()
+	s.corruption.config = nil
+	s.corruption.stats = CorruptionStats{}
+}
+
+// handleCorruption is the test control endpoint for corrupted responses
+// (POST sets, GET returns settings and stats, DELETE resets).
+func (s *Server) handleCorruption(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var config CorruptionConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.SetCorruption(config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	case http.MethodGet:
+		s.corruption.mu.Lock()
+		resp := CorruptionResponse{Corruption: s.corruption.config, Stats: s.corruption.stats}
+		s.corruption.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+
+	case http.MethodDelete:
+		s.ResetCorruption()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
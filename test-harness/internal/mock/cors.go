@@ -0,0 +1,220 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CORSConfig controls the CORS headers sent on SDK endpoints (/api/v1/sdk/*).
+// Test control endpoints always allow any origin so harnesses running in a
+// browser can reconfigure the mock.
+type CORSConfig struct {
+	// Enabled sends CORS headers; when false no Access-Control-* headers are
+	// sent at all, so every cross-origin browser request fails
+	Enabled bool `json:"enabled"`
+
+	// AllowedOrigins lists origins allowed to call SDK endpoints; "*" allows any
+	AllowedOrigins []string `json:"allowedOrigins"`
+
+	// AllowedMethods lists methods accepted in preflight requests
+	AllowedMethods []string `json:"allowedMethods"`
+
+	// AllowedHeaders lists request headers accepted in preflight requests; "*" allows any
+	AllowedHeaders []string `json:"allowedHeaders"`
+
+	// ExposedHeaders lists response headers readable by browser code
+	ExposedHeaders []string `json:"exposedHeaders"`
+
+	// AllowCredentials sends Access-Control-Allow-Credentials: true. The
+	// request origin is echoed instead of "*", as browsers require.
+	AllowCredentials bool `json:"allowCredentials,omitempty"`
+
+	// MaxAge is the preflight cache lifetime in seconds (0 = header omitted)
+	MaxAge int `json:"maxAge,omitempty"`
+}
+
+// DefaultCORSConfig returns the permissive default: any origin, method and header.
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		Enabled:        true,
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"*"},
+		ExposedHeaders: []string{"ETag", "Last-Modified", "Retry-After"},
+	}
+}
+
+// CORSStats counts cross-origin requests seen on SDK endpoints.
+type CORSStats struct {
+	Preflights int `json:"preflights"` // OPTIONS requests with Access-Control-Request-Method
+	Rejected   int `json:"rejected"`   // Requests or preflights denied by the current config
+}
+
+// corsState holds the CORS configuration and counters.
+type corsState struct {
+	mu     sync.Mutex
+	config CORSConfig
+	stats  CORSStats
+}
+
+func newCORSState() *corsState {
+	return &corsState{config: DefaultCORSConfig()}
+}
+
+// applyCORS writes CORS headers for r and reports whether the request was a
+// preflight that has been answered.
+func (s *Server) applyCORS(w http.ResponseWriter, r *http.Request) bool {
+	config := DefaultCORSConfig()
+	sdkPath := strings.HasPrefix(r.URL.Path, "/api/v1/sdk/")
+
+	cs := s.cors
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if sdkPath {
+		config = cs.config
+	}
+
+	origin := r.Header.Get("Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	if sdkPath && preflight {
+		cs.stats.Preflights++
+	}
+
+	allowed := config.Enabled && (origin == "" || matchesAny(config.AllowedOrigins, origin, true))
+	if allowed && preflight {
+		allowed = matchesAny(config.AllowedMethods, r.Header.Get("Access-Control-Request-Method"), false) &&
+			headersAllowed(config.AllowedHeaders, r.Header.Get("Access-Control-Request-Headers"))
+	}
+
+	if !allowed {
+		if sdkPath && origin != "" {
+			cs.stats.Rejected++
+		}
+		if r.Method == http.MethodOptions {
+			// No CORS headers: the browser fails the preflight
+			w.WriteHeader(http.StatusForbidden)
+			return true
+		}
+		return false
+	}
+
+	h := w.Header()
+	if contains(config.AllowedOrigins, "*") && !config.AllowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else if origin != "" {
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Add("Vary", "Origin")
+	}
+	if config.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(config.ExposedHeaders) > 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+	}
+
+	if r.Method != http.MethodOptions {
+		return false
+	}
+
+	h.Set("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ", "))
+	if requested := r.Header.Get("Access-Control-Request-Headers"); contains(config.AllowedHeaders, "*") && requested != "" {
+		h.Set("Access-Control-Allow-Headers", requested)
+	} else {
+		h.Set("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ", "))
+	}
+	if config.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
+	}
+	w.WriteHeader(http.StatusOK)
+	return true
+}
+
+// matchesAny reports whether value is in list; "*" matches anything.
+func matchesAny(list []string, value string, caseSensitive bool) bool {
+	for _, item := range list {
+		if item == "*" || item == value || (!caseSensitive && strings.EqualFold(item, value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// headersAllowed reports whether every header in a comma-separated
+// Access-Control-Request-Headers value is allowed.
+func headersAllowed(allowed []string, requested string) bool {
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !matchesAny(allowed, header, false) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCORS replaces the CORS configuration for SDK endpoints.
+func (s *Server) SetCORS(config CORSConfig) {
+	s.cors.mu.Lock()
+	defer s.cors.mu.Unlock()
+	s.cors.config = config
+}
+
+// GetCORSStats returns CORS request counters.
+func (s *Server) GetCORSStats() CORSStats {
+	s.cors.mu.Lock()
+	defer s.cors.mu.Unlock()
+	return s.cors.stats
+}
+
+// ResetCORS restores the permissive default and clears counters.
+func (s *Server) ResetCORS() {
+	s.cors.mu.Lock()
+	defer s.cors.mu.Unlock()
+	s.cors.config = DefaultCORSConfig()
+	s.cors.stats = CORSStats{}
+}
+
+// handleCORS is the test control endpoint for CORS
+// (POST sets, GET returns settings and stats, DELETE resets).
+func (s *Server) handleCORS(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var config CORSConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.SetCORS(config)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	case http.MethodGet:
+		s.cors.mu.Lock()
+		resp := CORSResponse{CORS: s.cors.config, Stats: s.cors.stats}
+		s.cors.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+
+	case http.MethodDelete:
+		s.ResetCORS()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
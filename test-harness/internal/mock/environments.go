@@ -0,0 +1,166 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// EnvironmentKey binds an additional API key to an environment, so SDKs
+// using that key see the flags' per-environment state.
+type EnvironmentKey struct {
+	APIKey      string `json:"apiKey"`
+	Environment string `json:"environment"`
+}
+
+// environmentState maps API keys to environment names. The server's own
+// key always maps to the default environment ("") and can be rotated.
+type environmentState struct {
+	mu     sync.RWMutex
+	apiKey string
+	keys   map[string]string
+}
+
+func newEnvironmentState(apiKey string) *environmentState {
+	return &environmentState{apiKey: apiKey, keys: make(map[string]string)}
+}
+
+// lookup returns the environment bound to key.
+func (es *environmentState) lookup(key string) (string, bool) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	env, ok := es.keys[key]
+	return env, ok
+}
+
+// isAPIKey reports whether key is the server's key or an environment key.
+func (s *Server) isAPIKey(key string) bool {
+	if key == s.APIKey() {
+		return true
+	}
+	_, ok := s.environments.lookup(key)
+	return ok
+}
+
+// APIKey returns the server's own API key.
+func (s *Server) APIKey() string {
+	s.environments.mu.RLock()
+	defer s.environments.mu.RUnlock()
+	return s.environments.apiKey
+}
+
+// RotateAPIKey replaces the server's own API key and returns the previous
+// one. Requests with the old key are rejected with 401 from then on, and
+// open SSE streams are closed so they must reconnect with the new key.
+func (s *Server) RotateAPIKey(apiKey string) string {
+	s.environments.mu.Lock()
+	previous := s.environments.apiKey
+	s.environments.apiKey = apiKey
+	s.environments.mu.Unlock()
+
+	s.DisconnectSSEClients()
+	return previous
+}
+
+// environmentFor returns the environment of the API key a request
+// authenticated with, or "" for the server's own key.
+func (s *Server) environmentFor(r *http.Request) string {
+	credentials := []string{r.URL.Query().Get("token")}
+	for scheme, credential := range presentedSchemes(r) {
+		if scheme == AuthBasic {
+			user, pass, _ := strings.Cut(credential, ":")
+			credentials = append(credentials, user, pass)
+			continue
+		}
+		credentials = append(credentials, credential)
+	}
+	for _, credential := range credentials {
+		if env, ok := s.environments.lookup(credential); ok {
+			return env
+		}
+	}
+	return ""
+}
+
+// SetEnvironmentKey makes apiKey valid on SDK endpoints and binds it to env.
+func (s *Server) SetEnvironmentKey(apiKey, env string) {
+	s.environments.mu.Lock()
+	defer s.environments.mu.Unlock()
+	s.environments.keys[apiKey] = env
+}
+
+// GetEnvironmentKeys returns the API keys bound to environments.
+func (s *Server) GetEnvironmentKeys() []EnvironmentKey {
+	s.environments.mu.RLock()
+	defer s.environments.mu.RUnlock()
+	keys := make([]EnvironmentKey, 0, len(s.environments.keys))
+	for key, env := range s.environments.keys {
+		keys = append(keys, EnvironmentKey{APIKey: key, Environment: env})
+	}
+	return keys
+}
+
+// ClearEnvironmentKeys removes all environment keys.
+func (s *Server) ClearEnvironmentKeys() {
+	s.environments.mu.Lock()
+	defer s.environments.mu.Unlock()
+	s.environments.keys = make(map[string]string)
+}
+
+// handleEnvironments is the test control endpoint for environment keys
+// (POST binds a key, GET lists keys, DELETE removes all).
+func (s *Server) handleEnvironments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var body EnvironmentKey
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if body.APIKey == "" || body.Environment == "" {
+			http.Error(w, "apiKey and environment are required", http.StatusBadRequest)
+			return
+		}
+		s.SetEnvironmentKey(body.APIKey, body.Environment)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(EnvironmentsResponse{Keys: s.GetEnvironmentKeys()})
+
+	case http.MethodDelete:
+		s.ClearEnvironmentKeys()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleRotateKey replaces the server's API key (POST
+// /api/v1/test/rotate-key).
+func (s *Server) handleRotateKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body RotateKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if body.APIKey == "" {
+		http.Error(w, "apiKey is required", http.StatusBadRequest)
+		return
+	}
+	previous := s.RotateAPIKey(body.APIKey)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RotateKeyResponse{Success: true, Previous: previous})
+}
@@ -1,14 +1,30 @@
 package mock
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 // EvaluationReason explains why a flag evaluated to a particular value.
+//
+// The canonical schema is the same on every endpoint (V1 reasons, V2 flags):
+//   - kind is always set
+//   - ruleId and ruleIndex are set for RULE_MATCH only (ruleIndex may be 0)
+//   - inRollout is set for RULE_MATCH and FALLTHROUGH, true or false
+//   - variationId is set whenever a typed flag serves a variation: the
+//     matched rule's variation, or the default variation for TARGET_MATCH
+//     and FALLTHROUGH; and whenever a weighted split selects one
+//   - errorKind is set for ERROR only
+//   - prerequisiteKey is set for PREREQUISITE_FAILED only
 type EvaluationReason struct {
-	Kind       string `json:"kind"`                 // OFF, TARGET_MATCH, RULE_MATCH, FALLTHROUGH, ERROR, UNKNOWN
-	RuleID     string `json:"ruleId,omitempty"`     // For RULE_MATCH
-	RuleIndex  int    `json:"ruleIndex,omitempty"`  // For RULE_MATCH
-	InRollout  bool   `json:"inRollout,omitempty"`  // Whether user was in rollout percentage
-	ErrorKind  string `json:"errorKind,omitempty"`  // For ERROR
+	Kind        string `json:"kind"`                  // OFF, PREREQUISITE_FAILED, TARGET_MATCH, RULE_MATCH, FALLTHROUGH, ERROR, UNKNOWN
+	RuleID      string `json:"ruleId,omitempty"`      // For RULE_MATCH
+	RuleIndex   *int   `json:"ruleIndex,omitempty"`   // For RULE_MATCH
+	InRollout   *bool  `json:"inRollout,omitempty"`   // For RULE_MATCH and FALLTHROUGH
+	VariationID string `json:"variationId,omitempty"` // Variation served by a typed or weighted flag
+	ErrorKind   string `json:"errorKind,omitempty"`   // For ERROR
+
+	PrerequisiteKey string `json:"prerequisiteKey,omitempty"` // For PREREQUISITE_FAILED
 }
 
 // EvaluationResult contains the value and reason for an evaluation.
@@ -27,6 +43,74 @@ type FlagState struct {
 	Rules             []Rule            `json:"rules,omitempty"`
 	Variations        map[string]any    `json:"variations,omitempty"` // For typed flags
 	DefaultVariation  string            `json:"defaultVariation,omitempty"`
+	// Environments overrides targeting per environment name; environments
+	// without an entry use the fields above
+	Environments map[string]EnvironmentState `json:"environments,omitempty"`
+
+	// Prerequisites must evaluate to their required values, in the same
+	// environment and for the same user, before the flag's targeting is
+	// evaluated; otherwise the flag is off with a PREREQUISITE_FAILED reason
+	Prerequisites []Prerequisite `json:"prerequisites,omitempty"`
+
+	// WeightedVariations splits the users reaching the fallthrough between
+	// variations in proportion to their weights, in place of
+	// RolloutPercentage: every such user gets true and a variation
+	WeightedVariations []WeightedVariation `json:"weightedVariations,omitempty"`
+
+	env string // Environment the flag was resolved for by ForEnvironment
+
+	// Version and UpdatedAt are set by FlagStore.Set: the version goes up by
+	// one on every Set of the key, unless the flag sets a higher one
+	Version   int        `json:"version,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// Metadata returns the flag's version and last update time.
+func (f *FlagState) Metadata() FlagMetadata {
+	meta := FlagMetadata{Version: f.Version}
+	if f.UpdatedAt != nil {
+		meta.UpdatedAt = *f.UpdatedAt
+	}
+	return meta
+}
+
+// EnvironmentState is a flag's targeting in one environment.
+type EnvironmentState struct {
+	Enabled           bool     `json:"enabled"`
+	RolloutPercentage int      `json:"rolloutPercentage,omitempty"` // 0-100
+	TargetUsers       []string `json:"targetUsers,omitempty"`
+	Rules             []Rule   `json:"rules,omitempty"`
+	DefaultVariation  string   `json:"defaultVariation,omitempty"` // Empty keeps the flag's default
+}
+
+// ForEnvironment returns the flag as configured in env. The flag itself is
+// returned for the default environment.
+func (f *FlagState) ForEnvironment(env string) *FlagState {
+	if env == "" {
+		return f
+	}
+	resolved := *f
+	resolved.env = env
+	es, ok := f.Environments[env]
+	if !ok {
+		return &resolved
+	}
+	resolved.Enabled = es.Enabled
+	resolved.RolloutPercentage = es.RolloutPercentage
+	resolved.TargetUsers = es.TargetUsers
+	resolved.Rules = es.Rules
+	if es.DefaultVariation != "" {
+		resolved.DefaultVariation = es.DefaultVariation
+	}
+	resolved.Environments = nil
+	return &resolved
+}
+
+// Prerequisite is a flag that must evaluate to RequiredValue for a
+// dependent flag to be evaluated.
+type Prerequisite struct {
+	FlagKey       string `json:"flagKey"`
+	RequiredValue bool   `json:"requiredValue"`
 }
 
 // Rule represents a targeting rule.
@@ -36,6 +120,17 @@ type Rule struct {
 	Conditions        []Condition `json:"conditions"`
 	RolloutPercentage int         `json:"rolloutPercentage"` // 0-100
 	Variation         string      `json:"variation,omitempty"`
+
+	// WeightedVariations splits the users matching the rule like the
+	// flag's, in place of RolloutPercentage and Variation
+	WeightedVariations []WeightedVariation `json:"weightedVariations,omitempty"`
+}
+
+// WeightedVariation is one arm of a weighted split. For typed flags the ID
+// names an entry of the flag's Variations.
+type WeightedVariation struct {
+	ID     string `json:"id"`
+	Weight int    `json:"weight"`
 }
 
 // Condition represents a rule condition.
@@ -62,6 +157,30 @@ func NewFlagStore() *FlagStore {
 func (fs *FlagStore) Set(flag *FlagState) {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
+	fs.set(flag)
+}
+
+// SetAll adds or updates several flags at once: no reader sees part of
+// the batch.
+func (fs *FlagStore) SetAll(flags []*FlagState) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, flag := range flags {
+		fs.set(flag)
+	}
+}
+
+// set adds or updates a flag, bumping its version. fs.mu must be held.
+func (fs *FlagStore) set(flag *FlagState) {
+	version := 1
+	if previous, ok := fs.flags[flag.Key]; ok {
+		version = previous.Version + 1
+	}
+	if flag.Version < version || flag.UpdatedAt == nil {
+		now := time.Now().UTC()
+		flag.UpdatedAt = &now
+	}
+	flag.Version = max(flag.Version, version)
 	fs.flags[flag.Key] = flag
 }
 
@@ -84,6 +203,30 @@ func (fs *FlagStore) GetAll() map[string]*FlagState {
 	return result
 }
 
+// GetAllForEnvironment returns all flags as configured in env; "" is the
+// default environment.
+func (fs *FlagStore) GetAllForEnvironment(env string) map[string]*FlagState {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	result := make(map[string]*FlagState, len(fs.flags))
+	for k, v := range fs.flags {
+		result[k] = v.ForEnvironment(env)
+	}
+	return result
+}
+
+// GetForEnvironment retrieves a flag as configured in env, without
+// copying the rest of the store.
+func (fs *FlagStore) GetForEnvironment(key, env string) (*FlagState, bool) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	f, ok := fs.flags[key]
+	if !ok {
+		return nil, false
+	}
+	return f.ForEnvironment(env), true
+}
+
 // Delete removes a flag.
 func (fs *FlagStore) Delete(key string) {
 	fs.mu.Lock()
@@ -111,6 +254,8 @@ func (fs *FlagStore) LoadScenario(scenario string) {
 		fs.loadRolloutScenario()
 	case "segments":
 		fs.loadSegmentsScenario()
+	case "environments":
+		fs.loadEnvironmentsScenario()
 	case "empty":
 		// Leave empty
 	default:
@@ -220,3 +365,58 @@ func (fs *FlagStore) loadRolloutScenario() {
 	fs.Set(&FlagState{Key: "rollout-90", Enabled: true, RolloutPercentage: 90})
 	fs.Set(&FlagState{Key: "rollout-100", Enabled: true, RolloutPercentage: 100})
 }
+
+// loadEnvironmentsScenario defines flags that differ between the default,
+// "staging" and "production" environments. Bind API keys to environments
+// with Server.SetEnvironmentKey.
+func (fs *FlagStore) loadEnvironmentsScenario() {
+	fs.loadBasicScenario()
+
+	// On in staging only
+	fs.Set(&FlagState{
+		Key:               "new-checkout",
+		Enabled:           false,
+		RolloutPercentage: 100,
+		Environments: map[string]EnvironmentState{
+			"staging":    {Enabled: true, RolloutPercentage: 100},
+			"production": {Enabled: false},
+		},
+	})
+
+	// Off in production only
+	fs.Set(&FlagState{
+		Key:               "debug-panel",
+		Enabled:           true,
+		RolloutPercentage: 100,
+		Environments: map[string]EnvironmentState{
+			"production": {Enabled: false},
+		},
+	})
+
+	// Targets internal users in staging, everyone in production
+	fs.Set(&FlagState{
+		Key:     "dark-launch",
+		Enabled: false,
+		Environments: map[string]EnvironmentState{
+			"staging":    {Enabled: true, TargetUsers: []string{"user-internal-1"}},
+			"production": {Enabled: true, RolloutPercentage: 100},
+		},
+	})
+
+	// Typed flag with a different variation per environment
+	fs.Set(&FlagState{
+		Key:               "api-endpoint",
+		Enabled:           true,
+		RolloutPercentage: 100,
+		Variations: map[string]any{
+			"local":      "http://localhost:8080",
+			"staging":    "https://staging.api.example.com",
+			"production": "https://api.example.com",
+		},
+		DefaultVariation: "local",
+		Environments: map[string]EnvironmentState{
+			"staging":    {Enabled: true, RolloutPercentage: 100, DefaultVariation: "staging"},
+			"production": {Enabled: true, RolloutPercentage: 100, DefaultVariation: "production"},
+		},
+	})
+}
@@ -0,0 +1,124 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// HeadersConfig attaches extra response headers to SDK endpoints, such as
+// X-RateLimit-Remaining, Server-Timing or a Deprecation warning, so SDK
+// header handling can be tested against realistic responses.
+type HeadersConfig struct {
+	// Endpoints maps an SDK path (e.g. /api/v1/sdk/flags) to the headers to
+	// add; "*" applies to every SDK path, and a path's own entry wins for
+	// the same header name. Headers the handler sets itself (Content-Type,
+	// ETag, ...) take precedence over injected ones.
+	Endpoints map[string]map[string]string `json:"endpoints"`
+}
+
+// HeadersStats counts responses that carried injected headers, by path.
+type HeadersStats struct {
+	Injected map[string]int `json:"injected"`
+}
+
+// headersState holds the header injection settings and counters.
+type headersState struct {
+	mu     sync.Mutex
+	config HeadersConfig
+	stats  HeadersStats
+}
+
+func newHeadersState() *headersState {
+	return &headersState{stats: HeadersStats{Injected: make(map[string]int)}}
+}
+
+// headersFor returns the headers to add for an SDK path and counts the
+// response if there are any.
+func (hs *headersState) headersFor(path string) map[string]string {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	headers := make(map[string]string)
+	for name, value := range hs.config.Endpoints["*"] {
+		headers[name] = value
+	}
+	for name, value := range hs.config.Endpoints[path] {
+		headers[name] = value
+	}
+	if len(headers) > 0 {
+		hs.stats.Injected[path]++
+	}
+	return headers
+}
+
+// applyHeaders adds the configured headers to the response of an SDK
+// request before its handler runs.
+func (s *Server) applyHeaders(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/api/v1/sdk/") {
+		return
+	}
+	for name, value := range s.headers.headersFor(r.URL.Path) {
+		w.Header().Set(name, value)
+	}
+}
+
+// SetHeaders replaces the injected response headers.
+func (s *Server) SetHeaders(config HeadersConfig) {
+	s.headers.mu.Lock()
+	defer s.headers.mu.Unlock()
+	s.headers.config = config
+}
+
+// GetHeadersStats returns a copy of the header injection counters.
+func (s *Server) GetHeadersStats() HeadersStats {
+	s.headers.mu.Lock()
+	defer s.headers.mu.Unlock()
+	stats := HeadersStats{Injected: make(map[string]int, len(s.headers.stats.Injected))}
+	for path, n := range s.headers.stats.Injected {
+		stats.Injected[path] = n
+	}
+	return stats
+}
+
+// ResetHeaders removes all injected headers and clears counters.
+func (s *Server) ResetHeaders() {
+	s.headers.mu.Lock()
+	defer s.headers.mu.Unlock()
+	s.headers.config = HeadersConfig{}
+	s.headers.stats = HeadersStats{Injected: make(map[string]int)}
+}
+
+// handleHeaders is the test control endpoint for injected response headers
+// (POST sets, GET returns settings and stats, DELETE resets).
+func (s *Server) handleHeaders(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var config HeadersConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.SetHeaders(config)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	case http.MethodGet:
+		s.headers.mu.Lock()
+		config := s.headers.config
+		s.headers.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(HeadersResponse{Headers: config, Stats: s.GetHeadersStats()})
+
+	case http.MethodDelete:
+		s.ResetHeaders()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
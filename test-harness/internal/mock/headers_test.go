@@ -0,0 +1,56 @@
+package mock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHeaders checks that injected headers reach the chosen SDK endpoints
+// without replacing the handler's own headers.
+func TestHeaders(t *testing.T) {
+	server := NewServer("test-key")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	get := func(path string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer test-key")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	resp, err := http.Post(ts.URL+"/api/v1/test/headers", "application/json", strings.NewReader(`{"endpoints":{
+		"*":{"Server-Timing":"db;dur=12","X-RateLimit-Remaining":"100"},
+		"/api/v1/sdk/flags":{"X-RateLimit-Remaining":"7","Deprecation":"true","Content-Type":"text/plain"}
+	}}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	flags := get("/api/v1/sdk/flags")
+	assert.Equal(t, "7", flags.Header.Get("X-RateLimit-Remaining"), "the path's own entry wins over *")
+	assert.Equal(t, "true", flags.Header.Get("Deprecation"))
+	assert.Equal(t, "db;dur=12", flags.Header.Get("Server-Timing"))
+	assert.Equal(t, "application/json", flags.Header.Get("Content-Type"), "handler headers take precedence")
+
+	v2 := get("/api/v1/sdk/v2/flags")
+	assert.Equal(t, "100", v2.Header.Get("X-RateLimit-Remaining"))
+	assert.Empty(t, v2.Header.Get("Deprecation"))
+
+	health := get("/health")
+	assert.Empty(t, health.Header.Get("Server-Timing"), "only SDK endpoints get injected headers")
+
+	assert.Equal(t, map[string]int{"/api/v1/sdk/flags": 1, "/api/v1/sdk/v2/flags": 1}, server.GetHeadersStats().Injected)
+
+	server.ResetHeaders()
+	assert.Empty(t, get("/api/v1/sdk/flags").Header.Get("Deprecation"))
+	assert.Empty(t, server.GetHeadersStats().Injected)
+}
@@ -0,0 +1,258 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LatencyConfig delays SDK endpoint responses by an exact amount, so timeout
+// handling can be tested without the error simulator.
+type LatencyConfig struct {
+	// Endpoints maps an SDK path (e.g. /api/v1/sdk/flags) to a delay in
+	// milliseconds; "*" applies to every SDK path without its own entry
+	Endpoints map[string]int `json:"endpoints"`
+}
+
+// LatencyDistribution describes the delays of one endpoint. Requests wait
+// MinMs plus a uniform jitter of up to JitterMs, capped at MaxMs. With
+// Percentile set, the remaining (100-Percentile)% of requests, spread evenly
+// (every 100th at 99), wait MaxMs instead: a tail at that percentile.
+type LatencyDistribution struct {
+	MinMs      int     `json:"minMs"`
+	MaxMs      int     `json:"maxMs,omitempty"`      // 0 leaves the jitter uncapped
+	JitterMs   int     `json:"jitterMs,omitempty"`   // Uniform, added to MinMs
+	Percentile float64 `json:"percentile,omitempty"` // 0 means no tail; needs MaxMs
+}
+
+// SetLatencyRequest is the body of POST /api/v1/test/set-latency.
+type SetLatencyRequest struct {
+	// Endpoints maps an SDK path, or "*", to its delays
+	Endpoints map[string]LatencyDistribution `json:"endpoints"`
+	// Seed makes the jitter reproducible; 0 seeds from the clock
+	Seed int64 `json:"seed,omitempty"`
+}
+
+// LatencyAbort records a client that gave up while its response was delayed.
+type LatencyAbort struct {
+	Path    string `json:"path"`
+	DelayMs int    `json:"delayMs"` // Configured delay
+	AfterMs int64  `json:"afterMs"` // How long the client waited before disconnecting
+}
+
+// LatencyStats counts delayed requests and client aborts.
+type LatencyStats struct {
+	Delayed int            `json:"delayed"` // Requests answered after their full delay
+	Tail    int            `json:"tail"`    // Requests given a distribution's tail delay
+	Aborts  []LatencyAbort `json:"aborts"`  // Requests abandoned by the client, in order
+}
+
+// latencyState holds the latency configuration and counters.
+type latencyState struct {
+	mu     sync.Mutex
+	config LatencyConfig
+	stats  LatencyStats
+
+	// Delay distributions, keyed like LatencyConfig.Endpoints; an exact
+	// delay for the same key wins
+	distributions map[string]LatencyDistribution
+	rand          *rand.Rand
+	requests      map[string]int // Requests drawn per distribution key, for the tail
+}
+
+func newLatencyState() *latencyState {
+	return &latencyState{
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		requests: make(map[string]int),
+	}
+}
+
+// delayFor returns the delay of the next request to an SDK path.
+func (ls *latencyState) delayFor(path string) int {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	for _, key := range []string{path, "*"} {
+		if ms, ok := ls.config.Endpoints[key]; ok {
+			return ms
+		}
+		if dist, ok := ls.distributions[key]; ok {
+			return ls.draw(key, dist)
+		}
+	}
+	return 0
+}
+
+// draw returns a delay from dist for the next request counted under key.
+// ls.mu must be held.
+func (ls *latencyState) draw(key string, dist LatencyDistribution) int {
+	n := ls.requests[key]
+	ls.requests[key]++
+	if dist.Percentile > 0 {
+		// Request n is in the tail when the tail's share of the first n+1
+		// requests reaches a new whole request
+		share := (100 - dist.Percentile) / 100
+		if int(float64(n+1)*share) > int(float64(n)*share) {
+			ls.stats.Tail++
+			return dist.MaxMs
+		}
+	}
+	ms := dist.MinMs
+	if dist.JitterMs > 0 {
+		ms += ls.rand.Intn(dist.JitterMs + 1)
+	}
+	if dist.MaxMs > 0 {
+		ms = min(ms, dist.MaxMs)
+	}
+	return ms
+}
+
+// validate rejects distributions that cannot be drawn from.
+func (dist LatencyDistribution) validate() error {
+	switch {
+	case dist.MinMs < 0 || dist.MaxMs < 0 || dist.JitterMs < 0:
+		return fmt.Errorf("delays must not be negative")
+	case dist.MaxMs > 0 && dist.MaxMs < dist.MinMs:
+		return fmt.Errorf("maxMs must not be below minMs")
+	case dist.Percentile < 0 || dist.Percentile >= 100:
+		return fmt.Errorf("percentile must be in [0, 100)")
+	case dist.Percentile > 0 && dist.MaxMs == 0:
+		return fmt.Errorf("percentile needs maxMs, the tail delay")
+	}
+	return nil
+}
+
+// applyLatency holds SDK requests for their configured delay. It reports
+// whether the client disconnected first, in which case nothing is written.
+func (s *Server) applyLatency(r *http.Request) bool {
+	if !strings.HasPrefix(r.URL.Path, "/api/v1/sdk/") {
+		return false
+	}
+	ms := s.latency.delayFor(r.URL.Path)
+	if ms <= 0 {
+		return false
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(time.Duration(ms) * time.Millisecond)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		s.latency.mu.Lock()
+		s.latency.stats.Delayed++
+		s.latency.mu.Unlock()
+		return false
+	case <-r.Context().Done():
+		s.latency.mu.Lock()
+		s.latency.stats.Aborts = append(s.latency.stats.Aborts, LatencyAbort{
+			Path:    r.URL.Path,
+			DelayMs: ms,
+			AfterMs: time.Since(start).Milliseconds(),
+		})
+		s.latency.mu.Unlock()
+		return true
+	}
+}
+
+// SetLatency replaces the per-endpoint delays.
+func (s *Server) SetLatency(config LatencyConfig) {
+	s.latency.mu.Lock()
+	defer s.latency.mu.Unlock()
+	s.latency.config = config
+}
+
+// SetLatencyDistributions replaces the per-endpoint delay distributions,
+// keeping exact delays. A nonzero seed makes the jitter reproducible.
+func (s *Server) SetLatencyDistributions(distributions map[string]LatencyDistribution, seed int64) {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	s.latency.mu.Lock()
+	defer s.latency.mu.Unlock()
+	s.latency.distributions = distributions
+	s.latency.rand = rand.New(rand.NewSource(seed))
+	s.latency.requests = make(map[string]int)
+}
+
+// GetLatencyStats returns a copy of the latency counters.
+func (s *Server) GetLatencyStats() LatencyStats {
+	s.latency.mu.Lock()
+	defer s.latency.mu.Unlock()
+	stats := s.latency.stats
+	stats.Aborts = append([]LatencyAbort(nil), s.latency.stats.Aborts...)
+	return stats
+}
+
+// ResetLatency removes all delays and clears counters.
+func (s *Server) ResetLatency() {
+	s.latency.mu.Lock()
+	defer s.latency.mu.Unlock()
+	s.latency.config = LatencyConfig{}
+	s.latency.stats = LatencyStats{}
+	s.latency.distributions = nil
+	s.latency.requests = make(map[string]int)
+}
+
+// handleLatency is the test control endpoint for response delays
+// (POST sets, GET returns settings and stats, DELETE resets).
+func (s *Server) handleLatency(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var config LatencyConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.SetLatency(config)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	case http.MethodGet:
+		s.latency.mu.Lock()
+		resp := LatencyResponse{Latency: s.latency.config, Distributions: s.latency.distributions}
+		s.latency.mu.Unlock()
+		resp.Stats = s.GetLatencyStats()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+
+	case http.MethodDelete:
+		s.ResetLatency()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSetLatency is the test control endpoint for delay distributions.
+// GET and DELETE /api/v1/test/latency report and reset them with the exact
+// delays.
+func (s *Server) handleSetLatency(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req SetLatencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for path, dist := range req.Endpoints {
+		if err := dist.validate(); err != nil {
+			http.Error(w, fmt.Sprintf("%s: %v", path, err), http.StatusBadRequest)
+			return
+		}
+	}
+	s.SetLatencyDistributions(req.Endpoints, req.Seed)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+}
@@ -0,0 +1,72 @@
+package mock
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLatencyDistribution checks the delays drawn from a distribution: the
+// jitter stays within its bounds, and the tail gets exactly its share.
+func TestLatencyDistribution(t *testing.T) {
+	server := NewServer("test-key")
+	server.SetLatencyDistributions(map[string]LatencyDistribution{
+		"/api/v1/sdk/flags": {MinMs: 20, JitterMs: 30, MaxMs: 500, Percentile: 99},
+		"*":                 {MinMs: 5, JitterMs: 100, MaxMs: 50},
+	}, 42)
+
+	var tail, jittered int
+	for i := 0; i < 1000; i++ {
+		ms := server.latency.delayFor("/api/v1/sdk/flags")
+		if ms == 500 {
+			tail++
+			continue
+		}
+		assert.GreaterOrEqual(t, ms, 20)
+		assert.LessOrEqual(t, ms, 50)
+		if ms > 20 {
+			jittered++
+		}
+	}
+	assert.Equal(t, 10, tail, "1%% of requests are in the tail")
+	assert.Equal(t, 10, server.GetLatencyStats().Tail)
+	assert.Greater(t, jittered, 900)
+
+	for i := 0; i < 100; i++ {
+		ms := server.latency.delayFor("/api/v1/sdk/events")
+		assert.GreaterOrEqual(t, ms, 5)
+		assert.LessOrEqual(t, ms, 50, "maxMs caps the jitter")
+	}
+
+	server.SetLatency(LatencyConfig{Endpoints: map[string]int{"/api/v1/sdk/events": 7}})
+	assert.Equal(t, 7, server.latency.delayFor("/api/v1/sdk/events"), "an exact delay wins")
+
+	server.ResetLatency()
+	assert.Zero(t, server.latency.delayFor("/api/v1/sdk/flags"))
+}
+
+// TestSetLatencyEndpoint checks the validation of POST
+// /api/v1/test/set-latency.
+func TestSetLatencyEndpoint(t *testing.T) {
+	server := NewServer("test-key")
+	post := func(body string) int {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/test/set-latency", bytes.NewBufferString(body)))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, post(`{"endpoints": {"*": {"minMs": 10, "jitterMs": 5, "maxMs": 200, "percentile": 95}}, "seed": 1}`))
+	assert.Contains(t, server.latency.distributions, "*")
+
+	for _, body := range []string{
+		`{"endpoints": {"*": {"minMs": -1}}}`,
+		`{"endpoints": {"*": {"minMs": 100, "maxMs": 50}}}`,
+		`{"endpoints": {"*": {"minMs": 10, "maxMs": 50, "percentile": 100}}}`,
+		`{"endpoints": {"*": {"minMs": 10, "percentile": 99}}}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, post(body), body)
+	}
+}
@@ -0,0 +1,246 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// OpenAPIVersion is the version of the generated document's info block.
+const OpenAPIVersion = "1.0.0"
+
+// OpenAPI returns an OpenAPI 3.0 document describing every mock endpoint.
+// Schemas are derived by reflection from the wire types in api.go.
+func (s *Server) OpenAPI(serverURL string) map[string]interface{} {
+	gen := &schemaGen{schemas: make(map[string]interface{})}
+	paths := make(map[string]interface{})
+
+	for _, rt := range s.routes() {
+		item := make(map[string]interface{})
+		for _, op := range rt.ops {
+			item[strings.ToLower(op.method)] = gen.operation(rt.path, op)
+		}
+		paths[rt.path] = item
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Rollgate Mock API",
+			"version":     OpenAPIVersion,
+			"description": "SDK endpoints (/api/v1/sdk/*) mirror production; test control endpoints (/api/v1/test/*) configure the mock.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": gen.schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"basic":  map[string]interface{}{"type": "http", "scheme": "basic"},
+				"header": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "query", "name": "api_key"},
+				"token":  map[string]interface{}{"type": "apiKey", "in": "query", "name": "token"},
+			},
+		},
+	}
+	if serverURL != "" {
+		doc["servers"] = []interface{}{map[string]interface{}{"url": serverURL}}
+	}
+	return doc
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(s.OpenAPI(scheme + "://" + r.Host))
+}
+
+// schemaGen converts Go types to OpenAPI schemas, collecting named struct
+// types under components/schemas.
+type schemaGen struct {
+	schemas map[string]interface{}
+}
+
+func (g *schemaGen) operation(path string, op operation) map[string]interface{} {
+	tag := "sdk"
+	if strings.HasPrefix(path, "/api/v1/test/") {
+		tag = "test"
+	} else if !strings.HasPrefix(path, "/api/v1/sdk/") {
+		tag = "meta"
+	}
+
+	result := map[string]interface{}{
+		"summary":     op.summary,
+		"operationId": operationID(op.method, path),
+		"tags":        []string{tag},
+	}
+
+	var params []interface{}
+	for _, p := range op.query {
+		params = append(params, map[string]interface{}{
+			"name":        p.name,
+			"in":          "query",
+			"description": p.description,
+			"schema":      map[string]interface{}{"type": "string"},
+		})
+	}
+	if len(params) > 0 {
+		result["parameters"] = params
+	}
+
+	switch op.auth {
+	case authBearer:
+		// Alternatives; which ones are accepted is set via /api/v1/test/auth
+		result["security"] = []interface{}{
+			map[string]interface{}{"bearer": []string{}},
+			map[string]interface{}{"basic": []string{}},
+			map[string]interface{}{"header": []string{}},
+			map[string]interface{}{"apiKey": []string{}},
+		}
+	case authToken:
+		result["security"] = []interface{}{map[string]interface{}{"token": []string{}}}
+	}
+
+	if op.request != nil {
+		result["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(op.request))},
+			},
+		}
+	}
+
+	responses := make(map[string]interface{})
+	if op.response != nil {
+		contentType, description := "application/json", "OK"
+		if op.stream {
+			contentType = "text/event-stream"
+			description = "Event stream; each data line is a JSON document of this schema"
+		}
+		responses["200"] = map[string]interface{}{
+			"description": description,
+			"content": map[string]interface{}{
+				contentType: map[string]interface{}{"schema": g.schema(reflect.TypeOf(op.response))},
+			},
+		}
+	}
+	if op.auth != authNone {
+		responses["401"] = g.jsonResponse("Invalid API key", ErrorResponse{})
+		if !op.stream {
+			responses["default"] = g.jsonResponse("Simulated error (see /api/v1/test/set-error)", SimulatedErrorResponse{})
+		}
+	}
+	if op.request != nil {
+		responses["400"] = map[string]interface{}{"description": "Malformed request body"}
+	}
+	result["responses"] = responses
+	return result
+}
+
+func (g *schemaGen) jsonResponse(description string, body interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": g.schema(reflect.TypeOf(body))},
+		},
+	}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// schema returns the schema for t, registering named structs as components.
+func (g *schemaGen) schema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]interface{}{"type": "integer", "format": "int64", "description": "Duration in nanoseconds"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := g.schema(t.Elem())
+		if _, isRef := s["$ref"]; isRef {
+			return s
+		}
+		s["nullable"] = true
+		return s
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Interface:
+		return map[string]interface{}{} // any JSON value
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			g.schemas[t.Name()] = nil // reserve to stop recursion
+			g.schemas[t.Name()] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func (g *schemaGen) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if name := jsonFieldName(field); name != "-" {
+			properties[name] = g.schema(field.Type)
+		}
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// jsonFieldName returns the JSON name of a struct field.
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// operationID derives a stable camelCase ID such as getApiV1SdkFlags.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '-' || r == '.' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package mock
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenAPI checks that the served document parses and documents every
+// registered route and operation.
+func TestOpenAPI(t *testing.T) {
+	server := NewServer("test-key")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/v1/test/openapi.json")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, &doc), "document must be valid JSON")
+
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	require.Len(t, doc.Servers, 1)
+	assert.Equal(t, ts.URL, doc.Servers[0].URL)
+
+	routes := server.routes()
+	assert.Len(t, doc.Paths, len(routes), "every path in the document must be a registered route")
+
+	operationIDs := make(map[string]string)
+	for _, rt := range routes {
+		item, ok := doc.Paths[rt.path]
+		if !assert.True(t, ok, "route %s missing from the document", rt.path) {
+			continue
+		}
+		assert.Len(t, item, len(rt.ops), "operations of %s", rt.path)
+
+		for _, op := range rt.ops {
+			raw, ok := item[strings.ToLower(op.method)]
+			if !assert.True(t, ok, "%s %s missing from the document", op.method, rt.path) {
+				continue
+			}
+			var operation struct {
+				OperationID string `json:"operationId"`
+				Summary     string `json:"summary"`
+			}
+			require.NoError(t, json.Unmarshal(raw, &operation))
+			assert.Equal(t, op.summary, operation.Summary)
+			if prev, dup := operationIDs[operation.OperationID]; dup {
+				t.Errorf("operationId %q used by %s and %s %s", operation.OperationID, prev, op.method, rt.path)
+			}
+			operationIDs[operation.OperationID] = op.method + " " + rt.path
+		}
+	}
+
+	// Every schema reference must resolve to a component
+	for _, ref := range strings.Split(string(body), `"$ref": "`)[1:] {
+		name := strings.TrimPrefix(ref[:strings.Index(ref, `"`)], "#/components/schemas/")
+		assert.Contains(t, doc.Components.Schemas, name, "unresolved $ref %s", name)
+	}
+}
@@ -0,0 +1,321 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRecordedRequests bounds the request log; the oldest requests are
+// dropped first.
+const maxRecordedRequests = 1000
+
+// RecordedRequest is an SDK request exactly as it reached the mock, before
+// any handler decoded it, so tests can check encodings byte for byte.
+type RecordedRequest struct {
+	Seq        int         `json:"seq"` // Arrival order, never reset
+	Method     string      `json:"method"`
+	Path       string      `json:"path"`     // Decoded path
+	RawPath    string      `json:"rawPath"`  // Path as sent, percent-encoded
+	RawQuery   string      `json:"rawQuery"` // Query string as sent
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body,omitempty"` // Base64 in JSON
+	ReceivedAt time.Time   `json:"receivedAt"`
+
+	UserID string `json:"userId,omitempty"` // The user the request is for; the identified user for identify
+	Status int    `json:"status,omitempty"` // Response status; 0 if the mock wrote nothing
+	ETag   string `json:"etag,omitempty"`   // Response ETag
+}
+
+// Query decodes the request's query string.
+func (rr RecordedRequest) Query() url.Values {
+	q, _ := url.ParseQuery(rr.RawQuery)
+	return q
+}
+
+// recorderState holds the recorded SDK requests.
+type recorderState struct {
+	mu       sync.Mutex
+	requests []RecordedRequest
+	seq      int
+}
+
+func newRecorderState() *recorderState {
+	return &recorderState{}
+}
+
+// RequestAssertion is the body of POST /api/v1/test/requests/assert. It
+// checks the recorded requests matching Path, Method and SinceSeq; unset
+// checks are skipped.
+type RequestAssertion struct {
+	Path     string `json:"path,omitempty"` // "" matches every SDK path
+	Method   string `json:"method,omitempty"`
+	SinceSeq int    `json:"sinceSeq,omitempty"` // Only requests with a higher Seq
+	MinCount *int   `json:"minCount,omitempty"`
+	MaxCount *int   `json:"maxCount,omitempty"`
+	// Headers every matching request carries, with the given value or "*"
+	// for any value
+	Headers map[string]string `json:"headers,omitempty"`
+	// AbsentHeaders no matching request carries
+	AbsentHeaders []string `json:"absentHeaders,omitempty"`
+	// Bounds on the gaps between consecutive matching requests (polling
+	// cadence), in milliseconds
+	MinIntervalMs int64 `json:"minIntervalMs,omitempty"`
+	MaxIntervalMs int64 `json:"maxIntervalMs,omitempty"`
+	// Revalidates requires every request after a response with an ETag to
+	// send that ETag in If-None-Match
+	Revalidates bool `json:"revalidates,omitempty"`
+}
+
+// RequestAssertionResult is the outcome of a RequestAssertion.
+type RequestAssertionResult struct {
+	Passed   bool     `json:"passed"`
+	Matched  int      `json:"matched"`            // Requests the checks ran on
+	Failures []string `json:"failures,omitempty"` // One per failed check, naming the request's seq
+}
+
+// recordingWriter notes the status and ETag of a recorded request's
+// response as soon as its header is written.
+type recordingWriter struct {
+	http.ResponseWriter
+	recorder *recorderState
+	seq      int
+	wrote    bool
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if !rw.wrote {
+		rw.wrote = true
+		rw.recorder.respond(rw.seq, code, rw.Header().Get("ETag"))
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if !rw.wrote {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// Flush keeps SSE streams working through the wrapper.
+func (rw *recordingWriter) Flush() {
+	if !rw.wrote {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the connection.
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// respond stores the response status and ETag of request seq, unless it
+// has left the log.
+func (rs *recorderState) respond(seq, status int, etag string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	for i := len(rs.requests) - 1; i >= 0; i-- {
+		if rs.requests[i].Seq == seq {
+			rs.requests[i].Status = status
+			rs.requests[i].ETag = etag
+			return
+		}
+	}
+}
+
+// recordRequest adds an SDK request to the log, returning w wrapped to
+// record the response. The body is read in full and replaced, so handlers
+// still see it.
+func (s *Server) recordRequest(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
+	if !strings.HasPrefix(r.URL.Path, "/api/v1/sdk/") {
+		return w
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, _ = io.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	userID := s.requestUserID(r, body)
+
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.recorder.seq++
+	s.recorder.requests = append(s.recorder.requests, RecordedRequest{
+		Seq:        s.recorder.seq,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		RawPath:    r.URL.EscapedPath(),
+		RawQuery:   r.URL.RawQuery,
+		Header:     r.Header.Clone(),
+		Body:       body,
+		ReceivedAt: time.Now(),
+		UserID:     userID,
+	})
+	if n := len(s.recorder.requests); n > maxRecordedRequests {
+		s.recorder.requests = append([]RecordedRequest(nil), s.recorder.requests[n-maxRecordedRequests:]...)
+	}
+	return &recordingWriter{ResponseWriter: w, recorder: s.recorder, seq: s.recorder.seq}
+}
+
+// requestUserID returns the user an SDK request is evaluated for, as the
+// handlers read it, or the user an identify request identifies.
+func (s *Server) requestUserID(r *http.Request, body []byte) string {
+	if r.URL.Path == "/api/v1/sdk/identify" {
+		var req IdentifyRequest
+		if json.Unmarshal(body, &req) == nil && req.User.ID != "" {
+			return req.User.ID
+		}
+	}
+	userID, _ := s.extractUserContext(r)
+	return userID
+}
+
+// RequestSeq returns the sequence number of the latest recorded request,
+// to assert on the requests that follow.
+func (s *Server) RequestSeq() int {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	return s.recorder.seq
+}
+
+// GetRecordedRequests returns the recorded SDK requests to path, or all of
+// them when path is empty.
+func (s *Server) GetRecordedRequests(path string) []RecordedRequest {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	var requests []RecordedRequest
+	for _, req := range s.recorder.requests {
+		if path == "" || req.Path == path {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// AssertRequests runs the checks of a on the recorded requests it matches.
+func (s *Server) AssertRequests(a RequestAssertion) RequestAssertionResult {
+	var matched []RecordedRequest
+	for _, req := range s.GetRecordedRequests(a.Path) {
+		if req.Seq > a.SinceSeq && (a.Method == "" || req.Method == a.Method) {
+			matched = append(matched, req)
+		}
+	}
+
+	result := RequestAssertionResult{Matched: len(matched)}
+	fail := func(format string, args ...any) {
+		result.Failures = append(result.Failures, fmt.Sprintf(format, args...))
+	}
+	if a.MinCount != nil && len(matched) < *a.MinCount {
+		fail("%d requests, want at least %d", len(matched), *a.MinCount)
+	}
+	if a.MaxCount != nil && len(matched) > *a.MaxCount {
+		fail("%d requests, want at most %d", len(matched), *a.MaxCount)
+	}
+	etag := ""
+	for i, req := range matched {
+		for name, want := range a.Headers {
+			got, ok := req.Header[http.CanonicalHeaderKey(name)]
+			switch {
+			case !ok:
+				fail("request %d: missing %s", req.Seq, name)
+			case want != "*" && got[0] != want:
+				fail("request %d: %s is %q, want %q", req.Seq, name, got[0], want)
+			}
+		}
+		for _, name := range a.AbsentHeaders {
+			if req.Header.Get(name) != "" {
+				fail("request %d: unexpected %s", req.Seq, name)
+			}
+		}
+		if i > 0 {
+			gap := req.ReceivedAt.Sub(matched[i-1].ReceivedAt).Milliseconds()
+			if a.MinIntervalMs > 0 && gap < a.MinIntervalMs {
+				fail("request %d: %dms after the previous one, want at least %dms", req.Seq, gap, a.MinIntervalMs)
+			}
+			if a.MaxIntervalMs > 0 && gap > a.MaxIntervalMs {
+				fail("request %d: %dms after the previous one, want at most %dms", req.Seq, gap, a.MaxIntervalMs)
+			}
+		}
+		if a.Revalidates && etag != "" && req.Header.Get("If-None-Match") != etag {
+			fail("request %d: If-None-Match is %q, want the previous ETag %q", req.Seq, req.Header.Get("If-None-Match"), etag)
+		}
+		if req.ETag != "" {
+			etag = req.ETag
+		}
+	}
+	result.Passed = len(result.Failures) == 0
+	return result
+}
+
+// ClearRecordedRequests empties the request log. Sequence numbers keep
+// counting.
+func (s *Server) ClearRecordedRequests() {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.recorder.requests = nil
+}
+
+// handleRecordedRequests is the test control endpoint for the SDK request
+// log (GET lists, optionally filtered by ?path= and ?since=, DELETE clears).
+func (s *Server) handleRecordedRequests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		requests := s.GetRecordedRequests(r.URL.Query().Get("path"))
+		if since := r.URL.Query().Get("since"); since != "" {
+			seq, err := strconv.Atoi(since)
+			if err != nil {
+				http.Error(w, "since must be a sequence number", http.StatusBadRequest)
+				return
+			}
+			kept := requests[:0]
+			for _, req := range requests {
+				if req.Seq > seq {
+					kept = append(kept, req)
+				}
+			}
+			requests = kept
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(RecordedRequestsResponse{Requests: requests, Count: len(requests)})
+
+	case http.MethodDelete:
+		s.ClearRecordedRequests()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAssertRequests is the test control endpoint running a
+// RequestAssertion on the SDK request log.
+func (s *Server) handleAssertRequests(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var a RequestAssertion
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.AssertRequests(a))
+}
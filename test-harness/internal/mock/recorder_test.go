@@ -0,0 +1,120 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRecorder checks that SDK requests are logged exactly as sent and that
+// handlers still see the body.
+func TestRecorder(t *testing.T) {
+	server := NewServer("test-key")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	body := `{"user":{"id":"usér-😀","attributes":{"name":"a b"}}}`
+	req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/sdk/identify?user_id=a%2Fb%26c", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer test-key")
+	req.Header.Set("X-User-ID", "a%2Fb")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	resp, err = http.Get(ts.URL + "/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	requests := server.GetRecordedRequests("")
+	require.Len(t, requests, 1, "only SDK requests are recorded")
+	rec := requests[0]
+	assert.Equal(t, http.MethodPost, rec.Method)
+	assert.Equal(t, "/api/v1/sdk/identify", rec.Path)
+	assert.Equal(t, "user_id=a%2Fb%26c", rec.RawQuery)
+	assert.Equal(t, "a/b&c", rec.Query().Get("user_id"))
+	assert.Equal(t, "a%2Fb", rec.Header.Get("X-User-ID"))
+	assert.Equal(t, body, string(rec.Body))
+	require.Len(t, server.receivedIdentifies, 1, "the handler should still read the body")
+	assert.Equal(t, "usér-😀", server.receivedIdentifies[0].ID)
+
+	resp, err = http.Get(ts.URL + "/api/v1/test/requests?path=/api/v1/sdk/identify")
+	require.NoError(t, err)
+	var listed RecordedRequestsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+	resp.Body.Close()
+	require.Equal(t, 1, listed.Count)
+	assert.Equal(t, body, string(listed.Requests[0].Body), "the body survives the JSON round trip")
+
+	server.ClearRecordedRequests()
+	assert.Empty(t, server.GetRecordedRequests(""))
+	assert.Empty(t, server.GetRecordedRequests("/api/v1/sdk/flags"))
+}
+
+// TestAssertRequests checks the request log's response fields and the
+// checks of POST /api/v1/test/requests/assert.
+func TestAssertRequests(t *testing.T) {
+	server := NewServer("test-key")
+	server.SetFlag(&FlagState{Key: "beta", Enabled: true, RolloutPercentage: 100})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	get := func(ifNoneMatch string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/api/v1/sdk/flags?user_id=user-1", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer test-key")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+	since := server.RequestSeq()
+	etag := get("").Header.Get("ETag")
+	require.NotEmpty(t, etag)
+	get(etag)
+	get(`"stale"`)
+
+	requests := server.GetRecordedRequests("/api/v1/sdk/flags")
+	require.Len(t, requests, 3)
+	assert.Equal(t, "user-1", requests[0].UserID)
+	assert.Equal(t, []int{200, 304, 200}, []int{requests[0].Status, requests[1].Status, requests[2].Status})
+	assert.Equal(t, etag, requests[0].ETag)
+
+	three := 3
+	result := server.AssertRequests(RequestAssertion{
+		Path:          "/api/v1/sdk/flags",
+		SinceSeq:      since,
+		MinCount:      &three,
+		Headers:       map[string]string{"Authorization": "Bearer test-key"},
+		AbsentHeaders: []string{"X-Debug"},
+	})
+	assert.True(t, result.Passed, "%v", result.Failures)
+	assert.Equal(t, 3, result.Matched)
+
+	body := `{"path": "/api/v1/sdk/flags", "sinceSeq": ` + strconv.Itoa(since+1) + `, "maxCount": 1,
+		"headers": {"X-SDK-Version": "*"}, "minIntervalMs": 60000, "revalidates": true}`
+	resp, err := http.Post(ts.URL+"/api/v1/test/requests/assert", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	resp.Body.Close()
+	assert.False(t, result.Passed)
+	assert.Equal(t, 2, result.Matched)
+	assert.ElementsMatch(t, []string{
+		"2 requests, want at most 1",
+		fmt.Sprintf("request %d: missing X-SDK-Version", since+2),
+		fmt.Sprintf("request %d: missing X-SDK-Version", since+3),
+		fmt.Sprintf("request %d: %dms after the previous one, want at least 60000ms", since+3,
+			requests[2].ReceivedAt.Sub(requests[1].ReceivedAt).Milliseconds()),
+		fmt.Sprintf(`request %d: If-None-Match is "\"stale\"", want the previous ETag %q`, since+3, etag),
+	}, result.Failures)
+}
@@ -0,0 +1,232 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// redirectedPrefix marks requests that followed a simulated redirect. The
+// prefix is stripped before routing and such requests are never redirected
+// again, so redirects cannot loop.
+const redirectedPrefix = "/redirected"
+
+// RedirectConfig makes SDK endpoints answer with a redirect.
+type RedirectConfig struct {
+	// StatusCode is the redirect status: 301, 302, 307 or 308
+	StatusCode int `json:"statusCode"`
+
+	// Target is the base URL redirected to (default: the request's own host).
+	// Use a different host name for the same server (e.g. 127.0.0.1 instead
+	// of localhost) to simulate a cross-host redirect.
+	Target string `json:"target,omitempty"`
+
+	// Paths limits redirects to these paths (default: all /api/v1/sdk/ paths
+	// except the stream)
+	Paths []string `json:"paths,omitempty"`
+
+	// Count is the number of requests to redirect (-1 = always)
+	Count int `json:"count"`
+}
+
+// ProxyConfig makes SDK endpoints accept only proxy-style requests.
+type ProxyConfig struct {
+	// Require rejects requests that do not use an absolute request URI
+	// ("GET http://host/path"), as sent by clients talking to a forward proxy
+	Require bool `json:"require"`
+
+	// Authorization, if set, is the exact Proxy-Authorization header value
+	// required; other requests get 407
+	Authorization string `json:"authorization,omitempty"`
+}
+
+// RedirectStats counts redirect and proxy traffic on SDK endpoints.
+type RedirectStats struct {
+	Redirected     int `json:"redirected"`     // Redirect responses sent
+	Followed       int `json:"followed"`       // Requests that arrived via a redirect Location
+	FollowedNoAuth int `json:"followedNoAuth"` // Followed requests without credentials
+	ProxyRequests  int `json:"proxyRequests"`  // Requests with an absolute request URI
+	ProxyRejected  int `json:"proxyRejected"`  // Requests rejected by ProxyConfig
+}
+
+// redirectState holds redirect and proxy settings and counters.
+type redirectState struct {
+	mu       sync.Mutex
+	redirect *RedirectConfig
+	sent     int
+	proxy    ProxyConfig
+	stats    RedirectStats
+}
+
+func newRedirectState() *redirectState {
+	return &redirectState{}
+}
+
+// applyRedirects handles proxy requirements and simulated redirects for SDK
+// endpoints. It strips the redirected prefix from followed requests and
+// reports whether a response has been written.
+func (s *Server) applyRedirects(w http.ResponseWriter, r *http.Request) bool {
+	followed := false
+	if strings.HasPrefix(r.URL.Path, redirectedPrefix+"/") {
+		r.URL.Path = strings.TrimPrefix(r.URL.Path, redirectedPrefix)
+		followed = true
+	}
+	if !strings.HasPrefix(r.URL.Path, "/api/v1/sdk/") {
+		return false
+	}
+
+	rs := s.redirects
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	absolute := strings.HasPrefix(r.RequestURI, "http://") || strings.HasPrefix(r.RequestURI, "https://")
+	if absolute {
+		rs.stats.ProxyRequests++
+	}
+	if rs.proxy.Require && !absolute {
+		rs.stats.ProxyRejected++
+		http.Error(w, `{"error":"ProxyRequired","message":"request must be sent through a proxy"}`, http.StatusForbidden)
+		return true
+	}
+	if rs.proxy.Authorization != "" && r.Header.Get("Proxy-Authorization") != rs.proxy.Authorization {
+		rs.stats.ProxyRejected++
+		w.Header().Set("Proxy-Authenticate", `Basic realm="rollgate-mock"`)
+		http.Error(w, `{"error":"ProxyAuthenticationRequired","message":"invalid proxy credentials"}`, http.StatusProxyAuthRequired)
+		return true
+	}
+
+	if followed {
+		rs.stats.Followed++
+		if len(presentedSchemes(r)) == 0 {
+			rs.stats.FollowedNoAuth++
+		}
+		return false
+	}
+
+	rc := rs.redirect
+	if rc == nil || (rc.Count != -1 && rs.sent >= rc.Count) || !redirectsPath(rc, r.URL.Path) {
+		return false
+	}
+	rs.sent++
+	rs.stats.Redirected++
+
+	target := rc.Target
+	if target == "" {
+		target = "http://" + r.Host
+	}
+	location := strings.TrimRight(target, "/") + redirectedPrefix + r.URL.Path
+	if r.URL.RawQuery != "" {
+		location += "?" + r.URL.RawQuery
+	}
+	w.Header().Set("Location", location)
+	w.WriteHeader(rc.StatusCode)
+	return true
+}
+
+func redirectsPath(rc *RedirectConfig, path string) bool {
+	if len(rc.Paths) == 0 {
+		return path != "/api/v1/sdk/stream"
+	}
+	for _, p := range rc.Paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRedirect enables simulated redirects.
+func (s *Server) SetRedirect(config RedirectConfig) error {
+	switch config.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return fmt.Errorf("unsupported redirect status %d (want 301, 302, 307 or 308)", config.StatusCode)
+	}
+
+	s.redirects.mu.Lock()
+	defer s.redirects.mu.Unlock()
+	s.redirects.redirect = &config
+	s.redirects.sent = 0
+	return nil
+}
+
+// SetProxy configures proxy-style request requirements.
+func (s *Server) SetProxy(config ProxyConfig) {
+	s.redirects.mu.Lock()
+	defer s.redirects.mu.Unlock()
+	s.redirects.proxy = config
+}
+
+// GetRedirectStats returns redirect and proxy counters.
+func (s *Server) GetRedirectStats() RedirectStats {
+	s.redirects.mu.Lock()
+	defer s.redirects.mu.Unlock()
+	return s.redirects.stats
+}
+
+// ResetRedirects disables redirects and proxy requirements and clears counters.
+func (s *Server) ResetRedirects() {
+	s.redirects.mu.Lock()
+	defer s.redirects.mu.Unlock()
+	s.redirects.redirect = nil
+	s.redirects.sent = 0
+	s.redirects.proxy = ProxyConfig{}
+	s.redirects.stats = RedirectStats{}
+}
+
+// handleRedirect is the test control endpoint for redirects
+// (POST sets, GET returns settings and stats, DELETE resets).
+func (s *Server) handleRedirect(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var config RedirectConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.SetRedirect(config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	case http.MethodGet:
+		s.redirects.mu.Lock()
+		resp := RedirectResponse{Redirect: s.redirects.redirect, Proxy: s.redirects.proxy, Stats: s.redirects.stats}
+		s.redirects.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+
+	case http.MethodDelete:
+		s.ResetRedirects()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleProxy is the test control endpoint for proxy requirements (POST).
+func (s *Server) handleProxy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var config ProxyConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.SetProxy(config)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+}
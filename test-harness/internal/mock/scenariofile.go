@@ -0,0 +1,154 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScenarioFile is a scenario declared in a YAML or JSON file (see the
+// scenarios/ directory) instead of in code. Field names are the same in
+// both formats and match the mock's JSON API.
+type ScenarioFile struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Extends names a built-in scenario (basic, targeting, ...) loaded
+	// before the file's flags; empty starts from no flags
+	Extends  string                 `json:"extends,omitempty"`
+	Flags    []FlagState            `json:"flags,omitempty"`
+	Segments map[string][]Condition `json:"segments,omitempty"`
+	// Error is simulated once the scenario is loaded; nil clears any
+	// simulation left by a previous scenario
+	Error *ScenarioError `json:"error,omitempty"`
+}
+
+// ScenarioError is the error simulation of a scenario file. DelayMs is the
+// readable form of ErrorSimulation.Delay.
+type ScenarioError struct {
+	ErrorSimulation
+	DelayMs int `json:"delayMs,omitempty"`
+}
+
+// builtinScenarios are the names FlagStore.LoadScenario knows.
+var builtinScenarios = map[string]bool{
+	"basic": true, "targeting": true, "rollout": true,
+	"segments": true, "environments": true, "empty": true,
+}
+
+// ReadScenarioFile reads and validates a scenario file. Files ending in
+// .yaml or .yml are YAML, anything else JSON.
+func ReadScenarioFile(path string) (*ScenarioFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// Go through JSON so both formats share the json tags
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	scenario, err := ParseScenario(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if scenario.Name == "" {
+		scenario.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return scenario, nil
+}
+
+// ParseScenario decodes and validates a JSON scenario. Unknown fields are
+// rejected so a typo does not silently drop part of the scenario.
+func ParseScenario(data []byte) (*ScenarioFile, error) {
+	var scenario ScenarioFile
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&scenario); err != nil {
+		return nil, err
+	}
+	if err := scenario.validate(); err != nil {
+		return nil, err
+	}
+	return &scenario, nil
+}
+
+func (f *ScenarioFile) validate() error {
+	if f.Extends != "" && !builtinScenarios[f.Extends] {
+		return fmt.Errorf("extends unknown scenario %q", f.Extends)
+	}
+	seen := make(map[string]bool, len(f.Flags))
+	for i, flag := range f.Flags {
+		if flag.Key == "" {
+			return fmt.Errorf("flags[%d] has no key", i)
+		}
+		if seen[flag.Key] {
+			return fmt.Errorf("flag %q is declared twice", flag.Key)
+		}
+		seen[flag.Key] = true
+		if flag.RolloutPercentage < 0 || flag.RolloutPercentage > 100 {
+			return fmt.Errorf("flag %q: rolloutPercentage must be between 0 and 100", flag.Key)
+		}
+	}
+	for id := range f.Segments {
+		if id == "" {
+			return fmt.Errorf("segment with an empty id")
+		}
+	}
+	if f.Error != nil && (f.Error.StatusCode < 100 || f.Error.StatusCode > 599) {
+		return fmt.Errorf("error.statusCode %d is not an HTTP status", f.Error.StatusCode)
+	}
+	return nil
+}
+
+// LoadScenario replaces the mock's flags, segments and error simulation with
+// the scenario's, as SetScenario does for a built-in one.
+func (s *Server) LoadScenario(scenario *ScenarioFile) {
+	if scenario.Extends != "" {
+		s.flags.LoadScenario(scenario.Extends)
+	} else {
+		s.flags.Clear()
+	}
+	flags := make([]*FlagState, len(scenario.Flags))
+	for i := range scenario.Flags {
+		flag := scenario.Flags[i]
+		flags[i] = &flag
+	}
+	s.SetFlags(flags)
+
+	s.ClearSegments()
+	for id, conditions := range scenario.Segments {
+		s.SetSegment(id, conditions)
+	}
+
+	if scenario.Error == nil {
+		s.ClearError()
+		return
+	}
+	sim := scenario.Error.ErrorSimulation
+	if scenario.Error.DelayMs > 0 {
+		sim.Delay = time.Duration(scenario.Error.DelayMs) * time.Millisecond
+	}
+	s.SetError(&sim)
+}
+
+// LoadScenarioFile reads a YAML or JSON scenario file and loads it.
+func (s *Server) LoadScenarioFile(path string) error {
+	scenario, err := ReadScenarioFile(path)
+	if err != nil {
+		return err
+	}
+	s.LoadScenario(scenario)
+	return nil
+}
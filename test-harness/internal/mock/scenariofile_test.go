@@ -0,0 +1,74 @@
+package mock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScenarioFiles checks that every file in scenarios/ loads.
+func TestScenarioFiles(t *testing.T) {
+	paths, err := filepath.Glob("../../scenarios/*")
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+	for _, path := range paths {
+		_, err := ReadScenarioFile(path)
+		assert.NoError(t, err, path)
+	}
+}
+
+// TestLoadScenarioFile checks that a scenario file replaces the flags,
+// segments and error simulation, in YAML and JSON alike.
+func TestLoadScenarioFile(t *testing.T) {
+	server := NewServer("test-key")
+	server.SetError(&ErrorSimulation{StatusCode: 500, Count: -1})
+
+	require.NoError(t, server.LoadScenarioFile("../../scenarios/pro-plan.yaml"))
+	for _, key := range []string{"enabled-flag", "disabled-flag", "rollout-50"} {
+		_, ok := server.flags.Get(key)
+		assert.True(t, ok, "%s comes from the basic scenario", key)
+	}
+	flag, ok := server.flags.Get("pro-feature")
+	require.True(t, ok)
+	assert.Equal(t, "pro-users", flag.Rules[0].Conditions[0].Value)
+	assert.Equal(t, []Condition{{Attribute: "plan", Operator: "eq", Value: "pro"}}, server.segments["pro-users"])
+	assert.Nil(t, server.errorSim, "a scenario without an error clears the simulation")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "slow.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"flags": [{"key": "only", "enabled": true}],
+		"error": {"statusCode": 429, "count": 2, "retryAfter": 5, "delayMs": 150}}`), 0o644))
+	require.NoError(t, server.LoadScenarioFile(path))
+	assert.Len(t, server.flags.GetAll(), 1, "no extends starts from no flags")
+	assert.Empty(t, server.segments)
+	require.NotNil(t, server.errorSim)
+	assert.Equal(t, 429, server.errorSim.StatusCode)
+	assert.Equal(t, 150*time.Millisecond, server.errorSim.Delay)
+}
+
+// TestReadScenarioFileErrors checks that invalid scenario files are
+// rejected instead of loaded in part.
+func TestReadScenarioFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"typo.yaml":      "flgas:\n  - key: a\n",
+		"no-key.yaml":    "flags:\n  - enabled: true\n",
+		"twice.yaml":     "flags:\n  - key: a\n  - key: a\n",
+		"rollout.json":   `{"flags": [{"key": "a", "rolloutPercentage": 150}]}`,
+		"extends.yaml":   "extends: nope\n",
+		"status.json":    `{"error": {"count": 1}}`,
+		"malformed.yaml": "flags: [\n",
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		_, err := ReadScenarioFile(path)
+		assert.Error(t, err, name)
+	}
+
+	_, err := ReadScenarioFile(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
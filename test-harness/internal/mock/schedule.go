@@ -0,0 +1,172 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ScheduleChangeRequest is the body of POST /api/v1/test/schedule-change.
+// The change is due at At, or AfterMs from now if At is unset.
+type ScheduleChangeRequest struct {
+	At      *time.Time `json:"at,omitempty"` // Host clock, RFC 3339
+	AfterMs int64      `json:"afterMs,omitempty"`
+	Flag    FlagState  `json:"flag"` // Replaces the flag when the change fires
+}
+
+// ScheduledChange is a flag change scheduled on the host clock.
+type ScheduledChange struct {
+	ID      int        `json:"id"`
+	FlagKey string     `json:"flagKey"`
+	At      time.Time  `json:"at"`                // When the change is due
+	FiredAt *time.Time `json:"firedAt,omitempty"` // When the flag changed; unset while pending
+	Flag    FlagState  `json:"flag"`
+}
+
+// ScheduledChangesResponse is returned by GET /api/v1/test/schedule-change.
+type ScheduledChangesResponse struct {
+	Pending []ScheduledChange `json:"pending"` // By due time
+	Fired   []ScheduledChange `json:"fired"`   // In firing order
+}
+
+// scheduleState holds the flag changes scheduled on the host clock. Unlike
+// the virtual clock's flips they fire on their own, for tests of SDKs
+// reacting to changes while they run.
+type scheduleState struct {
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]*pendingChange
+	fired   []ScheduledChange
+}
+
+// pendingChange is a scheduled change and the timer that fires it.
+type pendingChange struct {
+	change ScheduledChange
+	timer  *time.Timer
+}
+
+func newScheduleState() *scheduleState {
+	return &scheduleState{pending: make(map[int]*pendingChange)}
+}
+
+// ScheduleChange replaces flag.Key with flag at the host time at, and
+// broadcasts the change to SSE clients as a dashboard change would be. A
+// time in the past fires right away.
+func (s *Server) ScheduleChange(flag FlagState, at time.Time) ScheduledChange {
+	s.schedule.mu.Lock()
+	defer s.schedule.mu.Unlock()
+	s.schedule.nextID++
+	change := ScheduledChange{ID: s.schedule.nextID, FlagKey: flag.Key, At: at, Flag: flag}
+	pending := &pendingChange{change: change}
+	s.schedule.pending[change.ID] = pending
+	pending.timer = time.AfterFunc(time.Until(at), func() { s.fireChange(change.ID) })
+	return change
+}
+
+// fireChange applies a pending change, unless it was cancelled.
+func (s *Server) fireChange(id int) {
+	s.schedule.mu.Lock()
+	pending, ok := s.schedule.pending[id]
+	if !ok {
+		s.schedule.mu.Unlock()
+		return
+	}
+	delete(s.schedule.pending, id)
+	// Applied under the lock, so that a cancel cannot interleave
+	flag := pending.change.Flag
+	s.flags.Set(&flag)
+	s.BroadcastFlagChange(flag.Key, flag.Enabled)
+	firedAt := time.Now()
+	change := pending.change
+	change.FiredAt = &firedAt
+	s.schedule.fired = append(s.schedule.fired, change)
+	s.schedule.mu.Unlock()
+}
+
+// GetScheduledChanges returns the pending and fired scheduled changes.
+func (s *Server) GetScheduledChanges() ScheduledChangesResponse {
+	s.schedule.mu.Lock()
+	defer s.schedule.mu.Unlock()
+	resp := ScheduledChangesResponse{
+		Pending: make([]ScheduledChange, 0, len(s.schedule.pending)),
+		Fired:   append([]ScheduledChange{}, s.schedule.fired...),
+	}
+	for _, pending := range s.schedule.pending {
+		resp.Pending = append(resp.Pending, pending.change)
+	}
+	sort.Slice(resp.Pending, func(i, j int) bool {
+		a, b := resp.Pending[i], resp.Pending[j]
+		return a.At.Before(b.At) || (a.At.Equal(b.At) && a.ID < b.ID)
+	})
+	return resp
+}
+
+// CancelScheduledChanges drops all pending changes and the fired ones'
+// history.
+func (s *Server) CancelScheduledChanges() {
+	s.schedule.mu.Lock()
+	defer s.schedule.mu.Unlock()
+	for id, pending := range s.schedule.pending {
+		pending.timer.Stop()
+		delete(s.schedule.pending, id)
+	}
+	s.schedule.fired = nil
+}
+
+// handleScheduleChange is the test control endpoint for scheduled changes
+// (POST schedules one, GET lists them, DELETE cancels them all).
+func (s *Server) handleScheduleChange(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req ScheduleChangeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		at, err := req.dueTime(time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		change := s.ScheduleChange(req.Flag, at)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(change)
+
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.GetScheduledChanges())
+
+	case http.MethodDelete:
+		s.CancelScheduledChanges()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// dueTime validates the request and returns when the change is due.
+func (req ScheduleChangeRequest) dueTime(now time.Time) (time.Time, error) {
+	if req.Flag.Key == "" {
+		return time.Time{}, fmt.Errorf("flag.key is required")
+	}
+	if req.At != nil && req.AfterMs != 0 {
+		return time.Time{}, fmt.Errorf("set either at or afterMs, not both")
+	}
+	if req.AfterMs < 0 {
+		return time.Time{}, fmt.Errorf("afterMs must not be negative")
+	}
+	if req.At != nil {
+		if req.At.Before(now) {
+			return time.Time{}, fmt.Errorf("at must not be in the past")
+		}
+		return *req.At, nil
+	}
+	return now.Add(time.Duration(req.AfterMs) * time.Millisecond), nil
+}
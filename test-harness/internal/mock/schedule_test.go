@@ -0,0 +1,81 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScheduleChange checks that scheduled changes replace the flag once
+// due, are broadcast to SSE clients, and can be cancelled.
+func TestScheduleChange(t *testing.T) {
+	server := NewServer("test-key")
+	server.SetFlag(&FlagState{Key: "rollout", Enabled: true, RolloutPercentage: 10})
+	conn := server.sse.add(&sseSubscriber{})
+	defer server.sse.remove(conn)
+
+	start := time.Now()
+	server.ScheduleChange(FlagState{Key: "rollout", Enabled: true, RolloutPercentage: 50}, start.Add(50*time.Millisecond))
+	cancelled := server.ScheduleChange(FlagState{Key: "rollout", Enabled: false}, start.Add(time.Hour))
+
+	pending := server.GetScheduledChanges().Pending
+	require.Len(t, pending, 2)
+	assert.Equal(t, 50, pending[0].Flag.RolloutPercentage, "pending changes are listed by due time")
+
+	require.Eventually(t, func() bool { return len(server.GetScheduledChanges().Fired) == 1 }, 2*time.Second, 5*time.Millisecond)
+	fired := server.GetScheduledChanges().Fired[0]
+	assert.False(t, fired.FiredAt.Before(fired.At), "a change fires no earlier than due")
+	flag, _ := server.GetFlagStore().Get("rollout")
+	assert.Equal(t, 50, flag.RolloutPercentage)
+	assert.Equal(t, 2, flag.Version)
+
+	select {
+	case msg := <-conn.queue:
+		assert.Equal(t, "flag-changed", msg.event)
+		assert.Contains(t, string(msg.data), `"key":"rollout"`)
+	case <-time.After(time.Second):
+		t.Fatal("expected the change to be broadcast")
+	}
+
+	server.CancelScheduledChanges()
+	state := server.GetScheduledChanges()
+	assert.Empty(t, state.Pending)
+	assert.Empty(t, state.Fired)
+	server.fireChange(cancelled.ID)
+	flag, _ = server.GetFlagStore().Get("rollout")
+	assert.True(t, flag.Enabled, "a cancelled change does not fire")
+}
+
+// TestScheduleChangeEndpoint checks the validation of POST
+// /api/v1/test/schedule-change.
+func TestScheduleChangeEndpoint(t *testing.T) {
+	server := NewServer("test-key")
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/test/schedule-change", bytes.NewBufferString(body)))
+		return rec
+	}
+
+	rec := post(`{"afterMs": 60000, "flag": {"key": "later", "enabled": true}}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var change ScheduledChange
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&change))
+	assert.Equal(t, "later", change.FlagKey)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), change.At, 5*time.Second)
+
+	for _, body := range []string{
+		`{"afterMs": 1000, "flag": {}}`,
+		`{"afterMs": -1, "flag": {"key": "f"}}`,
+		`{"at": "2000-01-01T00:00:00Z", "flag": {"key": "f"}}`,
+		`{"at": "2999-01-01T00:00:00Z", "afterMs": 1000, "flag": {"key": "f"}}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, post(body).Code, body)
+	}
+	server.CancelScheduledChanges()
+}
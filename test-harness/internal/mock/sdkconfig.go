@@ -0,0 +1,121 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Headers carrying SDK configuration hints on flags responses.
+const (
+	HeaderPollInterval = "X-Rollgate-Poll-Interval" // Seconds
+	HeaderStreaming    = "X-Rollgate-Streaming"     // "true" or "false"
+)
+
+// SDKConfig is the "sdkConfig" object of a flags payload: configuration
+// the API suggests to every SDK it serves.
+type SDKConfig struct {
+	PollIntervalSeconds float64 `json:"pollIntervalSeconds,omitempty"`
+	Streaming           *bool   `json:"streaming,omitempty"`
+}
+
+// SDKConfigHints makes the mock suggest SDK configuration on
+// /api/v1/sdk/flags responses, including 304s, so SDKs that honor server
+// hints can be tested.
+type SDKConfigHints struct {
+	PollIntervalSeconds float64 `json:"pollIntervalSeconds,omitempty"` // 0 = no poll interval hint
+	Streaming           *bool   `json:"streaming,omitempty"`           // Unset = no streaming hint
+	// Delivery is how hints are sent: "headers" (default), "body" for the
+	// payload's sdkConfig object only, or "both"
+	Delivery string `json:"delivery,omitempty"`
+}
+
+// sdkConfigState holds the hints sent with flags responses.
+type sdkConfigState struct {
+	mu    sync.Mutex
+	hints SDKConfigHints
+}
+
+func newSDKConfigState() *sdkConfigState {
+	return &sdkConfigState{}
+}
+
+// apply sets the hint headers on a flags response and returns the
+// payload's sdkConfig object, or nil if hints are not sent in the body.
+func (sc *sdkConfigState) apply(w http.ResponseWriter) *SDKConfig {
+	sc.mu.Lock()
+	hints := sc.hints
+	sc.mu.Unlock()
+	if hints.PollIntervalSeconds == 0 && hints.Streaming == nil {
+		return nil
+	}
+
+	if hints.Delivery != "body" {
+		if hints.PollIntervalSeconds != 0 {
+			w.Header().Set(HeaderPollInterval, strconv.FormatFloat(hints.PollIntervalSeconds, 'f', -1, 64))
+		}
+		if hints.Streaming != nil {
+			w.Header().Set(HeaderStreaming, strconv.FormatBool(*hints.Streaming))
+		}
+	}
+	if hints.Delivery != "body" && hints.Delivery != "both" {
+		return nil
+	}
+	return &SDKConfig{PollIntervalSeconds: hints.PollIntervalSeconds, Streaming: hints.Streaming}
+}
+
+// SetSDKConfigHints replaces the hints sent with flags responses.
+func (s *Server) SetSDKConfigHints(hints SDKConfigHints) {
+	s.sdkConfig.mu.Lock()
+	defer s.sdkConfig.mu.Unlock()
+	s.sdkConfig.hints = hints
+}
+
+// GetSDKConfigHints returns the hints sent with flags responses.
+func (s *Server) GetSDKConfigHints() SDKConfigHints {
+	s.sdkConfig.mu.Lock()
+	defer s.sdkConfig.mu.Unlock()
+	return s.sdkConfig.hints
+}
+
+// ResetSDKConfigHints stops sending hints.
+func (s *Server) ResetSDKConfigHints() {
+	s.SetSDKConfigHints(SDKConfigHints{})
+}
+
+// handleSDKConfig is the test control endpoint for SDK configuration hints
+// (POST sets, GET returns, DELETE resets).
+func (s *Server) handleSDKConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var hints SDKConfigHints
+		if err := json.NewDecoder(r.Body).Decode(&hints); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch hints.Delivery {
+		case "", "headers", "body", "both":
+		default:
+			http.Error(w, `{"error":"delivery must be headers, body or both"}`, http.StatusBadRequest)
+			return
+		}
+		s.SetSDKConfigHints(hints)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.GetSDKConfigHints())
+
+	case http.MethodDelete:
+		s.ResetSDKConfigHints()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -24,6 +24,22 @@ type ErrorSimulation struct {
 	RetryAfter int           `json:"retryAfter"` // Retry-After header value for 429
 	Delay      time.Duration `json:"delay"`      // Delay before responding (for timeout testing)
 	Message    string        `json:"message"`    // Error message
+	// Endpoints lists the SDK paths to fail; empty fails the flags
+	// endpoints only
+	Endpoints []string `json:"endpoints,omitempty"`
+}
+
+// appliesTo reports whether the simulation fails requests to path.
+func (sim *ErrorSimulation) appliesTo(path string) bool {
+	if len(sim.Endpoints) == 0 {
+		return path == "/api/v1/sdk/flags" || path == "/api/v1/sdk/v2/flags"
+	}
+	for _, endpoint := range sim.Endpoints {
+		if endpoint == path {
+			return true
+		}
+	}
+	return false
 }
 
 // TrackEventItem represents a single tracked event received by the mock server.
@@ -32,6 +48,7 @@ type TrackEventItem struct {
 	EventName   string                 `json:"eventName"`
 	UserID      string                 `json:"userId"`
 	VariationID string                 `json:"variationId,omitempty"`
+	Reason      *EvaluationReason      `json:"reason,omitempty"` // Reason of the evaluation the event is attributed to
 	Value       *float64               `json:"value,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 	Timestamp   *time.Time             `json:"timestamp,omitempty"`
@@ -42,21 +59,63 @@ type EvalStats struct {
 	Total int `json:"total"`
 	True  int `json:"true"`
 	False int `json:"false"`
+
+	// Breakdowns sent by SDKs that report them; nil otherwise.
+	Reasons    map[string]int `json:"reasons,omitempty"`    // Evaluations per reason kind
+	Variations map[string]int `json:"variations,omitempty"` // Evaluations per variation ID
+}
+
+// DefaultStats counts evaluations of a flag key that returned the caller's
+// default because no value was available, by cause (FLAG_NOT_FOUND,
+// CLIENT_NOT_READY, ...).
+type DefaultStats struct {
+	Total  int            `json:"total"`
+	Causes map[string]int `json:"causes"`
 }
 
 // TelemetryPayload represents a telemetry batch payload.
 type TelemetryPayload struct {
 	Evaluations map[string]EvalStats `json:"evaluations"`
 	PeriodMs    int                  `json:"period_ms"`
+
+	Defaults map[string]DefaultStats `json:"defaults,omitempty"` // Sent by SDKs that report them
+}
+
+// ReceivedTelemetry is a telemetry batch as recorded by the mock. Seq counts
+// batches in arrival order and is not reset by clearing the received list,
+// so tests can spot batches that were dropped or delivered twice.
+type ReceivedTelemetry struct {
+	Evaluations map[string]EvalStats `json:"evaluations"`
+	PeriodMs    int                  `json:"period_ms"`
+	Seq         int                  `json:"seq"`
+	ReceivedAt  time.Time            `json:"receivedAt"`
+
+	Defaults map[string]DefaultStats `json:"defaults,omitempty"`
+}
+
+// sseSubscriber is the user and options of one SSE connection, used to
+// evaluate reasons for flag-changed events.
+type sseSubscriber struct {
+	userID      string
+	env         string
+	withReasons bool
+	v2          bool // Opened with ?format=v2: typed flags-v2 and flag-update-v2 events
+
+	// record logs the outcome of sending an event; nil in tests
+	record func(msg sseMessage, status string)
+}
+
+// sseMessage is one event queued for an SSE connection.
+type sseMessage struct {
+	event string // flag-changed, segment-updated, rules-changed, ...
+	data  []byte
 }
 
 // Server is a mock Rollgate API server.
 type Server struct {
-	mux        *http.ServeMux
-	flags      *FlagStore
-	apiKey     string
-	sseClients map[chan []byte]struct{}
-	sseMu      sync.Mutex
+	mux   *http.ServeMux
+	flags *FlagStore
+	sse   *sseHub
 	// User sessions - stores user context by user_id for remote evaluation
 	userSessions map[string]map[string]interface{}
 	userMu       sync.RWMutex
@@ -70,9 +129,41 @@ type Server struct {
 	// Received events for testing
 	receivedEvents []TrackEventItem
 	eventsMu       sync.Mutex
+	// Received identify payloads for testing
+	receivedIdentifies []IdentifyUser
+	identifiesMu       sync.Mutex
 	// Received telemetry for testing
-	receivedTelemetry []TelemetryPayload
+	receivedTelemetry []ReceivedTelemetry
+	telemetrySeq      int
 	telemetryMu       sync.Mutex
+	// Conditional request validators (ETag / Last-Modified)
+	conditional *conditionalState
+	// CORS configuration for SDK endpoints
+	cors *corsState
+	// Accepted auth schemes for SDK endpoints
+	auth *authState
+	// Simulated redirects and proxy requirements for SDK endpoints
+	redirects *redirectState
+	// Exact per-endpoint response delays
+	latency *latencyState
+	// Corrupted flags responses
+	corruption *corruptionState
+	// SSE connection attempts, for reconnect timing
+	sseLog *sseLogState
+	// Clock offset and received event timestamp checks
+	clock *clockState
+	// Virtual time and the flag flips scheduled on it
+	virtualClock *virtualClockState
+	// Flag changes scheduled on the host clock
+	schedule *scheduleState
+	// Additional API keys bound to flag environments
+	environments *environmentState
+	// Extra response headers on SDK endpoints
+	headers *headersState
+	// Raw SDK requests, for encoding checks
+	recorder *recorderState
+	// SDK configuration hints on flags responses
+	sdkConfig *sdkConfigState
 }
 
 // NewServer creates a new mock server.
@@ -80,10 +171,23 @@ func NewServer(apiKey string) *Server {
 	s := &Server{
 		mux:          http.NewServeMux(),
 		flags:        NewFlagStore(),
-		apiKey:       apiKey,
-		sseClients:   make(map[chan []byte]struct{}),
+		sse:          newSSEHub(sseQueueSize),
 		userSessions: make(map[string]map[string]interface{}),
 		segments:     make(map[string][]Condition),
+		conditional:  newConditionalState(),
+		cors:         newCORSState(),
+		auth:         newAuthState(),
+		redirects:    newRedirectState(),
+		latency:      newLatencyState(),
+		corruption:   newCorruptionState(),
+		sseLog:       newSSELogState(),
+		clock:        newClockState(),
+		virtualClock: newVirtualClockState(),
+		schedule:     newScheduleState(),
+		environments: newEnvironmentState(apiKey),
+		headers:      newHeadersState(),
+		recorder:     newRecorderState(),
+		sdkConfig:    newSDKConfigState(),
 	}
 	s.setupRoutes()
 	return s
@@ -96,15 +200,21 @@ func (s *Server) GetFlagStore() *FlagStore {
 
 // ServeHTTP implements http.Handler.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// CORS headers for browser SDK testing
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "*")
-	w.Header().Set("Access-Control-Expose-Headers", "ETag, Retry-After")
+	w = s.recordRequest(w, r)
 
-	// Handle preflight
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
+	// CORS headers for browser SDK testing; answers preflight requests
+	if s.applyCORS(w, r) {
+		return
+	}
+	if s.applyRedirects(w, r) {
+		return
+	}
+	if s.applyLatency(r) {
+		return
+	}
+	s.applyClock(w, r)
+	s.applyHeaders(w, r)
+	if s.applyCorruption(w, r) {
 		return
 	}
 
@@ -112,26 +222,14 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) setupRoutes() {
-	s.mux.HandleFunc("/api/v1/sdk/flags", s.handleFlags)
-	s.mux.HandleFunc("/api/v1/sdk/v2/flags", s.handleFlagsV2)
-	s.mux.HandleFunc("/api/v1/sdk/stream", s.handleSSE)
-	s.mux.HandleFunc("/api/v1/sdk/identify", s.handleIdentify)
-	s.mux.HandleFunc("/api/v1/sdk/events", s.handleEvents)
-	s.mux.HandleFunc("/api/v1/test/set-error", s.handleSetError)
-	s.mux.HandleFunc("/api/v1/test/clear-error", s.handleClearError)
-	s.mux.HandleFunc("/api/v1/test/sse/send-event", s.handleSSESendEvent)
-	s.mux.HandleFunc("/api/v1/test/sse/disconnect", s.handleSSEDisconnect)
-	s.mux.HandleFunc("/api/v1/test/sse/clients", s.handleSSEClients)
-	s.mux.HandleFunc("/api/v1/test/events", s.handleTestEvents)
-	s.mux.HandleFunc("/api/v1/test/set-segment", s.handleSetSegment)
-	s.mux.HandleFunc("/api/v1/sdk/telemetry", s.handleTelemetry)
-	s.mux.HandleFunc("/api/v1/test/telemetry", s.handleTestTelemetry)
-	s.mux.HandleFunc("/health", s.handleHealth)
+	for _, rt := range s.routes() {
+		s.mux.HandleFunc(rt.path, rt.handler)
+	}
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	json.NewEncoder(w).Encode(HealthResponse{Status: "ok"})
 }
 
 func (s *Server) handleSetError(w http.ResponseWriter, r *http.Request) {
@@ -152,7 +250,7 @@ func (s *Server) handleSetError(w http.ResponseWriter, r *http.Request) {
 	s.errorMu.Unlock()
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	json.NewEncoder(w).Encode(SuccessResponse{Success: true})
 }
 
 func (s *Server) handleClearError(w http.ResponseWriter, r *http.Request) {
@@ -162,14 +260,15 @@ func (s *Server) handleClearError(w http.ResponseWriter, r *http.Request) {
 	s.errorMu.Unlock()
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	json.NewEncoder(w).Encode(SuccessResponse{Success: true})
 }
 
-// checkErrorSimulation checks if an error should be simulated and returns true if so.
-func (s *Server) checkErrorSimulation(w http.ResponseWriter) bool {
+// checkErrorSimulation checks if an error should be simulated for r and
+// returns true if so.
+func (s *Server) checkErrorSimulation(w http.ResponseWriter, r *http.Request) bool {
 	s.errorMu.Lock()
 
-	if s.errorSim == nil {
+	if s.errorSim == nil || !s.errorSim.appliesTo(r.URL.Path) {
 		s.errorMu.Unlock()
 		return false
 	}
@@ -224,14 +323,12 @@ func (s *Server) checkErrorSimulation(w http.ResponseWriter) bool {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"error": map[string]interface{}{
-			"code":      errorType,
-			"category":  "internal",
-			"message":   message,
-			"retryable": retryable,
-		},
-	})
+	json.NewEncoder(w).Encode(SimulatedErrorResponse{Error: SimulatedError{
+		Code:      errorType,
+		Category:  "internal",
+		Message:   message,
+		Retryable: retryable,
+	}})
 
 	return true
 }
@@ -247,11 +344,7 @@ func (s *Server) extractUserContext(r *http.Request) (string, map[string]interfa
 			decoded, err = base64.URLEncoding.DecodeString(xuc)
 		}
 		if err == nil {
-			var ctx struct {
-				ID         string                 `json:"id"`
-				Email      string                 `json:"email"`
-				Attributes map[string]interface{} `json:"attributes"`
-			}
+			var ctx IdentifyUser
 			if json.Unmarshal(decoded, &ctx) == nil && ctx.ID != "" {
 				attrs := make(map[string]interface{})
 				if ctx.Email != "" {
@@ -260,6 +353,7 @@ func (s *Server) extractUserContext(r *http.Request) (string, map[string]interfa
 				for k, v := range ctx.Attributes {
 					attrs[k] = v
 				}
+				addContextAttributes(attrs, ctx.Contexts)
 				return ctx.ID, attrs
 			}
 		}
@@ -293,9 +387,23 @@ func (s *Server) extractUserContext(r *http.Request) (string, map[string]interfa
 	return userID, userAttrs
 }
 
+// addContextAttributes adds the keys and attributes of non-user contexts to
+// a user's attributes under kind-qualified names ("org.key", "org.plan").
+func addContextAttributes(attrs map[string]interface{}, contexts []EvaluationContext) {
+	for _, ctx := range contexts {
+		if ctx.Kind == "" || ctx.Kind == "user" {
+			continue
+		}
+		attrs[ctx.Kind+".key"] = ctx.Key
+		for k, v := range ctx.Attributes {
+			attrs[ctx.Kind+"."+k] = v
+		}
+	}
+}
+
 func (s *Server) handleFlags(w http.ResponseWriter, r *http.Request) {
 	// Check for simulated errors first
-	if s.checkErrorSimulation(w) {
+	if s.checkErrorSimulation(w, r) {
 		return
 	}
 
@@ -308,41 +416,41 @@ func (s *Server) handleFlags(w http.ResponseWriter, r *http.Request) {
 	includeReasons := r.URL.Query().Get("withReasons") == "true"
 
 	// Build V1 response: map[string]bool (enabled/disabled only)
-	allFlags := s.flags.GetAll()
+	allFlags := s.flags.GetAllForEnvironment(s.environmentFor(r))
 	evaluated := make(map[string]bool, len(allFlags))
 	reasons := make(map[string]EvaluationReason, len(allFlags))
+	metadata := make(map[string]FlagMetadata, len(allFlags))
 
 	for key, flag := range allFlags {
 		result := s.evaluateFlagWithReason(flag, userID, userAttrs)
 		evaluated[key] = result.Value
 		reasons[key] = result.Reason
+		metadata[key] = flag.Metadata()
 	}
 
-	// Generate ETag
-	etag := s.generateETag(evaluated)
-
-	// Check If-None-Match
-	if r.Header.Get("If-None-Match") == etag {
+	// Generate ETag and answer conditional requests. A new flag version
+	// changes the payload even when the values stay the same.
+	etag := s.generateETag(FlagsResponse{Flags: evaluated, Metadata: metadata})
+	sdkConfig := s.sdkConfig.apply(w)
+	if s.checkNotModified(w, r, userID, etag) {
 		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("ETag", etag)
 
-	response := map[string]interface{}{
-		"flags": evaluated,
-	}
+	response := FlagsResponse{Flags: evaluated, Metadata: metadata, SDKConfig: sdkConfig}
 	if includeReasons {
-		response["reasons"] = reasons
+		response.Reasons = reasons
 	}
 	json.NewEncoder(w).Encode(response)
 }
 
 // handleFlagsV2 returns flags with typed values (V2 format).
 // Matches production: /api/v1/sdk/v2/flags
+// Reasons are included unless the request sets ?withReasons=false.
 func (s *Server) handleFlagsV2(w http.ResponseWriter, r *http.Request) {
-	if s.checkErrorSimulation(w) {
+	if s.checkErrorSimulation(w, r) {
 		return
 	}
 
@@ -352,60 +460,73 @@ func (s *Server) handleFlagsV2(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userID, userAttrs := s.extractUserContext(r)
+	includeReasons := r.URL.Query().Get("withReasons") != "false"
 
-	allFlags := s.flags.GetAll()
-
-	type V2FlagValue struct {
-		Key     string              `json:"key"`
-		Type    string              `json:"type"`
-		Value   interface{}         `json:"value"`
-		Enabled bool                `json:"enabled"`
-		Reason  *EvaluationReason   `json:"reason,omitempty"`
-	}
+	allFlags := s.flags.GetAllForEnvironment(s.environmentFor(r))
 
 	evaluated := make(map[string]V2FlagValue, len(allFlags))
 
 	for key, flag := range allFlags {
-		result := s.evaluateFlagWithReason(flag, userID, userAttrs)
-		typedValue := s.resolveTypedValueFromResult(flag, result)
-
-		// Determine flag type
-		flagType := "boolean"
-		if flag.DefaultVariation != "" && len(flag.Variations) > 0 {
-			switch flag.Variations[flag.DefaultVariation].(type) {
-			case string:
-				flagType = "string"
-			case float64, int, int64:
-				flagType = "number"
-			case map[string]interface{}:
-				flagType = "json"
-			}
-		}
+		evaluated[key] = s.v2FlagValue(key, flag, userID, userAttrs, includeReasons)
+	}
 
-		reason := result.Reason
-		evaluated[key] = V2FlagValue{
-			Key:     key,
-			Type:    flagType,
-			Value:   typedValue,
-			Enabled: result.Value,
-			Reason:  &reason,
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(FlagsV2Response{Flags: evaluated})
+}
+
+// v2FlagValue evaluates flag for a user into its V2 payload entry.
+func (s *Server) v2FlagValue(key string, flag *FlagState, userID string, attrs map[string]interface{}, includeReasons bool) V2FlagValue {
+	result := s.evaluateFlagWithReason(flag, userID, attrs)
+	typedValue := s.resolveTypedValueFromResult(flag, result)
+
+	// Determine flag type
+	flagType := "boolean"
+	if flag.DefaultVariation != "" && len(flag.Variations) > 0 {
+		switch flag.Variations[flag.DefaultVariation].(type) {
+		case string:
+			flagType = "string"
+		case float64, int, int64:
+			flagType = "number"
+		case map[string]interface{}:
+			flagType = "json"
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"flags": evaluated,
-	})
+	value := V2FlagValue{
+		Key:       key,
+		Type:      flagType,
+		Value:     typedValue,
+		Enabled:   result.Value,
+		Version:   flag.Version,
+		UpdatedAt: flag.UpdatedAt,
+	}
+	if includeReasons {
+		reason := result.Reason
+		value.Reason = &reason
+	}
+	return value
 }
 
 func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
+	if s.checkErrorSimulation(w, r) {
+		return
+	}
+
 	// Check auth from query param (EventSource doesn't support headers)
 	token := r.URL.Query().Get("token")
-	if token != s.apiKey {
+	if !s.isAPIKey(token) {
 		http.Error(w, `{"error":"AuthenticationError","message":"Invalid API key"}`, http.StatusUnauthorized)
 		return
 	}
 
+	connID, rejectStatus := s.sseLog.open(r.URL.Query().Get("user_id"))
+	if rejectStatus != 0 {
+		http.Error(w, `{"error":"ServiceUnavailable","message":"stream connection rejected"}`, rejectStatus)
+		return
+	}
+	closedBy := "client"
+	defer func() { s.sseLog.close(connID, closedBy) }()
+
 	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -419,54 +540,129 @@ func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create client channel
-	clientChan := make(chan []byte, 10)
-	s.sseMu.Lock()
-	s.sseClients[clientChan] = struct{}{}
-	s.sseMu.Unlock()
+	userID := r.URL.Query().Get("user_id")
+	sub := &sseSubscriber{
+		userID:      userID,
+		env:         s.environmentFor(r),
+		withReasons: r.URL.Query().Get("withReasons") == "true",
+		v2:          r.URL.Query().Get("format") == "v2",
 
-	defer func() {
-		s.sseMu.Lock()
-		// Only close if still in map (wasn't already closed by DisconnectSSEClients)
-		if _, exists := s.sseClients[clientChan]; exists {
-			delete(s.sseClients, clientChan)
-			s.sseMu.Unlock()
-			close(clientChan)
-		} else {
-			s.sseMu.Unlock()
-		}
-	}()
+		record: func(msg sseMessage, status string) { s.sseLog.record(connID, msg, status) },
+	}
+	conn := s.sse.add(sub)
+	defer s.sse.remove(conn)
 
-	// Send initial flags (V1 format: map[string]bool)
-	userID := r.URL.Query().Get("user_id")
+	// Send initial flags: V1 (map[string]bool), or typed values in V2
 	var userAttrs map[string]interface{}
 	if userID != "" {
 		s.userMu.RLock()
 		userAttrs = s.userSessions[userID]
 		s.userMu.RUnlock()
 	}
-	allFlags := s.flags.GetAll()
-	evaluated := make(map[string]bool, len(allFlags))
-	for key, flag := range allFlags {
-		result := s.evaluateFlagWithReason(flag, userID, userAttrs)
-		evaluated[key] = result.Value
-	}
+	allFlags := s.flags.GetAllForEnvironment(sub.env)
+	var initMsg sseMessage
+	if sub.v2 {
+		typed := make(map[string]V2FlagValue, len(allFlags))
+		for key, flag := range allFlags {
+			typed[key] = s.v2FlagValue(key, flag, userID, userAttrs, sub.withReasons)
+		}
+		initData, _ := json.Marshal(FlagsV2Response{Flags: typed})
+		initMsg = sseMessage{event: "flags-v2", data: initData}
+	} else {
+		evaluated := make(map[string]bool, len(allFlags))
+		reasons := make(map[string]EvaluationReason, len(allFlags))
+		metadata := make(map[string]FlagMetadata, len(allFlags))
+		for key, flag := range allFlags {
+			result := s.evaluateFlagWithReason(flag, userID, userAttrs)
+			evaluated[key] = result.Value
+			reasons[key] = result.Reason
+			metadata[key] = flag.Metadata()
+		}
 
-	initData, _ := json.Marshal(map[string]interface{}{"flags": evaluated})
-	fmt.Fprintf(w, "event: init\ndata: %s\n\n", initData)
+		init := FlagsResponse{Flags: evaluated, Metadata: metadata}
+		if sub.withReasons {
+			init.Reasons = reasons
+		}
+		initData, _ := json.Marshal(init)
+		initMsg = sseMessage{event: "init", data: initData}
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", initMsg.event, initMsg.data)
 	flusher.Flush()
+	sub.record(initMsg, SSEDelivered)
 
-	// Keep connection open
+	// Keep connection open, writing queued events in batches until the
+	// client leaves or the server ends the stream
+	rc := http.NewResponseController(w)
 	for {
 		select {
 		case <-r.Context().Done():
+			// Events still queued never reach the client
+			for {
+				select {
+				case msg := <-conn.queue:
+					sub.record(msg, SSEWriteFailed)
+				default:
+					return
+				}
+			}
+		case <-conn.done:
+			// Disconnect requested or evicted: send what is already queued
+			closedBy = "server"
+			writeSSEBatch(w, rc, conn, nil)
 			return
-		case msg, ok := <-clientChan:
-			if !ok {
-				// Channel was closed (disconnect requested)
+		case msg := <-conn.queue:
+			if !writeSSEBatch(w, rc, conn, &msg) {
 				return
 			}
-			fmt.Fprintf(w, "event: flag-changed\ndata: %s\n\n", msg)
-			flusher.Flush()
+		}
+	}
+}
+
+// sseWriteTimeout bounds how long one batch of events may take to write,
+// so a client that stops reading cannot pin its handler forever.
+const sseWriteTimeout = 5 * time.Second
+
+// writeSSEBatch writes first, if set, and every event already queued for
+// conn, then flushes once and logs the outcome of each event. It reports
+// false if the write failed.
+func writeSSEBatch(w http.ResponseWriter, rc *http.ResponseController, conn *sseConn, first *sseMessage) bool {
+	var batch []sseMessage
+	if first != nil {
+		batch = append(batch, *first)
+	}
+	ok := true
+	defer func() {
+		if conn.sub.record == nil {
+			return
+		}
+		status := SSEDelivered
+		if !ok {
+			status = SSEWriteFailed
+		}
+		for _, msg := range batch {
+			conn.sub.record(msg, status)
+		}
+	}()
+
+	// Recorders used in tests have no deadline support; writes just block
+	_ = rc.SetWriteDeadline(time.Now().Add(sseWriteTimeout))
+	if first != nil {
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", first.event, first.data); err != nil {
+			ok = false
+			return false
+		}
+	}
+	for {
+		select {
+		case msg := <-conn.queue:
+			batch = append(batch, msg)
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.event, msg.data); err != nil {
+				ok = false
+				return false
+			}
+		default:
+			ok = rc.Flush() == nil
+			return ok
 		}
 	}
 }
@@ -477,50 +673,78 @@ func (s *Server) handleIdentify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.checkErrorSimulation(w, r) {
+		return
+	}
+
 	if !s.authenticate(r) {
 		http.Error(w, `{"error":"AuthenticationError","message":"Invalid API key"}`, http.StatusUnauthorized)
 		return
 	}
 
 	// Parse user context from body
-	var body struct {
-		User struct {
-			ID         string                 `json:"id"`
-			Email      string                 `json:"email"`
-			Attributes map[string]interface{} `json:"attributes"`
-		} `json:"user"`
-	}
+	var body IdentifyRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&body); err == nil && body.User.ID != "" {
-		// Store user session with attributes
-		s.userMu.Lock()
-		attrs := make(map[string]interface{})
-		if body.User.Email != "" {
-			attrs["email"] = body.User.Email
-		}
-		for k, v := range body.User.Attributes {
-			attrs[k] = v
-		}
-		s.userSessions[body.User.ID] = attrs
-		s.userMu.Unlock()
+	if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+		s.identify(body.User)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	json.NewEncoder(w).Encode(SuccessResponse{Success: true})
 }
 
-func (s *Server) authenticate(r *http.Request) bool {
-	auth := r.Header.Get("Authorization")
-	if auth == "" {
-		return false
+// handleIdentifyBatch stores the attributes of many users in one request.
+// Each user is recorded as if it had been identified on its own.
+func (s *Server) handleIdentifyBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.checkErrorSimulation(w, r) {
+		return
+	}
+
+	if !s.authenticate(r) {
+		http.Error(w, `{"error":"AuthenticationError","message":"Invalid API key"}`, http.StatusUnauthorized)
+		return
 	}
 
-	// Bearer token
-	if strings.HasPrefix(auth, "Bearer ") {
-		return strings.TrimPrefix(auth, "Bearer ") == s.apiKey
+	var body IdentifyBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, `{"error":"ValidationError","message":"Invalid identify batch"}`, http.StatusBadRequest)
+		return
+	}
+	for _, user := range body.Users {
+		s.identify(user)
 	}
 
-	return auth == s.apiKey
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReceivedResponse{Received: len(body.Users)})
+}
+
+// identify records an identified user and stores its attributes for later
+// evaluations by user_id.
+func (s *Server) identify(user IdentifyUser) {
+	s.identifiesMu.Lock()
+	s.receivedIdentifies = append(s.receivedIdentifies, user)
+	s.identifiesMu.Unlock()
+	if user.ID == "" {
+		return
+	}
+
+	// Store user session with attributes
+	s.userMu.Lock()
+	attrs := make(map[string]interface{})
+	if user.Email != "" {
+		attrs["email"] = user.Email
+	}
+	for k, v := range user.Attributes {
+		attrs[k] = v
+	}
+	addContextAttributes(attrs, user.Contexts)
+	s.userSessions[user.ID] = attrs
+	s.userMu.Unlock()
 }
 
 func (s *Server) evaluateFlag(flag *FlagState, userID string, attrs map[string]interface{}) bool {
@@ -555,10 +779,35 @@ func (s *Server) resolveTypedValueFromResult(flag *FlagState, result EvaluationR
 
 // evaluateFlagWithReason evaluates a flag and returns both value and reason.
 func (s *Server) evaluateFlagWithReason(flag *FlagState, userID string, attrs map[string]interface{}) EvaluationResult {
+	result := s.matchFlag(flag, userID, attrs)
+	if result.Value && len(flag.Variations) > 0 && flag.DefaultVariation != "" {
+		if result.Variation == "" {
+			result.Variation = flag.DefaultVariation
+		}
+		result.Reason.VariationID = result.Variation
+	}
+	return result
+}
+
+// matchFlag walks the flag's targeting and returns the matched outcome.
+func (s *Server) matchFlag(flag *FlagState, userID string, attrs map[string]interface{}) EvaluationResult {
+	return s.matchFlagVisiting(flag, userID, attrs, map[string]bool{})
+}
+
+// matchFlagVisiting is matchFlag with the flags whose prerequisites are
+// being evaluated, so that a prerequisite cycle fails instead of recursing.
+func (s *Server) matchFlagVisiting(flag *FlagState, userID string, attrs map[string]interface{}, visiting map[string]bool) EvaluationResult {
 	if !flag.Enabled {
 		return EvaluationResult{Value: false, Reason: EvaluationReason{Kind: "OFF"}}
 	}
 
+	// Check prerequisites before any targeting
+	for _, prereq := range flag.Prerequisites {
+		if !s.prerequisiteMet(flag, prereq, userID, attrs, visiting) {
+			return EvaluationResult{Value: false, Reason: EvaluationReason{Kind: "PREREQUISITE_FAILED", PrerequisiteKey: prereq.FlagKey}}
+		}
+	}
+
 	// Check target users first
 	for _, target := range flag.TargetUsers {
 		if target == userID {
@@ -573,6 +822,14 @@ func (s *Server) evaluateFlagWithReason(flag *FlagState, userID string, attrs ma
 				continue
 			}
 			if s.evaluateConditions(rule.Conditions, userID, attrs) {
+				index := i
+				if len(rule.WeightedVariations) > 0 {
+					result := s.splitResult(rule.WeightedVariations, userID, flag.Key)
+					result.Reason.Kind = "RULE_MATCH"
+					result.Reason.RuleID = rule.ID
+					result.Reason.RuleIndex = &index
+					return result
+				}
 				inRollout := s.evaluateRollout(rule.RolloutPercentage, userID, flag.Key)
 				return EvaluationResult{
 					Value:     inRollout,
@@ -580,8 +837,8 @@ func (s *Server) evaluateFlagWithReason(flag *FlagState, userID string, attrs ma
 					Reason: EvaluationReason{
 						Kind:      "RULE_MATCH",
 						RuleID:    rule.ID,
-						RuleIndex: i,
-						InRollout: inRollout,
+						RuleIndex: &index,
+						InRollout: &inRollout,
 					},
 				}
 			}
@@ -589,12 +846,33 @@ func (s *Server) evaluateFlagWithReason(flag *FlagState, userID string, attrs ma
 		// No rule matched - fall through to global rollout
 	}
 
-	// Global rollout (FALLTHROUGH)
+	// Weighted split or global rollout (FALLTHROUGH)
+	if len(flag.WeightedVariations) > 0 {
+		result := s.splitResult(flag.WeightedVariations, userID, flag.Key)
+		result.Reason.Kind = "FALLTHROUGH"
+		return result
+	}
 	inRollout := s.evaluateRollout(flag.RolloutPercentage, userID, flag.Key)
 	return EvaluationResult{
 		Value:  inRollout,
-		Reason: EvaluationReason{Kind: "FALLTHROUGH", InRollout: inRollout},
+		Reason: EvaluationReason{Kind: "FALLTHROUGH", InRollout: &inRollout},
+	}
+}
+
+// prerequisiteMet reports whether prereq evaluates to its required value for
+// the user in the dependent flag's environment. Missing flags and cycles
+// never meet a prerequisite.
+func (s *Server) prerequisiteMet(flag *FlagState, prereq Prerequisite, userID string, attrs map[string]interface{}, visiting map[string]bool) bool {
+	if visiting[prereq.FlagKey] || prereq.FlagKey == flag.Key {
+		return false
 	}
+	target, ok := s.flags.GetForEnvironment(prereq.FlagKey, flag.env)
+	if !ok {
+		return false
+	}
+	visiting[flag.Key] = true
+	defer delete(visiting, flag.Key)
+	return s.matchFlagVisiting(target, userID, attrs, visiting).Value == prereq.RequiredValue
 }
 
 func (s *Server) evaluateConditions(conditions []Condition, userID string, attrs map[string]interface{}) bool {
@@ -765,29 +1043,114 @@ func (s *Server) evaluateRollout(percentage int, userID, flagKey string) bool {
 	return bucket < percentage
 }
 
+// splitResult assigns the user a variation of a weighted split. Users
+// without an ID, and splits without weight, select none and get false.
+func (s *Server) splitResult(variations []WeightedVariation, userID, flagKey string) EvaluationResult {
+	variation := s.selectVariation(variations, userID, flagKey)
+	inRollout := variation != ""
+	return EvaluationResult{
+		Value:     inRollout,
+		Variation: variation,
+		Reason:    EvaluationReason{InRollout: &inRollout, VariationID: variation},
+	}
+}
+
+// selectVariation buckets the user by the same hash as evaluateRollout,
+// scaled to the total weight.
+func (s *Server) selectVariation(variations []WeightedVariation, userID, flagKey string) string {
+	total := 0
+	for _, v := range variations {
+		total += max(v.Weight, 0)
+	}
+	if total == 0 || userID == "" {
+		return ""
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(userID + ":" + flagKey))
+	bucket := int(h.Sum32() % uint32(total))
+	for _, v := range variations {
+		bucket -= max(v.Weight, 0)
+		if bucket < 0 {
+			return v.ID
+		}
+	}
+	return ""
+}
+
 func (s *Server) generateETag(flags interface{}) string {
 	data, _ := json.Marshal(flags)
 	hash := sha256.Sum256(data)
 	return `"` + hex.EncodeToString(hash[:8]) + `"`
 }
 
-// BroadcastFlagChange notifies all SSE clients of a flag change.
+// BroadcastFlagChange notifies all SSE clients of a flag change. Clients
+// that connected with ?withReasons=true also get the flag's reason for
+// their user. Clients that connected with ?format=v2 get a flag-update-v2
+// event instead, with the flag's typed value evaluated for their user as
+// if it were turned on or off as broadcast, unless the flag does not
+// exist.
 func (s *Server) BroadcastFlagChange(flagKey string, enabled bool) {
-	s.sseMu.Lock()
-	defer s.sseMu.Unlock()
-
-	data, _ := json.Marshal(map[string]interface{}{
-		"key":     flagKey,
-		"enabled": enabled,
+	changed := FlagChangedEvent{Key: flagKey, Enabled: enabled}
+	if flag, ok := s.flags.Get(flagKey); ok {
+		changed.Version = flag.Version
+		changed.UpdatedAt = flag.UpdatedAt
+	}
+	plain, _ := json.Marshal(changed)
+
+	// Plain subscribers share one encoded event; only typed and reason
+	// events are evaluated per subscriber
+	s.sse.broadcast(func(sub *sseSubscriber) (sseMessage, bool) {
+		msg := sseMessage{event: "flag-changed", data: plain}
+		if typed, ok := s.streamTypedValue(sub, flagKey, enabled); ok {
+			msg.event = "flag-update-v2"
+			msg.data, _ = json.Marshal(typed)
+		} else if sub.withReasons {
+			withReason := changed
+			withReason.Reason = s.streamReason(sub, flagKey)
+			msg.data, _ = json.Marshal(withReason)
+		}
+		return msg, true
 	})
+}
 
-	for ch := range s.sseClients {
-		select {
-		case ch <- data:
-		default:
-			// Client not ready, skip
-		}
+// streamTypedValue evaluates flagKey's V2 value, with the flag turned on
+// or off as enabled says, for a subscriber that opened its stream with
+// ?format=v2. It reports false for other subscribers and for unknown
+// flags.
+func (s *Server) streamTypedValue(sub *sseSubscriber, flagKey string, enabled bool) (V2FlagValue, bool) {
+	if !sub.v2 {
+		return V2FlagValue{}, false
 	}
+	stored, ok := s.flags.GetForEnvironment(flagKey, sub.env)
+	if !ok {
+		return V2FlagValue{}, false
+	}
+	flag := *stored
+	flag.Enabled = enabled
+	var attrs map[string]interface{}
+	if sub.userID != "" {
+		s.userMu.RLock()
+		attrs = s.userSessions[sub.userID]
+		s.userMu.RUnlock()
+	}
+	return s.v2FlagValue(flagKey, &flag, sub.userID, attrs, sub.withReasons), true
+}
+
+// streamReason evaluates flagKey's reason for an SSE subscriber's user.
+func (s *Server) streamReason(sub *sseSubscriber, flagKey string) *EvaluationReason {
+	flag, ok := s.flags.GetForEnvironment(flagKey, sub.env)
+	if !ok {
+		return &EvaluationReason{Kind: "UNKNOWN"}
+	}
+	var attrs map[string]interface{}
+	if sub.userID != "" {
+		s.userMu.RLock()
+		attrs = s.userSessions[sub.userID]
+		s.userMu.RUnlock()
+	}
+	reason := s.evaluateFlagWithReason(flag, sub.userID, attrs).Reason
+	return &reason
 }
 
 // SetScenario loads a test scenario.
@@ -795,11 +1158,10 @@ func (s *Server) SetScenario(scenario string) {
 	s.flags.LoadScenario(scenario)
 }
 
-// SetFlags sets multiple flags at once.
+// SetFlags sets multiple flags at once: no flags response carries part of
+// the batch.
 func (s *Server) SetFlags(flags []*FlagState) {
-	for _, f := range flags {
-		s.flags.Set(f)
-	}
+	s.flags.SetAll(flags)
 }
 
 // SetFlag sets a single flag.
@@ -849,10 +1211,7 @@ func (s *Server) handleSSESendEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var body struct {
-		Event string                 `json:"event"`
-		Data  map[string]interface{} `json:"data"`
-	}
+	var body SSESendEventRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -860,23 +1219,17 @@ func (s *Server) handleSSESendEvent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Broadcast to all SSE clients
-	data, _ := json.Marshal(body.Data)
-	s.sseMu.Lock()
-	clientCount := len(s.sseClients)
-	for ch := range s.sseClients {
-		select {
-		case ch <- data:
-		default:
-			// Client not ready, skip
-		}
+	event := body.Event
+	if event == "" {
+		event = "flag-changed"
 	}
-	s.sseMu.Unlock()
+	data, _ := json.Marshal(body.Data)
+	msg := sseMessage{event: event, data: data}
+	clientCount := s.sse.count()
+	s.sse.broadcast(func(*sseSubscriber) (sseMessage, bool) { return msg, true })
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"clients": clientCount,
-	})
+	json.NewEncoder(w).Encode(SSESendEventResponse{Success: true, Clients: clientCount})
 }
 
 // handleSSEDisconnect closes all SSE connections.
@@ -886,57 +1239,34 @@ func (s *Server) handleSSEDisconnect(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.sseMu.Lock()
-	clientCount := len(s.sseClients)
-	// Close all client channels to trigger disconnect
-	for ch := range s.sseClients {
-		close(ch)
-		delete(s.sseClients, ch)
-	}
-	s.sseMu.Unlock()
+	clientCount := s.sse.disconnectAll()
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success":     true,
-		"disconnected": clientCount,
-	})
+	json.NewEncoder(w).Encode(SSEDisconnectResponse{Success: true, Disconnected: clientCount})
 }
 
 // handleSSEClients returns the count of connected SSE clients.
 func (s *Server) handleSSEClients(w http.ResponseWriter, r *http.Request) {
-	s.sseMu.Lock()
-	clientCount := len(s.sseClients)
-	s.sseMu.Unlock()
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"clients": clientCount,
-	})
+	json.NewEncoder(w).Encode(SSEClientsResponse{Clients: s.sse.count(), Evicted: s.sse.evictedCount()})
 }
 
 // GetSSEClientCount returns the count of connected SSE clients.
 func (s *Server) GetSSEClientCount() int {
-	s.sseMu.Lock()
-	defer s.sseMu.Unlock()
-	return len(s.sseClients)
+	return s.sse.count()
+}
+
+// GetSSEEvictedCount returns how many SSE clients were disconnected for
+// not reading events fast enough.
+func (s *Server) GetSSEEvictedCount() int {
+	return s.sse.evictedCount()
 }
 
 // SendSSEEvent sends a custom event to all SSE clients.
 func (s *Server) SendSSEEvent(data map[string]interface{}) int {
-	encoded, _ := json.Marshal(data)
-	s.sseMu.Lock()
-	defer s.sseMu.Unlock()
-
-	sent := 0
-	for ch := range s.sseClients {
-		select {
-		case ch <- encoded:
-			sent++
-		default:
-			// Client not ready
-		}
-	}
-	return sent
+	msg := sseMessage{event: "flag-changed"}
+	msg.data, _ = json.Marshal(data)
+	return s.sse.broadcast(func(*sseSubscriber) (sseMessage, bool) { return msg, true })
 }
 
 // handleEvents receives tracked events from SDKs (POST /api/v1/sdk/events).
@@ -946,14 +1276,16 @@ func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.checkErrorSimulation(w, r) {
+		return
+	}
+
 	if !s.authenticate(r) {
 		http.Error(w, `{"error":"AuthenticationError","message":"Invalid API key"}`, http.StatusUnauthorized)
 		return
 	}
 
-	var body struct {
-		Events []TrackEventItem `json:"events"`
-	}
+	var body EventsRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -963,11 +1295,10 @@ func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
 	s.eventsMu.Lock()
 	s.receivedEvents = append(s.receivedEvents, body.Events...)
 	s.eventsMu.Unlock()
+	s.recordEventTimings(body.Events)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"received": len(body.Events),
-	})
+	json.NewEncoder(w).Encode(ReceivedResponse{Received: len(body.Events)})
 }
 
 // handleTestEvents is the test control endpoint for events (GET/DELETE /api/v1/test/events).
@@ -980,18 +1311,16 @@ func (s *Server) handleTestEvents(w http.ResponseWriter, r *http.Request) {
 		s.eventsMu.Unlock()
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"events": events,
-			"count":  len(events),
-		})
+		json.NewEncoder(w).Encode(EventsListResponse{Events: events, Count: len(events)})
 
 	case http.MethodDelete:
 		s.eventsMu.Lock()
 		s.receivedEvents = nil
 		s.eventsMu.Unlock()
+		s.clearEventTimings()
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]bool{"success": true})
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
 
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -1010,21 +1339,52 @@ func (s *Server) GetReceivedEvents() []TrackEventItem {
 // ClearReceivedEvents clears all received events.
 func (s *Server) ClearReceivedEvents() {
 	s.eventsMu.Lock()
-	defer s.eventsMu.Unlock()
 	s.receivedEvents = nil
+	s.eventsMu.Unlock()
+	s.clearEventTimings()
 }
 
-// DisconnectSSEClients disconnects all SSE clients.
-func (s *Server) DisconnectSSEClients() int {
-	s.sseMu.Lock()
-	defer s.sseMu.Unlock()
+// GetReceivedIdentifies returns the users of all identify requests received,
+// in order.
+func (s *Server) GetReceivedIdentifies() []IdentifyUser {
+	s.identifiesMu.Lock()
+	defer s.identifiesMu.Unlock()
+	users := make([]IdentifyUser, len(s.receivedIdentifies))
+	copy(users, s.receivedIdentifies)
+	return users
+}
+
+// ClearReceivedIdentifies clears all received identify payloads.
+func (s *Server) ClearReceivedIdentifies() {
+	s.identifiesMu.Lock()
+	defer s.identifiesMu.Unlock()
+	s.receivedIdentifies = nil
+}
+
+// handleTestIdentifies is the test control endpoint for identify payloads
+// (GET/DELETE /api/v1/test/identifies).
+func (s *Server) handleTestIdentifies(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		users := s.GetReceivedIdentifies()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(IdentifiesListResponse{Users: users, Count: len(users)})
+
+	case http.MethodDelete:
+		s.ClearReceivedIdentifies()
 
-	count := len(s.sseClients)
-	for ch := range s.sseClients {
-		close(ch)
-		delete(s.sseClients, ch)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
-	return count
+}
+
+// DisconnectSSEClients disconnects all SSE clients.
+func (s *Server) DisconnectSSEClients() int {
+	return s.sse.disconnectAll()
 }
 
 // SetSegment registers a segment with the given ID and conditions.
@@ -1048,10 +1408,7 @@ func (s *Server) handleSetSegment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var body struct {
-		ID         string      `json:"id"`
-		Conditions []Condition `json:"conditions"`
-	}
+	var body SegmentRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -1061,7 +1418,7 @@ func (s *Server) handleSetSegment(w http.ResponseWriter, r *http.Request) {
 	s.SetSegment(body.ID, body.Conditions)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+	json.NewEncoder(w).Encode(SuccessResponse{Success: true})
 }
 
 // handleTelemetry receives telemetry data from SDKs (POST /api/v1/sdk/telemetry).
@@ -1071,6 +1428,10 @@ func (s *Server) handleTelemetry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.checkErrorSimulation(w, r) {
+		return
+	}
+
 	if !s.authenticate(r) {
 		http.Error(w, `{"error":"AuthenticationError","message":"Invalid API key"}`, http.StatusUnauthorized)
 		return
@@ -1088,37 +1449,34 @@ func (s *Server) handleTelemetry(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.telemetryMu.Lock()
-	s.receivedTelemetry = append(s.receivedTelemetry, payload)
+	s.telemetrySeq++
+	s.receivedTelemetry = append(s.receivedTelemetry, ReceivedTelemetry{
+		Evaluations: payload.Evaluations,
+		PeriodMs:    payload.PeriodMs,
+		Seq:         s.telemetrySeq,
+		ReceivedAt:  time.Now(),
+		Defaults:    payload.Defaults,
+	})
 	s.telemetryMu.Unlock()
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"received": totalReceived,
-	})
+	json.NewEncoder(w).Encode(ReceivedResponse{Received: totalReceived})
 }
 
 // handleTestTelemetry is the test control endpoint for telemetry (GET/DELETE /api/v1/test/telemetry).
 func (s *Server) handleTestTelemetry(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		s.telemetryMu.Lock()
-		telemetry := make([]TelemetryPayload, len(s.receivedTelemetry))
-		copy(telemetry, s.receivedTelemetry)
-		s.telemetryMu.Unlock()
+		telemetry := s.GetReceivedTelemetry()
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"telemetry": telemetry,
-			"count":     len(telemetry),
-		})
+		json.NewEncoder(w).Encode(TelemetryListResponse{Telemetry: telemetry, Count: len(telemetry)})
 
 	case http.MethodDelete:
-		s.telemetryMu.Lock()
-		s.receivedTelemetry = nil
-		s.telemetryMu.Unlock()
+		s.ClearReceivedTelemetry()
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]bool{"success": true})
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
 
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -1126,15 +1484,16 @@ func (s *Server) handleTestTelemetry(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetReceivedTelemetry returns all telemetry received by the mock server.
-func (s *Server) GetReceivedTelemetry() []TelemetryPayload {
+func (s *Server) GetReceivedTelemetry() []ReceivedTelemetry {
 	s.telemetryMu.Lock()
 	defer s.telemetryMu.Unlock()
-	telemetry := make([]TelemetryPayload, len(s.receivedTelemetry))
+	telemetry := make([]ReceivedTelemetry, len(s.receivedTelemetry))
 	copy(telemetry, s.receivedTelemetry)
 	return telemetry
 }
 
-// ClearReceivedTelemetry clears all received telemetry.
+// ClearReceivedTelemetry clears all received telemetry. Sequence numbers
+// keep counting.
 func (s *Server) ClearReceivedTelemetry() {
 	s.telemetryMu.Lock()
 	defer s.telemetryMu.Unlock()
@@ -0,0 +1,129 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// BroadcastSegmentUpdate sends a segment-updated event with the segment's
+// current conditions to all SSE clients. Each event lists the flags whose
+// rules reference the segment in the client's environment, so SDKs
+// evaluating locally know what to re-evaluate. Returns the number of
+// clients the event was queued for.
+func (s *Server) BroadcastSegmentUpdate(id string) int {
+	s.segmentsMu.RLock()
+	conditions := s.segments[id]
+	s.segmentsMu.RUnlock()
+	if conditions == nil {
+		conditions = []Condition{}
+	}
+
+	return s.sse.broadcast(func(sub *sseSubscriber) (sseMessage, bool) {
+		flags := []string{}
+		for key, flag := range s.flags.GetAllForEnvironment(sub.env) {
+			if referencesSegment(flag, id) {
+				flags = append(flags, key)
+			}
+		}
+		sort.Strings(flags)
+		data, _ := json.Marshal(SegmentUpdatedEvent{ID: id, Conditions: conditions, Flags: flags})
+		return sseMessage{event: "segment-updated", data: data}, true
+	})
+}
+
+// BroadcastRulesChange sends a rules-changed event with flagKey's full
+// targeting to all SSE clients, resolved for each client's environment.
+// The event also carries the flag's value for the client's user, with the
+// reason on streams opened with ?withReasons=true. Returns the number of
+// clients the event was queued for.
+func (s *Server) BroadcastRulesChange(flagKey string) int {
+	return s.sse.broadcast(func(sub *sseSubscriber) (sseMessage, bool) {
+		event := RulesChangedEvent{Key: flagKey}
+		if flag, ok := s.flags.GetForEnvironment(flagKey, sub.env); ok {
+			var attrs map[string]interface{}
+			if sub.userID != "" {
+				s.userMu.RLock()
+				attrs = s.userSessions[sub.userID]
+				s.userMu.RUnlock()
+			}
+			result := s.evaluateFlagWithReason(flag, sub.userID, attrs)
+			event.Flag = flag
+			event.Enabled = result.Value
+			if sub.withReasons {
+				event.Reason = &result.Reason
+			}
+		}
+		data, _ := json.Marshal(event)
+		return sseMessage{event: "rules-changed", data: data}, true
+	})
+}
+
+// referencesSegment reports whether any of flag's rules has a segment
+// condition naming id, directly or in a list of segment IDs.
+func referencesSegment(flag *FlagState, id string) bool {
+	for _, rule := range flag.Rules {
+		for _, cond := range rule.Conditions {
+			if cond.Attribute != "segment" || cond.Operator != "in" {
+				continue
+			}
+			switch v := cond.Value.(type) {
+			case string:
+				if v == id {
+					return true
+				}
+			case []string:
+				for _, segID := range v {
+					if segID == id {
+						return true
+					}
+				}
+			case []interface{}:
+				for _, segID := range v {
+					if segID == id {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// handleSSESegmentUpdated broadcasts a segment-updated event (POST
+// /api/v1/test/sse/segment-updated).
+func (s *Server) handleSSESegmentUpdated(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body SSESegmentUpdatedRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ID == "" {
+		http.Error(w, `{"error":"id is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	clients := s.BroadcastSegmentUpdate(body.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SSESendEventResponse{Success: true, Clients: clients})
+}
+
+// handleSSERulesChanged broadcasts a rules-changed event (POST
+// /api/v1/test/sse/rules-changed).
+func (s *Server) handleSSERulesChanged(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body SSERulesChangedRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Key == "" {
+		http.Error(w, `{"error":"key is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	clients := s.BroadcastRulesChange(body.Key)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SSESendEventResponse{Success: true, Clients: clients})
+}
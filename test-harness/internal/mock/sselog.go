@@ -0,0 +1,211 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxSSEDeliveries caps the deliveries logged per connection.
+const maxSSEDeliveries = 1000
+
+// Delivery outcomes of an SSE event.
+const (
+	SSEDelivered   = "delivered" // Written and flushed to the client
+	SSEDropped     = "dropped"   // Not queued: the connection's queue was full
+	SSEWriteFailed = "failed"    // The write or flush failed, or the client left first
+)
+
+// SSEConnection is one attempt to open the SSE stream, as seen by the mock.
+type SSEConnection struct {
+	ID          int        `json:"id"`
+	UserID      string     `json:"userId,omitempty"`
+	ConnectedAt time.Time  `json:"connectedAt"`
+	Status      int        `json:"status"`             // 200, or the status of a rejected attempt
+	ClosedAt    *time.Time `json:"closedAt,omitempty"` // Unset while the stream is open
+	ClosedBy    string     `json:"closedBy,omitempty"` // "server" or "client"
+
+	// Deliveries lists the events sent on the stream, starting with init,
+	// so a test can tell an event the SDK ignored from one the mock never
+	// delivered. At most 1000 are kept; later ones are only counted in
+	// DeliveriesOmitted.
+	Deliveries        []SSEDelivery `json:"deliveries,omitempty"`
+	DeliveriesOmitted int           `json:"deliveriesOmitted,omitempty"`
+}
+
+// SSEDelivery is the outcome of sending one event on an SSE connection.
+type SSEDelivery struct {
+	Event  string    `json:"event"`
+	Data   string    `json:"data"`
+	Status string    `json:"status"` // SSEDelivered, SSEDropped or SSEWriteFailed
+	At     time.Time `json:"at"`
+}
+
+// Open reports whether the stream is still connected.
+func (c SSEConnection) Open() bool {
+	return c.Status == http.StatusOK && c.ClosedAt == nil
+}
+
+// Delivered returns how many events of the given type reached the client.
+func (c SSEConnection) Delivered(event string) int {
+	n := 0
+	for _, d := range c.Deliveries {
+		if d.Event == event && d.Status == SSEDelivered {
+			n++
+		}
+	}
+	return n
+}
+
+// SSERejectConfig makes the stream endpoint refuse connection attempts, so
+// reconnect backoff can be observed.
+type SSERejectConfig struct {
+	StatusCode int `json:"statusCode"` // Status for rejected attempts (default 503)
+	Count      int `json:"count"`      // Number of attempts to reject (-1 = always)
+}
+
+// sseLogState records SSE connection attempts in arrival order.
+type sseLogState struct {
+	mu       sync.Mutex
+	conns    []SSEConnection
+	nextID   int
+	reject   SSERejectConfig
+	rejected int
+}
+
+func newSSELogState() *sseLogState {
+	return &sseLogState{}
+}
+
+// open records a connection attempt and returns its ID, or the status to
+// reject it with.
+func (sl *sseLogState) open(userID string) (id int, rejectStatus int) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	sl.nextID++
+	conn := SSEConnection{
+		ID:          sl.nextID,
+		UserID:      userID,
+		ConnectedAt: time.Now(),
+		Status:      http.StatusOK,
+	}
+	if sl.reject.Count == -1 || sl.rejected < sl.reject.Count {
+		sl.rejected++
+		conn.Status = sl.reject.StatusCode
+		if conn.Status == 0 {
+			conn.Status = http.StatusServiceUnavailable
+		}
+		now := conn.ConnectedAt
+		conn.ClosedAt = &now
+		conn.ClosedBy = "server"
+	}
+	sl.conns = append(sl.conns, conn)
+	if conn.Status != http.StatusOK {
+		return conn.ID, conn.Status
+	}
+	return conn.ID, 0
+}
+
+// find returns the log entry of connection id, or nil if it was reset
+// since. Must be called with mu held.
+func (sl *sseLogState) find(id int) *SSEConnection {
+	i := sort.Search(len(sl.conns), func(i int) bool { return sl.conns[i].ID >= id })
+	if i < len(sl.conns) && sl.conns[i].ID == id {
+		return &sl.conns[i]
+	}
+	return nil
+}
+
+// close records the end of an open stream. Streams opened before the last
+// reset are no longer in the log and are ignored.
+func (sl *sseLogState) close(id int, by string) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	if conn := sl.find(id); conn != nil {
+		now := time.Now()
+		conn.ClosedAt = &now
+		conn.ClosedBy = by
+	}
+}
+
+// record logs the outcome of sending msg on connection id.
+func (sl *sseLogState) record(id int, msg sseMessage, status string) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	conn := sl.find(id)
+	if conn == nil {
+		return
+	}
+	if len(conn.Deliveries) >= maxSSEDeliveries {
+		conn.DeliveriesOmitted++
+		return
+	}
+	conn.Deliveries = append(conn.Deliveries, SSEDelivery{
+		Event:  msg.event,
+		Data:   string(msg.data),
+		Status: status,
+		At:     time.Now(),
+	})
+}
+
+// GetSSEConnections returns every recorded stream connection attempt with
+// its deliveries.
+func (s *Server) GetSSEConnections() []SSEConnection {
+	s.sseLog.mu.Lock()
+	defer s.sseLog.mu.Unlock()
+	conns := append([]SSEConnection(nil), s.sseLog.conns...)
+	for i := range conns {
+		conns[i].Deliveries = append([]SSEDelivery(nil), conns[i].Deliveries...)
+	}
+	return conns
+}
+
+// RejectSSEConnections refuses the next stream connection attempts.
+func (s *Server) RejectSSEConnections(config SSERejectConfig) {
+	s.sseLog.mu.Lock()
+	defer s.sseLog.mu.Unlock()
+	s.sseLog.reject = config
+	s.sseLog.rejected = 0
+}
+
+// ResetSSEConnections clears the connection log and stops rejecting.
+func (s *Server) ResetSSEConnections() {
+	s.sseLog.mu.Lock()
+	defer s.sseLog.mu.Unlock()
+	s.sseLog.conns = nil
+	s.sseLog.reject = SSERejectConfig{}
+	s.sseLog.rejected = 0
+}
+
+// handleSSEConnections is the test control endpoint for the stream
+// connection log (GET lists attempts, POST rejects attempts, DELETE resets).
+func (s *Server) handleSSEConnections(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SSEConnectionsResponse{Connections: s.GetSSEConnections()})
+
+	case http.MethodPost:
+		var config SSERejectConfig
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.RejectSSEConnections(config)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	case http.MethodDelete:
+		s.ResetSSEConnections()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
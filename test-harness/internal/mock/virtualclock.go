@@ -0,0 +1,149 @@
+package mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ScheduledFlip turns a flag on or off when the virtual clock reaches AtMs.
+type ScheduledFlip struct {
+	AtMs    int64  `json:"atMs"` // Virtual time, from 0 at reset
+	FlagKey string `json:"flagKey"`
+	Enabled bool   `json:"enabled"`
+}
+
+// FiredFlip is a scheduled flip the virtual clock has applied.
+type FiredFlip struct {
+	AtMs    int64     `json:"atMs"`
+	FlagKey string    `json:"flagKey"`
+	Enabled bool      `json:"enabled"`
+	FiredAt time.Time `json:"firedAt"` // Host clock, when the flag changed
+}
+
+// VirtualClockRequest is the body of POST /api/v1/test/virtual-clock.
+// Flips in Schedule are added before the clock moves by AdvanceMs.
+type VirtualClockRequest struct {
+	Schedule  []ScheduledFlip `json:"schedule,omitempty"`
+	AdvanceMs int64           `json:"advanceMs,omitempty"`
+}
+
+// virtualClockState holds the virtual time and the flips scheduled on it.
+// The virtual clock only moves when advanced, so tests can cover hours of
+// schedule in milliseconds.
+type virtualClockState struct {
+	mu      sync.Mutex
+	nowMs   int64
+	pending []ScheduledFlip
+	fired   []FiredFlip
+}
+
+func newVirtualClockState() *virtualClockState {
+	return &virtualClockState{}
+}
+
+// ScheduleFlips adds flips to the virtual clock. Flips at or before the
+// current virtual time fire on the next advance.
+func (s *Server) ScheduleFlips(flips ...ScheduledFlip) {
+	s.virtualClock.mu.Lock()
+	defer s.virtualClock.mu.Unlock()
+	s.virtualClock.pending = append(s.virtualClock.pending, flips...)
+	sort.SliceStable(s.virtualClock.pending, func(i, j int) bool {
+		return s.virtualClock.pending[i].AtMs < s.virtualClock.pending[j].AtMs
+	})
+}
+
+// AdvanceVirtualClock moves the virtual clock forward by d and applies the
+// flips that came due, in schedule order. Each flip updates the flag and
+// is broadcast to SSE clients, as a dashboard change would be.
+func (s *Server) AdvanceVirtualClock(d time.Duration) []FiredFlip {
+	s.virtualClock.mu.Lock()
+	s.virtualClock.nowMs += d.Milliseconds()
+	var due []ScheduledFlip
+	i := 0
+	for ; i < len(s.virtualClock.pending) && s.virtualClock.pending[i].AtMs <= s.virtualClock.nowMs; i++ {
+		due = append(due, s.virtualClock.pending[i])
+	}
+	s.virtualClock.pending = s.virtualClock.pending[i:]
+	s.virtualClock.mu.Unlock()
+
+	fired := make([]FiredFlip, 0, len(due))
+	for _, flip := range due {
+		s.applyFlip(flip)
+		fired = append(fired, FiredFlip{AtMs: flip.AtMs, FlagKey: flip.FlagKey, Enabled: flip.Enabled, FiredAt: time.Now()})
+	}
+
+	s.virtualClock.mu.Lock()
+	s.virtualClock.fired = append(s.virtualClock.fired, fired...)
+	s.virtualClock.mu.Unlock()
+	return fired
+}
+
+// applyFlip turns a flag on or off, keeping the rest of its targeting. A
+// missing flag is created fully rolled out.
+func (s *Server) applyFlip(flip ScheduledFlip) {
+	flag := FlagState{Key: flip.FlagKey, RolloutPercentage: 100}
+	if existing, ok := s.flags.Get(flip.FlagKey); ok {
+		flag = *existing
+	}
+	flag.Enabled = flip.Enabled
+	s.flags.Set(&flag)
+	s.BroadcastFlagChange(flip.FlagKey, flip.Enabled)
+}
+
+// GetVirtualClock returns the virtual time and the pending and fired flips.
+func (s *Server) GetVirtualClock() VirtualClockResponse {
+	s.virtualClock.mu.Lock()
+	defer s.virtualClock.mu.Unlock()
+	return VirtualClockResponse{
+		NowMs:   s.virtualClock.nowMs,
+		Pending: append([]ScheduledFlip{}, s.virtualClock.pending...),
+		Fired:   append([]FiredFlip{}, s.virtualClock.fired...),
+	}
+}
+
+// ResetVirtualClock sets the virtual time back to 0 and drops all flips.
+func (s *Server) ResetVirtualClock() {
+	s.virtualClock.mu.Lock()
+	defer s.virtualClock.mu.Unlock()
+	s.virtualClock.nowMs = 0
+	s.virtualClock.pending = nil
+	s.virtualClock.fired = nil
+}
+
+// handleVirtualClock is the test control endpoint for the virtual clock
+// (POST schedules flips and advances, GET returns the state, DELETE resets).
+func (s *Server) handleVirtualClock(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req VirtualClockRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.AdvanceMs < 0 {
+			http.Error(w, "advanceMs must not be negative", http.StatusBadRequest)
+			return
+		}
+		s.ScheduleFlips(req.Schedule...)
+		s.AdvanceVirtualClock(time.Duration(req.AdvanceMs) * time.Millisecond)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.GetVirtualClock())
+
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.GetVirtualClock())
+
+	case http.MethodDelete:
+		s.ResetVirtualClock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SuccessResponse{Success: true})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,45 @@
+package mock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVirtualClock checks that flips fire only once the virtual clock
+// reaches them, in schedule order, and keep the flag's other targeting.
+func TestVirtualClock(t *testing.T) {
+	server := NewServer("test-key")
+	server.SetFlag(&FlagState{Key: "scheduled", Enabled: false, TargetUsers: []string{"user-1"}})
+
+	server.ScheduleFlips(
+		ScheduledFlip{AtMs: 120_000, FlagKey: "scheduled", Enabled: false},
+		ScheduledFlip{AtMs: 60_000, FlagKey: "scheduled", Enabled: true},
+		ScheduledFlip{AtMs: 60_000, FlagKey: "created", Enabled: true},
+	)
+
+	assert.Empty(t, server.AdvanceVirtualClock(59*time.Second), "nothing is due yet")
+
+	fired := server.AdvanceVirtualClock(time.Second)
+	require.Len(t, fired, 2)
+	assert.Equal(t, "scheduled", fired[0].FlagKey, "flips at the same time keep their schedule order")
+	flag, _ := server.GetFlagStore().Get("scheduled")
+	assert.True(t, flag.Enabled)
+	assert.Equal(t, []string{"user-1"}, flag.TargetUsers)
+	created, ok := server.GetFlagStore().Get("created")
+	require.True(t, ok, "a missing flag is created")
+	assert.Equal(t, 100, created.RolloutPercentage)
+
+	state := server.GetVirtualClock()
+	assert.Equal(t, int64(60_000), state.NowMs)
+	require.Len(t, state.Pending, 1)
+	assert.Len(t, state.Fired, 2)
+
+	server.ResetVirtualClock()
+	state = server.GetVirtualClock()
+	assert.Zero(t, state.NowMs)
+	assert.Empty(t, state.Pending)
+	assert.Empty(t, state.Fired)
+}
@@ -18,15 +18,41 @@ type Command struct {
 	VariationID   string                 `json:"variationId,omitempty"`
 	EventValue    *float64               `json:"eventValue,omitempty"`
 	EventMetadata map[string]interface{} `json:"eventMetadata,omitempty"`
+
+	// NoEvaluationContext tracks the event without the variation and
+	// reason of the flag's last evaluation; SDKs that do not attach them
+	// ignore it
+	NoEvaluationContext bool `json:"noEvaluationContext,omitempty"`
+	// For setApiKey
+	APIKey string `json:"apiKey,omitempty"`
+
+	// For identifyBatch: the users to register and whether to prefetch
+	// their flags
+	Users    []UserContext `json:"users,omitempty"`
+	Prefetch bool          `json:"prefetch,omitempty"`
 }
 
 // Config represents SDK initialization configuration.
 type Config struct {
 	APIKey          string `json:"apiKey"`
 	BaseURL         string `json:"baseUrl"`
+	SocketPath      string `json:"socketPath,omitempty"`      // Unix socket to dial instead of BaseURL's host
 	RefreshInterval int    `json:"refreshInterval,omitempty"` // ms, 0 to disable
 	EnableStreaming bool   `json:"enableStreaming,omitempty"`
 	Timeout         int    `json:"timeout,omitempty"` // ms
+	// StartWaitTimeMs makes init wait at most this long for the first flags
+	// while the SDK keeps initializing in the background; init fails if the
+	// wait expires (ms, 0 to block until initialized)
+	StartWaitTimeMs int `json:"startWaitTimeMs,omitempty"`
+	// Bootstrap seeds boolean flags the SDK serves until its first fetch;
+	// SDKs without bootstrap support ignore it
+	Bootstrap map[string]bool `json:"bootstrap,omitempty"`
+	// HonorServerHints makes the SDK follow poll interval and streaming
+	// hints on flags responses; SDKs without hint support ignore it
+	HonorServerHints bool `json:"honorServerHints,omitempty"`
+	// FallbackBaseURLs are tried in order when BaseURL is unreachable;
+	// SDKs without failover support ignore them
+	FallbackBaseURLs []string `json:"fallbackBaseUrls,omitempty"`
 }
 
 // UserContext represents a user for targeting.
@@ -34,6 +60,16 @@ type UserContext struct {
 	ID         string                 `json:"id"`
 	Email      string                 `json:"email,omitempty"`
 	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	// Contexts are non-user contexts (organization, device, ...) targeted
+	// as "<kind>.<attribute>"; SDKs without multi-context support ignore them
+	Contexts []Context `json:"contexts,omitempty"`
+}
+
+// Context is a non-user evaluation context of one kind.
+type Context struct {
+	Kind       string                 `json:"kind"`
+	Key        string                 `json:"key"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
 // Supported command types.
@@ -54,6 +90,9 @@ const (
 	CommandFlushEvents       = "flushEvents"
 	CommandFlushTelemetry    = "flushTelemetry"
 	CommandGetTelemetryStats = "getTelemetryStats"
+	CommandGetProcessStats   = "getProcessStats"
+	CommandSetAPIKey         = "setApiKey"     // Optional: rotate the API key of a running client
+	CommandIdentifyBatch     = "identifyBatch" // Optional: register many users, keeping the client's own
 )
 
 // NewInitCommand creates an init command.
@@ -184,3 +223,18 @@ func NewFlushTelemetryCommand() Command {
 func NewGetTelemetryStatsCommand() Command {
 	return Command{Command: CommandGetTelemetryStats}
 }
+
+// NewGetProcessStatsCommand creates a getProcessStats command.
+func NewGetProcessStatsCommand() Command {
+	return Command{Command: CommandGetProcessStats}
+}
+
+// NewSetAPIKeyCommand creates a setApiKey command.
+func NewSetAPIKeyCommand(apiKey string) Command {
+	return Command{Command: CommandSetAPIKey, APIKey: apiKey}
+}
+
+// NewIdentifyBatchCommand creates an identifyBatch command.
+func NewIdentifyBatchCommand(users []UserContext, prefetch bool) Command {
+	return Command{Command: CommandIdentifyBatch, Users: users, Prefetch: prefetch}
+}
@@ -2,11 +2,13 @@ package protocol
 
 // EvaluationReason explains why a flag evaluated to a particular value.
 type EvaluationReason struct {
-	Kind       string `json:"kind"`                 // OFF, TARGET_MATCH, RULE_MATCH, FALLTHROUGH, ERROR, UNKNOWN
+	Kind       string `json:"kind"`                 // OFF, PREREQUISITE_FAILED, TARGET_MATCH, RULE_MATCH, FALLTHROUGH, ERROR, UNKNOWN, BOOTSTRAP
 	RuleID     string `json:"ruleId,omitempty"`     // For RULE_MATCH
 	RuleIndex  *int   `json:"ruleIndex,omitempty"`  // For RULE_MATCH
 	InRollout  *bool  `json:"inRollout,omitempty"`  // Whether user was in rollout percentage
 	ErrorKind  string `json:"errorKind,omitempty"`  // For ERROR
+
+	PrerequisiteKey string `json:"prerequisiteKey,omitempty"` // For PREREQUISITE_FAILED
 }
 
 // Response represents a response from a test service.
@@ -22,6 +24,10 @@ type Response struct {
 	// For evaluation detail methods
 	Reason      *EvaluationReason `json:"reason,omitempty"`
 	VariationID string            `json:"variationId,omitempty"`
+	// Version and last update (RFC 3339) of the flag configuration the
+	// value was evaluated from, when the SDK reports them
+	FlagVersion   int    `json:"flagVersion,omitempty"`
+	FlagUpdatedAt string `json:"flagUpdatedAt,omitempty"`
 
 	// For getAllFlags
 	Flags map[string]bool `json:"flags,omitempty"`
@@ -30,6 +36,7 @@ type Response struct {
 	IsReady      *bool       `json:"isReady,omitempty"`
 	CircuitState string      `json:"circuitState,omitempty"`
 	CacheStats   *CacheStats `json:"cacheStats,omitempty"`
+	EventStats   *EventStats `json:"eventStats,omitempty"` // Optional
 
 	// For success responses
 	Success *bool `json:"success,omitempty"`
@@ -40,6 +47,9 @@ type Response struct {
 	// For telemetry
 	TelemetryStats *TelemetryStats `json:"telemetryStats,omitempty"`
 
+	// For getProcessStats
+	ProcessStats *ProcessStats `json:"processStats,omitempty"`
+
 	// For errors
 	Error   string `json:"error,omitempty"`
 	Message string `json:"message,omitempty"`
@@ -51,12 +61,31 @@ type CacheStats struct {
 	Misses int64 `json:"misses"`
 }
 
+// EventStats represents the event buffer and the outcome of the last flush.
+type EventStats struct {
+	Buffered        int    `json:"buffered"`
+	Dropped         int64  `json:"dropped"`                   // Discarded after failed flushes overflowed the buffer (and spill file)
+	LastFlushTime   string `json:"lastFlushTime,omitempty"`   // RFC 3339; empty before the first flush
+	LastFlushStatus int    `json:"lastFlushStatus,omitempty"` // HTTP status; 0 if the flush got no response
+	LastFlushError  string `json:"lastFlushError,omitempty"`
+
+	Delivered *int64 `json:"delivered,omitempty"` // Optional; events accepted by the events endpoint
+}
+
 // TelemetryStats represents telemetry buffer statistics.
 type TelemetryStats struct {
 	FlagCount       int `json:"flagCount"`
 	EvaluationCount int `json:"evaluationCount"`
 }
 
+// ProcessStats is a resource snapshot of the test service process, used to
+// detect leaks across a test. Fields a runtime cannot report are zero.
+type ProcessStats struct {
+	Goroutines int    `json:"goroutines,omitempty"` // Go services only
+	HeapBytes  uint64 `json:"heapBytes,omitempty"`  // Live heap after a forced GC where the runtime allows it
+	RSSBytes   uint64 `json:"rssBytes,omitempty"`   // Resident set size
+}
+
 // ErrorResponse creates an error response.
 func ErrorResponse(errorType, message string) Response {
 	return Response{
@@ -0,0 +1,97 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// junitSuites is the root of a JUnit XML report: one suite per SDK.
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+// junitCase is one test on one SDK; the classname is its capability, so CI
+// viewers group tests the way the compatibility matrix does.
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// seconds formats d as JUnit's decimal seconds.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes results as JUnit XML, with a test suite per SDK.
+func WriteJUnit(w io.Writer, results []Result) error {
+	root := junitSuites{}
+	var total time.Duration
+	suites := make(map[string]int)
+	suiteTimes := make(map[string]time.Duration)
+	for _, r := range results {
+		i, ok := suites[r.SDK]
+		if !ok {
+			i = len(root.Suites)
+			suites[r.SDK] = i
+			root.Suites = append(root.Suites, junitSuite{Name: r.SDK})
+		}
+		suite := &root.Suites[i]
+		tc := junitCase{Name: r.Name, Classname: r.Capability, Time: seconds(r.Elapsed)}
+		switch r.Status {
+		case StatusFail:
+			tc.Failure = &junitMessage{Message: "failed", Body: r.Output}
+			suite.Failures++
+		case StatusUnsupported, StatusSkipped:
+			// The message tells unsupported capabilities from environment skips
+			tc.Skipped = &junitMessage{Message: string(r.Status) + ": " + r.Reason, Body: r.Output}
+			suite.Skipped++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+		suiteTimes[r.SDK] += r.Elapsed
+		total += r.Elapsed
+	}
+	for i := range root.Suites {
+		suite := &root.Suites[i]
+		suite.Time = seconds(suiteTimes[suite.Name])
+		root.Tests += suite.Tests
+		root.Failures += suite.Failures
+		root.Skipped += suite.Skipped
+	}
+	root.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,328 @@
+// Package report turns the `go test -json` output of the contract tests into
+// per-SDK results, JUnit XML and an SDK × capability compatibility matrix.
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of a test for one SDK.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	// StatusUnsupported is a skip because the SDK lacks the capability
+	// under test, marked with UnsupportedMarker
+	StatusUnsupported Status = "skipped-unsupported"
+	// StatusSkipped is any other skip: the environment (an external
+	// server, -short, no IPv6) could not run the test
+	StatusSkipped Status = "skipped-environment"
+	// StatusPartial is a matrix cell where some tests pass and others are
+	// unsupported
+	StatusPartial Status = "partial"
+)
+
+// UnsupportedMarker starts the message of skips caused by a missing SDK
+// capability (see tests.SkipUnsupported).
+const UnsupportedMarker = "[unsupported] "
+
+// OtherCapability groups tests whose source file is unknown.
+const OtherCapability = "other"
+
+// Result is the outcome of one test for one SDK.
+type Result struct {
+	Test       string        `json:"test"` // Top-level test function
+	Name       string        `json:"name"` // Full name; the per-SDK subtest when there is one
+	SDK        string        `json:"sdk"`
+	Capability string        `json:"capability"`
+	Status     Status        `json:"status"`
+	Elapsed    time.Duration `json:"elapsed"`
+	Output     string        `json:"output,omitempty"` // Kept for failed and skipped tests
+	Reason     string        `json:"reason,omitempty"` // Skip message
+}
+
+// event is one line of `go test -json` output (see `go doc test2json`).
+type event struct {
+	Action  string
+	Test    string
+	Output  string
+	Elapsed float64
+}
+
+// testRun accumulates the events of one test or subtest.
+type testRun struct {
+	action  string // Final action: pass, fail or skip; empty if it never ended
+	elapsed time.Duration
+	output  strings.Builder
+}
+
+// logLine matches a t.Log or t.Skip line of test output.
+var logLine = regexp.MustCompile(`^\s+\S+\.go:\d+: (.*)$`)
+
+// skipStatus classifies a skip by its output, returning the skip message.
+func skipStatus(output string) (Status, string) {
+	var reason string
+	for _, line := range strings.Split(output, "\n") {
+		if i := strings.Index(line, UnsupportedMarker); i >= 0 {
+			return StatusUnsupported, strings.TrimSpace(line[i+len(UnsupportedMarker):])
+		}
+		if m := logLine.FindStringSubmatch(line); m != nil {
+			reason = strings.TrimSpace(m[1])
+		}
+	}
+	return StatusSkipped, reason
+}
+
+// testFunc matches the contract test functions in a source file.
+var testFunc = regexp.MustCompile(`(?m)^func (Test\w+)\(\w+ \*testing\.T\)`)
+
+// Capabilities maps every test function in the _test.go files of dir to its
+// capability, the file's name without _test.go (TestSegmentBasicMatch in
+// segments_test.go is "segments").
+func Capabilities(dir string) (map[string]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no test files in %s", dir)
+	}
+	capabilities := make(map[string]string)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		capability := strings.TrimSuffix(filepath.Base(path), "_test.go")
+		for _, m := range testFunc.FindAllStringSubmatch(string(data), -1) {
+			capabilities[m[1]] = capability
+		}
+	}
+	return capabilities, nil
+}
+
+// Parse reads `go test -json` output and returns one result per test and
+// SDK. Subtests ending in an SDK name (as RunForEachSDK names them) are
+// that SDK's result; a test without them counts for every SDK. Tests that
+// never ended, because the run timed out or panicked, fail.
+func Parse(r io.Reader, sdks []string, capabilities map[string]string) ([]Result, error) {
+	isSDK := make(map[string]bool, len(sdks))
+	for _, sdk := range sdks {
+		isSDK[sdk] = true
+	}
+
+	runs := make(map[string]*testRun)
+	var order []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Test == "" {
+			// Package-level events and non-JSON lines (build output)
+			continue
+		}
+		run := runs[e.Test]
+		if run == nil {
+			run = &testRun{}
+			runs[e.Test] = run
+			order = append(order, e.Test)
+		}
+		switch e.Action {
+		case "output":
+			run.output.WriteString(e.Output)
+		case "pass", "fail", "skip":
+			run.action = e.Action
+			run.elapsed = time.Duration(e.Elapsed * float64(time.Second))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("no test events in the input")
+	}
+
+	capabilityOf := func(test string) string {
+		if c, ok := capabilities[test]; ok {
+			return c
+		}
+		return OtherCapability
+	}
+	result := func(name, test, sdk string, run *testRun) Result {
+		res := Result{
+			Test:       test,
+			Name:       name,
+			SDK:        sdk,
+			Capability: capabilityOf(test),
+			Elapsed:    run.elapsed,
+		}
+		switch run.action {
+		case "pass":
+			res.Status = StatusPass
+		case "skip":
+			res.Status, res.Reason = skipStatus(run.output.String())
+		default:
+			res.Status = StatusFail
+		}
+		if res.Status != StatusPass {
+			res.Output = run.output.String()
+		}
+		return res
+	}
+
+	var results []Result
+	perSDK := make(map[string]bool)
+	for _, name := range order {
+		parts := strings.Split(name, "/")
+		if len(parts) > 1 && isSDK[parts[len(parts)-1]] {
+			results = append(results, result(name, parts[0], parts[len(parts)-1], runs[name]))
+			perSDK[parts[0]] = true
+		}
+	}
+	for _, name := range order {
+		if strings.Contains(name, "/") || perSDK[name] {
+			continue
+		}
+		for _, sdk := range sdks {
+			results = append(results, result(name, name, sdk, runs[name]))
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].SDK != results[j].SDK {
+			return results[i].SDK < results[j].SDK
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
+
+// Cell counts the results of one SDK on one capability.
+type Cell struct {
+	// Status is fail if any test failed; otherwise partial if tests both
+	// passed and were unsupported, then pass, skipped-unsupported and
+	// skipped-environment by what the tests did
+	Status      Status `json:"status"`
+	Passed      int    `json:"passed"`
+	Failed      int    `json:"failed"`
+	Unsupported int    `json:"unsupported"`
+	Skipped     int    `json:"skipped"` // Skipped by the environment
+}
+
+func (c *Cell) add(status Status) {
+	switch status {
+	case StatusPass:
+		c.Passed++
+	case StatusFail:
+		c.Failed++
+	case StatusUnsupported:
+		c.Unsupported++
+	case StatusSkipped:
+		c.Skipped++
+	}
+	switch {
+	case c.Failed > 0:
+		c.Status = StatusFail
+	case c.Passed > 0 && c.Unsupported > 0:
+		c.Status = StatusPartial
+	case c.Passed > 0:
+		c.Status = StatusPass
+	case c.Unsupported > 0:
+		c.Status = StatusUnsupported
+	default:
+		c.Status = StatusSkipped
+	}
+}
+
+// Unsupported is a test an SDK skipped for lack of a capability.
+type Unsupported struct {
+	Capability string `json:"capability"`
+	Test       string `json:"test"`
+	Reason     string `json:"reason"`
+}
+
+// Matrix is the SDK × capability compatibility matrix.
+type Matrix struct {
+	GeneratedAt  time.Time                  `json:"generatedAt"`
+	SDKs         []string                   `json:"sdks"`
+	Capabilities []string                   `json:"capabilities"`
+	Results      map[string]map[string]Cell `json:"results"` // SDK, then capability
+	// Backlog lists each SDK's unsupported tests by capability: the
+	// features it has yet to implement
+	Backlog map[string][]Unsupported `json:"backlog"`
+}
+
+// BuildMatrix aggregates results per SDK and capability.
+func BuildMatrix(results []Result) Matrix {
+	m := Matrix{
+		GeneratedAt: time.Now().UTC(),
+		Results:     make(map[string]map[string]Cell),
+		Backlog:     make(map[string][]Unsupported),
+	}
+	capabilities := make(map[string]bool)
+	for _, r := range results {
+		if m.Results[r.SDK] == nil {
+			m.Results[r.SDK] = make(map[string]Cell)
+			m.SDKs = append(m.SDKs, r.SDK)
+		}
+		capabilities[r.Capability] = true
+		cell := m.Results[r.SDK][r.Capability]
+		cell.add(r.Status)
+		m.Results[r.SDK][r.Capability] = cell
+		if r.Status == StatusUnsupported {
+			m.Backlog[r.SDK] = append(m.Backlog[r.SDK], Unsupported{r.Capability, r.Name, r.Reason})
+		}
+	}
+	for _, backlog := range m.Backlog {
+		sort.SliceStable(backlog, func(i, j int) bool { return backlog[i].Capability < backlog[j].Capability })
+	}
+	for c := range capabilities {
+		m.Capabilities = append(m.Capabilities, c)
+	}
+	sort.Strings(m.SDKs)
+	sort.Strings(m.Capabilities)
+	return m
+}
+
+// Count tallies the results of every SDK and capability together.
+func Count(results []Result) Cell {
+	var total Cell
+	for _, r := range results {
+		total.add(r.Status)
+	}
+	return total
+}
+
+// WriteDir writes junit.xml and compatibility.json for results to dir,
+// creating it if needed.
+func WriteDir(dir string, results []Result) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	junit, err := os.Create(filepath.Join(dir, "junit.xml"))
+	if err != nil {
+		return err
+	}
+	defer junit.Close()
+	if err := WriteJUnit(junit, results); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(BuildMatrix(results), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "compatibility.json"), append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return junit.Close()
+}
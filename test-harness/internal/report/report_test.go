@@ -0,0 +1,165 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// events is `go test -json` output of a run against sdk-go and sdk-node:
+// a per-SDK test where sdk-node fails, a test shared by both SDKs, a test
+// skipped for both by the environment, a per-SDK test sdk-node does not
+// support, and a test cut short by a timeout.
+const events = `{"Action":"start","Package":"github.com/rollgate/test-harness/internal/tests"}
+{"Action":"run","Test":"TestPollingRequests"}
+{"Action":"run","Test":"TestPollingRequests/polling_requests/sdk-go"}
+{"Action":"pass","Test":"TestPollingRequests/polling_requests/sdk-go","Elapsed":3.2}
+{"Action":"run","Test":"TestPollingRequests/polling_requests/sdk-node"}
+{"Action":"output","Test":"TestPollingRequests/polling_requests/sdk-node","Output":"    requestlog_test.go:55: 2 flags requests\n"}
+{"Action":"fail","Test":"TestPollingRequests/polling_requests/sdk-node","Elapsed":3.1}
+{"Action":"fail","Test":"TestPollingRequests","Elapsed":6.3}
+{"Action":"run","Test":"TestSegmentBasicMatch"}
+{"Action":"pass","Test":"TestSegmentBasicMatch","Elapsed":0.1}
+{"Action":"run","Test":"TestStreamingReconnect"}
+{"Action":"output","Test":"TestStreamingReconnect","Output":"    streaming_test.go:12: requires mock server\n"}
+{"Action":"skip","Test":"TestStreamingReconnect","Elapsed":0}
+{"Action":"run","Test":"TestTypedFlags/getString/sdk-go"}
+{"Action":"pass","Test":"TestTypedFlags/getString/sdk-go","Elapsed":0.2}
+{"Action":"run","Test":"TestTypedFlags/getString/sdk-node"}
+{"Action":"output","Test":"TestTypedFlags/getString/sdk-node","Output":"=== RUN   TestTypedFlags/getString/sdk-node\n"}
+{"Action":"output","Test":"TestTypedFlags/getString/sdk-node","Output":"    typed_flags_test.go:40: [unsupported] sdk-node: getString not supported (V2 feature)\n"}
+{"Action":"output","Test":"TestTypedFlags/getString/sdk-node","Output":"--- SKIP: TestTypedFlags/getString/sdk-node (0.00s)\n"}
+{"Action":"skip","Test":"TestTypedFlags/getString/sdk-node","Elapsed":0}
+{"Action":"pass","Test":"TestTypedFlags","Elapsed":0.2}
+{"Action":"run","Test":"TestSegmentNoMatch"}
+{"Action":"output","Test":"TestSegmentNoMatch","Output":"panic: test timed out after 5m0s\n"}
+not json: build output
+{"Action":"fail","Package":"github.com/rollgate/test-harness/internal/tests","Elapsed":300}
+`
+
+var capabilities = map[string]string{
+	"TestPollingRequests":   "requestlog",
+	"TestSegmentBasicMatch": "segments",
+	"TestSegmentNoMatch":    "segments",
+	"TestTypedFlags":        "typed_flags",
+}
+
+func parseEvents(t *testing.T) []Result {
+	t.Helper()
+	results, err := Parse(strings.NewReader(events), []string{"sdk-go", "sdk-node"}, capabilities)
+	require.NoError(t, err)
+	return results
+}
+
+// TestParse checks how test events become per-SDK results.
+func TestParse(t *testing.T) {
+	results := parseEvents(t)
+
+	byKey := make(map[string]Result)
+	for _, r := range results {
+		byKey[r.SDK+" "+r.Test] = r
+	}
+	assert.Len(t, results, 10, "5 tests for 2 SDKs")
+
+	assert.Equal(t, StatusPass, byKey["sdk-go TestPollingRequests"].Status)
+	node := byKey["sdk-node TestPollingRequests"]
+	assert.Equal(t, StatusFail, node.Status, "a per-SDK subtest fails only its SDK")
+	assert.Equal(t, "TestPollingRequests/polling_requests/sdk-node", node.Name)
+	assert.Equal(t, "requestlog", node.Capability)
+	assert.Contains(t, node.Output, "2 flags requests")
+
+	assert.Equal(t, StatusPass, byKey["sdk-node TestSegmentBasicMatch"].Status, "a shared test counts for every SDK")
+	skipped := byKey["sdk-go TestStreamingReconnect"]
+	assert.Equal(t, StatusSkipped, skipped.Status, "skips without the marker are environment skips")
+	assert.Equal(t, "requires mock server", skipped.Reason)
+	unsupported := byKey["sdk-node TestTypedFlags"]
+	assert.Equal(t, StatusUnsupported, unsupported.Status)
+	assert.Equal(t, "sdk-node: getString not supported (V2 feature)", unsupported.Reason)
+	assert.Equal(t, StatusPass, byKey["sdk-go TestTypedFlags"].Status)
+	assert.Equal(t, OtherCapability, byKey["sdk-go TestStreamingReconnect"].Capability)
+	assert.Equal(t, StatusFail, byKey["sdk-go TestSegmentNoMatch"].Status, "a test that never ended fails")
+	assert.Empty(t, byKey["sdk-go TestSegmentBasicMatch"].Output, "passing tests keep no output")
+
+	_, err := Parse(strings.NewReader("FAIL build failed\n"), []string{"sdk-go"}, nil)
+	assert.Error(t, err)
+}
+
+// TestBuildMatrix checks the aggregation per SDK and capability.
+func TestBuildMatrix(t *testing.T) {
+	m := BuildMatrix(parseEvents(t))
+	assert.Equal(t, []string{"sdk-go", "sdk-node"}, m.SDKs)
+	assert.Equal(t, []string{"other", "requestlog", "segments", "typed_flags"}, m.Capabilities)
+	assert.Equal(t, Cell{Status: StatusFail, Passed: 1, Failed: 1}, m.Results["sdk-go"]["segments"])
+	assert.Equal(t, Cell{Status: StatusPass, Passed: 1}, m.Results["sdk-go"]["requestlog"])
+	assert.Equal(t, Cell{Status: StatusFail, Failed: 1}, m.Results["sdk-node"]["requestlog"])
+	assert.Equal(t, Cell{Status: StatusSkipped, Skipped: 1}, m.Results["sdk-node"]["other"])
+	assert.Equal(t, Cell{Status: StatusUnsupported, Unsupported: 1}, m.Results["sdk-node"]["typed_flags"])
+	assert.Equal(t, Cell{Status: StatusPass, Passed: 1}, m.Results["sdk-go"]["typed_flags"])
+
+	assert.Empty(t, m.Backlog["sdk-go"])
+	assert.Equal(t, []Unsupported{{"typed_flags", "TestTypedFlags/getString/sdk-node", "sdk-node: getString not supported (V2 feature)"}},
+		m.Backlog["sdk-node"], "unsupported tests are the SDK's backlog")
+
+	var partial Cell
+	partial.add(StatusPass)
+	partial.add(StatusUnsupported)
+	partial.add(StatusSkipped)
+	assert.Equal(t, Cell{Status: StatusPartial, Passed: 1, Unsupported: 1, Skipped: 1}, partial)
+}
+
+// TestWriteJUnit checks the JUnit XML: a suite per SDK with its counts.
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, parseEvents(t)))
+
+	var root junitSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &root))
+	assert.Equal(t, 10, root.Tests)
+	assert.Equal(t, 3, root.Failures)
+	assert.Equal(t, 3, root.Skipped)
+	require.Len(t, root.Suites, 2)
+	node := root.Suites[1]
+	assert.Equal(t, "sdk-node", node.Name)
+	assert.Equal(t, 2, node.Failures)
+	assert.Equal(t, "3.100", node.Cases[0].Time)
+	require.NotNil(t, node.Cases[0].Failure)
+	assert.Contains(t, node.Cases[0].Failure.Body, "2 flags requests")
+	assert.Equal(t, "requestlog", node.Cases[0].Classname)
+	unsupported := node.Cases[len(node.Cases)-1]
+	assert.Equal(t, "TestTypedFlags/getString/sdk-node", unsupported.Name)
+	require.NotNil(t, unsupported.Skipped)
+	assert.Equal(t, "skipped-unsupported: sdk-node: getString not supported (V2 feature)", unsupported.Skipped.Message)
+}
+
+// TestWriteDir checks the files written for -report-dir.
+func TestWriteDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+	require.NoError(t, WriteDir(dir, parseEvents(t)))
+
+	_, err := os.Stat(filepath.Join(dir, "junit.xml"))
+	assert.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(dir, "compatibility.json"))
+	require.NoError(t, err)
+	var m Matrix
+	require.NoError(t, json.Unmarshal(data, &m))
+	assert.Equal(t, StatusFail, m.Results["sdk-node"]["requestlog"].Status)
+}
+
+// TestCapabilities checks that the contract tests map to their files.
+func TestCapabilities(t *testing.T) {
+	capabilities, err := Capabilities("../tests")
+	require.NoError(t, err)
+	assert.Equal(t, "segments", capabilities["TestSegmentBasicMatch"])
+	assert.Equal(t, "requestlog", capabilities["TestPollingRequests"])
+	assert.NotContains(t, capabilities, "TestMain")
+
+	_, err = Capabilities(t.TempDir())
+	assert.Error(t, err)
+}
@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAuthSchemeMock verifies the mock accepts each scheme only when enabled.
+func TestAuthSchemeMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to control auth schemes")
+	}
+	defer h.ResetAuth()
+
+	key := h.GetAPIKey()
+	present := map[mock.AuthScheme]func(*http.Request){
+		mock.AuthBearer: func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+key) },
+		mock.AuthRaw:    func(r *http.Request) { r.Header.Set("Authorization", key) },
+		mock.AuthHeader: func(r *http.Request) { r.Header.Set("X-API-Key", key) },
+		mock.AuthBasic: func(r *http.Request) {
+			r.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(key+":")))
+		},
+		mock.AuthQuery: func(r *http.Request) {
+			q := r.URL.Query()
+			q.Set("api_key", key)
+			r.URL.RawQuery = q.Encode()
+		},
+	}
+
+	for _, enabled := range mock.AllAuthSchemes {
+		h.SetAuthSchemes(enabled)
+		for _, scheme := range mock.AllAuthSchemes {
+			req, err := http.NewRequest(http.MethodGet, h.GetMockURL()+"/api/v1/sdk/flags", nil)
+			require.NoError(t, err)
+			present[scheme](req)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			want := http.StatusUnauthorized
+			if scheme == enabled {
+				want = http.StatusOK
+			}
+			assert.Equal(t, want, resp.StatusCode, "enabled=%s presented=%s", enabled, scheme)
+		}
+	}
+}
+
+// TestAuthSchemeMatrix runs each SDK against every auth scheme on its own.
+// Bearer is required; other schemes are reported so support can be tracked
+// as the production API adds them.
+func TestAuthSchemeMatrix(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to control auth schemes")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetAuth()
+
+	h.SetScenario("basic")
+
+	tc.RunForEachSDK("auth schemes", func(t *testing.T, svc harness.SDKService) {
+		for _, scheme := range mock.AllAuthSchemes {
+			h.SetAuthSchemes(scheme)
+
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfig(), nil))
+			require.NoError(t, err)
+			supported := !resp.IsError()
+			if supported {
+				flag, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+				require.NoError(t, err)
+				supported = flag.Value != nil && *flag.Value
+			}
+			svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+			if scheme == mock.AuthBearer {
+				assert.True(t, supported, "%s must authenticate with Bearer", svc.GetName())
+			}
+			t.Logf("%s: %-10s supported=%v", svc.GetName(), scheme, supported)
+		}
+	})
+
+	stats := h.GetAuthStats()
+	assert.Greater(t, stats.Presented[mock.AuthBearer], 0, "SDKs should present a Bearer token")
+}
+
+// rotatedAPIKey is the key the mock switches to mid-test.
+const rotatedAPIKey = "test-api-key-rotated"
+
+// authFailure matches error messages that name an authentication failure.
+var authFailure = regexp.MustCompile(`(?i)auth|api key|401|unauthori`)
+
+// TestAPIKeyRotationMock verifies the mock rejects the old key after a
+// rotation and closes streams opened with it.
+func TestAPIKeyRotationMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to rotate the API key")
+	}
+	defer h.ResetAPIKey()
+
+	status := func(key string) int {
+		req, err := http.NewRequest(http.MethodGet, h.GetMockURL()+"/api/v1/sdk/flags", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+key)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	oldKey := h.GetAPIKey()
+	stream, err := http.Get(h.GetMockURL() + "/api/v1/sdk/stream?token=" + oldKey)
+	require.NoError(t, err)
+	defer stream.Body.Close()
+	require.Equal(t, http.StatusOK, stream.StatusCode)
+
+	h.RotateAPIKey(rotatedAPIKey)
+	assert.Equal(t, http.StatusUnauthorized, status(oldKey), "the old key must be rejected")
+	assert.Equal(t, http.StatusOK, status(rotatedAPIKey))
+	_, err = io.Copy(io.Discard, stream.Body)
+	assert.NoError(t, err, "the stream opened with the old key must be closed")
+
+	h.ResetAPIKey()
+	assert.Equal(t, http.StatusOK, status(oldKey))
+	assert.Equal(t, http.StatusUnauthorized, status(rotatedAPIKey))
+}
+
+// TestAPIKeyRotation rotates the mock's key while each SDK is running.
+// SDKs that accept a new key at runtime (setApiKey) must recover and serve
+// changes made after the rotation; the rest must report an authentication
+// failure when refreshing instead of silently keeping stale flags, and a
+// new client with the old key must fail to initialize.
+func TestAPIKeyRotation(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to rotate the API key")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetAPIKey()
+
+	tc.RunForEachSDK("key rotation", func(t *testing.T, svc harness.SDKService) {
+		h.ResetAPIKey()
+		h.SetScenario("basic")
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		user := protocol.UserContext{ID: "rotation-user"}
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfig(), &user))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "init failed: %s", resp.Message)
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+		require.NoError(t, err)
+		require.True(t, resp.GetValue(false))
+
+		h.RotateAPIKey(rotatedAPIKey)
+		h.SetFlag(&mock.FlagState{Key: "enabled-flag", Enabled: false})
+
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewSetAPIKeyCommand(rotatedAPIKey))
+		require.NoError(t, err)
+		rotates := !resp.IsError()
+
+		refresh, err := svc.SendCommand(tc.Ctx, protocol.NewIdentifyCommand(user))
+		require.NoError(t, err)
+		if rotates {
+			require.False(t, refresh.IsError(), "refresh with the rotated key failed: %s", refresh.Message)
+			resp, err = svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", true))
+			require.NoError(t, err)
+			assert.False(t, resp.GetValue(true), "flags changed after the rotation must be served")
+		} else {
+			require.True(t, refresh.IsError(), "a refresh with a revoked key must fail, not keep stale flags silently")
+			assert.Regexp(t, authFailure, refresh.Error+" "+refresh.Message, "the failure must name authentication")
+		}
+		t.Logf("%s: runtime key rotation supported=%v", svc.GetName(), rotates)
+		svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfig(), nil))
+		require.NoError(t, err)
+		if assert.True(t, resp.IsError(), "init with the revoked key must fail") {
+			assert.Regexp(t, authFailure, resp.Error+" "+resp.Message)
+		}
+		svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		config := h.InitSDKConfig()
+		config.APIKey = rotatedAPIKey
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "init with the rotated key failed: %s", resp.Message)
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", true))
+		require.NoError(t, err)
+		assert.False(t, resp.GetValue(true), "a client with the rotated key must see current flags")
+	})
+}
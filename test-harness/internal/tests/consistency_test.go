@@ -0,0 +1,181 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// churnFlags is how many flags the churn suite flips together.
+	churnFlags = 8
+	// churnInterval is how often the flags flip.
+	churnInterval = 20 * time.Millisecond
+	// churnDuration is how long the flags churn while the SDK is read.
+	churnDuration = 2 * time.Second
+	// churnPollInterval is the refresh interval of the SDK under churn.
+	churnPollInterval = 50 * time.Millisecond
+)
+
+// churnFlagKey returns the key of the i-th churning flag.
+func churnFlagKey(i int) string {
+	return fmt.Sprintf("churn-%d", i)
+}
+
+// setChurnGeneration turns every churning flag on or off in one batch, so
+// every flags response has them all on or all off.
+func setChurnGeneration(h *harness.Harness, enabled bool) {
+	flags := make([]*mock.FlagState, churnFlags)
+	for i := range flags {
+		flags[i] = &mock.FlagState{Key: churnFlagKey(i), Enabled: enabled, RolloutPercentage: 100}
+	}
+	h.SetFlags(flags...)
+}
+
+// TestGetAllFlagsConsistencyUnderChurn checks that getAllFlags never
+// returns a mix of old and new flags. The mock flips a set of flags
+// together while the SDK polls, and getAllFlags and isEnabled are called
+// concurrently throughout: each getAllFlags response must have the flags
+// all on or all off, and once the churn stops both commands must agree on
+// the final state.
+func TestGetAllFlagsConsistencyUnderChurn(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to flip flags")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	tc.RunForEachSDK("getAllFlags under churn", func(t *testing.T, svc harness.SDKService) {
+		h.GetMockServer().GetFlagStore().Clear()
+		setChurnGeneration(h, false)
+
+		config := h.InitSDKConfig()
+		config.RefreshInterval = int(churnPollInterval / time.Millisecond)
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, &protocol.UserContext{ID: "churn-user"}))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "%s init failed: %s", svc.GetName(), resp.Error)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewGetAllFlagsCommand())
+		require.NoError(t, err)
+		if resp.Error == "UnknownCommand" {
+			SkipUnsupported(t, "%s does not support getAllFlags", svc.GetName())
+		}
+
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+
+		// Flip the flags until the churn ends, leaving them on
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(churnInterval)
+			defer ticker.Stop()
+			enabled := false
+			for {
+				select {
+				case <-stop:
+					setChurnGeneration(h, true)
+					return
+				case <-ticker.C:
+					enabled = !enabled
+					setChurnGeneration(h, enabled)
+				}
+			}
+		}()
+
+		var snapshots, mixed, allOn, allOff, evaluations, failures atomic.Int64
+		for reader := 0; reader < 3; reader++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					resp, err := svc.SendCommand(tc.Ctx, protocol.NewGetAllFlagsCommand())
+					if err != nil || resp.IsError() {
+						failures.Add(1)
+						continue
+					}
+					snapshots.Add(1)
+					on := 0
+					for i := 0; i < churnFlags; i++ {
+						if resp.Flags[churnFlagKey(i)] {
+							on++
+						}
+					}
+					switch on {
+					case churnFlags:
+						allOn.Add(1)
+					case 0:
+						allOff.Add(1)
+					default:
+						if mixed.Add(1) == 1 {
+							t.Logf("%s: mixed getAllFlags response: %v", svc.GetName(), resp.Flags)
+						}
+					}
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand(churnFlagKey(i%churnFlags), false))
+				if err != nil || resp.IsError() || resp.Value == nil {
+					failures.Add(1)
+					continue
+				}
+				evaluations.Add(1)
+			}
+		}()
+
+		time.Sleep(churnDuration)
+		close(stop)
+		wg.Wait()
+
+		t.Logf("%s: %d getAllFlags (%d all on, %d all off), %d isEnabled", svc.GetName(),
+			snapshots.Load(), allOn.Load(), allOff.Load(), evaluations.Load())
+		assert.Zero(t, failures.Load(), "%s: commands failed under churn", svc.GetName())
+		assert.Zero(t, mixed.Load(), "%s: getAllFlags mixed old and new flags", svc.GetName())
+		assert.Positive(t, allOn.Load(), "%s never served the flags on", svc.GetName())
+		assert.Positive(t, allOff.Load(), "%s never served the flags off", svc.GetName())
+		assert.Positive(t, evaluations.Load())
+
+		// Once the churn stops, both commands converge on the final state
+		require.Eventually(t, func() bool {
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewGetAllFlagsCommand())
+			if err != nil {
+				return false
+			}
+			for i := 0; i < churnFlags; i++ {
+				if !resp.Flags[churnFlagKey(i)] {
+					return false
+				}
+			}
+			return true
+		}, 5*churnPollInterval+time.Second, 20*time.Millisecond, "%s did not converge after the churn", svc.GetName())
+		for i := 0; i < churnFlags; i++ {
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand(churnFlagKey(i), false))
+			require.NoError(t, err)
+			assert.True(t, resp.GetValue(false), "%s: isEnabled(%s) disagrees with getAllFlags", svc.GetName(), churnFlagKey(i))
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sentContexts reports whether any identify body or X-User-Context header
+// recorded after seq carried non-user contexts.
+func sentContexts(h *harness.Harness, seq int) bool {
+	for _, req := range h.GetRecordedRequests("") {
+		if req.Seq <= seq {
+			continue
+		}
+		var user mock.IdentifyUser
+		if req.Path == "/api/v1/sdk/identify" {
+			var body mock.IdentifyRequest
+			if json.Unmarshal(req.Body, &body) == nil {
+				user = body.User
+			}
+		}
+		if xuc := req.Header.Get("X-User-Context"); xuc != "" && len(user.Contexts) == 0 {
+			if decoded, err := base64.StdEncoding.DecodeString(xuc); err == nil {
+				json.Unmarshal(decoded, &user)
+			}
+		}
+		if len(user.Contexts) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// TestMultiContextTargeting tests that rules on kind-qualified attributes
+// ("org.plan", "device.os", "org.key") match the contexts sent with a user,
+// and stop matching when the user's contexts change. SDKs that do not send
+// contexts are skipped.
+func TestMultiContextTargeting(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for the request recorder")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("empty")
+	rule := func(attribute, operator, value string) []mock.Rule {
+		return []mock.Rule{{
+			ID:                attribute,
+			Enabled:           true,
+			Conditions:        []mock.Condition{{Attribute: attribute, Operator: operator, Value: value}},
+			RolloutPercentage: 100,
+		}}
+	}
+	h.SetFlag(&mock.FlagState{Key: "enterprise-orgs", Enabled: true, Rules: rule("org.plan", "eq", "enterprise")})
+	h.SetFlag(&mock.FlagState{Key: "ios-devices", Enabled: true, Rules: rule("device.os", "eq", "ios")})
+	h.SetFlag(&mock.FlagState{Key: "acme-only", Enabled: true, Rules: rule("org.key", "eq", "acme")})
+	h.SetFlag(&mock.FlagState{Key: "user-plan", Enabled: true, Rules: rule("plan", "eq", "pro")})
+
+	enterprise := &protocol.UserContext{
+		ID:         "context-user",
+		Attributes: map[string]interface{}{"plan": "pro"},
+		Contexts: []protocol.Context{
+			{Kind: "org", Key: "acme", Attributes: map[string]interface{}{"plan": "enterprise"}},
+			{Kind: "device", Key: "device-1", Attributes: map[string]interface{}{"os": "ios"}},
+		},
+	}
+	free := protocol.UserContext{
+		ID:         "context-user",
+		Attributes: map[string]interface{}{"plan": "pro"},
+		Contexts: []protocol.Context{
+			{Kind: "org", Key: "globex", Attributes: map[string]interface{}{"plan": "free"}},
+			{Kind: "device", Key: "device-2", Attributes: map[string]interface{}{"os": "android"}},
+		},
+	}
+
+	tc.RunForEachSDK("multi-context", func(t *testing.T, svc harness.SDKService) {
+		seq := lastRecordedSeq(h)
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfig(), enterprise))
+		require.NoError(t, err)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		require.False(t, resp.IsError(), "init should succeed: %s - %s", resp.Error, resp.Message)
+		if !sentContexts(h, seq) {
+			SkipUnsupported(t, "SDK does not send contexts")
+		}
+
+		assertFlags := func(want map[string]bool) {
+			t.Helper()
+			for key, expected := range want {
+				resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand(key, !expected))
+				require.NoError(t, err)
+				assert.Equal(t, expected, resp.GetValue(!expected), "flag %s", key)
+			}
+		}
+		assertFlags(map[string]bool{"enterprise-orgs": true, "ios-devices": true, "acme-only": true, "user-plan": true})
+
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewIdentifyCommand(free))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "identify should succeed: %s - %s", resp.Error, resp.Message)
+		assertFlags(map[string]bool{"enterprise-orgs": false, "ios-devices": false, "acme-only": false, "user-plan": true})
+	})
+}
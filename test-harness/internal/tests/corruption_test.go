@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var corruptionModes = []string{mock.CorruptInvalidJSON, mock.CorruptTruncated, mock.CorruptWrongContentType}
+
+// TestCorruptionMock verifies the mock serves unparseable flags responses
+// in each mode, only for the configured count, and leaves errors alone.
+func TestCorruptionMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to corrupt responses")
+	}
+	defer h.ResetCorruption()
+
+	h.SetScenario("basic")
+
+	get := func(apiKey string) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodGet, h.GetMockURL()+"/api/v1/sdk/flags", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, body
+	}
+
+	for _, mode := range corruptionModes {
+		t.Run(mode, func(t *testing.T) {
+			require.NoError(t, h.SetCorruption(mode, 1))
+
+			resp, body := get(h.GetAPIKey())
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			var payload mock.FlagsResponse
+			assert.Error(t, json.Unmarshal(body, &payload), "body should not parse: %s", body)
+			if mode == mock.CorruptWrongContentType {
+				assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html"))
+			}
+
+			// Only the configured count is corrupted
+			resp, body = get(h.GetAPIKey())
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.NoError(t, json.Unmarshal(body, &payload))
+			assert.Equal(t, 1, h.GetCorruptionStats().Corrupted)
+		})
+	}
+
+	// Errors pass through uncorrupted and do not use up the count
+	require.NoError(t, h.SetCorruption(mock.CorruptInvalidJSON, 1))
+	resp, _ := get("wrong-key")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, 0, h.GetCorruptionStats().Corrupted)
+
+	assert.Error(t, h.SetCorruption("garbage", 1), "unknown mode should be rejected")
+}
+
+// TestMalformedResponse checks that SDKs survive unparseable flags
+// responses: Init fails cleanly, a refresh keeps the last known flags, and
+// flags missing because of it are reported as ERROR/MALFORMED_RESPONSE.
+func TestMalformedResponse(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to corrupt responses")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetCorruption()
+
+	h.SetScenario("basic")
+
+	tc.RunForEachSDK("malformed response", func(t *testing.T, svc harness.SDKService) {
+		for _, mode := range corruptionModes {
+			// Init against a corrupted payload fails without crashing the SDK
+			require.NoError(t, h.SetCorruption(mode, -1))
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfig(), nil))
+			require.NoError(t, err, "%s: test service must survive a corrupted init", mode)
+			if !resp.IsError() {
+				flag, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+				require.NoError(t, err)
+				require.NotNil(t, flag.Value)
+				assert.False(t, *flag.Value, "%s: no flags could be parsed, expected the default", mode)
+			}
+			svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+			// A corrupted refresh keeps the last known flags
+			h.ResetCorruption()
+			resp, err = svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfig(), nil))
+			require.NoError(t, err)
+			require.False(t, resp.IsError(), "%s: init failed: %s", mode, resp.Message)
+
+			require.NoError(t, h.SetCorruption(mode, -1))
+			_, err = svc.SendCommand(tc.Ctx, protocol.NewIdentifyCommand(protocol.UserContext{ID: "corrupted-user"}))
+			require.NoError(t, err)
+			if h.GetCorruptionStats().Corrupted == 0 {
+				t.Logf("%s: SDK did not refetch flags on identify, skipping refresh checks", mode)
+				svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+				continue
+			}
+
+			flag, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+			require.NoError(t, err)
+			require.NotNil(t, flag.Value)
+			assert.True(t, *flag.Value, "%s: expected the last known value", mode)
+
+			detail, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledDetailCommand("not-in-cache", false))
+			require.NoError(t, err)
+			require.NotNil(t, detail.Reason, "%s: no reason", mode)
+			assert.Equal(t, "ERROR", detail.Reason.Kind, mode)
+			assert.Equal(t, "MALFORMED_RESPONSE", detail.Reason.ErrorKind, mode)
+
+			h.ResetCorruption()
+			svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		}
+	})
+}
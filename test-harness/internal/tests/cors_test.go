@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// preflight sends a CORS preflight for the flags endpoint.
+func preflight(t *testing.T, baseURL, origin, method, headers string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodOptions, baseURL+"/api/v1/sdk/flags", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+// TestCORSConfiguration tests the CORS control endpoint that browser SDK
+// suites use to exercise preflight failures.
+func TestCORSConfiguration(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to control CORS")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetCORS()
+
+	h.SetScenario("basic")
+	baseURL := h.GetMockURL()
+
+	t.Run("default allows any origin", func(t *testing.T) {
+		resp := preflight(t, baseURL, "https://app.example.com", "GET", "Authorization, X-User-Context")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
+		assert.Contains(t, resp.Header.Get("Access-Control-Expose-Headers"), "ETag")
+	})
+
+	t.Run("restricted origin list", func(t *testing.T) {
+		config := mock.DefaultCORSConfig()
+		config.AllowedOrigins = []string{"https://app.example.com"}
+		config.AllowedHeaders = []string{"Authorization"}
+		config.AllowCredentials = true
+		h.SetCORS(config)
+
+		resp := preflight(t, baseURL, "https://app.example.com", "GET", "authorization")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "https://app.example.com", resp.Header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", resp.Header.Get("Access-Control-Allow-Credentials"))
+
+		resp = preflight(t, baseURL, "https://evil.example.com", "GET", "")
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+
+		resp = preflight(t, baseURL, "https://app.example.com", "GET", "Authorization, X-User-Context")
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode, "unlisted request header must fail preflight")
+	})
+
+	t.Run("disabled sends no CORS headers", func(t *testing.T) {
+		h.SetCORS(mock.CORSConfig{Enabled: false})
+
+		resp := preflight(t, baseURL, "https://app.example.com", "GET", "")
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+
+		// Test control endpoints stay reachable from browsers
+		req, _ := http.NewRequest(http.MethodOptions, baseURL+"/api/v1/test/cors", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "DELETE")
+		ctrl, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		ctrl.Body.Close()
+		assert.Equal(t, "*", ctrl.Header.Get("Access-Control-Allow-Origin"))
+
+		// Server-side SDKs do not use CORS and must be unaffected
+		require.NoError(t, tc.InitAllSDKs(nil))
+		tc.AssertFlagValue("enabled-flag", true, false)
+		tc.CloseAllSDKs()
+	})
+
+	stats := h.GetCORSStats()
+	assert.Equal(t, 5, stats.Preflights)
+	assert.Equal(t, 3, stats.Rejected)
+}
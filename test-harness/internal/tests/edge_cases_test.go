@@ -14,64 +14,6 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-// TestUserIdSpecialChars tests user ID with special characters and emoji.
-func TestUserIdSpecialChars(t *testing.T) {
-	h := getHarness(t)
-	tc := Setup(t, h)
-	defer tc.Teardown()
-
-	h.SetScenario("basic")
-
-	specialIDs := []string{
-		"user-with-dash",
-		"user_with_underscore",
-		"user.with.dots",
-		"user@email.com",
-		"user+tag",
-		"user🎉emoji",
-		"user 🚀 space",
-	}
-
-	for _, id := range specialIDs {
-		t.Run(id, func(t *testing.T) {
-			user := &protocol.UserContext{ID: id}
-			require.NoError(t, tc.InitAllSDKs(user))
-			tc.AssertFlagValue("enabled-flag", true, false)
-			tc.CloseAllSDKs()
-		})
-	}
-}
-
-// TestUserIdUnicode tests user ID with various unicode characters.
-func TestUserIdUnicode(t *testing.T) {
-	h := getHarness(t)
-	tc := Setup(t, h)
-	defer tc.Teardown()
-
-	h.SetScenario("basic")
-
-	unicodeIDs := []string{
-		"user-ñ-spanish",
-		"user-ü-german",
-		"user-中文-chinese",
-		"user-日本語-japanese",
-		"user-한국어-korean",
-		"user-العربية-arabic",
-		"user-עברית-hebrew",
-		"user-Ελληνικά-greek",
-		"user-кириллица-cyrillic",
-	}
-
-	for _, id := range unicodeIDs {
-		t.Run(id, func(t *testing.T) {
-			user := &protocol.UserContext{ID: id}
-			require.NoError(t, tc.InitAllSDKs(user))
-			tc.AssertFlagValue("enabled-flag", true, false)
-			tc.CloseAllSDKs()
-		})
-	}
-}
-
 // TestUserIdVeryLong tests user ID with 1000+ characters.
 func TestUserIdVeryLong(t *testing.T) {
 	h := getHarness(t)
@@ -377,6 +319,59 @@ func TestManyFlags(t *testing.T) {
 	tc.CloseAllSDKs()
 }
 
+// stressMaxHeapGrowth bounds how much a service's live heap may grow while
+// holding the generated large-payload scenario.
+const stressMaxHeapGrowth = 128 << 20
+
+// TestLargePayloadStress tests init time, memory and correctness with a
+// generated scenario of thousands of flags, hundreds of rules per flag and
+// deeply nested JSON variations.
+func TestLargePayloadStress(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	cfg := harness.DefaultStressConfig()
+	scenario := h.LoadStressScenario(t.Name(), cfg)
+	before := h.SampleProcessStats(tc.Ctx)
+
+	start := time.Now()
+	require.NoError(t, tc.InitAllSDKs(&scenario.User))
+	defer tc.CloseAllSDKs()
+	initTime := time.Since(start)
+
+	t.Logf("Init with %d flags x %d rules took %v", cfg.Flags, cfg.RulesPerFlag, initTime)
+	assert.Less(t, initTime, 15*time.Second, "Init should complete within 15 seconds")
+
+	after := h.SampleProcessStats(tc.Ctx)
+	for name, b := range before {
+		a, ok := after[name]
+		if !ok || b.HeapBytes == 0 || a.HeapBytes == 0 {
+			continue
+		}
+		t.Logf("%s: heap %d -> %d bytes", name, b.HeapBytes, a.HeapBytes)
+		assert.LessOrEqual(t, a.HeapBytes, b.HeapBytes+stressMaxHeapGrowth,
+			"%s: heap should grow by at most %d bytes", name, stressMaxHeapGrowth)
+	}
+
+	tc.AssertAllFlags(scenario.Expected)
+
+	tc.RunForEachSDK("stress-json", func(t *testing.T, svc harness.SDKService) {
+		for key, want := range scenario.ExpectedJSON {
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewGetJSONCommand(key, nil))
+			require.NoError(t, err)
+			if resp.IsError() && resp.Error == "UnknownCommand" {
+				SkipUnsupported(t, "SDK does not support typed flags")
+			}
+			require.False(t, resp.IsError(), "getJson(%q) should succeed: %s - %s", key, resp.Error, resp.Message)
+			assert.Equal(t, want, resp.JSONValue, "getJson(%q) should return the nested variation", key)
+		}
+	})
+}
+
 // TestManyAttributes tests user with many attributes.
 func TestManyAttributes(t *testing.T) {
 	h := getHarness(t)
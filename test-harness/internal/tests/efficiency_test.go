@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// efficiencyWindow is how long SDKs sit idle while their requests are
+	// counted; EFFICIENCY_WINDOW overrides it for quicker local runs.
+	efficiencyWindow = 60 * time.Second
+	// efficiencyPollInterval is the refresh interval of idle SDKs.
+	efficiencyPollInterval = 5 * time.Second
+)
+
+// TestIdleRequestBudget holds each SDK to an HTTP budget while idle: with
+// polling on and no flag changes, evaluations or user changes, it polls at
+// its interval and no more, revalidates so the mock answers 304, never
+// identifies the same user twice, and sends nothing else.
+func TestIdleRequestBudget(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server request log")
+	}
+	if testing.Short() {
+		t.Skip("sits idle for the efficiency window")
+	}
+	window := efficiencyWindow
+	if v := os.Getenv("EFFICIENCY_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		require.NoError(t, err, "EFFICIENCY_WINDOW")
+		window = d
+	}
+	require.GreaterOrEqual(t, window, 3*efficiencyPollInterval, "the window must cover several polls")
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	// tc.Ctx would expire during a window of its own length
+	ctx, cancel := context.WithTimeout(context.Background(), window+30*time.Second)
+	defer cancel()
+
+	h.SetScenario("basic")
+
+	tc.RunForEachSDK("idle request budget", func(t *testing.T, svc harness.SDKService) {
+		start := h.RequestSeq()
+		config := h.InitSDKConfig()
+		config.RefreshInterval = int(efficiencyPollInterval / time.Millisecond)
+		resp, err := svc.SendCommand(ctx, protocol.NewInitCommand(config, &protocol.UserContext{ID: "idle-user"}))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "%s init failed: %s", svc.GetName(), resp.Error)
+		idle := h.RequestSeq()
+		time.Sleep(window)
+
+		// Polling interval adherence: one poll per interval, none doubled up
+		polls := int(window / efficiencyPollInterval)
+		minPolls, maxPolls := polls-1, polls+1
+		result := h.AssertRequests(mock.RequestAssertion{
+			Path:          "/api/v1/sdk/flags",
+			Method:        "GET",
+			SinceSeq:      idle,
+			MinCount:      &minPolls,
+			MaxCount:      &maxPolls,
+			MinIntervalMs: (efficiencyPollInterval / 2).Milliseconds(),
+			MaxIntervalMs: (efficiencyPollInterval * 2).Milliseconds(),
+			Revalidates:   true,
+		})
+		assert.True(t, result.Passed, "%s: %d polls in %v: %v", svc.GetName(), result.Matched, window, result.Failures)
+
+		// No duplicate identifies: one user, identified at most once
+		maxIdentifies := 1
+		result = h.AssertRequests(mock.RequestAssertion{
+			Path:     "/api/v1/sdk/identify",
+			SinceSeq: start,
+			MaxCount: &maxIdentifies,
+		})
+		assert.True(t, result.Passed, "%s identified idle-user %d times", svc.GetName(), result.Matched)
+
+		// 304 usage, and nothing but polls while idle
+		var full, other []string
+		for _, req := range h.GetRecordedRequests("") {
+			switch {
+			case req.Seq <= idle:
+			case req.Path != "/api/v1/sdk/flags":
+				other = append(other, req.Method+" "+req.Path)
+			case req.Status != http.StatusNotModified:
+				full = append(full, fmt.Sprintf("#%d %d", req.Seq, req.Status))
+			}
+		}
+		assert.Empty(t, full, "%s: polls of unchanged flags not answered 304", svc.GetName())
+		assert.Empty(t, other, "%s: requests other than polls while idle", svc.GetName())
+
+		_, err = svc.SendCommand(ctx, protocol.NewCloseCommand())
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,259 @@
+package tests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodingValues are user IDs, attribute values and flag keys that tend to
+// break somewhere between an SDK and the API.
+var encodingValues = []string{
+	"user-with-dash",
+	"user_with_underscore",
+	"user.with.dots",
+	"user@email.com",
+	"user+tag",
+	"user 🚀 space",
+	"user-ñ-spanish",
+	"user-ü-german",
+	"user-中文-chinese",
+	"user-日本語-japanese",
+	"user-한국어-korean",
+	"user-العربية-arabic",
+	"user-עברית-hebrew",
+	"user-Ελληνικά-greek",
+	"user-кириллица-cyrillic",
+	// Astral characters, sent as surrogate pairs by UTF-16 runtimes, and a
+	// zero-width-joiner sequence
+	"user-😀-𝄞-👩‍💻",
+	// Combining marks, which must not be normalized
+	"café-ñ",
+	// Characters with a meaning in paths, query strings and form encoding
+	"a/b?c=d&e#f%20+g;h",
+	// Characters JSON encoders escape
+	"quote\"back\\slash <tag>&amp",
+}
+
+// userIDCarriers returns the user ID of a recorded request from every place
+// an SDK may carry it: the user_id query parameter, the X-User-ID header
+// and the base64 JSON X-User-Context header.
+func userIDCarriers(req mock.RecordedRequest) map[string]string {
+	carriers := make(map[string]string)
+	if id := req.Query().Get("user_id"); id != "" {
+		carriers["query user_id"] = id
+	}
+	if id := req.Header.Get("X-User-ID"); id != "" {
+		carriers["header X-User-ID"] = id
+	}
+	if xuc := req.Header.Get("X-User-Context"); xuc != "" {
+		decoded, err := base64.StdEncoding.DecodeString(xuc)
+		if err != nil {
+			decoded, _ = base64.URLEncoding.DecodeString(xuc)
+		}
+		var user protocol.UserContext
+		if json.Unmarshal(decoded, &user) == nil && user.ID != "" {
+			carriers["header X-User-Context"] = user.ID
+		}
+	}
+	return carriers
+}
+
+// assertUserIDCarried checks that the flag and stream requests after seq
+// carry exactly userID, wherever they carry it.
+func assertUserIDCarried(t *testing.T, h *harness.Harness, seq int, userID string) {
+	t.Helper()
+	carried := 0
+	for _, req := range h.GetRecordedRequests("") {
+		if req.Seq <= seq {
+			continue
+		}
+		switch req.Path {
+		case "/api/v1/sdk/flags", "/api/v1/sdk/v2/flags", "/api/v1/sdk/stream":
+		default:
+			continue
+		}
+		for carrier, id := range userIDCarriers(req) {
+			carried++
+			assert.Equal(t, userID, id, "%s %s should carry the user ID byte for byte in its %s (raw query %q)",
+				req.Method, req.Path, carrier, req.RawQuery)
+		}
+	}
+	assert.Positive(t, carried, "flag requests should carry the user ID")
+}
+
+// lastRecordedSeq returns the sequence number of the newest recorded
+// request, or 0.
+func lastRecordedSeq(h *harness.Harness) int {
+	requests := h.GetRecordedRequests("")
+	if len(requests) == 0 {
+		return 0
+	}
+	return requests[len(requests)-1].Seq
+}
+
+// TestEncodingUserIDs tests that user IDs and attribute values reach the API
+// unchanged in query parameters, headers and identify bodies, and that
+// server-side targeting matches them.
+func TestEncodingUserIDs(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for the request recorder")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	for _, value := range encodingValues {
+		t.Run(value, func(t *testing.T) {
+			h.SetScenario("basic")
+			h.SetFlag(&mock.FlagState{Key: "encoded-target", Enabled: true, TargetUsers: []string{value}})
+			h.SetFlag(&mock.FlagState{
+				Key:     "encoded-attribute",
+				Enabled: true,
+				Rules: []mock.Rule{{
+					ID:                "name-match",
+					Enabled:           true,
+					Conditions:        []mock.Condition{{Attribute: "name", Operator: "eq", Value: value}},
+					RolloutPercentage: 100,
+				}},
+			})
+
+			seq := lastRecordedSeq(h)
+			require.NoError(t, tc.InitAllSDKs(&protocol.UserContext{ID: value}))
+			defer tc.CloseAllSDKs()
+
+			assertUserIDCarried(t, h, seq, value)
+			tc.AssertFlagValue("encoded-target", true, false)
+
+			identified := protocol.UserContext{ID: value + "-identified", Attributes: map[string]interface{}{"name": value}}
+			seq = lastRecordedSeq(h)
+			require.NoError(t, tc.IdentifyUser(identified))
+
+			for _, req := range h.GetRecordedRequests("/api/v1/sdk/identify") {
+				if req.Seq <= seq {
+					continue
+				}
+				assert.True(t, utf8.Valid(req.Body), "identify body should be valid UTF-8: %q", req.Body)
+				var body mock.IdentifyRequest
+				require.NoError(t, json.Unmarshal(req.Body, &body), "identify body should be JSON: %q", req.Body)
+				assert.Equal(t, identified.ID, body.User.ID, "identify body should carry the user ID byte for byte")
+				assert.Equal(t, value, body.User.Attributes["name"], "identify body should carry attribute values byte for byte")
+			}
+			assertUserIDCarried(t, h, seq, identified.ID)
+			tc.AssertFlagValue("encoded-attribute", true, false)
+		})
+	}
+}
+
+// TestEncodingFlagKeys tests that flag keys and event names survive the
+// flags response and the events body unchanged.
+func TestEncodingFlagKeys(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for the request recorder")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("empty")
+	for _, value := range encodingValues {
+		h.SetFlag(&mock.FlagState{Key: "flag-" + value, Enabled: true, RolloutPercentage: 100})
+	}
+
+	require.NoError(t, tc.InitAllSDKs(&protocol.UserContext{ID: "encoding-user"}))
+	defer tc.CloseAllSDKs()
+
+	tc.RunForEachSDK("encoding-flag-keys", func(t *testing.T, svc harness.SDKService) {
+		seq := lastRecordedSeq(h)
+		for _, value := range encodingValues {
+			key := "flag-" + value
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand(key, false))
+			require.NoError(t, err)
+			require.False(t, resp.IsError(), "isEnabled(%q) should succeed: %s - %s", key, resp.Error, resp.Message)
+			assert.True(t, resp.GetValue(false), "isEnabled(%q) should find the flag", key)
+
+			resp, err = svc.SendCommand(tc.Ctx, protocol.NewTrackCommand(key, value, value))
+			require.NoError(t, err)
+			if resp.IsError() && resp.Error == "UnknownCommand" {
+				SkipUnsupported(t, "SDK does not support track")
+			}
+		}
+		_, err := svc.SendCommand(tc.Ctx, protocol.NewFlushEventsCommand())
+		require.NoError(t, err)
+
+		sent := make(map[string]mock.TrackEventItem)
+		for _, req := range h.GetRecordedRequests("/api/v1/sdk/events") {
+			if req.Seq <= seq {
+				continue
+			}
+			assert.True(t, utf8.Valid(req.Body), "events body should be valid UTF-8: %q", req.Body)
+			var body mock.EventsRequest
+			require.NoError(t, json.Unmarshal(req.Body, &body), "events body should be JSON: %q", req.Body)
+			for _, event := range body.Events {
+				sent[event.FlagKey] = event
+			}
+		}
+		for _, value := range encodingValues {
+			event, ok := sent["flag-"+value]
+			if assert.True(t, ok, "an event for flag %q should be sent with its key byte for byte", "flag-"+value) {
+				assert.Equal(t, value, event.EventName, "event name should be sent byte for byte")
+				assert.Equal(t, value, event.UserID, "event user ID should be sent byte for byte")
+			}
+		}
+	})
+}
+
+// TestEncodingSSE tests that the stream request carries the user ID
+// unchanged and that flag-changed events for non-ASCII keys are applied.
+func TestEncodingSSE(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for the request recorder")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+	userID := "stream-😀-a/b?c=d&e"
+
+	tc.RunForEachSDK("encoding-sse", func(t *testing.T, svc harness.SDKService) {
+		seq := lastRecordedSeq(h)
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfigWithStreaming(), &protocol.UserContext{ID: userID}))
+		require.NoError(t, err)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		if resp.IsError() {
+			SkipUnsupported(t, "streaming not supported: %s", resp.Error)
+		}
+		if _, ok := waitForSSEConnection(h, 0, 2*time.Second); !ok {
+			t.Skip("no SSE connection after init")
+		}
+
+		assertUserIDCarried(t, h, seq, userID)
+
+		for _, value := range encodingValues {
+			key := "sse-" + value
+			h.SetFlag(&mock.FlagState{Key: key, Enabled: true, RolloutPercentage: 100})
+			h.BroadcastFlagChange(key, true)
+
+			applied := false
+			deadline := time.Now().Add(2 * time.Second)
+			for !applied && time.Now().Before(deadline) {
+				resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand(key, false))
+				require.NoError(t, err)
+				applied = resp.GetValue(false)
+				if !applied {
+					time.Sleep(20 * time.Millisecond)
+				}
+			}
+			assert.True(t, applied, "flag-changed event for %q should be applied under the same key", key)
+		}
+	})
+}
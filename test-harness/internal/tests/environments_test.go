@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// environmentKeys are the API keys bound to environments in these tests.
+var environmentKeys = map[string]string{
+	"staging":    "test-api-key-staging",
+	"production": "test-api-key-production",
+}
+
+// environmentFlags are the expected values of the environments scenario
+// per environment ("" is the harness's own key).
+var environmentFlags = map[string]map[string]bool{
+	"":           {"new-checkout": false, "debug-panel": true, "dark-launch": false},
+	"staging":    {"new-checkout": true, "debug-panel": true, "dark-launch": false},
+	"production": {"new-checkout": false, "debug-panel": false, "dark-launch": true},
+}
+
+// TestEnvironmentFlagsMock checks the mock serves each environment's flag
+// state to the API key bound to it.
+func TestEnvironmentFlagsMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for environment keys")
+	}
+	defer h.ClearEnvironmentKeys()
+
+	h.SetScenario("environments")
+	for env, key := range environmentKeys {
+		h.SetEnvironmentKey(key, env)
+	}
+
+	get := func(key string) (int, mock.FlagsResponse) {
+		req, err := http.NewRequest(http.MethodGet, h.GetMockURL()+"/api/v1/sdk/flags", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+key)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var body mock.FlagsResponse
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		}
+		return resp.StatusCode, body
+	}
+
+	keys := map[string]string{"": h.GetAPIKey()}
+	for env, key := range environmentKeys {
+		keys[env] = key
+	}
+	for env, key := range keys {
+		status, body := get(key)
+		require.Equal(t, http.StatusOK, status, "environment %q", env)
+		for flag, want := range environmentFlags[env] {
+			assert.Equal(t, want, body.Flags[flag], "environment %q flag %s", env, flag)
+		}
+		assert.True(t, body.Flags["enabled-flag"], "flags without overrides are shared by all environments")
+	}
+
+	// Typed values follow the environment's default variation
+	req, err := http.NewRequest(http.MethodGet, h.GetMockURL()+"/api/v1/sdk/v2/flags", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+environmentKeys["staging"])
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	var v2 mock.FlagsV2Response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&v2))
+	resp.Body.Close()
+	assert.Equal(t, "https://staging.api.example.com", v2.Flags["api-endpoint"].Value)
+
+	status, _ := get("test-api-key-unknown")
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	h.ClearEnvironmentKeys()
+	status, _ = get(environmentKeys["staging"])
+	assert.Equal(t, http.StatusUnauthorized, status, "cleared environment keys must be rejected")
+}
+
+// TestEnvironmentFlags initializes each SDK once per environment against the
+// same mock and checks it sees that environment's flags, including targeting
+// and typed values that differ per environment.
+func TestEnvironmentFlags(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for environment keys")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ClearEnvironmentKeys()
+
+	h.SetScenario("environments")
+	for env, key := range environmentKeys {
+		h.SetEnvironmentKey(key, env)
+	}
+
+	endpoints := map[string]string{
+		"":           "http://localhost:8080",
+		"staging":    "https://staging.api.example.com",
+		"production": "https://api.example.com",
+	}
+	internal := &protocol.UserContext{ID: "user-internal-1"}
+
+	tc.RunForEachSDK("environments", func(t *testing.T, svc harness.SDKService) {
+		for _, env := range []string{"", "staging", "production"} {
+			config := h.InitSDKConfig()
+			if env != "" {
+				config.APIKey = environmentKeys[env]
+			}
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+			require.NoError(t, err)
+			require.False(t, resp.IsError(), "%s: init in %q failed: %s", svc.GetName(), env, resp.Error)
+
+			for flag, want := range environmentFlags[env] {
+				got, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand(flag, !want))
+				require.NoError(t, err)
+				require.NotNil(t, got.Value)
+				assert.Equal(t, want, *got.Value, "%s: %s in environment %q", svc.GetName(), flag, env)
+			}
+
+			// Typed flags are optional; SDKs without them return the default
+			str, err := svc.SendCommand(tc.Ctx, protocol.NewGetStringCommand("api-endpoint", "unset"))
+			require.NoError(t, err)
+			if str.IsError() || str.StringValue == nil || *str.StringValue == "unset" {
+				t.Logf("%s: typed values not evaluated (error=%q)", svc.GetName(), str.Error)
+			} else {
+				assert.Equal(t, endpoints[env], *str.StringValue, "%s: api-endpoint in environment %q", svc.GetName(), env)
+			}
+
+			// Targeting differs too: staging targets internal users only
+			if env == "staging" {
+				_, err := svc.SendCommand(tc.Ctx, protocol.NewIdentifyCommand(*internal))
+				require.NoError(t, err)
+				got, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("dark-launch", false))
+				require.NoError(t, err)
+				require.NotNil(t, got.Value)
+				assert.True(t, *got.Value, "%s: dark-launch for internal user in staging", svc.GetName())
+			}
+
+			svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		}
+	})
+}
@@ -248,3 +248,56 @@ func TestPollingWithETag(t *testing.T) {
 		svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
 	}
 }
+
+// TestLastModifiedFallback tests polling when the server (e.g. behind a CDN
+// that strips ETags) only provides Last-Modified. A flag change must never be
+// hidden by a 304 answered from If-Modified-Since.
+func TestLastModifiedFallback(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to control validators")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetConditional()
+
+	config := protocol.Config{
+		APIKey:          h.GetAPIKey(),
+		BaseURL:         h.GetMockURL(),
+		RefreshInterval: 500,
+		EnableStreaming: false,
+		Timeout:         5000,
+	}
+
+	for _, svc := range h.GetServices() {
+		h.SetScenario("basic")
+		h.ResetConditional()
+		h.SetValidators(false, true)
+
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		if resp.IsError() {
+			t.Logf("%s: polling not supported: %s", svc.GetName(), resp.Error)
+			continue
+		}
+
+		// Let the SDK poll with an unchanged payload
+		time.Sleep(1500 * time.Millisecond)
+		flagResp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+		require.NoError(t, err)
+		assert.True(t, *flagResp.Value, "%s: enabled-flag should be true", svc.GetName())
+
+		// Change the flag; the next poll must see it
+		h.SetFlag(&mock.FlagState{Key: "enabled-flag", Enabled: false})
+		time.Sleep(1500 * time.Millisecond)
+		flagResp, err = svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", true))
+		require.NoError(t, err)
+		assert.False(t, *flagResp.Value, "%s: flag change should not be masked by If-Modified-Since", svc.GetName())
+
+		stats := h.GetConditionalStats()
+		assert.Zero(t, stats.IfNoneMatch, "%s: no ETag was served, If-None-Match should not be sent", svc.GetName())
+		t.Logf("%s: If-Modified-Since=%d, 304s=%d", svc.GetName(), stats.IfModifiedSince, stats.NotModified)
+
+		svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+	}
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// orderedEventCount is how many events each SDK tracks in one batch
+	orderedEventCount = 10
+	// clockTolerance is how far an event timestamp may be from the clock it
+	// follows; the Date header has one-second resolution
+	clockTolerance = 2 * time.Second
+	// clockSkew is the mock clock offset used for the skew test
+	clockSkew = time.Hour
+)
+
+// trackOrdered tracks orderedEventCount numbered events, flushes them and
+// returns the mock's timestamp checks for them.
+func trackOrdered(t *testing.T, tc *TestContext, svc harness.SDKService) []mock.EventTiming {
+	t.Helper()
+	h := tc.Harness
+
+	for i := 0; i < orderedEventCount; i++ {
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewTrackCommandFull(
+			"order-flag", fmt.Sprintf("event-%02d", i), "user-order", "", nil,
+			map[string]interface{}{"seq": i}))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "%s: track failed: %s", svc.GetName(), resp.Error)
+	}
+	_, err := svc.SendCommand(tc.Ctx, protocol.NewFlushEventsCommand())
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(h.GetEventTimings()) >= orderedEventCount
+	}, 2*time.Second, 20*time.Millisecond, "%s: events not received", svc.GetName())
+	return h.GetEventTimings()
+}
+
+// TestEventOrdering checks that events tracked in sequence arrive in that
+// order, with non-decreasing timestamps close to the server's clock.
+func TestEventOrdering(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+
+	require.NoError(t, tc.InitAllSDKs(nil))
+	defer tc.CloseAllSDKs()
+
+	tc.RunForEachSDK("event ordering", func(t *testing.T, svc harness.SDKService) {
+		h.ClearReceivedEvents()
+		timings := trackOrdered(t, tc, svc)
+
+		require.Len(t, timings, orderedEventCount)
+		for i, timing := range timings {
+			assert.Equal(t, fmt.Sprintf("event-%02d", i), timing.EventName,
+				"%s: event %d out of order", svc.GetName(), i)
+			require.False(t, timing.Timestamp.IsZero(), "%s: event %d has no timestamp", svc.GetName(), i)
+			assert.True(t, timing.Monotonic, "%s: timestamp of event %d goes backwards", svc.GetName(), i)
+			assert.LessOrEqual(t, abs(time.Duration(timing.DeltaMs)*time.Millisecond), clockTolerance,
+				"%s: event %d timestamp is %dms from the server clock", svc.GetName(), i, timing.DeltaMs)
+		}
+		// Batching may split events across requests, but never reorders them
+		for i := 1; i < len(timings); i++ {
+			assert.GreaterOrEqual(t, timings[i].Batch, timings[i-1].Batch)
+		}
+	})
+}
+
+// TestEventClockSkew skews the mock's Date header and checks each SDK's
+// event timestamps follow one clock consistently: the server's (skew
+// corrected) or the local one. Which one is reported in the log.
+func TestEventClockSkew(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to skew the clock")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.SetClockOffset(0)
+
+	h.SetScenario("basic")
+	h.SetClockOffset(clockSkew)
+
+	tc.RunForEachSDK("event clock skew", func(t *testing.T, svc harness.SDKService) {
+		h.ClearReceivedEvents()
+
+		// Init fetches flags, which is where SDKs learn the server's clock
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfig(), nil))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "%s init failed: %s", svc.GetName(), resp.Error)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		timings := trackOrdered(t, tc, svc)
+
+		corrected := 0
+		for _, timing := range timings {
+			delta := time.Duration(timing.DeltaMs) * time.Millisecond
+			switch {
+			case abs(delta) <= clockTolerance:
+				corrected++
+			case abs(delta+clockSkew) <= clockTolerance:
+				// Local clock, uncorrected
+			default:
+				t.Errorf("%s: event %s timestamp is %v from the server clock, matching neither clock",
+					svc.GetName(), timing.EventName, delta)
+			}
+			assert.True(t, timing.Monotonic, "%s: timestamp of %s goes backwards", svc.GetName(), timing.EventName)
+		}
+		assert.True(t, corrected == 0 || corrected == len(timings),
+			"%s: %d of %d events corrected for skew; timestamps must use one clock", svc.GetName(), corrected, len(timings))
+		t.Logf("%s: corrects for server clock skew = %v", svc.GetName(), corrected == len(timings))
+	})
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -1,10 +1,12 @@
 package tests
 
 import (
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
 	"github.com/rollgate/test-harness/internal/protocol"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -85,6 +87,59 @@ func TestTrackEventWithVariation(t *testing.T) {
 	})
 }
 
+// TestTrackEvaluationContext tests that an event tracked without a
+// variation is attributed to the flag's last evaluation for the user, and
+// that the opt-out sends it as given. SDKs that do not attach evaluations
+// send no variation at all.
+func TestTrackEvaluationContext(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+
+	require.NoError(t, tc.InitAllSDKs(&protocol.UserContext{ID: "user-ctx"}))
+	defer tc.CloseAllSDKs()
+
+	tc.RunForEachSDK("track-evaluation-context", func(t *testing.T, svc harness.SDKService) {
+		h.ClearReceivedEvents()
+
+		_, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+		require.NoError(t, err)
+
+		optOut := protocol.NewTrackCommand("enabled-flag", "signup", "user-ctx")
+		optOut.NoEvaluationContext = true
+		for _, cmd := range []protocol.Command{protocol.NewTrackCommand("enabled-flag", "purchase", "user-ctx"), optOut} {
+			resp, err := svc.SendCommand(tc.Ctx, cmd)
+			require.NoError(t, err)
+			require.False(t, resp.IsError(), "track should succeed: %s - %s", resp.Error, resp.Message)
+		}
+
+		_, err = svc.SendCommand(tc.Ctx, protocol.NewFlushEventsCommand())
+		require.NoError(t, err)
+		time.Sleep(500 * time.Millisecond)
+
+		events := map[string]mock.TrackEventItem{}
+		for _, event := range h.GetReceivedEvents() {
+			events[event.EventName] = event
+		}
+		require.Contains(t, events, "purchase")
+		require.Contains(t, events, "signup")
+
+		if purchase := events["purchase"]; purchase.VariationID != "" {
+			assert.Equal(t, "true", purchase.VariationID, "%s: variation of the last evaluation", svc.GetName())
+			if assert.NotNil(t, purchase.Reason, "%s: reason of the last evaluation", svc.GetName()) {
+				assert.Equal(t, "FALLTHROUGH", purchase.Reason.Kind)
+			}
+		}
+		assert.Empty(t, events["signup"].VariationID, "%s: opted-out event should have no variation", svc.GetName())
+		assert.Nil(t, events["signup"].Reason, "%s: opted-out event should have no reason", svc.GetName())
+	})
+}
+
 // TestTrackEventWithValue tests tracking with a numeric value.
 func TestTrackEventWithValue(t *testing.T) {
 	h := getHarness(t)
@@ -210,3 +265,89 @@ func TestTrackMultipleEvents(t *testing.T) {
 		assert.Contains(t, eventNames, "purchase")
 	})
 }
+
+// TestEventFlushRetry tests that a flush hitting transient 503s is retried
+// until the events are delivered. SDKs whose flush fails instead do not
+// retry and are skipped.
+func TestEventFlushRetry(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+
+	require.NoError(t, tc.InitAllSDKs(nil))
+	defer tc.CloseAllSDKs()
+
+	tc.RunForEachSDK("flush-retry", func(t *testing.T, svc harness.SDKService) {
+		h.ClearReceivedEvents()
+		defer h.ClearError()
+
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewTrackCommand("retry-flag", "purchase", "user-1"))
+		require.NoError(t, err)
+		require.False(t, resp.IsError())
+
+		h.SetError(http.StatusServiceUnavailable, 2, 0, "Service unavailable")
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewFlushEventsCommand())
+		require.NoError(t, err)
+		if resp.IsError() {
+			SkipUnsupported(t, "%s: event flushes are not retried: %s", svc.GetName(), resp.Message)
+		}
+
+		events := h.GetReceivedEvents()
+		require.Len(t, events, 1, "the event should be delivered once")
+		assert.Equal(t, "retry-flag", events[0].FlagKey)
+	})
+}
+
+// TestEventStats tests that getState reports the event buffer and the last
+// flush. The eventStats field is optional; SDKs that omit it are skipped.
+func TestEventStats(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+
+	require.NoError(t, tc.InitAllSDKs(nil))
+	defer tc.CloseAllSDKs()
+
+	tc.RunForEachSDK("event-stats", func(t *testing.T, svc harness.SDKService) {
+		stateCmd := protocol.NewGetStateCommand()
+
+		for _, name := range []string{"view", "purchase"} {
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewTrackCommand("stats-flag", name, "user-1"))
+			require.NoError(t, err)
+			assert.False(t, resp.IsError())
+		}
+
+		resp, err := svc.SendCommand(tc.Ctx, stateCmd)
+		require.NoError(t, err)
+		if resp.EventStats == nil {
+			SkipUnsupported(t, "SDK does not report eventStats")
+		}
+		assert.Equal(t, 2, resp.EventStats.Buffered, "tracked events should be buffered until flushed")
+
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewFlushEventsCommand())
+		require.NoError(t, err)
+		assert.False(t, resp.IsError(), "flushEvents should succeed: %s - %s", resp.Error, resp.Message)
+
+		resp, err = svc.SendCommand(tc.Ctx, stateCmd)
+		require.NoError(t, err)
+		require.NotNil(t, resp.EventStats)
+		assert.Equal(t, 0, resp.EventStats.Buffered)
+		assert.Equal(t, 200, resp.EventStats.LastFlushStatus)
+		assert.Empty(t, resp.EventStats.LastFlushError)
+		assert.NotEmpty(t, resp.EventStats.LastFlushTime)
+		assert.Zero(t, resp.EventStats.Dropped)
+		if resp.EventStats.Delivered != nil {
+			assert.EqualValues(t, 2, *resp.EventStats.Delivered, "flushed events should be counted as delivered")
+		}
+	})
+}
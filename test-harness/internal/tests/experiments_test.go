@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// experimentSplit is the 50/30/20 split the experiment tests run against.
+var experimentSplit = []mock.WeightedVariation{
+	{ID: "control", Weight: 50},
+	{ID: "treatment-a", Weight: 30},
+	{ID: "treatment-b", Weight: 20},
+}
+
+// TestWeightedVariationsMock checks that the mock splits users between
+// weighted variations in proportion to their weights, and reports the
+// variation in the reason.
+func TestWeightedVariationsMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+
+	h.GetMockServer().GetFlagStore().Clear()
+	h.SetFlag(&mock.FlagState{Key: "wv-experiment", Enabled: true, WeightedVariations: experimentSplit})
+
+	type evaluation struct {
+		value  bool
+		reason map[string]any
+	}
+	evaluate := func(userID string) evaluation {
+		req, err := http.NewRequest(http.MethodGet, h.GetMockURL()+"/api/v1/sdk/flags?withReasons=true&user_id="+userID, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+h.GetAPIKey())
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Flags   map[string]bool           `json:"flags"`
+			Reasons map[string]map[string]any `json:"reasons"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return evaluation{body.Flags["wv-experiment"], body.Reasons["wv-experiment"]}
+	}
+
+	t.Run("should split users by weight", func(t *testing.T) {
+		counts := map[string]int{}
+		users := 1000
+		for i := 0; i < users; i++ {
+			e := evaluate(fmt.Sprintf("wv-user-%d", i))
+			require.True(t, e.value)
+			require.Equal(t, "FALLTHROUGH", e.reason["kind"])
+			variation, _ := e.reason["variationId"].(string)
+			counts[variation]++
+		}
+		for _, v := range experimentSplit {
+			share := counts[v.ID] * 100 / users
+			assert.InDelta(t, v.Weight, share, 6, "share of %s", v.ID)
+		}
+	})
+
+	t.Run("should assign a user the same variation every time", func(t *testing.T) {
+		first := evaluate("wv-sticky")
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, first.reason["variationId"], evaluate("wv-sticky").reason["variationId"])
+		}
+	})
+
+	t.Run("should select no variation without a user", func(t *testing.T) {
+		e := evaluate("")
+		assert.False(t, e.value)
+		assert.NotContains(t, e.reason, "variationId")
+	})
+}
+
+// TestExperimentAssignment checks the A/B flow end to end: the SDK reports
+// the variation the mock assigned, and events tracked afterwards carry it.
+func TestExperimentAssignment(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetFlag(&mock.FlagState{Key: "checkout-experiment", Enabled: true, WeightedVariations: experimentSplit})
+
+	require.NoError(t, tc.InitAllSDKs(&protocol.UserContext{ID: "experiment-user"}))
+	defer tc.CloseAllSDKs()
+
+	tc.RunForEachSDK("experiment-assignment", func(t *testing.T, svc harness.SDKService) {
+		h.ClearReceivedEvents()
+
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledDetailCommand("checkout-experiment", false))
+		require.NoError(t, err)
+		if resp.Error == "UnknownCommand" {
+			SkipUnsupported(t, "%s does not support isEnabledDetail", svc.GetName())
+		}
+		require.False(t, resp.IsError(), "isEnabledDetail should succeed: %s - %s", resp.Error, resp.Message)
+		if resp.VariationID == "" {
+			SkipUnsupported(t, "%s does not report variations of weighted flags", svc.GetName())
+		}
+		variation := resp.VariationID
+		assert.Contains(t, []string{"control", "treatment-a", "treatment-b"}, variation)
+		require.NotNil(t, resp.Value)
+		assert.True(t, *resp.Value, "%s: a user in the split should get true", svc.GetName())
+
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewTrackCommand("checkout-experiment", "purchase", "experiment-user"))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "track should succeed: %s - %s", resp.Error, resp.Message)
+		_, err = svc.SendCommand(tc.Ctx, protocol.NewFlushEventsCommand())
+		require.NoError(t, err)
+		time.Sleep(500 * time.Millisecond)
+
+		events := h.GetReceivedEvents()
+		require.Len(t, events, 1)
+		assert.Equal(t, variation, events[0].VariationID, "%s: event should carry the assigned variation", svc.GetName())
+	})
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failoverPollInterval is the polling interval of SDKs in failover tests,
+// in ms; failover is observed within a few polls.
+const failoverPollInterval = 300
+
+// startRegions starts a primary and a fallback mock region. region-flag is
+// off in the primary and on in the fallback, so evaluations show which
+// region served the flags.
+func startRegions(t *testing.T, h *harness.Harness) (primary, fallback *harness.MockRegion) {
+	t.Helper()
+	primary, err := h.StartRegion("primary")
+	require.NoError(t, err)
+	fallback, err = h.StartRegion("fallback")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		primary.Stop(ctx)
+		fallback.Stop(ctx)
+	})
+
+	primary.Server.SetFlag(&mock.FlagState{Key: "region-flag", Enabled: false, RolloutPercentage: 100})
+	fallback.Server.SetFlag(&mock.FlagState{Key: "region-flag", Enabled: true, RolloutPercentage: 100})
+	return primary, fallback
+}
+
+// waitForRegionRequests waits for region to receive a flags request and
+// then for one more poll to be applied, reporting whether it got any.
+func waitForRegionRequests(region *harness.MockRegion, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if len(region.Server.GetRecordedRequests("/api/v1/sdk/flags")) > 0 {
+			time.Sleep(failoverPollInterval * time.Millisecond)
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return false
+}
+
+// TestRegionFailover tests that an SDK configured with a fallback base URL
+// keeps receiving flag updates from the fallback region once the primary
+// goes down, and keeps its flags if every region is down. SDKs that never
+// contact the fallback do not support fallback URLs and are skipped.
+func TestRegionFailover(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock servers")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	tc.RunForEachSDK("failover", func(t *testing.T, svc harness.SDKService) {
+		primary, fallback := startRegions(t, h)
+		config := h.InitSDKConfigForRegions(primary, fallback)
+		config.RefreshInterval = failoverPollInterval
+
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "%s: init failed: %s", svc.GetName(), resp.Message)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("region-flag", true))
+		require.NoError(t, err)
+		assert.False(t, resp.GetValue(true), "%s: flags should come from the primary", svc.GetName())
+		assert.Empty(t, fallback.Server.GetRecordedRequests("/api/v1/sdk/flags"),
+			"%s: the fallback should not be used while the primary is up", svc.GetName())
+
+		primary.Kill()
+		if !waitForRegionRequests(fallback, 10*failoverPollInterval*time.Millisecond) {
+			SkipUnsupported(t, "%s: fallback base URLs not supported", svc.GetName())
+		}
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("region-flag", false))
+		require.NoError(t, err)
+		assert.True(t, resp.GetValue(false), "%s: flags should come from the fallback", svc.GetName())
+
+		fallback.Kill()
+		time.Sleep(3 * failoverPollInterval * time.Millisecond)
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("region-flag", false))
+		require.NoError(t, err)
+		assert.True(t, resp.GetValue(false), "%s: cached flags should survive every region being down", svc.GetName())
+	})
+}
+
+// TestRegionFailoverInit tests that an SDK whose primary region is down at
+// startup initializes from the fallback.
+func TestRegionFailoverInit(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock servers")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	tc.RunForEachSDK("failover-init", func(t *testing.T, svc harness.SDKService) {
+		primary, fallback := startRegions(t, h)
+		primary.Kill()
+		config := h.InitSDKConfigForRegions(primary, fallback)
+
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		if len(fallback.Server.GetRecordedRequests("/api/v1/sdk/flags")) == 0 {
+			SkipUnsupported(t, "%s: fallback base URLs not supported", svc.GetName())
+		}
+		require.False(t, resp.IsError(), "%s: init should succeed from the fallback: %s", svc.GetName(), resp.Message)
+
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("region-flag", false))
+		require.NoError(t, err)
+		assert.True(t, resp.GetValue(false), "%s: flags should come from the fallback", svc.GetName())
+	})
+}
@@ -137,6 +137,56 @@ func TestConsistentHashing(t *testing.T) {
 	}
 }
 
+// TestRolloutDistribution evaluates a 50% rollout for a seeded corpus of
+// random users and checks the split is plausible and identical across SDKs.
+// A failure prints the seed; rerun with -seed to get the same corpus.
+func TestRolloutDistribution(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("rollout")
+
+	const users = 200
+	ids := h.RandomUserIDs("rollout-distribution", users)
+
+	require.NoError(t, tc.InitAllSDKs(nil))
+	defer tc.CloseAllSDKs()
+
+	results := make(map[string][]bool)
+	for _, id := range ids {
+		require.NoError(t, tc.IdentifyUser(protocol.UserContext{ID: id}))
+		for _, svc := range h.GetServices() {
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("rollout-50", false))
+			require.NoError(t, err)
+			results[svc.GetName()] = append(results[svc.GetName()], resp.GetValue(false))
+		}
+	}
+
+	var reference []bool
+	for name, values := range results {
+		enabled := 0
+		for _, v := range values {
+			if v {
+				enabled++
+			}
+		}
+		// 4 standard deviations of Binomial(200, 0.5) is about 28
+		assert.InDelta(t, users/2, enabled, 28, "%s: %d/%d users in a 50%% rollout", name, enabled, users)
+
+		if reference == nil {
+			reference = values
+			continue
+		}
+		for i := range values {
+			assert.Equal(t, reference[i], values[i], "%s disagrees for user %s", name, ids[i])
+		}
+	}
+}
+
 // TestFlagTypes tests different flag scenarios.
 func TestFlagTypes(t *testing.T) {
 	h := getHarness(t)
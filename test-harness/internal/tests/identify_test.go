@@ -1,10 +1,14 @@
 package tests
 
 import (
+	"encoding/json"
+	"net/http"
 	"testing"
 
+	"github.com/rollgate/test-harness/internal/harness"
 	"github.com/rollgate/test-harness/internal/mock"
 	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -71,6 +75,58 @@ func TestIdentify(t *testing.T) {
 	})
 }
 
+// TestIdentifyBatch tests that a bulk identify registers every user
+// server-side, as their own identify requests would, without changing the
+// SDK's user or flags.
+func TestIdentifyBatch(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("targeting")
+	require.NoError(t, tc.InitAllSDKs(nil))
+	defer tc.CloseAllSDKs()
+
+	users := []protocol.UserContext{
+		{ID: "batch-pro", Attributes: map[string]interface{}{"plan": "pro"}},
+		{ID: "batch-free", Attributes: map[string]interface{}{"plan": "free"}},
+	}
+
+	tc.RunForEachSDK("identifyBatch", func(t *testing.T, svc harness.SDKService) {
+		h.ClearReceivedIdentifies()
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewIdentifyBatchCommand(users, true))
+		require.NoError(t, err)
+		if resp.Error == "UnknownCommand" {
+			SkipUnsupported(t, "%s does not support identifyBatch", svc.GetName())
+		}
+		require.False(t, resp.IsError(), "identifyBatch failed: %s", resp.Message)
+
+		var ids []string
+		for _, user := range h.GetReceivedIdentifies() {
+			ids = append(ids, user.ID)
+		}
+		assert.ElementsMatch(t, []string{"batch-pro", "batch-free"}, ids, "every user should be identified")
+
+		// The server evaluates the registered attributes by user_id
+		req, err := http.NewRequest(http.MethodGet, h.GetMockURL()+"/api/v1/sdk/flags?user_id=batch-pro", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+h.GetAPIKey())
+		flagsResp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer flagsResp.Body.Close()
+		var body mock.FlagsResponse
+		require.NoError(t, json.NewDecoder(flagsResp.Body).Decode(&body))
+		assert.True(t, body.Flags["pro-only"], "batch-pro should be registered with its plan")
+
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("pro-only", false))
+		require.NoError(t, err)
+		assert.False(t, resp.GetValue(true), "the SDK's own user should be unchanged")
+	})
+}
+
 // TestReset tests user context reset.
 func TestReset(t *testing.T) {
 	h := getHarness(t)
@@ -2,8 +2,10 @@ package tests
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -108,6 +110,78 @@ func TestInitTimeout(t *testing.T) {
 	tc.CloseAllSDKs()
 }
 
+// TestInitStartWait checks startWaitTimeMs: init waits that long at most
+// for flags the mock delays, and succeeds when they arrive in time.
+func TestInitStartWait(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for latency injection")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetLatency()
+
+	h.SetScenario("basic")
+
+	tc.RunForEachSDK("start wait", func(t *testing.T, svc harness.SDKService) {
+		config := h.InitSDKConfig()
+		config.StartWaitTimeMs = 200
+
+		h.SetLatency("/api/v1/sdk/flags", time.Second)
+		start := time.Now()
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		waited := time.Since(start)
+		svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		assert.True(t, resp.IsError(), "%s: init should fail once the start wait expires", svc.GetName())
+		assert.Less(t, waited, 800*time.Millisecond, "%s: init waited for the delayed flags", svc.GetName())
+
+		h.SetLatency("/api/v1/sdk/flags", 50*time.Millisecond)
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		require.False(t, resp.IsError(), "%s: init within the start wait failed: %s", svc.GetName(), resp.Message)
+
+		flag, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+		require.NoError(t, err)
+		require.NotNil(t, flag.Value)
+		assert.True(t, *flag.Value, "%s: flags fetched within the start wait", svc.GetName())
+	})
+}
+
+// TestInitBootstrap tests that bootstrap values give way to the fetched
+// flags once init completes, including flags only the bootstrap had.
+func TestInitBootstrap(t *testing.T) {
+	h := getHarness(t)
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+
+	tc.RunForEachSDK("bootstrap", func(t *testing.T, svc harness.SDKService) {
+		config := h.InitSDKConfig()
+		config.Bootstrap = map[string]bool{"enabled-flag": false, "bootstrap-only": true}
+
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "%s: init failed: %s", svc.GetName(), resp.Message)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		flag, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledDetailCommand("enabled-flag", false))
+		require.NoError(t, err)
+		require.NotNil(t, flag.Value)
+		assert.True(t, *flag.Value, "%s: fetched value should replace the bootstrap", svc.GetName())
+
+		flag, err = svc.SendCommand(tc.Ctx, protocol.NewIsEnabledDetailCommand("bootstrap-only", false))
+		require.NoError(t, err)
+		require.NotNil(t, flag.Value)
+		assert.False(t, *flag.Value, "%s: bootstrap-only flag after init", svc.GetName())
+		if flag.Reason != nil {
+			assert.Equal(t, "UNKNOWN", flag.Reason.Kind, "%s: bootstrap-only flag after init", svc.GetName())
+		}
+	})
+}
+
 // TestDoubleInit tests calling init twice.
 func TestDoubleInit(t *testing.T) {
 	h := getHarness(t)
@@ -169,8 +243,43 @@ func getHarness(t *testing.T) *harness.Harness {
 // Environment variables:
 //   - EXTERNAL_SERVER_URL: Use real Rollgate server instead of mock (e.g., "http://localhost:3000")
 //   - EXTERNAL_API_KEY: API key for external server (required if using EXTERNAL_SERVER_URL)
+//   - MOCK_NETWORK: Mock listener network: localhost, ipv4, ipv6 or dual (default: localhost)
+//   - MOCK_SOCKET: Also serve the mock on this unix domain socket
+//   - TEST_SEED: Seed for generated test data when -seed is not given
+//   - LEAK_CHECK: Set to "off" to disable per-test leak detection
+//   - LEAK_MAX_GOROUTINES, LEAK_MAX_HEAP_MB, LEAK_MAX_RSS_MB: Override leak thresholds
+//   - COMMAND_BUDGETS: Per-command slow thresholds as command=ms pairs (e.g. "init=5000,*=500")
+//   - TIMING_REPORT: Set to "off" to skip the command timing report
 func SetupHarness(services map[string]string) (*harness.Harness, error) {
 	cfg := harness.DefaultConfig()
+	cfg.MockNetwork = os.Getenv("MOCK_NETWORK")
+	cfg.MockSocket = os.Getenv("MOCK_SOCKET")
+	cfg.Seed = *seedFlag
+	if cfg.Seed == 0 {
+		cfg.Seed, _ = strconv.ParseInt(os.Getenv("TEST_SEED"), 10, 64)
+	}
+
+	if os.Getenv("LEAK_CHECK") != "off" {
+		th := harness.DefaultLeakThresholds()
+		if v, err := strconv.Atoi(os.Getenv("LEAK_MAX_GOROUTINES")); err == nil {
+			th.MaxGoroutineGrowth = v
+		}
+		if v, err := strconv.ParseUint(os.Getenv("LEAK_MAX_HEAP_MB"), 10, 64); err == nil {
+			th.MaxHeapGrowth = v << 20
+		}
+		if v, err := strconv.ParseUint(os.Getenv("LEAK_MAX_RSS_MB"), 10, 64); err == nil {
+			th.MaxRSSGrowth = v << 20
+		}
+		leakThresholds = &th
+	}
+
+	if os.Getenv("TIMING_REPORT") != "off" {
+		budgets, err := harness.ParseCommandBudgets(os.Getenv("COMMAND_BUDGETS"))
+		if err != nil {
+			return nil, fmt.Errorf("COMMAND_BUDGETS: %w", err)
+		}
+		commandBudgets = budgets
+	}
 
 	// Check for external server
 	if externalURL := os.Getenv("EXTERNAL_SERVER_URL"); externalURL != "" {
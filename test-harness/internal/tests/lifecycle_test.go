@@ -0,0 +1,222 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lifecycleUser returns a targeting-scenario user on the given plan.
+func lifecycleUser(id, plan string) protocol.UserContext {
+	return protocol.UserContext{
+		ID:         id,
+		Email:      id + "@example.com",
+		Attributes: map[string]interface{}{"plan": plan},
+	}
+}
+
+// lifecycleStep is one command in an identify/reset sequence and the state
+// expected after it.
+type lifecycleStep struct {
+	name    string
+	cmd     func() protocol.Command
+	proOnly bool                  // Expected pro-only value
+	vip     bool                  // Expected vip-feature value
+	sent    *protocol.UserContext // User the mock must have received by now, if any
+}
+
+// assertIdentified checks the latest identify payload for user.ID carries the
+// user's email and attributes.
+func assertIdentified(t *testing.T, received []mock.IdentifyUser, user protocol.UserContext, sdk string) {
+	t.Helper()
+	for i := len(received) - 1; i >= 0; i-- {
+		if received[i].ID != user.ID {
+			continue
+		}
+		assert.Equal(t, user.Email, received[i].Email, "%s: identify email for %s", sdk, user.ID)
+		assert.Equal(t, user.Attributes["plan"], received[i].Attributes["plan"], "%s: identify attributes for %s", sdk, user.ID)
+		return
+	}
+	t.Errorf("%s: mock never received identify for %s", sdk, user.ID)
+}
+
+// TestIdentifyResetLifecycle runs identify/reset sequences on every SDK,
+// checking flag values and the identify payloads the mock receives after
+// each step.
+func TestIdentifyResetLifecycle(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("targeting")
+
+	pro := lifecycleUser("user-life-pro", "pro")
+	free := lifecycleUser("user-life-free", "free")
+	vip := lifecycleUser("user-vip-2", "free")
+
+	sequences := map[string][]lifecycleStep{
+		"init-identify-reset-identify": {
+			{name: "init anonymous", cmd: func() protocol.Command { return protocol.NewInitCommand(h.InitSDKConfig(), nil) }},
+			{name: "identify pro", cmd: func() protocol.Command { return protocol.NewIdentifyCommand(pro) }, proOnly: true, sent: &pro},
+			{name: "reset", cmd: protocol.NewResetCommand},
+			{name: "identify free", cmd: func() protocol.Command { return protocol.NewIdentifyCommand(free) }, sent: &free},
+			{name: "identify vip", cmd: func() protocol.Command { return protocol.NewIdentifyCommand(vip) }, vip: true, sent: &vip},
+		},
+		"init-with-user-reset-reidentify": {
+			{name: "init pro", cmd: func() protocol.Command { return protocol.NewInitCommand(h.InitSDKConfig(), &pro) }, proOnly: true, sent: &pro},
+			{name: "reset", cmd: protocol.NewResetCommand},
+			{name: "reset again", cmd: protocol.NewResetCommand},
+			{name: "identify pro again", cmd: func() protocol.Command { return protocol.NewIdentifyCommand(pro) }, proOnly: true, sent: &pro},
+		},
+		"identify-same-user-twice": {
+			{name: "init free", cmd: func() protocol.Command { return protocol.NewInitCommand(h.InitSDKConfig(), &free) }, sent: &free},
+			{name: "identify free again", cmd: func() protocol.Command { return protocol.NewIdentifyCommand(free) }, sent: &free},
+			{name: "identify pro", cmd: func() protocol.Command { return protocol.NewIdentifyCommand(pro) }, proOnly: true, sent: &pro},
+		},
+	}
+
+	for seqName, steps := range sequences {
+		tc.RunForEachSDK(seqName, func(t *testing.T, svc harness.SDKService) {
+			h.ClearUserSessions()
+			h.ClearReceivedIdentifies()
+			defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+			for _, step := range steps {
+				resp, err := svc.SendCommand(tc.Ctx, step.cmd())
+				require.NoError(t, err, step.name)
+				require.False(t, resp.IsError(), "%s: %s failed: %s", svc.GetName(), step.name, resp.Error)
+
+				for flag, want := range map[string]bool{"pro-only": step.proOnly, "vip-feature": step.vip} {
+					got, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand(flag, !want))
+					require.NoError(t, err)
+					require.NotNil(t, got.Value, "%s: %s after %s", svc.GetName(), flag, step.name)
+					assert.Equal(t, want, *got.Value, "%s: %s after %s", svc.GetName(), flag, step.name)
+				}
+				if step.sent != nil {
+					assertIdentified(t, h.GetReceivedIdentifies(), *step.sent, svc.GetName())
+				}
+			}
+		})
+	}
+}
+
+// TestIdentifyBeforeInit checks that identify on an SDK that is not
+// initialized is answered (as an error or a no-op) and does not leak into
+// the client created by a later init.
+func TestIdentifyBeforeInit(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("targeting")
+	pro := lifecycleUser("user-early-pro", "pro")
+
+	tc.RunForEachSDK("identify before init", func(t *testing.T, svc harness.SDKService) {
+		h.ClearUserSessions()
+		svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewIdentifyCommand(pro))
+		require.NoError(t, err, "%s must answer identify before init", svc.GetName())
+		t.Logf("%s: identify before init -> error=%q", svc.GetName(), resp.Error)
+
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfig(), nil))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "%s: init after early identify failed: %s", svc.GetName(), resp.Error)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		got, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("pro-only", true))
+		require.NoError(t, err)
+		require.NotNil(t, got.Value)
+		assert.False(t, *got.Value, "%s: user identified before init leaked into the new client", svc.GetName())
+	})
+}
+
+// TestConcurrentIdentify sends overlapping identify commands and checks every
+// payload the mock receives is intact (no attributes from another user), and
+// that the SDK still follows a later identify.
+func TestConcurrentIdentify(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("targeting")
+
+	const concurrent = 8
+
+	tc.RunForEachSDK("concurrent identify", func(t *testing.T, svc harness.SDKService) {
+		h.ClearUserSessions()
+		h.ClearReceivedIdentifies()
+
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfig(), nil))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "%s init failed: %s", svc.GetName(), resp.Error)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		users := make(map[string]protocol.UserContext, concurrent)
+		var wg sync.WaitGroup
+		errs := make(chan error, concurrent)
+		for i := 0; i < concurrent; i++ {
+			plan := "free"
+			if i%2 == 0 {
+				plan = "pro"
+			}
+			user := lifecycleUser(fmt.Sprintf("user-conc-%d", i), plan)
+			users[user.ID] = user
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := svc.SendCommand(context.Background(), protocol.NewIdentifyCommand(user))
+				if err == nil && resp.IsError() {
+					err = fmt.Errorf("identify %s: %s", user.ID, resp.Error)
+				}
+				if err != nil {
+					errs <- err
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Errorf("%s: %v", svc.GetName(), err)
+		}
+
+		for _, received := range h.GetReceivedIdentifies() {
+			user, ok := users[received.ID]
+			if !assert.True(t, ok, "%s: unexpected identify for %q", svc.GetName(), received.ID) {
+				continue
+			}
+			if plan, ok := received.Attributes["plan"]; ok {
+				assert.Equal(t, user.Attributes["plan"], plan,
+					"%s: identify for %s carries another user's attributes", svc.GetName(), received.ID)
+			}
+		}
+
+		final := lifecycleUser("user-conc-final", "pro")
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewIdentifyCommand(final))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "%s: identify after concurrent identifies failed: %s", svc.GetName(), resp.Error)
+
+		got, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("pro-only", false))
+		require.NoError(t, err)
+		require.NotNil(t, got.Value)
+		assert.True(t, *got.Value, "%s: pro-only after final identify", svc.GetName())
+		assertIdentified(t, h.GetReceivedIdentifies(), final, svc.GetName())
+	})
+}
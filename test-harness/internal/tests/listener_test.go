@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startListenerHarness starts a second mock on a free port with the given
+// listener options. SDK services of the main harness are pointed at it
+// through the init config.
+func startListenerHarness(t *testing.T, network, socket string) *harness.Harness {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	h := harness.New(harness.Config{
+		MockPort:    port,
+		MockNetwork: network,
+		MockSocket:  socket,
+		APIKey:      getHarness(t).GetAPIKey(),
+	})
+	require.NoError(t, h.Start(context.Background()))
+	t.Cleanup(func() { h.Stop(context.Background()) })
+
+	h.SetScenario("basic")
+	return h
+}
+
+func ipv6Available() bool {
+	l, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// TestMockListenerNetworks runs each SDK against the mock bound on IPv4,
+// IPv6 and dual-stack addresses.
+func TestMockListenerNetworks(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to control listeners")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	for _, network := range []string{harness.NetworkIPv4, harness.NetworkIPv6, harness.NetworkDual} {
+		t.Run(network, func(t *testing.T) {
+			if network != harness.NetworkIPv4 && !ipv6Available() {
+				t.Skip("IPv6 loopback not available")
+			}
+			mh := startListenerHarness(t, network, "")
+
+			urls := []string{mh.GetMockURL()}
+			if network == harness.NetworkDual {
+				// Same port over the other address family
+				_, port, _ := net.SplitHostPort(mh.GetMockURL()[len("http://"):])
+				urls = append(urls, "http://127.0.0.1:"+port, "http://[::1]:"+port)
+			}
+			for _, url := range urls {
+				resp, err := http.Get(url + "/health")
+				require.NoError(t, err, url)
+				resp.Body.Close()
+				assert.Equal(t, http.StatusOK, resp.StatusCode, url)
+			}
+
+			for _, svc := range h.GetServices() {
+				resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(mh.InitSDKConfig(), nil))
+				require.NoError(t, err)
+				require.False(t, resp.IsError(), "%s init against %s: %s", svc.GetName(), mh.GetMockURL(), resp.Error)
+
+				flag, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+				require.NoError(t, err)
+				assert.True(t, flag.Value != nil && *flag.Value, "%s over %s", svc.GetName(), network)
+				svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+			}
+		})
+	}
+}
+
+// TestMockUnixSocket checks the mock serves on a unix domain socket and
+// reports which SDKs can dial it. The init config carries an unresolvable
+// BaseURL host, so only SDKs honouring socketPath can initialize.
+func TestMockUnixSocket(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to control listeners")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	mh := startListenerHarness(t, harness.NetworkLocalhost, filepath.Join(t.TempDir(), "mock.sock"))
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", mh.GetMockSocket())
+		},
+	}}
+	resp, err := client.Get("http://mock.sock/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	for _, svc := range h.GetServices() {
+		config := mh.InitSDKConfig()
+		config.BaseURL = "http://mock.sock"
+
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		supported := !resp.IsError()
+		if supported {
+			flag, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+			require.NoError(t, err)
+			supported = flag.Value != nil && *flag.Value
+		}
+		svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		t.Logf("%s: unix socket supported=%v", svc.GetName(), supported)
+	}
+}
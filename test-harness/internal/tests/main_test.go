@@ -8,7 +8,10 @@ import (
 	"testing"
 )
 
-var servicesFlag = flag.String("services", "", "Comma-separated list of name=url pairs")
+var (
+	servicesFlag = flag.String("services", "", "Comma-separated list of name=url pairs")
+	seedFlag     = flag.Int64("seed", 0, "Seed for generated test data (default: TEST_SEED or time-based)")
+)
 
 func TestMain(m *testing.M) {
 	flag.Parse()
@@ -42,8 +45,17 @@ func TestMain(m *testing.M) {
 		log.Fatalf("Failed to setup harness: %v", err)
 	}
 
+	log.Printf("Seed: %d", h.Seed())
+
 	// Run tests
 	code := m.Run()
+	if code != 0 {
+		log.Printf("Tests failed with seed %d; reproduce with -seed=%d", h.Seed(), h.Seed())
+	}
+
+	if commandBudgets != nil {
+		h.TimingReport(commandBudgets, slowestCommands).Write(os.Stdout)
+	}
 
 	// Teardown
 	TeardownHarness(h)
@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// partitionSubsystem is an SDK subsystem and the endpoints it depends on.
+type partitionSubsystem struct {
+	name  string
+	paths []string
+}
+
+// partitionSubsystems are the subsystems the partition matrix takes down
+// in every combination.
+var partitionSubsystems = []partitionSubsystem{
+	{name: "flags", paths: []string{"/api/v1/sdk/flags", "/api/v1/sdk/v2/flags"}},
+	{name: "stream", paths: []string{"/api/v1/sdk/stream"}},
+	{name: "identify", paths: []string{"/api/v1/sdk/identify"}},
+	{name: "events", paths: []string{"/api/v1/sdk/events"}},
+	{name: "telemetry", paths: []string{"/api/v1/sdk/telemetry"}},
+}
+
+// partition is one combination of unhealthy subsystems.
+type partition map[string]bool
+
+// name lists the unhealthy subsystems, or "all-healthy".
+func (p partition) name() string {
+	var down []string
+	for _, sub := range partitionSubsystems {
+		if p[sub.name] {
+			down = append(down, sub.name)
+		}
+	}
+	if len(down) == 0 {
+		return "all-healthy"
+	}
+	return strings.Join(down, "+") + "-down"
+}
+
+// paths returns the endpoints of the unhealthy subsystems.
+func (p partition) paths() []string {
+	var paths []string
+	for _, sub := range partitionSubsystems {
+		if p[sub.name] {
+			paths = append(paths, sub.paths...)
+		}
+	}
+	return paths
+}
+
+// allPartitions returns every combination of healthy and unhealthy
+// subsystems.
+func allPartitions() []partition {
+	partitions := make([]partition, 0, 1<<len(partitionSubsystems))
+	for mask := 0; mask < 1<<len(partitionSubsystems); mask++ {
+		p := make(partition)
+		for i, sub := range partitionSubsystems {
+			p[sub.name] = mask&(1<<i) != 0
+		}
+		partitions = append(partitions, p)
+	}
+	return partitions
+}
+
+// assertEvaluation checks that an SDK serves the basic scenario's values,
+// asking with the opposite defaults so a default cannot pass for a value.
+func assertEvaluation(t *testing.T, tc *TestContext, svc harness.SDKService, when string) {
+	t.Helper()
+	for key, want := range map[string]bool{"enabled-flag": true, "disabled-flag": false} {
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand(key, !want))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "isEnabled(%s) %s: %s - %s", key, when, resp.Error, resp.Message)
+		assert.Equal(t, want, resp.GetValue(!want), "%s %s", key, when)
+	}
+}
+
+// TestNetworkPartitionMatrix tests every combination of healthy and
+// unhealthy flags, stream, identify, events and telemetry endpoints, and
+// asserts that a failing subsystem never degrades another: flags keep
+// evaluating to their last known values, identify changes targeting while
+// the flags endpoint is up, and events and telemetry reach healthy
+// endpoints whatever else is down.
+func TestNetworkPartitionMatrix(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for error injection")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ClearError()
+
+	tc.RunForEachSDK("partition", func(t *testing.T, svc harness.SDKService) {
+		for _, p := range allPartitions() {
+			t.Run(p.name(), func(t *testing.T) {
+				h.ClearError()
+				h.SetScenario("basic")
+				h.SetFlag(&mock.FlagState{Key: "partition-targeted", Enabled: true, TargetUsers: []string{"partition-user"}})
+				h.ClearReceivedEvents()
+				h.ClearReceivedTelemetry()
+
+				// Start healthy, then partition
+				resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfigWithStreaming(), nil))
+				require.NoError(t, err)
+				if resp.IsError() {
+					resp, err = svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfig(), nil))
+					require.NoError(t, err)
+				}
+				defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+				require.False(t, resp.IsError(), "init should succeed: %s - %s", resp.Error, resp.Message)
+
+				if paths := p.paths(); len(paths) > 0 {
+					h.SetEndpointErrors(http.StatusServiceUnavailable, paths...)
+				}
+				// Streams reconnect into the partition
+				h.DisconnectSSEClients()
+				assertEvaluation(t, tc, svc, "after the partition")
+
+				// Identify
+				resp, err = svc.SendCommand(tc.Ctx, protocol.NewIdentifyCommand(protocol.UserContext{ID: "partition-user"}))
+				require.NoError(t, err)
+				if !p["flags"] {
+					assert.False(t, resp.IsError(), "identify should succeed while flags are up: %s - %s", resp.Error, resp.Message)
+					resp, err = svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("partition-targeted", false))
+					require.NoError(t, err)
+					assert.True(t, resp.GetValue(false), "targeting should follow identify while flags are up")
+				}
+				assertEvaluation(t, tc, svc, "after identify")
+
+				// Events
+				_, err = svc.SendCommand(tc.Ctx, protocol.NewTrackCommand("enabled-flag", "partition-event", "partition-user"))
+				require.NoError(t, err)
+				_, err = svc.SendCommand(tc.Ctx, protocol.NewFlushEventsCommand())
+				require.NoError(t, err)
+				if !p["events"] {
+					assert.Eventually(t, func() bool {
+						for _, ev := range h.GetReceivedEvents() {
+							if ev.EventName == "partition-event" {
+								return true
+							}
+						}
+						return false
+					}, 2*time.Second, 20*time.Millisecond, "events should reach a healthy endpoint")
+				}
+				assertEvaluation(t, tc, svc, "after flushing events")
+
+				// Telemetry
+				resp, err = svc.SendCommand(tc.Ctx, protocol.NewFlushTelemetryCommand())
+				require.NoError(t, err)
+				if !p["telemetry"] && !(resp.IsError() && resp.Error == "UnknownCommand") {
+					assert.Eventually(t, func() bool {
+						return len(h.GetReceivedTelemetry()) > 0
+					}, 2*time.Second, 20*time.Millisecond, "telemetry should reach a healthy endpoint")
+				}
+				assertEvaluation(t, tc, svc, "after flushing telemetry")
+			})
+		}
+	})
+}
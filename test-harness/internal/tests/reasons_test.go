@@ -1,8 +1,15 @@
 package tests
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/rollgate/test-harness/internal/harness"
 	"github.com/rollgate/test-harness/internal/mock"
 	"github.com/rollgate/test-harness/internal/protocol"
 	"github.com/stretchr/testify/assert"
@@ -130,6 +137,45 @@ func TestReasonTargetMatch(t *testing.T) {
 	}
 }
 
+// TestReasonFlagVersion tests that evaluation details carry the version and
+// last update time of the flag configuration that was served.
+func TestReasonFlagVersion(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.GetMockServer().GetFlagStore().Clear()
+	h.SetFlag(&mock.FlagState{Key: "versioned-flag", Enabled: false})
+	h.SetFlag(&mock.FlagState{Key: "versioned-flag", Enabled: true})
+
+	store := h.GetMockServer().GetFlagStore()
+	tc.RunForEachSDK("flag version", func(t *testing.T, svc harness.SDKService) {
+		detail := func() protocol.Response {
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfig(), nil))
+			require.NoError(t, err)
+			require.False(t, resp.IsError(), "%s: init failed: %s", svc.GetName(), resp.Message)
+			defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+			resp, err = svc.SendCommand(tc.Ctx, protocol.NewIsEnabledDetailCommand("versioned-flag", false))
+			require.NoError(t, err)
+			require.False(t, resp.IsError(), "%s: %s", svc.GetName(), resp.Message)
+			return resp
+		}
+
+		flag, _ := store.Get("versioned-flag")
+		resp := detail()
+		assert.Equal(t, flag.Version, resp.FlagVersion, "%s: served version", svc.GetName())
+		updatedAt, err := time.Parse(time.RFC3339Nano, resp.FlagUpdatedAt)
+		require.NoError(t, err, "%s: flagUpdatedAt should be RFC 3339", svc.GetName())
+		assert.True(t, updatedAt.Equal(*flag.UpdatedAt), "%s: served update time", svc.GetName())
+
+		h.SetFlag(&mock.FlagState{Key: "versioned-flag", Enabled: true})
+		assert.Equal(t, flag.Version+1, detail().FlagVersion, "%s: version after an update", svc.GetName())
+	})
+}
+
 // TestReasonValueConsistency tests that isEnabledDetail returns same value as isEnabled.
 func TestReasonValueConsistency(t *testing.T) {
 	h := getHarness(t)
@@ -195,3 +241,267 @@ func TestReasonHasKind(t *testing.T) {
 			"%s: reason kind %q should be one of %v", svc.GetName(), resp.Reason.Kind, validKinds)
 	}
 }
+
+// TestReasonSchemaMock checks the mock serves complete reasons in the
+// canonical schema on both the V1 and V2 endpoints: ruleIndex 0 and
+// inRollout false are present, and typed flags name the variation served.
+func TestReasonSchemaMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+
+	variations := map[string]any{"a": "A", "b": "B"}
+	h.GetMockServer().GetFlagStore().Clear()
+	h.SetFlag(&mock.FlagState{Key: "schema-off", Enabled: false, Variations: variations, DefaultVariation: "a"})
+	h.SetFlag(&mock.FlagState{Key: "schema-target", Enabled: true, TargetUsers: []string{"user-1"}, Variations: variations, DefaultVariation: "a"})
+	h.SetFlag(&mock.FlagState{Key: "schema-fallthrough", Enabled: true, RolloutPercentage: 100, Variations: variations, DefaultVariation: "a"})
+	h.SetFlag(&mock.FlagState{Key: "schema-rule", Enabled: true, Variations: variations, DefaultVariation: "a", Rules: []mock.Rule{{
+		ID: "rule-user-1", Enabled: true, RolloutPercentage: 100, Variation: "b",
+		Conditions: []mock.Condition{{Attribute: "id", Operator: "eq", Value: "user-1"}},
+	}}})
+	h.SetFlag(&mock.FlagState{Key: "schema-rule-out", Enabled: true, RolloutPercentage: 100, Rules: []mock.Rule{{
+		ID: "rule-user-1", Enabled: true, RolloutPercentage: 0,
+		Conditions: []mock.Condition{{Attribute: "id", Operator: "eq", Value: "user-1"}},
+	}}})
+
+	// Reasons are decoded as raw maps so missing and zero fields differ
+	want := map[string]map[string]any{
+		"schema-off":         {"kind": "OFF"},
+		"schema-target":      {"kind": "TARGET_MATCH", "variationId": "a"},
+		"schema-fallthrough": {"kind": "FALLTHROUGH", "inRollout": true, "variationId": "a"},
+		"schema-rule":        {"kind": "RULE_MATCH", "ruleId": "rule-user-1", "ruleIndex": float64(0), "inRollout": true, "variationId": "b"},
+		"schema-rule-out":    {"kind": "RULE_MATCH", "ruleId": "rule-user-1", "ruleIndex": float64(0), "inRollout": false},
+	}
+
+	get := func(path string, body any) {
+		req, err := http.NewRequest(http.MethodGet, h.GetMockURL()+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+h.GetAPIKey())
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(body))
+	}
+
+	var v1 struct {
+		Reasons map[string]map[string]any `json:"reasons"`
+	}
+	get("/api/v1/sdk/flags?withReasons=true&user_id=user-1", &v1)
+
+	var v2 struct {
+		Flags map[string]struct {
+			Reason map[string]any `json:"reason"`
+		} `json:"flags"`
+	}
+	get("/api/v1/sdk/v2/flags?user_id=user-1", &v2)
+
+	for key, reason := range want {
+		assert.Equal(t, reason, v1.Reasons[key], "V1 reason for %s", key)
+		assert.Equal(t, reason, v2.Flags[key].Reason, "V2 reason for %s", key)
+	}
+}
+
+// readSSEEvent reads the next event from an SSE stream.
+func readSSEEvent(t *testing.T, r *bufio.Reader) (event, data string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && event != "":
+			return event, data
+		}
+	}
+}
+
+// TestSSEReasonsMock checks the mock's stream carries reasons in init and
+// flag-changed events when opened with ?withReasons=true, and only then.
+func TestSSEReasonsMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+
+	h.GetMockServer().GetFlagStore().Clear()
+	h.SetFlag(&mock.FlagState{Key: "stream-target", Enabled: true, TargetUsers: []string{"user-1"}})
+	h.SetFlag(&mock.FlagState{Key: "stream-fallthrough", Enabled: true, RolloutPercentage: 100})
+
+	connect := func(ctx context.Context, withReasons bool) *bufio.Reader {
+		url := h.GetMockURL() + "/api/v1/sdk/stream?user_id=user-1&token=" + h.GetAPIKey()
+		if withReasons {
+			url += "&withReasons=true"
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		t.Cleanup(func() { resp.Body.Close() })
+		return bufio.NewReader(resp.Body)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	withReasons := connect(ctx, true)
+	plain := connect(ctx, false)
+
+	event, data := readSSEEvent(t, withReasons)
+	require.Equal(t, "init", event)
+	var init mock.FlagsResponse
+	require.NoError(t, json.Unmarshal([]byte(data), &init))
+	assert.Equal(t, "TARGET_MATCH", init.Reasons["stream-target"].Kind)
+	assert.Equal(t, "FALLTHROUGH", init.Reasons["stream-fallthrough"].Kind)
+
+	event, data = readSSEEvent(t, plain)
+	require.Equal(t, "init", event)
+	init = mock.FlagsResponse{}
+	require.NoError(t, json.Unmarshal([]byte(data), &init))
+	assert.Empty(t, init.Reasons, "reasons must be opt-in on the stream")
+
+	// The update carries the reason re-evaluated for the stream's user
+	require.Eventually(t, func() bool { return h.GetSSEClientCount() == 2 }, time.Second, 10*time.Millisecond)
+	h.SetFlag(&mock.FlagState{Key: "stream-fallthrough", Enabled: false})
+	h.BroadcastFlagChange("stream-fallthrough", false)
+
+	event, data = readSSEEvent(t, withReasons)
+	require.Equal(t, "flag-changed", event)
+	var changed mock.FlagChangedEvent
+	require.NoError(t, json.Unmarshal([]byte(data), &changed))
+	assert.Equal(t, "stream-fallthrough", changed.Key)
+	require.NotNil(t, changed.Reason)
+	assert.Equal(t, "OFF", changed.Reason.Kind)
+
+	event, data = readSSEEvent(t, plain)
+	require.Equal(t, "flag-changed", event)
+	changed = mock.FlagChangedEvent{}
+	require.NoError(t, json.Unmarshal([]byte(data), &changed))
+	assert.Nil(t, changed.Reason)
+
+	// V2 reasons can be turned off
+	req, err := http.NewRequest(http.MethodGet, h.GetMockURL()+"/api/v1/sdk/v2/flags?withReasons=false", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+h.GetAPIKey())
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var v2 mock.FlagsV2Response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&v2))
+	assert.Nil(t, v2.Flags["stream-target"].Reason)
+}
+
+// TestReasonPrerequisiteFailed tests that a flag whose prerequisite is not
+// met evaluates to false with a PREREQUISITE_FAILED reason naming it.
+func TestReasonPrerequisiteFailed(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.GetMockServer().GetFlagStore().Clear()
+	h.SetFlag(&mock.FlagState{
+		Key:         "prereq-parent",
+		Enabled:     true,
+		TargetUsers: []string{"prereq-user"},
+	})
+	h.SetFlag(&mock.FlagState{
+		Key:               "prereq-child",
+		Enabled:           true,
+		RolloutPercentage: 100,
+		Prerequisites:     []mock.Prerequisite{{FlagKey: "prereq-parent", RequiredValue: true}},
+	})
+
+	cmd := protocol.NewIsEnabledDetailCommand("prereq-child", false)
+
+	t.Run("met", func(t *testing.T) {
+		require.NoError(t, tc.InitAllSDKs(&protocol.UserContext{ID: "prereq-user"}))
+		defer tc.CloseAllSDKs()
+
+		for _, svc := range h.GetServices() {
+			resp, err := svc.SendCommand(tc.Ctx, cmd)
+			require.NoError(t, err, "%s should not error", svc.GetName())
+			require.False(t, resp.IsError(), "%s response should not be error: %s", svc.GetName(), resp.Error)
+			require.NotNil(t, resp.Value, "%s should return a value", svc.GetName())
+
+			assert.True(t, *resp.Value, "%s: prereq-child should be true once its prerequisite is met", svc.GetName())
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		require.NoError(t, tc.InitAllSDKs(&protocol.UserContext{ID: "other-user"}))
+		defer tc.CloseAllSDKs()
+
+		for _, svc := range h.GetServices() {
+			resp, err := svc.SendCommand(tc.Ctx, cmd)
+			require.NoError(t, err, "%s should not error", svc.GetName())
+			require.False(t, resp.IsError(), "%s response should not be error: %s", svc.GetName(), resp.Error)
+			require.NotNil(t, resp.Value, "%s should return a value", svc.GetName())
+			require.NotNil(t, resp.Reason, "%s should return a reason", svc.GetName())
+
+			assert.False(t, *resp.Value, "%s: prereq-child should be false without its prerequisite", svc.GetName())
+			if resp.Reason.Kind == "FALLTHROUGH" {
+				continue // SDKs without server reasons report their own
+			}
+			assert.Equal(t, "PREREQUISITE_FAILED", resp.Reason.Kind, "%s: reason should be PREREQUISITE_FAILED", svc.GetName())
+			assert.Equal(t, "prereq-parent", resp.Reason.PrerequisiteKey, "%s: reason should name the prerequisite", svc.GetName())
+		}
+	})
+}
+
+// TestPrerequisitesMock checks the mock's prerequisite evaluation: required
+// false values, chains, cycles and missing prerequisites.
+func TestPrerequisitesMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+
+	prereq := func(key string, required bool) []mock.Prerequisite {
+		return []mock.Prerequisite{{FlagKey: key, RequiredValue: required}}
+	}
+	h.GetMockServer().GetFlagStore().Clear()
+	h.SetFlag(&mock.FlagState{Key: "pm-off", Enabled: false})
+	h.SetFlag(&mock.FlagState{Key: "pm-on", Enabled: true, RolloutPercentage: 100})
+	h.SetFlag(&mock.FlagState{Key: "pm-requires-off", Enabled: true, RolloutPercentage: 100, Prerequisites: prereq("pm-off", false)})
+	h.SetFlag(&mock.FlagState{Key: "pm-chain", Enabled: true, RolloutPercentage: 100, Prerequisites: prereq("pm-requires-off", true)})
+	h.SetFlag(&mock.FlagState{Key: "pm-missing", Enabled: true, RolloutPercentage: 100, Prerequisites: prereq("pm-nope", false)})
+	h.SetFlag(&mock.FlagState{Key: "pm-cycle-a", Enabled: true, RolloutPercentage: 100, Prerequisites: prereq("pm-cycle-b", true)})
+	h.SetFlag(&mock.FlagState{Key: "pm-cycle-b", Enabled: true, RolloutPercentage: 100, Prerequisites: prereq("pm-cycle-a", true)})
+	h.SetFlag(&mock.FlagState{Key: "pm-disabled", Enabled: false, Prerequisites: prereq("pm-on", false)})
+
+	want := map[string]map[string]any{
+		"pm-requires-off": {"kind": "FALLTHROUGH", "inRollout": true},
+		"pm-chain":        {"kind": "FALLTHROUGH", "inRollout": true},
+		"pm-missing":      {"kind": "PREREQUISITE_FAILED", "prerequisiteKey": "pm-nope"},
+		"pm-cycle-a":      {"kind": "PREREQUISITE_FAILED", "prerequisiteKey": "pm-cycle-b"},
+		"pm-cycle-b":      {"kind": "PREREQUISITE_FAILED", "prerequisiteKey": "pm-cycle-a"},
+		"pm-disabled":     {"kind": "OFF"},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, h.GetMockURL()+"/api/v1/sdk/flags?withReasons=true&user_id=user-1", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+h.GetAPIKey())
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Flags   map[string]bool           `json:"flags"`
+		Reasons map[string]map[string]any `json:"reasons"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	for key, reason := range want {
+		assert.Equal(t, reason, body.Reasons[key], "reason for %s", key)
+		assert.Equal(t, reason["kind"] == "FALLTHROUGH", body.Flags[key], "value for %s", key)
+	}
+}
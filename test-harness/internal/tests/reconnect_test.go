@@ -0,0 +1,228 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// reconnectDisconnects is how many times each SDK's stream is dropped
+	reconnectDisconnects = 3
+	// reconnectDeadline bounds how long an SDK may take to reconnect
+	reconnectDeadline = 10 * time.Second
+	// reconnectMinGap is the shortest acceptable wait between failed attempts;
+	// anything faster is a reconnect storm
+	reconnectMinGap = 100 * time.Millisecond
+)
+
+// openSSEConnection returns the open stream in the mock's log, if any.
+func openSSEConnection(h *harness.Harness) (mock.SSEConnection, bool) {
+	for _, conn := range h.GetSSEConnections() {
+		if conn.Open() {
+			return conn, true
+		}
+	}
+	return mock.SSEConnection{}, false
+}
+
+// waitForSSEConnection waits for an open stream newer than afterID.
+func waitForSSEConnection(h *harness.Harness, afterID int, timeout time.Duration) (mock.SSEConnection, bool) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if conn, ok := openSSEConnection(h); ok && conn.ID > afterID {
+			return conn, true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return mock.SSEConnection{}, false
+}
+
+// initStreaming inits an SDK with streaming and waits for its first stream.
+// It skips the test for SDKs that do not stream.
+func initStreaming(t *testing.T, tc *TestContext, svc harness.SDKService) mock.SSEConnection {
+	t.Helper()
+	h := tc.Harness
+
+	resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfigWithStreaming(), nil))
+	require.NoError(t, err)
+	if resp.IsError() {
+		svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		SkipUnsupported(t, "%s: streaming not supported: %s", svc.GetName(), resp.Error)
+	}
+
+	conn, ok := waitForSSEConnection(h, 0, 2*time.Second)
+	if !ok {
+		svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		t.Skipf("%s: no SSE connection after init", svc.GetName())
+	}
+	return conn
+}
+
+// TestSSEConnectionLogMock verifies the mock logs stream connections, who
+// closed them, and rejected attempts.
+func TestSSEConnectionLogMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for the SSE connection log")
+	}
+	h.ResetSSEConnections()
+	defer h.ResetSSEConnections()
+
+	h.SetScenario("basic")
+	streamURL := h.GetMockURL() + "/api/v1/sdk/stream?token=" + h.GetAPIKey()
+
+	connect := func(ctx context.Context) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// Closed by the server
+	resp := connect(context.Background())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, ok := waitForSSEConnection(h, 0, time.Second)
+	require.True(t, ok)
+	assert.Equal(t, 1, h.DisconnectSSEClients())
+	resp.Body.Close()
+
+	// Closed by the client
+	ctx, cancel := context.WithCancel(context.Background())
+	resp = connect(ctx)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, ok = waitForSSEConnection(h, 1, time.Second)
+	require.True(t, ok)
+	cancel()
+	resp.Body.Close()
+
+	// Rejected
+	h.RejectSSEConnections(http.StatusServiceUnavailable, 1)
+	resp = connect(context.Background())
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	require.Eventually(t, func() bool {
+		conns := h.GetSSEConnections()
+		return len(conns) == 3 && conns[1].ClosedAt != nil
+	}, time.Second, 10*time.Millisecond)
+
+	conns := h.GetSSEConnections()
+	assert.Equal(t, "server", conns[0].ClosedBy)
+	assert.Equal(t, "client", conns[1].ClosedBy)
+	assert.Equal(t, http.StatusServiceUnavailable, conns[2].Status)
+	for _, conn := range conns {
+		assert.False(t, conn.Open())
+	}
+}
+
+// TestSSEReconnect drops each SDK's stream repeatedly and checks that it
+// reconnects within reconnectDeadline with exactly one new connection each
+// time. Reconnect latencies are measured from the mock's connection log.
+func TestSSEReconnect(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for the SSE connection log")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetSSEConnections()
+
+	h.SetScenario("basic")
+
+	tc.RunForEachSDK("SSE reconnect", func(t *testing.T, svc harness.SDKService) {
+		h.DisconnectSSEClients()
+		h.ResetSSEConnections()
+		conn := initStreaming(t, tc, svc)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		latencies := make([]time.Duration, 0, reconnectDisconnects)
+		for i := 0; i < reconnectDisconnects; i++ {
+			h.DisconnectSSEClients()
+
+			next, ok := waitForSSEConnection(h, conn.ID, reconnectDeadline)
+			require.True(t, ok, "%s did not reconnect within %v after disconnect %d",
+				svc.GetName(), reconnectDeadline, i+1)
+
+			// The closing side records ClosedAt; re-read the log for it
+			for _, c := range h.GetSSEConnections() {
+				if c.ID == conn.ID {
+					conn = c
+				}
+			}
+			require.NotNil(t, conn.ClosedAt)
+			assert.Equal(t, "server", conn.ClosedBy)
+			latencies = append(latencies, next.ConnectedAt.Sub(*conn.ClosedAt))
+			conn = next
+		}
+
+		conns := h.GetSSEConnections()
+		t.Logf("%s: reconnect latencies %v over %d connection attempts", svc.GetName(), latencies, len(conns))
+		assert.Len(t, conns, reconnectDisconnects+1,
+			"%s opened extra streams (storm or duplicate connections)", svc.GetName())
+
+		// Still receiving updates on the latest stream
+		h.BroadcastFlagChange("enabled-flag", false)
+		require.Eventually(t, func() bool {
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", true))
+			return err == nil && resp.Value != nil && !*resp.Value
+		}, 2*time.Second, 50*time.Millisecond, "%s missed an update after reconnecting", svc.GetName())
+		h.BroadcastFlagChange("enabled-flag", true)
+	})
+}
+
+// TestSSEReconnectBackoff rejects several reconnect attempts and checks that
+// the SDK backs off between them instead of retrying in a tight loop, then
+// reconnects once the stream accepts connections again.
+func TestSSEReconnectBackoff(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for the SSE connection log")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetSSEConnections()
+
+	h.SetScenario("basic")
+
+	const rejections = 2
+
+	tc.RunForEachSDK("SSE reconnect backoff", func(t *testing.T, svc harness.SDKService) {
+		h.DisconnectSSEClients()
+		h.ResetSSEConnections()
+		conn := initStreaming(t, tc, svc)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		h.RejectSSEConnections(http.StatusServiceUnavailable, rejections)
+		h.DisconnectSSEClients()
+
+		_, ok := waitForSSEConnection(h, conn.ID, reconnectDeadline*rejections)
+		require.True(t, ok, "%s did not reconnect after %d rejected attempts", svc.GetName(), rejections)
+
+		var attempts []time.Time
+		for _, c := range h.GetSSEConnections() {
+			if c.ID > conn.ID {
+				attempts = append(attempts, c.ConnectedAt)
+			}
+		}
+		require.Len(t, attempts, rejections+1, "%s made unexpected connection attempts", svc.GetName())
+
+		gaps := make([]time.Duration, 0, len(attempts)-1)
+		for i := 1; i < len(attempts); i++ {
+			gaps = append(gaps, attempts[i].Sub(attempts[i-1]))
+		}
+		t.Logf("%s: gaps between attempts after rejection %v", svc.GetName(), gaps)
+		for _, gap := range gaps {
+			assert.GreaterOrEqual(t, gap, reconnectMinGap,
+				"%s retried a rejected stream without backing off", svc.GetName())
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var redirectStatuses = []int{
+	http.StatusMovedPermanently,
+	http.StatusFound,
+	http.StatusTemporaryRedirect,
+	http.StatusPermanentRedirect,
+}
+
+// TestRedirectMock verifies the mock's redirects with a plain HTTP client.
+// Go drops the Authorization header on cross-host redirects, which the mock
+// answers with 401 and counts as a followed request without credentials.
+func TestRedirectMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to simulate redirects")
+	}
+	defer h.ResetRedirects()
+
+	for _, status := range redirectStatuses {
+		for _, crossHost := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%d cross-host=%v", status, crossHost), func(t *testing.T) {
+				h.ResetRedirects()
+				err := h.SetRedirect(status, crossHost, 1)
+				if errors.Is(err, harness.ErrNoCrossHost) {
+					t.Skip(err)
+				}
+				require.NoError(t, err)
+
+				req, err := http.NewRequest(http.MethodGet, h.GetMockURL()+"/api/v1/sdk/flags", nil)
+				require.NoError(t, err)
+				req.Header.Set("Authorization", "Bearer "+h.GetAPIKey())
+
+				resp, err := http.DefaultClient.Do(req)
+				require.NoError(t, err)
+				resp.Body.Close()
+
+				stats := h.GetRedirectStats()
+				assert.Equal(t, 1, stats.Redirected)
+				assert.Equal(t, 1, stats.Followed)
+				if crossHost {
+					assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+					assert.Equal(t, 1, stats.FollowedNoAuth)
+				} else {
+					assert.Equal(t, http.StatusOK, resp.StatusCode)
+					assert.Equal(t, 0, stats.FollowedNoAuth)
+				}
+			})
+		}
+	}
+
+	assert.Error(t, h.SetRedirect(http.StatusOK, false, 1), "non-redirect status should be rejected")
+}
+
+// TestProxyMock verifies the mock only serves proxy-style requests when
+// configured to require them.
+func TestProxyMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to simulate a proxy")
+	}
+	defer h.ResetRedirects()
+
+	proxyURL, err := url.Parse(h.GetMockURL())
+	require.NoError(t, err)
+	proxyURL.User = url.UserPassword("proxy", "secret")
+	viaProxy := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	get := func(client *http.Client, target string) int {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+h.GetAPIKey())
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	h.SetProxy(mock.ProxyConfig{Require: true})
+	assert.Equal(t, http.StatusForbidden, get(http.DefaultClient, h.GetMockURL()+"/api/v1/sdk/flags"),
+		"direct request should be rejected")
+	assert.Equal(t, http.StatusOK, get(viaProxy, "http://api.rollgate.invalid/api/v1/sdk/flags"),
+		"absolute-URI request should be served")
+
+	// Proxy-Authorization: Basic base64("proxy:secret")
+	h.SetProxy(mock.ProxyConfig{Require: true, Authorization: "Basic cHJveHk6c2VjcmV0"})
+	assert.Equal(t, http.StatusOK, get(viaProxy, "http://api.rollgate.invalid/api/v1/sdk/flags"))
+
+	h.SetProxy(mock.ProxyConfig{Require: true, Authorization: "Basic d3Jvbmc6d3Jvbmc="})
+	assert.Equal(t, http.StatusProxyAuthRequired, get(viaProxy, "http://api.rollgate.invalid/api/v1/sdk/flags"))
+
+	stats := h.GetRedirectStats()
+	assert.Equal(t, 3, stats.ProxyRequests)
+	assert.Equal(t, 2, stats.ProxyRejected)
+}
+
+// TestRedirectFollowing checks that SDKs follow same-host redirects on the
+// flags endpoint. Cross-host redirects are reported only: whether credentials
+// survive them depends on the HTTP client, and dropping them is correct.
+func TestRedirectFollowing(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to simulate redirects")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetRedirects()
+
+	h.SetScenario("basic")
+
+	tc.RunForEachSDK("redirect following", func(t *testing.T, svc harness.SDKService) {
+		for _, status := range redirectStatuses {
+			for _, crossHost := range []bool{false, true} {
+				h.ResetRedirects()
+				err := h.SetRedirect(status, crossHost, -1)
+				if errors.Is(err, harness.ErrNoCrossHost) {
+					continue
+				}
+				require.NoError(t, err)
+
+				resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfig(), nil))
+				require.NoError(t, err)
+				followed := !resp.IsError()
+				if followed {
+					flag, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+					require.NoError(t, err)
+					followed = flag.Value != nil && *flag.Value
+				}
+				svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+				stats := h.GetRedirectStats()
+				if !crossHost {
+					assert.True(t, followed, "%s must follow a same-host %d redirect", svc.GetName(), status)
+					assert.Greater(t, stats.Followed, 0)
+				}
+				t.Logf("%s: %d cross-host=%-5v followed=%v (redirected=%d, arrived without auth=%d)",
+					svc.GetName(), status, crossHost, followed, stats.Redirected, stats.FollowedNoAuth)
+			}
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestLogPollInterval is the refresh interval of SDKs in the request
+// log suite.
+const requestLogPollInterval = 500 * time.Millisecond
+
+// TestPollingRequests checks each SDK's flags requests as the mock logged
+// them: authenticated and versioned, sent at the configured cadence, and
+// revalidating the previous response's ETag.
+func TestPollingRequests(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server request log")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+
+	tc.RunForEachSDK("polling requests", func(t *testing.T, svc harness.SDKService) {
+		config := h.InitSDKConfig()
+		config.RefreshInterval = int(requestLogPollInterval / time.Millisecond)
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, &protocol.UserContext{ID: "poll-user"}))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "%s init failed: %s", svc.GetName(), resp.Error)
+		// Init may fetch before identifying the user; only polls are checked
+		since := h.RequestSeq()
+
+		time.Sleep(5*requestLogPollInterval + requestLogPollInterval/2)
+		_, err = svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		require.NoError(t, err)
+
+		minCount := 4
+		result := h.AssertRequests(mock.RequestAssertion{
+			Path:     "/api/v1/sdk/flags",
+			Method:   "GET",
+			SinceSeq: since,
+			MinCount: &minCount,
+			Headers: map[string]string{
+				"Authorization": "Bearer " + h.GetAPIKey(),
+				"X-SDK-Version": "*",
+			},
+			MinIntervalMs: (requestLogPollInterval / 2).Milliseconds(),
+			MaxIntervalMs: (requestLogPollInterval * 2).Milliseconds(),
+			Revalidates:   true,
+		})
+		assert.True(t, result.Passed, "%s: %d flags requests: %v", svc.GetName(), result.Matched, result.Failures)
+		for _, req := range h.GetRecordedRequests("/api/v1/sdk/flags") {
+			if req.Seq > since {
+				assert.Equal(t, "poll-user", req.UserID, "%s: request %d", svc.GetName(), req.Seq)
+			}
+		}
+	})
+}
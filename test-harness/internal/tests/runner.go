@@ -10,26 +10,50 @@ import (
 	"github.com/rollgate/test-harness/internal/harness"
 	"github.com/rollgate/test-harness/internal/mock"
 	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/rollgate/test-harness/internal/report"
 )
 
+// leakThresholds bounds test service growth across each test; nil disables
+// leak detection. Set by SetupHarness.
+var leakThresholds *harness.LeakThresholds
+
+// commandBudgets are the per-command thresholds for the timing report
+// printed after the run; nil disables the report. Set by SetupHarness.
+var commandBudgets map[string]time.Duration
+
+// slowestCommands is how many individual commands the timing report lists.
+const slowestCommands = 15
+
+// leakSettleTime is how long Teardown waits for a service to release
+// resources (goroutines exiting after Close) before reporting a leak.
+const leakSettleTime = 3 * time.Second
+
 // TestContext holds the test context.
 type TestContext struct {
 	T       *testing.T
 	Harness *harness.Harness
 	Ctx     context.Context
 	Cancel  context.CancelFunc
+
+	// Process stats sampled in Setup, compared in Teardown
+	baseline map[string]protocol.ProcessStats
 }
 
 // Setup creates a new test context.
 func Setup(t *testing.T, h *harness.Harness) *TestContext {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 
-	return &TestContext{
+	tc := &TestContext{
 		T:       t,
 		Harness: h,
 		Ctx:     ctx,
 		Cancel:  cancel,
 	}
+	h.SetCurrentTest(t.Name())
+	if leakThresholds != nil {
+		tc.baseline = h.SampleProcessStats(ctx)
+	}
+	return tc
 }
 
 // Teardown cleans up the test context.
@@ -40,6 +64,38 @@ func (tc *TestContext) Teardown() {
 	for _, svc := range tc.Harness.GetServices() {
 		_ = svc.Cleanup(context.Background())
 	}
+
+	tc.checkLeaks()
+
+	if tc.T.Failed() {
+		tc.T.Logf("seed: %d (rerun with -seed=%d)", tc.Harness.Seed(), tc.Harness.Seed())
+	}
+}
+
+// checkLeaks fails the test if a service grew past leakThresholds since
+// Setup, after giving it leakSettleTime to release resources.
+func (tc *TestContext) checkLeaks() {
+	if leakThresholds == nil || len(tc.baseline) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), leakSettleTime+5*time.Second)
+	defer cancel()
+
+	deadline := time.Now().Add(leakSettleTime)
+	for {
+		violations := harness.LeakViolations(tc.baseline, tc.Harness.SampleProcessStats(ctx), *leakThresholds)
+		if len(violations) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			for _, v := range violations {
+				tc.T.Errorf("leak detected: %s", v)
+			}
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
 }
 
 // InitAllSDKs initializes all SDKs with the given user.
@@ -202,11 +258,20 @@ func (tc *TestContext) RunForEachSDK(name string, fn func(t *testing.T, svc harn
 
 	for _, svc := range tc.Harness.GetServices() {
 		tc.T.Run(fmt.Sprintf("%s/%s", name, svc.GetName()), func(t *testing.T) {
+			tc.Harness.SetCurrentTest(t.Name())
 			fn(t, svc)
 		})
 	}
 }
 
+// SkipUnsupported skips t because the SDK under test lacks the capability
+// it checks. Reports list these skips as the SDK's backlog, apart from
+// skips caused by the environment (t.Skip).
+func SkipUnsupported(t *testing.T, format string, args ...interface{}) {
+	t.Helper()
+	t.Skipf(report.UnsupportedMarker+format, args...)
+}
+
 // TestScenario represents a test scenario.
 type TestScenario struct {
 	Name     string
@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// schedulingPollInterval is the refresh interval of polling SDKs in the
+	// scheduling suite.
+	schedulingPollInterval = 500 * time.Millisecond
+	// streamingObservationBudget bounds how long a streaming SDK may serve a
+	// flag's old value after the change.
+	streamingObservationBudget = time.Second
+	// pollingObservationBudget bounds the same for polling SDKs: one interval
+	// plus a request round trip.
+	pollingObservationBudget = schedulingPollInterval + time.Second
+)
+
+// schedulingStep advances the virtual clock and names the flag value the
+// SDK should serve afterwards.
+type schedulingStep struct {
+	name    string
+	advance time.Duration
+	fires   int
+	want    bool
+}
+
+// schedulingSteps walks a schedule of flips at 5m (on), 30m (off), 2h (off)
+// and 2h1m (on), covering hours of virtual time in seconds. The last two
+// fire in one advance and only end on if applied in order.
+var schedulingSteps = []schedulingStep{
+	{name: "nothing is due a minute in", advance: time.Minute, fires: 0, want: false},
+	{name: "turns on at 5m", advance: 9 * time.Minute, fires: 1, want: true},
+	{name: "turns off at 30m", advance: 30 * time.Minute, fires: 1, want: false},
+	{name: "two flips in one advance apply in order", advance: 2 * time.Hour, fires: 2, want: true},
+}
+
+// observeFlag polls an SDK until it serves want for flagKey and returns how
+// long that took after since, or false if it did not within timeout.
+func observeFlag(t *testing.T, tc *TestContext, svc harness.SDKService, flagKey string, want bool, since time.Time, timeout time.Duration) (time.Duration, bool) {
+	t.Helper()
+	deadline := since.Add(timeout)
+	for {
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand(flagKey, !want))
+		require.NoError(t, err)
+		if resp.GetValue(!want) == want {
+			return time.Since(since), true
+		}
+		if time.Now().After(deadline) {
+			return 0, false
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// runSchedulingSteps schedules flips on the mock's virtual clock, walks
+// schedulingSteps and checks each change reaches the SDK within budget.
+func runSchedulingSteps(t *testing.T, tc *TestContext, svc harness.SDKService, budget time.Duration) {
+	h := tc.Harness
+	flagKey := "scheduled-flag"
+	h.ScheduleFlagFlip(5*time.Minute, flagKey, true)
+	h.ScheduleFlagFlip(30*time.Minute, flagKey, false)
+	h.ScheduleFlagFlip(2*time.Hour+time.Minute, flagKey, true)
+	h.ScheduleFlagFlip(2*time.Hour, flagKey, false)
+
+	for _, step := range schedulingSteps {
+		fired := h.AdvanceVirtualClock(step.advance)
+		require.Len(t, fired, step.fires, "%s: flips fired", step.name)
+
+		if step.fires == 0 {
+			// Give the SDK time to pick up a change, then check there was none
+			time.Sleep(budget)
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand(flagKey, !step.want))
+			require.NoError(t, err)
+			assert.Equal(t, step.want, resp.GetValue(!step.want), "%s: flag should keep its value", step.name)
+			continue
+		}
+
+		last := fired[len(fired)-1]
+		latency, ok := observeFlag(t, tc, svc, flagKey, step.want, last.FiredAt, 2*budget)
+		if assert.True(t, ok, "%s: SDK should serve %v after the flip", step.name, step.want) {
+			assert.LessOrEqual(t, latency, budget, "%s: observation latency", step.name)
+			t.Logf("%s: observed after %v (virtual time %v)", step.name, latency.Round(time.Millisecond),
+				time.Duration(h.GetVirtualClock().NowMs)*time.Millisecond)
+		}
+	}
+	assert.Empty(t, h.GetVirtualClock().Pending, "every scheduled flip should have fired")
+}
+
+// TestVirtualClockScheduling tests that flag flips scheduled on the mock's
+// virtual clock reach streaming SDKs within streamingObservationBudget and
+// polling SDKs within pollingObservationBudget, without waiting out the
+// schedule in real time.
+func TestVirtualClockScheduling(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for the virtual clock")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetVirtualClock()
+
+	reset := func() {
+		h.SetScenario("basic")
+		h.ResetVirtualClock()
+		h.SetFlag(&mock.FlagState{Key: "scheduled-flag", Enabled: false, RolloutPercentage: 100})
+	}
+
+	tc.RunForEachSDK("streaming", func(t *testing.T, svc harness.SDKService) {
+		reset()
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfigWithStreaming(), &protocol.UserContext{ID: "scheduling-user"}))
+		require.NoError(t, err)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		if resp.IsError() {
+			SkipUnsupported(t, "streaming not supported: %s", resp.Error)
+		}
+		if _, ok := waitForSSEConnection(h, 0, 2*time.Second); !ok {
+			t.Skip("no SSE connection after init")
+		}
+
+		runSchedulingSteps(t, tc, svc, streamingObservationBudget)
+	})
+
+	tc.RunForEachSDK("polling", func(t *testing.T, svc harness.SDKService) {
+		reset()
+		config := h.InitSDKConfig()
+		config.RefreshInterval = int(schedulingPollInterval.Milliseconds())
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, &protocol.UserContext{ID: "scheduling-user"}))
+		require.NoError(t, err)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		require.False(t, resp.IsError(), "init should succeed: %s - %s", resp.Error, resp.Message)
+
+		runSchedulingSteps(t, tc, svc, pollingObservationBudget)
+	})
+}
+
+// TestScheduledFlagChange tests that a change scheduled on the mock's host
+// clock (POST /api/v1/test/schedule-change) fires on its own at the due
+// time and reaches a streaming SDK within streamingObservationBudget: the
+// rollout goes from 0% to 100% half a second in, and back off a second in.
+func TestScheduledFlagChange(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for scheduled changes")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.CancelScheduledChanges()
+
+	flagKey := "scheduled-rollout"
+	tc.RunForEachSDK("streaming", func(t *testing.T, svc harness.SDKService) {
+		h.SetScenario("basic")
+		h.CancelScheduledChanges()
+		h.SetFlag(&mock.FlagState{Key: flagKey, Enabled: true, RolloutPercentage: 0})
+
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(h.InitSDKConfigWithStreaming(), &protocol.UserContext{ID: "scheduling-user"}))
+		require.NoError(t, err)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		if resp.IsError() {
+			SkipUnsupported(t, "streaming not supported: %s", resp.Error)
+		}
+		if _, ok := waitForSSEConnection(h, 0, 2*time.Second); !ok {
+			t.Skip("no SSE connection after init")
+		}
+
+		on := h.ScheduleFlagChange(&mock.FlagState{Key: flagKey, Enabled: true, RolloutPercentage: 100}, 500*time.Millisecond)
+		h.ScheduleFlagChange(&mock.FlagState{Key: flagKey, Enabled: false}, time.Second)
+
+		time.Sleep(time.Until(on.At) - 200*time.Millisecond)
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand(flagKey, true))
+		require.NoError(t, err)
+		assert.False(t, resp.GetValue(true), "flag should stay off before the change is due")
+
+		for i, want := range []bool{true, false} {
+			var fired []mock.ScheduledChange
+			require.Eventually(t, func() bool {
+				fired = h.GetScheduledChanges().Fired
+				return len(fired) > i
+			}, 2*time.Second, 10*time.Millisecond, "change %d should fire", i)
+			latency, ok := observeFlag(t, tc, svc, flagKey, want, *fired[i].FiredAt, 2*streamingObservationBudget)
+			if assert.True(t, ok, "SDK should serve %v after change %d", want, i) {
+				assert.LessOrEqual(t, latency, streamingObservationBudget, "change %d: observation latency", i)
+				t.Logf("change %d: fired %v after due, observed after %v", i,
+					fired[i].FiredAt.Sub(fired[i].At).Round(time.Millisecond), latency.Round(time.Millisecond))
+			}
+		}
+		assert.Empty(t, h.GetScheduledChanges().Pending, "every scheduled change should have fired")
+	})
+}
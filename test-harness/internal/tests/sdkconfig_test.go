@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hintedPollWait is how long SDKs get to poll at a 1s hinted interval.
+const hintedPollWait = 2500 * time.Millisecond
+
+// TestServerHintPollInterval tests that an SDK honoring server hints polls
+// at the interval the mock suggests instead of its configured one, whether
+// the hint comes in headers or in the payload. Polls answered with 304
+// carry no payload, so body hints must outlive them. SDKs that keep their
+// configured interval do not support hints and are skipped.
+func TestServerHintPollInterval(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetSDKConfigHints()
+
+	h.SetScenario("basic")
+
+	for _, delivery := range []string{"headers", "body"} {
+		tc.RunForEachSDK("poll-interval-"+delivery, func(t *testing.T, svc harness.SDKService) {
+			h.SetSDKConfigHints(mock.SDKConfigHints{PollIntervalSeconds: 1, Delivery: delivery})
+			config := h.InitSDKConfig()
+			config.RefreshInterval = 60000
+			config.HonorServerHints = true
+
+			h.ClearRecordedRequests()
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+			require.NoError(t, err)
+			require.False(t, resp.IsError(), "%s: init failed: %s", svc.GetName(), resp.Message)
+			defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+			time.Sleep(hintedPollWait)
+			polls := len(h.GetRecordedRequests("/api/v1/sdk/flags")) - 1
+			if polls == 0 {
+				SkipUnsupported(t, "%s: server hints not supported", svc.GetName())
+			}
+			assert.GreaterOrEqual(t, polls, 2, "%s: should poll about every second", svc.GetName())
+		})
+	}
+}
+
+// TestServerHintStreamingDisabled tests that a streaming SDK honoring
+// server hints polls instead of opening the stream when the mock says
+// streaming is unavailable, and still serves the fetched flags.
+func TestServerHintStreamingDisabled(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetSDKConfigHints()
+
+	h.SetScenario("basic")
+	streaming := false
+	h.SetSDKConfigHints(mock.SDKConfigHints{Streaming: &streaming})
+
+	tc.RunForEachSDK("streaming-disabled", func(t *testing.T, svc harness.SDKService) {
+		config := h.InitSDKConfigWithStreaming()
+		config.HonorServerHints = true
+
+		h.ResetSSEConnections()
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "%s: init failed: %s", svc.GetName(), resp.Message)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		time.Sleep(500 * time.Millisecond)
+		if len(h.GetSSEConnections()) > 0 {
+			SkipUnsupported(t, "%s: server hints not supported", svc.GetName())
+		}
+
+		flag, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+		require.NoError(t, err)
+		require.NotNil(t, flag.Value)
+		assert.True(t, *flag.Value, "%s: flags should be served from the fetch", svc.GetName())
+	})
+}
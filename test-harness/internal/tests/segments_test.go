@@ -5,6 +5,7 @@ import (
 
 	"github.com/rollgate/test-harness/internal/mock"
 	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/require"
 )
 
 // TestSegmentBasicMatch tests that a user matching segment conditions gets the flag enabled.
@@ -86,3 +87,32 @@ func TestSegmentMultipleConditions(t *testing.T) {
 	tc.AssertFlagValue("pro-feature", false, true)
 	tc.CloseAllSDKs()
 }
+
+// TestSegmentScenarioFile tests segment targeting declared in a scenario
+// file instead of in code.
+func TestSegmentScenarioFile(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server to load scenario files")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	require.NoError(t, h.LoadScenarioFile("../../scenarios/pro-plan.yaml"))
+	defer h.SetScenario("basic")
+
+	tc.InitAllSDKs(&protocol.UserContext{
+		ID:         "pro-user-1",
+		Attributes: map[string]interface{}{"plan": "pro"},
+	})
+	tc.AssertFlagValue("pro-feature", true, false)
+	tc.AssertFlagValue("enabled-flag", true, false)
+	tc.CloseAllSDKs()
+
+	tc.InitAllSDKs(&protocol.UserContext{
+		ID:         "free-user-1",
+		Attributes: map[string]interface{}{"plan": "free"},
+	})
+	tc.AssertFlagValue("pro-feature", false, true)
+	tc.CloseAllSDKs()
+}
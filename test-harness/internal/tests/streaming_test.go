@@ -1,9 +1,15 @@
 package tests
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/rollgate/test-harness/internal/mock"
 	"github.com/rollgate/test-harness/internal/protocol"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -102,6 +108,7 @@ func TestSSEFlagUpdate(t *testing.T) {
 	cmd := protocol.NewInitCommand(config, nil)
 
 	for _, svc := range h.GetServices() {
+		h.ResetSSEConnections()
 		resp, err := svc.SendCommand(tc.Ctx, cmd)
 		require.NoError(t, err)
 
@@ -125,15 +132,24 @@ func TestSSEFlagUpdate(t *testing.T) {
 		// Give SDK time to process the event
 		time.Sleep(500 * time.Millisecond)
 
+		// The mock's delivery log tells a lost event from an ignored one
+		delivered := 0
+		for _, conn := range h.GetSSEConnections() {
+			if conn.Open() {
+				delivered += conn.Delivered("flag-changed") + conn.Delivered("flag-update-v2")
+			}
+		}
+		assert.Equal(t, 1, delivered, "%s: the mock should deliver the flag change once", svc.GetName())
+
 		// Check if flag was updated
 		// Note: Some SDKs might not update in-memory cache from SSE events
 		// This tests the expected behavior
 		flagResp2, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", true))
 		require.NoError(t, err)
 		if flagResp2.Value != nil {
-			t.Logf("%s: flag value after SSE update = %v (expected: false)", svc.GetName(), *flagResp2.Value)
+			t.Logf("%s: flag value after delivered SSE update = %v (expected: false)", svc.GetName(), *flagResp2.Value)
 		} else {
-			t.Logf("%s: flag value is nil after SSE update", svc.GetName())
+			t.Logf("%s: flag value is nil after delivered SSE update", svc.GetName())
 		}
 
 		svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
@@ -174,6 +190,53 @@ func TestSSEFallbackToPolling(t *testing.T) {
 	}
 }
 
+// TestSSEPollingFallback tests that an SDK whose stream keeps failing polls
+// at its refresh interval, and streams again once the stream recovers.
+func TestSSEPollingFallback(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	config := h.InitSDKConfigWithStreaming()
+	config.RefreshInterval = 200
+
+	for _, svc := range h.GetServices() {
+		h.SetScenario("basic")
+		h.RejectSSEConnections(http.StatusServiceUnavailable, 1000)
+
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		if resp.IsError() {
+			t.Logf("%s: streaming not supported: %s", svc.GetName(), resp.Error)
+			svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+			h.ResetSSEConnections()
+			continue
+		}
+
+		// Changed without a broadcast: only polling can pick it up
+		h.SetFlag(&mock.FlagState{Key: "enabled-flag", Enabled: false})
+		assert.Eventually(t, func() bool {
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", true))
+			return err == nil && resp.Value != nil && !*resp.Value
+		}, 10*time.Second, 100*time.Millisecond, "%s: no polling while the stream is failing", svc.GetName())
+
+		h.ResetSSEConnections()
+		assert.Eventually(t, func() bool { return h.GetSSEClientCount() > 0 }, 20*time.Second, 100*time.Millisecond,
+			"%s: stream never reconnected", svc.GetName())
+		h.SetFlag(&mock.FlagState{Key: "enabled-flag", Enabled: true, RolloutPercentage: 100})
+		h.BroadcastFlagChange("enabled-flag", true)
+		assert.Eventually(t, func() bool {
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+			return err == nil && resp.Value != nil && *resp.Value
+		}, 2*time.Second, 50*time.Millisecond, "%s: no streamed update after recovery", svc.GetName())
+
+		svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+	}
+}
+
 // TestSSEDisconnectRecovery tests that SDK handles SSE disconnection gracefully.
 func TestSSEDisconnectRecovery(t *testing.T) {
 	h := getHarness(t)
@@ -295,3 +358,237 @@ func TestMultipleSSEClients(t *testing.T) {
 	finalCount := h.GetSSEClientCount()
 	t.Logf("Final SSE clients: %d", finalCount)
 }
+
+// TestSSETargetingEventsMock checks the mock's segment-updated and
+// rules-changed events carry the new targeting, so SDKs evaluating locally
+// can re-evaluate without refetching.
+func TestSSETargetingEventsMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	defer h.ClearSegments()
+
+	h.GetMockServer().GetFlagStore().Clear()
+	h.SetSegment("beta-testers", []mock.Condition{{Attribute: "plan", Operator: "eq", Value: "pro"}})
+	h.SetFlag(&mock.FlagState{Key: "segment-flag", Enabled: true, Rules: []mock.Rule{{
+		ID: "beta", Enabled: true, RolloutPercentage: 100,
+		Conditions: []mock.Condition{{Attribute: "segment", Operator: "in", Value: "beta-testers"}},
+	}}})
+	h.SetFlag(&mock.FlagState{Key: "other-flag", Enabled: true, RolloutPercentage: 100})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	url := h.GetMockURL() + "/api/v1/sdk/stream?user_id=user-1&withReasons=true&token=" + h.GetAPIKey()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	stream := bufio.NewReader(resp.Body)
+
+	event, _ := readSSEEvent(t, stream)
+	require.Equal(t, "init", event)
+	require.Eventually(t, func() bool { return h.GetSSEClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	// A segment change lists the flags that reference it
+	segment := []mock.Condition{{Attribute: "plan", Operator: "in", Value: []string{"pro", "enterprise"}}}
+	h.SetSegment("beta-testers", segment)
+	assert.Equal(t, 1, h.BroadcastSegmentUpdate("beta-testers"))
+
+	event, data := readSSEEvent(t, stream)
+	require.Equal(t, "segment-updated", event)
+	var updated mock.SegmentUpdatedEvent
+	require.NoError(t, json.Unmarshal([]byte(data), &updated))
+	assert.Equal(t, "beta-testers", updated.ID)
+	require.Len(t, updated.Conditions, 1)
+	assert.Equal(t, "in", updated.Conditions[0].Operator)
+	assert.Equal(t, []string{"segment-flag"}, updated.Flags)
+
+	// A rule change carries the full flag and the value for the stream's user
+	h.SetFlag(&mock.FlagState{Key: "other-flag", Enabled: true, Rules: []mock.Rule{{
+		ID: "user-1-only", Enabled: true, RolloutPercentage: 100,
+		Conditions: []mock.Condition{{Attribute: "id", Operator: "eq", Value: "user-1"}},
+	}}})
+	assert.Equal(t, 1, h.BroadcastRulesChange("other-flag"))
+
+	event, data = readSSEEvent(t, stream)
+	require.Equal(t, "rules-changed", event)
+	var changed mock.RulesChangedEvent
+	require.NoError(t, json.Unmarshal([]byte(data), &changed))
+	assert.Equal(t, "other-flag", changed.Key)
+	require.NotNil(t, changed.Flag)
+	require.Len(t, changed.Flag.Rules, 1)
+	assert.Equal(t, "user-1-only", changed.Flag.Rules[0].ID)
+	assert.True(t, changed.Enabled)
+	require.NotNil(t, changed.Reason)
+	assert.Equal(t, "RULE_MATCH", changed.Reason.Kind)
+
+	// Deleted flags are sent with a null flag
+	h.GetMockServer().GetFlagStore().Delete("other-flag")
+	h.BroadcastRulesChange("other-flag")
+	event, data = readSSEEvent(t, stream)
+	require.Equal(t, "rules-changed", event)
+	changed = mock.RulesChangedEvent{}
+	require.NoError(t, json.Unmarshal([]byte(data), &changed))
+	assert.Nil(t, changed.Flag)
+	assert.False(t, changed.Enabled)
+
+	// The control endpoint honors the event name
+	body, _ := json.Marshal(mock.SSESendEventRequest{Event: "custom-event", Data: map[string]interface{}{"ok": true}})
+	sendResp, err := http.Post(h.GetMockURL()+"/api/v1/test/sse/send-event", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	sendResp.Body.Close()
+	event, data = readSSEEvent(t, stream)
+	assert.Equal(t, "custom-event", event)
+	assert.JSONEq(t, `{"ok":true}`, data)
+}
+
+// TestSSETargetingEventsIgnored checks that SDKs which do not evaluate
+// locally keep serving the right values after segment-updated and
+// rules-changed events they do not handle.
+func TestSSETargetingEventsIgnored(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ClearSegments()
+
+	h.SetScenario("segments")
+	h.SetSegment("pro-users", []mock.Condition{{Attribute: "plan", Operator: "eq", Value: "pro"}})
+
+	config := h.InitSDKConfigWithStreaming()
+	cmd := protocol.NewInitCommand(config, nil)
+
+	for _, svc := range h.GetServices() {
+		resp, err := svc.SendCommand(tc.Ctx, cmd)
+		require.NoError(t, err)
+		if resp.IsError() {
+			t.Logf("%s: streaming not supported: %s", svc.GetName(), resp.Error)
+			svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+			continue
+		}
+		// Wait for SSE connection to establish
+		time.Sleep(300 * time.Millisecond)
+
+		h.BroadcastSegmentUpdate("pro-users")
+		h.BroadcastRulesChange("pro-feature")
+		time.Sleep(200 * time.Millisecond)
+
+		flagResp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("pro-feature", true))
+		require.NoError(t, err)
+		require.NotNil(t, flagResp.Value, "%s: no value after targeting events", svc.GetName())
+		assert.False(t, *flagResp.Value, "%s: anonymous user is not in the segment", svc.GetName())
+
+		svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+	}
+}
+
+// TestSSETypedValuesMock tests that streams opened with ?format=v2 get
+// typed flags-v2 and flag-update-v2 events instead of init and
+// flag-changed.
+func TestSSETypedValuesMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		h.GetMockURL()+"/api/v1/sdk/stream?format=v2&withReasons=true&user_id=user-1&token="+h.GetAPIKey(), nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	stream := bufio.NewReader(resp.Body)
+
+	event, data := readSSEEvent(t, stream)
+	require.Equal(t, "flags-v2", event)
+	var init mock.FlagsV2Response
+	require.NoError(t, json.Unmarshal([]byte(data), &init))
+	assert.Equal(t, "string", init.Flags["banner-text"].Type)
+	assert.Equal(t, "Welcome", init.Flags["banner-text"].Value)
+	assert.Equal(t, "boolean", init.Flags["enabled-flag"].Type)
+	require.NotNil(t, init.Flags["banner-text"].Reason)
+
+	require.Eventually(t, func() bool { return h.GetSSEClientCount() == 1 }, time.Second, 10*time.Millisecond)
+	h.SetFlag(&mock.FlagState{
+		Key:               "banner-text",
+		Enabled:           true,
+		RolloutPercentage: 100,
+		Variations:        map[string]any{"default": "Goodbye"},
+		DefaultVariation:  "default",
+	})
+	h.BroadcastFlagChange("banner-text", true)
+
+	event, data = readSSEEvent(t, stream)
+	require.Equal(t, "flag-update-v2", event)
+	var update mock.V2FlagValue
+	require.NoError(t, json.Unmarshal([]byte(data), &update))
+	assert.Equal(t, "banner-text", update.Key)
+	assert.Equal(t, "Goodbye", update.Value)
+	assert.True(t, update.Enabled)
+	require.NotNil(t, update.Reason)
+	assert.Equal(t, "FALLTHROUGH", update.Reason.Kind)
+}
+
+// TestSSETypedFlagUpdate tests that a typed value changed over the stream
+// is served by getString without waiting for a poll.
+func TestSSETypedFlagUpdate(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+
+	config := h.InitSDKConfigWithStreaming()
+	for _, svc := range h.GetServices() {
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		if resp.IsError() {
+			t.Logf("%s: streaming not supported: %s", svc.GetName(), resp.Error)
+			svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+			continue
+		}
+
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewGetStringCommand("banner-text", "default"))
+		require.NoError(t, err)
+		if resp.Error == "UnknownCommand" {
+			t.Logf("%s: getString not supported (V2 feature)", svc.GetName())
+			svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+			continue
+		}
+		require.NotNil(t, resp.StringValue, "%s: banner-text", svc.GetName())
+		assert.Equal(t, "Welcome", *resp.StringValue)
+
+		require.Eventually(t, func() bool { return h.GetSSEClientCount() > 0 }, 2*time.Second, 20*time.Millisecond,
+			"%s: no SSE connection", svc.GetName())
+		h.SetFlag(&mock.FlagState{
+			Key:               "banner-text",
+			Enabled:           true,
+			RolloutPercentage: 100,
+			Variations:        map[string]any{"default": "Goodbye"},
+			DefaultVariation:  "default",
+		})
+		h.BroadcastFlagChange("banner-text", true)
+
+		assert.Eventually(t, func() bool {
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewGetStringCommand("banner-text", "default"))
+			return err == nil && resp.StringValue != nil && *resp.StringValue == "Goodbye"
+		}, 2*time.Second, 50*time.Millisecond, "%s: streamed string value not applied", svc.GetName())
+
+		svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		h.SetScenario("basic")
+	}
+}
@@ -13,7 +13,7 @@ import (
 
 // waitForTelemetry polls the mock server for received telemetry payloads
 // with retries, returning the payloads once at least one is received.
-func waitForTelemetry(h *harness.Harness, timeout time.Duration) []mock.TelemetryPayload {
+func waitForTelemetry(h *harness.Harness, timeout time.Duration) []mock.ReceivedTelemetry {
 	deadline := time.Now().Add(timeout)
 	for time.Now().Before(deadline) {
 		telemetry := h.GetReceivedTelemetry()
@@ -192,6 +192,133 @@ func TestTelemetryMultipleFlags(t *testing.T) {
 	})
 }
 
+// TestTelemetryBreakdown tests that evaluation stats break down by reason
+// kind, with the breakdown adding up to the total. SDKs that send only
+// totals are skipped.
+func TestTelemetryBreakdown(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+
+	require.NoError(t, tc.InitAllSDKs(nil))
+	defer tc.CloseAllSDKs()
+
+	tc.RunForEachSDK("telemetry-breakdown", func(t *testing.T, svc harness.SDKService) {
+		h.ClearReceivedTelemetry()
+
+		evaluations := map[string]int{"enabled-flag": 2, "disabled-flag": 1}
+		for flagKey, n := range evaluations {
+			for i := 0; i < n; i++ {
+				_, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand(flagKey, false))
+				require.NoError(t, err)
+			}
+		}
+
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewFlushTelemetryCommand())
+		require.NoError(t, err)
+		assert.False(t, resp.IsError())
+
+		telemetry := waitForTelemetry(h, 3*time.Second)
+		require.GreaterOrEqual(t, len(telemetry), 1, "should have received telemetry")
+
+		reasons := map[string]map[string]int{}
+		totals := map[string]int{}
+		for _, payload := range telemetry {
+			for key, stats := range payload.Evaluations {
+				totals[key] += stats.Total
+				if stats.Reasons == nil {
+					continue
+				}
+				if reasons[key] == nil {
+					reasons[key] = map[string]int{}
+				}
+				for kind, n := range stats.Reasons {
+					reasons[key][kind] += n
+				}
+			}
+		}
+		if len(reasons) == 0 {
+			SkipUnsupported(t, "%s: telemetry breakdown not supported", svc.GetName())
+		}
+
+		for flagKey, n := range evaluations {
+			assert.Equal(t, n, totals[flagKey], "%s: total for %s", svc.GetName(), flagKey)
+			sum := 0
+			for _, c := range reasons[flagKey] {
+				sum += c
+			}
+			assert.Equal(t, totals[flagKey], sum, "%s: reasons for %s should add up to the total", svc.GetName(), flagKey)
+		}
+		assert.Equal(t, 1, reasons["disabled-flag"]["OFF"], "%s: disabled-flag should be counted as OFF", svc.GetName())
+		assert.Zero(t, reasons["enabled-flag"]["OFF"], "%s: enabled-flag should not be counted as OFF", svc.GetName())
+	})
+}
+
+// TestTelemetryDefaults tests that evaluations of an unknown flag are
+// reported as defaults, by cause, and not as evaluations. SDKs that do not
+// report defaults are skipped.
+func TestTelemetryDefaults(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+
+	require.NoError(t, tc.InitAllSDKs(nil))
+	defer tc.CloseAllSDKs()
+
+	tc.RunForEachSDK("telemetry-defaults", func(t *testing.T, svc harness.SDKService) {
+		h.ClearReceivedTelemetry()
+
+		for i := 0; i < 2; i++ {
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("no-such-flag", true))
+			require.NoError(t, err)
+			assert.True(t, resp.GetValue(false), "%s: unknown flag should return the default", svc.GetName())
+		}
+		_, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+		require.NoError(t, err)
+
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewFlushTelemetryCommand())
+		require.NoError(t, err)
+		assert.False(t, resp.IsError())
+
+		telemetry := waitForTelemetry(h, 3*time.Second)
+		require.GreaterOrEqual(t, len(telemetry), 1, "should have received telemetry")
+
+		var defaults mock.DefaultStats
+		reported := false
+		for _, payload := range telemetry {
+			if payload.Defaults != nil {
+				reported = true
+			}
+			if stats, ok := payload.Defaults["no-such-flag"]; ok {
+				defaults.Total += stats.Total
+				if defaults.Causes == nil {
+					defaults.Causes = map[string]int{}
+				}
+				for cause, n := range stats.Causes {
+					defaults.Causes[cause] += n
+				}
+			}
+			_, ok := payload.Evaluations["no-such-flag"]
+			assert.False(t, ok, "%s: unknown flag should not be counted as an evaluation", svc.GetName())
+		}
+		if !reported {
+			SkipUnsupported(t, "%s: default telemetry not supported", svc.GetName())
+		}
+		assert.Equal(t, 2, defaults.Total, "%s: defaults for no-such-flag", svc.GetName())
+		assert.Equal(t, 2, defaults.Causes["FLAG_NOT_FOUND"], "%s: no-such-flag should be counted as FLAG_NOT_FOUND", svc.GetName())
+	})
+}
+
 // TestTelemetryPeriodMs tests that period_ms is positive.
 func TestTelemetryPeriodMs(t *testing.T) {
 	h := getHarness(t)
@@ -227,3 +354,153 @@ func TestTelemetryPeriodMs(t *testing.T) {
 		assert.GreaterOrEqual(t, telemetry[0].PeriodMs, 0, "period_ms should be >= 0")
 	})
 }
+
+// telemetryPeriodTolerance is how far period_ms may drift from the time the
+// mock measured between two batches. Browser SDKs flush through a page and
+// a bridge, which adds latency on both ends.
+var telemetryPeriodTolerance = map[string]time.Duration{
+	"sdk-browser": 750 * time.Millisecond,
+	"sdk-react":   750 * time.Millisecond,
+	"sdk-vue":     750 * time.Millisecond,
+	"sdk-svelte":  750 * time.Millisecond,
+	"sdk-angular": 750 * time.Millisecond,
+}
+
+// defaultTelemetryPeriodTolerance applies to SDKs missing from
+// telemetryPeriodTolerance.
+const defaultTelemetryPeriodTolerance = 250 * time.Millisecond
+
+// telemetrySince returns the batches the mock received after seq.
+func telemetrySince(h *harness.Harness, seq int) []mock.ReceivedTelemetry {
+	var batches []mock.ReceivedTelemetry
+	for _, batch := range h.GetReceivedTelemetry() {
+		if batch.Seq > seq {
+			batches = append(batches, batch)
+		}
+	}
+	return batches
+}
+
+// lastTelemetrySeq returns the sequence number of the newest batch, or 0.
+func lastTelemetrySeq(h *harness.Harness) int {
+	telemetry := h.GetReceivedTelemetry()
+	if len(telemetry) == 0 {
+		return 0
+	}
+	return telemetry[len(telemetry)-1].Seq
+}
+
+// flagTelemetryTotal sums the evaluations of flagKey across batches.
+func flagTelemetryTotal(batches []mock.ReceivedTelemetry, flagKey string) int {
+	total := 0
+	for _, batch := range batches {
+		total += batch.Evaluations[flagKey].Total
+	}
+	return total
+}
+
+// waitForFlagTelemetry polls until the batches after seq report at least
+// want evaluations of flagKey, returning those batches.
+func waitForFlagTelemetry(h *harness.Harness, seq int, flagKey string, want int, timeout time.Duration) []mock.ReceivedTelemetry {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		batches := telemetrySince(h, seq)
+		if flagTelemetryTotal(batches, flagKey) >= want {
+			return batches
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return telemetrySince(h, seq)
+}
+
+// TestTelemetryPeriodAccuracy tests that period_ms matches the wall time
+// between two flushes, as measured by the mock.
+func TestTelemetryPeriodAccuracy(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+
+	require.NoError(t, tc.InitAllSDKs(nil))
+	defer tc.CloseAllSDKs()
+
+	tc.RunForEachSDK("telemetry-period-accuracy", func(t *testing.T, svc harness.SDKService) {
+		tolerance, ok := telemetryPeriodTolerance[svc.GetName()]
+		if !ok {
+			tolerance = defaultTelemetryPeriodTolerance
+		}
+
+		var received []mock.ReceivedTelemetry
+		for round := 0; round < 2; round++ {
+			if round > 0 {
+				time.Sleep(600 * time.Millisecond)
+			}
+			seq := lastTelemetrySeq(h)
+
+			_, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+			require.NoError(t, err)
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewFlushTelemetryCommand())
+			require.NoError(t, err)
+			require.False(t, resp.IsError(), "flushTelemetry should succeed: %s - %s", resp.Error, resp.Message)
+
+			batches := waitForFlagTelemetry(h, seq, "enabled-flag", 1, 3*time.Second)
+			require.NotEmpty(t, batches, "round %d should deliver a telemetry batch", round)
+			received = append(received, batches[len(batches)-1])
+		}
+
+		first, second := received[0], received[1]
+		assert.GreaterOrEqual(t, first.PeriodMs, 0, "period_ms should never be negative")
+		assert.GreaterOrEqual(t, second.PeriodMs, 0, "period_ms should never be negative")
+
+		elapsed := second.ReceivedAt.Sub(first.ReceivedAt)
+		period := time.Duration(second.PeriodMs) * time.Millisecond
+		assert.InDelta(t, elapsed.Milliseconds(), period.Milliseconds(), float64(tolerance.Milliseconds()),
+			"period_ms of the second batch (%v) should match the time between batches (%v)", period, elapsed)
+	})
+}
+
+// TestTelemetryRapidFlushes tests that rapid consecutive flushes deliver
+// every evaluation exactly once. Batches are told apart from earlier ones by
+// the mock's sequence numbers rather than by clearing the received list.
+func TestTelemetryRapidFlushes(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+
+	require.NoError(t, tc.InitAllSDKs(nil))
+	defer tc.CloseAllSDKs()
+
+	tc.RunForEachSDK("telemetry-rapid-flushes", func(t *testing.T, svc harness.SDKService) {
+		const rounds = 10
+		seq := lastTelemetrySeq(h)
+
+		for i := 0; i < rounds; i++ {
+			_, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+			require.NoError(t, err)
+			resp, err := svc.SendCommand(tc.Ctx, protocol.NewFlushTelemetryCommand())
+			require.NoError(t, err)
+			require.False(t, resp.IsError(), "flushTelemetry should succeed: %s - %s", resp.Error, resp.Message)
+		}
+		// A flush that found another one in flight leaves its evaluations
+		// buffered; one more flush sends whatever is left.
+		_, err := svc.SendCommand(tc.Ctx, protocol.NewFlushTelemetryCommand())
+		require.NoError(t, err)
+
+		waitForFlagTelemetry(h, seq, "enabled-flag", rounds, 3*time.Second)
+		// Give duplicates a moment to show up.
+		time.Sleep(200 * time.Millisecond)
+		batches := telemetrySince(h, seq)
+
+		assert.Equal(t, rounds, flagTelemetryTotal(batches, "enabled-flag"),
+			"every evaluation should be reported exactly once across %d batches", len(batches))
+	})
+}
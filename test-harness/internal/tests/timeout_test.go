@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rollgate/test-harness/internal/harness"
+	"github.com/rollgate/test-harness/internal/mock"
+	"github.com/rollgate/test-harness/internal/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// sdkTimeout is the request timeout given to SDKs in the timeout suite
+	sdkTimeout = 500 * time.Millisecond
+	// timeoutTolerance is how far past its Timeout an SDK may still be waiting
+	timeoutTolerance = 250 * time.Millisecond
+)
+
+// TestLatencyMock verifies the mock delays SDK responses by the configured
+// amount and records clients that give up first.
+func TestLatencyMock(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for latency injection")
+	}
+	defer h.ResetLatency()
+
+	h.SetScenario("basic")
+	h.SetLatency("/api/v1/sdk/flags", 200*time.Millisecond)
+
+	get := func(client *http.Client) (time.Duration, error) {
+		req, err := http.NewRequest(http.MethodGet, h.GetMockURL()+"/api/v1/sdk/flags", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+h.GetAPIKey())
+
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			return time.Since(start), err
+		}
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		return time.Since(start), nil
+	}
+
+	elapsed, err := get(http.DefaultClient)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, elapsed, 200*time.Millisecond)
+
+	_, err = get(&http.Client{Timeout: 50 * time.Millisecond})
+	assert.Error(t, err, "client timeout shorter than the delay should fire")
+
+	// The abort is recorded once the handler notices the disconnect
+	require.Eventually(t, func() bool {
+		return len(h.GetLatencyStats().Aborts) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	stats := h.GetLatencyStats()
+	assert.Equal(t, 1, stats.Delayed)
+	assert.Equal(t, 200, stats.Aborts[0].DelayMs)
+	assert.Less(t, stats.Aborts[0].AfterMs, int64(200))
+
+	// Only SDK endpoints are delayed
+	h.SetLatency("*", time.Second)
+	start := time.Now()
+	resp, err := http.Get(h.GetMockURL() + "/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+// TestRequestTimeout checks that each SDK abandons a flags request close to
+// its configured Timeout. The mock measures when the client disconnects, so
+// SDK retries after the first timeout do not affect the result.
+func TestRequestTimeout(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for latency injection")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetLatency()
+
+	h.SetScenario("basic")
+
+	tc.RunForEachSDK("request timeout", func(t *testing.T, svc harness.SDKService) {
+		h.ResetLatency()
+		h.SetLatency("/api/v1/sdk/flags", 4*sdkTimeout)
+
+		config := h.InitSDKConfig()
+		config.Timeout = int(sdkTimeout / time.Millisecond)
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+
+		require.Eventually(t, func() bool {
+			return len(h.GetLatencyStats().Aborts) > 0
+		}, 4*sdkTimeout, 10*time.Millisecond, "%s never gave up on a delayed request", svc.GetName())
+
+		abort := h.GetLatencyStats().Aborts[0]
+		waited := time.Duration(abort.AfterMs) * time.Millisecond
+		t.Logf("%s: timeout=%v waited=%v initError=%q", svc.GetName(), sdkTimeout, waited, resp.Error)
+
+		assert.GreaterOrEqual(t, waited, sdkTimeout-timeoutTolerance,
+			"%s gave up well before its Timeout", svc.GetName())
+		assert.LessOrEqual(t, waited, sdkTimeout+timeoutTolerance,
+			"%s kept waiting past its Timeout", svc.GetName())
+	})
+}
+
+// TestRequestWithinTimeout checks that a response arriving before the
+// configured Timeout is accepted.
+func TestRequestWithinTimeout(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for latency injection")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetLatency()
+
+	h.SetScenario("basic")
+
+	tc.RunForEachSDK("request within timeout", func(t *testing.T, svc harness.SDKService) {
+		h.ResetLatency()
+		h.SetLatency("/api/v1/sdk/flags", sdkTimeout/2)
+
+		config := h.InitSDKConfig()
+		config.Timeout = int(sdkTimeout / time.Millisecond)
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		require.False(t, resp.IsError(), "%s init failed: %s", svc.GetName(), resp.Error)
+
+		flag, err := svc.SendCommand(tc.Ctx, protocol.NewIsEnabledCommand("enabled-flag", false))
+		require.NoError(t, err)
+		require.NotNil(t, flag.Value)
+		assert.True(t, *flag.Value)
+
+		stats := h.GetLatencyStats()
+		assert.Empty(t, stats.Aborts, "%s abandoned a request that arrived within its Timeout", svc.GetName())
+		assert.Greater(t, stats.Delayed, 0)
+	})
+}
+
+// TestTailLatency checks that an SDK recovers from a flags request in the
+// latency tail: it gives up at its Timeout, and the retry, drawn from the
+// fast part of the distribution, succeeds.
+func TestTailLatency(t *testing.T) {
+	h := getHarness(t)
+	if h.IsUsingExternalServer() {
+		t.Skip("requires mock server for latency injection")
+	}
+	tc := Setup(t, h)
+	defer tc.Teardown()
+	defer h.ResetLatency()
+
+	h.SetScenario("basic")
+
+	tc.RunForEachSDK("tail latency", func(t *testing.T, svc harness.SDKService) {
+		h.ResetLatency()
+		// Every other request is in the tail, starting with the second
+		h.SetLatencyDistribution("/api/v1/sdk/flags", mock.LatencyDistribution{
+			MinMs: 10, JitterMs: 40, MaxMs: int(4 * sdkTimeout / time.Millisecond), Percentile: 50,
+		}, 1)
+		req, err := http.NewRequest(http.MethodGet, h.GetMockURL()+"/api/v1/sdk/flags", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+h.GetAPIKey())
+		warmup, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		warmup.Body.Close()
+
+		config := h.InitSDKConfig()
+		config.Timeout = int(sdkTimeout / time.Millisecond)
+		start := time.Now()
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewInitCommand(config, nil))
+		require.NoError(t, err)
+		defer svc.SendCommand(tc.Ctx, protocol.NewCloseCommand())
+		elapsed := time.Since(start)
+		require.False(t, resp.IsError(), "%s did not retry past the tail: %s", svc.GetName(), resp.Error)
+
+		stats := h.GetLatencyStats()
+		require.Len(t, stats.Aborts, 1, "%s should abandon the tail request", svc.GetName())
+		assert.Equal(t, 1, stats.Tail)
+		waited := time.Duration(stats.Aborts[0].AfterMs) * time.Millisecond
+		assert.InDelta(t, sdkTimeout.Seconds(), waited.Seconds(), timeoutTolerance.Seconds(),
+			"%s should give up on the tail at its Timeout", svc.GetName())
+		assert.Less(t, elapsed, 4*sdkTimeout, "%s waited out the tail", svc.GetName())
+	})
+}
@@ -26,7 +26,7 @@ func TestGetStringFlag(t *testing.T) {
 		require.NoError(t, err)
 
 		if resp.Error == "UnknownCommand" {
-			t.Skipf("%s: getString not supported (V2 feature)", svc.GetName())
+			SkipUnsupported(t, "%s: getString not supported (V2 feature)", svc.GetName())
 		}
 
 		if resp.IsError() {
@@ -57,7 +57,7 @@ func TestGetStringFlagDefault(t *testing.T) {
 		require.NoError(t, err)
 
 		if resp.Error == "UnknownCommand" {
-			t.Skipf("%s: getString not supported (V2 feature)", svc.GetName())
+			SkipUnsupported(t, "%s: getString not supported (V2 feature)", svc.GetName())
 		}
 
 		if resp.StringValue != nil {
@@ -86,7 +86,7 @@ func TestGetNumberFlag(t *testing.T) {
 		require.NoError(t, err)
 
 		if resp.Error == "UnknownCommand" {
-			t.Skipf("%s: getNumber not supported (V2 feature)", svc.GetName())
+			SkipUnsupported(t, "%s: getNumber not supported (V2 feature)", svc.GetName())
 		}
 
 		if resp.IsError() {
@@ -117,7 +117,7 @@ func TestGetNumberFlagDefault(t *testing.T) {
 		require.NoError(t, err)
 
 		if resp.Error == "UnknownCommand" {
-			t.Skipf("%s: getNumber not supported (V2 feature)", svc.GetName())
+			SkipUnsupported(t, "%s: getNumber not supported (V2 feature)", svc.GetName())
 		}
 
 		if resp.NumberValue != nil {
@@ -147,7 +147,7 @@ func TestGetJSONFlag(t *testing.T) {
 		require.NoError(t, err)
 
 		if resp.Error == "UnknownCommand" {
-			t.Skipf("%s: getJson not supported (V2 feature)", svc.GetName())
+			SkipUnsupported(t, "%s: getJson not supported (V2 feature)", svc.GetName())
 		}
 
 		if resp.IsError() {
@@ -182,7 +182,7 @@ func TestGetJSONFlagDefault(t *testing.T) {
 		require.NoError(t, err)
 
 		if resp.Error == "UnknownCommand" {
-			t.Skipf("%s: getJson not supported (V2 feature)", svc.GetName())
+			SkipUnsupported(t, "%s: getJson not supported (V2 feature)", svc.GetName())
 		}
 
 		if resp.JSONValue != nil {
@@ -213,7 +213,7 @@ func TestTypeMismatch(t *testing.T) {
 		require.NoError(t, err)
 
 		if resp.Error == "UnknownCommand" {
-			t.Skipf("%s: getString not supported (V2 feature)", svc.GetName())
+			SkipUnsupported(t, "%s: getString not supported (V2 feature)", svc.GetName())
 		}
 
 		// SDK should return default or handle type mismatch gracefully
@@ -232,6 +232,49 @@ func TestTypeMismatch(t *testing.T) {
 	tc.CloseAllSDKs()
 }
 
+// TestTypedFlagDetail tests getValueDetail for typed flags: served values
+// come with their reason, and a type mismatch returns the default with
+// ERROR/WRONG_TYPE.
+func TestTypedFlagDetail(t *testing.T) {
+	h := getHarness(t)
+	tc := Setup(t, h)
+	defer tc.Teardown()
+
+	h.SetScenario("basic")
+	require.NoError(t, tc.InitAllSDKs(nil))
+
+	for _, svc := range h.GetServices() {
+		resp, err := svc.SendCommand(tc.Ctx, protocol.NewGetValueDetailCommand("banner-text", "default"))
+		require.NoError(t, err)
+		if resp.Error == "UnknownCommand" {
+			SkipUnsupported(t, "%s: getValueDetail not supported (V2 feature)", svc.GetName())
+		}
+		if resp.StringValue == nil {
+			t.Logf("%s: getValueDetail has no typed detail support", svc.GetName())
+			continue
+		}
+		assert.Equal(t, "Welcome", *resp.StringValue, "%s: banner-text", svc.GetName())
+		require.NotNil(t, resp.Reason, "%s: no reason", svc.GetName())
+		assert.Equal(t, "FALLTHROUGH", resp.Reason.Kind, "%s: banner-text", svc.GetName())
+
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewGetValueDetailCommand("max-items", 1.0))
+		require.NoError(t, err)
+		require.NotNil(t, resp.NumberValue, "%s: max-items", svc.GetName())
+		assert.Equal(t, 10.0, *resp.NumberValue, "%s: max-items", svc.GetName())
+
+		// A number flag read as a string
+		resp, err = svc.SendCommand(tc.Ctx, protocol.NewGetValueDetailCommand("max-items", "default"))
+		require.NoError(t, err)
+		require.NotNil(t, resp.StringValue, "%s: max-items as string", svc.GetName())
+		assert.Equal(t, "default", *resp.StringValue, "%s: type mismatch should return the default", svc.GetName())
+		require.NotNil(t, resp.Reason, "%s: no reason", svc.GetName())
+		assert.Equal(t, "ERROR", resp.Reason.Kind, "%s: max-items as string", svc.GetName())
+		assert.Equal(t, "WRONG_TYPE", resp.Reason.ErrorKind, "%s: max-items as string", svc.GetName())
+	}
+
+	tc.CloseAllSDKs()
+}
+
 // TestAllTypedFlagsNotSupported verifies that SDKs gracefully handle missing typed flag support.
 func TestAllTypedFlagsNotSupported(t *testing.T) {
 	h := getHarness(t)